@@ -0,0 +1,223 @@
+package matlab
+
+import (
+	"fmt"
+
+	"github.com/scigolib/matlab/internal/v73"
+	"github.com/scigolib/matlab/types"
+)
+
+// AttributesVariable is the name of the struct variable in which
+// WithAttributesVariable stores the attributes of the variables of a v5
+// file.
+const AttributesVariable = "__attributes__"
+
+// WithAttributesVariable stores the Attributes of top-level variables in
+// v5 files, which have no attributes of their own. They are written at
+// Close as the struct AttributesVariable, with one field per variable
+// holding a struct of its attributes, so MATLAB users see them as
+// __attributes__.x.units. Open applies them to the variables again and
+// leaves the struct out of MatFile.Variables.
+//
+// v7.3 files always store attributes as HDF5 attributes, and the option
+// has no effect on them. Both formats persist string, integer and
+// floating-point values and slices of them, read back as string, int64
+// and float64 (or slices of those); one-element slices read back as
+// scalars. Attributes named MATLAB_* or HDF5_*, which the library writes
+// or derives, and values of other types are not stored. In v5 files,
+// empty strings and slices are not stored either, and attribute names
+// must be valid MATLAB identifiers (see types.ValidateName).
+//
+// Example:
+//
+//	x.Attributes = map[string]interface{}{"units": "V", "gain": 2.5}
+//	writer, _ := matlab.Create("run.mat", matlab.Version5, matlab.WithAttributesVariable())
+//	writer.WriteVariable(x)
+//	writer.Close()
+//	// After Open: matFile.GetVariable("x").Attributes["units"] == "V"
+func WithAttributesVariable() Option {
+	return func(c *config) {
+		c.attributesVariable = true
+	}
+}
+
+// recordAttributes keeps the attributes of v for AttributesVariable, if
+// WithAttributesVariable is set for a v5 file.
+func (w *MatFileWriter) recordAttributes(v *types.Variable) {
+	if !w.attributesAll || w.version != Version5 {
+		return
+	}
+	names, values := v73.UserAttributes(v.Attributes)
+	var fields []string
+	var elements []*types.Variable
+	for i, name := range names {
+		if value := attributeVar(name, values[i]); value != nil {
+			fields = append(fields, name)
+			elements = append(elements, value)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	w.attributes = append(w.attributes, &types.Variable{
+		Name:       v.Name,
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     fields,
+			Elements:   [][]*types.Variable{elements},
+			Dimensions: []int{1, 1},
+		},
+	})
+}
+
+// attributeVar returns an attribute value, as converted by
+// v73.UserAttributes, as a char row or a numeric row vector, or nil for
+// empty values, which cannot be written.
+func attributeVar(name string, value interface{}) *types.Variable {
+	v := &types.Variable{Name: name, Data: value}
+	var n int
+	switch value := value.(type) {
+	case string:
+		v.DataType, n = types.Char, len([]rune(value))
+	case int64:
+		v.DataType, n, v.Data = types.Int64, 1, []int64{value}
+	case float64:
+		v.DataType, n, v.Data = types.Double, 1, []float64{value}
+	case []int64:
+		v.DataType, n = types.Int64, len(value)
+	case []float64:
+		v.DataType, n = types.Double, len(value)
+	}
+	if n == 0 {
+		return nil
+	}
+	v.Dimensions = []int{1, n}
+	return v
+}
+
+// attributesVar returns the recorded attributes as the struct
+// AttributesVariable, or nil if no variable has attributes.
+func (w *MatFileWriter) attributesVar() (*types.Variable, error) {
+	if len(w.attributes) == 0 {
+		return nil, nil
+	}
+	if w.names[AttributesVariable] {
+		return nil, fmt.Errorf("%w: %q", ErrDuplicateVariable, AttributesVariable)
+	}
+	fields := make([]string, len(w.attributes))
+	for i, v := range w.attributes {
+		if err := types.ValidateName(v.Name); err != nil {
+			return nil, fmt.Errorf("variable %s: %w", v.Name, err)
+		}
+		for _, name := range v.Data.(*types.StructArray).Fields {
+			if err := types.ValidateName(name); err != nil {
+				return nil, fmt.Errorf("variable %s: attribute %w", v.Name, err)
+			}
+		}
+		fields[i] = v.Name
+	}
+	return &types.Variable{
+		Name:       AttributesVariable,
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     fields,
+			Elements:   [][]*types.Variable{w.attributes},
+			Dimensions: []int{1, 1},
+		},
+	}, nil
+}
+
+// writeAttributes writes AttributesVariable to a v5 file, if variables
+// with attributes were recorded.
+func (w *MatFileWriter) writeAttributes() error {
+	v, err := w.attributesVar()
+	if err == nil && v != nil {
+		err = w.v5writer.WriteVariable(v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write attributes: %w", err)
+	}
+	if v != nil {
+		w.recordName(v.Name)
+	}
+	return nil
+}
+
+// applyAttributesVariable sets the attributes stored in the struct
+// AttributesVariable of a v5 file on the variables they belong to, and
+// removes the struct from the variables of m. Variables renamed by
+// WithNameNormalization are found by their stored names.
+func applyAttributesVariable(m *MatFile) {
+	index := -1
+	for i, v := range m.Variables {
+		if m.storedName(v.Name) == AttributesVariable {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return
+	}
+	st, ok := m.Variables[index].Data.(*types.StructArray)
+	if !ok || len(st.Elements) != 1 {
+		return // Not written by WithAttributesVariable
+	}
+	delete(m.StoredNames, m.Variables[index].Name)
+	m.Variables = append(m.Variables[:index], m.Variables[index+1:]...)
+	for i, info := range m.storage {
+		if info.Name == AttributesVariable {
+			m.storage = append(m.storage[:i], m.storage[i+1:]...)
+			break
+		}
+	}
+
+	current := make(map[string]*types.Variable, len(m.Variables))
+	for _, v := range m.Variables {
+		current[m.storedName(v.Name)] = v
+	}
+	for i, name := range st.Fields {
+		v := current[name]
+		if v == nil || i >= len(st.Elements[0]) {
+			continue
+		}
+		attrs, ok := st.Elements[0][i].Data.(*types.StructArray)
+		if !ok || len(attrs.Elements) != 1 {
+			continue
+		}
+		for j, attr := range attrs.Fields {
+			if j >= len(attrs.Elements[0]) || attrs.Elements[0][j] == nil {
+				continue
+			}
+			if value, ok := attributeValue(attrs.Elements[0][j]); ok {
+				if v.Attributes == nil {
+					v.Attributes = make(map[string]interface{})
+				}
+				v.Attributes[attr] = value
+			}
+		}
+	}
+}
+
+// attributeValue returns the value of an attribute stored by
+// recordAttributes, as v7.3 files read back.
+func attributeValue(v *types.Variable) (interface{}, bool) {
+	switch data := v.Data.(type) {
+	case string:
+		return data, true
+	case *types.CharArray:
+		return string(data.Data), true
+	case []int64:
+		if len(data) == 1 {
+			return data[0], true
+		}
+		return data, len(data) > 0
+	case []float64:
+		if len(data) == 1 {
+			return data[0], true
+		}
+		return data, len(data) > 0
+	}
+	return nil, false
+}
@@ -0,0 +1,138 @@
+package matlab
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestAttributes_RoundTrip(t *testing.T) {
+	attrs := map[string]interface{}{
+		"units":        "V",
+		"gain":         2.5,
+		"channel":      7,
+		"ids":          []int64{1, 2},
+		"weights":      []float32{0.5, 1.5},
+		"MATLAB_class": "double",       // Written by the library
+		"when":         struct{}{},     // Not persisted
+		"empty":        []float64(nil), // Not persisted
+	}
+	want := map[string]interface{}{
+		"units":   "V",
+		"gain":    2.5,
+		"channel": int64(7),
+		"ids":     []int64{1, 2},
+		"weights": []float64{0.5, 1.5},
+	}
+
+	x := &types.Variable{Name: "x", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}, Attributes: attrs}
+	z := &types.Variable{Name: "z", Dimensions: []int{1, 1}, DataType: types.Double, IsComplex: true,
+		Data: &types.NumericArray{Real: []float64{1}, Imag: []float64{2}}, Attributes: attrs}
+	field := &types.Variable{Name: "f", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{3}}
+	s := &types.Variable{Name: "s", Dimensions: []int{1, 1}, DataType: types.Struct, Attributes: attrs,
+		Data: &types.StructArray{Fields: []string{"f"}, Elements: [][]*types.Variable{{field}}, Dimensions: []int{1, 1}}}
+	plain := &types.Variable{Name: "plain", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{4}}
+
+	for _, version := range []Version{Version5, Version73} {
+		path := filepath.Join(t.TempDir(), "attrs.mat")
+		w, err := Create(path, version, WithAttributesVariable())
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if err := w.WriteVariables(x, z, s, plain); err != nil {
+			t.Fatalf("WriteVariables() error = %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		matFile, err := openPath(path, nil)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		got := matFile.GetVariableNames()
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, []string{"plain", "s", "x", "z"}) {
+			t.Errorf("v%d: variables = %v, want [plain s x z]", version, got)
+		}
+		for _, name := range []string{"x", "z", "s"} {
+			got := matFile.GetVariable(name).Attributes
+			for attr, value := range want {
+				if !reflect.DeepEqual(got[attr], value) {
+					t.Errorf("v%d: %s.Attributes[%q] = %#v, want %#v", version, name, attr, got[attr], value)
+				}
+			}
+			for _, attr := range []string{"when", "empty"} {
+				if _, ok := got[attr]; ok {
+					t.Errorf("v%d: %s.Attributes has %q", version, name, attr)
+				}
+			}
+		}
+		if got := matFile.GetVariable("plain").Attributes["units"]; got != nil {
+			t.Errorf("v%d: plain.Attributes[units] = %v, want none", version, got)
+		}
+	}
+}
+
+func TestWithAttributesVariable(t *testing.T) {
+	x := &types.Variable{Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1},
+		Attributes: map[string]interface{}{"units": "V"}}
+
+	// Without the option v5 files have no attributes
+	path := filepath.Join(t.TempDir(), "plain.mat")
+	w, err := Create(path, Version5)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := w.WriteVariable(x); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	matFile, err := openPath(path, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(matFile.Variables) != 1 || matFile.Variables[0].Attributes["units"] != nil {
+		t.Errorf("variables = %v, want x without attributes", matFile.GetVariableNames())
+	}
+
+	// The struct is visible to metadata readers, as MATLAB shows it
+	path = filepath.Join(t.TempDir(), "attrs.mat")
+	w, err = Create(path, Version5, WithAttributesVariable())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := w.WriteVariable(x); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	meta, err := readMetadata(path, nil)
+	if err != nil {
+		t.Fatalf("OpenMetadata() error = %v", err)
+	}
+	if len(meta.Variables) != 2 || meta.Variables[1].Name != AttributesVariable {
+		t.Errorf("metadata variables = %d, want x and %s", len(meta.Variables), AttributesVariable)
+	}
+
+	// Names MATLAB cannot load as fields
+	w, err = Create(filepath.Join(t.TempDir(), "bad.mat"), Version5, WithAttributesVariable())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	bad := &types.Variable{Name: "y", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1},
+		Attributes: map[string]interface{}{"sample rate": 1000.0}}
+	if err := w.WriteVariable(bad); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	if err := w.Close(); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("Close() error = %v, want ErrInvalidName", err)
+	}
+}
@@ -0,0 +1,147 @@
+package matlab_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/internal/testgen"
+	"github.com/scigolib/matlab/types"
+)
+
+// benchmarkSpecs are the files the benchmarks write and open: many small
+// variables, and a few large ones.
+var benchmarkSpecs = []struct {
+	name   string
+	spec   testgen.Spec
+	v5Only bool
+}{
+	// The HDF5 writer fails to link a tenth dataset into a group
+	{"1000x1k", testgen.Spec{Variables: 1000, Elements: 1000}, true},
+	{"4x1M", testgen.Spec{Variables: 4, Dims: []int{1000, 1000}}, false},
+	{"4x1M_compressed", testgen.Spec{Variables: 4, Dims: []int{1000, 1000}, Compression: 6}, true},
+}
+
+func BenchmarkOpen(b *testing.B) {
+	for _, version := range []matlab.Version{matlab.Version5, matlab.Version73} {
+		for _, bs := range benchmarkSpecs {
+			if bs.v5Only && version != matlab.Version5 {
+				continue
+			}
+			b.Run(fmt.Sprintf("v%d/%s", version, bs.name), func(b *testing.B) {
+				path := filepath.Join(b.TempDir(), "bench.mat")
+				if err := testgen.Write(path, version, bs.spec); err != nil {
+					b.Fatal(err)
+				}
+				info, err := os.Stat(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.SetBytes(info.Size())
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					file, err := os.Open(path)
+					if err != nil {
+						b.Fatal(err)
+					}
+					_, err = matlab.Open(file)
+					file.Close()
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkOpen_Allocator compares the garbage collection work of bulk
+// v5 loads with heap-allocated data and with data in a reused arena. It
+// reports the number of collections and their total pause time per Open.
+func BenchmarkOpen_Allocator(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "bench.mat")
+	if err := testgen.Write(path, matlab.Version5, testgen.Spec{Variables: 100, Elements: 10000}); err != nil {
+		b.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, name := range []string{"heap", "arena"} {
+		b.Run(name, func(b *testing.B) {
+			var opts []matlab.OpenOption
+			arena := types.NewArena(1 << 20)
+			if name == "arena" {
+				opts = append(opts, matlab.WithAllocator(arena))
+			}
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := matlab.Open(bytes.NewReader(data), opts...); err != nil {
+					b.Fatal(err)
+				}
+				arena.Reset()
+			}
+			b.StopTimer()
+			runtime.ReadMemStats(&after)
+			b.ReportMetric(float64(after.NumGC-before.NumGC)/float64(b.N), "gc/op")
+			b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+		})
+	}
+}
+
+func BenchmarkWrite(b *testing.B) {
+	for _, version := range []matlab.Version{matlab.Version5, matlab.Version73} {
+		for _, bs := range benchmarkSpecs {
+			if bs.v5Only && version != matlab.Version5 {
+				continue
+			}
+			b.Run(fmt.Sprintf("v%d/%s", version, bs.name), func(b *testing.B) {
+				path := filepath.Join(b.TempDir(), "bench.mat")
+				for i := 0; i < b.N; i++ {
+					if err := testgen.Write(path, version, bs.spec); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestSoak writes and reads back large generated files in both formats.
+// It runs only when MATLAB_SOAK is set, e.g.
+//
+//	MATLAB_SOAK=1 go test -run TestSoak -timeout 30m .
+func TestSoak(t *testing.T) {
+	if os.Getenv("MATLAB_SOAK") == "" {
+		t.Skip("set MATLAB_SOAK to run")
+	}
+	spec := testgen.Spec{Variables: 8, Dims: []int{2000, 2000, 4}, Types: testgen.Classes}
+	for _, version := range []matlab.Version{matlab.Version5, matlab.Version73} {
+		path := filepath.Join(t.TempDir(), "soak.mat")
+		if err := testgen.Write(path, version, spec); err != nil {
+			t.Fatalf("v%d: Write() error = %v", version, err)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matFile, err := matlab.Open(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("v%d: Open() error = %v", version, err)
+		}
+		if len(matFile.Variables) != spec.Variables {
+			t.Errorf("v%d: %d variables, want %d", version, len(matFile.Variables), spec.Variables)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package matlab
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/scigolib/matlab/internal/v5"
+	"github.com/scigolib/matlab/types"
+)
+
+// Cache keeps the metadata and variables of recently used MAT-files in
+// memory, for servers that repeatedly answer queries over a stable set of
+// files. Entries are keyed by path and checked against the file's
+// modification time and size on every lookup, so a rewritten file is read
+// again. The least recently used file is evicted once more than the
+// capacity are cached.
+//
+// Metadata is read when a file is first used; each variable is read when
+// it is first requested. Concurrent requests for the same file or
+// variable read it once and share the result. Returned values are shared
+// by all callers and must not be modified; Clone a variable to change it.
+//
+// A Cache is safe for concurrent use.
+//
+// Example:
+//
+//	cache := matlab.NewCache(100)
+//	http.HandleFunc("/var", func(w http.ResponseWriter, r *http.Request) {
+//	    v, err := cache.Variable(r.FormValue("file"), r.FormValue("name"))
+//	    if err != nil {
+//	        http.Error(w, err.Error(), http.StatusNotFound)
+//	        return
+//	    }
+//	    v.StreamTo(w, types.FormatJSON)
+//	})
+type Cache struct {
+	capacity int
+	opts     []OpenOption
+	payloads int64 // Budget of each file's decompressed payloads
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // Path to element of lru holding *cacheEntry
+	lru     *list.List               // Most recently used first
+}
+
+// cacheEntry holds one cached file.
+type cacheEntry struct {
+	path    string
+	modTime time.Time
+	size    int64
+
+	ready chan struct{} // Closed once meta and err are set
+	meta  *Metadata
+	err   error
+
+	mu       sync.Mutex
+	vars     map[string]*cachedVariable // Loaded or loading variables
+	all      *cachedVariable            // Whole-file read of a v7.3 file
+	payloads *payloadCache              // Decompressed data, nil without WithDecompressionCache
+}
+
+// cachedVariable is a variable that is loaded once and then shared.
+type cachedVariable struct {
+	ready chan struct{} // Closed once v and err are set
+	v     *types.Variable
+	err   error
+}
+
+// NewCache creates a cache holding up to capacity files (at least one).
+// The options are used whenever a file is read; transforms are applied
+// to each loaded variable, which is still requested by its stored name.
+//
+// Example:
+//
+//	cache := matlab.NewCache(64, matlab.WithMaxObjects(10000))
+func NewCache(capacity int, opts ...OpenOption) *Cache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	cfg := defaultOpenConfig()
+	applyOpenOptions(cfg, opts)
+	return &Cache{
+		capacity: capacity,
+		opts:     opts,
+		payloads: cfg.payloadBudget,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Metadata returns the metadata of the file at path, as OpenMetadata
+// reads it.
+//
+// Example:
+//
+//	meta, err := cache.Metadata("results/run42.mat")
+//	for _, v := range meta.Variables {
+//	    fmt.Println(v.Name, v.DataType, v.Dimensions)
+//	}
+func (c *Cache) Metadata(path string) (*Metadata, error) {
+	entry, err := c.entry(path)
+	if err != nil {
+		return nil, err
+	}
+	return entry.meta, nil
+}
+
+// Variable returns the variable name of the file at path, reading it on
+// first use. In v5 files only that variable is read, starting at its
+// recorded offset. v7.3 files are read in full on the first request and
+// all their variables are kept.
+//
+// Returns ErrVariableNotFound if the file has no such variable.
+//
+// Example:
+//
+//	v, err := cache.Variable("results/run42.mat", "temperature")
+//	if err != nil {
+//	    return err
+//	}
+//	values, _ := v.GetFloat64Array()
+func (c *Cache) Variable(path, name string) (*types.Variable, error) {
+	entry, err := c.entry(path)
+	if err != nil {
+		return nil, err
+	}
+	if entry.meta.Version == "7.3" {
+		return entry.loadV73(c.opts, name)
+	}
+
+	var info *types.VariableInfo
+	for _, v := range entry.meta.Variables {
+		if v.Name == name {
+			info = v
+			break
+		}
+	}
+	if info == nil {
+		return nil, fmt.Errorf("%w: %s", ErrVariableNotFound, name)
+	}
+	return entry.load(name, func() (*types.Variable, error) {
+		return readV5Variable(entry.path, info, c.opts, entry.payloads)
+	})
+}
+
+// Invalidate removes the file at path from the cache.
+func (c *Cache) Invalidate(path string) {
+	path = filepath.Clean(path)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[path]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, path)
+	}
+}
+
+// Len returns the number of cached files.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// entry returns the up-to-date entry for path, reading its metadata if
+// the file is not cached or has changed.
+func (c *Cache) entry(path string) (*cacheEntry, error) {
+	path = filepath.Clean(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	elem, ok := c.entries[path]
+	if ok {
+		if cached := elem.Value.(*cacheEntry); cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+			c.lru.MoveToFront(elem)
+			c.mu.Unlock()
+			return cached.wait()
+		}
+		c.lru.Remove(elem)
+	}
+	entry := &cacheEntry{
+		path:     path,
+		modTime:  info.ModTime(),
+		size:     info.Size(),
+		ready:    make(chan struct{}),
+		vars:     make(map[string]*cachedVariable),
+		payloads: newPayloadCache(c.payloads),
+	}
+	c.entries[path] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).path)
+	}
+	c.mu.Unlock()
+
+	entry.meta, entry.err = readMetadata(path, c.opts)
+	close(entry.ready)
+	if entry.err != nil {
+		// Failures are not cached: the file may be complete next time
+		c.mu.Lock()
+		if elem, ok := c.entries[path]; ok && elem.Value == entry {
+			c.lru.Remove(elem)
+			delete(c.entries, path)
+		}
+		c.mu.Unlock()
+	}
+	return entry.wait()
+}
+
+// wait waits for the entry's metadata to be read.
+func (e *cacheEntry) wait() (*cacheEntry, error) {
+	<-e.ready
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e, nil
+}
+
+// load returns the variable name, calling read if it has not been loaded.
+// Concurrent callers wait for the same read. Failed reads are retried by
+// the next caller.
+func (e *cacheEntry) load(name string, read func() (*types.Variable, error)) (*types.Variable, error) {
+	e.mu.Lock()
+	cached, ok := e.vars[name]
+	if !ok {
+		cached = &cachedVariable{ready: make(chan struct{})}
+		e.vars[name] = cached
+	}
+	e.mu.Unlock()
+
+	if !ok {
+		cached.v, cached.err = read()
+		close(cached.ready)
+		if cached.err != nil {
+			e.mu.Lock()
+			delete(e.vars, name)
+			e.mu.Unlock()
+		}
+	}
+	<-cached.ready
+	return cached.v, cached.err
+}
+
+// loadV73 reads a v7.3 file in full once and returns its variable name.
+func (e *cacheEntry) loadV73(opts []OpenOption, name string) (*types.Variable, error) {
+	const whole = "\x00file" // Not a valid variable name
+	_, err := e.load(whole, func() (*types.Variable, error) {
+		matFile, err := openPath(e.path, opts)
+		if err != nil {
+			return nil, err
+		}
+		e.mu.Lock()
+		for _, v := range matFile.Variables {
+			if _, ok := e.vars[v.Name]; !ok {
+				ready := make(chan struct{})
+				close(ready)
+				e.vars[v.Name] = &cachedVariable{ready: ready, v: v}
+			}
+		}
+		e.mu.Unlock()
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	cached, ok := e.vars[name]
+	e.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrVariableNotFound, name)
+	}
+	return cached.v, nil
+}
+
+// readMetadata reads the metadata of the file at path.
+func readMetadata(path string, opts []OpenOption) (*Metadata, error) {
+	//nolint:gosec // G304: paths are provided by the caller, expected behavior
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck // Read-only file
+	return OpenMetadata(f, opts...)
+}
+
+// openPath opens and parses the file at path.
+func openPath(path string, opts []OpenOption) (*MatFile, error) {
+	//nolint:gosec // G304: paths are provided by the caller, expected behavior
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck // Read-only file
+	return Open(f, opts...)
+}
+
+// readV5Variable reads one variable of a v5 file, seeking to its stored
+// offset so the variables before it are not scanned. Files compressed
+// with gzip or zstd are decompressed in full, as their offsets are those
+// of the decompressed file. Decompressed data is kept in payloads, which
+// may be nil.
+func readV5Variable(path string, info *types.VariableInfo, opts []OpenOption, payloads *payloadCache) (*types.Variable, error) {
+	cfg := defaultOpenConfig()
+	applyOpenOptions(cfg, opts)
+
+	//nolint:gosec // G304: paths are provided by the caller, expected behavior
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck // Read-only file
+
+	header := make([]byte, headerSize)
+	n, _ := f.ReadAt(header, 0)
+	if compressedContainer(header[:n]) != "" {
+		data, err := payloads.load(0, func() ([]byte, error) {
+			src, err := newSource(f)
+			if err != nil {
+				return nil, err
+			}
+			return io.ReadAll(src.r)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return readV5At(bytes.NewReader(data), info.Offset, info.Name, cfg)
+	}
+
+	if payloads != nil && info.Compressed && info.Offset > 0 && n == headerSize {
+		// The decompressed element, after the header, reads as a file
+		// holding only this variable
+		data, err := payloads.load(info.Offset, func() ([]byte, error) {
+			stored := info.Size - 8 // Without the tag
+			if stored < 0 || stored > math.MaxUint32 {
+				return nil, fmt.Errorf("variable %s: invalid stored size %d", info.Name, info.Size)
+			}
+			return v5.Decompress(io.NewSectionReader(f, info.Offset+8, stored), uint32(stored))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("variable %s: %w", info.Name, err)
+		}
+		return readV5At(io.MultiReader(bytes.NewReader(header), bytes.NewReader(data)), 0, info.Name, cfg)
+	}
+	return readV5At(f, info.Offset, info.Name, cfg)
+}
+
+// readV5At reads the variable name of the v5 file r, seeking to offset
+// if it is positive.
+func readV5At(r io.Reader, offset int64, name string, cfg *openConfig) (*types.Variable, error) {
+	parser, err := v5.NewParser(r)
+	if err != nil {
+		return nil, err
+	}
+	parser.Transform = cfg.transform()
+	if offset > 0 {
+		seeker, ok := r.(io.Seeker)
+		if !ok {
+			return nil, fmt.Errorf("variable %s: offset %d of an unseekable reader", name, offset)
+		}
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	v, found, err := parser.ReadVariable(name)
+	if err != nil {
+		return nil, fmt.Errorf("variable %s: %w", name, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrVariableNotFound, name)
+	}
+	return v, nil
+}
@@ -0,0 +1,142 @@
+package matlab
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.mat")
+	writeIndexFixture(t, path,
+		&types.Variable{Name: "skip", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}},
+		&types.Variable{Name: "x", Dimensions: []int{1, 3}, DataType: types.Int16, Data: []int16{4, 5, 6}},
+	)
+
+	cache := NewCache(2)
+	meta, err := cache.Metadata(path)
+	if err != nil {
+		t.Fatalf("Metadata() error = %v", err)
+	}
+	if len(meta.Variables) != 2 || meta.Variables[1].Name != "x" {
+		t.Fatalf("Metadata() variables = %v", meta.Variables)
+	}
+	if again, _ := cache.Metadata(path); again != meta {
+		t.Error("Metadata() was read again")
+	}
+
+	// Concurrent requests share one read
+	var wg sync.WaitGroup
+	got := make([]*types.Variable, 8)
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i], _ = cache.Variable(path, "x")
+		}(i)
+	}
+	wg.Wait()
+	for _, v := range got {
+		if v != got[0] {
+			t.Fatal("concurrent Variable() calls returned different copies")
+		}
+	}
+	if got[0] == nil || !reflect.DeepEqual(got[0].Data, []int16{4, 5, 6}) {
+		t.Fatalf("Variable(x) = %+v", got[0])
+	}
+
+	if _, err := cache.Variable(path, "nope"); !errors.Is(err, ErrVariableNotFound) {
+		t.Errorf("Variable(nope) error = %v, want ErrVariableNotFound", err)
+	}
+	if _, err := cache.Metadata(filepath.Join(dir, "missing.mat")); err == nil {
+		t.Error("Metadata(missing) error = nil")
+	}
+
+	// A rewritten file is read again
+	writeIndexFixture(t, path,
+		&types.Variable{Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{9}},
+	)
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	v, err := cache.Variable(path, "x")
+	if err != nil || !reflect.DeepEqual(v.Data, []float64{9}) {
+		t.Fatalf("Variable(x) after rewrite = %+v, %v", v, err)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", cache.Len())
+	}
+
+	cache.Invalidate(path)
+	if cache.Len() != 0 {
+		t.Errorf("Len() after Invalidate = %d, want 0", cache.Len())
+	}
+}
+
+func TestCache_Eviction(t *testing.T) {
+	dir := t.TempDir()
+	paths := make([]string, 3)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, string(rune('a'+i))+".mat")
+		writeIndexFixture(t, paths[i],
+			&types.Variable{Name: "n", Dimensions: []int{1, 1}, DataType: types.Int32, Data: []int32{int32(i)}})
+	}
+
+	cache := NewCache(2)
+	first, _ := cache.Variable(paths[0], "n")
+	if _, err := cache.Metadata(paths[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Metadata(paths[0]); err != nil { // paths[1] is now least recently used
+		t.Fatal(err)
+	}
+	if _, err := cache.Metadata(paths[2]); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", cache.Len())
+	}
+	if again, _ := cache.Variable(paths[0], "n"); again != first {
+		t.Error("recently used file was evicted")
+	}
+}
+
+func TestCache_V73(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v73.mat")
+	writer, err := Create(path, Version73)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for _, v := range []*types.Variable{
+		{Name: "a", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}},
+		{Name: "b", Dimensions: []int{1, 1}, DataType: types.Uint8, Data: []uint8{3}},
+	} {
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewCache(1)
+	b, err := cache.Variable(path, "b")
+	if err != nil || !reflect.DeepEqual(b.Data, []uint8{3}) {
+		t.Fatalf("Variable(b) = %+v, %v", b, err)
+	}
+	a, err := cache.Variable(path, "a")
+	if err != nil || !reflect.DeepEqual(a.Data, []float64{1, 2}) {
+		t.Fatalf("Variable(a) = %+v, %v", a, err)
+	}
+	if _, err := cache.Variable(path, "c"); !errors.Is(err, ErrVariableNotFound) {
+		t.Errorf("Variable(c) error = %v, want ErrVariableNotFound", err)
+	}
+}
@@ -0,0 +1,213 @@
+package matlab
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unicode/utf16"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// ErrUnsupportedFeature indicates a variable that the writer of a format
+// cannot write, as reported by FormatCapabilities.Check.
+var ErrUnsupportedFeature = errors.New("not supported by the format")
+
+// largeVariableBytes is the size from which MATLAB only saves variables
+// in v7.3 files.
+const largeVariableBytes = 2 << 30
+
+// FormatCapabilities describes what the writer of a MAT-file format
+// version supports, as returned by Capabilities.
+type FormatCapabilities struct {
+	Version        Version
+	Format         string           // Name of the format for messages: "v5" or "v7.3"
+	Classes        []types.DataType // Classes WriteVariable accepts
+	Complex        bool             // Complex numeric arrays
+	StructArrays   bool             // Structs of other sizes than 1x1
+	Cells          bool             // Cell arrays
+	Sparse         bool             // Sparse double and logical matrices
+	Compression    bool             // Compressed variables (WithCompression)
+	LargeVariables bool             // Variables of 2 GB or more, which MATLAB only saves in v7.3 files
+	Resume         bool             // Checkpoint and Resume
+}
+
+// writerClasses are the classes both writers accept.
+var writerClasses = []types.DataType{
+	types.Double, types.Single,
+	types.Int8, types.Uint8, types.Int16, types.Uint16,
+	types.Int32, types.Uint32, types.Int64, types.Uint64,
+	types.Char, types.Logical, types.Struct,
+}
+
+// Capabilities returns what the writer of a format version supports, so
+// generic tools can check their data against the target format before
+// writing (see FormatCapabilities.Check) or pick one (see ChooseVersion).
+//
+// Returns an error wrapping ErrUnsupportedVersion for versions other than
+// Version5 and Version73.
+//
+// Example:
+//
+//	caps, _ := matlab.Capabilities(matlab.Version73)
+//	if !caps.Sparse {
+//	    v, _ = v.ToDense()
+//	}
+func Capabilities(version Version) (FormatCapabilities, error) {
+	switch version {
+	case Version5:
+		return FormatCapabilities{
+			Version:      Version5,
+			Format:       "v5",
+			Classes:      append([]types.DataType(nil), writerClasses...),
+			Complex:      true,
+			StructArrays: true,
+			Sparse:       true,
+			Compression:  true,
+			Resume:       true,
+		}, nil
+	case Version73:
+		return FormatCapabilities{
+			Version:        Version73,
+			Format:         "v7.3",
+			Classes:        append([]types.DataType(nil), writerClasses...),
+			Complex:        true,
+			LargeVariables: true,
+		}, nil
+	default:
+		return FormatCapabilities{}, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+}
+
+// Check reports whether the writer of the format can write v, including
+// the fields of structs. Problems the writer only finds while encoding,
+// such as data that does not match its class, are not checked.
+//
+// Returns an error wrapping ErrUnsupportedFeature that names the variable
+// or field and the feature it needs.
+//
+// Example:
+//
+//	caps, _ := matlab.Capabilities(matlab.Version5)
+//	if err := caps.Check(v); err != nil {
+//	    log.Printf("cannot save as v5: %v", err)
+//	}
+func (c FormatCapabilities) Check(v *types.Variable) error {
+	if v == nil {
+		return nil
+	}
+	return c.check(v, v.Name)
+}
+
+// check checks v, named path in error messages, and its struct fields.
+func (c FormatCapabilities) check(v *types.Variable, path string) error {
+	unsupported := func(feature string) error {
+		return fmt.Errorf("%w: %s: %s in %s files", ErrUnsupportedFeature, path, feature, c.Format)
+	}
+	switch {
+	case v.DataType == types.CellArray && !c.Cells:
+		return unsupported("cell arrays")
+	case !c.supports(v.DataType):
+		return unsupported(v.DataType.String() + " arrays")
+	case v.IsComplex && !c.Complex:
+		return unsupported("complex arrays")
+	case v.IsSparse && !c.Sparse:
+		return unsupported("sparse matrices")
+	case !c.LargeVariables && dataBytes(v) >= largeVariableBytes:
+		return unsupported(fmt.Sprintf("variables of %d bytes", dataBytes(v)))
+	}
+
+	st, ok := v.Data.(*types.StructArray)
+	if !ok {
+		return nil
+	}
+	if len(st.Elements) != 1 && !c.StructArrays {
+		return unsupported(fmt.Sprintf("struct arrays of %d elements", len(st.Elements)))
+	}
+	for i, element := range st.Elements {
+		for j, field := range element {
+			if field == nil || j >= len(st.Fields) {
+				continue // Reported by the writer
+			}
+			name := path + "." + st.Fields[j]
+			if len(st.Elements) > 1 {
+				name = fmt.Sprintf("%s(%d).%s", path, i+1, st.Fields[j])
+			}
+			if err := c.check(field, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// supports reports whether class is one of the classes of the format.
+func (c FormatCapabilities) supports(class types.DataType) bool {
+	for _, supported := range c.Classes {
+		if supported == class {
+			return true
+		}
+	}
+	return false
+}
+
+// dataBytes estimates the bytes of the data of v, without struct fields:
+// the size of its elements in memory, or two bytes per UTF-16 code unit
+// of a string.
+func dataBytes(v *types.Variable) int64 {
+	switch data := v.Data.(type) {
+	case string:
+		return 2 * int64(len(utf16.Encode([]rune(data))))
+	case *types.NumericArray:
+		return sliceBytes(data.Real) + sliceBytes(data.Imag)
+	case *types.SparseMatrix:
+		return sliceBytes(data.Real) + sliceBytes(data.Imag) + sliceBytes(data.RowIndices) + sliceBytes(data.ColPointers)
+	default:
+		return sliceBytes(data)
+	}
+}
+
+// sliceBytes returns the size in memory of the elements of a slice, or 0
+// for other values.
+func sliceBytes(data interface{}) int64 {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Slice {
+		return 0
+	}
+	return int64(rv.Len()) * int64(rv.Type().Elem().Size()) //nolint:gosec // G115: element sizes are small
+}
+
+// ChooseVersion returns the first of Version5 and Version73 whose writer
+// supports all the variables. v5 is preferred: its files load in every
+// MATLAB release and in Octave.
+//
+// Returns an error wrapping ErrUnsupportedFeature, with the problem found
+// for v7.3, if neither writer supports them.
+//
+// Example:
+//
+//	version, err := matlab.ChooseVersion(vars...)
+//	if err != nil {
+//	    return err
+//	}
+//	writer, err := matlab.Create("results.mat", version)
+func ChooseVersion(vars ...*types.Variable) (Version, error) {
+	var err error
+	for _, version := range []Version{Version5, Version73} {
+		caps, _ := Capabilities(version)
+		if err = caps.checkAll(vars); err == nil {
+			return version, nil
+		}
+	}
+	return 0, err
+}
+
+// checkAll checks each variable.
+func (c FormatCapabilities) checkAll(vars []*types.Variable) error {
+	for _, v := range vars {
+		if err := c.Check(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package matlab
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// capabilityVariables returns variables that need the features
+// FormatCapabilities describes, keyed by feature.
+func capabilityVariables() map[string]*types.Variable {
+	scalar := func(x float64) *types.Variable {
+		return &types.Variable{Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{x}}
+	}
+	return map[string]*types.Variable{
+		"plain": {Name: "x", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}},
+		"sparse": {Name: "s", Dimensions: []int{2, 2}, DataType: types.Double, IsSparse: true,
+			Data: &types.SparseMatrix{Rows: 2, Cols: 2, RowIndices: []int{1}, ColPointers: []int{0, 0, 1}, Real: []float64{5}, NZMax: 1}},
+		"struct array": {Name: "r", Dimensions: []int{1, 2}, DataType: types.Struct, Data: &types.StructArray{
+			Fields: []string{"a"}, Dimensions: []int{1, 2}, Elements: [][]*types.Variable{{scalar(1)}, {scalar(2)}},
+		}},
+		"sparse field": {Name: "p", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+			Fields: []string{"m"}, Dimensions: []int{1, 1}, Elements: [][]*types.Variable{{{
+				Dimensions: []int{2, 2}, DataType: types.Double, IsSparse: true,
+				Data: &types.SparseMatrix{Rows: 2, Cols: 2, RowIndices: []int{0}, ColPointers: []int{0, 1, 1}, Real: []float64{1}, NZMax: 1},
+			}}},
+		}},
+		"cell": {Name: "c", Dimensions: []int{1, 1}, DataType: types.CellArray, Data: []*types.Variable{scalar(1)}},
+	}
+}
+
+// TestCapabilities_MatchWriters tests that Check accepts exactly the
+// variables each writer writes.
+func TestCapabilities_MatchWriters(t *testing.T) {
+	for _, version := range []Version{Version5, Version73} {
+		caps, err := Capabilities(version)
+		if err != nil {
+			t.Fatalf("Capabilities(%d) error = %v", version, err)
+		}
+		for feature, v := range capabilityVariables() {
+			w, err := Create(filepath.Join(t.TempDir(), "caps.mat"), version)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			writeErr := w.WriteVariable(v)
+			w.Close()
+			checkErr := caps.Check(v)
+			if (writeErr == nil) != (checkErr == nil) {
+				t.Errorf("%s %s: Check() error = %v, WriteVariable() error = %v", caps.Format, feature, checkErr, writeErr)
+			}
+			if checkErr != nil && !errors.Is(checkErr, ErrUnsupportedFeature) {
+				t.Errorf("%s %s: Check() error = %v, want ErrUnsupportedFeature", caps.Format, feature, checkErr)
+			}
+		}
+	}
+	if _, err := Capabilities(Version(7)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Capabilities(7) error = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+func TestChooseVersion(t *testing.T) {
+	vars := capabilityVariables()
+	tests := []struct {
+		features []string
+		want     Version
+		wantErr  bool
+	}{
+		{[]string{"plain", "sparse"}, Version5, false},
+		{[]string{"plain", "struct array"}, Version5, false},
+		{[]string{"cell"}, 0, true},
+	}
+	for _, tt := range tests {
+		var selected []*types.Variable
+		for _, feature := range tt.features {
+			selected = append(selected, vars[feature])
+		}
+		got, err := ChooseVersion(selected...)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ChooseVersion(%v) = %d, %v; want %d", tt.features, got, err, tt.want)
+		}
+	}
+
+	if n := dataBytes(&types.Variable{DataType: types.Char, Data: "a𝄞"}); n != 6 {
+		t.Errorf("dataBytes(a𝄞) = %d, want 6", n)
+	}
+	if n := dataBytes(vars["sparse"]); n != 8+8+3*8 {
+		t.Errorf("dataBytes(sparse) = %d, want 40", n)
+	}
+}
@@ -0,0 +1,198 @@
+package matlab
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// ErrNotChannelData indicates variables that do not follow the layout of
+// ChannelData: an N x C real numeric matrix, C channel names and a
+// positive sample rate.
+var ErrNotChannelData = errors.New("not multi-channel data")
+
+// ChannelData is gap-free multi-channel data as DAQ exports store it:
+// an N x C matrix with one column of N samples per channel, the channel
+// names and the sample rate, as three variables of the file.
+type ChannelData struct {
+	Names      []string    // Channel names, one per column
+	Samples    [][]float64 // Samples of each channel, all of the same length
+	SampleRate float64     // Samples per second
+}
+
+// ChannelLayout names the variables holding ChannelData. Empty names are
+// those of DefaultChannelLayout.
+type ChannelLayout struct {
+	Data       string // N x C data matrix
+	Names      string // Channel names: a cellstr, or a char matrix with one row per channel
+	SampleRate string // Sample rate scalar
+}
+
+// DefaultChannelLayout is the most common naming of the variables.
+var DefaultChannelLayout = ChannelLayout{Data: "data", Names: "channels", SampleRate: "fs"}
+
+// withDefaults returns the layout with empty names replaced by those of
+// DefaultChannelLayout.
+func (l ChannelLayout) withDefaults() ChannelLayout {
+	if l.Data == "" {
+		l.Data = DefaultChannelLayout.Data
+	}
+	if l.Names == "" {
+		l.Names = DefaultChannelLayout.Names
+	}
+	if l.SampleRate == "" {
+		l.SampleRate = DefaultChannelLayout.SampleRate
+	}
+	return l
+}
+
+// Len returns the number of samples per channel.
+func (c *ChannelData) Len() int {
+	if len(c.Samples) == 0 {
+		return 0
+	}
+	return len(c.Samples[0])
+}
+
+// Channel returns the samples of the channel with the given name.
+func (c *ChannelData) Channel(name string) ([]float64, bool) {
+	for i, n := range c.Names {
+		if n == name && i < len(c.Samples) {
+			return c.Samples[i], true
+		}
+	}
+	return nil, false
+}
+
+// WriteChannelData writes multi-channel data as the three variables of
+// layout, in one WriteVariables batch: the samples as an N x C double
+// matrix, the names as a char matrix with one row per channel, padded
+// with spaces (cellstr(channels) in MATLAB gives the names), and the
+// sample rate as a double scalar.
+//
+// Returns an error wrapping ErrNotChannelData if there are no channels,
+// the number of names and channels differ, the channels have different
+// lengths or the sample rate is not positive.
+//
+// Example:
+//
+//	err := writer.WriteChannelData(&matlab.ChannelData{
+//	    Names:      []string{"accel_x", "accel_y"},
+//	    Samples:    [][]float64{ax, ay},
+//	    SampleRate: 2048,
+//	}, matlab.ChannelLayout{})
+func (w *MatFileWriter) WriteChannelData(cd *ChannelData, layout ChannelLayout) error {
+	layout = layout.withDefaults()
+	if err := cd.check(); err != nil {
+		return err
+	}
+	n := cd.Len()
+	data := make([]float64, 0, n*len(cd.Samples))
+	for _, samples := range cd.Samples {
+		data = append(data, samples...) // Column-major: one column per channel
+	}
+	return w.WriteVariables(
+		&types.Variable{Name: layout.Data, Dimensions: []int{n, len(cd.Samples)}, DataType: types.Double, Data: data},
+		charMatrix(layout.Names, cd.Names),
+		&types.Variable{Name: layout.SampleRate, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{cd.SampleRate}},
+	)
+}
+
+// check validates the channel data for writing.
+func (c *ChannelData) check() error {
+	switch {
+	case len(c.Samples) == 0:
+		return fmt.Errorf("%w: no channels", ErrNotChannelData)
+	case len(c.Names) != len(c.Samples):
+		return fmt.Errorf("%w: %d names for %d channels", ErrNotChannelData, len(c.Names), len(c.Samples))
+	case !(c.SampleRate > 0):
+		return fmt.Errorf("%w: sample rate %g", ErrNotChannelData, c.SampleRate)
+	}
+	for i, samples := range c.Samples {
+		if len(samples) != c.Len() {
+			return fmt.Errorf("%w: channel %s has %d samples, channel %s %d",
+				ErrNotChannelData, c.Names[i], len(samples), c.Names[0], c.Len())
+		}
+	}
+	return nil
+}
+
+// GetChannelData returns the multi-channel data held by the variables of
+// layout. The data matrix may have any real numeric class, and the names
+// may be a cellstr or a char matrix; trailing spaces are removed from
+// the rows of a char matrix. The channels of a double matrix share its
+// data.
+//
+// Returns an error wrapping ErrVariableNotFound if a variable of the
+// layout is missing, and one wrapping ErrNotChannelData if the variables
+// do not hold channel data.
+//
+// Example:
+//
+//	cd, err := matFile.GetChannelData(matlab.ChannelLayout{})
+//	ax, _ := cd.Channel("accel_x")
+//	fmt.Println(len(ax), "samples at", cd.SampleRate, "Hz")
+func (m *MatFile) GetChannelData(layout ChannelLayout) (*ChannelData, error) {
+	layout = layout.withDefaults()
+	vars := make([]*types.Variable, 3)
+	for i, name := range []string{layout.Data, layout.Names, layout.SampleRate} {
+		if vars[i] = m.GetVariable(name); vars[i] == nil {
+			return nil, fmt.Errorf("%w: %q", ErrVariableNotFound, name)
+		}
+	}
+	data, names, rate := vars[0], vars[1], vars[2]
+
+	cd := &ChannelData{}
+	var ok bool
+	if cd.Names, ok = channelNames(names); !ok {
+		return nil, fmt.Errorf("%w: %s is not a cellstr or char matrix", ErrNotChannelData, layout.Names)
+	}
+	values, err := rate.GetFloat64Array()
+	if err != nil || len(values) != 1 || !(values[0] > 0) || rate.IsComplex {
+		return nil, fmt.Errorf("%w: %s is not a positive scalar", ErrNotChannelData, layout.SampleRate)
+	}
+	cd.SampleRate = values[0]
+
+	if data.Kind() != types.KindNumeric || data.IsComplex || len(data.Dimensions) != 2 {
+		return nil, fmt.Errorf("%w: %s is not a real numeric matrix", ErrNotChannelData, layout.Data)
+	}
+	rows, columns := data.Dimensions[0], data.Dimensions[1]
+	if columns != len(cd.Names) {
+		return nil, fmt.Errorf("%w: %s has %d columns for %d channel names",
+			ErrNotChannelData, layout.Data, columns, len(cd.Names))
+	}
+	samples, err := data.GetFloat64Array()
+	if err != nil || len(samples) != rows*columns {
+		return nil, fmt.Errorf("%w: %s: cannot read samples", ErrNotChannelData, layout.Data)
+	}
+	cd.Samples = make([][]float64, columns)
+	for c := range cd.Samples {
+		cd.Samples[c] = samples[c*rows : (c+1)*rows : (c+1)*rows]
+	}
+	return cd, nil
+}
+
+// channelNames returns the strings of a cellstr, or the rows of a char
+// matrix without their padding.
+func channelNames(v *types.Variable) ([]string, bool) {
+	cells, ok := v.Data.([]*types.Variable)
+	if !ok {
+		if v.DataType != types.Char {
+			return nil, false
+		}
+		return manifestRows(v)
+	}
+	names := make([]string, len(cells))
+	for i, cell := range cells {
+		if cell == nil || cell.DataType != types.Char {
+			return nil, false
+		}
+		row, ok := manifestRows(cell)
+		if !ok || len(row) != 1 {
+			return nil, false
+		}
+		names[i] = row[0]
+	}
+	return names, true
+}
@@ -0,0 +1,98 @@
+package matlab
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestChannelData_RoundTrip(t *testing.T) {
+	cd := &ChannelData{
+		Names:      []string{"accel_x", "v"},
+		Samples:    [][]float64{{1, 2, 3}, {-1, -2, -3}},
+		SampleRate: 2048,
+	}
+	for _, version := range []Version{Version5, Version73} {
+		path := filepath.Join(t.TempDir(), "daq.mat")
+		w, err := Create(path, version)
+		if err != nil {
+			t.Fatalf("v%d: Create() error = %v", version, err)
+		}
+		if err := w.WriteChannelData(cd, ChannelLayout{Data: "y"}); err != nil {
+			t.Fatalf("v%d: WriteChannelData() error = %v", version, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("v%d: Close() error = %v", version, err)
+		}
+
+		matFile, err := openPath(path, nil)
+		if err != nil {
+			t.Fatalf("v%d: Open() error = %v", version, err)
+		}
+		if data := matFile.GetVariable("y"); data == nil || !reflect.DeepEqual(data.Dimensions, []int{3, 2}) {
+			t.Fatalf("v%d: y = %v, want a 3x2 matrix", version, data)
+		}
+		got, err := matFile.GetChannelData(ChannelLayout{Data: "y"})
+		if err != nil {
+			t.Fatalf("v%d: GetChannelData() error = %v", version, err)
+		}
+		if !reflect.DeepEqual(got, cd) {
+			t.Errorf("v%d: GetChannelData() = %+v, want %+v", version, got, cd)
+		}
+		if v, ok := got.Channel("v"); !ok || v[2] != -3 || got.Len() != 3 {
+			t.Errorf("v%d: Channel(v) = %v, %v; Len() = %d", version, v, ok, got.Len())
+		}
+	}
+}
+
+func TestGetChannelData_Cellstr(t *testing.T) {
+	name := func(s string) *types.Variable {
+		return &types.Variable{Dimensions: []int{1, len(s)}, DataType: types.Char, Data: s}
+	}
+	matFile := &MatFile{Variables: []*types.Variable{
+		{Name: "data", Dimensions: []int{2, 3}, DataType: types.Int16, Data: []int16{1, 2, 3, 4, 5, 6}},
+		{Name: "channels", Dimensions: []int{1, 3}, DataType: types.CellArray, Data: []*types.Variable{name("a"), name("b"), name("c")}},
+		{Name: "fs", Dimensions: []int{1, 1}, DataType: types.Uint16, Data: []uint16{500}},
+	}}
+	cd, err := matFile.GetChannelData(ChannelLayout{})
+	if err != nil {
+		t.Fatalf("GetChannelData() error = %v", err)
+	}
+	want := &ChannelData{Names: []string{"a", "b", "c"}, Samples: [][]float64{{1, 2}, {3, 4}, {5, 6}}, SampleRate: 500}
+	if !reflect.DeepEqual(cd, want) {
+		t.Errorf("GetChannelData() = %+v, want %+v", cd, want)
+	}
+}
+
+func TestChannelData_Errors(t *testing.T) {
+	w, err := Create(filepath.Join(t.TempDir(), "bad.mat"), Version5)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer w.Close()
+	for _, cd := range []*ChannelData{
+		{SampleRate: 1},
+		{Names: []string{"a"}, Samples: [][]float64{{1}, {2}}, SampleRate: 1},
+		{Names: []string{"a", "b"}, Samples: [][]float64{{1}, {2, 3}}, SampleRate: 1},
+		{Names: []string{"a"}, Samples: [][]float64{{1}}},
+	} {
+		if err := w.WriteChannelData(cd, ChannelLayout{}); !errors.Is(err, ErrNotChannelData) {
+			t.Errorf("WriteChannelData(%+v) error = %v, want ErrNotChannelData", cd, err)
+		}
+	}
+
+	matFile := &MatFile{Variables: []*types.Variable{
+		{Name: "data", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}},
+		charMatrix("channels", []string{"a", "b", "c"}),
+		{Name: "fs", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{100}},
+	}}
+	if _, err := matFile.GetChannelData(ChannelLayout{}); !errors.Is(err, ErrNotChannelData) {
+		t.Errorf("GetChannelData() error = %v, want ErrNotChannelData", err)
+	}
+	if _, err := matFile.GetChannelData(ChannelLayout{SampleRate: "rate"}); !errors.Is(err, ErrVariableNotFound) {
+		t.Errorf("GetChannelData(rate) error = %v, want ErrVariableNotFound", err)
+	}
+}
@@ -0,0 +1,82 @@
+package matlab
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// defaultChunkSize is the chunk size used when WithRateLimit or
+// WithContext is given without WithChunkSize.
+const defaultChunkSize = 64 << 10
+
+// chunkWriter splits large writes into chunks, so a slow destination
+// receives bounded writes, and optionally limits the average write rate
+// and stops between chunks once a context is done.
+type chunkWriter struct {
+	w       io.Writer
+	ctx     context.Context
+	size    int     // Bytes per Write to w
+	rate    float64 // Bytes per second, 0 for unlimited
+	start   time.Time
+	written int64
+}
+
+// newChunkWriter wraps w as configured by WithChunkSize, WithRateLimit
+// and WithContext, or returns w itself if none of them is set.
+func newChunkWriter(w io.Writer, cfg *config) io.Writer {
+	if cfg.chunkSize <= 0 && cfg.rateLimit <= 0 && cfg.ctx == nil {
+		return w
+	}
+	c := &chunkWriter{w: w, ctx: cfg.ctx, size: cfg.chunkSize, rate: float64(cfg.rateLimit), start: time.Now()}
+	if c.ctx == nil {
+		c.ctx = context.Background()
+	}
+	if c.size <= 0 {
+		c.size = defaultChunkSize
+	}
+	return c
+}
+
+// Write implements io.Writer.
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if err := c.wait(); err != nil {
+			return n, err
+		}
+		chunk := p[n:min(len(p), n+c.size)]
+		m, err := c.w.Write(chunk)
+		n += m
+		c.written += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// wait returns once the next chunk may be written without exceeding the
+// rate limit, or the context's error if it is done first.
+func (c *chunkWriter) wait() error {
+	if err := c.ctx.Err(); err != nil {
+		return fmt.Errorf("write interrupted: %w", err)
+	}
+	if c.rate <= 0 {
+		return nil
+	}
+	due := c.start.Add(time.Duration(float64(c.written) / c.rate * float64(time.Second)))
+	delay := time.Until(due)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-c.ctx.Done():
+		return fmt.Errorf("write interrupted: %w", c.ctx.Err())
+	}
+}
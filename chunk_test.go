@@ -0,0 +1,113 @@
+package matlab
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// recordingWriter records the size of each Write and calls onWrite after
+// it.
+type recordingWriter struct {
+	bytes.Buffer
+	sizes   []int
+	onWrite func()
+}
+
+func (r *recordingWriter) Write(p []byte) (int, error) {
+	r.sizes = append(r.sizes, len(p))
+	n, err := r.Buffer.Write(p)
+	if r.onWrite != nil {
+		r.onWrite()
+	}
+	return n, err
+}
+
+func TestNewWriter(t *testing.T) {
+	big := &types.Variable{Name: "x", Dimensions: []int{1, 1000}, DataType: types.Double, Data: make([]float64, 1000)}
+	small := &types.Variable{Name: "s", Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"}
+
+	var plain bytes.Buffer
+	writer, err := NewWriter(&plain)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if err := writer.WriteVariables(big, small); err != nil {
+		t.Fatalf("WriteVariables() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	matFile, err := Open(bytes.NewReader(plain.Bytes()))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if names := matFile.GetVariableNames(); len(names) != 2 || names[0] != "x" || names[1] != "s" {
+		t.Errorf("variables = %v", names)
+	}
+	if err := writer.WriteVariable(small); err == nil {
+		t.Error("WriteVariable() after Close error = nil")
+	}
+
+	chunked := &recordingWriter{}
+	writer, err = NewWriter(chunked, WithChunkSize(1000))
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if err := writer.WriteVariables(big, small); err != nil {
+		t.Fatalf("WriteVariables() error = %v", err)
+	}
+	if !bytes.Equal(chunked.Bytes(), plain.Bytes()) {
+		t.Error("chunked output differs")
+	}
+	for _, size := range chunked.sizes {
+		if size > 1000 {
+			t.Errorf("Write of %d bytes, chunk size 1000", size)
+		}
+	}
+}
+
+func TestNewWriter_RateLimit(t *testing.T) {
+	var out bytes.Buffer
+	start := time.Now()
+	writer, err := NewWriter(&out, WithChunkSize(256), WithRateLimit(20000))
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if err := writer.WriteVariable(&types.Variable{
+		Name: "x", Dimensions: []int{1, 500}, DataType: types.Double, Data: make([]float64, 500),
+	}); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	// 128 header bytes and ~4 KB of data at 20 KB/s; the last chunk
+	// needs no wait.
+	want := time.Duration(float64(out.Len()-256) / 20000 * float64(time.Second))
+	if elapsed := time.Since(start); elapsed < want {
+		t.Errorf("wrote %d bytes in %v, want at least %v", out.Len(), elapsed, want)
+	}
+}
+
+func TestNewWriter_Context(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := &recordingWriter{}
+	writer, err := NewWriter(out, WithChunkSize(512), WithContext(ctx))
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	out.onWrite = cancel
+	err = writer.WriteVariable(&types.Variable{
+		Name: "x", Dimensions: []int{1, 1000}, DataType: types.Double, Data: make([]float64, 1000),
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("WriteVariable() error = %v, want context.Canceled", err)
+	}
+	// The header and the first chunk of the variable
+	if out.Len() <= 128 || out.Len() > 128+512 {
+		t.Errorf("wrote %d bytes after cancel", out.Len())
+	}
+}
@@ -0,0 +1,273 @@
+// Package main implements csv2mat, which bundles CSV files into a MAT-file.
+//
+// Usage:
+//
+//	csv2mat [-o out.mat] [-format 5|7.3] a.csv [b.csv ...]
+//
+// Each CSV file becomes one matrix variable named after the file (for
+// example "run-1.csv" becomes "run_1"). A first row containing non-numeric
+// cells is treated as a header and skipped. The class is inferred from
+// the values: logical if every cell is true/false, int32 or int64 if every
+// cell is an integer, double otherwise. Empty cells are stored as NaN.
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/reshape"
+	"github.com/scigolib/matlab/types"
+)
+
+// maxNameLength is the longest variable name MATLAB accepts.
+const maxNameLength = 63
+
+func main() {
+	if err := run(os.Args[1:], os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "csv2mat:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the command line and writes the MAT-file.
+func run(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("csv2mat", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "", "output file (default: first input with .mat extension)")
+	format := flags.String("format", "5", "MAT-file format: 5 or 7.3")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: csv2mat [-o out.mat] [-format 5|7.3] a.csv [b.csv ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no input files")
+	}
+
+	var version matlab.Version
+	switch *format {
+	case "5":
+		version = matlab.Version5
+	case "7.3":
+		version = matlab.Version73
+	default:
+		return fmt.Errorf("unknown format %q (want 5 or 7.3)", *format)
+	}
+
+	// Parse everything before creating the output, so a bad input does
+	// not leave a partial file behind.
+	seen := make(map[string]bool)
+	variables := make([]*types.Variable, 0, flags.NArg())
+	for _, path := range flags.Args() {
+		v, err := readCSV(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("%s: duplicate variable name %q", path, v.Name)
+		}
+		seen[v.Name] = true
+		variables = append(variables, v)
+	}
+
+	outPath := *output
+	if outPath == "" {
+		first := flags.Arg(0)
+		outPath = strings.TrimSuffix(first, filepath.Ext(first)) + ".mat"
+	}
+
+	writer, err := matlab.Create(outPath, version)
+	if err != nil {
+		return err
+	}
+	for _, v := range variables {
+		if err := writer.WriteVariable(v); err != nil {
+			writer.Close() //nolint:errcheck // Already failing
+			return fmt.Errorf("failed to write %s: %w", v.Name, err)
+		}
+	}
+	return writer.Close()
+}
+
+// readCSV reads a CSV file into a matrix variable.
+func readCSV(path string) (*types.Variable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && isHeader(records[0]) {
+		records = records[1:]
+	}
+	if len(records) == 0 {
+		return nil, errors.New("no data rows")
+	}
+
+	v, err := recordsToVariable(records)
+	if err != nil {
+		return nil, err
+	}
+	v.Name = variableName(path)
+	return v, nil
+}
+
+// isHeader reports whether a row contains a cell that is not a value.
+func isHeader(record []string) bool {
+	for _, cell := range record {
+		cell = strings.TrimSpace(cell)
+		if cell == "" || isBool(cell) {
+			continue
+		}
+		if _, err := strconv.ParseFloat(cell, 64); err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isBool reports whether a cell is a logical literal.
+func isBool(cell string) bool {
+	return strings.EqualFold(cell, "true") || strings.EqualFold(cell, "false")
+}
+
+// recordsToVariable converts rows of cells to a column-major matrix with
+// an inferred class.
+func recordsToVariable(records [][]string) (*types.Variable, error) {
+	rows, cols := len(records), len(records[0])
+	for i, record := range records {
+		if len(record) != cols {
+			return nil, fmt.Errorf("row %d has %d columns, want %d", i+1, len(record), cols)
+		}
+	}
+
+	// CSV rows are row-major; MATLAB stores columns one after another
+	trimmed := make([]string, 0, rows*cols)
+	for _, record := range records {
+		for _, cell := range record {
+			trimmed = append(trimmed, strings.TrimSpace(cell))
+		}
+	}
+	cells := make([]string, rows*cols)
+	if err := reshape.RowMajorToColMajor(cells, trimmed, []int{rows, cols}); err != nil {
+		return nil, err
+	}
+
+	v := &types.Variable{Dimensions: []int{rows, cols}}
+
+	if allCells(cells, isBool) {
+		data := make([]bool, len(cells))
+		for i, cell := range cells {
+			data[i] = strings.EqualFold(cell, "true")
+		}
+		v.DataType, v.Data = types.Logical, data
+		return v, nil
+	}
+
+	if ints, ok := parseInts(cells); ok {
+		if fitsInt32(ints) {
+			data := make([]int32, len(ints))
+			for i, n := range ints {
+				data[i] = int32(n)
+			}
+			v.DataType, v.Data = types.Int32, data
+		} else {
+			v.DataType, v.Data = types.Int64, ints
+		}
+		return v, nil
+	}
+
+	data := make([]float64, len(cells))
+	for i, cell := range cells {
+		if cell == "" {
+			data[i] = math.NaN()
+			continue
+		}
+		f, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cell %q is not a number", cell)
+		}
+		data[i] = f
+	}
+	v.DataType, v.Data = types.Double, data
+	return v, nil
+}
+
+// allCells reports whether every cell satisfies pred.
+func allCells(cells []string, pred func(string) bool) bool {
+	for _, cell := range cells {
+		if !pred(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseInts parses every cell as a base-10 integer.
+func parseInts(cells []string) ([]int64, bool) {
+	ints := make([]int64, len(cells))
+	for i, cell := range cells {
+		n, err := strconv.ParseInt(cell, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		ints[i] = n
+	}
+	return ints, true
+}
+
+// fitsInt32 reports whether every value is within the int32 range.
+func fitsInt32(ints []int64) bool {
+	for _, n := range ints {
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return false
+		}
+	}
+	return true
+}
+
+// variableName derives a valid MATLAB identifier from a file path.
+func variableName(path string) string {
+	base := filepath.Base(path)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+
+	var b strings.Builder
+	for _, r := range base {
+		if isLetter(r) || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+
+	// Identifiers must start with a letter
+	name := b.String()
+	if name == "" || !isLetter(rune(name[0])) {
+		name = "x" + name
+	}
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+	return name
+}
+
+// isLetter reports whether r is an ASCII letter.
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
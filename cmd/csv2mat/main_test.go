@@ -0,0 +1,118 @@
+package main
+
+import (
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestRun_BundlesFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "run-1.csv"), "a,b\n1,2\n3,4\n5,6\n")
+	writeFile(t, filepath.Join(dir, "2nd.csv"), "0.5,\n1e3,-2\n")
+	writeFile(t, filepath.Join(dir, "mask.csv"), "true,FALSE\n")
+	out := filepath.Join(dir, "out.mat")
+
+	args := []string{"-o", out,
+		filepath.Join(dir, "run-1.csv"),
+		filepath.Join(dir, "2nd.csv"),
+		filepath.Join(dir, "mask.csv"),
+	}
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	file, err := os.Open(out)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer file.Close()
+	matFile, err := matlab.Open(file)
+	if err != nil {
+		t.Fatalf("matlab.Open failed: %v", err)
+	}
+
+	run1 := matFile.GetVariable("run_1")
+	if run1 == nil || run1.DataType != types.Int32 {
+		t.Fatalf("run_1 = %v, want int32 matrix", run1)
+	}
+	if !reflect.DeepEqual(run1.Dimensions, []int{3, 2}) || !reflect.DeepEqual(run1.Data, []int32{1, 3, 5, 2, 4, 6}) {
+		t.Errorf("run_1 = %v %v", run1.Dimensions, run1.Data)
+	}
+
+	second := matFile.GetVariable("x2nd")
+	if second == nil || second.DataType != types.Double {
+		t.Fatalf("x2nd = %v, want double matrix", second)
+	}
+	data := second.Data.([]float64)
+	if data[0] != 0.5 || data[1] != 1000 || !math.IsNaN(data[2]) || data[3] != -2 {
+		t.Errorf("x2nd data = %v", data)
+	}
+
+	mask := matFile.GetVariable("mask")
+	if mask == nil || !reflect.DeepEqual(mask.Data, []bool{true, false}) {
+		t.Errorf("mask = %v", mask)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	dir := t.TempDir()
+	ragged := filepath.Join(dir, "ragged.csv")
+	writeFile(t, ragged, "1,2\n3\n")
+	text := filepath.Join(dir, "text.csv")
+	writeFile(t, text, "a,b\nc,d\n")
+	good := filepath.Join(dir, "good.csv")
+	writeFile(t, good, "1\n")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no inputs", nil},
+		{"bad format", []string{"-format", "6", good}},
+		{"missing file", []string{filepath.Join(dir, "missing.csv")}},
+		{"ragged rows", []string{ragged}},
+		{"non-numeric data", []string{text}},
+		{"duplicate names", []string{good, good}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := run(tt.args, io.Discard); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestVariableName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"data.csv", "data"},
+		{"/tmp/run-1.csv", "run_1"},
+		{"2024 results.csv", "x2024_results"},
+		{"_hidden.csv", "x_hidden"},
+		{"ünï.csv", "x_n_"},
+	}
+
+	for _, tt := range tests {
+		if got := variableName(tt.path); got != tt.want {
+			t.Errorf("variableName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Package main implements mat2csv, which exports numeric variables from a
+// MAT-file to CSV files.
+//
+// Usage:
+//
+//	mat2csv [-var name] [-out dir] [-digits n] file.mat
+//
+// Each real numeric or logical variable with at most two dimensions is
+// written to <dir>/<name>.csv, one matrix row per line. Floating-point
+// values are written with the fewest digits that read back exactly, or
+// rounded to -digits significant digits. Other variables
+// are skipped with a note on stderr. With -var, only the named variable is
+// exported and it is an error if it cannot be.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "mat2csv:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the command line and exports the selected variables.
+func run(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("mat2csv", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	varName := flags.String("var", "", "export only this variable")
+	outDir := flags.String("out", ".", "directory for the CSV files")
+	digits := flags.Int("digits", 0, "significant digits of floating-point values (0 = exact)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mat2csv [-var name] [-out dir] [-digits n] file.mat")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected exactly one input file")
+	}
+	if *digits < 0 || *digits > 17 {
+		return fmt.Errorf("digits %d out of range 0-17", *digits)
+	}
+	opts := []types.StreamOption{types.WithSignificantDigits(*digits)}
+
+	matFile, err := openMatFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *varName != "" {
+		v := matFile.GetVariable(*varName)
+		if v == nil {
+			return fmt.Errorf("variable %q not found", *varName)
+		}
+		return exportVariable(v, *outDir, opts)
+	}
+
+	exported := 0
+	for _, v := range matFile.Variables {
+		if err := exportVariable(v, *outDir, opts); err != nil {
+			fmt.Fprintf(stderr, "skipping %s: %v\n", v.Name, err)
+			continue
+		}
+		exported++
+	}
+	if exported == 0 {
+		return errors.New("no exportable variables")
+	}
+	return nil
+}
+
+// openMatFile opens and parses a MAT-file from disk.
+func openMatFile(path string) (*matlab.MatFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+
+	matFile, err := matlab.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return matFile, nil
+}
+
+// exportVariable writes v to <dir>/<name>.csv.
+func exportVariable(v *types.Variable, dir string, opts []types.StreamOption) error {
+	if len(v.Dimensions) > 2 {
+		return fmt.Errorf("not a 2D matrix (dimensions %v)", v.Dimensions)
+	}
+	if v.IsComplex {
+		return errors.New("complex data is not supported")
+	}
+
+	path, err := csvPath(dir, v.Name)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	// StreamTo writes row by row, so large matrices need no second copy
+	if err := v.StreamTo(file, types.FormatCSV, opts...); err != nil {
+		file.Close()           //nolint:errcheck // Already failing
+		os.Remove(file.Name()) //nolint:errcheck // Best effort
+		return err
+	}
+	return file.Close()
+}
+
+// csvPath returns <dir>/<name>.csv. Names come from the input file, so
+// one that would place the CSV file outside dir, such as "../x" or
+// "/etc/x", is an error.
+func csvPath(dir, name string) (string, error) {
+	base := name + ".csv"
+	if strings.ContainsAny(name, `/\`) || !filepath.IsLocal(base) {
+		return "", fmt.Errorf("variable name %q is not a safe file name", name)
+	}
+	return filepath.Join(dir, base), nil
+}
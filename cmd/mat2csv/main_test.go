@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+func writeMat(t *testing.T, vars ...*types.Variable) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "in.mat")
+	writer, err := matlab.Create(path, matlab.Version5)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, v := range vars {
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) failed: %v", v.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	return string(data)
+}
+
+func TestRun_ExportsAll(t *testing.T) {
+	in := writeMat(t,
+		&types.Variable{Name: "m", Dimensions: []int{2, 3}, DataType: types.Double, Data: []float64{1, 4, 2, 5, 3, 6.5}},
+		&types.Variable{Name: "ids", Dimensions: []int{3, 1}, DataType: types.Int32, Data: []int32{7, 8, 9}},
+		&types.Variable{Name: "mask", Dimensions: []int{1, 2}, DataType: types.Logical, Data: []bool{true, false}},
+		&types.Variable{Name: "label", Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"},
+	)
+	out := t.TempDir()
+
+	var stderr bytes.Buffer
+	if err := run([]string{"-out", out, in}, &stderr); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	want := map[string]string{
+		"m.csv":    "1,2,3\n4,5,6.5\n",
+		"ids.csv":  "7\n8\n9\n",
+		"mask.csv": "1,0\n",
+	}
+	for name, content := range want {
+		if got := readFile(t, filepath.Join(out, name)); got != content {
+			t.Errorf("%s = %q, want %q", name, got, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "label.csv")); !os.IsNotExist(err) {
+		t.Error("label.csv should not be written")
+	}
+	if !strings.Contains(stderr.String(), "skipping label") {
+		t.Errorf("stderr = %q, want skip note for label", stderr.String())
+	}
+}
+
+func TestRun_SelectedVariable(t *testing.T) {
+	in := writeMat(t,
+		&types.Variable{Name: "a", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}},
+		&types.Variable{Name: "b", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{2}},
+		&types.Variable{Name: "cube", Dimensions: []int{1, 1, 2}, DataType: types.Double, Data: []float64{1, 2}},
+	)
+	out := t.TempDir()
+
+	if err := run([]string{"-var", "b", "-out", out, in}, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if got := readFile(t, filepath.Join(out, "b.csv")); got != "2\n" {
+		t.Errorf("b.csv = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(out, "a.csv")); !os.IsNotExist(err) {
+		t.Error("a.csv should not be written")
+	}
+
+	if err := run([]string{"-var", "missing", "-out", out, in}, io.Discard); err == nil {
+		t.Error("expected error for missing variable")
+	}
+	if err := run([]string{"-var", "cube", "-out", out, in}, io.Discard); err == nil {
+		t.Error("expected error for 3D variable")
+	}
+}
+
+func TestRun_Digits(t *testing.T) {
+	in := writeMat(t, &types.Variable{Name: "x", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{0.30000000000000004, 2.0 / 3}})
+	out := t.TempDir()
+
+	if err := run([]string{"-out", out, in}, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if got := readFile(t, filepath.Join(out, "x.csv")); got != "0.30000000000000004,0.6666666666666666\n" {
+		t.Errorf("x.csv = %q, want exact values", got)
+	}
+
+	if err := run([]string{"-digits", "3", "-out", out, in}, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if got := readFile(t, filepath.Join(out, "x.csv")); got != "0.3,0.667\n" {
+		t.Errorf("x.csv = %q, want 3 digits", got)
+	}
+
+	if err := run([]string{"-digits", "-1", "-out", out, in}, io.Discard); err == nil {
+		t.Error("expected error for negative digits")
+	}
+}
+
+func TestExportVariable_UnsafeName(t *testing.T) {
+	root := t.TempDir()
+	out := filepath.Join(root, "out")
+	if err := os.Mkdir(out, 0o755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	for _, name := range []string{"../x", "../../x", filepath.Join(root, "abs"), `..\x`} {
+		v := &types.Variable{Name: name, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}
+		if err := exportVariable(v, out, nil); err == nil {
+			t.Errorf("exportVariable(%q) succeeded", name)
+		}
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("files written outside -out: %v", entries)
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	if err := run(nil, io.Discard); err == nil {
+		t.Error("expected error without input file")
+	}
+	if err := run([]string{filepath.Join(t.TempDir(), "missing.mat")}, io.Discard); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -0,0 +1,166 @@
+// Package main implements matdump, which prints the values of the
+// variables in a MAT-file as text.
+//
+// Usage:
+//
+//	matdump [-precision digits | -exact] [-width columns] [-hist bins] file.mat [name ...]
+//
+// Variables are printed in file order, or in the order named, the way
+// MATLAB displays them. Floating-point values keep their exponent
+// (1.2346e+06) and are printed with -precision significant digits, or,
+// with -exact, with the fewest digits that read back to the same value.
+// Matrices wider than -width characters are split into column blocks.
+// The output is deterministic, so dumps of small result files can be
+// diffed and reviewed like source code:
+//
+//	diff <(matdump old.mat) <(matdump new.mat)
+//
+// With -hist, numeric and logical variables are summarized instead, for
+// quality-control reports: count, minimum, maximum, mean, standard
+// deviation, estimated percentiles and a histogram of the given number of
+// bins. Other variables are skipped with a note on stderr.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "matdump:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the command line and prints the variables.
+func run(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("matdump", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	precision := flags.Int("precision", 5, "significant digits of floating-point values")
+	exact := flags.Bool("exact", false, "print floating-point values with full round-trip precision")
+	width := flags.Int("width", 80, "maximum line width before matrices are split")
+	hist := flags.Int("hist", 0, "summarize numeric variables with a histogram of this many bins")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: matdump [-precision digits | -exact] [-width columns] [-hist bins] file.mat [name ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return errors.New("expected an input file")
+	}
+	if *precision < 1 || *precision > 17 {
+		return fmt.Errorf("precision %d out of range 1-17", *precision)
+	}
+	if *width < 1 {
+		return fmt.Errorf("invalid width %d", *width)
+	}
+	if *hist < 0 {
+		return fmt.Errorf("invalid number of bins %d", *hist)
+	}
+
+	path := flags.Arg(0)
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+
+	matFile, err := matlab.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	variables := matFile.Variables
+	if flags.NArg() > 1 {
+		variables = nil
+		for _, name := range flags.Args()[1:] {
+			v := matFile.GetVariable(name)
+			if v == nil {
+				return fmt.Errorf("%w: %q", matlab.ErrVariableNotFound, name)
+			}
+			variables = append(variables, v)
+		}
+	}
+
+	if *exact {
+		*precision = -1
+	}
+	if *hist > 0 {
+		for _, v := range variables {
+			h, err := v.Histogram(*hist)
+			if err != nil {
+				fmt.Fprintf(stderr, "matdump: %s: skipped: %v\n", v.Name, err)
+				continue
+			}
+			printHistogram(stdout, v.Name, h, *precision, *width)
+		}
+		return nil
+	}
+
+	opts := []matlab.PrintOption{matlab.WithPrecision(*precision), matlab.WithLineWidth(*width)}
+	for _, v := range variables {
+		if err := matlab.Print(stdout, v, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportPercentiles are the percentiles printed with -hist.
+var reportPercentiles = []float64{5, 25, 50, 75, 95}
+
+// printHistogram prints the summary statistics, percentiles and bins of a
+// histogram, with a bar per bin scaled to fit in width columns.
+func printHistogram(w io.Writer, name string, h *types.Histogram, precision, width int) {
+	format := func(x float64) string {
+		return strconv.FormatFloat(x, 'g', precision, 64)
+	}
+	fmt.Fprintf(w, "%s: %d values, min %s, max %s, mean %s, std %s\n",
+		name, h.Count, format(h.Min), format(h.Max), format(h.Mean), format(h.Std))
+	if h.NaN > 0 {
+		fmt.Fprintf(w, "  NaN: %d\n", h.NaN)
+	}
+	if h.Count == 0 {
+		fmt.Fprintln(w)
+		return
+	}
+	percentiles := make([]string, len(reportPercentiles))
+	for i, p := range reportPercentiles {
+		percentiles[i] = fmt.Sprintf("p%g %s", p, format(h.Percentile(p)))
+	}
+	fmt.Fprintf(w, "  %s\n", strings.Join(percentiles, ", "))
+
+	labels := make([]string, len(h.Counts))
+	counts := make([]string, len(h.Counts))
+	labelWidth, countWidth, most := 0, 0, 0
+	for i, n := range h.Counts {
+		closing := ")"
+		if i == len(h.Counts)-1 {
+			closing = "]"
+		}
+		labels[i] = "[" + format(h.Edges[i]) + ", " + format(h.Edges[i+1]) + closing
+		counts[i] = strconv.Itoa(n)
+		labelWidth, countWidth, most = max(labelWidth, len(labels[i])), max(countWidth, len(counts[i])), max(most, n)
+	}
+	bar := max(width-labelWidth-countWidth-6, 0)
+	for i, n := range h.Counts {
+		line := fmt.Sprintf("  %-*s  %*s", labelWidth, labels[i], countWidth, counts[i])
+		if length := n * bar / max(most, 1); length > 0 {
+			line += "  " + strings.Repeat("#", length)
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+func writeMat(t *testing.T, vars ...*types.Variable) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "in.mat")
+	writer, err := matlab.Create(path, matlab.Version5)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, v := range vars {
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) failed: %v", v.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	path := writeMat(t,
+		&types.Variable{Name: "a", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{3.14159265, 2e10}},
+		&types.Variable{Name: "b", Dimensions: []int{1, 1}, DataType: types.Int16, Data: []int16{-7}},
+	)
+
+	var stdout bytes.Buffer
+	if err := run([]string{path}, &stdout, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	want := "a =\n\n   3.1416    2e+10\n\nb =\n\n   -7\n\n"
+	if stdout.String() != want {
+		t.Errorf("output =\n%q\nwant\n%q", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-precision", "9", path, "a"}, &stdout, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	want = "a =\n\n   3.14159265        2e+10\n\n"
+	if stdout.String() != want {
+		t.Errorf("output =\n%q\nwant\n%q", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	if err := run([]string{"-exact", path, "a"}, &stdout, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	want = "a =\n\n   3.14159265        2e+10\n\n"
+	if stdout.String() != want {
+		t.Errorf("output =\n%q\nwant\n%q", stdout.String(), want)
+	}
+}
+
+func TestRun_Hist(t *testing.T) {
+	path := writeMat(t,
+		&types.Variable{Name: "x", Dimensions: []int{1, 5}, DataType: types.Double, Data: []float64{0, 1, 1, 3, 4}},
+		&types.Variable{Name: "s", Dimensions: []int{1, 2}, DataType: types.Char, Data: "ab"},
+	)
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-hist", "2", "-width", "30", path}, &stdout, &stderr); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	want := "x: 5 values, min 0, max 4, mean 1.8, std 1.6432\n" +
+		"  p5 0.16667, p25 0.83333, p50 1.6667, p75 2.75, p95 3.75\n" +
+		"  [0, 2)  3  #################\n" +
+		"  [2, 4]  2  ###########\n\n"
+	if stdout.String() != want {
+		t.Errorf("output =\n%q\nwant\n%q", stdout.String(), want)
+	}
+	if !bytes.Contains(stderr.Bytes(), []byte("s: skipped")) {
+		t.Errorf("stderr = %q, want s skipped", stderr.String())
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	path := writeMat(t, &types.Variable{Name: "y", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}})
+
+	tests := [][]string{
+		{},
+		{"-precision", "0", path},
+		{"-width", "0", path},
+		{"-hist", "-1", path},
+		{path, "missing"},
+		{filepath.Join(t.TempDir(), "missing.mat")},
+	}
+	for _, args := range tests {
+		if err := run(args, io.Discard, io.Discard); err == nil {
+			t.Errorf("run(%v) succeeded", args)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Package main implements matinfo, which describes the contents of a
+// MAT-file.
+//
+// Usage:
+//
+//	matinfo [-json] file.mat
+//
+// By default a human-readable summary is printed. With -json, a single
+// JSON document is printed instead:
+//
+//	{
+//	  "schema": 1,
+//	  "file": "data.mat",
+//	  "version": "5.0",
+//	  "endian": "IM",
+//	  "description": "...",
+//	  "variables": [
+//	    {"name": "x", "class": "double", "dims": [2, 3], "complex": false,
+//	     "sparse": false, "bytes": 48, "attributes": {...}}
+//	  ]
+//	}
+//
+// Variables appear in file order. "bytes" is the in-memory size of the
+// decoded data. Struct variables list their "fields" recursively. The
+// document layout only changes together with "schema".
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+// schemaVersion identifies the layout of the JSON document.
+const schemaVersion = 1
+
+// fileInfo is the JSON document printed by -json.
+type fileInfo struct {
+	Schema      int             `json:"schema"`
+	File        string          `json:"file"`
+	Version     string          `json:"version"`
+	Endian      string          `json:"endian,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Variables   []*variableInfo `json:"variables"`
+}
+
+// variableInfo describes one variable or struct field.
+type variableInfo struct {
+	Name       string                 `json:"name"`
+	Class      string                 `json:"class"`
+	Dims       []int                  `json:"dims"`
+	Complex    bool                   `json:"complex"`
+	Sparse     bool                   `json:"sparse"`
+	Bytes      int64                  `json:"bytes"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Fields     []*variableInfo        `json:"fields,omitempty"`
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "matinfo:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the command line and prints the description.
+func run(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("matinfo", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "print a machine-readable JSON document")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: matinfo [-json] file.mat")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected exactly one input file")
+	}
+
+	path := flags.Arg(0)
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+
+	matFile, err := matlab.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	info := describeFile(path, matFile)
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	printText(stdout, info)
+	return nil
+}
+
+// describeFile builds the description of a parsed file.
+func describeFile(path string, matFile *matlab.MatFile) *fileInfo {
+	info := &fileInfo{
+		Schema:      schemaVersion,
+		File:        path,
+		Version:     matFile.Version,
+		Endian:      matFile.Endian,
+		Description: matFile.Description,
+		Variables:   make([]*variableInfo, 0, len(matFile.Variables)),
+	}
+	for _, v := range matFile.Variables {
+		info.Variables = append(info.Variables, describeVariable(v))
+	}
+	return info
+}
+
+// describeVariable builds the description of a variable.
+func describeVariable(v *types.Variable) *variableInfo {
+	info := &variableInfo{
+		Name:       v.Name,
+		Class:      v.DataType.String(),
+		Dims:       v.Dimensions,
+		Complex:    v.IsComplex,
+		Sparse:     v.IsSparse,
+		Attributes: attributeValues(v.Attributes),
+	}
+	if info.Dims == nil {
+		info.Dims = []int{}
+	}
+
+	if st, ok := v.Data.(*types.StructArray); ok {
+		for _, element := range st.Elements {
+			for _, field := range element {
+				if field == nil {
+					continue
+				}
+				fieldInfo := describeVariable(field)
+				info.Bytes += fieldInfo.Bytes
+				info.Fields = append(info.Fields, fieldInfo)
+			}
+		}
+		return info
+	}
+
+	info.Bytes = dataBytes(v.Data)
+	return info
+}
+
+// dataBytes returns the in-memory size of decoded variable data.
+func dataBytes(data interface{}) int64 {
+	switch d := data.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(d))
+	case *types.NumericArray:
+		return dataBytes(d.Real) + dataBytes(d.Imag)
+	}
+
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Slice {
+		return 0
+	}
+	return int64(rv.Len()) * int64(rv.Type().Elem().Size())
+}
+
+// attributeValues returns the variable attributes that can be encoded as
+// JSON; others are omitted.
+func attributeValues(attrs map[string]interface{}) map[string]interface{} {
+	if len(attrs) == 0 {
+		return nil
+	}
+	values := make(map[string]interface{}, len(attrs))
+	for name, attr := range attrs {
+		if _, err := json.Marshal(attr); err != nil {
+			continue
+		}
+		values[name] = attr
+	}
+	return values
+}
+
+// printText prints a human-readable summary.
+func printText(w io.Writer, info *fileInfo) {
+	fmt.Fprintf(w, "File:        %s\n", info.File)
+	fmt.Fprintf(w, "Version:     %s\n", info.Version)
+	if info.Endian != "" {
+		fmt.Fprintf(w, "Endian:      %s\n", info.Endian)
+	}
+	if info.Description != "" {
+		fmt.Fprintf(w, "Description: %s\n", info.Description)
+	}
+	fmt.Fprintf(w, "Variables:   %d\n", len(info.Variables))
+	for _, v := range info.Variables {
+		printVariable(w, v, "  ")
+	}
+}
+
+// printVariable prints one variable line, followed by its struct fields.
+func printVariable(w io.Writer, v *variableInfo, indent string) {
+	flags := ""
+	if v.Complex {
+		flags += " complex"
+	}
+	if v.Sparse {
+		flags += " sparse"
+	}
+	fmt.Fprintf(w, "%s%s: %s %v%s (%d bytes)\n", indent, v.Name, v.Class, v.Dims, flags, v.Bytes)
+
+	names := make([]string, 0, len(v.Attributes))
+	for name := range v.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s  @%s = %v\n", indent, name, v.Attributes[name])
+	}
+
+	for _, field := range v.Fields {
+		printVariable(w, field, indent+"  ")
+	}
+}
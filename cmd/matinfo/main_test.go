@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+func writeMat(t *testing.T, version matlab.Version, vars ...*types.Variable) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "in.mat")
+	writer, err := matlab.Create(path, version)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, v := range vars {
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) failed: %v", v.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return path
+}
+
+func runJSON(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+	var stdout bytes.Buffer
+	if err := run([]string{"-json", path}, &stdout, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON %q: %v", stdout.String(), err)
+	}
+	return doc
+}
+
+func TestRun_JSON_V5(t *testing.T) {
+	path := writeMat(t, matlab.Version5,
+		&types.Variable{Name: "m", Dimensions: []int{2, 3}, DataType: types.Double, Data: []float64{1, 2, 3, 4, 5, 6}},
+		&types.Variable{
+			Name: "z", Dimensions: []int{1, 2}, DataType: types.Single, IsComplex: true,
+			Data: &types.NumericArray{Real: []float32{1, 2}, Imag: []float32{3, 4}},
+		},
+	)
+
+	doc := runJSON(t, path)
+	if doc["schema"] != 1.0 || doc["version"] != "5.0" || doc["file"] != path {
+		t.Errorf("header = %v", doc)
+	}
+
+	vars := doc["variables"].([]interface{})
+	if len(vars) != 2 {
+		t.Fatalf("got %d variables, want 2", len(vars))
+	}
+	want := []map[string]interface{}{
+		{"name": "m", "class": "double", "dims": []interface{}{2.0, 3.0}, "complex": false, "sparse": false, "bytes": 48.0},
+		{"name": "z", "class": "single", "dims": []interface{}{1.0, 2.0}, "complex": true, "sparse": false, "bytes": 16.0},
+	}
+	for i, v := range vars {
+		if !reflect.DeepEqual(v, want[i]) {
+			t.Errorf("variables[%d] = %v, want %v", i, v, want[i])
+		}
+	}
+}
+
+func TestRun_JSON_V73Attributes(t *testing.T) {
+	path := writeMat(t, matlab.Version73,
+		&types.Variable{Name: "mask", Dimensions: []int{1, 3}, DataType: types.Logical, Data: []bool{true, false, true}},
+	)
+
+	doc := runJSON(t, path)
+	v := doc["variables"].([]interface{})[0].(map[string]interface{})
+	if v["class"] != "logical" || v["bytes"] != 3.0 {
+		t.Errorf("variable = %v", v)
+	}
+	attrs, ok := v["attributes"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("attributes missing: %v", v)
+	}
+	if attrs["MATLAB_class"] != "logical" {
+		t.Errorf("MATLAB_class = %v, want logical", attrs["MATLAB_class"])
+	}
+}
+
+func TestRun_Text(t *testing.T) {
+	path := writeMat(t, matlab.Version5,
+		&types.Variable{Name: "x", Dimensions: []int{1, 2}, DataType: types.Int16, Data: []int16{1, 2}},
+	)
+
+	var stdout bytes.Buffer
+	if err := run([]string{path}, &stdout, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "x: int16 [1 2] (4 bytes)") {
+		t.Errorf("output = %q", stdout.String())
+	}
+}
+
+func TestDescribeVariable_Struct(t *testing.T) {
+	v := &types.Variable{
+		Name:       "s",
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     []string{"a", "b"},
+			Dimensions: []int{1, 1},
+			Elements: [][]*types.Variable{{
+				{Name: "a", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}},
+				{Name: "b", Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"},
+			}},
+		},
+	}
+
+	info := describeVariable(v)
+	if info.Bytes != 10 || len(info.Fields) != 2 || info.Fields[1].Class != "char" {
+		t.Errorf("info = %+v", info)
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	if err := run(nil, io.Discard, io.Discard); err == nil {
+		t.Error("expected error without input file")
+	}
+	if err := run([]string{filepath.Join(t.TempDir(), "missing.mat")}, io.Discard, io.Discard); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+func writeMat(t *testing.T, vars ...*types.Variable) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "in.mat")
+	writer, err := matlab.Create(path, matlab.Version5)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, v := range vars {
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) failed: %v", v.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return path
+}
+
+func openMat(t *testing.T, path string) *matlab.MatFile {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer file.Close()
+	matFile, err := matlab.Open(file)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	return matFile
+}
+
+func TestRun_Compress(t *testing.T) {
+	zeros := &types.Variable{Name: "x", Dimensions: []int{100, 100}, DataType: types.Double, Data: make([]float64, 10000)}
+	src := writeMat(t, zeros, zeros)
+	dst := filepath.Join(t.TempDir(), "out.mat")
+
+	var stdout bytes.Buffer
+	if err := run([]string{"-compress", "9", src, dst}, &stdout, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	out := stdout.String()
+	if !strings.HasPrefix(out, "repacked 1 variables: ") || !strings.Contains(out, "dropped earlier copies of: x") {
+		t.Errorf("output = %q", out)
+	}
+
+	matFile := openMat(t, dst)
+	if len(matFile.Variables) != 1 || !matFile.Features.Compressed {
+		t.Errorf("repacked file = %+v", matFile)
+	}
+}
+
+func TestRun_Format(t *testing.T) {
+	src := writeMat(t, &types.Variable{Name: "y", Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{1, 2, 3}})
+	if err := run([]string{"-format", "7.3", src, src}, io.Discard, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if matFile := openMat(t, src); matFile.Version != "7.3" || !matFile.HasVariable("y") {
+		t.Errorf("converted file = %+v", matFile)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	src := writeMat(t, &types.Variable{Name: "y", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}})
+	dst := filepath.Join(t.TempDir(), "out.mat")
+
+	tests := [][]string{
+		{src},
+		{"-format", "6", src, dst},
+		{"-compress", "10", src, dst},
+		{filepath.Join(t.TempDir(), "missing.mat"), dst},
+	}
+	for _, args := range tests {
+		if err := run(args, io.Discard, io.Discard); err == nil {
+			t.Errorf("run(%v) succeeded", args)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+// writeTruncated writes variables a, b and c and cuts the file off in the
+// middle of c.
+func writeTruncated(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "damaged.mat")
+	writer, err := matlab.Create(path, matlab.Version5)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		v := &types.Variable{Name: name, Dimensions: []int{1, 8}, DataType: types.Double, Data: make([]float64, 8)}
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) failed: %v", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if err := os.WriteFile(path, data[:len(data)-30], 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return path
+}
+
+func TestRun_Repair(t *testing.T) {
+	input := writeTruncated(t)
+	output := filepath.Join(t.TempDir(), "repaired.mat")
+
+	var stdout bytes.Buffer
+	if err := run([]string{"-o", output, input}, &stdout, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	report := stdout.String()
+	if !strings.Contains(report, "recovered a\nrecovered b\n") || !strings.Contains(report, "skipped") {
+		t.Errorf("report = %q", report)
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer file.Close()
+	matFile, err := matlab.Open(file)
+	if err != nil {
+		t.Fatalf("repaired file does not open: %v", err)
+	}
+	if names := matFile.GetVariableNames(); !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("repaired variables = %v", names)
+	}
+}
+
+func TestRun_ReportOnly(t *testing.T) {
+	input := writeTruncated(t)
+	var stdout bytes.Buffer
+	if err := run([]string{input}, &stdout, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "recovered b") {
+		t.Errorf("report = %q", stdout.String())
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	input := writeTruncated(t)
+	empty := filepath.Join(t.TempDir(), "empty.mat")
+	if err := os.WriteFile(empty, make([]byte, 200), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [][]string{
+		{},
+		{"-o", input, input},
+		{filepath.Join(t.TempDir(), "missing.mat")},
+		{empty},
+	}
+	for _, args := range tests {
+		if err := run(args, io.Discard, io.Discard); err == nil {
+			t.Errorf("run(%v) succeeded", args)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package matlab
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/scigolib/matlab/internal/v5"
+	"github.com/scigolib/matlab/types"
+)
+
+// GetColumns returns the selected zero-based columns of a 2-D variable as
+// a new rows×len(cols) variable of the same class, in the order given.
+// Columns may repeat. Columns are contiguous in MATLAB's column-major
+// storage, so each one is a single copy and rows are never gathered
+// element by element, which makes this cheap for picking features out of
+// wide matrices. Numeric and logical arrays are supported; complex arrays
+// keep both parts.
+//
+// Returns ErrVariableNotFound if the variable does not exist and
+// ErrColumnOutOfRange if a column is outside the matrix. To avoid decoding
+// the other columns at all, use LoadColumns.
+//
+// Example:
+//
+//	features, err := matFile.GetColumns("X", 0, 4, 17)
+//	if err != nil {
+//	    return err
+//	}
+//	data := features.Data.([]float64) // 3 columns of X, column-major
+func (m *MatFile) GetColumns(name string, cols ...int) (*types.Variable, error) {
+	v := m.GetVariable(name)
+	if v == nil {
+		return nil, fmt.Errorf("%w: %q", ErrVariableNotFound, name)
+	}
+	return selectColumns(v, cols)
+}
+
+// LoadColumns reads the selected zero-based columns of the 2-D variable
+// name from the MAT-file in r, like GetColumns, without reading the file
+// into memory first.
+//
+// In v5 files the other variables are skipped undecoded, and so is the
+// data of the unselected columns, so extracting a few columns of a large
+// matrix costs little more than their own size in memory. Only numeric
+// and logical arrays can be read this way. v7.3 files are read in full
+// before the columns are selected.
+//
+// Example:
+//
+//	file, _ := os.Open("features.mat")
+//	defer file.Close()
+//	selected, err := matlab.LoadColumns(file, "X", 3, 8)
+func LoadColumns(r io.Reader, name string, cols ...int) (*types.Variable, error) {
+	src, err := newSource(r)
+	if err != nil {
+		return nil, err
+	}
+	defer src.close()
+
+	if isHDF5Format(src.header) {
+		matFile, err := parseV73(src.r, defaultOpenConfig(), "")
+		if err != nil {
+			return nil, err
+		}
+		return matFile.GetColumns(name, cols...)
+	}
+	if !isV5Format(src.header) {
+		return nil, ErrInvalidFormat
+	}
+
+	parser, err := v5.NewParser(src.r)
+	if err != nil {
+		return nil, err
+	}
+	v, found, err := parser.ReadColumns(name, cols)
+	if err != nil {
+		return nil, fmt.Errorf("variable %s: %w", name, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %q", ErrVariableNotFound, name)
+	}
+	return v, nil
+}
+
+// selectColumns copies the selected columns of a parsed 2-D variable.
+func selectColumns(v *types.Variable, cols []int) (*types.Variable, error) {
+	if len(v.Dimensions) != 2 {
+		return nil, fmt.Errorf("cannot select columns of %s: array has %d dimensions, want 2",
+			v.Name, len(v.Dimensions))
+	}
+	rows, ncols := v.Dimensions[0], v.Dimensions[1]
+	for _, c := range cols {
+		if c < 0 || c >= ncols {
+			return nil, fmt.Errorf("%w: %s has %d columns, got %d", ErrColumnOutOfRange, v.Name, ncols, c)
+		}
+	}
+
+	result := &types.Variable{
+		Name:       v.Name,
+		Dimensions: []int{rows, len(cols)},
+		DataType:   v.DataType,
+		IsComplex:  v.IsComplex,
+	}
+	if complexData, ok := v.Data.(*types.NumericArray); ok {
+		realPart, err := columnsOf(v, complexData.Real, rows, cols)
+		if err != nil {
+			return nil, err
+		}
+		imagPart, err := columnsOf(v, complexData.Imag, rows, cols)
+		if err != nil {
+			return nil, err
+		}
+		result.Data = &types.NumericArray{
+			Real:       realPart,
+			Imag:       imagPart,
+			Dimensions: result.Dimensions,
+			Type:       complexData.Type,
+		}
+		return result, nil
+	}
+
+	data, err := columnsOf(v, v.Data, rows, cols)
+	if err != nil {
+		return nil, err
+	}
+	result.Data = data
+	return result, nil
+}
+
+// columnsOf copies the selected columns out of column-major slice data.
+func columnsOf(v *types.Variable, data interface{}, rows int, cols []int) (interface{}, error) {
+	src := reflect.ValueOf(data)
+	if src.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot select columns of %s %s: only numeric and logical arrays are supported",
+			v.Name, v.DataType)
+	}
+	if src.Len() != rows*v.Dimensions[1] {
+		return nil, fmt.Errorf("%s: data holds %d elements, want %d", v.Name, src.Len(), rows*v.Dimensions[1])
+	}
+	dst := reflect.MakeSlice(src.Type(), 0, rows*len(cols))
+	for _, c := range cols {
+		dst = reflect.AppendSlice(dst, src.Slice(c*rows, (c+1)*rows))
+	}
+	return dst.Interface(), nil
+}
@@ -0,0 +1,82 @@
+package matlab
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestGetColumns(t *testing.T) {
+	files := map[string][]byte{
+		"v5":            writeIntoFile(t, Version5),
+		"v5 compressed": writeIntoFile(t, Version5, WithCompression(6)),
+		"v7.3":          writeIntoFile(t, Version73),
+	}
+	cols := []int{99, 3, 3, 0}
+	want := make([]float64, 0, 400)
+	for _, c := range cols {
+		for r := 0; r < 100; r++ {
+			want = append(want, float64(c*100+r)/3)
+		}
+	}
+
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			matFile, err := Open(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			got, err := matFile.GetColumns("signal", cols...)
+			if err != nil {
+				t.Fatalf("GetColumns() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Data, want) || !reflect.DeepEqual(got.Dimensions, []int{100, 4}) {
+				t.Errorf("GetColumns() = %v, data differs", got.Dimensions)
+			}
+
+			loaded, err := LoadColumns(bytes.NewReader(data), "signal", cols...)
+			if err != nil {
+				t.Fatalf("LoadColumns() error = %v", err)
+			}
+			if !reflect.DeepEqual(loaded.Data, want) || loaded.DataType != types.Double {
+				t.Errorf("LoadColumns() = %v %v, data differs", loaded.Dimensions, loaded.DataType)
+			}
+
+			if _, err := matFile.GetColumns("missing", 0); !errors.Is(err, ErrVariableNotFound) {
+				t.Errorf("missing: error = %v", err)
+			}
+			if _, err := LoadColumns(bytes.NewReader(data), "missing", 0); !errors.Is(err, ErrVariableNotFound) {
+				t.Errorf("LoadColumns(missing): error = %v", err)
+			}
+			if _, err := matFile.GetColumns("signal", 100); !errors.Is(err, ErrColumnOutOfRange) {
+				t.Errorf("out of range: error = %v", err)
+			}
+			if _, err := LoadColumns(bytes.NewReader(data), "signal", -1); !errors.Is(err, ErrColumnOutOfRange) {
+				t.Errorf("LoadColumns(-1): error = %v", err)
+			}
+		})
+	}
+}
+
+func TestGetColumns_Complex(t *testing.T) {
+	matFile := &MatFile{Variables: []*types.Variable{
+		{Name: "z", Dimensions: []int{1, 3}, DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1, 2, 3}, Imag: []float64{4, 5, 6}}},
+		{Name: "s", Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"},
+	}}
+
+	got, err := matFile.GetColumns("z", 2, 0)
+	if err != nil {
+		t.Fatalf("GetColumns(z) error = %v", err)
+	}
+	z := got.Data.(*types.NumericArray)
+	if !got.IsComplex || !reflect.DeepEqual(z.Real, []float64{3, 1}) || !reflect.DeepEqual(z.Imag, []float64{6, 4}) {
+		t.Errorf("GetColumns(z) = %+v", z)
+	}
+	if _, err := matFile.GetColumns("s", 0); err == nil {
+		t.Error("char array: expected error")
+	}
+}
@@ -0,0 +1,38 @@
+package matlab
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// WithGzip writes the file gzip-compressed, for archives that store
+// results as .mat.gz files. Open and the other readers detect gzip and
+// zstd containers and decompress them transparently; MATLAB needs such
+// files decompressed first (gunzip).
+//
+// v5 output is compressed as it is written. v7.3 files are written by the
+// HDF5 library and compressed in place by Close. Failed v5 WriteVariables
+// batches cannot be rolled back in compressed files. With WithEncryption
+// the file is compressed before it is encrypted. It cannot be combined
+// with WithSignature.
+//
+// Default: uncompressed files
+//
+// Example:
+//
+//	writer, _ := matlab.Create("results.mat.gz", matlab.Version5, matlab.WithGzip())
+//	// ... write variables, Close
+//	file, _ := os.Open("results.mat.gz")
+//	matFile, err := matlab.Open(file) // Decompressed transparently
+func WithGzip() Option {
+	return func(c *config) {
+		c.gzip = true
+	}
+}
+
+// gzipFile replaces the file at path with its gzip-compressed form.
+func gzipFile(path string) error {
+	return rewriteFile(path, path, func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	})
+}
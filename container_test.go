@@ -0,0 +1,151 @@
+package matlab
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOpen_Compressed(t *testing.T) {
+	data := v5FileBytes(t)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("gzip Write() error = %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip Close() error = %v", err)
+	}
+	inputs := map[string][]byte{"gzip": gz.Bytes()}
+
+	if _, err := exec.LookPath("zstd"); err == nil {
+		cmd := exec.Command("zstd", "-q", "-c")
+		cmd.Stdin = bytes.NewReader(data)
+		zst, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("zstd error = %v", err)
+		}
+		inputs["zstd"] = zst
+	} else {
+		t.Log("zstd not found, only the checked-in zstd file is tested")
+	}
+
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			for _, r := range []io.Reader{bytes.NewReader(input), io.MultiReader(bytes.NewReader(input))} {
+				matFile, err := Open(r)
+				if err != nil {
+					t.Fatalf("Open(%T) error = %v", r, err)
+				}
+				if v := matFile.GetVariable("x"); v == nil {
+					t.Fatalf("Open(%T): variable x not found", r)
+				}
+				if got := matFile.Stats().Bytes; got != int64(len(input)) {
+					t.Errorf("Open(%T): Stats().Bytes = %d, want %d", r, got, len(input))
+				}
+			}
+			meta, err := OpenMetadata(bytes.NewReader(input))
+			if err != nil {
+				t.Fatalf("OpenMetadata() error = %v", err)
+			}
+			if len(meta.Variables) != 1 || meta.Variables[0].Name != "x" {
+				t.Errorf("OpenMetadata() variables = %v, want [x]", meta.Variables)
+			}
+		})
+	}
+}
+
+// TestOpen_ZstdFixture reads a checked-in zstd file, so zstd input is
+// tested on hosts without the zstd tool too.
+func TestOpen_ZstdFixture(t *testing.T) {
+	path := filepath.Join("testdata", "generated", "endian_le_v5.mat")
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	zst, err := os.ReadFile(path + ".zst")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !bytes.HasPrefix(zst, zstdMagic) {
+		t.Fatalf("%s.zst is not a zstd file", path)
+	}
+
+	wantFile, err := Open(bytes.NewReader(want))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for _, r := range []io.Reader{bytes.NewReader(zst), io.MultiReader(bytes.NewReader(zst))} {
+		matFile, err := Open(r)
+		if err != nil {
+			t.Fatalf("Open(%T) error = %v", r, err)
+		}
+		got, wantA := matFile.GetVariable("A"), wantFile.GetVariable("A")
+		if got == nil || !reflect.DeepEqual(got.Dimensions, wantA.Dimensions) || !reflect.DeepEqual(got.Data, wantA.Data) {
+			t.Errorf("Open(%T): A = %v, want %v", r, got, wantA)
+		}
+	}
+}
+
+func TestOpen_CompressedShort(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte("MATLAB"))
+	_ = zw.Close()
+	if _, err := Open(bytes.NewReader(gz.Bytes())); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Open(short gzip) error = %v, want ErrInvalidFormat", err)
+	}
+	if _, err := Open(bytes.NewReader(gzipMagic)); err == nil {
+		t.Error("Open(gzip magic only) succeeded")
+	}
+}
+
+// TestOpen_CompressionBomb reads containers that decompress to far more
+// than 1000 times their size, which are rejected before the v7.3 reader
+// copies them to a temporary file.
+func TestOpen_CompressionBomb(t *testing.T) {
+	header := make([]byte, headerSize)
+	copy(header, "\x89HDF\r\n\x1a\n")
+
+	// Deflate tops out near 1030:1
+	var gz bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&gz, gzip.BestCompression)
+	_, _ = zw.Write(header)
+	_, _ = zw.Write(make([]byte, 16<<20))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip Close() error = %v", err)
+	}
+
+	// A zstd frame of the header as a raw block, then run-length
+	// encoded blocks of 128 KiB zeros, 4 bytes each
+	blockHeader := func(last bool, blockType, size int) []byte {
+		h := blockType<<1 | size<<3
+		if last {
+			h |= 1
+		}
+		return []byte{byte(h), byte(h >> 8), byte(h >> 16)}
+	}
+	zst := append([]byte{}, zstdMagic...)
+	zst = append(zst, 0x00, 0x38) // No content size or checksum, 128 KiB window
+	zst = append(append(zst, blockHeader(false, 0, headerSize)...), header...)
+	for i := range 256 {
+		zst = append(append(zst, blockHeader(i == 255, 1, 128<<10)...), 0)
+	}
+
+	for name, input := range map[string][]byte{"gzip": gz.Bytes(), "zstd": zst} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Open(bytes.NewReader(input))
+			if err == nil || !strings.Contains(err.Error(), "compression ratio too high") {
+				t.Errorf("Open(%d byte bomb) error = %v, want compression ratio error", len(input), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package matlab
+
+import "github.com/scigolib/matlab/types"
+
+// Datasets returns the numeric and logical arrays of the file as
+// types.Dataset values, in file order, so analysis code written against
+// the scigolib dataset interface reads MAT-files like other formats.
+// Fields of scalar structs are included under slash-separated names, such
+// as "run42/x", as GetVariable finds them; other variables are skipped.
+//
+// Example:
+//
+//	for _, ds := range matFile.Datasets() {
+//	    if ds.DType() == "float64" {
+//	        fmt.Println(ds.Name(), ds.Shape())
+//	    }
+//	}
+func (m *MatFile) Datasets() []types.Dataset {
+	var datasets []types.Dataset
+	for _, v := range m.Variables {
+		datasets = appendDatasets(datasets, v, v.Name)
+	}
+	return datasets
+}
+
+// appendDatasets appends v, named name, or the datasets among the fields
+// of a scalar struct v to datasets.
+func appendDatasets(datasets []types.Dataset, v *types.Variable, name string) []types.Dataset {
+	if st, ok := v.Data.(*types.StructArray); ok {
+		if len(st.Elements) != 1 {
+			return datasets
+		}
+		for _, field := range st.Fields {
+			if f := st.Field(0, field); f != nil {
+				datasets = appendDatasets(datasets, f, name+"/"+field)
+			}
+		}
+		return datasets
+	}
+	if v.Name != name {
+		renamed := *v
+		renamed.Name = name
+		v = &renamed
+	}
+	if ds, err := types.AsDataset(v); err == nil {
+		datasets = append(datasets, ds)
+	}
+	return datasets
+}
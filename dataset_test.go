@@ -0,0 +1,41 @@
+package matlab
+
+import (
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestMatFile_Datasets(t *testing.T) {
+	field := func(name string, value float64) *types.Variable {
+		return &types.Variable{Name: name, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{value}}
+	}
+	m := &MatFile{Variables: []*types.Variable{
+		{Name: "x", Dimensions: []int{1, 2}, DataType: types.Int32, Data: []int32{1, 2}},
+		{Name: "label", Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"},
+		{Name: "run", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+			Fields: []string{"gain", "name"}, Dimensions: []int{1, 1},
+			Elements: [][]*types.Variable{{field("gain", 2), {Name: "name", Dimensions: []int{1, 1}, DataType: types.Char, Data: "a"}}},
+		}},
+		{Name: "runs", Dimensions: []int{1, 2}, DataType: types.Struct, Data: &types.StructArray{
+			Fields: []string{"gain"}, Dimensions: []int{1, 2},
+			Elements: [][]*types.Variable{{field("gain", 1)}, {field("gain", 2)}},
+		}},
+	}}
+
+	datasets := m.Datasets()
+	var names []string
+	for _, ds := range datasets {
+		names = append(names, ds.Name()+":"+ds.DType())
+	}
+	if len(names) != 2 || names[0] != "x:int32" || names[1] != "run/gain:float64" {
+		t.Fatalf("Datasets() = %v, want [x:int32 run/gain:float64]", names)
+	}
+	gain := make([]float64, 1)
+	if err := datasets[1].ReadInto(gain); err != nil || gain[0] != 2 {
+		t.Errorf("ReadInto() = %v, %v", gain, err)
+	}
+	if m.Variables[2].Data.(*types.StructArray).Elements[0][0].Name != "gain" {
+		t.Error("Datasets() renamed the struct field in place")
+	}
+}
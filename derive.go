@@ -0,0 +1,96 @@
+package matlab
+
+import (
+	"fmt"
+
+	"github.com/scigolib/matlab/expr"
+	"github.com/scigolib/matlab/types"
+)
+
+// derivedVariable is a variable computed at Close (WithDerived).
+type derivedVariable struct {
+	name       string
+	expression string
+	parsed     *expr.Expr
+}
+
+// WithDerived writes a variable computed element-wise from others when the
+// writer is closed, such as a power channel from voltage and current. The
+// expression uses MATLAB syntax and is evaluated by the expr package over
+// the top-level variables written to the file; derived variables are
+// written in the order of their options, and can use the ones before them.
+// Create returns an error wrapping expr.ErrSyntax for an invalid
+// expression, and Close one naming the derived variable if it cannot be
+// computed, for example because a variable it uses was not written.
+//
+// The writer keeps the variables that derived ones use until Close, so
+// they must not be modified after they are written. Derived variables are
+// written even if the file has reached the limits of WithMaxVariables or
+// WithMaxFileSize.
+//
+// Example:
+//
+//	writer, _ := matlab.Create("run.mat", matlab.Version5,
+//	    matlab.WithDerived("p", "u .* i"),
+//	    matlab.WithDerived("overload", "p > 1500"))
+func WithDerived(name, expression string) Option {
+	return func(c *config) {
+		c.derived = append(c.derived, derivedVariable{name: name, expression: expression})
+	}
+}
+
+// parseDerived parses the expressions of WithDerived.
+func parseDerived(derived []derivedVariable) error {
+	for i := range derived {
+		parsed, err := expr.Parse(derived[i].expression)
+		if err != nil {
+			return fmt.Errorf("derived variable %s: %w", derived[i].name, err)
+		}
+		derived[i].parsed = parsed
+	}
+	return nil
+}
+
+// recordOperand keeps a variable written to the file if a derived variable
+// uses it.
+func (w *MatFileWriter) recordOperand(v *types.Variable) {
+	for _, d := range w.derived {
+		for _, name := range d.parsed.Variables() {
+			if name != v.Name {
+				continue
+			}
+			if w.operands == nil {
+				w.operands = make(map[string]*types.Variable)
+			}
+			w.operands[name] = v
+			return
+		}
+	}
+}
+
+// writeDerived computes and writes the variables of WithDerived, once.
+func (w *MatFileWriter) writeDerived() error {
+	derived := w.derived
+	w.derived = nil
+	if len(derived) == 0 || (w.v5writer == nil && w.v73writer == nil) {
+		return nil
+	}
+	w.maxVariables, w.maxFileSize = 0, 0
+	lookup := func(name string) *types.Variable { return w.operands[name] }
+	for _, d := range derived {
+		v, err := d.parsed.Eval(lookup)
+		if err != nil {
+			return fmt.Errorf("derived variable %s: %w", d.name, err)
+		}
+		v.Name = d.name
+		if err := w.WriteVariable(v); err != nil {
+			return fmt.Errorf("derived variable %s: %w", d.name, err)
+		}
+		if w.operands == nil {
+			w.operands = make(map[string]*types.Variable)
+		}
+		w.operands[d.name] = v
+	}
+	w.operands = nil
+	return nil
+}
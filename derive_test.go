@@ -0,0 +1,67 @@
+package matlab
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/expr"
+	"github.com/scigolib/matlab/types"
+)
+
+func TestWithDerived(t *testing.T) {
+	for _, version := range []Version{Version5, Version73} {
+		path := filepath.Join(t.TempDir(), "derived.mat")
+		w, err := Create(path, version,
+			WithDerived("p", "u .* i"),
+			WithDerived("high", "p > 5"),
+			WithMaxVariables(2))
+		if err != nil {
+			t.Fatalf("v%d: Create() error = %v", version, err)
+		}
+		if err := w.WriteVariable(&types.Variable{Name: "u", Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{1, 2, 3}}); err != nil {
+			t.Fatalf("v%d: WriteVariable(u) error = %v", version, err)
+		}
+		if err := w.WriteVariables(&types.Variable{Name: "i", Dimensions: []int{1, 3}, DataType: types.Int8, Data: []int8{2, 2, 2}}); err != nil {
+			t.Fatalf("v%d: WriteVariables(i) error = %v", version, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("v%d: Close() error = %v", version, err)
+		}
+
+		matFile, err := openPath(path, nil)
+		if err != nil {
+			t.Fatalf("v%d: Open() error = %v", version, err)
+		}
+		p := matFile.GetVariable("p")
+		if p == nil || !reflect.DeepEqual(p.Data, []float64{2, 4, 6}) {
+			t.Errorf("v%d: p = %+v, want [2 4 6]", version, p)
+		}
+		high := matFile.GetVariable("high")
+		if high == nil || high.DataType != types.Logical || !reflect.DeepEqual(high.Data, []bool{false, false, true}) {
+			t.Errorf("v%d: high = %+v, want logical [false false true]", version, high)
+		}
+	}
+}
+
+func TestWithDerived_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Create(filepath.Join(dir, "bad.mat"), Version5, WithDerived("p", "u .*")); !errors.Is(err, expr.ErrSyntax) {
+		t.Errorf("Create() error = %v, want expr.ErrSyntax", err)
+	}
+
+	w, err := Create(filepath.Join(dir, "missing.mat"), Version5, WithDerived("p", "u .* i"))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := w.WriteVariable(&types.Variable{Name: "u", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}); err != nil {
+		t.Fatalf("WriteVariable(u) error = %v", err)
+	}
+	if err := w.Close(); !errors.Is(err, expr.ErrUndefined) {
+		t.Errorf("Close() error = %v, want expr.ErrUndefined", err)
+	}
+	if names := fileVariables(t, filepath.Join(dir, "missing.mat")); !reflect.DeepEqual(names, []string{"u"}) {
+		t.Errorf("variables = %v, want [u]", names)
+	}
+}
@@ -0,0 +1,49 @@
+package matlab
+
+import "github.com/scigolib/matlab/internal/v5"
+
+// FormatDetails holds low-level facts about the layout of a v5 MAT-file
+// that the parsed variables do not show, for forensic and validation
+// tools.
+type FormatDetails struct {
+	HeaderVersion   uint16 // Version field of the header, 0x0100 in valid files
+	SubsystemOffset int64  // Byte offset of the subsystem data, 0 if none
+	Elements        int    // Top-level data elements, including those that are not arrays
+	PaddingBytes    int64  // Alignment padding outside compressed elements
+}
+
+// FormatDetails returns the low-level details of a v5 file, or nil for
+// v7.3 files, whose layout is described by HDF5Tree, and for files read
+// with Recover.
+//
+// Subsystem data holds the class definitions of objects such as tables
+// and strings, written by MATLAB after the variables. Padding counts the
+// bytes that align data elements to 8 bytes in the file itself; the
+// content of compressed elements is not counted.
+//
+// Example:
+//
+//	if d := matFile.FormatDetails(); d != nil && d.SubsystemOffset > 0 {
+//	    fmt.Printf("subsystem data at %#x\n", d.SubsystemOffset)
+//	}
+func (m *MatFile) FormatDetails() *FormatDetails {
+	if m.details == nil {
+		return nil
+	}
+	details := *m.details
+	return &details
+}
+
+// formatDetails converts the layout recorded by the v5 parser.
+func formatDetails(file *v5.Mat5File) *FormatDetails {
+	offset := int64(file.Header.SubsystemOffset)
+	if offset < 0 {
+		offset = 0 // Not a file offset
+	}
+	return &FormatDetails{
+		HeaderVersion:   file.Header.Version,
+		SubsystemOffset: offset,
+		Elements:        file.Elements,
+		PaddingBytes:    file.Padding,
+	}
+}
@@ -0,0 +1,56 @@
+package matlab
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestMatFile_FormatDetails(t *testing.T) {
+	dir := t.TempDir()
+	path := writeSetFile(t, dir, "a.mat", Version5,
+		&types.Variable{Name: "abcde", Dimensions: []int{1, 5}, DataType: types.Int8, Data: []int8{1, 2, 3, 4, 5}},
+		&types.Variable{Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}})
+
+	got := readMatFile(t, path).FormatDetails()
+	// Padding after the 5-byte name and data of abcde and the 1-byte name
+	// of x
+	want := FormatDetails{HeaderVersion: 0x0100, Elements: 2, PaddingBytes: 3 + 3 + 7}
+	if got == nil || *got != want {
+		t.Errorf("FormatDetails() = %+v, want %+v", got, want)
+	}
+
+	// A subsystem offset in the header
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.LittleEndian.PutUint64(data[116:124], 0x1234)
+	matFile, err := Open(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got := matFile.FormatDetails(); got == nil || got.SubsystemOffset != 0x1234 {
+		t.Errorf("FormatDetails() = %+v, want subsystem offset 0x1234", got)
+	}
+
+	v73 := writeSetFile(t, dir, "b.mat", Version73,
+		&types.Variable{Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}})
+	if got := readMatFile(t, v73).FormatDetails(); got != nil {
+		t.Errorf("FormatDetails() of a v7.3 file = %+v, want nil", got)
+	}
+}
+
+func TestMatFile_FormatDetails_Scipy(t *testing.T) {
+	// The subsystem offset is filled with spaces, and every variable is
+	// compressed, so nothing is padded
+	matFile := readMatFile(t, filepath.Join("testdata", "scipy", "inner_outer_tbl_param.mat"))
+	want := FormatDetails{HeaderVersion: 0x0100, Elements: 12}
+	if got := matFile.FormatDetails(); got == nil || *got != want {
+		t.Errorf("FormatDetails() = %+v, want %+v", got, want)
+	}
+}
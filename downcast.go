@@ -0,0 +1,136 @@
+package matlab
+
+import (
+	"math"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// WithDowncastToSingle writes double variables as single when every value
+// is within relative tolerance rtol of its single-precision value, which
+// halves their size for data that only needs plotting precision. NaN and
+// infinities convert exactly; values outside the single range, or that
+// underflow, keep a variable double unless rtol allows the error. An rtol
+// of 0 downcasts only variables that single represents exactly.
+//
+// Real and complex top-level variables are converted; struct fields and
+// sparse matrices are written as given. The variables written as single
+// are listed in Stats.Downcast with the largest relative error of each.
+//
+// Default: disabled
+//
+// Example:
+//
+//	writer, _ := matlab.Create("plot.mat", matlab.Version5, matlab.WithDowncastToSingle(1e-6))
+//	// ... write variables, Close
+//	for name, loss := range writer.Stats().Downcast {
+//	    log.Printf("%s stored as single (relative error %.2g)", name, loss)
+//	}
+func WithDowncastToSingle(rtol float64) Option {
+	return func(c *config) {
+		if rtol < 0 || math.IsNaN(rtol) {
+			rtol = 0
+		}
+		c.downcast = true
+		c.downcastTolerance = rtol
+	}
+}
+
+// prepare returns v as it is written: normalized (see normalize) and, with
+// WithDowncastToSingle, converted to single. Downcast variables are noted
+// for the statistics of the write.
+func (w *MatFileWriter) prepare(v *types.Variable) *types.Variable {
+	v = w.normalize(v)
+	if !w.downcast {
+		return v
+	}
+	single, loss, ok := downcastToSingle(v, w.downcastTolerance)
+	if !ok {
+		return v
+	}
+	if w.pendingDowncast == nil {
+		w.pendingDowncast = make(map[string]float64)
+	}
+	w.pendingDowncast[v.Name] = loss
+	return single
+}
+
+// downcastToSingle returns a copy of the double variable v with single
+// data and the largest relative error of the conversion, or ok false if v
+// is not a dense double variable or a value is not within rtol.
+func downcastToSingle(v *types.Variable, rtol float64) (single *types.Variable, loss float64, ok bool) {
+	if v.DataType != types.Double || v.IsSparse {
+		return nil, 0, false
+	}
+	copied := *v
+	copied.DataType = types.Single
+	switch data := v.Data.(type) {
+	case []float64:
+		values, loss, ok := toSingle(data, rtol)
+		if !ok {
+			return nil, 0, false
+		}
+		copied.Data = values
+		return &copied, loss, true
+	case *types.NumericArray:
+		re, ok := data.Real.([]float64)
+		if !ok {
+			return nil, 0, false
+		}
+		realValues, realLoss, ok := toSingle(re, rtol)
+		if !ok {
+			return nil, 0, false
+		}
+		array := &types.NumericArray{Real: realValues}
+		if data.Imag != nil {
+			im, ok := data.Imag.([]float64)
+			if !ok {
+				return nil, 0, false
+			}
+			imagValues, imagLoss, ok := toSingle(im, rtol)
+			if !ok {
+				return nil, 0, false
+			}
+			array.Imag = imagValues
+			realLoss = max(realLoss, imagLoss)
+		}
+		copied.Data = array
+		return &copied, realLoss, true
+	}
+	return nil, 0, false
+}
+
+// toSingle converts data to float32, returning the largest relative error
+// and ok false as soon as a value is off by more than rtol.
+func toSingle(data []float64, rtol float64) (values []float32, loss float64, ok bool) {
+	values = make([]float32, len(data))
+	for i, x := range data {
+		f := float32(x)
+		values[i] = f
+		if x == float64(f) || math.IsNaN(x) {
+			continue
+		}
+		// Overflow to infinity and underflow to zero are relative error
+		// +Inf and 1
+		relative := math.Abs(float64(f)-x) / math.Abs(x)
+		if relative > rtol {
+			return nil, 0, false
+		}
+		loss = max(loss, relative)
+	}
+	return values, loss, true
+}
+
+// commitDowncast moves the downcast variables of a successful write into
+// the statistics, and forgets them after a failed one.
+func (w *MatFileWriter) commitDowncast(err error) {
+	if err == nil && len(w.pendingDowncast) > 0 {
+		if w.stats.Downcast == nil {
+			w.stats.Downcast = make(map[string]float64)
+		}
+		for name, loss := range w.pendingDowncast {
+			w.stats.Downcast[name] = loss
+		}
+	}
+	w.pendingDowncast = nil
+}
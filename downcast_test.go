@@ -0,0 +1,88 @@
+package matlab
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestWithDowncastToSingle(t *testing.T) {
+	for _, version := range []Version{Version5, Version73} {
+		path := filepath.Join(t.TempDir(), "downcast.mat")
+		writer, err := Create(path, version, WithDowncastToSingle(1e-6))
+		if err != nil {
+			t.Fatalf("v%d: Create() error = %v", version, err)
+		}
+		if err := writer.WriteVariable(&types.Variable{
+			Name: "x", Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{0.1, 2, math.NaN()},
+		}); err != nil {
+			t.Fatalf("v%d: WriteVariable() error = %v", version, err)
+		}
+		if err := writer.WriteVariables(
+			&types.Variable{Name: "big", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1e300}},
+			&types.Variable{Name: "fine", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1 + 1e-12}},
+			&types.Variable{Name: "n", Dimensions: []int{1, 1}, DataType: types.Int32, Data: []int32{7}},
+		); err != nil {
+			t.Fatalf("v%d: WriteVariables() error = %v", version, err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("v%d: Close() error = %v", version, err)
+		}
+
+		downcast := writer.Stats().Downcast
+		if len(downcast) != 2 || downcast["x"] <= 0 || downcast["x"] > 1e-6 || downcast["fine"] > 1e-6 {
+			t.Errorf("v%d: Stats().Downcast = %v, want x and fine", version, downcast)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matFile, err := Open(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("v%d: Open() error = %v", version, err)
+		}
+		for name, want := range map[string]types.DataType{"x": types.Single, "fine": types.Single, "big": types.Double, "n": types.Int32} {
+			if v := matFile.GetVariable(name); v == nil || v.DataType != want {
+				t.Errorf("v%d: %s = %v, want %v", version, name, v, want)
+			}
+		}
+		if x, ok := matFile.GetVariable("x").Data.([]float32); !ok || x[0] != float32(0.1) || !math.IsNaN(float64(x[2])) {
+			t.Errorf("v%d: x = %v", version, matFile.GetVariable("x").Data)
+		}
+	}
+}
+
+func TestDowncastToSingle(t *testing.T) {
+	tests := []struct {
+		name string
+		v    *types.Variable
+		rtol float64
+		ok   bool
+	}{
+		{"exact", &types.Variable{DataType: types.Double, Data: []float64{1, 0.5, math.Inf(-1)}}, 0, true},
+		{"inexact", &types.Variable{DataType: types.Double, Data: []float64{0.1}}, 0, false},
+		{"within", &types.Variable{DataType: types.Double, Data: []float64{0.1}}, 1e-7, true},
+		{"underflow", &types.Variable{DataType: types.Double, Data: []float64{1e-60}}, 1e-3, false},
+		{"complex", &types.Variable{DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1}, Imag: []float64{0.1}}}, 1e-7, true},
+		{"complex inexact", &types.Variable{DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1}, Imag: []float64{0.1}}}, 0, false},
+		{"sparse", &types.Variable{DataType: types.Double, IsSparse: true, Data: []float64{1}}, 1, false},
+		{"int", &types.Variable{DataType: types.Int32, Data: []int32{1}}, 1, false},
+	}
+	for _, tt := range tests {
+		single, _, ok := downcastToSingle(tt.v, tt.rtol)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if ok && (single.DataType != types.Single || tt.v.DataType != types.Double) {
+			t.Errorf("%s: DataType = %v, original %v", tt.name, single.DataType, tt.v.DataType)
+		}
+	}
+}
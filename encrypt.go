@@ -0,0 +1,277 @@
+package matlab
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrDecryption indicates an encrypted MAT-file that cannot be decrypted:
+// the key is wrong, or the data was modified or truncated.
+var ErrDecryption = errors.New("cannot decrypt MAT-file: wrong key or corrupted data")
+
+// Encrypted files consist of a header followed by the MAT-file in
+// segments sealed with AES-GCM, in the manner of the STREAM construction:
+//
+//	magic        8 bytes  "MATENC\x00\x01"
+//	segment size 4 bytes  big-endian plaintext bytes per segment
+//	nonce prefix 7 bytes  random
+//	segments              ciphertext and 16-byte tag each
+//
+// The nonce of segment i is the prefix, i as a big-endian uint32 and 1 for
+// the last segment or 0 for the others, so segments cannot be reordered,
+// dropped or truncated without failing authentication. The header is the
+// additional data of every segment.
+const (
+	encMagic         = "MATENC\x00\x01"
+	encHeaderSize    = len(encMagic) + 4 + encPrefixSize
+	encPrefixSize    = 7
+	encSegmentSize   = 64 << 10
+	encMaxSegmentLen = 16 << 20 // Largest segment size accepted on read
+)
+
+// IsEncrypted reports whether header, the first bytes of a file, starts
+// an encrypted MAT-file.
+func IsEncrypted(header []byte) bool {
+	return bytes.HasPrefix(header, []byte(encMagic))
+}
+
+// OpenEncrypted decrypts and reads a MAT-file written with WithEncryption,
+// as Open does for plain files. key is the AES key given to
+// WithEncryption (16, 24 or 32 bytes).
+//
+// The file is decrypted as it is read, so v5 files are never held or
+// stored in plain form beyond what Open itself keeps. v7.3 files are
+// copied to a temporary file for the HDF5 library (see WithTempDir),
+// which holds the plain text until Open returns.
+//
+// A wrong key or modified data returns an error wrapping ErrDecryption.
+//
+// Example:
+//
+//	file, _ := os.Open("results.mat.enc")
+//	defer file.Close()
+//	matFile, err := matlab.OpenEncrypted(file, key)
+func OpenEncrypted(r io.Reader, key []byte, opts ...OpenOption) (*MatFile, error) {
+	dr, err := newDecryptReader(r, key)
+	if err != nil {
+		return nil, err
+	}
+	return Open(dr, opts...)
+}
+
+// newGCM returns the AES-GCM cipher for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// segmentNonce returns the nonce of segment i.
+func segmentNonce(prefix []byte, i uint32, last bool) []byte {
+	nonce := make([]byte, 0, 12)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, i)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// encryptWriter seals what is written to it into segments written to w.
+// Close seals the last segment; it does not close w.
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	segment uint32
+}
+
+// newEncryptWriter writes the header of an encrypted file to w and
+// returns a writer for its content.
+func newEncryptWriter(w io.Writer, key []byte) (*encryptWriter, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, encHeaderSize)
+	copy(header, encMagic)
+	binary.BigEndian.PutUint32(header[len(encMagic):], encSegmentSize)
+	if _, err := rand.Read(header[len(encMagic)+4:]); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, aead: aead, header: header, buf: make([]byte, 0, encSegmentSize)}, nil
+}
+
+// Write implements io.Writer. A segment is sealed once it is full and
+// more data follows, so the last segment is only known at Close.
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if len(e.buf) == encSegmentSize {
+			if err := e.seal(false); err != nil {
+				return n - len(p), err
+			}
+		}
+		m := copy(e.buf[len(e.buf):encSegmentSize], p)
+		e.buf = e.buf[:len(e.buf)+m]
+		p = p[m:]
+	}
+	return n, nil
+}
+
+// Close seals the last segment.
+func (e *encryptWriter) Close() error {
+	return e.seal(true)
+}
+
+// seal writes the buffered segment.
+func (e *encryptWriter) seal(last bool) error {
+	if e.segment == ^uint32(0) {
+		return errors.New("encrypted file too large")
+	}
+	nonce := segmentNonce(e.header[len(encMagic)+4:], e.segment, last)
+	sealed := e.aead.Seal(nil, nonce, e.buf, e.header)
+	e.segment++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(sealed)
+	return err
+}
+
+// decryptReader opens the segments of an encrypted file as they are
+// read.
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	sealed  []byte // Buffer for one sealed segment
+	plain   []byte // Unread plain text of the current segment
+	segment uint32
+	done    bool // Last segment opened
+}
+
+// newDecryptReader reads the header of an encrypted file from r.
+func newDecryptReader(r io.Reader, key []byte) (*decryptReader, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, encHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: reading encryption header: %w", ErrInvalidFormat, err)
+	}
+	if !IsEncrypted(header) {
+		return nil, fmt.Errorf("%w: not an encrypted MAT-file", ErrInvalidFormat)
+	}
+	size := binary.BigEndian.Uint32(header[len(encMagic):])
+	if size == 0 || size > encMaxSegmentLen {
+		return nil, fmt.Errorf("%w: segment size %d", ErrInvalidFormat, size)
+	}
+	return &decryptReader{
+		r:      bufio.NewReader(r),
+		aead:   aead,
+		header: header,
+		sealed: make([]byte, int(size)+aead.Overhead()),
+	}, nil
+}
+
+// Read implements io.Reader.
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// open reads and opens the next segment. A segment is the last one if it
+// is short or nothing follows it.
+func (d *decryptReader) open() error {
+	n, err := io.ReadFull(d.r, d.sealed)
+	last := false
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		last = true
+	case err != nil:
+		return err
+	default:
+		if _, peekErr := d.r.Peek(1); errors.Is(peekErr, io.EOF) {
+			last = true
+		}
+	}
+	nonce := segmentNonce(d.header[len(encMagic)+4:], d.segment, last)
+	plain, openErr := d.aead.Open(d.sealed[:0], nonce, d.sealed[:n], d.header)
+	if openErr != nil {
+		return fmt.Errorf("%w (segment %d)", ErrDecryption, d.segment)
+	}
+	d.plain = plain
+	d.segment++
+	d.done = last
+	return nil
+}
+
+// encryptFileTo writes the encrypted form of the file at src to dst,
+// through a temporary file in the directory of dst.
+func encryptFileTo(src, dst string, key []byte) error {
+	return rewriteFile(src, dst, func(w io.Writer) (io.WriteCloser, error) {
+		return newEncryptWriter(w, key)
+	})
+}
+
+// rewriteFile replaces the file at target, which may be path, with the
+// contents of path written through the writer wrap returns, by way of a
+// temporary file in the same directory. Closing the wrapping writer must
+// not close w.
+func rewriteFile(path, target string, wrap func(w io.Writer) (io.WriteCloser, error)) (err error) {
+	//nolint:gosec // G304: path is the file just written
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close() //nolint:errcheck // Read-only file
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	dst, err := wrap(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
@@ -0,0 +1,106 @@
+package matlab
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	// Larger than one segment, so files span several
+	big := &types.Variable{Name: "x", Dimensions: []int{1, 20000}, DataType: types.Double, Data: make([]float64, 20000)}
+	for i := range big.Data.([]float64) {
+		big.Data.([]float64)[i] = float64(i)
+	}
+	label := &types.Variable{Name: "label", Dimensions: []int{1, 6}, DataType: types.Char, Data: "secret"}
+
+	for _, version := range []Version{Version5, Version73} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "enc.mat")
+		given := bytes.Clone(key)
+		writer, err := Create(path, version, WithEncryption(given))
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		clear(given) // The writer keeps its own copy
+		if err := writer.WriteVariables(big, label); err != nil {
+			t.Fatalf("WriteVariables() error = %v", err)
+		}
+		// Plain text never reaches the destination
+		if partial, err := os.ReadFile(path); err == nil && bytes.Contains(partial, []byte("label")) {
+			t.Errorf("version %d: destination holds plain text before Close", version)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+			t.Errorf("version %d: files left after Close: %v", version, entries)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(data) || bytes.Contains(data, []byte("label")) {
+			t.Fatalf("version %d: file is not encrypted", version)
+		}
+		if _, err := Open(bytes.NewReader(data)); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("version %d: Open() error = %v, want ErrInvalidFormat", version, err)
+		}
+
+		matFile, err := OpenEncrypted(bytes.NewReader(data), key)
+		if err != nil {
+			t.Fatalf("version %d: OpenEncrypted() error = %v", version, err)
+		}
+		got := matFile.GetVariable("x")
+		if got == nil || got.Data.([]float64)[19999] != 19999 || matFile.GetVariable("label").Data != "secret" {
+			t.Errorf("version %d: variables = %v", version, matFile.Variables)
+		}
+
+		wrongKey := bytes.Repeat([]byte{8}, 32)
+		if _, err := OpenEncrypted(bytes.NewReader(data), wrongKey); !errors.Is(err, ErrDecryption) {
+			t.Errorf("version %d: OpenEncrypted(wrong key) error = %v", version, err)
+		}
+		tampered := append([]byte(nil), data...)
+		tampered[len(tampered)/2] ^= 1
+		if _, err := OpenEncrypted(bytes.NewReader(tampered), key); !errors.Is(err, ErrDecryption) {
+			t.Errorf("version %d: OpenEncrypted(tampered) error = %v", version, err)
+		}
+		// Dropping the last segment leaves a non-final one at the end
+		truncated := data[:encHeaderSize+encSegmentSize+16]
+		if _, err := OpenEncrypted(bytes.NewReader(truncated), key); !errors.Is(err, ErrDecryption) {
+			t.Errorf("version %d: OpenEncrypted(truncated) error = %v", version, err)
+		}
+	}
+}
+
+func TestEncryption_Stream(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	var out bytes.Buffer
+	writer, err := NewWriter(&out, WithEncryption(key), WithChunkSize(1000))
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if err := writer.WriteVariable(&types.Variable{Name: "y", Dimensions: []int{1, 2}, DataType: types.Int8, Data: []int8{1, 2}}); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	matFile, err := OpenEncrypted(&out, key)
+	if err != nil {
+		t.Fatalf("OpenEncrypted() error = %v", err)
+	}
+	if v := matFile.GetVariable("y"); v == nil || v.Data.([]int8)[1] != 2 {
+		t.Errorf("variables = %v", matFile.Variables)
+	}
+
+	if _, err := Create(filepath.Join(t.TempDir(), "x.mat"), Version5, WithEncryption([]byte("short"))); err == nil {
+		t.Error("Create(5-byte key) error = nil")
+	}
+}
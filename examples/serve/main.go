@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/scigolib/matlab/serve"
+)
+
+// Example program serving the variables of a MAT-file over HTTP.
+//
+// Usage:
+//
+//	go run ./examples/serve -addr :8080 results.mat
+//
+// Then list the variables and fetch columns 0-9 of X as raw doubles:
+//
+//	curl localhost:8080/variables
+//	curl 'localhost:8080/variables/X?cols=0:10&format=raw' > x.bin
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: serve [-addr host:port] file.mat")
+	}
+
+	fmt.Printf("Serving %s on %s\n", flag.Arg(0), *addr)
+	//nolint:gosec // G114: example server without timeouts
+	log.Fatal(http.ListenAndServe(*addr, serve.NewHandler(flag.Arg(0))))
+}
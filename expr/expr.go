@@ -0,0 +1,453 @@
+// Package expr evaluates MATLAB expressions element-wise over variables,
+// to derive new channels from existing ones, such as a power from a
+// voltage and a current, without a numeric library.
+//
+// Expressions use MATLAB syntax: numbers, variable names, parentheses,
+// the arithmetic operators + - .* ./ .^ (and * / ^ where MATLAB applies
+// them element-wise, with a scalar operand), the comparisons == ~= < <= >
+// >=, the logical operators & | ~, the constants pi, Inf, NaN, eps, true
+// and false, and the functions
+//
+//	abs sqrt exp log log2 log10 sin cos tan asin acos atan
+//	sinh cosh tanh floor ceil round fix sign
+//	atan2 hypot mod rem min max power
+//
+// Operands are real numeric or logical variables, converted to double.
+// Their dimensions must agree, except that scalars apply to every element.
+// Results are double, or logical for comparisons and logical operators.
+// Unlike MATLAB, results that would be complex, such as sqrt(-1), are NaN.
+//
+// Example:
+//
+//	power, err := expr.Derive("p", "u .* i", matFile.GetVariable)
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// ErrSyntax indicates an expression that cannot be parsed.
+var ErrSyntax = errors.New("invalid expression")
+
+// ErrUndefined indicates a name that is neither a variable, a constant nor
+// a function.
+var ErrUndefined = errors.New("undefined name")
+
+// Lookup returns the variable with the given name, or nil if there is
+// none. MatFile.GetVariable is a Lookup.
+type Lookup func(name string) *types.Variable
+
+// Expr is a parsed expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses an expression.
+//
+// Returns an error wrapping ErrSyntax if src is not a valid expression.
+func Parse(src string) (*Expr, error) {
+	root, err := parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", src, err)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Variables returns the names of the variables the expression uses, sorted.
+// Constants such as pi are included, as a variable of that name takes
+// precedence over the constant.
+func (e *Expr) Variables() []string {
+	seen := make(map[string]bool)
+	walk(e.root, func(n node) {
+		if name, ok := n.(*nameNode); ok {
+			seen[name.name] = true
+		}
+	})
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Eval evaluates the expression with the variables returned by lookup. The
+// result has no name.
+//
+// Returns an error wrapping ErrUndefined for an unknown name or function,
+// and one wrapping types.ErrDimensionMismatch if the dimensions of the
+// operands do not agree.
+func (e *Expr) Eval(lookup Lookup) (*types.Variable, error) {
+	result, err := e.root.eval(lookup)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", e.src, err)
+	}
+	if result.logical {
+		values := make([]bool, len(result.data))
+		for i, x := range result.data {
+			values[i] = x != 0
+		}
+		return &types.Variable{Dimensions: result.dims, DataType: types.Logical, Data: values}, nil
+	}
+	return &types.Variable{Dimensions: result.dims, DataType: types.Double, Data: result.data}, nil
+}
+
+// Derive parses and evaluates an expression, and names the result.
+//
+// Example:
+//
+//	speed, err := expr.Derive("speed", "hypot(vx, vy)", matFile.GetVariable)
+func Derive(name, src string, lookup Lookup) (*types.Variable, error) {
+	e, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	v, err := e.Eval(lookup)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	v.Name = name
+	return v, nil
+}
+
+// value is an intermediate result: a double or logical array.
+type value struct {
+	data    []float64
+	dims    []int
+	logical bool
+}
+
+// scalar returns a 1x1 value.
+func scalar(x float64, logical bool) value {
+	return value{data: []float64{x}, dims: []int{1, 1}, logical: logical}
+}
+
+// node is an element of the syntax tree.
+type node interface {
+	eval(lookup Lookup) (value, error)
+}
+
+// walk calls fn for n and every node below it.
+func walk(n node, fn func(node)) {
+	fn(n)
+	switch n := n.(type) {
+	case *unaryNode:
+		walk(n.operand, fn)
+	case *binaryNode:
+		walk(n.left, fn)
+		walk(n.right, fn)
+	case *callNode:
+		for _, arg := range n.args {
+			walk(arg, fn)
+		}
+	}
+}
+
+type numberNode struct {
+	value float64
+}
+
+func (n *numberNode) eval(Lookup) (value, error) {
+	return scalar(n.value, false), nil
+}
+
+// constants are the values of names that are not variables.
+var constants = map[string]value{
+	"pi":    scalar(math.Pi, false),
+	"Inf":   scalar(math.Inf(1), false),
+	"inf":   scalar(math.Inf(1), false),
+	"NaN":   scalar(math.NaN(), false),
+	"nan":   scalar(math.NaN(), false),
+	"eps":   scalar(math.Nextafter(1, 2)-1, false),
+	"true":  scalar(1, true),
+	"false": scalar(0, true),
+}
+
+type nameNode struct {
+	name string
+	pos  int
+}
+
+func (n *nameNode) eval(lookup Lookup) (value, error) {
+	v := lookup(n.name)
+	if v == nil {
+		if c, ok := constants[n.name]; ok {
+			return c, nil
+		}
+		return value{}, fmt.Errorf("%w %q at column %d", ErrUndefined, n.name, n.pos+1)
+	}
+	if v.IsComplex {
+		return value{}, fmt.Errorf("%s: complex variables are not supported", n.name)
+	}
+	double, err := v.PromoteTo(types.Double, false)
+	if err != nil {
+		return value{}, err
+	}
+	data, ok := double.Data.([]float64)
+	if !ok {
+		return value{}, fmt.Errorf("%s: data is %T, not numeric", n.name, double.Data)
+	}
+	if len(data) != numel(v.Dimensions) {
+		return value{}, fmt.Errorf("%s: data holds %d elements, want %d", n.name, len(data), numel(v.Dimensions))
+	}
+	return value{data: data, dims: v.Dimensions, logical: v.DataType == types.Logical}, nil
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+	pos     int
+}
+
+func (n *unaryNode) eval(lookup Lookup) (value, error) {
+	x, err := n.operand.eval(lookup)
+	if err != nil {
+		return value{}, err
+	}
+	switch n.op {
+	case "-":
+		return apply(func(a ...float64) float64 { return -a[0] }, false, x), nil
+	case "~":
+		return apply(func(a ...float64) float64 { return truth(a[0] == 0) }, true, x), nil
+	}
+	return apply(func(a ...float64) float64 { return a[0] }, false, x), nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+	pos         int
+}
+
+// binaryOps are the element-wise operators, and whether they return
+// logical values.
+var binaryOps = map[string]struct {
+	fn      func(a ...float64) float64
+	logical bool
+}{
+	"+":  {fn: func(a ...float64) float64 { return a[0] + a[1] }},
+	"-":  {fn: func(a ...float64) float64 { return a[0] - a[1] }},
+	".*": {fn: func(a ...float64) float64 { return a[0] * a[1] }},
+	"./": {fn: func(a ...float64) float64 { return a[0] / a[1] }},
+	".^": {fn: func(a ...float64) float64 { return math.Pow(a[0], a[1]) }},
+	"==": {fn: func(a ...float64) float64 { return truth(a[0] == a[1]) }, logical: true},
+	"~=": {fn: func(a ...float64) float64 { return truth(a[0] != a[1]) }, logical: true},
+	"<":  {fn: func(a ...float64) float64 { return truth(a[0] < a[1]) }, logical: true},
+	"<=": {fn: func(a ...float64) float64 { return truth(a[0] <= a[1]) }, logical: true},
+	">":  {fn: func(a ...float64) float64 { return truth(a[0] > a[1]) }, logical: true},
+	">=": {fn: func(a ...float64) float64 { return truth(a[0] >= a[1]) }, logical: true},
+	"&":  {fn: func(a ...float64) float64 { return truth(a[0] != 0 && a[1] != 0) }, logical: true},
+	"|":  {fn: func(a ...float64) float64 { return truth(a[0] != 0 || a[1] != 0) }, logical: true},
+}
+
+func (n *binaryNode) eval(lookup Lookup) (value, error) {
+	x, err := n.left.eval(lookup)
+	if err != nil {
+		return value{}, err
+	}
+	y, err := n.right.eval(lookup)
+	if err != nil {
+		return value{}, err
+	}
+	op := n.op
+	switch op {
+	case "*", "/", "^":
+		// Matrix operators are element-wise when MATLAB would scale
+		if (op == "*" && len(x.data) != 1 && len(y.data) != 1) ||
+			(op == "/" && len(y.data) != 1) ||
+			(op == "^" && (len(x.data) != 1 || len(y.data) != 1)) {
+			return value{}, fmt.Errorf("matrix operator %s at column %d needs scalar operands; use .%s for element-wise", op, n.pos+1, op)
+		}
+		op = "." + op
+	}
+	if _, err := broadcast(x, y); err != nil {
+		return value{}, fmt.Errorf("operator %s at column %d: %w", n.op, n.pos+1, err)
+	}
+	return apply(binaryOps[op].fn, binaryOps[op].logical, x, y), nil
+}
+
+type callNode struct {
+	name string
+	args []node
+	pos  int
+}
+
+// functions are the functions that can be called, applied element-wise.
+var functions = map[string]func(a ...float64) float64{
+	"abs":   func(a ...float64) float64 { return math.Abs(a[0]) },
+	"sqrt":  func(a ...float64) float64 { return math.Sqrt(a[0]) },
+	"exp":   func(a ...float64) float64 { return math.Exp(a[0]) },
+	"log":   func(a ...float64) float64 { return math.Log(a[0]) },
+	"log2":  func(a ...float64) float64 { return math.Log2(a[0]) },
+	"log10": func(a ...float64) float64 { return math.Log10(a[0]) },
+	"sin":   func(a ...float64) float64 { return math.Sin(a[0]) },
+	"cos":   func(a ...float64) float64 { return math.Cos(a[0]) },
+	"tan":   func(a ...float64) float64 { return math.Tan(a[0]) },
+	"asin":  func(a ...float64) float64 { return math.Asin(a[0]) },
+	"acos":  func(a ...float64) float64 { return math.Acos(a[0]) },
+	"atan":  func(a ...float64) float64 { return math.Atan(a[0]) },
+	"sinh":  func(a ...float64) float64 { return math.Sinh(a[0]) },
+	"cosh":  func(a ...float64) float64 { return math.Cosh(a[0]) },
+	"tanh":  func(a ...float64) float64 { return math.Tanh(a[0]) },
+	"floor": func(a ...float64) float64 { return math.Floor(a[0]) },
+	"ceil":  func(a ...float64) float64 { return math.Ceil(a[0]) },
+	"round": func(a ...float64) float64 { return math.Round(a[0]) },
+	"fix":   func(a ...float64) float64 { return math.Trunc(a[0]) },
+	"sign":  sign,
+	"atan2": func(a ...float64) float64 { return math.Atan2(a[0], a[1]) },
+	"hypot": func(a ...float64) float64 { return math.Hypot(a[0], a[1]) },
+	"mod":   mod,
+	"rem":   rem,
+	"min":   func(a ...float64) float64 { return extreme(a[0], a[1], math.Min) },
+	"max":   func(a ...float64) float64 { return extreme(a[0], a[1], math.Max) },
+	"power": func(a ...float64) float64 { return math.Pow(a[0], a[1]) },
+}
+
+// binaryFunctions are the functions of two arguments.
+var binaryFunctions = map[string]bool{
+	"atan2": true, "hypot": true, "mod": true, "rem": true, "min": true, "max": true, "power": true,
+}
+
+func (n *callNode) eval(lookup Lookup) (value, error) {
+	fn, ok := functions[n.name]
+	if !ok {
+		return value{}, fmt.Errorf("%w: function %q at column %d", ErrUndefined, n.name, n.pos+1)
+	}
+	want := 1
+	if binaryFunctions[n.name] {
+		want = 2
+	}
+	if len(n.args) != want {
+		return value{}, fmt.Errorf("%s at column %d takes %d arguments, got %d", n.name, n.pos+1, want, len(n.args))
+	}
+	args := make([]value, len(n.args))
+	for i, arg := range n.args {
+		var err error
+		if args[i], err = arg.eval(lookup); err != nil {
+			return value{}, err
+		}
+	}
+	if _, err := broadcast(args...); err != nil {
+		return value{}, fmt.Errorf("%s at column %d: %w", n.name, n.pos+1, err)
+	}
+	return apply(fn, false, args...), nil
+}
+
+// broadcast returns the dimensions of the result of an element-wise
+// operation on values: those of the non-scalar values, which must agree.
+func broadcast(values ...value) ([]int, error) {
+	dims := []int{1, 1}
+	shaped := false
+	for _, v := range values {
+		if len(v.data) == 1 {
+			continue
+		}
+		if shaped && !sameDims(dims, v.dims) {
+			return nil, fmt.Errorf("%w: %v and %v", types.ErrDimensionMismatch, dims, v.dims)
+		}
+		dims, shaped = v.dims, true
+	}
+	return dims, nil
+}
+
+// apply returns fn applied to the elements of values, broadcast to the
+// same dimensions.
+func apply(fn func(a ...float64) float64, logical bool, values ...value) value {
+	dims, _ := broadcast(values...)
+	result := value{data: make([]float64, numel(dims)), dims: append([]int(nil), dims...), logical: logical}
+	args := make([]float64, len(values))
+	for i := range result.data {
+		for j, v := range values {
+			if len(v.data) == 1 {
+				args[j] = v.data[0]
+			} else {
+				args[j] = v.data[i]
+			}
+		}
+		result.data[i] = fn(args...)
+	}
+	return result
+}
+
+// sameDims reports whether a and b are the same dimensions, ignoring
+// trailing singleton dimensions.
+func sameDims(a, b []int) bool {
+	for i := 0; i < max(len(a), len(b)); i++ {
+		x, y := 1, 1
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
+
+// numel returns the number of elements of an array of dimensions dims.
+func numel(dims []int) int {
+	n := 1
+	for _, d := range dims {
+		n *= d
+	}
+	return n
+}
+
+// truth returns 1 for true and 0 for false.
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// sign returns -1, 0 or 1 as MATLAB's sign does.
+func sign(a ...float64) float64 {
+	switch {
+	case a[0] > 0:
+		return 1
+	case a[0] < 0:
+		return -1
+	}
+	return a[0] // 0 or NaN
+}
+
+// mod returns a modulo b with the sign of b, as MATLAB's mod does;
+// mod(a, 0) is a.
+func mod(a ...float64) float64 {
+	if a[1] == 0 {
+		return a[0]
+	}
+	return a[0] - math.Floor(a[0]/a[1])*a[1]
+}
+
+// rem returns the remainder of a / b with the sign of a, as MATLAB's rem
+// does; rem(a, 0) is NaN.
+func rem(a ...float64) float64 {
+	return math.Mod(a[0], a[1])
+}
+
+// extreme returns fn(a, b), ignoring NaN as MATLAB's min and max do.
+func extreme(a, b float64, fn func(a, b float64) float64) float64 {
+	switch {
+	case math.IsNaN(a):
+		return b
+	case math.IsNaN(b):
+		return a
+	}
+	return fn(a, b)
+}
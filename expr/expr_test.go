@@ -0,0 +1,139 @@
+package expr
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// testVars returns a lookup of a few small variables.
+func testVars() Lookup {
+	vars := map[string]*types.Variable{
+		"u":    {Name: "u", Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{1, 2, 3}},
+		"v":    {Name: "v", Dimensions: []int{1, 3}, DataType: types.Int16, Data: []int16{4, -5, 6}},
+		"k":    {Name: "k", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{10}},
+		"col":  {Name: "col", Dimensions: []int{3, 1}, DataType: types.Double, Data: []float64{1, 2, 3}},
+		"mask": {Name: "mask", Dimensions: []int{1, 3}, DataType: types.Logical, Data: []bool{true, false, true}},
+		"z": {Name: "z", Dimensions: []int{1, 1}, DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1}, Imag: []float64{2}}},
+		"s": {Name: "s", Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"},
+	}
+	return func(name string) *types.Variable {
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return nil
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []float64
+	}{
+		{"u .* v", []float64{4, -10, 18}},
+		{"u + k", []float64{11, 12, 13}},
+		{"2*u - 1", []float64{1, 3, 5}},
+		{"u / 2", []float64{0.5, 1, 1.5}},
+		{"k ./ u", []float64{10, 5, 10.0 / 3}},
+		{"u.^2", []float64{1, 4, 9}},
+		{"2.*u", []float64{2, 4, 6}},
+		{"-2^2", []float64{-4}},
+		{"2^3^2", []float64{64}},
+		{"2^-1", []float64{0.5}},
+		{"(1 + 2) * 3", []float64{9}},
+		{"1 + 2 * 3", []float64{7}},
+		{".5e1 + 1E-1", []float64{5.1}},
+		{"abs(v)", []float64{4, 5, 6}},
+		{"max(u, 2)", []float64{2, 2, 3}},
+		{"min(NaN, u)", []float64{1, 2, 3}},
+		{"mod(v, 4)", []float64{0, 3, 2}},
+		{"rem(v, 4)", []float64{0, -1, 2}},
+		{"hypot(3, 4)", []float64{5}},
+		{"round(-2.5) + fix(-2.5) + sign(-3)", []float64{-3 - 2 - 1}},
+		{"mask .* u", []float64{1, 0, 3}},
+		{"pi", []float64{math.Pi}},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.src)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.src, err)
+		}
+		got, err := e.Eval(testVars())
+		if err != nil {
+			t.Fatalf("Eval(%q) error = %v", tt.src, err)
+		}
+		data := got.Data.([]float64)
+		if got.DataType != types.Double || len(data) != len(tt.want) {
+			t.Fatalf("Eval(%q) = %s %v, want double %v", tt.src, got.DataType, data, tt.want)
+		}
+		for i := range data {
+			if math.Abs(data[i]-tt.want[i]) > 1e-12 {
+				t.Errorf("Eval(%q) = %v, want %v", tt.src, data, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestEval_Logical(t *testing.T) {
+	got, err := Derive("flags", "u >= 2 & ~(v < 0) | mask", testVars())
+	if err != nil {
+		t.Fatalf("Derive() error = %v", err)
+	}
+	if got.Name != "flags" || got.DataType != types.Logical {
+		t.Fatalf("Derive() = %s %s, want logical flags", got.Name, got.DataType)
+	}
+	if want := []bool{true, false, true}; !reflect.DeepEqual(got.Data, want) {
+		t.Errorf("Derive() data = %v, want %v", got.Data, want)
+	}
+	if !reflect.DeepEqual(got.Dimensions, []int{1, 3}) {
+		t.Errorf("Derive() dimensions = %v, want [1 3]", got.Dimensions)
+	}
+}
+
+func TestEval_Errors(t *testing.T) {
+	tests := []struct {
+		src     string
+		wantErr error
+	}{
+		{"u + col", types.ErrDimensionMismatch},
+		{"u + missing", ErrUndefined},
+		{"frobnicate(u)", ErrUndefined},
+		{"u * v", nil},
+		{"sqrt(u, v)", nil},
+		{"z + 1", nil},
+		{"s + 1", types.ErrNotPromotable},
+	}
+	for _, tt := range tests {
+		_, err := Derive("x", tt.src, testVars())
+		if err == nil {
+			t.Errorf("Derive(%q) error = nil, want an error", tt.src)
+			continue
+		}
+		if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Errorf("Derive(%q) error = %v, want %v", tt.src, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, src := range []string{"", "u +", "(u", "u v", "max(u,", "u # 2", "u ** 2", "1.2.3"} {
+		if _, err := Parse(src); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Parse(%q) error = %v, want ErrSyntax", src, err)
+		}
+	}
+}
+
+func TestExpr_Variables(t *testing.T) {
+	e, err := Parse("sqrt(u.^2 + v.^2) * pi - u")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got, want := e.Variables(), []string{"pi", "u", "v"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Variables() = %v, want %v", got, want)
+	}
+}
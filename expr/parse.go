@@ -0,0 +1,265 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// token is a lexical token of an expression.
+type token struct {
+	kind byte // 'n' number, 'i' identifier, 'o' operator or punctuation, 0 end
+	text string
+	num  float64
+	pos  int // Byte offset in the source
+}
+
+// operators lists the operators and punctuation, longest first.
+var operators = []string{
+	".*", "./", ".^", "==", "~=", "<=", ">=",
+	"+", "-", "*", "/", "^", "<", ">", "&", "|", "~", "(", ")", ",",
+}
+
+// tokenize splits src into tokens, ending with a token of kind 0.
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c >= '0' && c <= '9' || (c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9'):
+			end := scanNumber(src, i)
+			x, err := strconv.ParseFloat(src[i:end], 64)
+			if err != nil {
+				return nil, syntaxError(i, "invalid number %q", src[i:end])
+			}
+			tokens = append(tokens, token{kind: 'n', text: src[i:end], num: x, pos: i})
+			i = end
+		case c == '_' || c < 128 && unicode.IsLetter(c):
+			end := i + 1
+			for end < len(src) && (src[end] == '_' || src[end] < 128 && (unicode.IsLetter(rune(src[end])) || unicode.IsDigit(rune(src[end])))) {
+				end++
+			}
+			tokens = append(tokens, token{kind: 'i', text: src[i:end], pos: i})
+			i = end
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, syntaxError(i, "unexpected character %q", src[i])
+			}
+			tokens = append(tokens, token{kind: 'o', text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{pos: len(src)}), nil
+}
+
+// scanNumber returns the end of the number literal starting at i: digits
+// with an optional fraction and exponent.
+func scanNumber(src string, i int) int {
+	digits := func(i int) int {
+		for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+			i++
+		}
+		return i
+	}
+	i = digits(i)
+	// A dot starting an element-wise operator, as in 2.*x, is not a fraction
+	if i < len(src) && src[i] == '.' && (i+1 >= len(src) || !strings.ContainsRune("*/^", rune(src[i+1]))) {
+		i = digits(i + 1)
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		if j < len(src) && src[j] >= '0' && src[j] <= '9' {
+			i = digits(j)
+		}
+	}
+	return i
+}
+
+// parser builds the syntax tree of an expression by recursive descent,
+// one function per precedence level of MATLAB's operators.
+type parser struct {
+	tokens []token
+	next   int
+}
+
+// parse returns the syntax tree of src.
+func parse(src string) (node, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != 0 {
+		return nil, syntaxError(t.pos, "unexpected %q", t.text)
+	}
+	return n, nil
+}
+
+// peek returns the next token without consuming it.
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+// accept consumes the next token and returns its text if it is one of
+// the operators ops.
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != 'o' {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.next++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// binaryLevel parses operands joined by the left-associative operators
+// ops, with operands parsed by operand.
+func (p *parser) binaryLevel(operand func() (node, error), ops ...string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		pos := p.peek().pos
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right, pos: pos}
+	}
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.binaryLevel(p.parseAnd, "|")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.binaryLevel(p.parseComparison, "&")
+}
+
+func (p *parser) parseComparison() (node, error) {
+	return p.binaryLevel(p.parseSum, "==", "~=", "<", "<=", ">", ">=")
+}
+
+func (p *parser) parseSum() (node, error) {
+	return p.binaryLevel(p.parseProduct, "+", "-")
+}
+
+func (p *parser) parseProduct() (node, error) {
+	return p.binaryLevel(p.parseUnary, "*", "/", ".*", "./")
+}
+
+// parseUnary parses prefix operators, which bind less tightly than
+// powers: -2^2 is -(2^2).
+func (p *parser) parseUnary() (node, error) {
+	pos := p.peek().pos
+	if op, ok := p.accept("+", "-", "~"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand, pos: pos}, nil
+	}
+	return p.parsePower()
+}
+
+// parsePower parses left-associative powers, as MATLAB evaluates 2^3^2
+// as (2^3)^2. Exponents may carry a sign, as in 2^-1.
+func (p *parser) parsePower() (node, error) {
+	return p.binaryLevel(p.parsePrimary, "^", ".^")
+}
+
+// parsePrimary parses a number, a variable, a function call or a
+// parenthesized expression; after a power operator it also accepts
+// prefix operators.
+func (p *parser) parsePrimary() (node, error) {
+	t := p.peek()
+	switch t.kind {
+	case 'n':
+		p.next++
+		return &numberNode{value: t.num}, nil
+	case 'i':
+		p.next++
+		if _, ok := p.accept("("); !ok {
+			return &nameNode{name: t.text, pos: t.pos}, nil
+		}
+		call := &callNode{name: t.text, pos: t.pos}
+		if _, ok := p.accept(")"); ok {
+			return call, nil
+		}
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if _, ok := p.accept(")"); ok {
+				return call, nil
+			}
+			if _, ok := p.accept(","); !ok {
+				return nil, p.unexpected("',' or ')'")
+			}
+		}
+	case 'o':
+		if _, ok := p.accept("("); ok {
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, p.unexpected("')'")
+			}
+			return n, nil
+		}
+		if p.next > 0 && (p.tokens[p.next-1].text == "^" || p.tokens[p.next-1].text == ".^") {
+			if op, ok := p.accept("+", "-", "~"); ok {
+				operand, err := p.parsePrimary()
+				if err != nil {
+					return nil, err
+				}
+				return &unaryNode{op: op, operand: operand, pos: t.pos}, nil
+			}
+		}
+	}
+	return nil, p.unexpected("an operand")
+}
+
+// unexpected returns a syntax error for the next token, which is not
+// what the parser wanted.
+func (p *parser) unexpected(want string) error {
+	t := p.peek()
+	if t.kind == 0 {
+		return syntaxError(t.pos, "expected %s at end of expression", want)
+	}
+	return syntaxError(t.pos, "expected %s, found %q", want, t.text)
+}
+
+// syntaxError returns an error wrapping ErrSyntax at byte offset pos.
+func syntaxError(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("%w at column %d: %s", ErrSyntax, pos+1, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,42 @@
+package matlab
+
+// Features reports the format features a MAT-file was found to use.
+//
+// Together with saveFormats it tells which MATLAB save option could have
+// produced the file, and so which MATLAB releases can load it.
+type Features struct {
+	Compressed   bool // Variables are zlib-compressed (v5 miCOMPRESSED elements)
+	UnicodeChars bool // Character data is stored as UTF-8, UTF-16 or UTF-32
+	HDF5         bool // The file is an HDF5 container (v7.3)
+}
+
+// saveFormats is the compatibility matrix of MATLAB save options, oldest
+// first, and the features files written with each may use.
+var saveFormats = []struct {
+	flag string
+	Features
+}{
+	{"-v6", Features{}},
+	{"-v7", Features{Compressed: true, UnicodeChars: true}},
+	{"-v7.3", Features{Compressed: true, UnicodeChars: true, HDF5: true}},
+}
+
+// SaveFlag returns the oldest MATLAB save option ("-v6", "-v7" or
+// "-v7.3") whose files may use all of the detected features.
+//
+// Example:
+//
+//	mat, _ := matlab.Open(file)
+//	if mat.Features.SaveFlag() != "-v6" {
+//	    log.Println("file needs MATLAB 7 or later")
+//	}
+func (f Features) SaveFlag() string {
+	for _, format := range saveFormats {
+		if (!f.Compressed || format.Compressed) &&
+			(!f.UnicodeChars || format.UnicodeChars) &&
+			(!f.HDF5 || format.HDF5) {
+			return format.flag
+		}
+	}
+	return saveFormats[len(saveFormats)-1].flag
+}
@@ -0,0 +1,55 @@
+package matlab
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFeatures_SaveFlag(t *testing.T) {
+	tests := []struct {
+		features Features
+		want     string
+	}{
+		{Features{}, "-v6"},
+		{Features{UnicodeChars: true}, "-v7"},
+		{Features{Compressed: true}, "-v7"},
+		{Features{HDF5: true}, "-v7.3"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.features.SaveFlag(); got != tt.want {
+			t.Errorf("%+v.SaveFlag() = %q, want %q", tt.features, got, tt.want)
+		}
+	}
+}
+
+// TestOpen_Features tests feature detection on MATLAB and generated files.
+func TestOpen_Features(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join("testdata", "scipy", "testdouble_7.4_GLNX86.mat"), "-v7"},
+		{filepath.Join("testdata", "generated", "endian_le_v5.mat"), "-v6"},
+		{filepath.Join("testdata", "generated", "simple_double.mat"), "-v7.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.path), func(t *testing.T) {
+			file, err := os.Open(tt.path)
+			if err != nil {
+				t.Fatalf("Failed to open: %v", err)
+			}
+			defer file.Close()
+
+			matFile, err := Open(file)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			if got := matFile.Features.SaveFlag(); got != tt.want {
+				t.Errorf("SaveFlag() = %q, want %q (features %+v)", got, tt.want, matFile.Features)
+			}
+		})
+	}
+}
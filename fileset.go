@@ -0,0 +1,319 @@
+package matlab
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// ErrSetMismatch indicates a variable of a FileSet whose parts cannot be
+// concatenated: their shapes differ, or their classes have no common
+// class.
+var ErrSetMismatch = errors.New("variable differs between files of the set")
+
+// FileSet presents several MAT-files as one: each variable that every
+// file holds, with the same size in all dimensions but one, reads as the
+// concatenation of its parts along that dimension. This
+// is how simulation output split into chunk files is usually organized,
+// with one file per time window.
+//
+// Only metadata is read when the set is opened. Variable reads the parts
+// of one variable, and ReadRange only the files that hold the requested
+// range, so a window of a long run costs no more than the files it spans.
+// Numeric and logical arrays, real or complex, can be concatenated; parts
+// of different classes are promoted to their common class (see
+// types.PromoteType), and to complex if any part is complex. Other classes
+// are left out of the set.
+//
+// Example:
+//
+//	set, err := matlab.OpenSet(1, "run_000.mat", "run_001.mat", "run_002.mat")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	// Columns 5000 to 5999 of the 3xN state history, from the files
+//	// holding them
+//	window, err := set.ReadRange("x", 5000, 1000)
+type FileSet struct {
+	dim   int
+	paths []string
+
+	versions  []string                         // MAT-file version of each file
+	variables []*types.VariableInfo            // Concatenated variables, in first-file order
+	parts     map[string][]*types.VariableInfo // Parts of each variable, by file
+	mismatch  map[string]error                 // Variables left out, and why
+	payloads  []*payloadCache                  // Decompressed data of each file, nil entries without a cache
+}
+
+// OpenSet opens the files at paths as a FileSet concatenating along the
+// zero-based dimension dim: 0 stacks rows, 1 appends columns. Arrays with
+// fewer dimensions are treated as having trailing dimensions of size 1.
+// Only the metadata of the files is read.
+//
+// Example:
+//
+//	paths, _ := filepath.Glob("out/chunk_*.mat")
+//	set, err := matlab.OpenSet(1, paths...)
+func OpenSet(dim int, paths ...string) (*FileSet, error) {
+	if dim < 0 {
+		return nil, fmt.Errorf("invalid concatenation dimension %d", dim)
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("file set has no files")
+	}
+	s := &FileSet{
+		dim:      dim,
+		paths:    append([]string(nil), paths...),
+		versions: make([]string, len(paths)),
+		payloads: make([]*payloadCache, len(paths)),
+		parts:    make(map[string][]*types.VariableInfo),
+		mismatch: make(map[string]error),
+	}
+
+	var names []string
+	for i, path := range paths {
+		meta, err := readMetadata(path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		s.versions[i] = meta.Version
+		for _, info := range meta.Variables {
+			parts, ok := s.parts[info.Name]
+			if !ok {
+				if i > 0 {
+					continue // Missing from an earlier file
+				}
+				names = append(names, info.Name)
+			}
+			if len(parts) == i {
+				s.parts[info.Name] = append(parts, info)
+			}
+		}
+	}
+
+	for _, name := range names {
+		parts := s.parts[name]
+		if len(parts) != len(paths) {
+			delete(s.parts, name)
+			continue
+		}
+		info, err := s.concatInfo(parts)
+		if err != nil {
+			s.mismatch[name] = err
+			delete(s.parts, name)
+			continue
+		}
+		s.variables = append(s.variables, info)
+	}
+	return s, nil
+}
+
+// SetDecompressionCache keeps up to maxBytes of decompressed data for
+// each file of the set, as WithDecompressionCache does for Cache, so
+// overlapping or repeated reads of compressed v5 variables inflate them
+// once. A budget of 0 removes the caches.
+//
+// Example:
+//
+//	set.SetDecompressionCache(32 << 20)
+//	for start := 0; start < set.Len("x"); start += 1000 {
+//	    window, err := set.ReadRange("x", start, 1000)
+//	    // ...
+//	}
+func (s *FileSet) SetDecompressionCache(maxBytes int64) {
+	for i := range s.payloads {
+		s.payloads[i] = newPayloadCache(maxBytes)
+	}
+}
+
+// concatInfo describes the concatenation of the parts of a variable.
+func (s *FileSet) concatInfo(parts []*types.VariableInfo) (*types.VariableInfo, error) {
+	first := parts[0]
+	if !concatenable(first.DataType) || first.IsSparse {
+		return nil, fmt.Errorf("%w: %s %s arrays cannot be concatenated", ErrSetMismatch, first.Name, first.DataType)
+	}
+	info := &types.VariableInfo{
+		Name:      first.Name,
+		DataType:  first.DataType,
+		IsComplex: first.IsComplex,
+		Offset:    -1,
+	}
+	shapes := make([][]int, len(parts))
+	for i, part := range parts {
+		class, err := types.PromoteType(info.DataType, part.DataType)
+		if err != nil || part.IsSparse {
+			return nil, fmt.Errorf("%w: %s is %s in %s and %s in %s",
+				ErrSetMismatch, first.Name, describeClass(first), s.paths[0], describeClass(part), s.paths[i])
+		}
+		info.DataType = class
+		info.IsComplex = info.IsComplex || part.IsComplex
+		if _, err := types.ConcatDims(s.dim, first.Dimensions, part.Dimensions); err != nil {
+			return nil, fmt.Errorf("%w: %s is %v in %s and %v in %s",
+				ErrSetMismatch, first.Name, first.Dimensions, s.paths[0], part.Dimensions, s.paths[i])
+		}
+		shapes[i] = part.Dimensions
+		info.Compressed = info.Compressed || part.Compressed
+		info.Size += part.Size
+		info.UncompressedSize += part.UncompressedSize
+	}
+	dims, err := types.ConcatDims(s.dim, shapes...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrSetMismatch, first.Name, err)
+	}
+	info.Dimensions = dims
+	return info, nil
+}
+
+// describeClass returns the class of a variable, qualified if complex.
+func describeClass(info *types.VariableInfo) string {
+	if info.IsComplex {
+		return "complex " + info.DataType.String()
+	}
+	return info.DataType.String()
+}
+
+// Paths returns the files of the set, in concatenation order.
+func (s *FileSet) Paths() []string {
+	return append([]string(nil), s.paths...)
+}
+
+// Variables returns the variables of the set with their concatenated
+// dimensions and sizes, in the order of the first file. Offset is -1.
+func (s *FileSet) Variables() []*types.VariableInfo {
+	return s.variables
+}
+
+// Len returns the size of variable name along the concatenation
+// dimension, or 0 if the set has no such variable.
+func (s *FileSet) Len(name string) int {
+	for _, info := range s.variables {
+		if info.Name == name {
+			return info.Dimensions[s.dim]
+		}
+	}
+	return 0
+}
+
+// Variable reads variable name from every file and returns the
+// concatenation of its parts.
+//
+// Returns ErrVariableNotFound if a file lacks the variable and
+// ErrSetMismatch if its parts cannot be concatenated.
+func (s *FileSet) Variable(name string) (*types.Variable, error) {
+	parts, err := s.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.ReadRange(name, 0, s.total(parts))
+}
+
+// ReadRange returns count slices of variable name along the concatenation
+// dimension, starting at the zero-based index start, reading only the
+// files that hold them.
+//
+// Example:
+//
+//	// The last 100 samples of a 1xN signal
+//	tail, err := set.ReadRange("signal", set.Len("signal")-100, 100)
+func (s *FileSet) ReadRange(name string, start, count int) (*types.Variable, error) {
+	parts, err := s.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if total := s.total(parts); start < 0 || count < 0 || start+count > total {
+		return nil, fmt.Errorf("range [%d, %d) of %s is outside [0, %d)", start, start+count, name, total)
+	}
+
+	var pieces []*types.Variable
+	offset := 0 // Index of the part's first slice in the set
+	for i, part := range parts {
+		n := dimSize(part.Dimensions, s.dim)
+		lo, hi := max(start, offset)-offset, min(start+count, offset+n)-offset
+		offset += n
+		if lo >= hi {
+			continue
+		}
+		v, err := s.readPart(i, part)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.paths[i], err)
+		}
+		if lo > 0 || hi < n {
+			if v, err = sliceAlong(v, s.dim, lo, hi-lo); err != nil {
+				return nil, fmt.Errorf("%s: %w", s.paths[i], err)
+			}
+		}
+		pieces = append(pieces, v)
+	}
+	if len(pieces) == 0 {
+		// An empty range still has the shape of the variable
+		v, err := s.readPart(0, parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.paths[0], err)
+		}
+		return sliceAlong(v, s.dim, 0, 0)
+	}
+	return types.Concat(s.dim, pieces...)
+}
+
+// lookup returns the parts of variable name.
+func (s *FileSet) lookup(name string) ([]*types.VariableInfo, error) {
+	if err, ok := s.mismatch[name]; ok {
+		return nil, err
+	}
+	parts, ok := s.parts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not in every file of the set", ErrVariableNotFound, name)
+	}
+	return parts, nil
+}
+
+// total returns the concatenated size of parts along the set's dimension.
+func (s *FileSet) total(parts []*types.VariableInfo) int {
+	n := 0
+	for _, part := range parts {
+		n += dimSize(part.Dimensions, s.dim)
+	}
+	return n
+}
+
+// readPart reads the part of a variable stored in the i-th file.
+func (s *FileSet) readPart(i int, info *types.VariableInfo) (*types.Variable, error) {
+	if s.versions[i] != "7.3" {
+		return readV5Variable(s.paths[i], info, nil, s.payloads[i])
+	}
+	matFile, err := openPath(s.paths[i], nil)
+	if err != nil {
+		return nil, err
+	}
+	v := matFile.GetVariable(info.Name)
+	if v == nil {
+		return nil, fmt.Errorf("%w: %s", ErrVariableNotFound, info.Name)
+	}
+	return v, nil
+}
+
+// concatenable reports whether arrays of class t can be concatenated.
+func concatenable(t types.DataType) bool {
+	return t <= types.Uint64 || t == types.Logical
+}
+
+// dimSize returns the size of dimension dim of an array with dimensions
+// dims, 1 past its last dimension.
+func dimSize(dims []int, dim int) int {
+	if dim < len(dims) {
+		return dims[dim]
+	}
+	return 1
+}
+
+// sliceAlong returns count slices of v along the zero-based dimension
+// dim, starting at start.
+func sliceAlong(v *types.Variable, dim, start, count int) (*types.Variable, error) {
+	ranges := make([]types.Range, dim+1)
+	for i := range dim {
+		ranges[i] = types.Range{Stop: dimSize(v.Dimensions, i)}
+	}
+	ranges[dim] = types.Range{Start: start, Stop: start + count}
+	return types.NewVariableFromSlice(v, ranges)
+}
@@ -0,0 +1,167 @@
+package matlab
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// writeSetFile writes vars to a new file in dir.
+func writeSetFile(t *testing.T, dir, name string, version Version, vars ...*types.Variable) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	w, err := Create(path, version)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := w.WriteVariables(vars...); err != nil {
+		t.Fatalf("WriteVariables() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return path
+}
+
+func TestFileSet(t *testing.T) {
+	dir := t.TempDir()
+	matrix := func(cols int, data ...float64) *types.Variable {
+		return &types.Variable{Name: "x", Dimensions: []int{2, cols}, DataType: types.Double, Data: data}
+	}
+	z := func(re, im float64) *types.Variable {
+		return &types.Variable{Name: "z", Dimensions: []int{1, 1}, DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{re}, Imag: []float64{im}}}
+	}
+	rows := func(n int) *types.Variable {
+		return &types.Variable{Name: "r", Dimensions: []int{n, 2}, DataType: types.Int32, Data: make([]int32, 2*n)}
+	}
+	only := &types.Variable{Name: "only", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}
+
+	// Files of both versions; r has a different number of rows in each
+	paths := []string{
+		writeSetFile(t, dir, "a.mat", Version5, matrix(2, 1, 2, 3, 4), z(1, -1), rows(1), only),
+		writeSetFile(t, dir, "b.mat", Version73, matrix(1, 5, 6), z(2, -2), rows(2)),
+		writeSetFile(t, dir, "c.mat", Version5, matrix(3, 7, 8, 9, 10, 11, 12), z(3, -3), rows(3)),
+	}
+
+	set, err := OpenSet(1, paths...)
+	if err != nil {
+		t.Fatalf("OpenSet() error = %v", err)
+	}
+	var names []string
+	for _, info := range set.Variables() {
+		names = append(names, info.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"x", "z"}) {
+		t.Errorf("Variables() = %v, want [x z]", names)
+	}
+	if set.Len("x") != 6 || set.Len("r") != 0 {
+		t.Errorf("Len() = %d, %d", set.Len("x"), set.Len("r"))
+	}
+
+	x, err := set.Variable("x")
+	if err != nil {
+		t.Fatalf("Variable(x) error = %v", err)
+	}
+	if !reflect.DeepEqual(x.Dimensions, []int{2, 6}) ||
+		!reflect.DeepEqual(x.Data, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}) {
+		t.Errorf("x = %v %v", x.Dimensions, x.Data)
+	}
+
+	// Columns 1 to 3 span all three files
+	window, err := set.ReadRange("x", 1, 3)
+	if err != nil {
+		t.Fatalf("ReadRange() error = %v", err)
+	}
+	if !reflect.DeepEqual(window.Dimensions, []int{2, 3}) || !reflect.DeepEqual(window.Data, []float64{3, 4, 5, 6, 7, 8}) {
+		t.Errorf("window = %v %v", window.Dimensions, window.Data)
+	}
+	if _, err := set.ReadRange("x", 4, 3); err == nil {
+		t.Error("ReadRange(past end) error = nil")
+	}
+
+	zs, err := set.Variable("z")
+	if err != nil {
+		t.Fatalf("Variable(z) error = %v", err)
+	}
+	array := zs.Data.(*types.NumericArray)
+	if !reflect.DeepEqual(array.Real, []float64{1, 2, 3}) || !reflect.DeepEqual(array.Imag, []float64{-1, -2, -3}) {
+		t.Errorf("z = %v %v", array.Real, array.Imag)
+	}
+
+	if _, err := set.Variable("r"); !errors.Is(err, ErrSetMismatch) {
+		t.Errorf("Variable(r) error = %v, want ErrSetMismatch", err)
+	}
+	if _, err := set.Variable("only"); !errors.Is(err, ErrVariableNotFound) {
+		t.Errorf("Variable(only) error = %v, want ErrVariableNotFound", err)
+	}
+
+	// Along rows, r concatenates and x does not
+	set, err = OpenSet(0, paths...)
+	if err != nil {
+		t.Fatalf("OpenSet() error = %v", err)
+	}
+	r, err := set.ReadRange("r", 2, 3)
+	if err != nil {
+		t.Fatalf("ReadRange(r) error = %v", err)
+	}
+	if !reflect.DeepEqual(r.Dimensions, []int{3, 2}) {
+		t.Errorf("r dimensions = %v, want [3 2]", r.Dimensions)
+	}
+	if _, err := set.Variable("x"); !errors.Is(err, ErrSetMismatch) {
+		t.Errorf("Variable(x) error = %v, want ErrSetMismatch", err)
+	}
+}
+
+func TestFileSet_Promote(t *testing.T) {
+	dir := t.TempDir()
+	counts := &types.Variable{Name: "n", Dimensions: []int{1, 2}, DataType: types.Uint8, Data: []uint8{200, 1}}
+	offsets := &types.Variable{Name: "n", Dimensions: []int{1, 1}, DataType: types.Int8, Data: []int8{-3}}
+	z := &types.Variable{Name: "n", Dimensions: []int{1, 1}, DataType: types.Int8, IsComplex: true,
+		Data: &types.NumericArray{Real: []int8{4}, Imag: []int8{-4}}}
+	set, err := OpenSet(1,
+		writeSetFile(t, dir, "a.mat", Version5, counts),
+		writeSetFile(t, dir, "b.mat", Version73, offsets),
+		writeSetFile(t, dir, "c.mat", Version5, z))
+	if err != nil {
+		t.Fatalf("OpenSet() error = %v", err)
+	}
+	if info := set.Variables()[0]; info.DataType != types.Int16 || !info.IsComplex {
+		t.Errorf("Variables()[0] = %s complex %v, want complex int16", info.DataType, info.IsComplex)
+	}
+	n, err := set.Variable("n")
+	if err != nil {
+		t.Fatalf("Variable(n) error = %v", err)
+	}
+	array := n.Data.(*types.NumericArray)
+	if n.DataType != types.Int16 || !reflect.DeepEqual(array.Real, []int16{200, 1, -3, 4}) || !reflect.DeepEqual(array.Imag, []int16{0, 0, 0, -4}) {
+		t.Errorf("n = %s %v %v, want int16 [200 1 -3 4] [0 0 0 -4]", n.DataType, array.Real, array.Imag)
+	}
+}
+
+func TestSliceAlong(t *testing.T) {
+	// 2x3x2, column-major
+	v := &types.Variable{Name: "a", Dimensions: []int{2, 3, 2}, DataType: types.Int8,
+		Data: []int8{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}}
+	got, err := sliceAlong(v, 1, 1, 2)
+	if err != nil {
+		t.Fatalf("sliceAlong() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Dimensions, []int{2, 2, 2}) || !reflect.DeepEqual(got.Data, []int8{3, 4, 5, 6, 9, 10, 11, 12}) {
+		t.Errorf("sliceAlong() = %v %v", got.Dimensions, got.Data)
+	}
+
+	// Splitting and joining back restores the array
+	head, _ := sliceAlong(v, 1, 0, 1)
+	tail, _ := sliceAlong(v, 1, 1, 2)
+	joined, err := types.Concat(1, head, tail)
+	if err != nil {
+		t.Fatalf("Concat() error = %v", err)
+	}
+	if !reflect.DeepEqual(joined.Dimensions, v.Dimensions) || !reflect.DeepEqual(joined.Data, v.Data) {
+		t.Errorf("Concat() = %v %v", joined.Dimensions, joined.Data)
+	}
+}
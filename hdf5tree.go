@@ -0,0 +1,34 @@
+package matlab
+
+import "github.com/scigolib/matlab/internal/v73"
+
+// HDF5Node describes one group or dataset of a v7.3 MAT-file as seen by
+// the HDF5 layer, including datatype, dimensions, storage layout and
+// attributes. String renders the subtree as indented text.
+type HDF5Node = v73.TreeNode
+
+// HDF5Attribute describes an HDF5 attribute attached to an HDF5Node.
+type HDF5Attribute = v73.TreeAttribute
+
+// HDF5 object kinds reported in HDF5Node.Kind.
+const (
+	HDF5Group   = v73.KindGroup
+	HDF5Dataset = v73.KindDataset
+)
+
+// HDF5Tree returns the HDF5 group/dataset/attribute hierarchy of a v7.3
+// MAT-file, or nil for v5 files.
+//
+// The tree reflects the file as stored, including objects whose MATLAB
+// class is not decoded into Variables, which makes it useful for
+// inspecting unfamiliar files and reporting unsupported content.
+//
+// Example:
+//
+//	matFile, _ := matlab.Open(file)
+//	if tree := matFile.HDF5Tree(); tree != nil {
+//	    fmt.Print(tree)
+//	}
+func (m *MatFile) HDF5Tree() *HDF5Node {
+	return m.hdf5Tree
+}
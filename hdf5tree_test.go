@@ -0,0 +1,66 @@
+package matlab
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatFile_HDF5Tree_V73(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "tree.mat")
+	writer, err := Create(tmpfile, Version73)
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteVariable(&types.Variable{
+		Name:       "signal",
+		Dimensions: []int{4},
+		DataType:   types.Single,
+		Data:       []float32{1, 2, 3, 4},
+	}))
+	require.NoError(t, writer.Close())
+
+	file, err := os.Open(tmpfile)
+	require.NoError(t, err)
+	defer file.Close()
+
+	matFile, err := Open(file)
+	require.NoError(t, err)
+
+	tree := matFile.HDF5Tree()
+	require.NotNil(t, tree)
+	assert.Equal(t, HDF5Group, tree.Kind)
+	require.Len(t, tree.Children, 1)
+
+	node := tree.Children[0]
+	assert.Equal(t, "/signal", node.Path)
+	assert.Equal(t, HDF5Dataset, node.Kind)
+	assert.Equal(t, 4, node.ElementSize)
+	assert.Equal(t, []uint64{4}, node.Dims)
+	assert.Equal(t, uint64(16), node.Bytes())
+	assert.True(t, strings.Contains(tree.String(), "MATLAB_class"))
+}
+
+func TestMatFile_HDF5Tree_V5(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "tree_v5.mat")
+	writer, err := Create(tmpfile, Version5)
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteVariable(&types.Variable{
+		Name:       "x",
+		Dimensions: []int{1, 1},
+		DataType:   types.Double,
+		Data:       []float64{1},
+	}))
+	require.NoError(t, writer.Close())
+
+	file, err := os.Open(tmpfile)
+	require.NoError(t, err)
+	defer file.Close()
+
+	matFile, err := Open(file)
+	require.NoError(t, err)
+	assert.Nil(t, matFile.HDF5Tree())
+}
@@ -0,0 +1,214 @@
+package matlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// CatalogEntry describes one variable of an indexed file.
+type CatalogEntry struct {
+	File     string `json:"file"`              // Path as passed to Index
+	Version  string `json:"version"`           // MAT-file version ("5.0" or "7.3")
+	Variable string `json:"variable"`          // Variable name
+	Class    string `json:"class"`             // MATLAB class, e.g. "double"
+	Dims     []int  `json:"dims"`              // Array dimensions
+	Complex  bool   `json:"complex,omitempty"` // True for complex numbers
+	Bytes    int64  `json:"bytes"`             // Bytes the variable occupies in the file
+}
+
+// CatalogError records a file that could not be indexed.
+type CatalogError struct {
+	File string // Path as passed to Index
+	Err  error  // Reason the file could not be read
+}
+
+// Error implements the error interface.
+func (e *CatalogError) Error() string {
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CatalogError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON includes the error message.
+func (e *CatalogError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		File  string `json:"file"`
+		Error string `json:"error"`
+	}{e.File, e.Err.Error()})
+}
+
+// Catalog is an index of the variables stored in a set of MAT-files.
+type Catalog struct {
+	Entries []CatalogEntry  `json:"entries"`          // Variables, grouped by file in input order
+	Errors  []*CatalogError `json:"errors,omitempty"` // Files that could not be indexed
+}
+
+// Index builds a catalog of the variables in the given files.
+//
+// Files are read with OpenMetadata, so no variable data is loaded. Up to
+// workers files are read concurrently; workers < 1 selects
+// runtime.NumCPU(). A file that cannot be read is recorded in
+// Catalog.Errors and does not stop the others from being indexed.
+//
+// Example:
+//
+//	paths, _ := filepath.Glob("archive/*.mat")
+//	catalog := matlab.Index(paths, 8)
+//	for _, e := range catalog.Lookup("temperature") {
+//	    fmt.Println(e.File, e.Dims)
+//	}
+//	catalog.WriteJSON(os.Stdout)
+func Index(paths []string, workers int, opts ...OpenOption) *Catalog {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	type result struct {
+		entries []CatalogEntry
+		err     error
+	}
+	results := make([]result, len(paths))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j].entries, results[j].err = indexFile(paths[j], opts)
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	catalog := &Catalog{}
+	for i, res := range results {
+		if res.err != nil {
+			catalog.Errors = append(catalog.Errors, &CatalogError{File: paths[i], Err: res.err})
+			continue
+		}
+		catalog.Entries = append(catalog.Entries, res.entries...)
+	}
+	return catalog
+}
+
+// indexFile reads the metadata of one file.
+func indexFile(path string, opts []OpenOption) ([]CatalogEntry, error) {
+	//nolint:gosec // G304: paths are provided by the caller for indexing, expected behavior
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck // Read-only file
+
+	meta, err := OpenMetadata(f, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]CatalogEntry, len(meta.Variables))
+	for i, v := range meta.Variables {
+		entries[i] = CatalogEntry{
+			File:     path,
+			Version:  meta.Version,
+			Variable: v.Name,
+			Class:    v.DataType.String(),
+			Dims:     v.Dimensions,
+			Complex:  v.IsComplex,
+			Bytes:    v.Size,
+		}
+	}
+	return entries, nil
+}
+
+// Lookup returns the entries for variables with the given name.
+func (c *Catalog) Lookup(variable string) []CatalogEntry {
+	return c.Filter(func(e CatalogEntry) bool {
+		return e.Variable == variable
+	})
+}
+
+// Filter returns the entries for which keep returns true.
+//
+// Example:
+//
+//	large := catalog.Filter(func(e matlab.CatalogEntry) bool {
+//	    return e.Bytes > 1<<30
+//	})
+func (c *Catalog) Filter(keep func(CatalogEntry) bool) []CatalogEntry {
+	var out []CatalogEntry
+	for _, e := range c.Entries {
+		if keep(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// WriteJSON writes the catalog as an indented JSON document.
+func (c *Catalog) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+// WriteSQL writes the catalog as SQL statements that create and fill the
+// named table, for loading into SQLite or another SQL database:
+//
+//	sqlite3 catalog.db < catalog.sql
+//
+// Dimensions are stored as text such as "3x2".
+func (c *Catalog) WriteSQL(w io.Writer, table string) error {
+	table = quoteIdentifier(table)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CREATE TABLE IF NOT EXISTS %s (\n", table)
+	sb.WriteString("  file TEXT NOT NULL,\n")
+	sb.WriteString("  version TEXT NOT NULL,\n")
+	sb.WriteString("  variable TEXT NOT NULL,\n")
+	sb.WriteString("  class TEXT NOT NULL,\n")
+	sb.WriteString("  dims TEXT NOT NULL,\n")
+	sb.WriteString("  complex INTEGER NOT NULL,\n")
+	sb.WriteString("  bytes INTEGER NOT NULL\n")
+	sb.WriteString(");\n")
+	sb.WriteString("BEGIN TRANSACTION;\n")
+	for _, e := range c.Entries {
+		dims := make([]string, len(e.Dims))
+		for i, d := range e.Dims {
+			dims[i] = fmt.Sprint(d)
+		}
+		complexFlag := 0
+		if e.Complex {
+			complexFlag = 1
+		}
+		fmt.Fprintf(&sb, "INSERT INTO %s VALUES (%s, %s, %s, %s, %s, %d, %d);\n",
+			table, quoteString(e.File), quoteString(e.Version), quoteString(e.Variable),
+			quoteString(e.Class), quoteString(strings.Join(dims, "x")), complexFlag, e.Bytes)
+	}
+	sb.WriteString("COMMIT;\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// quoteString quotes s as an SQL string literal.
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteIdentifier quotes s as an SQL identifier.
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,126 @@
+package matlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// writeIndexFixture writes a v5 file holding the given variables.
+func writeIndexFixture(t *testing.T, path string, vars ...*types.Variable) {
+	t.Helper()
+	writer, err := Create(path, Version5)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for _, v := range vars {
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.mat")
+	second := filepath.Join(dir, "second.mat")
+	missing := filepath.Join(dir, "missing.mat")
+
+	writeIndexFixture(t, first,
+		&types.Variable{Name: "temperature", Dimensions: []int{2, 2}, DataType: types.Double, Data: []float64{1, 2, 3, 4}},
+		&types.Variable{Name: "id", Dimensions: []int{1, 1}, DataType: types.Int32, Data: []int32{7}},
+	)
+	writeIndexFixture(t, second,
+		&types.Variable{Name: "temperature", Dimensions: []int{1, 3}, DataType: types.Single, Data: []float32{1, 2, 3}},
+	)
+
+	for _, workers := range []int{0, 1, 4} {
+		catalog := Index([]string{first, missing, second}, workers)
+
+		if len(catalog.Entries) != 3 {
+			t.Fatalf("workers=%d: got %d entries, want 3", workers, len(catalog.Entries))
+		}
+		gotOrder := []string{catalog.Entries[0].Variable, catalog.Entries[1].Variable, catalog.Entries[2].File}
+		wantOrder := []string{"temperature", "id", second}
+		if !reflect.DeepEqual(gotOrder, wantOrder) {
+			t.Errorf("workers=%d: order = %v, want %v", workers, gotOrder, wantOrder)
+		}
+
+		if len(catalog.Errors) != 1 || catalog.Errors[0].File != missing {
+			t.Fatalf("workers=%d: Errors = %v, want one for %s", workers, catalog.Errors, missing)
+		}
+		if !errors.Is(catalog.Errors[0], os.ErrNotExist) {
+			t.Errorf("error = %v, want os.ErrNotExist", catalog.Errors[0])
+		}
+	}
+
+	catalog := Index([]string{first, second}, 2)
+	hits := catalog.Lookup("temperature")
+	if len(hits) != 2 || hits[0].Class != "double" || hits[1].Class != "single" {
+		t.Errorf("Lookup() = %+v", hits)
+	}
+	if !reflect.DeepEqual(hits[1].Dims, []int{1, 3}) || hits[1].Bytes <= 0 {
+		t.Errorf("second entry = %+v", hits[1])
+	}
+}
+
+func TestCatalog_WriteJSON(t *testing.T) {
+	catalog := &Catalog{
+		Entries: []CatalogEntry{{File: "a.mat", Version: "5.0", Variable: "x", Class: "double", Dims: []int{1, 2}, Bytes: 64}},
+		Errors:  []*CatalogError{{File: "b.mat", Err: errors.New("boom")}},
+	}
+
+	var buf bytes.Buffer
+	if err := catalog.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var doc struct {
+		Entries []CatalogEntry `json:"entries"`
+		Errors  []struct {
+			File  string `json:"file"`
+			Error string `json:"error"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(doc.Entries, catalog.Entries) {
+		t.Errorf("entries = %+v, want %+v", doc.Entries, catalog.Entries)
+	}
+	if len(doc.Errors) != 1 || doc.Errors[0].File != "b.mat" || doc.Errors[0].Error != "boom" {
+		t.Errorf("errors = %+v", doc.Errors)
+	}
+}
+
+func TestCatalog_WriteSQL(t *testing.T) {
+	catalog := &Catalog{Entries: []CatalogEntry{
+		{File: "it's.mat", Version: "7.3", Variable: "z", Class: "double", Dims: []int{3, 2}, Complex: true, Bytes: 96},
+	}}
+
+	var buf bytes.Buffer
+	if err := catalog.WriteSQL(&buf, "mat_vars"); err != nil {
+		t.Fatalf("WriteSQL() error = %v", err)
+	}
+	sql := buf.String()
+
+	for _, want := range []string{
+		`CREATE TABLE IF NOT EXISTS "mat_vars" (`,
+		`INSERT INTO "mat_vars" VALUES ('it''s.mat', '7.3', 'z', 'double', '3x2', 1, 96);`,
+		"COMMIT;",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL missing %q:\n%s", want, sql)
+		}
+	}
+}
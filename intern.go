@@ -0,0 +1,157 @@
+package matlab
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"reflect"
+	"sync"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// DefaultInternSize is the size, in bytes, of the largest payload that an
+// InternPool created with size 0 shares.
+const DefaultInternSize = 64 << 10
+
+// InternPool shares the data of identical small variables across the
+// files opened with WithInterning, for batch ingestion of many files that
+// repeat the same calibration tables or constants. Each numeric, logical
+// or char payload up to the pool's size is identified by a SHA-256 hash
+// of its class and contents; a payload already in the pool is replaced by
+// the pooled slice, so every copy but the first can be released.
+//
+// Interned slices are shared, so variables read with a pool must not be
+// modified. The pool keeps every distinct payload it has seen; use one
+// pool per batch and drop it afterwards. An InternPool is safe for
+// concurrent use.
+//
+// Example:
+//
+//	pool := matlab.NewInternPool(0)
+//	for _, path := range paths {
+//	    matFile, err := matlab.Open(path, matlab.WithInterning(pool))
+//	    ...
+//	}
+//	log.Printf("%+v", pool.Stats())
+type InternPool struct {
+	maxBytes int
+
+	mu       sync.Mutex
+	payloads map[internKey]interface{}
+	stats    InternStats
+}
+
+// InternStats describes the payloads an InternPool has seen.
+type InternStats struct {
+	Payloads int   // Distinct payloads held by the pool
+	Bytes    int64 // Size of those payloads
+	Hits     int   // Payloads replaced by a pooled one
+	Saved    int64 // Size of the replaced payloads
+}
+
+// internKey identifies a payload: the Go type of its slice and the hash
+// of its contents.
+type internKey struct {
+	typ reflect.Type
+	sum [sha256.Size]byte
+}
+
+// NewInternPool returns a pool that shares payloads of up to maxBytes
+// bytes, or DefaultInternSize if maxBytes is 0 or less.
+func NewInternPool(maxBytes int) *InternPool {
+	if maxBytes <= 0 {
+		maxBytes = DefaultInternSize
+	}
+	return &InternPool{maxBytes: maxBytes, payloads: make(map[internKey]interface{})}
+}
+
+// WithInterning shares the data of identical small variables through
+// pool, including the fields of structs and the cells of cell arrays.
+// Variables are interned after the transforms of WithTransform. Sparse
+// matrices are not interned.
+//
+// Default: no interning
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithInterning(pool))
+func WithInterning(pool *InternPool) OpenOption {
+	return func(c *openConfig) {
+		c.intern = pool
+	}
+}
+
+// Stats returns the statistics of the pool so far.
+func (p *InternPool) Stats() InternStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// internVariable replaces the data of v and of the variables it contains
+// with pooled payloads.
+func (p *InternPool) internVariable(v *types.Variable) {
+	switch data := v.Data.(type) {
+	case *types.NumericArray:
+		data.Real = p.intern(data.Real)
+		data.Imag = p.intern(data.Imag)
+	case *types.CharArray:
+		data.Data = p.intern(data.Data).([]rune)
+	case *types.StructArray:
+		for _, element := range data.Elements {
+			for _, field := range element {
+				if field != nil {
+					p.internVariable(field)
+				}
+			}
+		}
+	case []*types.Variable:
+		for _, cell := range data {
+			if cell != nil {
+				p.internVariable(cell)
+			}
+		}
+	case *types.SparseMatrix:
+	default:
+		v.Data = p.intern(v.Data)
+	}
+}
+
+// intern returns the pooled payload equal to data, adding data to the pool
+// if it is new. Payloads that are not strings or slices of fixed-size
+// elements, are empty or are larger than the pool's size are returned as
+// given.
+func (p *InternPool) intern(data interface{}) interface{} {
+	s, isString := data.(string)
+	size := len(s)
+	if !isString {
+		if reflect.ValueOf(data).Kind() != reflect.Slice {
+			return data
+		}
+		size = binary.Size(data) // -1 for slices of other elements
+	}
+	if size <= 0 || size > p.maxBytes {
+		return data
+	}
+	h := sha256.New()
+	if isString {
+		h.Write([]byte(s))
+	} else {
+		//nolint:errcheck,gosec // Writes to a hash do not fail
+		binary.Write(h, binary.LittleEndian, data)
+	}
+	key := internKey{typ: reflect.TypeOf(data)}
+	h.Sum(key.sum[:0])
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pooled, ok := p.payloads[key]; ok {
+		p.stats.Hits++
+		p.stats.Saved += int64(size)
+		return pooled
+	}
+	p.payloads[key] = data
+	p.stats.Payloads++
+	p.stats.Bytes += int64(size)
+	return data
+}
@@ -0,0 +1,82 @@
+package matlab
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// internFile returns a v5 file holding a calibration table, a struct with
+// the same table and a run-specific vector.
+func internFile(t *testing.T, run float64) []byte {
+	t.Helper()
+	table := func() *types.Variable {
+		return &types.Variable{Name: "cal", Dimensions: []int{1, 4}, DataType: types.Double, Data: []float64{1, 2, 3, 4}}
+	}
+	field := table()
+	field.Name = "table"
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	err = w.WriteVariables(table(),
+		&types.Variable{Name: "meta", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+			Fields: []string{"table"}, Elements: [][]*types.Variable{{field}}, Dimensions: []int{1, 1}}},
+		&types.Variable{Name: "x", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{run, run}})
+	if err != nil {
+		t.Fatalf("WriteVariables() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestWithInterning(t *testing.T) {
+	pool := NewInternPool(0)
+	var files []*MatFile
+	for _, run := range []float64{1, 2} {
+		matFile, err := Open(bytes.NewReader(internFile(t, run)), WithInterning(pool))
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		files = append(files, matFile)
+	}
+
+	first := files[0].GetVariable("cal").Data.([]float64)
+	second := files[1].GetVariable("cal").Data.([]float64)
+	field := files[1].GetVariable("meta").Data.(*types.StructArray).Elements[0][0].Data.([]float64)
+	if &second[0] != &first[0] || &field[0] != &first[0] {
+		t.Errorf("identical tables are not shared")
+	}
+	if x1, x2 := files[0].GetVariable("x").Data.([]float64), files[1].GetVariable("x").Data.([]float64); x1[0] != 1 || x2[0] != 2 {
+		t.Errorf("x = %v and %v, want [1 1] and [2 2]", x1, x2)
+	}
+
+	want := InternStats{Payloads: 3, Bytes: 32 + 2*16, Hits: 3, Saved: 3 * 32} // The table and both x
+	if got := pool.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestInternPool_Limits(t *testing.T) {
+	pool := NewInternPool(16)
+	small := func() *types.Variable {
+		return &types.Variable{Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}}
+	}
+	large := func() *types.Variable {
+		return &types.Variable{Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{1, 2, 3}}
+	}
+	for range 2 {
+		pool.internVariable(small())
+		pool.internVariable(large())
+	}
+	// Same contents, another class
+	pool.internVariable(&types.Variable{Dimensions: []int{1, 2}, DataType: types.Int64, Data: []int64{1, 2}})
+
+	if got, want := pool.Stats(), (InternStats{Payloads: 2, Bytes: 32, Hits: 1, Saved: 16}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
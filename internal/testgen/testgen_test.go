@@ -0,0 +1,75 @@
+package testgen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+func TestParseTypes(t *testing.T) {
+	got, err := ParseTypes("double, int16,logical,char")
+	if err != nil {
+		t.Fatalf("ParseTypes() error = %v", err)
+	}
+	want := []types.DataType{types.Double, types.Int16, types.Logical, types.Char}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTypes() = %v, want %v", got, want)
+	}
+	if _, err := ParseTypes("double,struct"); err == nil {
+		t.Error("ParseTypes(struct) error = nil")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	vars, err := Generate(Spec{Variables: 3, Dims: []int{2, 3}, Types: []types.DataType{types.Uint8, types.Char}})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(vars) != 3 || vars[0].Name != "v1" || vars[2].Name != "v3" {
+		t.Fatalf("Generate() = %v", vars)
+	}
+	if !reflect.DeepEqual(vars[0].Data, []uint8{0, 1, 2, 3, 4, 5}) || vars[1].Data != "abcdef" ||
+		vars[2].DataType != types.Uint8 || !reflect.DeepEqual(vars[2].Dimensions, []int{2, 3}) {
+		t.Errorf("Generate() = %v %v %v", vars[0].Data, vars[1].Data, vars[2])
+	}
+
+	vars, err = Generate(Spec{})
+	if err != nil || len(vars) != 1 || !reflect.DeepEqual(vars[0].Dimensions, []int{1, 1000}) || vars[0].DataType != types.Double {
+		t.Errorf("Generate(Spec{}) = %v, %v", vars, err)
+	}
+	if _, err := Generate(Spec{Dims: []int{2, -1}}); err == nil {
+		t.Error("Generate(negative dimension) error = nil")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	spec := Spec{Variables: 4, Elements: 5000, Types: Classes, Compression: 6}
+	for _, version := range []matlab.Version{matlab.Version5, matlab.Version73} {
+		path := filepath.Join(t.TempDir(), "gen.mat")
+		if err := Write(path, version, spec); err != nil {
+			t.Fatalf("Write(%d) error = %v", version, err)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matFile, err := matlab.Open(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("Open(%d) error = %v", version, err)
+		}
+		want, _ := Generate(spec)
+		if len(matFile.Variables) != len(want) {
+			t.Fatalf("version %d: %d variables, want %d", version, len(matFile.Variables), len(want))
+		}
+		for i, v := range matFile.Variables {
+			if v.Name != want[i].Name || v.DataType != want[i].DataType || !reflect.DeepEqual(v.Data, want[i].Data) {
+				t.Errorf("version %d: %s differs", version, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package v5
+
+import "github.com/scigolib/matlab/types"
+
+// Lengths of the chunks a batch cuts values from. Larger values are
+// allocated on their own.
+const (
+	batchInts      = 1024
+	batchVariables = 64
+	batchBytes     = 16 << 10
+	maxBatchBytes  = 1 << 10
+)
+
+// batch cuts the small values parsed from a file, which would otherwise
+// be allocated one by one, from larger chunks. The slices it returns have
+// no spare capacity, so appending to one never overwrites another. A nil
+// batch allocates each value on its own.
+type batch struct {
+	intChunk      []int
+	variableChunk []types.Variable
+	byteChunk     []byte
+}
+
+// ints returns n zeroed ints.
+func (b *batch) ints(n int) []int {
+	if b == nil || n > batchInts/16 {
+		return make([]int, n)
+	}
+	if len(b.intChunk) < n {
+		b.intChunk = make([]int, batchInts)
+	}
+	s := b.intChunk[:n:n]
+	b.intChunk = b.intChunk[n:]
+	return s
+}
+
+// variable returns a zeroed variable.
+func (b *batch) variable() *types.Variable {
+	if b == nil {
+		return &types.Variable{}
+	}
+	if len(b.variableChunk) == 0 {
+		b.variableChunk = make([]types.Variable, batchVariables)
+	}
+	v := &b.variableChunk[0]
+	b.variableChunk = b.variableChunk[1:]
+	return v
+}
+
+// bytes returns n zeroed bytes.
+func (b *batch) bytes(n int) []byte {
+	if b == nil || n > maxBatchBytes {
+		return make([]byte, n)
+	}
+	if len(b.byteChunk) < n {
+		b.byteChunk = make([]byte, batchBytes)
+	}
+	s := b.byteChunk[:n:n]
+	b.byteChunk = b.byteChunk[n:]
+	return s
+}
@@ -0,0 +1,61 @@
+package v5
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// benchmarkFile returns a v5 file with n small variables: scalars, short
+// vectors and strings, as written by instruments and logging code.
+func benchmarkFile(b *testing.B, n, compression int) []byte {
+	b.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "", "IM")
+	if err != nil {
+		b.Fatal(err)
+	}
+	w.Compression = compression
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("var_%05d", i)
+		var v *types.Variable
+		switch i % 3 {
+		case 0:
+			v = &types.Variable{Name: name, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{float64(i)}}
+		case 1:
+			v = &types.Variable{Name: name, Dimensions: []int{1, 8}, DataType: types.Int32, Data: make([]int32, 8)}
+		default:
+			v = &types.Variable{Name: name, Dimensions: []int{1, 5}, DataType: types.Char, Data: "hello"}
+		}
+		if err := w.WriteVariable(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkParse_10kVariables(b *testing.B) {
+	for _, compression := range []int{0, 6} {
+		b.Run(fmt.Sprintf("compression=%d", compression), func(b *testing.B) {
+			data := benchmarkFile(b, 10000, compression)
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				p, err := NewParser(bytes.NewReader(data))
+				if err != nil {
+					b.Fatal(err)
+				}
+				file, err := p.Parse()
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(file.Variables) != 10000 {
+					b.Fatalf("parsed %d variables", len(file.Variables))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package v5
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// ErrColumnOutOfRange indicates a column index outside the matrix.
+var ErrColumnOutOfRange = errors.New("column index out of range")
+
+// ReadColumns scans the top-level variables for name and decodes the given
+// zero-based columns of the 2-D array, in the order given. Columns are
+// contiguous in column-major storage, so the data of unselected columns is
+// skipped without being decoded. It reports whether the variable was found.
+//
+// Only numeric and logical arrays can be read this way.
+func (p *Parser) ReadColumns(name string, cols []int) (*types.Variable, bool, error) {
+	var variable *types.Variable
+	found, err := p.scanFor(name, func(sub *Parser, hdr *arrayHeader) error {
+		var err error
+		variable, err = sub.decodeColumns(hdr, cols)
+		return err
+	})
+	return variable, found, err
+}
+
+// decodeColumns decodes the selected columns of the array whose header
+// was just read.
+func (p *Parser) decodeColumns(hdr *arrayHeader, cols []int) (*types.Variable, error) {
+	elemType, ok := classElemTypes[hdr.class]
+	if !ok {
+		return nil, fmt.Errorf("cannot select columns of %s %s: only numeric and logical arrays are supported",
+			hdr.name, classToDataType(hdr.class))
+	}
+	if len(hdr.dimensions) != 2 {
+		return nil, fmt.Errorf("cannot select columns of %s: array has %d dimensions, want 2",
+			hdr.name, len(hdr.dimensions))
+	}
+	rows, ncols := hdr.dimensions[0], hdr.dimensions[1]
+	for _, c := range cols {
+		if c < 0 || c >= ncols {
+			return nil, fmt.Errorf("%w: %s has %d columns, got %d", ErrColumnOutOfRange, hdr.name, ncols, c)
+		}
+	}
+
+	dataType := classToDataType(hdr.class)
+	if hdr.isLogical && !hdr.isComplex {
+		elemType, dataType = reflect.TypeOf(false), types.Logical
+	}
+	realPart, err := p.readColumnsPart(hdr.name, rows, ncols, cols, elemType, hdr.isComplex)
+	if err != nil {
+		return nil, err
+	}
+
+	variable := &types.Variable{
+		Name:       hdr.name,
+		Dimensions: []int{rows, len(cols)},
+		DataType:   dataType,
+		Data:       realPart,
+	}
+	if hdr.isComplex {
+		imagPart, err := p.readColumnsPart(hdr.name, rows, ncols, cols, elemType, false)
+		if err != nil {
+			return nil, err
+		}
+		variable.IsComplex = true
+		variable.Data = &types.NumericArray{
+			Real:       realPart,
+			Imag:       imagPart,
+			Dimensions: variable.Dimensions,
+			Type:       dataType,
+		}
+	}
+	return variable, nil
+}
+
+// readColumnsPart decodes the selected columns of one data subelement into
+// a new slice of elemType. Each distinct column is decoded once, in storage
+// order; repeated columns are copied. With skipRest, the unread remainder
+// of the subelement and its padding are consumed so the next subelement
+// can be read.
+func (p *Parser) readColumnsPart(name string, rows, ncols int, cols []int, elemType reflect.Type, skipRest bool) (interface{}, error) {
+	tag, err := p.readTag()
+	if err != nil {
+		return nil, err
+	}
+	size := storageSize(tag.DataType)
+	if size == 0 {
+		return nil, fmt.Errorf("unsupported data type %d for %s", tag.DataType, name)
+	}
+	if int(tag.Size) != rows*ncols*size {
+		return nil, fmt.Errorf("%s: data holds %d bytes, want %d", name, tag.Size, rows*ncols*size)
+	}
+
+	value := reflect.MakeSlice(reflect.SliceOf(elemType), rows*len(cols), rows*len(cols))
+	dst := value.Interface()
+	if tag.IsSmall {
+		for i, c := range cols {
+			column := tag.SmallData[c*rows*size : (c+1)*rows*size]
+			if err := p.decodeChunk(column, tag.DataType, size, dst, i*rows); err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+	}
+
+	order := make([]int, len(cols))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return cols[order[a]] < cols[order[b]] })
+
+	next, last := 0, -1 // next stored column; output index of the last decoded one
+	for _, i := range order {
+		c := cols[i]
+		if c < next {
+			reflect.Copy(value.Slice(i*rows, (i+1)*rows), value.Slice(last*rows, (last+1)*rows))
+			continue
+		}
+		if _, err := io.CopyN(io.Discard, p.r, int64((c-next)*rows*size)); err != nil {
+			return nil, err
+		}
+		if err := p.decodeRun(tag.DataType, size, rows, dst, i*rows); err != nil {
+			return nil, err
+		}
+		next, last = c+1, i
+	}
+
+	if skipRest {
+		rest := int64((ncols-next)*rows*size) + int64((8-tag.Size%8)%8)
+		if _, err := io.CopyN(io.Discard, p.r, rest); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,80 @@
+package v5
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestParser_ReadColumns(t *testing.T) {
+	wide := make([]int32, 3*1000) // 3x1000, element value = column*10 + row
+	for c := 0; c < 1000; c++ {
+		for r := 0; r < 3; r++ {
+			wide[c*3+r] = int32(c*10 + r)
+		}
+	}
+	vars := []*types.Variable{
+		{Name: "wide", Dimensions: []int{3, 1000}, DataType: types.Int32, Data: wide},
+		{Name: "z", Dimensions: []int{2, 2}, DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1, 2, 3, 4}, Imag: []float64{5, 6, 7, 8}}},
+		{Name: "cube", Dimensions: []int{1, 1, 2}, DataType: types.Double, Data: []float64{1, 2}},
+	}
+
+	for _, compression := range []int{0, 6} {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, "", "IM")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Compression = compression
+		for _, v := range vars {
+			if err := w.WriteVariable(v); err != nil {
+				t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+			}
+		}
+		readColumns := func(data []byte, name string, cols ...int) (*types.Variable, bool, error) {
+			t.Helper()
+			p, err := NewParser(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("NewParser() error = %v", err)
+			}
+			return p.ReadColumns(name, cols)
+		}
+
+		got, found, err := readColumns(buf.Bytes(), "wide", 999, 2, 2, 0)
+		if !found || err != nil {
+			t.Fatalf("ReadColumns(wide) = %v, %v", found, err)
+		}
+		want := []int32{9990, 9991, 9992, 20, 21, 22, 20, 21, 22, 0, 1, 2}
+		if !reflect.DeepEqual(got.Data, want) || !reflect.DeepEqual(got.Dimensions, []int{3, 4}) {
+			t.Errorf("compression %d: wide = %v %v", compression, got.Dimensions, got.Data)
+		}
+
+		got, _, err = readColumns(buf.Bytes(), "z", 1)
+		if err != nil {
+			t.Fatalf("ReadColumns(z) error = %v", err)
+		}
+		z, ok := got.Data.(*types.NumericArray)
+		if !ok || !got.IsComplex || !reflect.DeepEqual(z.Real, []float64{3, 4}) || !reflect.DeepEqual(z.Imag, []float64{7, 8}) {
+			t.Errorf("compression %d: z = %+v", compression, got.Data)
+		}
+
+		x, _, err := readColumns(narrowDoubleFile(t), "x", 2, 0)
+		if err != nil || !reflect.DeepEqual(x.Data, []float64{9, 7}) {
+			t.Errorf("ReadColumns(x) = %+v, %v", x, err)
+		}
+
+		if _, _, err := readColumns(buf.Bytes(), "wide", 1000); !errors.Is(err, ErrColumnOutOfRange) {
+			t.Errorf("out of range: error = %v", err)
+		}
+		if _, _, err := readColumns(buf.Bytes(), "cube", 0); err == nil {
+			t.Error("3-D array: expected error")
+		}
+		if _, found, err := readColumns(buf.Bytes(), "nope", 0); found || err != nil {
+			t.Errorf("ReadColumns(nope) = %v, %v", found, err)
+		}
+	}
+}
@@ -24,39 +24,149 @@ const maxCompressionRatio = 1000
 // - Maximum decompressed size limit (100MB).
 // - Maximum compression ratio check (1000:1).
 func decompress(r io.Reader, compressedSize uint32) ([]byte, error) {
+	return (&inflater{}).inflate(r, compressedSize, nil)
+}
+
+// Decompress decompresses the compressedSize bytes of a miCOMPRESSED
+// element's data read from r, with the limits of decompress. The result
+// is the stored element, tag included.
+func Decompress(r io.Reader, compressedSize uint32) ([]byte, error) {
+	return decompress(r, compressedSize)
+}
+
+// inflateBudget applies the decompression limits cumulatively to a
+// top-level element and the compressed elements nested in it, so nesting
+// cannot multiply them.
+type inflateBudget struct {
+	stored   int64 // Size of the top-level element in the file
+	inflated int64 // Bytes decompressed so far, at every level
+}
+
+// inflater decompresses the elements of a file one after the other,
+// reusing its zlib state and input buffer. Creating a zlib reader per
+// element dominated the parse time of files with many small variables.
+type inflater struct {
+	compressed []byte        // Input buffer, grown to the largest element
+	src        bytes.Reader  // Reader over compressed
+	zr         io.ReadCloser // zlib reader, reset for each element
+}
+
+// inflate decompresses the next compressedSize bytes of r, as decompress
+// does. The returned content is newly allocated. If budget is not nil,
+// the limits also apply to the bytes it has already counted.
+func (z *inflater) inflate(r io.Reader, compressedSize uint32, budget *inflateBudget) ([]byte, error) {
 	// Read compressed data
-	compressed := make([]byte, compressedSize)
+	if cap(z.compressed) < int(compressedSize) {
+		z.compressed = make([]byte, compressedSize)
+	}
+	compressed := z.compressed[:compressedSize]
 	if _, err := io.ReadFull(r, compressed); err != nil {
 		return nil, fmt.Errorf("failed to read compressed data: %w", err)
 	}
 
-	// Create zlib reader
-	zlibReader, err := zlib.NewReader(bytes.NewReader(compressed))
-	if err != nil {
+	// Create or reset the zlib reader
+	z.src.Reset(compressed)
+	if z.zr == nil {
+		zr, err := zlib.NewReader(&z.src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zlib reader: %w", err)
+		}
+		z.zr = zr
+	} else if err := z.zr.(zlib.Resetter).Reset(&z.src, nil); err != nil {
 		return nil, fmt.Errorf("failed to create zlib reader: %w", err)
 	}
-	defer zlibReader.Close() //nolint:errcheck // Best effort cleanup
 
 	// Read decompressed data with size limit
+	var done int64 // Decompressed by enclosing elements
+	if budget != nil {
+		done = budget.inflated
+	}
 	var decompressed bytes.Buffer
-	limited := io.LimitReader(zlibReader, maxDecompressedSize+1)
+	limited := io.LimitReader(z.zr, maxDecompressedSize-done+1)
 	n, err := io.Copy(&decompressed, limited)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decompress data: %w", err)
 	}
 
 	// Check for size limit exceeded
-	if n > maxDecompressedSize {
-		return nil, fmt.Errorf("decompressed size exceeds limit: %d > %d bytes", n, maxDecompressedSize)
+	if done+n > maxDecompressedSize {
+		return nil, sizeLimitError(done + n)
 	}
 
 	// Check compression ratio
 	if compressedSize > 0 {
-		ratio := float64(n) / float64(compressedSize)
-		if ratio > maxCompressionRatio {
-			return nil, fmt.Errorf("compression ratio too high: %.1f:1 (max %d:1)", ratio, maxCompressionRatio)
+		if err := checkRatio(n, int64(compressedSize)); err != nil {
+			return nil, err
+		}
+	}
+	if budget != nil {
+		budget.inflated += n
+		if budget.stored > 0 {
+			ratio := float64(budget.inflated) / float64(budget.stored)
+			if ratio > maxCompressionRatio {
+				return nil, fmt.Errorf("compression ratio of nested elements too high: %.1f:1 (max %d:1)", ratio, maxCompressionRatio)
+			}
 		}
 	}
 
 	return decompressed.Bytes(), nil
 }
+
+// sizeLimitError reports n decompressed bytes, above maxDecompressedSize.
+func sizeLimitError(n int64) error {
+	return fmt.Errorf("decompressed size exceeds limit: %d > %d bytes", n, maxDecompressedSize)
+}
+
+// checkRatio returns an error if inflated bytes decompressed from stored
+// exceed maxCompressionRatio.
+func checkRatio(inflated, stored int64) error {
+	if ratio := float64(inflated) / float64(stored); ratio > maxCompressionRatio {
+		return fmt.Errorf("compression ratio too high: %.1f:1 (max %d:1)", ratio, maxCompressionRatio)
+	}
+	return nil
+}
+
+// StreamLimit applies the limits of decompress to a whole compressed
+// stream, such as a gzip or zstd container, which is read rather than
+// decompressed at once. The compressed input is counted through Input
+// and the decompressed data read through Output, which fails as soon as
+// either limit is exceeded.
+type StreamLimit struct {
+	budget inflateBudget
+}
+
+// Input returns r, counting the compressed bytes read from it.
+func (l *StreamLimit) Input(r io.Reader) io.Reader {
+	return readFunc(func(p []byte) (int, error) {
+		n, err := r.Read(p)
+		l.budget.stored += int64(n)
+		return n, err
+	})
+}
+
+// Output returns zr, the decompressor of the Input stream, failing once
+// it has decompressed more than 100MB or 1000 times the bytes read from
+// the input.
+func (l *StreamLimit) Output(zr io.Reader) io.Reader {
+	return readFunc(func(p []byte) (int, error) {
+		n, err := zr.Read(p)
+		l.budget.inflated += int64(n)
+		if l.budget.inflated > maxDecompressedSize {
+			return 0, sizeLimitError(l.budget.inflated)
+		}
+		if l.budget.stored > 0 {
+			if err := checkRatio(l.budget.inflated, l.budget.stored); err != nil {
+				return 0, err
+			}
+		}
+		return n, err
+	})
+}
+
+// readFunc adapts a function to io.Reader.
+type readFunc func(p []byte) (int, error)
+
+// Read implements io.Reader.
+func (f readFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
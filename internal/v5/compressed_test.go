@@ -3,6 +3,8 @@ package v5
 import (
 	"bytes"
 	"compress/zlib"
+	"io"
+	"strings"
 	"testing"
 )
 
@@ -91,3 +93,45 @@ func TestDecompress_TruncatedReader(t *testing.T) {
 		t.Error("decompress() expected error for truncated reader, got nil")
 	}
 }
+
+// zeros is an endless reader of zero bytes.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// TestStreamLimit checks both limits of a decompressed stream, with zeros
+// standing in for the decompressor.
+func TestStreamLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored int64
+		want   string
+	}{
+		{"size", 1 << 20, "decompressed size exceeds limit"},
+		{"ratio", 1 << 10, "compression ratio too high"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l StreamLimit
+			if _, err := io.Copy(io.Discard, l.Input(io.LimitReader(zeros{}, tt.stored))); err != nil {
+				t.Fatal(err)
+			}
+			_, err := io.Copy(io.Discard, l.Output(zeros{}))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Output() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// Within both limits
+	var l StreamLimit
+	if _, err := io.Copy(io.Discard, l.Input(io.LimitReader(zeros{}, 1<<10))); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := io.Copy(io.Discard, l.Output(io.LimitReader(zeros{}, 1<<19))); err != nil || n != 1<<19 {
+		t.Errorf("Output() = %d, %v, want 512:1 accepted", n, err)
+	}
+}
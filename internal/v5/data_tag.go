@@ -16,6 +16,8 @@ type DataTag struct {
 	Size      uint32 // Data size in bytes
 	IsSmall   bool   // True for small data elements
 	SmallData []byte // For small format: data bytes (up to 4 bytes)
+
+	small [4]byte // Backing array of SmallData
 }
 
 // readTag reads a data tag from the stream.
@@ -25,10 +27,19 @@ type DataTag struct {
 //     lower 16 bits = type, bytes 4-7 = packed data.
 //   - Regular format (8 bytes tag + N bytes data): bytes 0-3 = type, bytes 4-7 = size.
 func (p *Parser) readTag() (*DataTag, error) {
-	buf := make([]byte, 8)
-	if _, err := io.ReadFull(p.r, buf); err != nil {
+	tag := &DataTag{}
+	if err := p.readTagInto(tag); err != nil {
 		return nil, err
 	}
+	return tag, nil
+}
+
+// readTagInto reads a data tag from the stream into tag.
+func (p *Parser) readTagInto(tag *DataTag) error {
+	buf := p.scratch[:]
+	if _, err := io.ReadFull(p.r, buf); err != nil {
+		return err
+	}
 	p.pos += 8
 
 	firstWord := p.Header.Order.Uint32(buf[0:4])
@@ -38,16 +49,15 @@ func (p *Parser) readTag() (*DataTag, error) {
 	size := firstWord >> 16
 	if size > 0 && size <= 4 {
 		// Small format: data is packed in bytes 4-7 of the 8-byte tag
-		dataType := firstWord & 0xFFFF
 		// Copy the small data from bytes 4 to 4+size
-		smallData := make([]byte, size)
-		copy(smallData, buf[4:4+size])
-		return &DataTag{
-			DataType:  dataType,
-			Size:      size,
-			IsSmall:   true,
-			SmallData: smallData,
-		}, nil
+		*tag = DataTag{
+			DataType: firstWord & 0xFFFF,
+			Size:     size,
+			IsSmall:  true,
+		}
+		copy(tag.small[:], buf[4:4+size])
+		tag.SmallData = tag.small[:size]
+		return nil
 	}
 
 	// Regular format: entire first word is type, second word is size
@@ -56,12 +66,13 @@ func (p *Parser) readTag() (*DataTag, error) {
 
 	// Validate size to prevent memory exhaustion attacks
 	if size > maxReasonableSize {
-		return nil, fmt.Errorf("tag size too large: %d bytes (max %d)", size, maxReasonableSize)
+		return fmt.Errorf("tag size too large: %d bytes (max %d)", size, maxReasonableSize)
 	}
 
-	return &DataTag{
+	*tag = DataTag{
 		DataType: dataType,
 		Size:     size,
 		IsSmall:  false,
-	}, nil
+	}
+	return nil
 }
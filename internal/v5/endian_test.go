@@ -0,0 +1,231 @@
+package v5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// endianVariables covers every class and storage type the writer emits.
+// It matches endianTypeVariables in scripts/generate-testdata, which wrote
+// testdata/generated/endian_be_types_v5.mat.
+func endianVariables() []*types.Variable {
+	return []*types.Variable{
+		{Name: "d", Dimensions: []int{2, 3}, DataType: types.Double, Data: []float64{1, -2.5, 3e100, 4, 5, 6}},
+		{Name: "s", Dimensions: []int{1, 3}, DataType: types.Single, Data: []float32{1.5, -2, 3}},
+		{Name: "i8", Dimensions: []int{1, 3}, DataType: types.Int8, Data: []int8{-128, 0, 127}},
+		{Name: "u8", Dimensions: []int{1, 3}, DataType: types.Uint8, Data: []uint8{0, 1, 255}},
+		{Name: "i16", Dimensions: []int{1, 3}, DataType: types.Int16, Data: []int16{-32768, 1, 32767}},
+		{Name: "u16", Dimensions: []int{1, 3}, DataType: types.Uint16, Data: []uint16{0, 258, 65535}},
+		{Name: "i32", Dimensions: []int{1, 3}, DataType: types.Int32, Data: []int32{-1 << 31, 1, 1<<31 - 1}},
+		{Name: "u32", Dimensions: []int{1, 3}, DataType: types.Uint32, Data: []uint32{0, 0x01020304, 1<<32 - 1}},
+		{Name: "i64", Dimensions: []int{1, 3}, DataType: types.Int64, Data: []int64{-1 << 63, 0x0102030405060708, 1<<63 - 1}},
+		{Name: "u64", Dimensions: []int{1, 3}, DataType: types.Uint64, Data: []uint64{0, 0x0102030405060708, 1<<64 - 1}},
+		{Name: "mask", Dimensions: []int{1, 3}, DataType: types.Logical, Data: []bool{true, false, true}},
+		{Name: "ascii", Dimensions: []int{1, 5}, DataType: types.Char, Data: "hello"},
+		{Name: "bmp", Dimensions: []int{1, 4}, DataType: types.Char, Data: "Grüß"},
+		{Name: "z", Dimensions: []int{1, 2}, DataType: types.Int16, IsComplex: true,
+			Data: &types.NumericArray{Real: []int16{1, -2}, Imag: []int16{3, -4}}},
+		{Name: "cube", Dimensions: []int{2, 1, 2}, DataType: types.Double, Data: []float64{1, 2, 3, 4}},
+		{Name: "cfg", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+			Fields:     []string{"rate", "label"},
+			Dimensions: []int{1, 1},
+			Elements: [][]*types.Variable{{
+				{Name: "rate", Dimensions: []int{1, 1}, DataType: types.Uint32, Data: []uint32{44100}},
+				{Name: "label", Dimensions: []int{1, 2}, DataType: types.Char, Data: "ab"},
+			}},
+		}},
+	}
+}
+
+// writeEndian writes endianVariables in the given byte order.
+func writeEndian(t *testing.T, endian string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "MATLAB MAT-file, created by scigolib/matlab", endian)
+	if err != nil {
+		t.Fatalf("NewWriter(%s) error = %v", endian, err)
+	}
+	for _, v := range endianVariables() {
+		if err := w.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// swapFile returns a copy of an uncompressed v5 file written in byte
+// order from, with the header and every tag and stored value byte-swapped.
+// It walks the element structure independently of the writer, so a field
+// the writer encodes in the wrong order shows up as a difference.
+func swapFile(t *testing.T, data []byte, from binary.ByteOrder) []byte {
+	t.Helper()
+	out := append([]byte(nil), data...)
+	reverse(out[124:126])
+	reverse(out[126:128])
+	swapElements(t, data, out, 128, len(data), from)
+	return out
+}
+
+// swapElements swaps the data elements in data[pos:end] into out.
+func swapElements(t *testing.T, data, out []byte, pos, end int, from binary.ByteOrder) {
+	t.Helper()
+	for pos < end {
+		word := from.Uint32(data[pos:])
+		if size := int(word >> 16); size > 0 {
+			reverse(out[pos : pos+4])
+			swapValues(t, out[pos+4:pos+4+size], word&0xFFFF)
+			pos += 8
+			continue
+		}
+
+		size := int(from.Uint32(data[pos+4:]))
+		reverse(out[pos : pos+4])
+		reverse(out[pos+4 : pos+8])
+		if word == miMATRIX {
+			swapElements(t, data, out, pos+8, pos+8+size, from)
+		} else {
+			swapValues(t, out[pos+8:pos+8+size], word)
+		}
+		pos += 8 + size + (8-size%8)%8
+	}
+}
+
+// swapValues reverses each stored value of the given type in b.
+func swapValues(t *testing.T, b []byte, dataType uint32) {
+	t.Helper()
+	size := storageSize(dataType)
+	switch dataType {
+	case miUTF8:
+		size = 1
+	case miUTF16:
+		size = 2
+	}
+	if size == 0 {
+		t.Fatalf("unexpected element type %d", dataType)
+	}
+	for i := 0; i+size <= len(b); i += size {
+		reverse(b[i : i+size])
+	}
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// TestWriter_ByteOrderMirrors checks that a big-endian file is exactly the
+// byte-swapped little-endian one: every tag, dimension, flag and value
+// must honor the selected order.
+func TestWriter_ByteOrderMirrors(t *testing.T) {
+	le := writeEndian(t, "IM")
+	be := writeEndian(t, "MI")
+	if swapped := swapFile(t, le, binary.LittleEndian); !bytes.Equal(swapped, be) {
+		for i := range swapped {
+			if swapped[i] != be[i] {
+				t.Fatalf("big-endian output differs from swapped little-endian output at byte %d", i)
+			}
+		}
+		t.Fatalf("big-endian output has %d bytes, swapped little-endian %d", len(be), len(swapped))
+	}
+	if swapped := swapFile(t, be, binary.BigEndian); !bytes.Equal(swapped, le) {
+		t.Error("little-endian output is not the swapped big-endian output")
+	}
+}
+
+// TestWriter_ByteOrderRoundTrip reads every class back in both orders,
+// compressed and not.
+func TestWriter_ByteOrderRoundTrip(t *testing.T) {
+	for _, endian := range []string{"IM", "MI"} {
+		for _, compression := range []int{0, 6} {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, "", endian)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Compression = compression
+			want := endianVariables()
+			for _, v := range want {
+				if err := w.WriteVariable(v); err != nil {
+					t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+				}
+			}
+
+			p, err := NewParser(&buf)
+			if err != nil {
+				t.Fatalf("NewParser() error = %v", err)
+			}
+			file, err := p.Parse()
+			if err != nil {
+				t.Fatalf("%s/%d: Parse() error = %v", endian, compression, err)
+			}
+			for i, v := range file.Variables {
+				checkEndianVariable(t, endian, v, want[i])
+			}
+		}
+	}
+}
+
+// TestBigEndianFixture reads the checked-in big-endian fixture, which
+// scripts/octave/check_endian.m verifies independently with Octave.
+func TestBigEndianFixture(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/generated/endian_be_types_v5.mat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, writeEndian(t, "MI")) {
+		t.Error("writer output no longer matches endian_be_types_v5.mat")
+	}
+
+	p, err := NewParser(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewParser() error = %v", err)
+	}
+	file, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if file.Header.EndianIndicator != "MI" {
+		t.Errorf("EndianIndicator = %q", file.Header.EndianIndicator)
+	}
+	want := endianVariables()
+	if len(file.Variables) != len(want) {
+		t.Fatalf("got %d variables, want %d", len(file.Variables), len(want))
+	}
+	for i, v := range file.Variables {
+		checkEndianVariable(t, "MI", v, want[i])
+	}
+}
+
+func checkEndianVariable(t *testing.T, endian string, got, want *types.Variable) {
+	t.Helper()
+	if got.Name != want.Name || !reflect.DeepEqual(got.Dimensions, want.Dimensions) {
+		t.Errorf("%s: got %s %v, want %s %v", endian, got.Name, got.Dimensions, want.Name, want.Dimensions)
+		return
+	}
+	switch w := want.Data.(type) {
+	case *types.NumericArray:
+		g, ok := got.Data.(*types.NumericArray)
+		if !ok || !reflect.DeepEqual(g.Real, w.Real) || !reflect.DeepEqual(g.Imag, w.Imag) {
+			t.Errorf("%s: %s = %+v", endian, got.Name, got.Data)
+		}
+	case *types.StructArray:
+		g, ok := got.Data.(*types.StructArray)
+		if !ok || !reflect.DeepEqual(g.Fields, w.Fields) {
+			t.Errorf("%s: %s = %+v", endian, got.Name, got.Data)
+			return
+		}
+		for j := range w.Elements[0] {
+			checkEndianVariable(t, endian, g.Elements[0][j], w.Elements[0][j])
+		}
+	default:
+		if !reflect.DeepEqual(got.Data, want.Data) {
+			t.Errorf("%s: %s = %v, want %v", endian, got.Name, got.Data, want.Data)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 // Header represents a MAT-file header.
 type Header struct {
 	Description     string           // File description
+	SubsystemOffset uint64           // Offset of the subsystem data, 0 if none
 	Version         uint16           // MAT-file version
 	EndianIndicator string           // Endian indicator ("MI" or "IM")
 	Order           binary.ByteOrder // Byte order
@@ -35,7 +36,26 @@ func parseHeader(data []byte) (*Header, error) {
 		return nil, errors.New("invalid endian indicator")
 	}
 
-	// Parse version
+	// Parse version. Some writers store the indicator bytes the wrong way
+	// round while writing the rest of the file in the other order; the
+	// version field (always 0x0100) reveals the order actually used.
 	hdr.Version = hdr.Order.Uint16(data[124:126])
+	if hdr.Version != 0x0100 && swapOrder(hdr.Order).Uint16(data[124:126]) == 0x0100 {
+		hdr.Order = swapOrder(hdr.Order)
+		hdr.Version = 0x0100
+	}
+
+	// Files without subsystem data hold zeros or spaces
+	if offset := hdr.Order.Uint64(data[116:124]); offset != 0x2020202020202020 {
+		hdr.SubsystemOffset = offset
+	}
 	return hdr, nil
 }
+
+// swapOrder returns the opposite byte order.
+func swapOrder(order binary.ByteOrder) binary.ByteOrder {
+	if order == binary.LittleEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
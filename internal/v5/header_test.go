@@ -163,6 +163,39 @@ func TestParseHeaderByteOrderVerification(t *testing.T) {
 	}
 }
 
+// TestParseHeaderSwappedIndicator tests that a header whose indicator bytes
+// disagree with the byte order of its version field is read in the order the
+// version field was written in.
+func TestParseHeaderSwappedIndicator(t *testing.T) {
+	tests := []struct {
+		name      string
+		endian    string
+		order     binary.ByteOrder
+		wantOrder binary.ByteOrder
+	}{
+		{"MI with little-endian body", "MI", binary.LittleEndian, binary.LittleEndian},
+		{"IM with big-endian body", "IM", binary.BigEndian, binary.BigEndian},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := makeHeader("Test", 0x0100, tt.endian)
+			tt.order.PutUint16(header[124:126], 0x0100)
+
+			got, err := parseHeader(header)
+			if err != nil {
+				t.Fatalf("parseHeader() unexpected error: %v", err)
+			}
+			if got.Version != 0x0100 {
+				t.Errorf("Version = 0x%04x, want 0x0100", got.Version)
+			}
+			if got.Order != tt.wantOrder {
+				t.Errorf("Order = %v, want %v", got.Order, tt.wantOrder)
+			}
+		})
+	}
+}
+
 // TestParseHeaderLongDescription tests handling of maximum-length descriptions.
 func TestParseHeaderLongDescription(t *testing.T) {
 	// Description field is 116 bytes (0-115)
@@ -0,0 +1,303 @@
+package v5
+
+import (
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// ErrDestinationMismatch indicates a destination slice whose type or
+// length does not match the variable read into it.
+var ErrDestinationMismatch = types.ErrDestinationMismatch
+
+// scratchSize is the size of the buffer data is decoded through.
+const scratchSize = 4096
+
+// scratchPool holds decoding buffers so repeated reads do not allocate.
+var scratchPool = sync.Pool{New: func() interface{} {
+	buf := make([]byte, scratchSize)
+	return &buf
+}}
+
+// ReadInto scans the top-level variables for name and decodes its data
+// directly into dst, a slice of the variable's element type ([]bool for
+// logical arrays) with one element per array element. Other variables are
+// skipped without being decoded. It reports whether the variable was found.
+//
+// Only real numeric and logical arrays can be read this way.
+func (p *Parser) ReadInto(name string, dst interface{}) (bool, error) {
+	return p.scanFor(name, func(sub *Parser, hdr *arrayHeader) error {
+		return sub.decodeArrayInto(hdr, dst)
+	})
+}
+
+// ReadVariable scans the top-level variables for name and parses it as
+// Parse would. Other variables are skipped without being decoded, and
+// reading stops once the variable is found. It reports whether the
+// variable was found.
+func (p *Parser) ReadVariable(name string) (*types.Variable, bool, error) {
+	var variable *types.Variable
+	found, err := p.scanFor(name, func(sub *Parser, hdr *arrayHeader) error {
+		var err error
+		variable, err = sub.parseArrayContent(hdr)
+		return err
+	})
+	if err != nil || !found {
+		return nil, found, err
+	}
+	if p.Transform != nil {
+		if variable, err = p.Transform(variable); err != nil {
+			return nil, true, fmt.Errorf("transform %q: %w", name, err)
+		}
+	}
+	return variable, variable != nil, nil
+}
+
+// scanFor scans the top-level variables for name. When found, read is
+// called with a parser positioned after the variable's array header, and
+// scanning stops. Other variables are skipped without being decoded.
+func (p *Parser) scanFor(name string, read func(sub *Parser, hdr *arrayHeader) error) (bool, error) {
+	for {
+		tag, err := p.readTag()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if tag.IsSmall || (tag.DataType != miMATRIX && tag.DataType != miCOMPRESSED) {
+			p.skipData(tag)
+			continue
+		}
+
+		body := &io.LimitedReader{R: p.r, N: int64(tag.Size)}
+		found, err := p.readElement(body, tag.DataType == miCOMPRESSED, name, read)
+		if found || err != nil {
+			return found, err
+		}
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return false, err
+		}
+		if body.N > 0 {
+			return false, io.ErrUnexpectedEOF
+		}
+		p.pos += int64(tag.Size)
+	}
+}
+
+// readElement reads the array header of a matrix element and, if it is
+// the named variable, calls read.
+func (p *Parser) readElement(r io.Reader, compressed bool, name string, read func(sub *Parser, hdr *arrayHeader) error) (bool, error) {
+	sub := &Parser{r: r, Header: p.Header}
+	if compressed {
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return false, fmt.Errorf("failed to create zlib reader: %w", err)
+		}
+		defer zr.Close() //nolint:errcheck // Best effort cleanup
+		sub.r = zr
+
+		inner, err := sub.readTag()
+		if err != nil {
+			return false, err
+		}
+		if inner.DataType != miMATRIX {
+			return false, nil
+		}
+	}
+
+	hdr, err := sub.readArrayHeader()
+	if err != nil {
+		return false, err
+	}
+	if hdr.name != name {
+		return false, nil
+	}
+	return true, read(sub, hdr)
+}
+
+// decodeArrayInto checks dst against the array header and decodes the
+// real data subelement into it.
+func (p *Parser) decodeArrayInto(hdr *arrayHeader, dst interface{}) error {
+	elemType, ok := classElemTypes[hdr.class]
+	if !ok || hdr.isComplex {
+		return fmt.Errorf("cannot read %s %s into a slice: only real numeric and logical arrays are supported",
+			hdr.name, classToDataType(hdr.class))
+	}
+	if hdr.isLogical {
+		elemType = reflect.TypeOf(false)
+	}
+
+	count := 1
+	for _, d := range hdr.dimensions {
+		count *= d
+	}
+	want := reflect.SliceOf(elemType)
+	value := reflect.ValueOf(dst)
+	if !value.IsValid() || value.Type() != want {
+		return fmt.Errorf("%w: %s needs %v, got %T", ErrDestinationMismatch, hdr.name, want, dst)
+	}
+	if value.Len() != count {
+		return fmt.Errorf("%w: %s has %d elements, destination has %d", ErrDestinationMismatch, hdr.name, count, value.Len())
+	}
+
+	tag, err := p.readTag()
+	if err != nil {
+		return err
+	}
+	size := storageSize(tag.DataType)
+	if size == 0 {
+		return fmt.Errorf("unsupported data type %d for %s", tag.DataType, hdr.name)
+	}
+	if int(tag.Size) != count*size {
+		return fmt.Errorf("%s: data holds %d bytes, want %d", hdr.name, tag.Size, count*size)
+	}
+
+	if tag.IsSmall {
+		return p.decodeChunk(tag.SmallData, tag.DataType, size, dst, 0)
+	}
+	return p.decodeRun(tag.DataType, size, count, dst, 0)
+}
+
+// decodeRun reads count stored elements from the stream and decodes them
+// into dst starting at index offset.
+func (p *Parser) decodeRun(dataType uint32, size, count int, dst interface{}, offset int) error {
+	bufp := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(bufp)
+	buf := (*bufp)[:scratchSize-scratchSize%size]
+	for i := 0; i < count; {
+		n := (count - i) * size
+		if n > len(buf) {
+			n = len(buf)
+		}
+		if _, err := io.ReadFull(p.r, buf[:n]); err != nil {
+			return err
+		}
+		if err := p.decodeChunk(buf[:n], dataType, size, dst, offset+i); err != nil {
+			return err
+		}
+		i += n / size
+	}
+	return nil
+}
+
+// decodeChunk decodes the stored elements in b into dst, starting at
+// index offset. 64-bit integers are copied exactly into 64-bit integer
+// destinations; other values pass through float64, which holds every
+// value of the narrower storage types.
+func (p *Parser) decodeChunk(b []byte, dataType uint32, size int, dst interface{}, offset int) error {
+	n := len(b) / size
+	order := p.Header.Order
+	raw64 := dataType == miINT64 || dataType == miUINT64
+	value := func(j int) float64 {
+		e := b[j*size:]
+		switch dataType {
+		case miINT8:
+			return float64(int8(e[0]))
+		case miUINT8:
+			return float64(e[0])
+		case miINT16:
+			return float64(int16(order.Uint16(e))) //nolint:gosec // G115: two's complement reinterpretation
+		case miUINT16:
+			return float64(order.Uint16(e))
+		case miINT32:
+			return float64(int32(order.Uint32(e))) //nolint:gosec // G115: two's complement reinterpretation
+		case miUINT32:
+			return float64(order.Uint32(e))
+		case miSINGLE:
+			return float64(math.Float32frombits(order.Uint32(e)))
+		case miINT64:
+			return float64(int64(order.Uint64(e))) //nolint:gosec // G115: two's complement reinterpretation
+		case miUINT64:
+			return float64(order.Uint64(e))
+		default:
+			return math.Float64frombits(order.Uint64(e))
+		}
+	}
+
+	switch d := dst.(type) {
+	case []float64:
+		if dataType == miDOUBLE {
+			for j := 0; j < n; j++ {
+				d[offset+j] = math.Float64frombits(order.Uint64(b[j*8:]))
+			}
+			return nil
+		}
+		for j := 0; j < n; j++ {
+			d[offset+j] = value(j)
+		}
+	case []float32:
+		for j := 0; j < n; j++ {
+			d[offset+j] = float32(value(j))
+		}
+	case []int8:
+		for j := 0; j < n; j++ {
+			d[offset+j] = int8(value(j))
+		}
+	case []uint8:
+		for j := 0; j < n; j++ {
+			d[offset+j] = uint8(value(j))
+		}
+	case []int16:
+		for j := 0; j < n; j++ {
+			d[offset+j] = int16(value(j))
+		}
+	case []uint16:
+		for j := 0; j < n; j++ {
+			d[offset+j] = uint16(value(j))
+		}
+	case []int32:
+		for j := 0; j < n; j++ {
+			d[offset+j] = int32(value(j))
+		}
+	case []uint32:
+		for j := 0; j < n; j++ {
+			d[offset+j] = uint32(value(j))
+		}
+	case []int64:
+		for j := 0; j < n; j++ {
+			if raw64 {
+				d[offset+j] = int64(order.Uint64(b[j*8:])) //nolint:gosec // G115: two's complement reinterpretation
+			} else {
+				d[offset+j] = int64(value(j))
+			}
+		}
+	case []uint64:
+		for j := 0; j < n; j++ {
+			if raw64 {
+				d[offset+j] = order.Uint64(b[j*8:])
+			} else {
+				d[offset+j] = uint64(value(j))
+			}
+		}
+	case []bool:
+		for j := 0; j < n; j++ {
+			d[offset+j] = value(j) != 0
+		}
+	default:
+		return fmt.Errorf("%w: unsupported destination %T", ErrDestinationMismatch, dst)
+	}
+	return nil
+}
+
+// storageSize returns the element size of a numeric storage type, or 0.
+func storageSize(dataType uint32) int {
+	switch dataType {
+	case miINT8, miUINT8:
+		return 1
+	case miINT16, miUINT16:
+		return 2
+	case miINT32, miUINT32, miSINGLE:
+		return 4
+	case miDOUBLE, miINT64, miUINT64:
+		return 8
+	default:
+		return 0
+	}
+}
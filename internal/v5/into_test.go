@@ -0,0 +1,143 @@
+package v5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// narrowDoubleFile returns a file with a 1x3 double variable "x" whose
+// data is stored as a small-format miUINT8 element, as MATLAB does for
+// small integer values.
+func narrowDoubleFile(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "", "IM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	le := binary.LittleEndian
+	var content []byte
+	flags := make([]byte, 8)
+	le.PutUint32(flags, mxDOUBLE_CLASS)
+	content = append(content, w.wrapInTag(miUINT32, flags)...)
+	dims := make([]byte, 8)
+	le.PutUint32(dims, 1)
+	le.PutUint32(dims[4:], 3)
+	content = append(content, w.wrapInTag(miINT32, dims)...)
+	content = append(content, w.wrapInTag(miINT8, []byte("x"))...)
+	small := make([]byte, 8)
+	le.PutUint32(small, 3<<16|miUINT8)
+	copy(small[4:], []byte{7, 8, 9})
+	content = append(content, small...)
+
+	if err := w.writeTag(miMATRIX, uint32(len(content))); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(content)
+	return buf.Bytes()
+}
+
+func TestParser_ReadInto(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "", "MI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Compression = 1
+	big := make([]int64, 1000)
+	for i := range big {
+		big[i] = int64(i) << 40
+	}
+	vars := []*types.Variable{
+		{Name: "skip", Dimensions: []int{2, 2}, DataType: types.Double, Data: []float64{1, 2, 3, 4}},
+		{Name: "big", Dimensions: []int{1000, 1}, DataType: types.Int64, Data: big},
+		{Name: "mask", Dimensions: []int{1, 3}, DataType: types.Logical, Data: []bool{true, false, true}},
+	}
+	for _, v := range vars {
+		if err := w.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+		}
+	}
+
+	readInto := func(data []byte, name string, dst interface{}) (bool, error) {
+		t.Helper()
+		p, err := NewParser(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("NewParser() error = %v", err)
+		}
+		return p.ReadInto(name, dst)
+	}
+
+	gotBig := make([]int64, 1000)
+	if found, err := readInto(buf.Bytes(), "big", gotBig); !found || err != nil || !reflect.DeepEqual(gotBig, big) {
+		t.Errorf("ReadInto(big) = %v, %v", found, err)
+	}
+	mask := make([]bool, 3)
+	if found, err := readInto(buf.Bytes(), "mask", mask); !found || err != nil || !reflect.DeepEqual(mask, []bool{true, false, true}) {
+		t.Errorf("ReadInto(mask) = %v, %v, %v", found, err, mask)
+	}
+	x := make([]float64, 3)
+	if found, err := readInto(narrowDoubleFile(t), "x", x); !found || err != nil || !reflect.DeepEqual(x, []float64{7, 8, 9}) {
+		t.Errorf("ReadInto(x) = %v, %v, %v", found, err, x)
+	}
+
+	if found, err := readInto(buf.Bytes(), "nope", x); found || err != nil {
+		t.Errorf("ReadInto(nope) = %v, %v", found, err)
+	}
+	if _, err := readInto(buf.Bytes(), "skip", make([]float32, 4)); !errors.Is(err, ErrDestinationMismatch) {
+		t.Errorf("wrong type: error = %v", err)
+	}
+	if _, err := readInto(buf.Bytes(), "skip", make([]float64, 3)); !errors.Is(err, ErrDestinationMismatch) {
+		t.Errorf("wrong length: error = %v", err)
+	}
+	if _, err := readInto(buf.Bytes(), "skip", nil); !errors.Is(err, ErrDestinationMismatch) {
+		t.Errorf("nil destination: error = %v", err)
+	}
+}
+
+func TestParser_ReadVariable(t *testing.T) {
+	want := endianVariables()
+	for _, compression := range []int{0, 6} {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, "", "IM")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Compression = compression
+		for _, v := range want {
+			if err := w.WriteVariable(v); err != nil {
+				t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+			}
+		}
+
+		for _, v := range want {
+			p, err := NewParser(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("NewParser() error = %v", err)
+			}
+			got, found, err := p.ReadVariable(v.Name)
+			if !found || err != nil {
+				t.Fatalf("ReadVariable(%s) = %v, %v", v.Name, found, err)
+			}
+			checkEndianVariable(t, "IM", got, v)
+		}
+
+		p, err := NewParser(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("NewParser() error = %v", err)
+		}
+		p.Transform = func(*types.Variable) (*types.Variable, error) { return nil, nil }
+		if got, found, err := p.ReadVariable("cfg"); got != nil || found || err != nil {
+			t.Errorf("dropped by Transform: ReadVariable(cfg) = %v, %v, %v", got, found, err)
+		}
+		p, _ = NewParser(bytes.NewReader(buf.Bytes()))
+		if got, found, err := p.ReadVariable("nope"); got != nil || found || err != nil {
+			t.Errorf("ReadVariable(nope) = %v, %v, %v", got, found, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package v5
+
+import (
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// ParseMetadata reads the header of every top-level variable and skips its
+// data, so no data buffers are allocated. Compressed variables are only
+// inflated as far as their array header.
+func (p *Parser) ParseMetadata() ([]*types.VariableInfo, error) {
+	var infos []*types.VariableInfo
+
+	for {
+		offset := p.pos
+		tag, err := p.readTag()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch tag.DataType {
+		case miMATRIX, miCOMPRESSED:
+			if tag.IsSmall {
+				continue
+			}
+			compressed := tag.DataType == miCOMPRESSED
+			info, err := p.readMatrixInfo(tag, compressed)
+			if err != nil {
+				return nil, fmt.Errorf("variable at offset %d: %w", offset, err)
+			}
+			info.Offset = offset
+			info.Size = p.pos - offset
+			info.Compressed = compressed
+			if !compressed {
+				info.UncompressedSize = info.Size
+			}
+			infos = append(infos, info)
+		default:
+			p.skipData(tag)
+		}
+	}
+
+	return infos, nil
+}
+
+// readMatrixInfo reads the array header of a matrix element, inflating it
+// first if compressed, and discards the rest of the element.
+func (p *Parser) readMatrixInfo(tag *DataTag, compressed bool) (*types.VariableInfo, error) {
+	body := &io.LimitedReader{R: p.r, N: int64(tag.Size)}
+	info, err := readArrayInfo(body, p.Header, compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip the data without buffering it
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return nil, err
+	}
+	p.pos += int64(tag.Size) - body.N
+	if body.N > 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return info, nil
+}
+
+// readArrayInfo reads the array header at the start of a matrix element's
+// content. For compressed elements, UncompressedSize is set from the tag
+// of the inflated matrix element.
+func readArrayInfo(r io.Reader, header *Header, compressed bool) (*types.VariableInfo, error) {
+	sub := &Parser{r: r, Header: header}
+	var inflated int64
+	if compressed {
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zlib reader: %w", err)
+		}
+		defer zr.Close() //nolint:errcheck // Best effort cleanup
+		sub.r = zr
+
+		inner, err := sub.readTag()
+		if err != nil {
+			return nil, err
+		}
+		if inner.DataType != miMATRIX {
+			return nil, fmt.Errorf("compressed element holds type %d, not a matrix", inner.DataType)
+		}
+		inflated = 8 + int64(inner.Size)
+	}
+
+	hdr, err := sub.readArrayHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &types.VariableInfo{
+		Name:             hdr.name,
+		Dimensions:       hdr.dimensions,
+		DataType:         classToDataType(hdr.class),
+		IsComplex:        hdr.isComplex,
+		IsSparse:         hdr.class == mxSPARSE_CLASS,
+		UncompressedSize: inflated,
+	}
+	if hdr.isLogical && !hdr.isComplex {
+		info.DataType = types.Logical
+	}
+	return info, nil
+}
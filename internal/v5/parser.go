@@ -1,24 +1,69 @@
 package v5
 
 import (
-	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/scigolib/matlab/types"
 )
 
 // Parser handles parsing of v5 MAT-files.
 type Parser struct {
-	r      io.Reader
-	Header *Header
-	pos    int64
+	r        io.Reader
+	Header   *Header
+	pos      int64
+	features *Features      // Shared with sub-parsers; nil disables recording
+	inflater *inflater      // Reused for compressed elements; created on first use
+	budget   *inflateBudget // Decompression so far in the current top-level element
+	padding  *int64         // Alignment padding read outside compressed data; nil disables counting
+	scratch  [8]byte        // Buffer of tags and padding
+
+	// Per-element state, kept in the parser to save allocations: the tag
+	// of the current top-level element and its decompression budget, the
+	// header of the matrix being parsed, the parser reused by sub and the
+	// reader it parses.
+	tag     DataTag
+	element inflateBudget
+	header  arrayHeader
+	child   *Parser
+	mem     memReader
+	batch   *batch // Shared with sub-parsers; nil allocates each value on its own
+
+	// Transform, if set, is applied to each top-level variable as soon as
+	// it is parsed. Returning nil drops the variable.
+	Transform func(*types.Variable) (*types.Variable, error)
+
+	// Allocator, if set, provides the slices numeric data is decoded into.
+	Allocator types.Allocator
+
+	// DimLimits bounds the shape of arrays whose data is parsed.
+	DimLimits types.DimLimits
+
+	// Locations records the storage of each top-level variable read by
+	// Parse on the variable (see types.Variable.Location).
+	Locations bool
 }
 
 // Mat5File represents a parsed v5 MAT-file.
 type Mat5File struct {
 	Header    *Header
 	Variables []*types.Variable
+	Features  Features              // Format features found while parsing
+	Storage   []*types.VariableInfo // Storage of each top-level variable, in file order
+	Elements  int                   // Top-level data elements, including those that are not arrays
+	Padding   int64                 // Alignment padding bytes outside compressed elements
+}
+
+// Features records format features used by a file. Files written with
+// MATLAB's save -v6 use none of them; save -v7 (the default) compresses
+// variables and may store characters as UTF-8/16/32.
+type Features struct {
+	Compressed   bool // At least one miCOMPRESSED element
+	UnicodeChars bool // Character data in miUTF8, miUTF16 or miUTF32 elements
 }
 
 // NewParser creates a new v5 parser.
@@ -53,55 +98,45 @@ func (p *Parser) Parse() (*Mat5File, error) {
 	file := &Mat5File{
 		Header: p.Header,
 	}
+	p.features = &file.Features
+	p.padding = &file.Padding
+	p.batch = &batch{}
+	var infos []types.VariableInfo // Backing array of file.Storage
+	if s, ok := p.r.(io.Seeker); ok {
+		n := p.countElements(s)
+		file.Variables = make([]*types.Variable, 0, n)
+		file.Storage = make([]*types.VariableInfo, 0, n)
+		infos = make([]types.VariableInfo, 0, n)
+	}
 
 	for {
-		tag, err := p.readTag()
+		offset := p.pos
+		tag := &p.tag
+		err := p.readTagInto(tag)
 		if errors.Is(err, io.EOF) {
 			break
 		}
 		if err != nil {
 			return nil, err
 		}
+		file.Elements++
 
 		switch tag.DataType {
-		case miMATRIX:
-			variable, err := p.parseMatrix(tag)
+		case miMATRIX, miCOMPRESSED:
+			variable, inflated, err := p.parseElement(tag)
 			if err != nil {
 				return nil, err
 			}
-			file.Variables = append(file.Variables, variable)
-		case miCOMPRESSED:
-			// Decompress the data
-			decompressed, err := decompress(p.r, tag.Size)
-			if err != nil {
-				return nil, err
+			if variable == nil {
+				continue
 			}
-			p.pos += int64(tag.Size)
-
-			// Note: Compressed elements do NOT have padding after the data.
-			// The next element starts immediately after the compressed bytes.
-
-			// Parse the decompressed content (should contain a miMATRIX element)
-			sub := &Parser{
-				r:      bytes.NewReader(decompressed),
-				Header: p.Header,
-				pos:    0,
+			if p.Locations {
+				variable.SetLocation(types.Location{Offset: offset, Length: p.pos - offset, Compressed: inflated > 0})
 			}
-
-			// Read the tag from decompressed data
-			subTag, err := sub.readTag()
-			if err != nil {
+			file.Storage = append(file.Storage, storageInfo(&infos, variable, offset, p.pos-offset, inflated))
+			if err := p.addVariable(file, variable); err != nil {
 				return nil, err
 			}
-
-			// The decompressed data should contain a matrix
-			if subTag.DataType == miMATRIX {
-				variable, err := sub.parseMatrix(subTag)
-				if err != nil {
-					return nil, err
-				}
-				file.Variables = append(file.Variables, variable)
-			}
 		default:
 			p.skipData(tag)
 		}
@@ -110,30 +145,191 @@ func (p *Parser) Parse() (*Mat5File, error) {
 	return file, nil
 }
 
-// parseMatrix parses a matrix element.
-func (p *Parser) parseMatrix(tag *DataTag) (*types.Variable, error) {
-	data := make([]byte, tag.Size)
-	if _, err := io.ReadFull(p.r, data); err != nil {
-		return nil, err
+// countElements returns the number of matrix and compressed elements from
+// the current position to the end of the stream, jumping from tag to tag
+// by their sizes, and seeks back. It returns 0 if the elements cannot be
+// counted; Parse then grows its slices as it goes.
+func (p *Parser) countElements(s io.Seeker) int {
+	start, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+	defer func() {
+		_, _ = s.Seek(start, io.SeekStart)
+	}()
+
+	n := 0
+	var buf [8]byte
+	for {
+		if _, err := io.ReadFull(p.r, buf[:]); err != nil {
+			return n
+		}
+		first := p.Header.Order.Uint32(buf[0:4])
+		if size := first >> 16; size > 0 && size <= 4 {
+			continue // Small element: data is in the tag
+		}
+		size := int64(p.Header.Order.Uint32(buf[4:8]))
+		switch first {
+		case miMATRIX:
+			n++
+			size += (8 - size%8) % 8
+		case miCOMPRESSED:
+			n++ // Compressed elements are not padded
+		default:
+			size += (8 - size%8) % 8
+		}
+		if _, err := s.Seek(size, io.SeekCurrent); err != nil {
+			return n
+		}
+	}
+}
+
+// parseElement parses a top-level miMATRIX or miCOMPRESSED element whose
+// tag has been read. It returns the variable, or nil for a compressed
+// element that does not hold a matrix, and the inflated size of compressed
+// elements (0 otherwise).
+func (p *Parser) parseElement(tag *DataTag) (*types.Variable, int64, error) {
+	p.element = inflateBudget{stored: 8 + int64(tag.Size)}
+	p.budget = &p.element
+	if tag.DataType == miMATRIX {
+		variable, err := p.parseMatrix(tag)
+		return variable, 0, err
+	}
+	return p.parseCompressed(tag)
+}
+
+// parseCompressed parses a miCOMPRESSED element whose tag has been read,
+// at the top level or nested in a container. MATLAB only compresses
+// top-level variables, but other writers also compress struct fields.
+func (p *Parser) parseCompressed(tag *DataTag) (*types.Variable, int64, error) {
+	if p.inflater == nil {
+		p.inflater = &inflater{}
+	}
+	decompressed, err := p.inflater.inflate(p.r, tag.Size, p.budget)
+	if err != nil {
+		return nil, 0, err
 	}
 	p.pos += int64(tag.Size)
+	if p.features != nil {
+		p.features.Compressed = true
+	}
 
-	sub := &Parser{
-		r:      bytes.NewReader(data),
-		Header: p.Header,
-		pos:    0,
+	// Note: Compressed elements do NOT have padding after the data.
+	// The next element starts immediately after the compressed bytes.
+
+	// Parse the decompressed content (should contain a miMATRIX element)
+	sub := p.sub(decompressed)
+	sub.padding = nil // Not stored in the file
+	subTag, err := sub.readTag()
+	if err != nil {
+		return nil, 0, err
 	}
-	return sub.parseMatrixContent()
+	if subTag.DataType != miMATRIX {
+		return nil, 0, nil
+	}
+	variable, err := sub.parseMatrix(subTag)
+	if err != nil {
+		return nil, 0, err
+	}
+	return variable, int64(len(decompressed)), nil
 }
 
-// parseMatrixContent parses the components of a matrix.
-func (p *Parser) parseMatrixContent() (*types.Variable, error) {
-	// Read array flags
-	flagsTag, err := p.readTag()
+// sub returns a parser of an element read into memory, sharing the
+// state of p. The parser is reused by the next call, so it must not be
+// used once the element has been parsed.
+func (p *Parser) sub(data []byte) *Parser {
+	c := p.child
+	if c == nil {
+		c = &Parser{}
+		p.child = c
+	}
+	c.Header = p.Header
+	c.pos = 0
+	c.features = p.features
+	c.inflater = p.inflater
+	c.budget = p.budget
+	c.padding = p.padding
+	c.batch = p.batch
+	c.Allocator = p.Allocator
+	c.DimLimits = p.DimLimits
+	c.mem = memReader{data: data}
+	c.r = &c.mem
+	return c
+}
+
+// storageInfo describes how a parsed variable is stored: size bytes at
+// offset, inflated to the given size if compressed (0 otherwise). The
+// description is appended to infos while it has room, and allocated on
+// its own otherwise (or if infos is nil), so the returned pointers stay
+// valid.
+func storageInfo(infos *[]types.VariableInfo, variable *types.Variable, offset, size, inflated int64) *types.VariableInfo {
+	var info *types.VariableInfo
+	if infos != nil && len(*infos) < cap(*infos) {
+		*infos = (*infos)[:len(*infos)+1]
+		info = &(*infos)[len(*infos)-1]
+	} else {
+		info = new(types.VariableInfo)
+	}
+	*info = types.VariableInfo{
+		Name:             variable.Name,
+		Dimensions:       variable.Dimensions,
+		DataType:         variable.DataType,
+		IsComplex:        variable.IsComplex,
+		IsSparse:         variable.IsSparse,
+		Compressed:       inflated > 0,
+		Offset:           offset,
+		Size:             size,
+		UncompressedSize: size,
+	}
+	if inflated > 0 {
+		info.UncompressedSize = inflated
+	}
+	return info
+}
+
+// addVariable applies Transform to a parsed variable and appends the
+// result to the file's variables.
+func (p *Parser) addVariable(file *Mat5File, variable *types.Variable) error {
+	if p.Transform != nil {
+		name := variable.Name
+		var err error
+		if variable, err = p.Transform(variable); err != nil {
+			return fmt.Errorf("transform %q: %w", name, err)
+		}
+		if variable == nil {
+			return nil
+		}
+	}
+	file.Variables = append(file.Variables, variable)
+	return nil
+}
+
+// parseMatrix parses a matrix element.
+func (p *Parser) parseMatrix(tag *DataTag) (*types.Variable, error) {
+	data, err := p.next(int(tag.Size))
 	if err != nil {
 		return nil, err
 	}
-	flagsData, err := p.readData(flagsTag)
+	p.pos += int64(tag.Size)
+	return p.sub(data).parseMatrixContent()
+}
+
+// arrayHeader holds the array flags, dimensions and name that start every
+// matrix element.
+type arrayHeader struct {
+	class      uint32
+	isComplex  bool
+	isLogical  bool
+	nzmax      int // Allocated elements of sparse arrays
+	dimensions []int
+	name       string
+}
+
+// readArrayHeader reads the array flags, dimensions and name subelements.
+// The header is held by p until the next call.
+func (p *Parser) readArrayHeader() (*arrayHeader, error) {
+	// Read array flags
+	_, flagsData, err := p.readSubelement()
 	if err != nil {
 		return nil, err
 	}
@@ -142,64 +338,102 @@ func (p *Parser) parseMatrixContent() (*types.Variable, error) {
 		return nil, errors.New("invalid array flags size")
 	}
 
+	// The class is the low byte of the first word, next to the flag bits.
+	// Files from earlier versions of this library stored it in the second
+	// word (reserved, or nzmax for sparse arrays) and left the byte zero.
 	flags := p.Header.Order.Uint32(flagsData[:4])
-	class := p.Header.Order.Uint32(flagsData[4:8])
-	isComplex := (flags & 0x0800) != 0
+	hdr := &p.header
+	*hdr = arrayHeader{
+		class:     flags & 0xFF,
+		isComplex: (flags & 0x0800) != 0,
+		isLogical: (flags & 0x0200) != 0,
+	}
+	if hdr.class == 0 {
+		hdr.class = p.Header.Order.Uint32(flagsData[4:8])
+	} else if hdr.class == mxSPARSE_CLASS {
+		hdr.nzmax = int(p.Header.Order.Uint32(flagsData[4:8]))
+	}
 
 	// Read dimensions
-	dimsTag, err := p.readTag()
-	if err != nil {
-		return nil, err
-	}
-	dimsData, err := p.readData(dimsTag)
+	_, dimsData, err := p.readSubelement()
 	if err != nil {
 		return nil, err
 	}
 
 	dimCount := len(dimsData) / 4
-	dimensions := make([]int, dimCount)
+	hdr.dimensions = p.batch.ints(dimCount)
 	for i := 0; i < dimCount; i++ {
-		dimensions[i] = int(p.Header.Order.Uint32(dimsData[i*4 : (i+1)*4]))
+		hdr.dimensions[i] = int(p.Header.Order.Uint32(dimsData[i*4 : (i+1)*4]))
 	}
 
 	// Read variable name
-	nameTag, err := p.readTag()
+	_, nameData, err := p.readSubelement()
 	if err != nil {
 		return nil, err
 	}
-	nameData, err := p.readData(nameTag)
+	hdr.name = strings.TrimRight(string(nameData), "\x00")
+
+	return hdr, nil
+}
+
+// parseMatrixContent parses the components of a matrix.
+func (p *Parser) parseMatrixContent() (*types.Variable, error) {
+	hdr, err := p.readArrayHeader()
 	if err != nil {
 		return nil, err
 	}
-	name := string(nameData)
+	return p.parseArrayContent(hdr)
+}
+
+// parseArrayContent parses the data of a matrix whose array header has
+// been read.
+func (p *Parser) parseArrayContent(hdr *arrayHeader) (*types.Variable, error) {
+	class, isComplex, isLogical := hdr.class, hdr.isComplex, hdr.isLogical
+	dimensions, name := hdr.dimensions, hdr.name
+	if err := p.DimLimits.Check(dimensions); err != nil {
+		return nil, fmt.Errorf("array %q: %w", name, err)
+	}
+
+	// Struct arrays carry field names and nested matrices instead of data
+	if class == mxSTRUCT_CLASS {
+		return p.parseStructContent(name, dimensions)
+	}
+	if class == mxOBJECT_CLASS {
+		return p.parseObjectContent(name, dimensions)
+	}
+	if class == mxCELL_CLASS {
+		return p.parseCellContent(name, dimensions)
+	}
+	if class == mxSPARSE_CLASS {
+		return p.parseSparseContent(hdr)
+	}
 
 	// Read real data
-	realTag, err := p.readTag()
+	realType, realData, err := p.readSubelement()
 	if err != nil {
 		return nil, err
 	}
-	realData, err := p.readData(realTag)
-	if err != nil {
-		return nil, err
+	realValue := p.castToClass(p.convertData(realData, realType, class), class)
+	if class == mxCHAR_CLASS && p.features != nil {
+		switch realType {
+		case miUTF8, miUTF16, miUTF32:
+			p.features.UnicodeChars = true
+		}
 	}
-	realValue := p.convertData(realData, realTag.DataType, class)
 
 	// Read imaginary data if complex
 	var imagValue interface{}
 	if isComplex {
-		imagTag, err := p.readTag()
-		if err != nil {
-			return nil, err
-		}
-		imagData, err := p.readData(imagTag)
+		imagType, imagData, err := p.readSubelement()
 		if err != nil {
 			return nil, err
 		}
-		imagValue = p.convertData(imagData, imagTag.DataType, class)
+		imagValue = p.castToClass(p.convertData(imagData, imagType, class), class)
 	}
 
 	// Create variable
-	variable := &types.Variable{
+	variable := p.batch.variable()
+	*variable = types.Variable{
 		Name:       name,
 		Dimensions: dimensions,
 		DataType:   classToDataType(class),
@@ -207,6 +441,19 @@ func (p *Parser) parseMatrixContent() (*types.Variable, error) {
 		IsComplex:  isComplex,
 	}
 
+	// Character arrays are decoded to a Go string
+	if class == mxCHAR_CLASS && !isComplex {
+		variable.Data = decodeChars(realValue)
+	}
+
+	// Logical arrays are stored as uint8 with the logical flag set
+	if isLogical && !isComplex {
+		if raw, ok := realValue.([]byte); ok {
+			variable.DataType = types.Logical
+			variable.Data = uint8ToBool(raw)
+		}
+	}
+
 	// For complex numbers, create a complex array
 	if isComplex {
 		variable.Data = &types.NumericArray{
@@ -220,6 +467,181 @@ func (p *Parser) parseMatrixContent() (*types.Variable, error) {
 	return variable, nil
 }
 
+// parseStructContent parses the field names and field values of a struct
+// array. It is called after the array flags, dimensions and name have been
+// read.
+//
+// Layout (MAT-File Format, "Structure MAT-File Data Element"):
+//   - Field name length (int32): bytes per name, including NUL padding
+//   - Field names (int8): all names, each padded to the name length
+//   - For each element (column-major), for each field: a miMATRIX element
+func (p *Parser) parseStructContent(name string, dimensions []int) (*types.Variable, error) {
+	_, lenData, err := p.readSubelement()
+	if err != nil {
+		return nil, err
+	}
+	if len(lenData) < 4 {
+		return nil, errors.New("invalid struct field name length")
+	}
+	nameLen := int(p.Header.Order.Uint32(lenData[:4]))
+
+	_, namesData, err := p.readSubelement()
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	if nameLen > 0 {
+		for off := 0; off+nameLen <= len(namesData); off += nameLen {
+			fields = append(fields, strings.TrimRight(string(namesData[off:off+nameLen]), "\x00"))
+		}
+	}
+
+	count := 1
+	for _, d := range dimensions {
+		count *= d
+	}
+
+	st := &types.StructArray{
+		Fields:     fields,
+		Dimensions: dimensions,
+	}
+	for i := 0; i < count && len(fields) > 0; i++ {
+		element := make([]*types.Variable, len(fields))
+		for j, field := range fields {
+			fieldTag, err := p.readTag()
+			if err != nil {
+				return nil, fmt.Errorf("struct %q field %q: %w", name, field, err)
+			}
+			var value *types.Variable
+			switch fieldTag.DataType {
+			case miMATRIX:
+				value, err = p.parseNestedMatrix(fieldTag)
+			case miCOMPRESSED:
+				value, err = p.parseNestedCompressed(fieldTag)
+			default:
+				return nil, fmt.Errorf("struct %q field %q: expected miMATRIX, got type %d", name, field, fieldTag.DataType)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("struct %q field %q: %w", name, field, err)
+			}
+			value.Name = field
+			element[j] = value
+		}
+		st.Elements = append(st.Elements, element)
+	}
+
+	return &types.Variable{
+		Name:       name,
+		Dimensions: dimensions,
+		DataType:   types.Struct,
+		Data:       st,
+	}, nil
+}
+
+// parseObjectContent parses an object of a class defined in MATLAB's
+// older, pre-classdef style. Its class name precedes fields laid out as
+// those of a struct; it is returned as a struct whose MATLAB_class
+// attribute names the class, as v7.3 objects stored as structs are.
+func (p *Parser) parseObjectContent(name string, dimensions []int) (*types.Variable, error) {
+	_, classData, err := p.readSubelement()
+	if err != nil {
+		return nil, fmt.Errorf("object %q class name: %w", name, err)
+	}
+	variable, err := p.parseStructContent(name, dimensions)
+	if err != nil {
+		return nil, err
+	}
+	if class := strings.TrimRight(string(classData), "\x00"); class != "" {
+		variable.Attributes = map[string]interface{}{types.AttrMatlabClass: class}
+	}
+	return variable, nil
+}
+
+// parseCellContent parses the contents of a cell array: one miMATRIX
+// element per cell, in column-major order, each with an empty name. It is
+// called after the array flags, dimensions and name have been read.
+func (p *Parser) parseCellContent(name string, dimensions []int) (*types.Variable, error) {
+	count := 1
+	for _, d := range dimensions {
+		count *= d
+	}
+	// Each cell takes at least a tag, so a damaged count fails on reading
+	// rather than on allocation
+	cells := make([]*types.Variable, 0, min(count, 1024))
+	for i := 0; i < count; i++ {
+		tag, err := p.readTag()
+		if err != nil {
+			return nil, fmt.Errorf("cell %q element %d: %w", name, i+1, err)
+		}
+		var value *types.Variable
+		switch tag.DataType {
+		case miMATRIX:
+			value, err = p.parseNestedMatrix(tag)
+		case miCOMPRESSED:
+			value, err = p.parseNestedCompressed(tag)
+		default:
+			return nil, fmt.Errorf("cell %q element %d: expected miMATRIX, got type %d", name, i+1, tag.DataType)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cell %q element %d: %w", name, i+1, err)
+		}
+		cells = append(cells, value)
+	}
+
+	return &types.Variable{
+		Name:       name,
+		Dimensions: dimensions,
+		DataType:   types.CellArray,
+		Data:       cells,
+	}, nil
+}
+
+// parseNestedMatrix parses a miMATRIX element nested inside a container.
+// A zero-length element denotes an empty [] value.
+func (p *Parser) parseNestedMatrix(tag *DataTag) (*types.Variable, error) {
+	if tag.Size == 0 {
+		return &types.Variable{
+			Dimensions: []int{0, 0},
+			DataType:   types.Double,
+			Data:       []float64{},
+		}, nil
+	}
+	return p.parseMatrix(tag)
+}
+
+// parseNestedCompressed parses a miCOMPRESSED element nested inside a
+// container. Writers disagree on whether nested compressed elements are
+// padded to 8 bytes, so zero padding after one is skipped.
+func (p *Parser) parseNestedCompressed(tag *DataTag) (*types.Variable, error) {
+	value, _, err := p.parseCompressed(tag)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, errors.New("compressed element does not hold a matrix")
+	}
+	if m, ok := p.r.(*memReader); ok {
+		padding := int((8 - tag.Size%8) % 8)
+		if rest := m.data[m.off:]; len(rest) >= padding && allZero(rest[:padding]) {
+			m.off += padding
+			p.pos += int64(padding)
+			p.countPadding(int64(padding))
+		}
+	}
+	return value, nil
+}
+
+// allZero reports whether every byte of b is zero.
+func allZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // readData reads data for a given tag.
 func (p *Parser) readData(tag *DataTag) ([]byte, error) {
 	// For small format, data is already captured in the tag
@@ -228,8 +650,8 @@ func (p *Parser) readData(tag *DataTag) ([]byte, error) {
 	}
 
 	// Regular format: read data from stream
-	data := make([]byte, tag.Size)
-	if _, err := io.ReadFull(p.r, data); err != nil {
+	data, err := p.next(int(tag.Size))
+	if err != nil {
 		return nil, err
 	}
 	p.pos += int64(tag.Size)
@@ -237,13 +659,104 @@ func (p *Parser) readData(tag *DataTag) ([]byte, error) {
 	// Skip padding to 8-byte boundary
 	padding := (8 - tag.Size%8) % 8
 	if padding > 0 {
-		_, _ = io.CopyN(io.Discard, p.r, int64(padding))
+		_, _ = io.ReadFull(p.r, p.scratch[:padding])
 		p.pos += int64(padding)
+		p.countPadding(int64(padding))
 	}
 
 	return data, nil
 }
 
+// countPadding records n bytes of alignment padding.
+func (p *Parser) countPadding(n int64) {
+	if p.padding != nil {
+		*p.padding += n
+	}
+}
+
+// readSubelement reads a data element, tag and data, as readTag and
+// readData do, and returns its type and data. Within an element read
+// into memory it does not allocate.
+func (p *Parser) readSubelement() (uint32, []byte, error) {
+	m, ok := p.r.(*memReader)
+	if !ok {
+		tag, err := p.readTag()
+		if err != nil {
+			return 0, nil, err
+		}
+		data, err := p.readData(tag)
+		return tag.DataType, data, err
+	}
+
+	buf, err := m.next(8)
+	if err != nil {
+		return 0, nil, err
+	}
+	p.pos += 8
+	first := p.Header.Order.Uint32(buf[0:4])
+	if size := first >> 16; size > 0 && size <= 4 {
+		return first & 0xFFFF, buf[4 : 4+size : 4+size], nil
+	}
+	size := p.Header.Order.Uint32(buf[4:8])
+	if size > maxReasonableSize {
+		return 0, nil, fmt.Errorf("tag size too large: %d bytes (max %d)", size, maxReasonableSize)
+	}
+	data, err := m.next(int(size))
+	if err != nil {
+		return 0, nil, err
+	}
+	padding := min(int((8-size%8)%8), len(m.data)-m.off)
+	m.off += padding
+	p.pos += int64(size) + int64(padding)
+	p.countPadding(int64(padding))
+	return first, data, nil
+}
+
+// next reads the next n bytes of the stream. Within an element read into
+// memory, the bytes are a view of the element, not a copy; the element's
+// buffer belongs to the variable parsed from it.
+func (p *Parser) next(n int) ([]byte, error) {
+	if m, ok := p.r.(*memReader); ok {
+		return m.next(n)
+	}
+	data := p.batch.bytes(n)
+	if _, err := io.ReadFull(p.r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// memReader reads the content of an element held in memory.
+type memReader struct {
+	data []byte
+	off  int
+}
+
+// Read implements io.Reader.
+func (m *memReader) Read(b []byte) (int, error) {
+	if m.off >= len(m.data) {
+		return 0, io.EOF
+	}
+	n := copy(b, m.data[m.off:])
+	m.off += n
+	return n, nil
+}
+
+// next returns the next n bytes, with the errors of io.ReadFull.
+func (m *memReader) next(n int) ([]byte, error) {
+	rest := len(m.data) - m.off
+	switch {
+	case n > rest && rest == 0:
+		return nil, io.EOF
+	case n > rest:
+		m.off = len(m.data)
+		return nil, io.ErrUnexpectedEOF
+	}
+	data := m.data[m.off : m.off+n : m.off+n]
+	m.off += n
+	return data, nil
+}
+
 // skipData skips over data for a given tag.
 func (p *Parser) skipData(tag *DataTag) {
 	// For small format, data was already read with the tag - nothing to skip
@@ -257,7 +770,52 @@ func (p *Parser) skipData(tag *DataTag) {
 
 	padding := (8 - tag.Size%8) % 8
 	if padding > 0 {
-		_, _ = io.CopyN(io.Discard, p.r, int64(padding))
+		_, _ = io.ReadFull(p.r, p.scratch[:padding])
 		p.pos += int64(padding)
+		p.countPadding(int64(padding))
+	}
+}
+
+// uint8ToBool converts logical values stored as bytes to []bool.
+func uint8ToBool(data []byte) []bool {
+	result := make([]bool, len(data))
+	for i, val := range data {
+		result[i] = val != 0
+	}
+	return result
+}
+
+// decodeChars converts character data to a Go string.
+//
+// MATLAB stores characters as UTF-16 code units (miUINT16) or, in files
+// written by some tools, as single bytes or UTF-8.
+func decodeChars(data interface{}) interface{} {
+	switch chars := data.(type) {
+	case []uint16:
+		return string(utf16.Decode(chars))
+	case []byte:
+		return decodeByteChars(chars)
+	case []int8:
+		buf := make([]byte, len(chars))
+		for i, c := range chars {
+			buf[i] = byte(c)
+		}
+		return decodeByteChars(buf)
+	default:
+		return data
+	}
+}
+
+// decodeByteChars decodes single-byte character data. Valid UTF-8 is kept
+// as is; anything else is taken to be in an ANSI code page and decoded as
+// Latin-1, which maps each byte to the code point of the same value.
+func decodeByteChars(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
 	}
+	return string(runes)
 }
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"compress/zlib"
 	"encoding/binary"
+	"io"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/scigolib/matlab/types"
@@ -1925,3 +1927,482 @@ func TestParse_SkipData_RegularFormatWithPadding(t *testing.T) {
 		t.Errorf("Name = %q, want %q", file.Variables[0].Name, "target")
 	}
 }
+
+// TestParse_Logical tests that the logical array flag yields []bool data.
+func TestParse_Logical(t *testing.T) {
+	r := buildV5TestData(t, &types.Variable{
+		Name:       "mask",
+		Dimensions: []int{1, 3},
+		DataType:   types.Logical,
+		Data:       []bool{true, false, true},
+	})
+
+	parser, err := NewParser(r)
+	if err != nil {
+		t.Fatalf("NewParser failed: %v", err)
+	}
+	file, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(file.Variables) != 1 {
+		t.Fatalf("expected 1 variable, got %d", len(file.Variables))
+	}
+
+	v := file.Variables[0]
+	if v.DataType != types.Logical {
+		t.Errorf("DataType = %v, want logical", v.DataType)
+	}
+	if !reflect.DeepEqual(v.Data, []bool{true, false, true}) {
+		t.Errorf("Data = %v, want [true false true]", v.Data)
+	}
+}
+
+// TestParse_StructRoundTrip tests writing and parsing a scalar struct with
+// numeric, char and nested struct fields.
+func TestParse_StructRoundTrip(t *testing.T) {
+	inner := &types.Variable{
+		Name:       "inner",
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     []string{"gain"},
+			Dimensions: []int{1, 1},
+			Elements: [][]*types.Variable{{
+				{Name: "gain", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{2.5}},
+			}},
+		},
+	}
+	cfg := &types.Variable{
+		Name:       "cfg",
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     []string{"rate", "label", "inner"},
+			Dimensions: []int{1, 1},
+			Elements: [][]*types.Variable{{
+				{Name: "rate", Dimensions: []int{1, 1}, DataType: types.Int32, Data: []int32{1000}},
+				{Name: "label", Dimensions: []int{1, 5}, DataType: types.Char, Data: "héllo"},
+				inner,
+			}},
+		},
+	}
+
+	parser, err := NewParser(buildV5TestData(t, cfg))
+	if err != nil {
+		t.Fatalf("NewParser failed: %v", err)
+	}
+	file, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(file.Variables) != 1 {
+		t.Fatalf("expected 1 variable, got %d", len(file.Variables))
+	}
+
+	v := file.Variables[0]
+	if v.Name != "cfg" || v.DataType != types.Struct {
+		t.Fatalf("got %v, want cfg struct", v)
+	}
+	st, ok := v.Data.(*types.StructArray)
+	if !ok {
+		t.Fatalf("Data type = %T, want *types.StructArray", v.Data)
+	}
+	if !reflect.DeepEqual(st.Fields, []string{"rate", "label", "inner"}) {
+		t.Errorf("Fields = %v", st.Fields)
+	}
+
+	if rate := st.Field(0, "rate"); rate == nil || !reflect.DeepEqual(rate.Data, []int32{1000}) {
+		t.Errorf("rate = %v", rate)
+	}
+	if label := st.Field(0, "label"); label == nil || label.Data != "héllo" || label.DataType != types.Char {
+		t.Errorf("label = %#v", label)
+	}
+	nested, ok := st.Field(0, "inner").Data.(*types.StructArray)
+	if !ok {
+		t.Fatalf("inner Data = %T, want *types.StructArray", st.Field(0, "inner").Data)
+	}
+	if gain := nested.Field(0, "gain"); gain == nil || !reflect.DeepEqual(gain.Data, []float64{2.5}) {
+		t.Errorf("inner.gain = %v", gain)
+	}
+}
+
+// TestParse_StructEmptyField tests that a zero-length nested miMATRIX is
+// decoded as an empty value.
+func TestParse_StructEmptyField(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Test", "IM")
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+
+	flags := make([]byte, 8)
+	binary.LittleEndian.PutUint32(flags[4:], mxSTRUCT_CLASS)
+	dims := make([]byte, 8)
+	binary.LittleEndian.PutUint32(dims[0:], 1)
+	binary.LittleEndian.PutUint32(dims[4:], 1)
+	nameLen := make([]byte, 4)
+	binary.LittleEndian.PutUint32(nameLen, 8)
+
+	var content []byte
+	content = append(content, w.wrapInTag(miUINT32, flags)...)
+	content = append(content, w.wrapInTag(miINT32, dims)...)
+	content = append(content, w.wrapInTag(miINT8, []byte("s"))...)
+	content = append(content, w.wrapInTag(miINT32, nameLen)...)
+	content = append(content, w.wrapInTag(miINT8, []byte("empty\x00\x00\x00"))...)
+	content = append(content, w.wrapInTag(miMATRIX, nil)...)
+	buf.Write(w.wrapInTag(miMATRIX, content))
+
+	parser, err := NewParser(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewParser failed: %v", err)
+	}
+	file, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	st := file.Variables[0].Data.(*types.StructArray)
+	empty := st.Field(0, "empty")
+	if empty == nil || !reflect.DeepEqual(empty.Dimensions, []int{0, 0}) {
+		t.Errorf("empty = %v, want 0x0 value", empty)
+	}
+}
+
+// v6Element encodes a little-endian data element the way MATLAB does:
+// small format for up to 4 bytes, otherwise a tag followed by data
+// padded to 8 bytes.
+func v6Element(dataType uint32, data []byte) []byte {
+	if len(data) <= 4 && dataType != miMATRIX {
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint32(buf, uint32(len(data))<<16|dataType)
+		copy(buf[4:], data)
+		return buf
+	}
+	buf := make([]byte, 8, 8+len(data)+7)
+	binary.LittleEndian.PutUint32(buf, dataType)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(len(data)))
+	buf = append(buf, data...)
+	for len(buf)%8 != 0 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+// v6Matrix encodes a real matrix with the class in the low byte of the
+// first array flags word, as MATLAB writes it.
+func v6Matrix(class uint32, dims []uint32, name string, dataType uint32, data []byte) []byte {
+	flags := make([]byte, 8)
+	binary.LittleEndian.PutUint32(flags, class)
+	dimBytes := make([]byte, 4*len(dims))
+	for i, d := range dims {
+		binary.LittleEndian.PutUint32(dimBytes[i*4:], d)
+	}
+
+	var content []byte
+	content = append(content, v6Element(miUINT32, flags)...)
+	content = append(content, v6Element(miINT32, dimBytes)...)
+	content = append(content, v6Element(miINT8, []byte(name))...)
+	content = append(content, v6Element(dataType, data)...)
+	return v6Element(miMATRIX, content)
+}
+
+// TestParse_V6Quirks tests reading data laid out like MATLAB's save -v6
+// output: class in the flags word, values stored in the smallest integer
+// type, small-format names and single-byte ANSI characters.
+func TestParse_V6Quirks(t *testing.T) {
+	header := make([]byte, 128)
+	copy(header, "MATLAB 5.0 MAT-file, Platform: PCWIN, Created on: Mon Jan 1 00:00:00 2001")
+	binary.LittleEndian.PutUint16(header[124:], 0x0100)
+	copy(header[126:], "IM")
+
+	hi := make([]byte, 4)
+	binary.LittleEndian.PutUint16(hi, 'h')
+	binary.LittleEndian.PutUint16(hi[2:], 'i')
+
+	var file bytes.Buffer
+	file.Write(header)
+	file.Write(v6Matrix(mxDOUBLE_CLASS, []uint32{1, 3}, "x", miUINT8, []byte{1, 2, 3}))
+	file.Write(v6Matrix(mxINT32_CLASS, []uint32{1, 2}, "n", miINT16, []byte{0xFE, 0xFF, 0x05, 0x00}))
+	file.Write(v6Matrix(mxCHAR_CLASS, []uint32{1, 2}, "s", miUINT16, hi))
+	file.Write(v6Matrix(mxCHAR_CLASS, []uint32{1, 4}, "caf", miUINT8, []byte{'c', 'a', 'f', 0xE9}))
+
+	parser, err := NewParser(&file)
+	if err != nil {
+		t.Fatalf("NewParser() error: %v", err)
+	}
+	mat, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	want := []*types.Variable{
+		{Name: "x", Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{1, 2, 3}},
+		{Name: "n", Dimensions: []int{1, 2}, DataType: types.Int32, Data: []int32{-2, 5}},
+		{Name: "s", Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"},
+		{Name: "caf", Dimensions: []int{1, 4}, DataType: types.Char, Data: "caf\u00e9"},
+	}
+	if len(mat.Variables) != len(want) {
+		t.Fatalf("got %d variables, want %d", len(mat.Variables), len(want))
+	}
+	for i, w := range want {
+		if !reflect.DeepEqual(mat.Variables[i], w) {
+			t.Errorf("variable %d = %+v, want %+v", i, mat.Variables[i], w)
+		}
+	}
+
+	if mat.Features != (Features{}) {
+		t.Errorf("Features = %+v, want none", mat.Features)
+	}
+}
+
+// TestParse_Features tests detection of v7 features.
+func TestParse_Features(t *testing.T) {
+	reader := buildV5TestData(t, &types.Variable{
+		Name: "s", Dimensions: []int{1, 2}, DataType: types.Char, Data: "ok",
+	})
+	parser, err := NewParser(reader)
+	if err != nil {
+		t.Fatalf("NewParser() error: %v", err)
+	}
+	mat, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if !mat.Features.UnicodeChars || mat.Features.Compressed {
+		t.Errorf("Features = %+v, want UnicodeChars only", mat.Features)
+	}
+}
+
+// TestParse_Preallocates checks that Parse counts the elements of a
+// seekable stream before parsing it, and parses unseekable streams alike.
+func TestParse_Preallocates(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "", "IM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*types.Variable{
+		{Name: "a", Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{1, 2, 3}},
+		{Name: "b", Dimensions: []int{1, 2}, DataType: types.Uint8, Data: []uint8{4, 5}},
+		{Name: "c", Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"},
+	}
+	for i, v := range want {
+		w.Compression = 6 * (i % 2)
+		if err := w.WriteVariable(v); err != nil {
+			t.Fatal(err)
+		}
+		// An element of another type between the variables
+		buf.Write([]byte{miUINT8, 0, 0, 0, 3, 0, 0, 0, 1, 2, 3, 0, 0, 0, 0, 0})
+	}
+
+	for _, r := range []io.Reader{
+		bytes.NewReader(buf.Bytes()),
+		io.MultiReader(bytes.NewReader(buf.Bytes())),
+	} {
+		p, err := NewParser(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := p.Parse()
+		if err != nil {
+			t.Fatalf("Parse(%T) error = %v", r, err)
+		}
+		if !reflect.DeepEqual(file.Variables, want) {
+			t.Errorf("Parse(%T) = %+v, want %+v", r, file.Variables, want)
+		}
+		if _, ok := r.(io.Seeker); ok && cap(file.Variables) != len(want) {
+			t.Errorf("cap(Variables) = %d, want %d", cap(file.Variables), len(want))
+		}
+	}
+}
+
+// TestParse_BatchedValues checks that the dimensions, data and
+// variables Parse cuts from shared chunks do not overlap.
+func TestParse_BatchedValues(t *testing.T) {
+	r := buildV5TestData(t,
+		&types.Variable{Name: "a", Dimensions: []int{1, 2}, DataType: types.Uint8, Data: []uint8{1, 2}},
+		&types.Variable{Name: "b", Dimensions: []int{1, 2}, DataType: types.Uint8, Data: []uint8{3, 4}},
+	)
+	p, err := NewParser(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := file.Variables[0], file.Variables[1]
+	_ = append(a.Dimensions, 9, 9)
+	_ = append(a.Data.([]uint8), 9, 9)
+	*a = types.Variable{}
+	want := &types.Variable{Name: "b", Dimensions: []int{1, 2}, DataType: types.Uint8, Data: []uint8{3, 4}}
+	if !reflect.DeepEqual(b, want) {
+		t.Errorf("b = %+v after changing a, want %+v", b, want)
+	}
+}
+
+// v6Compressed wraps an element in a miCOMPRESSED element, padded to 8
+// bytes if pad is set, as some third-party writers do.
+func v6Compressed(t *testing.T, element []byte, pad bool) []byte {
+	t.Helper()
+	var packed bytes.Buffer
+	zw := zlib.NewWriter(&packed)
+	if _, err := zw.Write(element); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 8, 8+packed.Len()+7)
+	binary.LittleEndian.PutUint32(buf, miCOMPRESSED)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(packed.Len()))
+	buf = append(buf, packed.Bytes()...)
+	for pad && len(buf)%8 != 0 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+// v6Struct encodes a 1xN struct array from the encoded field values of
+// each element, in element order.
+func v6Struct(name string, fields []string, n int, values ...[]byte) []byte {
+	flags := make([]byte, 8)
+	binary.LittleEndian.PutUint32(flags, mxSTRUCT_CLASS)
+	dims := make([]byte, 8)
+	binary.LittleEndian.PutUint32(dims, 1)
+	binary.LittleEndian.PutUint32(dims[4:], uint32(n))
+	nameLen := make([]byte, 4)
+	binary.LittleEndian.PutUint32(nameLen, 8)
+	var names []byte
+	for _, f := range fields {
+		names = append(names, f...)
+		names = append(names, make([]byte, 8-len(f))...)
+	}
+
+	var content []byte
+	content = append(content, v6Element(miUINT32, flags)...)
+	content = append(content, v6Element(miINT32, dims)...)
+	content = append(content, v6Element(miINT8, []byte(name))...)
+	content = append(content, v6Element(miINT32, nameLen)...)
+	content = append(content, v6Element(miINT8, names)...)
+	for _, v := range values {
+		content = append(content, v...)
+	}
+	return v6Element(miMATRIX, content)
+}
+
+// v6Object encodes a 1x1 object of a pre-classdef class from the encoded
+// values of its fields.
+func v6Object(name, class string, fields []string, values ...[]byte) []byte {
+	// A struct with the class name inserted after the variable name
+	st := v6Struct(name, fields, 1, values...)
+	content := st[8:]
+	binary.LittleEndian.PutUint32(content[8:], mxOBJECT_CLASS)
+	nameElement := v6Element(miINT8, []byte(name))
+	split := 16 + 16 + len(nameElement) // Flags, dimensions, name
+	var out []byte
+	out = append(out, content[:split]...)
+	out = append(out, v6Element(miINT8, []byte(class))...)
+	out = append(out, content[split:]...)
+	return v6Element(miMATRIX, out)
+}
+
+// TestParse_Object tests reading objects of pre-classdef classes as
+// structs that record their class.
+func TestParse_Object(t *testing.T) {
+	x := v6Matrix(mxDOUBLE_CLASS, []uint32{1, 3}, "", miUINT8, []byte{1, 2, 3})
+	mat, err := parseV6(v6Object("ts", "timeseries", []string{"Time", "Data"}, x, x))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	v := mat.Variables[0]
+	if v.Name != "ts" || v.DataType != types.Struct {
+		t.Fatalf("variable = %s %v, want ts struct", v.Name, v.DataType)
+	}
+	if class, _ := v.GetStringAttr(types.AttrMatlabClass); class != "timeseries" {
+		t.Errorf("class = %q, want timeseries", class)
+	}
+	st, ok := v.Data.(*types.StructArray)
+	if !ok || len(st.Elements) != 1 || !reflect.DeepEqual(st.Fields, []string{"Time", "Data"}) {
+		t.Fatalf("Data = %#v, want a 1x1 struct with fields Time and Data", v.Data)
+	}
+	if got := st.Elements[0][1].Data; !reflect.DeepEqual(got, []float64{1, 2, 3}) {
+		t.Errorf("Data field = %v, want [1 2 3]", got)
+	}
+}
+
+// parseV6 parses a little-endian v5 file of the given elements.
+func parseV6(elements ...[]byte) (*Mat5File, error) {
+	header := make([]byte, 128)
+	binary.LittleEndian.PutUint16(header[124:], 0x0100)
+	copy(header[126:], "IM")
+	file := bytes.NewBuffer(header)
+	for _, e := range elements {
+		file.Write(e)
+	}
+	parser, err := NewParser(file)
+	if err != nil {
+		return nil, err
+	}
+	return parser.Parse()
+}
+
+// TestParse_NestedCompressed tests reading struct fields stored as
+// miCOMPRESSED elements, padded or not and nested in compressed elements.
+func TestParse_NestedCompressed(t *testing.T) {
+	x := v6Matrix(mxDOUBLE_CLASS, []uint32{1, 3}, "", miUINT8, []byte{1, 2, 3})
+	y := v6Matrix(mxINT32_CLASS, []uint32{1, 1}, "", miINT32, []byte{7, 0, 0, 0})
+	for _, pad := range []bool{false, true} {
+		inner := v6Struct("", []string{"y"}, 1, v6Compressed(t, y, pad))
+		s := v6Struct("s", []string{"x", "inner", "z"}, 1,
+			v6Compressed(t, x, pad), v6Compressed(t, inner, pad), x)
+
+		mat, err := parseV6(v6Compressed(t, s, false))
+		if err != nil {
+			t.Fatalf("pad=%v: Parse() error: %v", pad, err)
+		}
+		st, ok := mat.Variables[0].Data.(*types.StructArray)
+		if !ok {
+			t.Fatalf("pad=%v: Data = %T, want *types.StructArray", pad, mat.Variables[0].Data)
+		}
+		for _, field := range []string{"x", "z"} {
+			if v := st.Field(0, field); v == nil || !reflect.DeepEqual(v.Data, []float64{1, 2, 3}) {
+				t.Errorf("pad=%v: %s = %v, want [1 2 3]", pad, field, v)
+			}
+		}
+		nested, ok := st.Field(0, "inner").Data.(*types.StructArray)
+		if !ok {
+			t.Fatalf("pad=%v: inner Data = %T, want *types.StructArray", pad, st.Field(0, "inner").Data)
+		}
+		if v := nested.Field(0, "y"); v == nil || !reflect.DeepEqual(v.Data, []int32{7}) {
+			t.Errorf("pad=%v: inner.y = %v, want [7]", pad, v)
+		}
+		if !mat.Features.Compressed {
+			t.Errorf("pad=%v: Features.Compressed not set", pad)
+		}
+	}
+}
+
+// TestParse_NestedCompressedLimit tests that the compression ratio limit
+// applies to a top-level element and its nested compressed elements
+// together: each field below is within the limit, but all of them
+// inflate far beyond it from the few bytes stored in the file.
+func TestParse_NestedCompressedLimit(t *testing.T) {
+	field := v6Compressed(t, v6Matrix(mxDOUBLE_CLASS, []uint32{1, 1024}, "", miDOUBLE, make([]byte, 8192)), false)
+	if _, err := decompress(bytes.NewReader(field[8:]), uint32(len(field)-8)); err != nil {
+		t.Fatalf("field alone: %v", err)
+	}
+
+	values := make([][]byte, 1000)
+	for i := range values {
+		values[i] = field
+	}
+	s := v6Struct("s", []string{"x"}, len(values), values...)
+	if _, err := parseV6(v6Compressed(t, s, false)); err == nil || !strings.Contains(err.Error(), "compression ratio") {
+		t.Fatalf("Parse() error = %v, want compression ratio error", err)
+	}
+	if _, err := parseV6(s); err != nil {
+		t.Errorf("Parse() of the uncompressed struct: %v", err)
+	}
+}
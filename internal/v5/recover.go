@@ -0,0 +1,154 @@
+package v5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// Recovery is the result of scanning a damaged v5 MAT-file.
+type Recovery struct {
+	Header        *Header
+	HeaderDamaged bool                  // Header unreadable; byte order guessed
+	Variables     []*types.Variable     // Intact top-level variables, in file order
+	Storage       []*types.VariableInfo // Storage of each recovered variable
+	Gaps          []Gap                 // Byte ranges that could not be recovered
+}
+
+// Gap is a byte range of a damaged file that holds no intact variable.
+type Gap struct {
+	Offset int64  // Start of the range
+	Length int64  // Length of the range in bytes
+	Reason string // Why parsing failed at Offset
+}
+
+// Recover extracts the intact top-level variables from the bytes of a
+// damaged v5 MAT-file. After an element that is truncated or fails to
+// parse, it resynchronizes on the next plausible miMATRIX or miCOMPRESSED
+// tag, so variables following a corrupted region are still found.
+// Elements other than variables are skipped like damaged ones.
+func Recover(data []byte) *Recovery {
+	rec := &Recovery{}
+	if len(data) >= 128 {
+		rec.Header, _ = parseHeader(data[:128])
+	}
+	if rec.Header == nil {
+		rec.HeaderDamaged = true
+		rec.Header = &Header{Version: 0x0100, EndianIndicator: "IM", Order: binary.LittleEndian}
+		if len(data) < 128 {
+			rec.addGap(0, int64(len(data)), "file shorter than the 128-byte header")
+			return rec
+		}
+		if guessOrder(data) == binary.BigEndian {
+			rec.Header.EndianIndicator, rec.Header.Order = "MI", binary.BigEndian
+		}
+	}
+
+	n := int64(len(data))
+	pos := int64(128)
+	gapStart, gapReason := int64(-1), ""
+	for pos < n {
+		next, reason := rec.recoverElement(data, pos)
+		if reason == "" {
+			if gapStart >= 0 {
+				rec.addGap(gapStart, pos-gapStart, gapReason)
+				gapStart = -1
+			}
+			pos = next
+			continue
+		}
+		if gapStart < 0 {
+			gapStart, gapReason = pos, reason
+		}
+		pos = rec.resync(data, pos+1)
+	}
+	if gapStart >= 0 {
+		rec.addGap(gapStart, n-gapStart, gapReason)
+	}
+	return rec
+}
+
+// recoverElement parses the variable element at pos. It returns the offset
+// following the element, or the reason it is not an intact variable.
+func (rec *Recovery) recoverElement(data []byte, pos int64) (int64, string) {
+	n := int64(len(data))
+	if pos+8 > n {
+		return 0, fmt.Sprintf("truncated tag: %d of 8 bytes present", n-pos)
+	}
+	order := rec.Header.Order
+	dataType := order.Uint32(data[pos:])
+	if dataType != miMATRIX && dataType != miCOMPRESSED {
+		return 0, fmt.Sprintf("unexpected element type %d", dataType)
+	}
+	size := int64(order.Uint32(data[pos+4:]))
+	end := pos + 8 + size
+	if end > n {
+		return 0, fmt.Sprintf("truncated element: %d of %d bytes present", n-pos-8, size)
+	}
+
+	p := &Parser{r: bytes.NewReader(data[pos:end]), Header: rec.Header, features: &Features{}}
+	tag, err := p.readTag()
+	if err != nil {
+		return 0, err.Error()
+	}
+	variable, inflated, err := p.parseElement(tag)
+	if err != nil {
+		return 0, err.Error()
+	}
+	if variable == nil {
+		return 0, "compressed element does not hold a matrix"
+	}
+	if variable.Name == "" {
+		// Cell and struct elements nested in a damaged variable
+		return 0, "matrix element without a variable name"
+	}
+	variable.SetLocation(types.Location{Offset: pos, Length: end - pos, Compressed: inflated > 0})
+	rec.Variables = append(rec.Variables, variable)
+	rec.Storage = append(rec.Storage, storageInfo(nil, variable, pos, end-pos, inflated))
+	return end, ""
+}
+
+// resync returns the offset of the first plausible variable tag at or
+// after pos, or len(data) if there is none.
+func (rec *Recovery) resync(data []byte, pos int64) int64 {
+	n := int64(len(data))
+	for ; pos+16 <= n; pos++ {
+		if plausibleTag(data[pos:], rec.Header.Order) {
+			return pos
+		}
+	}
+	return n
+}
+
+// plausibleTag reports whether b starts with what looks like a variable
+// tag: miMATRIX followed by the 8-byte array flags element, or
+// miCOMPRESSED followed by a zlib stream header. b must hold 16 bytes.
+func plausibleTag(b []byte, order binary.ByteOrder) bool {
+	switch order.Uint32(b) {
+	case miMATRIX:
+		return order.Uint32(b[8:]) == miUINT32 && order.Uint32(b[12:]) == 8
+	case miCOMPRESSED:
+		cmf, flg := b[8], b[9]
+		return cmf&0x0F == 8 && (uint16(cmf)<<8|uint16(flg))%31 == 0
+	}
+	return false
+}
+
+// guessOrder returns the byte order under which the element following a
+// damaged header looks like a variable tag, defaulting to little-endian.
+func guessOrder(data []byte) binary.ByteOrder {
+	if len(data) >= 144 && !plausibleTag(data[128:], binary.LittleEndian) &&
+		plausibleTag(data[128:], binary.BigEndian) {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// addGap records an unrecoverable byte range.
+func (rec *Recovery) addGap(offset, length int64, reason string) {
+	if length > 0 {
+		rec.Gaps = append(rec.Gaps, Gap{Offset: offset, Length: length, Reason: reason})
+	}
+}
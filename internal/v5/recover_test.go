@@ -0,0 +1,140 @@
+package v5
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// recoverFixture writes variables a, b and c of 1x4 doubles and returns
+// the file with the offset at which each variable starts.
+func recoverFixture(t *testing.T, endian string) ([]byte, []int) {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, "recover test", endian)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	var offsets []int
+	for i, name := range []string{"a", "b", "c"} {
+		offsets = append(offsets, buf.Len())
+		v := &types.Variable{
+			Name: name, Dimensions: []int{1, 4}, DataType: types.Double,
+			Data: []float64{float64(i), 1, 2, 3},
+		}
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) error = %v", name, err)
+		}
+	}
+	return buf.Bytes(), offsets
+}
+
+func recoveredNames(rec *Recovery) string {
+	names := make([]string, len(rec.Variables))
+	for i, v := range rec.Variables {
+		names[i] = v.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestRecover_Intact(t *testing.T) {
+	data, _ := recoverFixture(t, "IM")
+	rec := Recover(data)
+	if got := recoveredNames(rec); got != "a,b,c" || len(rec.Gaps) != 0 || rec.HeaderDamaged {
+		t.Errorf("Recover() = %s, gaps %v, header damaged %v", got, rec.Gaps, rec.HeaderDamaged)
+	}
+	if len(rec.Storage) != 3 || rec.Storage[1].Offset != 128+rec.Storage[0].Size {
+		t.Errorf("Storage = %+v", rec.Storage)
+	}
+}
+
+func TestRecover_Truncated(t *testing.T) {
+	data, offsets := recoverFixture(t, "IM")
+	cut := offsets[2] + 20
+	rec := Recover(data[:cut])
+
+	if got := recoveredNames(rec); got != "a,b" {
+		t.Errorf("recovered %s, want a,b", got)
+	}
+	if len(rec.Gaps) != 1 {
+		t.Fatalf("gaps = %v, want one", rec.Gaps)
+	}
+	gap := rec.Gaps[0]
+	if gap.Offset != int64(offsets[2]) || gap.Length != 20 || !strings.Contains(gap.Reason, "truncated element") {
+		t.Errorf("gap = %+v", gap)
+	}
+}
+
+func TestRecover_CorruptMiddle(t *testing.T) {
+	data, offsets := recoverFixture(t, "MI")
+	// Overwrite the data of b, including its tag
+	for i := offsets[1]; i < offsets[1]+40; i++ {
+		data[i] = 0xFF
+	}
+	rec := Recover(data)
+
+	if got := recoveredNames(rec); got != "a,c" {
+		t.Errorf("recovered %s, want a,c", got)
+	}
+	if len(rec.Gaps) != 1 || rec.Gaps[0].Offset != int64(offsets[1]) ||
+		rec.Gaps[0].Length != int64(offsets[2]-offsets[1]) {
+		t.Errorf("gaps = %+v", rec.Gaps)
+	}
+	if c := rec.Variables[1].Data.([]float64); c[0] != 2 {
+		t.Errorf("c = %v", c)
+	}
+}
+
+func TestRecover_DamagedHeader(t *testing.T) {
+	data, _ := recoverFixture(t, "MI")
+	copy(data[116:128], make([]byte, 12))
+	rec := Recover(data)
+
+	if !rec.HeaderDamaged || rec.Header.Order != binary.BigEndian {
+		t.Errorf("header = %+v, damaged %v", rec.Header, rec.HeaderDamaged)
+	}
+	if got := recoveredNames(rec); got != "a,b,c" {
+		t.Errorf("recovered %s, want a,b,c", got)
+	}
+
+	if rec := Recover(data[:50]); len(rec.Variables) != 0 || len(rec.Gaps) != 1 {
+		t.Errorf("short file: %+v", rec)
+	}
+}
+
+func TestRecover_Compressed(t *testing.T) {
+	data, offsets := recoverFixture(t, "IM")
+
+	// Rewrite b as a compressed element and corrupt its zlib stream
+	var packed bytes.Buffer
+	zw := zlib.NewWriter(&packed)
+	_, _ = zw.Write(data[offsets[1]:offsets[2]])
+	_ = zw.Close()
+	tag := make([]byte, 8)
+	binary.LittleEndian.PutUint32(tag, miCOMPRESSED)
+	binary.LittleEndian.PutUint32(tag[4:], uint32(packed.Len()))
+
+	var file []byte
+	file = append(file, data[:offsets[1]]...)
+	file = append(file, tag...)
+	file = append(file, packed.Bytes()...)
+	file = append(file, data[offsets[2]:]...)
+
+	rec := Recover(file)
+	if got := recoveredNames(rec); got != "a,b,c" || len(rec.Gaps) != 0 {
+		t.Fatalf("recovered %s, gaps %v", got, rec.Gaps)
+	}
+	if !rec.Storage[1].Compressed {
+		t.Errorf("b storage = %+v", rec.Storage[1])
+	}
+
+	file[offsets[1]+14] ^= 0xFF // inside the deflate stream
+	rec = Recover(file)
+	if got := recoveredNames(rec); got != "a,c" || len(rec.Gaps) != 1 {
+		t.Errorf("recovered %s, gaps %v", got, rec.Gaps)
+	}
+}
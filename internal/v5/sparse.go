@@ -0,0 +1,186 @@
+package v5
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// parseSparseContent parses the row indices, column pointers and values
+// of a sparse array. It is called after the array flags, dimensions and
+// name have been read.
+//
+// Layout (MAT-File Format, "Sparse Array Data Element"):
+//   - ir: row index of each stored element, nzmax entries
+//   - jc: column offsets into ir, one per column plus one
+//   - pr: stored values (real part), nzmax entries
+//   - pi: imaginary parts, for complex arrays
+//
+// Only the first jc[cols] entries of ir, pr and pi are meaningful; the
+// rest is preallocated space recorded as nzmax in the array flags.
+func (p *Parser) parseSparseContent(hdr *arrayHeader) (*types.Variable, error) {
+	if len(hdr.dimensions) != 2 {
+		return nil, fmt.Errorf("sparse array %s has %d dimensions", hdr.name, len(hdr.dimensions))
+	}
+	s := &types.SparseMatrix{
+		Rows:  hdr.dimensions[0],
+		Cols:  hdr.dimensions[1],
+		NZMax: hdr.nzmax,
+	}
+
+	parts := make([]interface{}, 3, 4)
+	if hdr.isComplex {
+		parts = parts[:4]
+	}
+	for i := range parts {
+		tag, err := p.readTag()
+		if err != nil {
+			return nil, err
+		}
+		data, err := p.readData(tag)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = p.convertData(data, tag.DataType, 0)
+	}
+
+	var err error
+	if s.RowIndices, err = sparseIndices(parts[0]); err != nil {
+		return nil, fmt.Errorf("sparse array %s row indices: %w", hdr.name, err)
+	}
+	if s.ColPointers, err = sparseIndices(parts[1]); err != nil {
+		return nil, fmt.Errorf("sparse array %s column pointers: %w", hdr.name, err)
+	}
+	if len(s.ColPointers) != s.Cols+1 {
+		return nil, fmt.Errorf("sparse array %s has %d column pointers, want %d", hdr.name, len(s.ColPointers), s.Cols+1)
+	}
+	nnz := s.ColPointers[s.Cols]
+	if nnz < 0 || len(s.RowIndices) < nnz {
+		return nil, fmt.Errorf("sparse array %s has %d row indices for %d elements", hdr.name, len(s.RowIndices), nnz)
+	}
+	s.RowIndices = s.RowIndices[:nnz]
+
+	values, ok := p.castToClass(parts[2], mxDOUBLE_CLASS).([]float64)
+	if !ok || len(values) < nnz {
+		return nil, fmt.Errorf("sparse array %s has too few values for %d elements", hdr.name, nnz)
+	}
+	s.Real = values[:nnz]
+	if hdr.isComplex {
+		imag, ok := p.castToClass(parts[3], mxDOUBLE_CLASS).([]float64)
+		if !ok || len(imag) < nnz {
+			return nil, fmt.Errorf("sparse array %s has too few imaginary values for %d elements", hdr.name, nnz)
+		}
+		s.Imag = imag[:nnz]
+	}
+
+	variable := &types.Variable{
+		Name:       hdr.name,
+		Dimensions: hdr.dimensions,
+		DataType:   types.Double,
+		Data:       s,
+		IsComplex:  hdr.isComplex,
+		IsSparse:   true,
+	}
+	if hdr.isLogical && !hdr.isComplex {
+		flags := make([]bool, nnz)
+		for i, x := range values[:nnz] {
+			flags[i] = x != 0
+		}
+		s.Real = flags
+		variable.DataType = types.Logical
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("sparse array %s: %w", hdr.name, err)
+	}
+	return variable, nil
+}
+
+// sparseIndices converts decoded integer data to []int.
+func sparseIndices(data interface{}) ([]int, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("unexpected index data %T", data)
+	}
+	indices := make([]int, v.Len())
+	switch v.Type().Elem().Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		for i := range indices {
+			indices[i] = int(v.Index(i).Int())
+		}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		for i := range indices {
+			indices[i] = int(v.Index(i).Uint()) //nolint:gosec // G115: validated against the dimensions
+		}
+	default:
+		return nil, fmt.Errorf("unexpected index data %T", data)
+	}
+	return indices, nil
+}
+
+// sparseNZMax returns the nzmax written for a sparse matrix: its recorded
+// capacity, at least the number of stored elements and at least 1, as
+// MATLAB requires.
+func sparseNZMax(s *types.SparseMatrix) int {
+	nzmax := s.NZMax
+	if nnz := s.NNZ(); nzmax < nnz {
+		nzmax = nnz
+	}
+	if nzmax < 1 {
+		nzmax = 1
+	}
+	return nzmax
+}
+
+// encodeSparseContent encodes the ir, jc, pr and pi subelements of a
+// sparse array. ir, pr and pi are padded with zeros to nzmax entries, as
+// MATLAB writes them, so the capacity survives a round trip.
+func (w *Writer) encodeSparseContent(v *types.Variable) ([]byte, error) {
+	s, ok := v.Data.(*types.SparseMatrix)
+	if !ok {
+		return nil, fmt.Errorf("expected *types.SparseMatrix for sparse variable, got %T", v.Data)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if len(v.Dimensions) != 2 || v.Dimensions[0] != s.Rows || v.Dimensions[1] != s.Cols {
+		return nil, fmt.Errorf("sparse matrix is %dx%d, dimensions are %v", s.Rows, s.Cols, v.Dimensions)
+	}
+	if v.IsComplex != (s.Imag != nil) {
+		return nil, fmt.Errorf("complex flag %v does not match sparse imaginary part", v.IsComplex)
+	}
+	nnz := s.NNZ()
+	nzmax := sparseNZMax(s)
+
+	ir := make([]byte, nzmax*4)
+	for i, r := range s.RowIndices[:nnz] {
+		w.header.Order.PutUint32(ir[i*4:], uint32(r)) //nolint:gosec // G115: validated row index
+	}
+	jc := make([]byte, len(s.ColPointers)*4)
+	for i, c := range s.ColPointers {
+		w.header.Order.PutUint32(jc[i*4:], uint32(c)) //nolint:gosec // G115: validated offset
+	}
+	buf := w.wrapInTag(miINT32, ir)
+	buf = append(buf, w.wrapInTag(miINT32, jc)...)
+
+	switch values := s.Real.(type) {
+	case []bool:
+		pr := make([]byte, nzmax)
+		for i, b := range values[:nnz] {
+			if b {
+				pr[i] = 1
+			}
+		}
+		buf = append(buf, w.wrapInTag(miUINT8, pr)...)
+	case []float64:
+		pr := make([]float64, nzmax)
+		copy(pr, values[:nnz])
+		buf = append(buf, w.wrapInTag(miDOUBLE, w.encodeFloat64Array(pr))...)
+		if s.Imag != nil {
+			pi := make([]float64, nzmax)
+			copy(pi, s.Imag[:nnz])
+			buf = append(buf, w.wrapInTag(miDOUBLE, w.encodeFloat64Array(pi))...)
+		}
+	}
+	return buf, nil
+}
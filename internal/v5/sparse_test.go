@@ -0,0 +1,131 @@
+package v5
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func sparseVariables() []*types.Variable {
+	return []*types.Variable{
+		{Name: "A", Dimensions: []int{3, 2}, DataType: types.Double, IsSparse: true, Data: &types.SparseMatrix{
+			Rows: 3, Cols: 2, RowIndices: []int{0, 2, 2}, ColPointers: []int{0, 2, 3},
+			Real: []float64{1, 2, 3}, NZMax: 10,
+		}},
+		{Name: "mask", Dimensions: []int{2, 2}, DataType: types.Logical, IsSparse: true, Data: &types.SparseMatrix{
+			Rows: 2, Cols: 2, RowIndices: []int{1}, ColPointers: []int{0, 1, 1},
+			Real: []bool{true}, NZMax: 1,
+		}},
+		{Name: "z", Dimensions: []int{1, 3}, DataType: types.Double, IsSparse: true, IsComplex: true, Data: &types.SparseMatrix{
+			Rows: 1, Cols: 3, RowIndices: []int{0}, ColPointers: []int{0, 0, 0, 1},
+			Real: []float64{0.5}, Imag: []float64{-1}, NZMax: 2,
+		}},
+		{Name: "empty", Dimensions: []int{4, 4}, DataType: types.Double, IsSparse: true, Data: &types.SparseMatrix{
+			Rows: 4, Cols: 4, RowIndices: []int{}, ColPointers: []int{0, 0, 0, 0, 0},
+			Real: []float64{}, NZMax: 1, // MATLAB's minimum
+		}},
+	}
+}
+
+func TestSparse_RoundTrip(t *testing.T) {
+	for _, endian := range []string{"IM", "MI"} {
+		for _, compression := range []int{0, 6} {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, "", endian)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Compression = compression
+			want := sparseVariables()
+			for _, v := range want {
+				if err := w.WriteVariable(v); err != nil {
+					t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+				}
+			}
+
+			p, err := NewParser(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			file, err := p.Parse()
+			if err != nil {
+				t.Fatalf("%s/%d: Parse() error = %v", endian, compression, err)
+			}
+			if len(file.Variables) != len(want) {
+				t.Fatalf("got %d variables, want %d", len(file.Variables), len(want))
+			}
+			for i, got := range file.Variables {
+				if !reflect.DeepEqual(got, want[i]) {
+					t.Errorf("%s/%d: %s = %+v, data %+v, want data %+v",
+						endian, compression, want[i].Name, got, got.Data, want[i].Data)
+				}
+				if !file.Storage[i].IsSparse {
+					t.Errorf("%s: storage not marked sparse", want[i].Name)
+				}
+			}
+
+			p, _ = NewParser(bytes.NewReader(buf.Bytes()))
+			infos, err := p.ParseMetadata()
+			if err != nil {
+				t.Fatalf("ParseMetadata() error = %v", err)
+			}
+			if !infos[1].IsSparse || infos[1].DataType != types.Logical || infos[0].DataType != types.Double {
+				t.Errorf("metadata = %+v, %+v", infos[0], infos[1])
+			}
+		}
+	}
+}
+
+// TestSparse_PaddedToNZMax checks that ir and pr hold nzmax entries, as
+// in MATLAB's files, and nzmax is stored in the second flags word.
+func TestSparse_PaddedToNZMax(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "", "IM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteVariable(sparseVariables()[0]); err != nil {
+		t.Fatal(err)
+	}
+	content := buf.Bytes()[128+8:]
+	order := w.header.Order
+	if flags := order.Uint32(content[8:]); flags&0xFF != mxSPARSE_CLASS {
+		t.Errorf("class byte = %d, want %d", flags&0xFF, mxSPARSE_CLASS)
+	}
+	if nzmax := order.Uint32(content[12:]); nzmax != 10 {
+		t.Errorf("nzmax = %d, want 10", nzmax)
+	}
+	// flags (16) + dims (16) + name "A" (16), then the ir tag
+	if irSize := order.Uint32(content[52:]); irSize != 10*4 {
+		t.Errorf("ir has %d bytes, want %d", irSize, 10*4)
+	}
+}
+
+func TestSparse_WriteErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    *types.Variable
+	}{
+		{"not sparse data", &types.Variable{Name: "a", Dimensions: []int{1, 1}, DataType: types.Double, IsSparse: true,
+			Data: []float64{1}}},
+		{"invalid", &types.Variable{Name: "a", Dimensions: []int{2, 1}, DataType: types.Double, IsSparse: true,
+			Data: &types.SparseMatrix{Rows: 2, Cols: 1, RowIndices: []int{5}, ColPointers: []int{0, 1}, Real: []float64{1}}}},
+		{"dimensions", &types.Variable{Name: "a", Dimensions: []int{3, 1}, DataType: types.Double, IsSparse: true,
+			Data: &types.SparseMatrix{Rows: 2, Cols: 1, RowIndices: []int{}, ColPointers: []int{0, 0}, Real: []float64{}}}},
+		{"complex flag", &types.Variable{Name: "a", Dimensions: []int{2, 1}, DataType: types.Double, IsSparse: true, IsComplex: true,
+			Data: &types.SparseMatrix{Rows: 2, Cols: 1, RowIndices: []int{}, ColPointers: []int{0, 0}, Real: []float64{}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewWriter(&bytes.Buffer{}, "", "IM")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteVariable(tt.v); err == nil {
+				t.Error("WriteVariable() error = nil")
+			}
+		})
+	}
+}
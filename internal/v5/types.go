@@ -2,6 +2,8 @@ package v5
 
 import (
 	"math"
+	"reflect"
+	"unicode/utf16"
 
 	"github.com/scigolib/matlab/types"
 )
@@ -21,6 +23,8 @@ const (
 	miMATRIX     = 14
 	miCOMPRESSED = 15
 	miUTF8       = 16
+	miUTF16      = 17
+	miUTF32      = 18
 )
 
 // MATLAB array class constants.
@@ -31,6 +35,7 @@ const (
 	mxSTRUCT_CLASS = 2
 	mxOBJECT_CLASS = 3
 	mxCHAR_CLASS   = 4
+	mxSPARSE_CLASS = 5
 	mxDOUBLE_CLASS = 6
 	mxSINGLE_CLASS = 7
 	mxINT8_CLASS   = 8
@@ -68,8 +73,10 @@ func classToDataType(class uint32) types.DataType {
 		return types.Uint64
 	case mxCHAR_CLASS:
 		return types.Char
-	case mxSTRUCT_CLASS:
-		return types.Struct
+	case mxSPARSE_CLASS:
+		return types.Double // Logical if the logical flag is set
+	case mxSTRUCT_CLASS, mxOBJECT_CLASS:
+		return types.Struct // Objects are read as structs
 	case mxCELL_CLASS:
 		return types.CellArray
 	default:
@@ -77,6 +84,55 @@ func classToDataType(class uint32) types.DataType {
 	}
 }
 
+// classElemTypes maps numeric array classes to the Go element type of
+// their data.
+var classElemTypes = map[uint32]reflect.Type{
+	mxDOUBLE_CLASS: reflect.TypeOf(float64(0)),
+	mxSINGLE_CLASS: reflect.TypeOf(float32(0)),
+	mxINT8_CLASS:   reflect.TypeOf(int8(0)),
+	mxUINT8_CLASS:  reflect.TypeOf(uint8(0)),
+	mxINT16_CLASS:  reflect.TypeOf(int16(0)),
+	mxUINT16_CLASS: reflect.TypeOf(uint16(0)),
+	mxINT32_CLASS:  reflect.TypeOf(int32(0)),
+	mxUINT32_CLASS: reflect.TypeOf(uint32(0)),
+	mxINT64_CLASS:  reflect.TypeOf(int64(0)),
+	mxUINT64_CLASS: reflect.TypeOf(uint64(0)),
+}
+
+// castToClass converts numeric data to the element type of its array
+// class. MATLAB stores data in the smallest type that holds the values
+// exactly, e.g. an integer-valued double array as miUINT8.
+func (p *Parser) castToClass(data interface{}, class uint32) interface{} {
+	elem, ok := classElemTypes[class]
+	if !ok {
+		return data
+	}
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice || v.Type().Elem() == elem {
+		return data
+	}
+	switch v.Type().Elem().Kind() {
+	case reflect.Int8, reflect.Uint8, reflect.Int16, reflect.Uint16, reflect.Int32, reflect.Uint32,
+		reflect.Int64, reflect.Uint64, reflect.Float32, reflect.Float64:
+	default:
+		return data
+	}
+
+	out := reflect.ValueOf(types.MakeSlice(p.alloc(), elem.Kind(), v.Len()))
+	for i := 0; i < v.Len(); i++ {
+		out.Index(i).Set(v.Index(i).Convert(elem))
+	}
+	return out.Interface()
+}
+
+// alloc returns the allocator for decoded numeric data.
+func (p *Parser) alloc() types.Allocator {
+	if p.Allocator != nil {
+		return p.Allocator
+	}
+	return types.HeapAllocator{}
+}
+
 // convertData converts raw bytes to appropriate Go type.
 //
 //nolint:gocognit,gocyclo,cyclop,funlen // Type conversion requires exhaustive type matching (MATLAB spec)
@@ -87,7 +143,7 @@ func (p *Parser) convertData(data []byte, dataType, _ uint32) interface{} {
 		if count == 0 || len(data) < count*8 {
 			return []float64{}
 		}
-		result := make([]float64, count)
+		result := p.alloc().Float64s(count)
 		for i := 0; i < count; i++ {
 			result[i] = math.Float64frombits(
 				p.Header.Order.Uint64(data[i*8 : (i+1)*8]))
@@ -99,7 +155,7 @@ func (p *Parser) convertData(data []byte, dataType, _ uint32) interface{} {
 		if count == 0 || len(data) < count*4 {
 			return []float32{}
 		}
-		result := make([]float32, count)
+		result := p.alloc().Float32s(count)
 		for i := 0; i < count; i++ {
 			result[i] = math.Float32frombits(
 				p.Header.Order.Uint32(data[i*4 : (i+1)*4]))
@@ -107,13 +163,18 @@ func (p *Parser) convertData(data []byte, dataType, _ uint32) interface{} {
 		return result
 
 	case miINT8:
-		result := make([]int8, len(data))
+		result := p.alloc().Int8s(len(data))
 		for i := 0; i < len(data); i++ {
 			result[i] = int8(data[i])
 		}
 		return result
 
 	case miUINT8:
+		if p.Allocator != nil {
+			result := p.Allocator.Uint8s(len(data))
+			copy(result, data)
+			return result
+		}
 		return data
 
 	case miINT16:
@@ -121,7 +182,7 @@ func (p *Parser) convertData(data []byte, dataType, _ uint32) interface{} {
 		if count == 0 || len(data) < count*2 {
 			return []int16{}
 		}
-		result := make([]int16, count)
+		result := p.alloc().Int16s(count)
 		for i := 0; i < count; i++ {
 			result[i] = int16(p.Header.Order.Uint16(data[i*2 : (i+1)*2]))
 		}
@@ -132,7 +193,7 @@ func (p *Parser) convertData(data []byte, dataType, _ uint32) interface{} {
 		if count == 0 || len(data) < count*2 {
 			return []uint16{}
 		}
-		result := make([]uint16, count)
+		result := p.alloc().Uint16s(count)
 		for i := 0; i < count; i++ {
 			result[i] = p.Header.Order.Uint16(data[i*2 : (i+1)*2])
 		}
@@ -143,7 +204,7 @@ func (p *Parser) convertData(data []byte, dataType, _ uint32) interface{} {
 		if count == 0 || len(data) < count*4 {
 			return []int32{}
 		}
-		result := make([]int32, count)
+		result := p.alloc().Int32s(count)
 		for i := 0; i < count; i++ {
 			result[i] = int32(p.Header.Order.Uint32(data[i*4 : (i+1)*4]))
 		}
@@ -154,7 +215,7 @@ func (p *Parser) convertData(data []byte, dataType, _ uint32) interface{} {
 		if count == 0 || len(data) < count*4 {
 			return []uint32{}
 		}
-		result := make([]uint32, count)
+		result := p.alloc().Uint32s(count)
 		for i := 0; i < count; i++ {
 			result[i] = p.Header.Order.Uint32(data[i*4 : (i+1)*4])
 		}
@@ -165,7 +226,7 @@ func (p *Parser) convertData(data []byte, dataType, _ uint32) interface{} {
 		if count == 0 || len(data) < count*8 {
 			return []int64{}
 		}
-		result := make([]int64, count)
+		result := p.alloc().Int64s(count)
 		for i := 0; i < count; i++ {
 			result[i] = int64(p.Header.Order.Uint64(data[i*8 : (i+1)*8]))
 		}
@@ -176,7 +237,7 @@ func (p *Parser) convertData(data []byte, dataType, _ uint32) interface{} {
 		if count == 0 || len(data) < count*8 {
 			return []uint64{}
 		}
-		result := make([]uint64, count)
+		result := p.alloc().Uint64s(count)
 		for i := 0; i < count; i++ {
 			result[i] = p.Header.Order.Uint64(data[i*8 : (i+1)*8])
 		}
@@ -185,6 +246,21 @@ func (p *Parser) convertData(data []byte, dataType, _ uint32) interface{} {
 	case miUTF8:
 		return string(data)
 
+	case miUTF16:
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = p.Header.Order.Uint16(data[i*2 : (i+1)*2])
+		}
+		return string(utf16.Decode(units))
+
+	case miUTF32:
+		runes := make([]rune, len(data)/4)
+		for i := range runes {
+			//nolint:gosec // G115: invalid code points decode to U+FFFD
+			runes[i] = rune(p.Header.Order.Uint32(data[i*4 : (i+1)*4]))
+		}
+		return string(runes)
+
 	default:
 		// For unsupported types, return raw bytes
 		return data
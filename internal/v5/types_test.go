@@ -5,6 +5,7 @@ import (
 	"math"
 	"reflect"
 	"testing"
+	"unicode/utf16"
 
 	"github.com/scigolib/matlab/types"
 )
@@ -492,6 +493,32 @@ func TestConvertData_UTF8(t *testing.T) {
 	}
 }
 
+func TestConvertData_UTF16AndUTF32(t *testing.T) {
+	text := "µ 世界 𝄞"
+	units := utf16.Encode([]rune(text))
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		p := &Parser{Header: &Header{Order: order}}
+
+		utf16Data := make([]byte, len(units)*2)
+		for i, u := range units {
+			order.PutUint16(utf16Data[i*2:], u)
+		}
+		if got := p.convertData(utf16Data, miUTF16, mxCHAR_CLASS); got != text {
+			t.Errorf("%v: convertData(miUTF16) = %q, want %q", order, got, text)
+		}
+
+		runes := []rune(text)
+		utf32Data := make([]byte, len(runes)*4)
+		for i, r := range runes {
+			order.PutUint32(utf32Data[i*4:], uint32(r))
+		}
+		if got := p.convertData(utf32Data, miUTF32, mxCHAR_CLASS); got != text {
+			t.Errorf("%v: convertData(miUTF32) = %q, want %q", order, got, text)
+		}
+	}
+}
+
 func TestConvertData_Unknown(t *testing.T) {
 	p := &Parser{Header: &Header{Order: binary.LittleEndian}}
 	data := []byte{1, 2, 3, 4}
@@ -501,6 +528,30 @@ func TestConvertData_Unknown(t *testing.T) {
 	}
 }
 
+func TestCastToClass(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  interface{}
+		class uint32
+		want  interface{}
+	}{
+		{"uint8 stored double", []byte{1, 2, 255}, mxDOUBLE_CLASS, []float64{1, 2, 255}},
+		{"int16 stored int32", []int16{-3, 4}, mxINT32_CLASS, []int32{-3, 4}},
+		{"matching type unchanged", []float32{1.5}, mxSINGLE_CLASS, []float32{1.5}},
+		{"char class unchanged", []uint16{104, 105}, mxCHAR_CLASS, []uint16{104, 105}},
+		{"string unchanged", "text", mxDOUBLE_CLASS, "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := (&Parser{}).castToClass(tt.data, tt.class)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("castToClass() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
 // BenchmarkConvertData benchmarks type conversion performance.
 func BenchmarkConvertData_Double(b *testing.B) {
 	data := make([]byte, 8*100) // 100 doubles
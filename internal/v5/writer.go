@@ -1,10 +1,16 @@
 package v5
 
 import (
+	"bytes"
+	"compress/zlib"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"math"
+	"reflect"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/scigolib/matlab/types"
 )
@@ -19,9 +25,14 @@ import (
 // Format v5 specification. The writer supports both little-endian ("IM")
 // and big-endian ("MI") byte ordering.
 type Writer struct {
-	w      io.Writer
-	header *Header
-	pos    int64
+	w        io.Writer
+	header   *Header
+	pos      int64
+	inflated int64 // Size of the compressed elements before compression
+
+	// Compression is the zlib level (1-9) at which each variable is
+	// written as a miCOMPRESSED element. 0 writes plain miMATRIX elements.
+	Compression int
 }
 
 // NewWriter creates a new v5 writer.
@@ -76,6 +87,24 @@ func NewWriter(w io.Writer, description, endian string) (*Writer, error) {
 	return writer, nil
 }
 
+// ResumeWriter creates a writer that continues a MAT-file after its first
+// offset bytes, which hold the given 128-byte header and complete data
+// elements. w must be positioned at offset; nothing is written until the
+// next variable.
+func ResumeWriter(w io.Writer, header []byte, offset int64) (*Writer, error) {
+	if len(header) < 128 {
+		return nil, fmt.Errorf("header has %d bytes, want 128", len(header))
+	}
+	if offset < 128 {
+		return nil, fmt.Errorf("offset %d is within the header", offset)
+	}
+	hdr, err := parseHeader(header[:128])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse header: %w", err)
+	}
+	return &Writer{w: w, header: hdr, pos: offset}, nil
+}
+
 // WriteVariable writes a MATLAB variable to the file.
 //
 // The variable is written as a miMATRIX data element containing nested
@@ -90,8 +119,11 @@ func NewWriter(w io.Writer, description, endian string) (*Writer, error) {
 //
 // Supported types:
 //   - Double, Single, Int8, Uint8, Int16, Uint16, Int32, Uint32, Int64, Uint64
+//   - Logical ([]bool), Char (string, written as UTF-16)
+//   - Struct (*types.StructArray)
 //   - Complex numbers (use types.NumericArray with Real/Imag)
-//   - Multi-dimensional arrays
+//   - Sparse double and logical matrices (*types.SparseMatrix, IsSparse set)
+//   - Multi-dimensional arrays (a single dimension n is written as n×1)
 func (w *Writer) WriteVariable(v *types.Variable) error {
 	// Validate variable
 	if err := w.validateVariable(v); err != nil {
@@ -102,14 +134,108 @@ func (w *Writer) WriteVariable(v *types.Variable) error {
 	return w.writeMatrix(v)
 }
 
+// WriteVariables writes several variables, encoding all of them before
+// the first byte is written. A variable that is invalid or too large for
+// v5 returns an error and nothing is written.
+//
+// An error from the underlying writer may leave part of the batch
+// written; Offset then still reports the position before the batch, so
+// the caller can truncate the output there.
+func (w *Writer) WriteVariables(vars []*types.Variable) error {
+	elements := make([][]byte, len(vars))
+	var inflated int64
+	for i, v := range vars {
+		if err := w.validateVariable(v); err != nil {
+			return fmt.Errorf("variable %d (%s): invalid variable: %w", i, v.Name, err)
+		}
+		element, size, err := w.encodeElement(v)
+		if err != nil {
+			return fmt.Errorf("variable %d (%s): %w", i, v.Name, err)
+		}
+		elements[i] = element
+		inflated += size
+	}
+
+	start := w.pos
+	for _, element := range elements {
+		n, err := w.w.Write(element)
+		w.pos += int64(n)
+		if err != nil {
+			w.pos = start
+			return err
+		}
+	}
+	w.inflated += inflated
+	return nil
+}
+
+// Offset returns the number of bytes written so far, header included.
+func (w *Writer) Offset() int64 {
+	return w.pos
+}
+
+// Inflated returns the total size of the compressed elements written so
+// far before compression, that is of the miMATRIX elements they hold.
+func (w *Writer) Inflated() int64 {
+	return w.inflated
+}
+
+// encodeElement encodes v as a complete data element, as writeMatrix
+// writes it: an miMATRIX element padded to 8 bytes, or an miCOMPRESSED
+// element when compression is enabled. For compressed elements it also
+// returns the size of the miMATRIX element before compression.
+func (w *Writer) encodeElement(v *types.Variable) ([]byte, int64, error) {
+	content, err := w.encodeMatrixContent(v)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode matrix content: %w", err)
+	}
+	if uint64(len(content)) > math.MaxUint32 {
+		return nil, 0, fmt.Errorf("variable too large for v5 format (%d bytes), use v7.3", len(content))
+	}
+	if w.Compression == 0 {
+		return w.wrapInTag(miMATRIX, content), 0, nil
+	}
+
+	packed, err := w.compress(content)
+	if err != nil {
+		return nil, 0, err
+	}
+	tag := make([]byte, 8)
+	w.header.Order.PutUint32(tag[0:4], miCOMPRESSED)
+	w.header.Order.PutUint32(tag[4:8], uint32(len(packed))) //nolint:gosec // G115: checked by compress
+	return append(tag, packed...), 8 + int64(len(content)), nil
+}
+
 // validateVariable checks if variable is valid for v5 format.
 func (w *Writer) validateVariable(v *types.Variable) error {
 	if v.Name == "" {
 		return fmt.Errorf("variable name is required")
 	}
-	if len(v.Name) > 63 {
-		return fmt.Errorf("variable name too long (max 63 characters): %d", len(v.Name))
+	if err := validateName(v.Name); err != nil {
+		return fmt.Errorf("variable %w", err)
+	}
+	return validateShape(v)
+}
+
+// validateName checks a variable or field name: valid UTF-8, at most
+// types.MaxNameLength characters (not bytes) and free of NUL bytes. Names
+// are stored as their UTF-8 bytes in an miINT8 element.
+func validateName(name string) error {
+	if !utf8.ValidString(name) {
+		return fmt.Errorf("name %q is not valid UTF-8", name)
+	}
+	if n := types.NameLength(name); n > types.MaxNameLength {
+		return fmt.Errorf("name too long (max %d characters): %d", types.MaxNameLength, n)
+	}
+	// Readers strip NUL padding from names, so a NUL would change the name
+	if strings.IndexByte(name, 0) >= 0 {
+		return fmt.Errorf("name %q contains a NUL byte", name)
 	}
+	return nil
+}
+
+// validateShape checks the dimensions and data of a variable or struct field.
+func validateShape(v *types.Variable) error {
 	if len(v.Dimensions) == 0 {
 		return fmt.Errorf("dimensions are required")
 	}
@@ -117,24 +243,64 @@ func (w *Writer) validateVariable(v *types.Variable) error {
 		return fmt.Errorf("data is required")
 	}
 
-	// Validate dimensions are positive and check for overflow
-	total := int64(1)
+	// Validate dimensions are positive and fit the format. v5 stores
+	// dimensions as int32 and element byte counts as uint32, so the total
+	// element count can never exceed math.MaxUint32.
+	total := uint64(1)
 	for i, d := range v.Dimensions {
 		if d <= 0 {
 			return fmt.Errorf("dimension[%d] must be positive, got %d", i, d)
 		}
-
-		// Check for overflow before multiplying
-		if d > 0 && total > math.MaxInt/int64(d) {
-			return fmt.Errorf("dimensions overflow (total elements too large): %v", v.Dimensions)
+		if d > math.MaxInt32 {
+			return fmt.Errorf("dimension[%d] = %d exceeds the v5 limit of %d", i, d, math.MaxInt32)
 		}
 
-		total *= int64(d)
+		total *= uint64(d)
+		if total > math.MaxUint32 {
+			return fmt.Errorf("dimensions overflow (total elements too large for v5, use v7.3): %v", v.Dimensions)
+		}
 	}
 
 	return nil
 }
 
+// validateElementCount checks that numeric, logical and character data
+// holds exactly one element per array element, so the dimensions written
+// describe the data. Struct elements and sparse matrices are checked when
+// they are encoded.
+func validateElementCount(v *types.Variable) error {
+	if v.DataType == types.Struct || v.IsSparse {
+		return nil
+	}
+	want := 1
+	for _, d := range v.Dimensions {
+		want *= d
+	}
+	parts := []interface{}{v.Data}
+	if v.IsComplex {
+		numArray, ok := v.Data.(*types.NumericArray)
+		if !ok {
+			return nil // Reported by encodeData
+		}
+		parts = []interface{}{numArray.Real, numArray.Imag}
+	}
+	for _, part := range parts {
+		count := -1
+		switch data := part.(type) {
+		case string:
+			count = len(utf16.Encode([]rune(data)))
+		default:
+			if rv := reflect.ValueOf(part); rv.Kind() == reflect.Slice {
+				count = rv.Len()
+			}
+		}
+		if count >= 0 && count != want {
+			return fmt.Errorf("data has %d elements, dimensions %v require %d", count, v.Dimensions, want)
+		}
+	}
+	return nil
+}
+
 // writeHeader writes the 128-byte MAT-file header.
 //
 // Header structure:
@@ -192,7 +358,14 @@ func (w *Writer) writeMatrix(v *types.Variable) error {
 		return fmt.Errorf("failed to encode matrix content: %w", err)
 	}
 
-	// Step 2: Write miMATRIX tag (8 bytes)
+	// Step 2: Write miMATRIX tag (8 bytes). The tag size is a uint32, so
+	// larger variables need the v7.3 format.
+	if uint64(len(content)) > math.MaxUint32 {
+		return fmt.Errorf("variable too large for v5 format (%d bytes), use v7.3", len(content))
+	}
+	if w.Compression > 0 {
+		return w.writeCompressed(content)
+	}
 	if err := w.writeTag(miMATRIX, uint32(len(content))); err != nil {
 		return fmt.Errorf("failed to write matrix tag: %w", err)
 	}
@@ -218,11 +391,55 @@ func (w *Writer) writeMatrix(v *types.Variable) error {
 	return nil
 }
 
+// writeCompressed writes a matrix element with the given content as a
+// zlib-compressed miCOMPRESSED element. Compressed elements are not padded.
+func (w *Writer) writeCompressed(content []byte) error {
+	packed, err := w.compress(content)
+	if err != nil {
+		return err
+	}
+	if err := w.writeTag(miCOMPRESSED, uint32(len(packed))); err != nil { //nolint:gosec // G115: checked by compress
+		return fmt.Errorf("failed to write compressed tag: %w", err)
+	}
+	n, err := w.w.Write(packed)
+	w.pos += int64(n)
+	if err != nil {
+		return err
+	}
+	w.inflated += 8 + int64(len(content))
+	return nil
+}
+
+// compress returns the zlib stream of the miMATRIX element holding
+// content, checking that it fits an miCOMPRESSED element.
+func (w *Writer) compress(content []byte) ([]byte, error) {
+	var packed bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&packed, w.Compression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zlib writer: %w", err)
+	}
+	if _, err := zw.Write(w.wrapInTag(miMATRIX, content)); err != nil {
+		return nil, fmt.Errorf("failed to compress matrix: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress matrix: %w", err)
+	}
+
+	if uint64(packed.Len()) > math.MaxUint32 {
+		return nil, fmt.Errorf("compressed variable too large for v5 format (%d bytes), use v7.3", packed.Len())
+	}
+	return packed.Bytes(), nil
+}
+
 // encodeMatrixContent encodes all matrix sub-elements to a byte buffer.
 //
 // Returns the complete matrix content as a single byte slice.
 // This is used to calculate the total size for the miMATRIX tag.
 func (w *Writer) encodeMatrixContent(v *types.Variable) ([]byte, error) {
+	if err := validateElementCount(v); err != nil {
+		return nil, err
+	}
+
 	var buf []byte
 
 	// Sub-element 1: Array Flags (8 bytes)
@@ -237,6 +454,24 @@ func (w *Writer) encodeMatrixContent(v *types.Variable) ([]byte, error) {
 	name := w.encodeName(v.Name)
 	buf = append(buf, name...)
 
+	// Struct arrays continue with field names and nested matrices
+	if v.DataType == types.Struct {
+		fields, err := w.encodeStructFields(v)
+		if err != nil {
+			return nil, err
+		}
+		return append(buf, fields...), nil
+	}
+
+	// Sparse arrays continue with row indices, column pointers and values
+	if v.IsSparse {
+		sparse, err := w.encodeSparseContent(v)
+		if err != nil {
+			return nil, err
+		}
+		return append(buf, sparse...), nil
+	}
+
 	// Sub-element 4: Real Data
 	realData, err := w.encodeData(v, false)
 	if err != nil {
@@ -256,11 +491,111 @@ func (w *Writer) encodeMatrixContent(v *types.Variable) ([]byte, error) {
 	return buf, nil
 }
 
+// encodeStructFields encodes the field name length, field names and
+// field values of a struct array.
+//
+// Field values are written as nested miMATRIX elements with empty names,
+// element by element in column-major order.
+func (w *Writer) encodeStructFields(v *types.Variable) ([]byte, error) {
+	st, ok := v.Data.(*types.StructArray)
+	if !ok {
+		return nil, fmt.Errorf("expected *types.StructArray for Struct, got %T", v.Data)
+	}
+	if len(st.Elements) != numElements(v.Dimensions) {
+		return nil, fmt.Errorf("struct has %d elements, dimensions %v require %d",
+			len(st.Elements), v.Dimensions, numElements(v.Dimensions))
+	}
+
+	// Field name length includes the NUL terminator
+	nameLen := 1
+	for _, field := range st.Fields {
+		if field == "" {
+			return nil, fmt.Errorf("struct field name is required")
+		}
+		if err := validateName(field); err != nil {
+			return nil, fmt.Errorf("struct field %w", err)
+		}
+		if len(field)+1 > nameLen {
+			nameLen = len(field) + 1
+		}
+	}
+
+	lenData := make([]byte, 4)
+	w.header.Order.PutUint32(lenData, uint32(nameLen))
+	buf := w.wrapInTag(miINT32, lenData)
+
+	names := make([]byte, nameLen*len(st.Fields))
+	for i, field := range st.Fields {
+		copy(names[i*nameLen:], field)
+	}
+	buf = append(buf, w.wrapInTag(miINT8, names)...)
+
+	for i, element := range st.Elements {
+		if len(element) != len(st.Fields) {
+			return nil, fmt.Errorf("struct element %d has %d values, want %d", i, len(element), len(st.Fields))
+		}
+		for j, value := range element {
+			if value == nil {
+				return nil, fmt.Errorf("struct element %d field %q is nil", i, st.Fields[j])
+			}
+			if err := validateShape(value); err != nil {
+				return nil, fmt.Errorf("struct field %q: %w", st.Fields[j], err)
+			}
+			field := *value
+			field.Name = ""
+			content, err := w.encodeMatrixContent(&field)
+			if err != nil {
+				return nil, fmt.Errorf("struct field %q: %w", st.Fields[j], err)
+			}
+			buf = append(buf, w.wrapInTag(miMATRIX, content)...)
+		}
+	}
+
+	return buf, nil
+}
+
+// encodeChars selects the element type for character data by content:
+// miUTF8 for ASCII text (one byte per character), miUINT16 for text in
+// the Basic Multilingual Plane (MATLAB's native char storage), and
+// miUTF16 when surrogate pairs are needed.
+func (w *Writer) encodeChars(str string) (uint32, []byte) {
+	ascii := true
+	for i := 0; i < len(str); i++ {
+		if str[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return miUTF8, []byte(str)
+	}
+
+	units := utf16.Encode([]rune(str))
+	dataType := uint32(miUINT16)
+	for _, u := range units {
+		if utf16.IsSurrogate(rune(u)) {
+			dataType = miUTF16
+			break
+		}
+	}
+	return dataType, w.encodeUint16Array(units)
+}
+
+// numElements returns the number of elements described by dims.
+func numElements(dims []int) int {
+	count := 1
+	for _, d := range dims {
+		count *= d
+	}
+	return count
+}
+
 // encodeArrayFlags encodes array flags sub-element.
 //
 // The array flags contain:
-// - Bytes 0-3: Flags (complex bit, sparse bit, etc.)
-// - Bytes 4-7: MATLAB class (mxDOUBLE_CLASS, etc.)
+// - Bytes 0-3: Flags (complex bit, sparse bit, etc.) above the MATLAB
+// class (mxDOUBLE_CLASS, etc.) in the low byte
+// - Bytes 4-7: Reserved (nzmax for sparse arrays).
 func (w *Writer) encodeArrayFlags(v *types.Variable) []byte {
 	// Build flags
 	var flags uint32
@@ -270,13 +605,19 @@ func (w *Writer) encodeArrayFlags(v *types.Variable) []byte {
 	if v.IsSparse {
 		flags |= 0x0400 // Sparse bit (bit 10)
 	}
+	if v.DataType == types.Logical {
+		flags |= 0x0200 // Logical bit (bit 9)
+	}
 
 	class := w.dataTypeToClass(v.DataType)
 
-	// Create 8-byte data: flags + class
+	// Create 8-byte data: flags and class, then nzmax for sparse arrays
 	data := make([]byte, 8)
-	w.header.Order.PutUint32(data[0:4], flags)
-	w.header.Order.PutUint32(data[4:8], class)
+	if s, ok := v.Data.(*types.SparseMatrix); ok && v.IsSparse {
+		class = mxSPARSE_CLASS
+		w.header.Order.PutUint32(data[4:8], uint32(sparseNZMax(s))) //nolint:gosec // G115: bounded by the v5 element count
+	}
+	w.header.Order.PutUint32(data[0:4], flags|class)
 
 	// Wrap in miUINT32 tag
 	return w.wrapInTag(miUINT32, data)
@@ -286,6 +627,9 @@ func (w *Writer) encodeArrayFlags(v *types.Variable) []byte {
 //
 // Dimensions are written as an int32 array wrapped in a data element tag.
 func (w *Writer) encodeDimensions(dims []int) []byte {
+	if len(dims) == 1 {
+		dims = []int{dims[0], 1}
+	}
 	// Convert to int32 array
 	data := make([]byte, len(dims)*4)
 	for i, d := range dims {
@@ -371,6 +715,21 @@ func (w *Writer) encodeData(v *types.Variable, imaginary bool) ([]byte, error) {
 		}
 		rawData = arr
 
+	case types.Char:
+		str, ok := data.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string for Char, got %T", data)
+		}
+		dataType, rawData = w.encodeChars(str)
+
+	case types.Logical:
+		dataType = miUINT8
+		arr, ok := data.([]bool)
+		if !ok {
+			return nil, fmt.Errorf("expected []bool for Logical, got %T", data)
+		}
+		rawData = w.encodeBoolArray(arr)
+
 	case types.Int16:
 		dataType = miINT16
 		arr, ok := data.([]int16)
@@ -494,6 +853,16 @@ func (w *Writer) encodeInt8Array(data []int8) []byte {
 	return buf
 }
 
+func (w *Writer) encodeBoolArray(data []bool) []byte {
+	buf := make([]byte, len(data))
+	for i, val := range data {
+		if val {
+			buf[i] = 1
+		}
+	}
+	return buf
+}
+
 func (w *Writer) encodeInt16Array(data []int16) []byte {
 	buf := make([]byte, len(data)*2)
 	for i, val := range data {
@@ -551,7 +920,7 @@ func (w *Writer) dataTypeToClass(dt types.DataType) uint32 {
 		return mxSINGLE_CLASS
 	case types.Int8:
 		return mxINT8_CLASS
-	case types.Uint8:
+	case types.Uint8, types.Logical:
 		return mxUINT8_CLASS
 	case types.Int16:
 		return mxINT16_CLASS
@@ -565,6 +934,10 @@ func (w *Writer) dataTypeToClass(dt types.DataType) uint32 {
 		return mxINT64_CLASS
 	case types.Uint64:
 		return mxUINT64_CLASS
+	case types.Char:
+		return mxCHAR_CLASS
+	case types.Struct:
+		return mxSTRUCT_CLASS
 	default:
 		return mxDOUBLE_CLASS // Fallback
 	}
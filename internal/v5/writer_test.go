@@ -5,7 +5,12 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"math/rand"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/quick"
+	"unicode/utf16"
 
 	"github.com/scigolib/matlab/types"
 )
@@ -766,7 +771,9 @@ func TestDataTypeToClass_AllTypes(t *testing.T) {
 		{"Int64", types.Int64, mxINT64_CLASS},
 		{"Uint64", types.Uint64, mxUINT64_CLASS},
 		{"Unknown falls back to Double", types.Unknown, mxDOUBLE_CLASS},
-		{"Char falls back to Double", types.Char, mxDOUBLE_CLASS},
+		{"Char", types.Char, mxCHAR_CLASS},
+		{"Struct", types.Struct, mxSTRUCT_CLASS},
+		{"CellArray falls back to Double", types.CellArray, mxDOUBLE_CLASS},
 	}
 
 	for _, tt := range tests {
@@ -1722,19 +1729,24 @@ func TestValidateDimensions_Overflow(t *testing.T) {
 			expectError: true,
 		},
 		{
-			name:        "single large valid dimension",
-			dims:        []int{math.MaxInt / 2}, // Valid, won't overflow
+			name:        "largest v5 dimension",
+			dims:        []int{math.MaxInt32},
 			expectError: false,
 		},
 		{
-			name:        "2D that overflows",
-			dims:        []int{100000000, 100000000}, // 10^16 elements, won't overflow on 64-bit
-			expectError: false,
+			name:        "dimension exceeds int32",
+			dims:        []int{math.MaxInt32 + 1},
+			expectError: true,
 		},
 		{
-			name:        "3D that overflows",
-			dims:        []int{100000, 100000, 1000}, // 10^13 elements, won't overflow
-			expectError: false,
+			name:        "2D beyond uint32 element count",
+			dims:        []int{100000000, 100000000}, // 10^16 elements
+			expectError: true,
+		},
+		{
+			name:        "3D beyond uint32 element count",
+			dims:        []int{100000, 100000, 1000}, // 10^13 elements
+			expectError: true,
 		},
 	}
 
@@ -1770,3 +1782,373 @@ func TestValidateDimensions_Overflow(t *testing.T) {
 		})
 	}
 }
+
+// TestWriteVariable_StructErrors tests validation of struct payloads.
+func TestWriteVariable_StructErrors(t *testing.T) {
+	field := &types.Variable{Name: "a", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}
+
+	tests := []struct {
+		name string
+		data interface{}
+	}{
+		{"wrong payload type", []float64{1}},
+		{"element count mismatch", &types.StructArray{Fields: []string{"a"}, Dimensions: []int{1, 1}}},
+		{"empty field name", &types.StructArray{Fields: []string{""}, Dimensions: []int{1, 1}, Elements: [][]*types.Variable{{field}}}},
+		{"missing value", &types.StructArray{Fields: []string{"a", "b"}, Dimensions: []int{1, 1}, Elements: [][]*types.Variable{{field}}}},
+		{"nil value", &types.StructArray{Fields: []string{"a"}, Dimensions: []int{1, 1}, Elements: [][]*types.Variable{{nil}}}},
+		{"invalid field shape", &types.StructArray{Fields: []string{"a"}, Dimensions: []int{1, 1}, Elements: [][]*types.Variable{{{DataType: types.Double, Data: []float64{1}}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, "Test", "IM")
+			if err != nil {
+				t.Fatalf("NewWriter failed: %v", err)
+			}
+			err = w.WriteVariable(&types.Variable{
+				Name:       "s",
+				Dimensions: []int{1, 1},
+				DataType:   types.Struct,
+				Data:       tt.data,
+			})
+			if err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+// TestEncodeChars tests element type selection for character data and the
+// round trip through the parser.
+func TestEncodeChars(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantType uint32
+		wantLen  int
+	}{
+		{"ASCII", "hello", miUTF8, 5},
+		{"BMP", "µV 世界", miUINT16, 10},
+		{"supplementary", "a𝄞", miUTF16, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, "Test", "IM")
+			if err != nil {
+				t.Fatalf("NewWriter failed: %v", err)
+			}
+
+			dataType, raw := w.encodeChars(tt.text)
+			if dataType != tt.wantType || len(raw) != tt.wantLen {
+				t.Errorf("encodeChars(%q) = type %d, %d bytes; want type %d, %d bytes",
+					tt.text, dataType, len(raw), tt.wantType, tt.wantLen)
+			}
+
+			units := len(utf16.Encode([]rune(tt.text)))
+			if err := w.WriteVariable(&types.Variable{
+				Name: "s", Dimensions: []int{1, units}, DataType: types.Char, Data: tt.text,
+			}); err != nil {
+				t.Fatalf("WriteVariable failed: %v", err)
+			}
+
+			parser, err := NewParser(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("NewParser failed: %v", err)
+			}
+			file, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := file.Variables[0].Data; got != tt.text {
+				t.Errorf("round trip = %q, want %q", got, tt.text)
+			}
+		})
+	}
+}
+
+// roundTripCase is a random variable for the property-based round-trip
+// test: a name of 1-63 characters, 1-6 dimensions and data of a random
+// class.
+type roundTripCase struct {
+	Variable    *types.Variable
+	Compression int
+	Endian      string
+}
+
+// Generate implements quick.Generator.
+func (roundTripCase) Generate(r *rand.Rand, _ int) reflect.Value {
+	const nameChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_"
+	name := make([]byte, 1+r.Intn(63))
+	for i := range name {
+		name[i] = nameChars[r.Intn(len(nameChars))]
+	}
+
+	dims := make([]int, 1+r.Intn(6))
+	n := 1
+	for i := range dims {
+		dims[i] = 1 + r.Intn(3)
+		n *= dims[i]
+	}
+
+	v := &types.Variable{Name: string(name), Dimensions: dims}
+	switch r.Intn(6) {
+	case 0:
+		data := make([]float64, n)
+		for i := range data {
+			data[i] = r.NormFloat64()
+		}
+		v.DataType, v.Data = types.Double, data
+	case 1:
+		data := make([]float32, n)
+		for i := range data {
+			data[i] = float32(r.NormFloat64())
+		}
+		v.DataType, v.Data = types.Single, data
+	case 2:
+		data := make([]int16, n)
+		for i := range data {
+			data[i] = int16(r.Intn(1 << 16))
+		}
+		v.DataType, v.Data = types.Int16, data
+	case 3:
+		data := make([]uint64, n)
+		for i := range data {
+			data[i] = r.Uint64()
+		}
+		v.DataType, v.Data = types.Uint64, data
+	case 4:
+		data := make([]bool, n)
+		for i := range data {
+			data[i] = r.Intn(2) == 1
+		}
+		v.DataType, v.Data = types.Logical, data
+	default:
+		re, im := make([]float64, n), make([]float64, n)
+		for i := range re {
+			re[i], im[i] = r.NormFloat64(), r.NormFloat64()
+		}
+		v.DataType, v.IsComplex = types.Double, true
+		v.Data = &types.NumericArray{Real: re, Imag: im, Dimensions: dims, Type: types.Double}
+	}
+
+	tc := roundTripCase{Variable: v, Endian: "IM"}
+	if r.Intn(2) == 1 {
+		tc.Endian = "MI"
+	}
+	if r.Intn(2) == 1 {
+		tc.Compression = 6
+	}
+	return reflect.ValueOf(tc)
+}
+
+// TestWriteVariable_RoundTripProperty writes random variables and checks
+// that the parser returns the same name, dimensions and data.
+func TestWriteVariable_RoundTripProperty(t *testing.T) {
+	property := func(tc roundTripCase) bool {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, "", tc.Endian)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		w.Compression = tc.Compression
+		v := tc.Variable
+		if err := w.WriteVariable(v); err != nil {
+			t.Logf("WriteVariable(%s %v) error = %v", v.Name, v.Dimensions, err)
+			return false
+		}
+		// Compressed elements are not padded
+		if tc.Compression == 0 && buf.Len()%8 != 0 {
+			t.Logf("%s: file length %d is not a multiple of 8", v.Name, buf.Len())
+			return false
+		}
+
+		parser, err := NewParser(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		file, err := parser.Parse()
+		if err != nil || len(file.Variables) != 1 {
+			t.Logf("%s %v: Parse() = %v, %v", v.Name, v.Dimensions, file, err)
+			return false
+		}
+		got := file.Variables[0]
+
+		wantDims := v.Dimensions
+		if len(wantDims) == 1 {
+			wantDims = []int{wantDims[0], 1}
+		}
+		wantData := v.Data
+		if numArray, ok := v.Data.(*types.NumericArray); ok {
+			wantData = &types.NumericArray{Real: numArray.Real, Imag: numArray.Imag, Dimensions: wantDims, Type: numArray.Type}
+		}
+		if got.Name != v.Name || !reflect.DeepEqual(got.Dimensions, wantDims) ||
+			got.DataType != v.DataType || got.IsComplex != v.IsComplex || !reflect.DeepEqual(got.Data, wantData) {
+			t.Logf("round trip of %s %v (%s) = %s %v (%s)", v.Name, v.Dimensions, v.DataType, got.Name, got.Dimensions, got.DataType)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 300}); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestWriteVariable_NamePadding checks names whose length sits on or next
+// to the 8-byte padding boundary, and names short enough for the 4-byte
+// small data element format.
+func TestWriteVariable_NamePadding(t *testing.T) {
+	for _, n := range []int{1, 3, 4, 5, 7, 8, 9, 15, 16, 17, 24, 31, 32, 56, 63} {
+		name := strings.Repeat("x", n)
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, "", "IM")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range []*types.Variable{
+			{Name: name, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{float64(n)}},
+			{Name: "next", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{-1}},
+		} {
+			if err := w.WriteVariable(v); err != nil {
+				t.Fatalf("WriteVariable(%d-char name) error = %v", n, err)
+			}
+		}
+
+		parser, err := NewParser(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := parser.Parse()
+		if err != nil {
+			t.Fatalf("%d-char name: Parse() error = %v", n, err)
+		}
+		if len(file.Variables) != 2 || file.Variables[0].Name != name || file.Variables[1].Name != "next" {
+			t.Errorf("%d-char name: got %d variables", n, len(file.Variables))
+			continue
+		}
+		if got := file.Variables[0].Data.([]float64)[0]; got != float64(n) {
+			t.Errorf("%d-char name: value = %v, want %d", n, got, n)
+		}
+	}
+}
+
+// TestWriteVariable_ShapeEdgeCases covers dimension lists the spec allows
+// and data the dimensions do not describe.
+func TestWriteVariable_ShapeEdgeCases(t *testing.T) {
+	valid := []struct {
+		v        *types.Variable
+		wantDims []int
+	}{
+		{&types.Variable{Name: "vec", Dimensions: []int{3}, DataType: types.Double, Data: []float64{1, 2, 3}}, []int{3, 1}},
+		{&types.Variable{Name: "hyper", Dimensions: []int{2, 1, 2, 1, 2}, DataType: types.Int8, Data: []int8{1, 2, 3, 4, 5, 6, 7, 8}},
+			[]int{2, 1, 2, 1, 2}},
+		{&types.Variable{Name: "pages", Dimensions: []int{1, 2, 3}, DataType: types.Char, Data: "abcdef"}, []int{1, 2, 3}},
+	}
+	for _, tt := range valid {
+		v := tt.v
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, "", "IM")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+		}
+		parser, err := NewParser(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := parser.Parse()
+		if err != nil {
+			t.Fatalf("%s: Parse() error = %v", v.Name, err)
+		}
+		if got := file.Variables[0].Dimensions; !reflect.DeepEqual(got, tt.wantDims) {
+			t.Errorf("%s: dimensions = %v, want %v", v.Name, got, tt.wantDims)
+		}
+	}
+
+	invalid := []*types.Variable{
+		{Name: "short", Dimensions: []int{2, 2}, DataType: types.Double, Data: []float64{1, 2, 3}},
+		{Name: "long", Dimensions: []int{1, 2}, DataType: types.Uint8, Data: []uint8{1, 2, 3}},
+		{Name: "text", Dimensions: []int{1, 3}, DataType: types.Char, Data: "ab"},
+		{Name: "imag", Dimensions: []int{1, 2}, DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1, 2}, Imag: []float64{1}}},
+		{Name: "nul\x00", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}},
+		{Name: "\x00\x00", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}},
+	}
+	for _, v := range invalid {
+		w, err := NewWriter(&bytes.Buffer{}, "", "IM")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteVariable(v); err == nil {
+			t.Errorf("WriteVariable(%q) error = nil", v.Name)
+		}
+	}
+}
+
+// TestWriteVariables tests that a batch is written in order, matches the
+// elements WriteVariable produces, and is rejected as a whole.
+func TestWriteVariables(t *testing.T) {
+	batch := []*types.Variable{
+		{Name: "a", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}},
+		{Name: "b", Dimensions: []int{1, 3}, DataType: types.Char, Data: "abc"},
+		{Name: "c", Dimensions: []int{2, 1}, DataType: types.Int16, Data: []int16{-1, 1}},
+	}
+	for _, compression := range []int{0, 6} {
+		var single, batched bytes.Buffer
+		ws, err := NewWriter(&single, "", "IM")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wb, err := NewWriter(&batched, "", "IM")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ws.Compression, wb.Compression = compression, compression
+		for _, v := range batch {
+			if err := ws.WriteVariable(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := wb.WriteVariables(batch); err != nil {
+			t.Fatalf("WriteVariables() error = %v", err)
+		}
+		if !bytes.Equal(single.Bytes(), batched.Bytes()) {
+			t.Errorf("compression %d: batch differs from single writes", compression)
+		}
+		if wb.Offset() != int64(batched.Len()) {
+			t.Errorf("Offset() = %d, want %d", wb.Offset(), batched.Len())
+		}
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "", "IM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := append(batch[:2:2], &types.Variable{Name: "d", Dimensions: []int{1, 2}, DataType: types.Int32, Data: []float64{1, 2}})
+	if err := w.WriteVariables(bad); err == nil {
+		t.Error("WriteVariables(wrong data type) error = nil")
+	}
+	if buf.Len() != 128 || w.Offset() != 128 {
+		t.Errorf("rejected batch wrote %d bytes, offset %d", buf.Len()-128, w.Offset())
+	}
+
+	// A failing writer leaves Offset at the start of the batch
+	fw := &failWriter{failAfter: 200}
+	w, err = NewWriter(fw, "", "IM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteVariables(batch); err == nil {
+		t.Error("WriteVariables(failing writer) error = nil")
+	}
+	if w.Offset() != 128 {
+		t.Errorf("Offset() after failed batch = %d, want 128", w.Offset())
+	}
+}
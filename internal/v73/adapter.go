@@ -1,7 +1,14 @@
 package v73
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode/utf16"
 
 	"github.com/scigolib/hdf5"
 	"github.com/scigolib/matlab/types"
@@ -22,113 +29,281 @@ const (
 	matlabClassChar   = "char"
 	matlabClassStruct = "struct"
 	matlabClassCell   = "cell"
+	matlabClassBool   = "logical"
 )
 
+// Default traversal limits for HDF5 object graphs.
+//
+// MATLAB itself never nests groups more than a handful of levels deep, so
+// these values only reject crafted or corrupted files.
+const (
+	DefaultMaxDepth   = 64
+	DefaultMaxObjects = 1 << 20
+)
+
+// Traversal errors returned by ConvertToMatlab.
+var (
+	// ErrMaxDepthExceeded indicates group nesting deeper than Limits.MaxDepth.
+	ErrMaxDepthExceeded = errors.New("HDF5 group nesting exceeds maximum depth")
+
+	// ErrMaxObjectsExceeded indicates more objects than Limits.MaxObjects.
+	ErrMaxObjectsExceeded = errors.New("HDF5 object count exceeds maximum")
+
+	// ErrCycleDetected indicates a group that links back to one of its ancestors.
+	ErrCycleDetected = errors.New("HDF5 group hierarchy contains a cycle")
+)
+
+// Limits bounds the work done while traversing an HDF5 object graph.
+// A zero field selects the corresponding default.
+type Limits struct {
+	MaxDepth   int // Maximum group nesting depth (root is depth 0)
+	MaxObjects int // Maximum number of groups and datasets visited
+}
+
+// withDefaults returns a copy of l with zero fields replaced by defaults.
+func (l Limits) withDefaults() Limits {
+	if l.MaxDepth <= 0 {
+		l.MaxDepth = DefaultMaxDepth
+	}
+	if l.MaxObjects <= 0 {
+		l.MaxObjects = DefaultMaxObjects
+	}
+	return l
+}
+
 // HDF5Adapter adapts HDF5 structures to MATLAB types.
 type HDF5Adapter struct {
-	file *hdf5.File
+	file   *hdf5.File
+	limits Limits
+
+	// FlattenStructs reports the fields of struct groups as separate
+	// variables named by their HDF5 path ("/run42/x") instead of
+	// converting each group to one struct variable.
+	FlattenStructs bool
+
+	// Transform, if set, is applied to each variable as soon as it is
+	// converted. Returning nil drops the variable.
+	Transform func(*types.Variable) (*types.Variable, error)
+
+	// ResolveSoftLinks converts soft links to the object they point to,
+	// under the link's name. Otherwise, and for dangling soft links and
+	// external links, a link is converted to a char variable holding its
+	// target, with AttrLinkTarget and AttrLinkFile attributes.
+	ResolveSoftLinks bool
+
+	// KeepRaw records the stored bytes of each contiguous dataset on its
+	// variable (see types.Variable.RawBytes).
+	KeepRaw bool
+
+	// DimLimits bounds the shape of datasets whose data is read.
+	DimLimits types.DimLimits
+
+	// Traversal state, reset by ConvertToMatlab.
+	objects   int
+	ancestry  map[uint64]bool   // Object header addresses of the groups being converted
+	addresses map[string]uint64 // Object header addresses by path, read as needed
+	datasets  map[uint64]bool
+	links     map[string]Link // Soft and external links by path
 }
 
-// NewHDF5Adapter creates a new adapter.
+// NewHDF5Adapter creates a new adapter with default traversal limits.
 func NewHDF5Adapter(file *hdf5.File) *HDF5Adapter {
-	return &HDF5Adapter{file: file}
+	return NewHDF5AdapterWithLimits(file, Limits{})
+}
+
+// NewHDF5AdapterWithLimits creates a new adapter with custom traversal limits.
+func NewHDF5AdapterWithLimits(file *hdf5.File, limits Limits) *HDF5Adapter {
+	return &HDF5Adapter{file: file, limits: limits.withDefaults(), ResolveSoftLinks: true}
 }
 
 // ConvertToMatlab converts HDF5 file to MATLAB variables.
+//
+// Returns ErrMaxDepthExceeded, ErrMaxObjectsExceeded or ErrCycleDetected
+// (wrapped with the offending path) if the object graph violates the limits.
 func (a *HDF5Adapter) ConvertToMatlab() ([]*types.Variable, error) {
 	var variables []*types.Variable
 
+	a.objects = 0
+	a.ancestry = make(map[uint64]bool)
+	a.addresses = map[string]uint64{"": a.file.Superblock().RootGroup}
+	a.datasets = make(map[uint64]bool)
+
+	// Files whose group structures cannot be parsed for links are read
+	// as before, without them.
+	links, err := readLinks(a.file, a.limits)
+	if err != nil {
+		links = nil
+	}
+	a.links = links
+
 	// Traverse the root group
 	root := a.file.Root()
-	a.traverseGroup(root, "", &variables)
+	if err := a.traverseGroup(root, "", 0, &variables); err != nil {
+		return nil, err
+	}
 
 	return variables, nil
 }
 
+// visit accounts for one more object and enforces the object limit.
+func (a *HDF5Adapter) visit(path string) error {
+	a.objects++
+	if a.objects > a.limits.MaxObjects {
+		return fmt.Errorf("%w: %d objects at %q", ErrMaxObjectsExceeded, a.limits.MaxObjects, path)
+	}
+	return nil
+}
+
 // traverseGroup recursively processes groups and datasets.
-func (a *HDF5Adapter) traverseGroup(group *hdf5.Group, path string, variables *[]*types.Variable) {
-	// Check if this is a complex number group by looking for MATLAB_complex attribute
+//
+// Datasets reachable through several hard links are converted only once,
+// keyed by their object header address. A group hard linked into itself
+// or one of its members is reported as a cycle, also keyed by address.
+func (a *HDF5Adapter) traverseGroup(group *hdf5.Group, path string, depth int, variables *[]*types.Variable) error {
+	if depth > a.limits.MaxDepth {
+		return fmt.Errorf("%w: %d levels at %q", ErrMaxDepthExceeded, a.limits.MaxDepth, path)
+	}
+	if address, ok := a.address(path); ok {
+		if a.ancestry[address] {
+			return fmt.Errorf("%w at %q", ErrCycleDetected, path)
+		}
+		a.ancestry[address] = true
+		defer delete(a.ancestry, address)
+	}
+
+	if err := a.visit(path); err != nil {
+		return err
+	}
+
 	// Complex groups have structure: group -> real/imag datasets
-	isComplexGroup := false
-	attrs, err := group.Attributes()
-	if err == nil {
-		for _, attr := range attrs {
-			if attr.Name == "MATLAB_complex" {
-				// Found MATLAB_complex attribute - this is a complex variable
-				isComplexGroup = true
-				break
-			}
+	isComplexGroup, isStructGroup := classifyGroup(group)
+
+	if isStructGroup && path != "" && !a.FlattenStructs {
+		// Struct fields are converted as part of the struct, not as
+		// separate variables.
+		variable, err := a.convertStructGroup(group, path, depth)
+		if err != nil {
+			return err
 		}
+		return a.addVariable(variables, variable)
 	}
 
 	if isComplexGroup {
 		// This IS a complex variable - convert it and don't traverse children
 		variable, err := a.convertComplexGroup(group, path)
 		if err == nil {
-			*variables = append(*variables, variable)
-			return // Don't traverse children (real/imag datasets)
+			// Don't traverse children (real/imag datasets)
+			return a.addVariable(variables, variable)
 		}
 		// If conversion failed, fall through to normal traversal
 	}
 
 	// Process all children (datasets and subgroups)
 	for _, child := range group.Children() {
+		if _, ok := a.links[path+"/"+child.Name()]; ok {
+			continue // Converted with the other links below
+		}
 		switch obj := child.(type) {
 		case *hdf5.Dataset:
-			variable := a.convertDataset(obj, path)
-			*variables = append(*variables, variable)
+			if a.datasets[obj.Address()] {
+				continue
+			}
+			a.datasets[obj.Address()] = true
+			if err := a.visit(path + "/" + obj.Name()); err != nil {
+				return err
+			}
+			variable, err := a.convertDataset(obj, path)
+			if err != nil {
+				return err
+			}
+			if err := a.addVariable(variables, variable); err != nil {
+				return err
+			}
 		case *hdf5.Group:
+			if path == "" && strings.HasPrefix(obj.Name(), "#") {
+				continue // MATLAB's own groups, such as #refs#
+			}
 			newPath := path + "/" + obj.Name()
-			a.traverseGroup(obj, newPath, variables)
+			if err := a.traverseGroup(obj, newPath, depth+1, variables); err != nil {
+				return err
+			}
+		}
+	}
+
+	links, err := a.convertLinks(path, depth)
+	if err != nil {
+		return err
+	}
+	for _, variable := range links {
+		if err := a.addVariable(variables, variable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addVariable applies Transform to a converted variable and appends the
+// result to variables.
+func (a *HDF5Adapter) addVariable(variables *[]*types.Variable, variable *types.Variable) error {
+	if a.Transform != nil {
+		name := variable.Name
+		var err error
+		if variable, err = a.Transform(variable); err != nil {
+			return fmt.Errorf("transform %q: %w", name, err)
+		}
+		if variable == nil {
+			return nil
+		}
+	}
+	*variables = append(*variables, variable)
+	return nil
+}
+
+// classifyGroup reports whether a group holds a complex variable (it has a
+// MATLAB_complex attribute) or a struct (its MATLAB_class is "struct", or
+// it is an object stored as a struct of its properties).
+func classifyGroup(group *hdf5.Group) (isComplex, isStruct bool) {
+	attrs, err := group.Attributes()
+	if err != nil {
+		return false, false
+	}
+	for _, attr := range attrs {
+		switch attr.Name {
+		case types.AttrMatlabComplex:
+			isComplex = true
+		case types.AttrMatlabClass:
+			if val, err := attr.ReadValue(); err == nil && val == matlabClassStruct {
+				isStruct = true
+			}
+		case types.AttrMatlabObjectDecode:
+			if val, err := attr.ReadValue(); err == nil && val == types.ObjectDecodeStruct {
+				isStruct = true
+			}
 		}
 	}
+	return isComplex, isStruct
 }
 
 // convertDataset converts HDF5 dataset to MATLAB variable.
-func (a *HDF5Adapter) convertDataset(dataset *hdf5.Dataset, path string) *types.Variable {
+func (a *HDF5Adapter) convertDataset(dataset *hdf5.Dataset, path string) (*types.Variable, error) {
 	name := path + "/" + dataset.Name()
 	if path == "" {
 		name = dataset.Name()
 	}
+	if err := a.checkDims(dataset); err != nil {
+		return nil, fmt.Errorf("dataset %q: %w", name, err)
+	}
 
 	// Determine MATLAB class from attributes
 	matlabClass := matlabClassDouble
-	if val, err := dataset.ReadAttribute("MATLAB_class"); err == nil {
+	if val, err := dataset.ReadAttribute(types.AttrMatlabClass); err == nil {
 		if strVal, ok := val.(string); ok {
 			matlabClass = strVal
 		}
 	}
 
 	// Determine data type
-	dataType := types.Unknown
-	switch matlabClass {
-	case matlabClassDouble:
-		dataType = types.Double
-	case matlabClassSingle:
-		dataType = types.Single
-	case matlabClassInt8:
-		dataType = types.Int8
-	case matlabClassUint8:
-		dataType = types.Uint8
-	case matlabClassInt16:
-		dataType = types.Int16
-	case matlabClassUint16:
-		dataType = types.Uint16
-	case matlabClassInt32:
-		dataType = types.Int32
-	case matlabClassUint32:
-		dataType = types.Uint32
-	case matlabClassInt64:
-		dataType = types.Int64
-	case matlabClassUint64:
-		dataType = types.Uint64
-	case matlabClassChar:
-		dataType = types.Char
-	case matlabClassStruct:
-		dataType = types.Struct
-	case matlabClassCell:
-		dataType = types.CellArray
-	}
+	dataType := a.matlabClassToDataType(matlabClass)
 
 	// Read data - try numeric first, then strings as fallback.
 	// This handles both numeric arrays and character/string datasets.
@@ -139,6 +314,24 @@ func (a *HDF5Adapter) convertDataset(dataset *hdf5.Dataset, path string) *types.
 	if err == nil {
 		data = numData
 		dims = []int{len(numData)}
+		switch dataType {
+		case types.Logical:
+			data = float64ToBool(numData)
+		case types.Single, types.Int32, types.Uint32, types.Int64, types.Uint64:
+			data = a.typedData(dataset, dataType, numData)
+		}
+	} else if values, vlenErr := readVarLenStrings(a.file, dataset, maxGlobalHeapBytes); vlenErr == nil {
+		// Names and labels written by other HDF5 tools
+		strs := varLenStringVariable(dataset, values)
+		data, dims, dataType = strs.Data, strs.Dimensions, strs.DataType
+	} else if rawData, rawErr := a.readRawDataset(dataset, dataType); rawErr == nil {
+		// 1- and 2-byte integers are not converted by the HDF5 library
+		data = rawData
+		if str, ok := rawData.(string); ok {
+			dims = []int{1, len(utf16.Encode([]rune(str)))}
+		} else {
+			dims = []int{reflect.ValueOf(rawData).Len()}
+		}
 	} else {
 		// If numeric read fails, try string read
 		strData, strErr := dataset.ReadStrings()
@@ -155,7 +348,7 @@ func (a *HDF5Adapter) convertDataset(dataset *hdf5.Dataset, path string) *types.
 	// Create variable
 	variable := &types.Variable{
 		Name:       name,
-		Dimensions: dims,
+		Dimensions: datasetDims(dataset, dims),
 		DataType:   dataType,
 		Data:       data,
 	}
@@ -165,13 +358,116 @@ func (a *HDF5Adapter) convertDataset(dataset *hdf5.Dataset, path string) *types.
 	attrList, err := dataset.Attributes()
 	if err == nil {
 		for _, attr := range attrList {
-			// Note: core.Attribute has Name field, not Name() method
-			attrs[attr.Name] = attr
+			// Attributes whose value cannot be decoded are left out
+			if value, err := attr.ReadValue(); err == nil {
+				attrs[attr.Name] = attributeValue(value)
+			}
+		}
+	}
+	if times, err := a.objectTimes(dataset.Address()); err == nil {
+		for name, value := range times.attributes() {
+			attrs[name] = value
 		}
 	}
 	variable.Attributes = attrs
 
-	return variable
+	if a.KeepRaw {
+		if raw, err := rawData(a.file, dataset, matlabClass); err == nil {
+			variable.SetRawBytes(raw)
+		}
+	}
+	variable.SetLocation(datasetLocation(dataset))
+
+	return variable, nil
+}
+
+// checkDims checks the dataspace of a dataset against DimLimits before
+// its data is read.
+func (a *HDF5Adapter) checkDims(dataset *hdf5.Dataset) error {
+	info, err := dataset.Info()
+	if err != nil {
+		return nil //nolint:nilerr // Reading the dataset reports the error
+	}
+	m := datasetInfoPattern.FindStringSubmatch(info)
+	if m == nil {
+		return nil
+	}
+	space := parseDataspace(m[3])
+	dims := make([]int, len(space))
+	for i, d := range space {
+		if d > math.MaxInt {
+			d = math.MaxInt
+		}
+		dims[i] = int(d) //nolint:gosec // G115: clamped above
+	}
+	return a.DimLimits.Check(dims)
+}
+
+// datasetDims returns the MATLAB dimensions of a dataset if they match the
+// number of elements read, and fallback (the flat length) otherwise.
+// MATLAB stores the dataspace dimensions in reverse order, so an m-by-n
+// matrix has the dataspace (n, m).
+func datasetDims(dataset *hdf5.Dataset, fallback []int) []int {
+	info, err := dataset.Info()
+	if err != nil {
+		return fallback
+	}
+	m := datasetInfoPattern.FindStringSubmatch(info)
+	if m == nil {
+		return fallback
+	}
+	space := parseDataspace(m[3])
+	if len(space) == 0 {
+		return fallback
+	}
+
+	count := 1
+	for _, d := range fallback {
+		count *= d
+	}
+	dims := make([]int, len(space))
+	n := 1
+	for i, d := range space {
+		dims[i] = int(d) //nolint:gosec // G115: bounded by the element count checked below
+		n *= dims[i]
+	}
+	if n != count {
+		return fallback
+	}
+	slices.Reverse(dims)
+	return dims
+}
+
+// attributeValue converts a decoded HDF5 attribute value to the native
+// types reported in Variable.Attributes: string, int64, float64 or slices
+// of those.
+func attributeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case []int32:
+		out := make([]int64, len(v))
+		for i, x := range v {
+			out[i] = int64(x)
+		}
+		return out
+	case []float32:
+		out := make([]float64, len(v))
+		for i, x := range v {
+			out[i] = float64(x)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// objectTimes reads the times recorded in the object header at address.
+func (a *HDF5Adapter) objectTimes(address uint64) (ObjectTimes, error) {
+	sb := a.file.Superblock()
+	return readObjectTimes(a.file.Reader(), address, int(sb.OffsetSize), int(sb.LengthSize))
 }
 
 // convertComplexGroup converts an HDF5 group representing a complex MATLAB variable.
@@ -199,34 +495,35 @@ func (a *HDF5Adapter) convertComplexGroup(group *hdf5.Group, name string) (*type
 		return nil, fmt.Errorf("complex group missing 'imag' dataset")
 	}
 
+	// MATLAB and the writer store the class on the group; older files
+	// written by this library stored it on the real dataset only
+	dataType := a.groupClass(group)
+	if dataType == types.Unknown {
+		if val, err := realDS.ReadAttribute(types.AttrMatlabClass); err == nil {
+			if classStr, ok := val.(string); ok {
+				dataType = a.matlabClassToDataType(classStr)
+			}
+		}
+	}
+
+	// Default to Double if unknown
+	if dataType == types.Unknown {
+		dataType = types.Double
+	}
+
 	// Read real data (this also gives us dimensions)
-	realData, err := realDS.Read()
+	realData, n, err := a.readComplexPart(realDS, dataType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read real data: %w", err)
 	}
 
 	// Read imag data
-	imagData, err := imagDS.Read()
+	imagData, _, err := a.readComplexPart(imagDS, dataType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read imag data: %w", err)
 	}
 
-	// Determine dimensions from data length
-	// Read() returns []float64, so use len() directly
-	dimensions := []int{len(realData)}
-
-	// Get MATLAB_class from real dataset (groups may not support ReadAttribute)
-	var dataType types.DataType
-	if val, err := realDS.ReadAttribute("MATLAB_class"); err == nil {
-		if classStr, ok := val.(string); ok {
-			dataType = a.matlabClassToDataType(classStr)
-		}
-	}
-
-	// Default to Double if unknown
-	if dataType == types.Unknown {
-		dataType = types.Double
-	}
+	dimensions := datasetDims(realDS, []int{n})
 
 	// Strip leading slash from name if present
 	if name != "" && name[0] == '/' {
@@ -234,7 +531,7 @@ func (a *HDF5Adapter) convertComplexGroup(group *hdf5.Group, name string) (*type
 	}
 
 	// Create Variable
-	return &types.Variable{
+	variable := &types.Variable{
 		Name:       name,
 		IsComplex:  true,
 		Dimensions: dimensions,
@@ -243,7 +540,57 @@ func (a *HDF5Adapter) convertComplexGroup(group *hdf5.Group, name string) (*type
 			Real: realData,
 			Imag: imagData,
 		},
-	}, nil
+	}
+	readUserAttributes(group, variable)
+	return variable, nil
+}
+
+// groupClass returns the data type named by the MATLAB_class attribute of
+// a group, or types.Unknown if it has none.
+func (a *HDF5Adapter) groupClass(group *hdf5.Group) types.DataType {
+	return a.matlabClassToDataType(groupClassName(group))
+}
+
+// groupClassName returns the MATLAB_class attribute of a group, or "" if
+// it has none.
+func groupClassName(group *hdf5.Group) string {
+	attrs, err := group.Attributes()
+	if err != nil {
+		return ""
+	}
+	for _, attr := range attrs {
+		if attr.Name != types.AttrMatlabClass {
+			continue
+		}
+		if val, err := attr.ReadValue(); err == nil {
+			if classStr, ok := val.(string); ok {
+				return classStr
+			}
+		}
+	}
+	return ""
+}
+
+// readComplexPart reads the real or imaginary part of a complex variable
+// as a slice of the Go type of its class, and returns its length.
+func (a *HDF5Adapter) readComplexPart(dataset *hdf5.Dataset, dataType types.DataType) (interface{}, int, error) {
+	if err := a.checkDims(dataset); err != nil {
+		return nil, 0, err
+	}
+	numData, err := dataset.Read()
+	if err == nil {
+		switch dataType {
+		case types.Single, types.Int32, types.Uint32, types.Int64, types.Uint64:
+			return a.typedData(dataset, dataType, numData), len(numData), nil
+		}
+		return numData, len(numData), nil
+	}
+	// 1- and 2-byte integers are not converted by the HDF5 library
+	rawData, rawErr := a.readRawDataset(dataset, dataType)
+	if rawErr != nil {
+		return nil, 0, err
+	}
+	return rawData, reflect.ValueOf(rawData).Len(), nil
 }
 
 // matlabClassToDataType converts MATLAB class string to DataType.
@@ -275,7 +622,205 @@ func (a *HDF5Adapter) matlabClassToDataType(matlabClass string) types.DataType {
 		return types.Struct
 	case matlabClassCell:
 		return types.CellArray
+	case matlabClassBool:
+		return types.Logical
 	default:
 		return types.Unknown
 	}
 }
+
+// readRawDataset reads a dataset of 1- or 2-byte integers directly from
+// the file, returning []uint8, []int8, []bool, []int16, []uint16 or, for
+// char data, a string decoded from UTF-16.
+func (a *HDF5Adapter) readRawDataset(dataset *hdf5.Dataset, dataType types.DataType) (interface{}, error) {
+	switch dataType {
+	case types.Uint8, types.Int8, types.Logical, types.Int16, types.Uint16, types.Char:
+	default:
+		return nil, fmt.Errorf("not a 1- or 2-byte MATLAB class: %v", dataType)
+	}
+
+	raw, err := readRawContiguous(a.file, dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	switch dataType {
+	case types.Int8:
+		result := make([]int8, len(raw))
+		for i, val := range raw {
+			result[i] = int8(val)
+		}
+		return result, nil
+	case types.Logical:
+		result := make([]bool, len(raw))
+		for i, val := range raw {
+			result[i] = val != 0
+		}
+		return result, nil
+	case types.Uint8:
+		return raw, nil
+	}
+
+	if len(raw)%2 != 0 {
+		return nil, fmt.Errorf("odd byte count %d for 2-byte data", len(raw))
+	}
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(raw[i*2:])
+	}
+
+	switch dataType {
+	case types.Int16:
+		result := make([]int16, len(units))
+		for i, val := range units {
+			result[i] = int16(val)
+		}
+		return result, nil
+	case types.Char:
+		return string(utf16.Decode(units)), nil
+	default:
+		return units, nil
+	}
+}
+
+// typedData returns the data of a 4- or 8-byte numeric dataset as a slice
+// of the MATLAB class's Go type. The HDF5 library reads such datasets as
+// float64, which loses precision for 64-bit integers beyond 2^53, so the
+// raw bytes are decoded where the layout allows and the float64 values
+// converted otherwise.
+func (a *HDF5Adapter) typedData(dataset *hdf5.Dataset, dataType types.DataType, values []float64) interface{} {
+	size := 8
+	if dataType == types.Single || dataType == types.Int32 || dataType == types.Uint32 {
+		size = 4
+	}
+	raw, err := readRawContiguous(a.file, dataset)
+	if err != nil || len(raw) != size*len(values) {
+		raw = nil
+	}
+
+	n := len(values)
+	switch dataType {
+	case types.Single:
+		result := make([]float32, n)
+		for i, val := range values {
+			result[i] = float32(val)
+			if raw != nil {
+				result[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:]))
+			}
+		}
+		return result
+	case types.Int32:
+		result := make([]int32, n)
+		for i, val := range values {
+			result[i] = int32(val)
+			if raw != nil {
+				result[i] = int32(binary.LittleEndian.Uint32(raw[i*4:])) //nolint:gosec // G115: two's complement reinterpretation
+			}
+		}
+		return result
+	case types.Uint32:
+		result := make([]uint32, n)
+		for i, val := range values {
+			result[i] = uint32(val)
+			if raw != nil {
+				result[i] = binary.LittleEndian.Uint32(raw[i*4:])
+			}
+		}
+		return result
+	case types.Int64:
+		result := make([]int64, n)
+		for i, val := range values {
+			result[i] = int64(val)
+			if raw != nil {
+				result[i] = int64(binary.LittleEndian.Uint64(raw[i*8:])) //nolint:gosec // G115: two's complement reinterpretation
+			}
+		}
+		return result
+	default:
+		result := make([]uint64, n)
+		for i, val := range values {
+			result[i] = uint64(val)
+			if raw != nil {
+				result[i] = binary.LittleEndian.Uint64(raw[i*8:])
+			}
+		}
+		return result
+	}
+}
+
+// convertStructGroup converts an HDF5 group with MATLAB_class "struct" to
+// a scalar struct variable. Each child dataset or group becomes a field.
+func (a *HDF5Adapter) convertStructGroup(group *hdf5.Group, path string, depth int) (*types.Variable, error) {
+	st := &types.StructArray{Dimensions: []int{1, 1}}
+	var values []*types.Variable
+
+	for _, child := range group.Children() {
+		if _, ok := a.links[path+"/"+child.Name()]; ok {
+			continue // Converted with the other links below
+		}
+		var fields []*types.Variable
+		switch obj := child.(type) {
+		case *hdf5.Dataset:
+			if err := a.visit(path + "/" + obj.Name()); err != nil {
+				return nil, err
+			}
+			field, err := a.convertDataset(obj, "")
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, field)
+		case *hdf5.Group:
+			// Plain subgroups are not fields; their contents are skipped.
+			if isComplex, isStruct := classifyGroup(obj); !isComplex && !isStruct {
+				continue
+			}
+			if err := a.traverseGroup(obj, path+"/"+obj.Name(), depth+1, &fields); err != nil {
+				return nil, err
+			}
+		}
+		if len(fields) != 1 {
+			continue
+		}
+		field := fields[0]
+		field.Name = child.Name()
+		st.Fields = append(st.Fields, field.Name)
+		values = append(values, field)
+	}
+	links, err := a.convertLinks(path, depth)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range links {
+		field.Name = field.Name[len(path)+1:]
+		st.Fields = append(st.Fields, field.Name)
+		values = append(values, field)
+	}
+	st.Elements = [][]*types.Variable{values}
+
+	name := path
+	if name != "" && name[0] == '/' {
+		name = name[1:]
+	}
+
+	variable := &types.Variable{
+		Name:       name,
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data:       st,
+	}
+	// Objects stored as structs keep their class
+	if class := groupClassName(group); class != "" && class != matlabClassStruct {
+		setAttribute(variable, types.AttrMatlabClass, class)
+	}
+	readUserAttributes(group, variable)
+	return variable, nil
+}
+
+// float64ToBool converts logical values stored as numbers (0 or 1) to []bool.
+func float64ToBool(data []float64) []bool {
+	result := make([]bool, len(data))
+	for i, val := range data {
+		result[i] = val != 0
+	}
+	return result
+}
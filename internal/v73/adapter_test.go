@@ -1,7 +1,10 @@
 package v73
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/scigolib/hdf5"
@@ -347,6 +350,46 @@ func TestConvertToMatlab_2DMatrix(t *testing.T) {
 	}
 }
 
+// TestConvertToMatlab_DimensionOrder reads a dataset laid out as MATLAB
+// saves a 2x3 matrix: the dataspace is (3, 2) and the data column-major.
+func TestConvertToMatlab_DimensionOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matlab.mat")
+	fw, err := hdf5.CreateForWrite(path, hdf5.CreateTruncate)
+	if err != nil {
+		t.Fatalf("CreateForWrite() error = %v", err)
+	}
+	ds, err := fw.CreateDataset("/M", hdf5.Float64, []uint64{3, 2})
+	if err != nil {
+		t.Fatalf("CreateDataset() error = %v", err)
+	}
+	if err := ds.WriteAttribute(types.AttrMatlabClass, "double"); err != nil {
+		t.Fatalf("WriteAttribute() error = %v", err)
+	}
+	if err := ds.Write([]float64{1, 4, 2, 5, 3, 6}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	file := openHDF5(t, path)
+	defer file.Close()
+	variables, err := NewHDF5Adapter(file).ConvertToMatlab()
+	if err != nil {
+		t.Fatalf("ConvertToMatlab() error = %v", err)
+	}
+	if len(variables) != 1 {
+		t.Fatalf("expected 1 variable, got %d", len(variables))
+	}
+	v := variables[0]
+	if !reflect.DeepEqual(v.Dimensions, []int{2, 3}) {
+		t.Errorf("Dimensions = %v, want [2 3]", v.Dimensions)
+	}
+	if !reflect.DeepEqual(v.Data, []float64{1, 4, 2, 5, 3, 6}) {
+		t.Errorf("Data = %v, want column-major [1 4 2 5 3 6]", v.Data)
+	}
+}
+
 func TestConvertToMatlab_ScalarVariable(t *testing.T) {
 	tmpFile := writeTestFile(t, &types.Variable{
 		Name:       "pi",
@@ -405,7 +448,288 @@ func TestConvertToMatlab_VariableAttributes(t *testing.T) {
 	if v.Attributes == nil {
 		t.Fatal("Attributes map is nil")
 	}
-	if _, ok := v.Attributes["MATLAB_class"]; !ok {
-		t.Error("MATLAB_class attribute not found in variable attributes")
+	if class, ok := v.GetStringAttr("MATLAB_class"); !ok || class != "int32" {
+		t.Errorf("MATLAB_class attribute = %v, want \"int32\"", v.Attributes["MATLAB_class"])
+	}
+}
+
+// writeNestedGroups creates an HDF5 file with a chain of nested groups
+// /g1/g2/.../gN and a single dataset in the innermost group.
+func writeNestedGroups(t *testing.T, depth int) string {
+	t.Helper()
+	tmpFile := filepath.Join(t.TempDir(), "nested.mat")
+	fw, err := hdf5.CreateForWrite(tmpFile, hdf5.CreateTruncate)
+	if err != nil {
+		t.Fatalf("CreateForWrite failed: %v", err)
+	}
+	path := ""
+	for i := 1; i <= depth; i++ {
+		path += fmt.Sprintf("/g%d", i)
+		if _, err := fw.CreateGroup(path); err != nil {
+			t.Fatalf("CreateGroup(%s) failed: %v", path, err)
+		}
+	}
+	ds, err := fw.CreateDataset(path+"/x", hdf5.Float64, []uint64{1})
+	if err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := ds.Write([]float64{1}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return tmpFile
+}
+
+func TestConvertToMatlab_MaxDepth(t *testing.T) {
+	tmpFile := writeNestedGroups(t, 4)
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		wantErr  bool
+	}{
+		{name: "default limit", maxDepth: 0, wantErr: false},
+		{name: "exact depth", maxDepth: 4, wantErr: false},
+		{name: "too shallow", maxDepth: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := openHDF5(t, tmpFile)
+			defer file.Close()
+
+			adapter := NewHDF5AdapterWithLimits(file, Limits{MaxDepth: tt.maxDepth})
+			variables, err := adapter.ConvertToMatlab()
+			if tt.wantErr {
+				if !errors.Is(err, ErrMaxDepthExceeded) {
+					t.Fatalf("expected ErrMaxDepthExceeded, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertToMatlab() error = %v", err)
+			}
+			if len(variables) != 1 || variables[0].Name != "/g1/g2/g3/g4/x" {
+				t.Errorf("unexpected variables: %v", variables)
+			}
+		})
+	}
+}
+
+func TestConvertToMatlab_MaxObjects(t *testing.T) {
+	tmpFile := writeTestFile(t,
+		&types.Variable{Name: "a", Dimensions: []int{1}, DataType: types.Double, Data: []float64{1}},
+		&types.Variable{Name: "b", Dimensions: []int{1}, DataType: types.Double, Data: []float64{2}},
+		&types.Variable{Name: "c", Dimensions: []int{1}, DataType: types.Double, Data: []float64{3}},
+	)
+
+	file := openHDF5(t, tmpFile)
+	defer file.Close()
+
+	// Root group plus three datasets is four objects.
+	adapter := NewHDF5AdapterWithLimits(file, Limits{MaxObjects: 3})
+	if _, err := adapter.ConvertToMatlab(); !errors.Is(err, ErrMaxObjectsExceeded) {
+		t.Fatalf("expected ErrMaxObjectsExceeded, got %v", err)
+	}
+
+	adapter = NewHDF5AdapterWithLimits(file, Limits{MaxObjects: 4})
+	variables, err := adapter.ConvertToMatlab()
+	if err != nil {
+		t.Fatalf("ConvertToMatlab() error = %v", err)
+	}
+	if len(variables) != 3 {
+		t.Errorf("expected 3 variables, got %d", len(variables))
+	}
+}
+
+func TestConvertToMatlab_HardLinkedDatasetOnce(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "links.mat")
+	fw, err := hdf5.CreateForWrite(tmpFile, hdf5.CreateTruncate)
+	if err != nil {
+		t.Fatalf("CreateForWrite failed: %v", err)
+	}
+	ds, err := fw.CreateDataset("/x", hdf5.Float64, []uint64{2})
+	if err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := ds.Write([]float64{1, 2}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := fw.CreateHardLink("/alias", "/x"); err != nil {
+		t.Fatalf("CreateHardLink failed: %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	file := openHDF5(t, tmpFile)
+	defer file.Close()
+
+	variables, err := NewHDF5Adapter(file).ConvertToMatlab()
+	if err != nil {
+		t.Fatalf("ConvertToMatlab() error = %v", err)
+	}
+	if len(variables) != 1 {
+		t.Errorf("expected hard-linked dataset to be converted once, got %d variables", len(variables))
+	}
+}
+
+func TestConvertToMatlab_HardLinkCycle(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "cycle.mat")
+	fw, err := hdf5.CreateForWrite(tmpFile, hdf5.CreateTruncate)
+	if err != nil {
+		t.Fatalf("CreateForWrite failed: %v", err)
+	}
+	if _, err := fw.CreateGroup("/a"); err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	ds, err := fw.CreateDataset("/a/x", hdf5.Float64, []uint64{2})
+	if err != nil {
+		t.Fatalf("CreateDataset failed: %v", err)
+	}
+	if err := ds.Write([]float64{1, 2}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	// /a/loop is /a itself; the HDF5 library loads it as a separate,
+	// empty group
+	if err := fw.CreateHardLink("/a/loop", "/a"); err != nil {
+		t.Fatalf("CreateHardLink failed: %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	file := openHDF5(t, tmpFile)
+	defer file.Close()
+
+	adapter := NewHDF5Adapter(file)
+	adapter.FlattenStructs = true
+	if _, err := adapter.ConvertToMatlab(); !errors.Is(err, ErrCycleDetected) {
+		t.Fatalf("expected ErrCycleDetected, got %v", err)
+	}
+}
+
+func TestLimits_WithDefaults(t *testing.T) {
+	got := Limits{}.withDefaults()
+	if got.MaxDepth != DefaultMaxDepth || got.MaxObjects != DefaultMaxObjects {
+		t.Errorf("withDefaults() = %+v", got)
+	}
+	got = Limits{MaxDepth: 2, MaxObjects: 5}.withDefaults()
+	if got.MaxDepth != 2 || got.MaxObjects != 5 {
+		t.Errorf("withDefaults() overrode explicit limits: %+v", got)
+	}
+}
+
+func TestConvertToMatlab_ByteDatasets(t *testing.T) {
+	tmpFile := writeTestFile(t,
+		&types.Variable{Name: "u", Dimensions: []int{3}, DataType: types.Uint8, Data: []uint8{1, 2, 255}},
+		&types.Variable{Name: "s", Dimensions: []int{3}, DataType: types.Int8, Data: []int8{-1, 0, 127}},
+		&types.Variable{Name: "b", Dimensions: []int{3}, DataType: types.Logical, Data: []bool{true, false, true}},
+	)
+
+	file := openHDF5(t, tmpFile)
+	defer file.Close()
+
+	variables, err := NewHDF5Adapter(file).ConvertToMatlab()
+	if err != nil {
+		t.Fatalf("ConvertToMatlab() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"u": []uint8{1, 2, 255},
+		"s": []int8{-1, 0, 127},
+		"b": []bool{true, false, true},
+	}
+	for _, v := range variables {
+		if !reflect.DeepEqual(v.Data, want[v.Name]) {
+			t.Errorf("%s: Data = %#v, want %#v", v.Name, v.Data, want[v.Name])
+		}
+		if len(v.Dimensions) != 1 || v.Dimensions[0] != 3 {
+			t.Errorf("%s: Dimensions = %v, want [3]", v.Name, v.Dimensions)
+		}
+		if v.Name == "b" && v.DataType != types.Logical {
+			t.Errorf("b: DataType = %v, want logical", v.DataType)
+		}
+		if decode, ok := v.GetIntAttr("MATLAB_int_decode"); v.Name == "b" && (!ok || decode != 1) {
+			t.Errorf("b: MATLAB_int_decode = %#v, want 1", v.Attributes["MATLAB_int_decode"])
+		}
+	}
+}
+
+func TestAttributeValue(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want interface{}
+	}{
+		{int32(-2), int64(-2)},
+		{int64(5), int64(5)},
+		{float32(1.5), 1.5},
+		{[]int32{1, 2}, []int64{1, 2}},
+		{[]float32{0.5}, []float64{0.5}},
+		{"double", "double"},
+		{[]string{"a", "b"}, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		if got := attributeValue(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("attributeValue(%#v) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestConvertToMatlab_StructGroup tests that a group with MATLAB_class
+// "struct" is converted to one struct variable with char and nested fields.
+func TestConvertToMatlab_StructGroup(t *testing.T) {
+	inner := &types.Variable{
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     []string{"level"},
+			Dimensions: []int{1, 1},
+			Elements: [][]*types.Variable{{
+				{Dimensions: []int{1, 1}, DataType: types.Int16, Data: []int16{-3}},
+			}},
+		},
+	}
+	tmpFile := writeTestFile(t, &types.Variable{
+		Name:       "cfg",
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     []string{"label", "inner"},
+			Dimensions: []int{1, 1},
+			Elements: [][]*types.Variable{{
+				{Dimensions: []int{1, 4}, DataType: types.Char, Data: "µ-V€"},
+				inner,
+			}},
+		},
+	})
+
+	file := openHDF5(t, tmpFile)
+	defer file.Close()
+
+	variables, err := NewHDF5Adapter(file).ConvertToMatlab()
+	if err != nil {
+		t.Fatalf("ConvertToMatlab() error = %v", err)
+	}
+	if len(variables) != 1 {
+		t.Fatalf("got %d variables, want 1", len(variables))
+	}
+
+	v := variables[0]
+	st, ok := v.Data.(*types.StructArray)
+	if v.Name != "cfg" || !ok {
+		t.Fatalf("got %s with %T, want cfg struct", v.Name, v.Data)
+	}
+	if label := st.Field(0, "label"); label == nil || label.Data != "µ-V€" {
+		t.Errorf("label = %#v", label)
+	}
+	nested, ok := st.Field(0, "inner").Data.(*types.StructArray)
+	if !ok {
+		t.Fatalf("inner Data = %T, want *types.StructArray", st.Field(0, "inner").Data)
+	}
+	if level := nested.Field(0, "level"); level == nil || !reflect.DeepEqual(level.Data, []int16{-3}) {
+		t.Errorf("inner.level = %#v", level)
 	}
 }
@@ -0,0 +1,141 @@
+package v73
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/scigolib/hdf5"
+	"github.com/scigolib/matlab/types"
+)
+
+// UserAttributes returns the entries of attrs that the writers persist,
+// sorted by name, with their values converted to the types they read back
+// as: string, int64, float64, or slices of those. Attributes the library
+// writes or derives itself, named MATLAB_* or HDF5_*, are left out, as are
+// values of other types, such as the time.Time of object header times.
+func UserAttributes(attrs map[string]interface{}) (names []string, values []interface{}) {
+	for name := range attrs {
+		if strings.HasPrefix(name, "MATLAB_") || strings.HasPrefix(name, "HDF5_") || name == "" {
+			continue
+		}
+		if _, ok := userAttributeValue(attrs[name]); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	values = make([]interface{}, len(names))
+	for i, name := range names {
+		values[i], _ = userAttributeValue(attrs[name])
+	}
+	return names, values
+}
+
+// userAttributeValue converts an attribute value to the type it reads
+// back as, reporting false for values that are not persisted.
+//
+//nolint:gocyclo,cyclop // One case per Go type
+func userAttributeValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		if v > math.MaxInt64 {
+			return nil, false
+		}
+		return int64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case []int64:
+		if len(v) == 0 {
+			return nil, false
+		}
+		return append([]int64(nil), v...), true
+	case []int:
+		if len(v) == 0 {
+			return nil, false
+		}
+		out := make([]int64, len(v))
+		for i, x := range v {
+			out[i] = int64(x)
+		}
+		return out, true
+	case []int32:
+		if len(v) == 0 {
+			return nil, false
+		}
+		out := make([]int64, len(v))
+		for i, x := range v {
+			out[i] = int64(x)
+		}
+		return out, true
+	case []float32:
+		if len(v) == 0 {
+			return nil, false
+		}
+		out := make([]float64, len(v))
+		for i, x := range v {
+			out[i] = float64(x)
+		}
+		return out, true
+	case []float64:
+		if len(v) == 0 {
+			return nil, false
+		}
+		return append([]float64(nil), v...), true
+	}
+	return nil, false
+}
+
+// writeUserAttributes writes the user attributes of v on the object at
+// path. A creation_time attribute is left to stampCreationTime when the
+// writer stamps variables.
+func (w *Writer) writeUserAttributes(path string, obj attributeWriter, v *types.Variable) error {
+	names, values := UserAttributes(v.Attributes)
+	for i, name := range names {
+		if name == AttrCreationTime && w.Now != nil && path == w.variablePath {
+			continue
+		}
+		if err := obj.WriteAttribute(name, values[i]); err != nil {
+			return fmt.Errorf("failed to write attribute %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// readUserAttributes adds the attributes of a group not named MATLAB_*
+// to v, as datasets report all of theirs.
+func readUserAttributes(group *hdf5.Group, v *types.Variable) {
+	attrs, err := group.Attributes()
+	if err != nil {
+		return
+	}
+	for _, attr := range attrs {
+		if strings.HasPrefix(attr.Name, "MATLAB_") {
+			continue
+		}
+		// Attributes whose value cannot be decoded are left out
+		if value, err := attr.ReadValue(); err == nil {
+			setAttribute(v, attr.Name, attributeValue(value))
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package v73
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestUserAttributes(t *testing.T) {
+	names, values := UserAttributes(map[string]interface{}{
+		"units":              "V",
+		"count":              uint16(3),
+		"big":                uint64(math.MaxUint64), // Does not fit int64
+		"scale":              float32(0.5),
+		"ids":                []int{1, 2},
+		"MATLAB_class":       "double",
+		AttrModificationTime: "derived",
+		"flag":               true,
+		"":                   "no name",
+	})
+	wantNames := []string{"count", "ids", "scale", "units"}
+	wantValues := []interface{}{int64(3), []int64{1, 2}, 0.5, "V"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("names = %v, want %v", names, wantNames)
+	}
+	if !reflect.DeepEqual(values, wantValues) {
+		t.Errorf("values = %#v, want %#v", values, wantValues)
+	}
+
+	if names, _ := UserAttributes(nil); len(names) != 0 {
+		t.Errorf("UserAttributes(nil) names = %v, want none", names)
+	}
+}
@@ -0,0 +1,175 @@
+package v73
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/hdf5"
+	"github.com/scigolib/matlab/types"
+)
+
+// writeExtendable writes an extendable double variable with the given
+// chunk length.
+func writeExtendable(t *testing.T, v *types.Variable, chunk int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "series.mat")
+	w, err := NewWriter(path)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if err := w.WriteExtendable(v, chunk); err != nil {
+		t.Fatalf("WriteExtendable() error = %v", err)
+	}
+	if err := w.WriteVariable(&types.Variable{Name: "unit", Dimensions: []int{1, 1}, DataType: types.Char, Data: "V"}); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return path
+}
+
+// readVariable reads a variable of the file at path.
+func readVariable(t *testing.T, path, name string) *types.Variable {
+	t.Helper()
+	file := openHDF5(t, path)
+	defer file.Close()
+	variables, err := NewHDF5Adapter(file).ConvertToMatlab()
+	if err != nil {
+		t.Fatalf("ConvertToMatlab() error = %v", err)
+	}
+	for _, v := range variables {
+		if v.Name == name {
+			return v
+		}
+	}
+	t.Fatalf("variable %q not found", name)
+	return nil
+}
+
+// checkChecksums checks the superblock checksum and end of file address
+// and the checksums of the object header chunks of the variable name.
+func checkChecksums(t *testing.T, path, name string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := binary.LittleEndian.Uint32(content[44:]), checksum(content[:44]); got != want {
+		t.Errorf("superblock checksum = %08x, want %08x", got, want)
+	}
+	if eof := binary.LittleEndian.Uint64(content[28:]); eof != uint64(len(content)) {
+		t.Errorf("end of file address = %d, file size %d", eof, len(content))
+	}
+
+	file := openHDF5(t, path)
+	defer file.Close()
+	h := headerReader{r: bytes.NewReader(content), offsetSize: 8, lengthSize: 8}
+	address := findObject(file, "/"+name).(*hdf5.Dataset).Address()
+	err = h.scan(address, nil, func(m headerMessage) error {
+		chunk := content[m.chunk.address : m.chunk.address+m.chunk.length]
+		if got, want := binary.LittleEndian.Uint32(content[m.chunk.address+m.chunk.length:]), checksum(chunk); got != want {
+			t.Errorf("object header chunk checksum = %08x, want %08x", got, want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scan() error = %v", err)
+	}
+}
+
+func sequence(from, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = float64(from + i)
+	}
+	return values
+}
+
+func TestExtend(t *testing.T) {
+	path := writeExtendable(t, &types.Variable{Name: "x", Dimensions: []int{1, 3}, DataType: types.Double, Data: sequence(0, 3)}, 4)
+
+	// Fills the last chunk, then spans enough chunks for a two-level index
+	for _, n := range []int{1, 300, 2} {
+		before := readVariable(t, path, "x").Data.([]float64)
+		if err := Extend(path, []Extension{{Name: "x", Data: sequence(len(before), n)}}); err != nil {
+			t.Fatalf("Extend(%d values) error = %v", n, err)
+		}
+		want := sequence(0, len(before)+n)
+		v := readVariable(t, path, "x")
+		if !reflect.DeepEqual(v.Dimensions, []int{1, len(want)}) || !reflect.DeepEqual(v.Data, want) {
+			t.Fatalf("after %d values: x = %v %v, want %d values", n, v.Dimensions, v.Data, len(want))
+		}
+		checkChecksums(t, path, "x")
+	}
+	if v := readVariable(t, path, "unit"); v.Data != "V" {
+		t.Errorf("unit = %v, want V", v.Data)
+	}
+}
+
+func TestExtend_DeepIndex(t *testing.T) {
+	path := writeExtendable(t, &types.Variable{Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{0}}, 1)
+
+	// One chunk per value: three index levels
+	n := chunkNodeEntries*chunkNodeEntries + 5
+	if err := Extend(path, []Extension{{Name: "x", Data: sequence(1, n)}}); err != nil {
+		t.Fatalf("Extend() error = %v", err)
+	}
+	if err := Extend(path, []Extension{{Name: "x", Data: sequence(n+1, 2)}}); err != nil {
+		t.Fatalf("Extend() error = %v", err)
+	}
+	if v := readVariable(t, path, "x"); !reflect.DeepEqual(v.Data, sequence(0, n+3)) {
+		t.Errorf("x has %d values, want 0..%d", len(v.Data.([]float64)), n+2)
+	}
+}
+
+func TestExtend_Matrix(t *testing.T) {
+	path := writeExtendable(t, &types.Variable{Name: "m", Dimensions: []int{2, 3}, DataType: types.Double, Data: sequence(0, 6)}, 2)
+
+	if err := Extend(path, []Extension{{Name: "m", Data: sequence(6, 3)}}); err == nil {
+		t.Error("Extend(partial column) error = nil")
+	}
+	if err := Extend(path, []Extension{{Name: "m", Data: sequence(6, 4)}}); err != nil {
+		t.Fatalf("Extend() error = %v", err)
+	}
+	v := readVariable(t, path, "m")
+	if !reflect.DeepEqual(v.Dimensions, []int{2, 5}) || !reflect.DeepEqual(v.Data, sequence(0, 10)) {
+		t.Errorf("m = %v %v, want 2x5 of 0..9", v.Dimensions, v.Data)
+	}
+}
+
+func TestExtend_NotExtendable(t *testing.T) {
+	path := writeExtendable(t, &types.Variable{Name: "x", Dimensions: []int{1, 3}, DataType: types.Double, Data: sequence(0, 3)}, 4)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		extensions []Extension
+	}{
+		{"contiguous", []Extension{{Name: "x", Data: []float64{3}}, {Name: "unit", Data: []float64{1}}}},
+		{"missing", []Extension{{Name: "x", Data: []float64{3}}, {Name: "missing", Data: []float64{1}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Extend(path, tt.extensions); !errors.Is(err, ErrNotExtendable) {
+				t.Errorf("Extend() error = %v, want ErrNotExtendable", err)
+			}
+			if after, _ := os.ReadFile(path); !bytes.Equal(after, content) {
+				t.Error("file changed by a failed Extend")
+			}
+		})
+	}
+
+	ints := writeExtendable(t, &types.Variable{Name: "n", Dimensions: []int{1, 2}, DataType: types.Int32, Data: []int32{1, 2}}, 4)
+	if err := Extend(ints, []Extension{{Name: "n", Data: []float64{3}}}); !errors.Is(err, ErrNotExtendable) {
+		t.Errorf("Extend(int32) error = %v, want ErrNotExtendable", err)
+	}
+}
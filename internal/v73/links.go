@@ -0,0 +1,554 @@
+package v73
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/scigolib/hdf5"
+	"github.com/scigolib/matlab/types"
+)
+
+// Attribute names under which the target of a soft or external link is
+// reported in Variable.Attributes. The values are strings.
+const (
+	AttrLinkTarget = "HDF5_link_target" // Object path the link points to
+	AttrLinkFile   = "HDF5_link_file"   // File of an external link
+)
+
+// Object header messages describing group members.
+const (
+	msgLinkInfo    = 0x0002
+	msgLink        = 0x0006
+	msgSymbolTable = 0x0011
+)
+
+// Link types of link messages.
+const (
+	linkHard     = 0
+	linkSoft     = 1
+	linkExternal = 64
+)
+
+// symbolCacheSoftLink is the cache type of symbol table entries holding a
+// soft link.
+const symbolCacheSoftLink = 2
+
+// Link is a soft or external link in an HDF5 file.
+type Link struct {
+	Path   string // Path of the link, such as "/latest"
+	Target string // Path of the object the link points to
+	File   string // File holding the target of an external link; "" for soft links
+}
+
+// readLinks returns the soft and external links of the file by path. The
+// HDF5 library skips links when it loads groups, so they are read from
+// the group structures directly: symbol tables (the format MATLAB
+// writes) and link messages of compact groups. Groups with dense link
+// storage are not searched.
+func readLinks(file *hdf5.File, limits Limits) (map[string]Link, error) {
+	sb := file.Superblock()
+	r := &linkReader{
+		h:       headerReader{r: file.Reader(), offsetSize: int(sb.OffsetSize), lengthSize: int(sb.LengthSize)},
+		limits:  limits.withDefaults(),
+		links:   make(map[string]Link),
+		visited: make(map[uint64]bool),
+	}
+	if err := r.object(sb.RootGroup, "", 0); err != nil {
+		return nil, err
+	}
+	return r.links, nil
+}
+
+// linkReader walks the group hierarchy collecting links.
+type linkReader struct {
+	h         headerReader
+	limits    Limits
+	links     map[string]Link
+	visited   map[uint64]bool   // Object headers already read
+	addresses map[string]uint64 // Object header addresses by path, if not nil
+	objects   int
+}
+
+// member is a group member read from a symbol table or link message.
+type member struct {
+	name    string
+	address uint64 // Object header of hard links
+	link    *Link  // Soft and external links
+}
+
+// object reads the object header at address and, for a group, its
+// members. The HDF5 library writes a soft or external link as an object
+// header holding only the link message, hard linked from the group; such
+// headers are recorded as links at path.
+func (r *linkReader) object(address uint64, path string, depth int) error {
+	if r.addresses != nil {
+		r.addresses[path] = address
+	}
+	if r.visited[address] {
+		return nil
+	}
+	r.visited[address] = true
+	r.objects++
+	if r.objects > r.limits.MaxObjects {
+		return fmt.Errorf("%w: %d objects at %q", ErrMaxObjectsExceeded, r.limits.MaxObjects, path)
+	}
+	if depth > r.limits.MaxDepth {
+		return fmt.Errorf("%w: %d levels at %q", ErrMaxDepthExceeded, r.limits.MaxDepth, path)
+	}
+
+	isGroup, members, err := r.h.groupMembers(address)
+	if err != nil {
+		return err
+	}
+	if !isGroup {
+		if len(members) == 1 && members[0].link != nil && path != "" {
+			link := *members[0].link
+			link.Path = path
+			r.links[path] = link
+		}
+		return nil
+	}
+
+	for _, m := range members {
+		memberPath := path + "/" + m.name
+		if m.link != nil {
+			link := *m.link
+			link.Path = memberPath
+			r.links[memberPath] = link
+			continue
+		}
+		if err := r.object(m.address, memberPath, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupMembers reads the object header at address and, for a group, its
+// members from its symbol table or link messages. For other objects
+// isGroup is false and members holds the link messages of the header.
+func (h headerReader) groupMembers(address uint64) (isGroup bool, members []member, err error) {
+	var symbolTable []byte
+	err = h.messages(address, nil, func(msgType uint16, data []byte) error {
+		switch msgType {
+		case msgSymbolTable:
+			symbolTable = data
+			isGroup = true
+		case msgLinkInfo:
+			isGroup = true
+		case msgLink:
+			m, err := h.parseLinkMessage(data)
+			if err != nil {
+				return err
+			}
+			members = append(members, m)
+		}
+		return nil
+	})
+	if err != nil || !isGroup || symbolTable == nil {
+		return isGroup, members, err
+	}
+	if len(symbolTable) < 2*h.offsetSize {
+		return true, nil, fmt.Errorf("truncated symbol table message")
+	}
+	entries, err := h.symbolTable(readUint(symbolTable, h.offsetSize), readUint(symbolTable[h.offsetSize:], h.offsetSize))
+	if err != nil {
+		return true, nil, err
+	}
+	return true, append(members, entries...), nil
+}
+
+// parseLinkMessage parses a link message.
+func (h headerReader) parseLinkMessage(data []byte) (member, error) {
+	errTruncated := fmt.Errorf("truncated link message")
+	if len(data) < 2 || data[0] != 1 {
+		return member{}, fmt.Errorf("unsupported link message")
+	}
+	flags := data[1]
+	pos := 2
+	linkType := byte(linkHard)
+	if flags&0x08 != 0 {
+		if pos >= len(data) {
+			return member{}, errTruncated
+		}
+		linkType = data[pos]
+		pos++
+	}
+	if flags&0x04 != 0 {
+		pos += 8 // creation order
+	}
+	if flags&0x10 != 0 {
+		pos++ // character set
+	}
+	sizeBytes := 1 << (flags & 0x03)
+	if pos+sizeBytes > len(data) {
+		return member{}, errTruncated
+	}
+	nameLen := readUint(data[pos:], sizeBytes)
+	pos += sizeBytes
+	if nameLen > uint64(len(data)-pos) {
+		return member{}, errTruncated
+	}
+	m := member{name: string(data[pos : pos+int(nameLen)])}
+	value := data[pos+int(nameLen):]
+
+	switch linkType {
+	case linkHard:
+		if len(value) < h.offsetSize {
+			return member{}, errTruncated
+		}
+		m.address = readUint(value, h.offsetSize)
+	case linkSoft:
+		target, _, err := lengthPrefixed(value)
+		if err != nil {
+			return member{}, err
+		}
+		m.link = &Link{Target: string(target)}
+	case linkExternal:
+		link, err := externalLink(value)
+		if err != nil {
+			return member{}, err
+		}
+		m.link = link
+	default:
+		return member{}, fmt.Errorf("unsupported link type %d", linkType)
+	}
+	return m, nil
+}
+
+// externalLink parses the value of an external link: a 2-byte length and
+// a flags byte followed by the NUL-terminated file name and object path.
+// The HDF5 library writes the file name and the path each with their own
+// 2-byte length instead; both forms are accepted.
+func externalLink(value []byte) (*Link, error) {
+	first, rest, err := lengthPrefixed(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		target, _, err := lengthPrefixed(rest)
+		if err != nil {
+			return nil, err
+		}
+		return &Link{File: string(first), Target: string(target)}, nil
+	}
+	if len(first) < 1 {
+		return nil, fmt.Errorf("truncated external link")
+	}
+	parts := bytes.SplitN(first[1:], []byte{0}, 3)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid external link")
+	}
+	return &Link{File: string(parts[0]), Target: string(parts[1])}, nil
+}
+
+// lengthPrefixed splits data after a 2-byte length and that many bytes.
+func lengthPrefixed(data []byte) (value, rest []byte, err error) {
+	if len(data) < 2 {
+		return nil, nil, fmt.Errorf("truncated link value")
+	}
+	n := int(binary.LittleEndian.Uint16(data))
+	if 2+n > len(data) {
+		return nil, nil, fmt.Errorf("truncated link value")
+	}
+	return data[2 : 2+n], data[2+n:], nil
+}
+
+// symbolTable returns the members of a group stored in a symbol table: a
+// version 1 B-tree of symbol table nodes, with names in a local heap.
+func (h headerReader) symbolTable(btree, heapAddress uint64) ([]member, error) {
+	heap, err := h.read(heapAddress, 8+2*h.lengthSize+h.offsetSize)
+	if err != nil {
+		return nil, err
+	}
+	if string(heap[0:4]) != "HEAP" {
+		return nil, fmt.Errorf("invalid local heap at 0x%X", heapAddress)
+	}
+	size := readUint(heap[8:], h.lengthSize)
+	if size > maxHeaderBlockSize {
+		return nil, fmt.Errorf("local heap too large: %d bytes", size)
+	}
+	names, err := h.read(readUint(heap[8+2*h.lengthSize:], h.offsetSize), int(size))
+	if err != nil {
+		return nil, err
+	}
+
+	var members []member
+	nodes := 0
+	err = h.groupNodes(btree, 0, &nodes, func(node uint64) error {
+		entries, err := h.symbolNode(node, names)
+		members = append(members, entries...)
+		return err
+	})
+	return members, err
+}
+
+// groupNodes calls visit with the symbol table nodes of the group B-tree
+// node at address and its descendants.
+func (h headerReader) groupNodes(address uint64, depth int, nodes *int, visit func(uint64) error) error {
+	*nodes++
+	if depth > maxBTreeDepth || *nodes > maxBTreeNodes {
+		return fmt.Errorf("group index too large or cyclic")
+	}
+	head, err := h.read(address, 8+2*h.offsetSize)
+	if err != nil {
+		return err
+	}
+	if string(head[0:4]) != "TREE" || head[4] != 0 {
+		return fmt.Errorf("invalid group index node at 0x%X", address)
+	}
+	level := head[5]
+	entries := int(binary.LittleEndian.Uint16(head[6:]))
+
+	// Keys (heap offsets) alternate with child addresses
+	body, err := h.read(address+uint64(len(head)), entries*(h.lengthSize+h.offsetSize)+h.lengthSize)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < entries; i++ {
+		child := readUint(body[i*(h.lengthSize+h.offsetSize)+h.lengthSize:], h.offsetSize)
+		if level == 0 {
+			err = visit(child)
+		} else {
+			err = h.groupNodes(child, depth+1, nodes, visit)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// symbolNode returns the entries of the symbol table node at address.
+func (h headerReader) symbolNode(address uint64, names []byte) ([]member, error) {
+	head, err := h.read(address, 8)
+	if err != nil {
+		return nil, err
+	}
+	if string(head[0:4]) != "SNOD" {
+		return nil, fmt.Errorf("invalid symbol table node at 0x%X", address)
+	}
+	count := int(binary.LittleEndian.Uint16(head[6:]))
+	entrySize := 2*h.offsetSize + 24
+	data, err := h.read(address+8, count*entrySize)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]member, 0, count)
+	for i := 0; i < count; i++ {
+		entry := data[i*entrySize:]
+		name, ok := heapString(names, readUint(entry, h.offsetSize))
+		if !ok {
+			return nil, fmt.Errorf("invalid symbol name offset in node at 0x%X", address)
+		}
+		m := member{name: name, address: readUint(entry[h.offsetSize:], h.offsetSize)}
+		scratch := entry[2*h.offsetSize+8:]
+		if binary.LittleEndian.Uint32(entry[2*h.offsetSize:]) == symbolCacheSoftLink {
+			target, ok := heapString(names, uint64(binary.LittleEndian.Uint32(scratch)))
+			if !ok {
+				return nil, fmt.Errorf("invalid soft link offset in node at 0x%X", address)
+			}
+			m.link = &Link{Target: target}
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// heapString returns the NUL-terminated string at offset in a local heap.
+func heapString(heap []byte, offset uint64) (string, bool) {
+	if offset >= uint64(len(heap)) {
+		return "", false
+	}
+	s := heap[offset:]
+	if end := bytes.IndexByte(s, 0); end >= 0 {
+		s = s[:end]
+	}
+	return string(s), true
+}
+
+// resolvePath returns the absolute form of a link target, which may be
+// relative to the group holding the link.
+func resolvePath(link Link) string {
+	if strings.HasPrefix(link.Target, "/") {
+		return path.Clean(link.Target)
+	}
+	return path.Join(path.Dir(link.Path), link.Target)
+}
+
+// maxLinkHops bounds the soft links followed to resolve one link.
+const maxLinkHops = 16
+
+// convertLinks converts the links of the group at path, in name order.
+// Variables are named as convertDataset names them.
+func (a *HDF5Adapter) convertLinks(group string, depth int) ([]*types.Variable, error) {
+	var paths []string
+	for p := range a.links {
+		if path.Dir(p) == group || (group == "" && path.Dir(p) == "/") {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	variables := make([]*types.Variable, 0, len(paths))
+	for _, p := range paths {
+		if err := a.visit(p); err != nil {
+			return nil, err
+		}
+		name := p
+		if group == "" {
+			name = p[1:]
+		}
+		variable, err := a.convertLink(a.links[p], name, depth)
+		if err != nil {
+			return nil, err
+		}
+		if variable != nil {
+			variables = append(variables, variable)
+		}
+	}
+	return variables, nil
+}
+
+// convertLink converts a link to the variable name. Soft links are
+// resolved if enabled and their target is a dataset or a group converting
+// to one variable.
+func (a *HDF5Adapter) convertLink(link Link, name string, depth int) (*types.Variable, error) {
+	target := resolvePath(link)
+	if link.File == "" && a.ResolveSoftLinks {
+		var variable *types.Variable
+		switch obj := a.lookup(link).(type) {
+		case *hdf5.Dataset:
+			var err error
+			if variable, err = a.convertDataset(obj, ""); err != nil {
+				return nil, err
+			}
+		case *hdf5.Group:
+			if address, ok := a.address(target); ok && a.ancestry[address] {
+				break // A link to an enclosing group is kept as a link
+			}
+			saved := a.Transform
+			a.Transform = nil // Applied to the link's variable instead
+			var converted []*types.Variable
+			err := a.traverseGroup(obj, target, depth+1, &converted)
+			a.Transform = saved
+			if err != nil {
+				return nil, err
+			}
+			if len(converted) == 1 {
+				variable = converted[0]
+			}
+		}
+		if variable != nil {
+			variable.Name = name
+			setAttribute(variable, AttrLinkTarget, target)
+			return variable, nil
+		}
+	}
+
+	text := target
+	attrs := map[string]interface{}{AttrLinkTarget: target}
+	if link.File != "" {
+		text = link.File + ":" + link.Target
+		attrs[AttrLinkTarget] = link.Target
+		attrs[AttrLinkFile] = link.File
+	}
+	return &types.Variable{
+		Name:       name,
+		Dimensions: []int{1, utf8.RuneCountInString(text)},
+		DataType:   types.Char,
+		Data:       text,
+		Attributes: attrs,
+	}, nil
+}
+
+// lookup returns the object a soft link points to, following further soft
+// links, or nil if it does not exist in the file.
+func (a *HDF5Adapter) lookup(link Link) hdf5.Object {
+	for hops := 0; hops < maxLinkHops; hops++ {
+		target := resolvePath(link)
+		if next, ok := a.links[target]; ok {
+			if next.File != "" {
+				return nil
+			}
+			link = next
+			continue
+		}
+		return findObject(a.file, target)
+	}
+	return nil
+}
+
+// setAttribute sets an attribute of v, allocating the map if needed.
+func setAttribute(v *types.Variable, name string, value interface{}) {
+	if v.Attributes == nil {
+		v.Attributes = make(map[string]interface{})
+	}
+	v.Attributes[name] = value
+}
+
+// findObject returns the object at an absolute path, without following
+// links, or nil if there is none.
+func findObject(file *hdf5.File, path string) hdf5.Object {
+	var obj hdf5.Object = file.Root()
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		group, ok := obj.(*hdf5.Group)
+		if !ok {
+			return nil
+		}
+		obj = nil
+		for _, child := range group.Children() {
+			if child.Name() == name {
+				obj = child
+				break
+			}
+		}
+		if obj == nil {
+			return nil
+		}
+	}
+	return obj
+}
+
+// address returns the object header address of the object at path,
+// reached through hard links from the root group, or false if the group
+// structures cannot be read. The HDF5 library loads each path as a
+// separate object, so addresses identify objects linked more than once.
+func (a *HDF5Adapter) address(p string) (uint64, bool) {
+	if p == "/" {
+		p = ""
+	}
+	if address, ok := a.addresses[p]; ok {
+		return address, true
+	}
+	if p == "" {
+		return 0, false
+	}
+	dir := path.Dir(p)
+	parent, ok := a.address(dir)
+	if !ok {
+		return 0, false
+	}
+	sb := a.file.Superblock()
+	h := headerReader{r: a.file.Reader(), offsetSize: int(sb.OffsetSize), lengthSize: int(sb.LengthSize)}
+	isGroup, members, err := h.groupMembers(parent)
+	if err != nil || !isGroup {
+		return 0, false
+	}
+	if dir == "/" {
+		dir = ""
+	}
+	for _, m := range members {
+		if m.link == nil {
+			a.addresses[dir+"/"+m.name] = m.address
+		}
+	}
+	address, ok := a.addresses[p]
+	return address, ok
+}
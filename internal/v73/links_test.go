@@ -0,0 +1,162 @@
+package v73
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// writeLinkedFile writes a file with a dataset x, a struct s and soft and
+// external links to them.
+func writeLinkedFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "links.mat")
+	w, err := NewWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	variables := []*types.Variable{
+		{Name: "x", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}},
+		{Name: "s", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+			Fields:     []string{"a"},
+			Dimensions: []int{1, 1},
+			Elements: [][]*types.Variable{{
+				{Name: "a", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{3}},
+			}},
+		}},
+	}
+	for _, v := range variables {
+		if err := w.WriteVariable(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, link := range [][2]string{{"/y", "/x"}, {"/dangling", "/missing"}, {"/s/b", "/x"}, {"/s/self", "/s"}, {"/t", "/s"}} {
+		if err := w.file.CreateSoftLink(link[0], link[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.file.CreateExternalLink("/ext", "other.mat", "/z"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// parseLinked parses the file at path.
+func parseLinked(t *testing.T, path string, resolve bool) map[string]*types.Variable {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	parser := NewParser()
+	parser.ResolveSoftLinks = resolve
+	variables, err := parser.Parse(f)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	byName := make(map[string]*types.Variable)
+	for _, v := range variables {
+		byName[v.Name] = v
+	}
+	return byName
+}
+
+func TestParser_Links(t *testing.T) {
+	path := writeLinkedFile(t)
+
+	vars := parseLinked(t, path, true)
+	if y := vars["y"]; y == nil || !reflect.DeepEqual(y.Data, []float64{1, 2}) || y.Attributes[AttrLinkTarget] != "/x" {
+		t.Errorf("y = %+v, want a copy of x linked to /x", y)
+	}
+	if tv := vars["t"]; tv == nil || tv.DataType != types.Struct {
+		t.Errorf("t = %+v, want a copy of struct s", tv)
+	}
+	s := vars["s"].Data.(*types.StructArray)
+	if b := s.Field(0, "b"); b == nil || !reflect.DeepEqual(b.Data, []float64{1, 2}) {
+		t.Errorf("s.b = %+v, want a copy of x", b)
+	}
+	// A link to an enclosing group cannot be resolved into a value
+	if self := s.Field(0, "self"); self == nil || self.Data != "/s" {
+		t.Errorf("s.self = %+v, want link to /s", self)
+	}
+	if d := vars["dangling"]; d == nil || d.DataType != types.Char || d.Data != "/missing" || d.Attributes[AttrLinkTarget] != "/missing" {
+		t.Errorf("dangling = %+v, want unresolved link", d)
+	}
+	ext := vars["ext"]
+	if ext == nil || ext.Data != "other.mat:/z" || ext.Attributes[AttrLinkFile] != "other.mat" || ext.Attributes[AttrLinkTarget] != "/z" {
+		t.Errorf("ext = %+v, want external link to other.mat:/z", ext)
+	}
+
+	vars = parseLinked(t, path, false)
+	if y := vars["y"]; y == nil || y.Data != "/x" || y.Attributes[AttrLinkTarget] != "/x" {
+		t.Errorf("unresolved y = %+v, want link to /x", y)
+	}
+	if x := vars["x"]; x == nil || !reflect.DeepEqual(x.Data, []float64{1, 2}) {
+		t.Errorf("x = %+v", x)
+	}
+}
+
+// TestParseLinkMessage tests the link message encodings of the HDF5
+// format, which the HDF5 library used by the tests does not write.
+func TestParseLinkMessage(t *testing.T) {
+	h := headerReader{offsetSize: 8, lengthSize: 8}
+	message := func(linkType byte, name string, value []byte) []byte {
+		data := []byte{1, 0x08, linkType, byte(len(name))}
+		return append(append(data, name...), value...)
+	}
+	lengthPrefix := func(b []byte) []byte {
+		return append(binary.LittleEndian.AppendUint16(nil, uint16(len(b))), b...)
+	}
+
+	hard := make([]byte, 8)
+	binary.LittleEndian.PutUint64(hard, 0x1234)
+	tests := []struct {
+		name string
+		data []byte
+		want member
+	}{
+		{"hard", message(linkHard, "x", hard), member{name: "x", address: 0x1234}},
+		{"soft", message(linkSoft, "y", lengthPrefix([]byte("/g/x"))), member{name: "y", link: &Link{Target: "/g/x"}}},
+		{"external", message(linkExternal, "e", lengthPrefix([]byte("\x00data.h5\x00/run/1\x00"))),
+			member{name: "e", link: &Link{File: "data.h5", Target: "/run/1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.parseLinkMessage(tt.data)
+			if err != nil {
+				t.Fatalf("parseLinkMessage() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLinkMessage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := h.parseLinkMessage(message(linkSoft, "y", []byte{9, 0, '/'})); err == nil {
+		t.Error("parseLinkMessage() accepted a truncated soft link")
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	tests := []struct {
+		link Link
+		want string
+	}{
+		{Link{Path: "/g/y", Target: "/x"}, "/x"},
+		{Link{Path: "/g/y", Target: "x"}, "/g/x"},
+		{Link{Path: "/g/y", Target: "../x"}, "/x"},
+	}
+	for _, tt := range tests {
+		if got := resolvePath(tt.link); got != tt.want {
+			t.Errorf("resolvePath(%+v) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
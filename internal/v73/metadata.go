@@ -0,0 +1,194 @@
+package v73
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// VariablesFromTree describes the MATLAB variables stored in an HDF5
+// hierarchy, following the same group conventions as ConvertToMatlab:
+// complex groups and struct groups are single variables, other groups
+// contribute their datasets as "/group/name" variables.
+//
+// Dimensions are reported in MATLAB order, the reverse of the dataspace
+// (see HDF5Tree for the stored order). Offset gives the
+// data address of contiguous datasets and is -1 otherwise. Size is the
+// number of data bytes stored in the file, which for compressed chunked
+// datasets is the sum of the compressed chunks; where that is unknown,
+// Size is the uncompressed data size.
+func VariablesFromTree(root *TreeNode) []*types.VariableInfo {
+	var infos []*types.VariableInfo
+	collectVariables(root, &infos)
+	return infos
+}
+
+// collectVariables appends the variables found below a group node.
+func collectVariables(group *TreeNode, infos *[]*types.VariableInfo) {
+	for _, child := range group.Children {
+		name := child.Path
+		if group.Path == "/" {
+			name = strings.TrimPrefix(name, "/")
+		}
+
+		if group.Path == "/" && strings.HasPrefix(name, "#") {
+			continue // MATLAB's own groups, such as #refs#
+		}
+		if child.Kind == KindDataset {
+			*infos = append(*infos, datasetInfo(child, name))
+			continue
+		}
+
+		switch {
+		case child.attribute(types.AttrMatlabComplex) != nil:
+			*infos = append(*infos, complexInfo(child, name))
+		case nodeClass(child) == matlabClassStruct, nodeObjectDecode(child) == types.ObjectDecodeStruct:
+			*infos = append(*infos, &types.VariableInfo{
+				Name:             name,
+				Dimensions:       []int{1, 1},
+				DataType:         types.Struct,
+				Compressed:       subtreeCompressed(child),
+				Offset:           -1,
+				Size:             subtreeStored(child),
+				UncompressedSize: int64(subtreeBytes(child)),
+			})
+		default:
+			collectVariables(child, infos)
+		}
+	}
+}
+
+// datasetInfo describes a dataset variable.
+func datasetInfo(node *TreeNode, name string) *types.VariableInfo {
+	info := &types.VariableInfo{
+		Name:             name,
+		Dimensions:       nodeDims(node),
+		DataType:         classToDataType(nodeClass(node)),
+		Compressed:       node.Compressed(),
+		Offset:           -1,
+		Size:             subtreeStored(node),
+		UncompressedSize: int64(node.Bytes()),
+	}
+	if m := contiguousLayoutPattern.FindStringSubmatch(node.Layout); m != nil {
+		address, errAddr := strconv.ParseInt(m[1], 16, 64)
+		size, errSize := strconv.ParseInt(m[2], 10, 64)
+		if errAddr == nil && errSize == nil {
+			info.Offset = address
+			info.Size = size
+		}
+	}
+	return info
+}
+
+// complexInfo describes a complex variable stored as a group holding
+// "real" and "imag" datasets.
+func complexInfo(node *TreeNode, name string) *types.VariableInfo {
+	info := &types.VariableInfo{
+		Name:             name,
+		DataType:         classToDataType(nodeClass(node)),
+		IsComplex:        true,
+		Compressed:       subtreeCompressed(node),
+		Offset:           -1,
+		Size:             subtreeStored(node),
+		UncompressedSize: int64(subtreeBytes(node)),
+	}
+	for _, child := range node.Children {
+		if child.Kind == KindDataset && strings.HasSuffix(child.Path, "/real") {
+			info.Dimensions = nodeDims(child)
+			if node.attribute(types.AttrMatlabClass) == nil {
+				info.DataType = classToDataType(nodeClass(child))
+			}
+		}
+	}
+	return info
+}
+
+// attribute returns the named attribute of the node, or nil.
+func (n *TreeNode) attribute(name string) *TreeAttribute {
+	for _, attr := range n.Attributes {
+		if attr.Name == name {
+			return attr
+		}
+	}
+	return nil
+}
+
+// nodeClass returns the MATLAB_class attribute of the node, defaulting to
+// double like ConvertToMatlab.
+func nodeClass(n *TreeNode) string {
+	if attr := n.attribute(types.AttrMatlabClass); attr != nil {
+		if class, ok := attr.Value.(string); ok {
+			return class
+		}
+	}
+	return matlabClassDouble
+}
+
+// nodeObjectDecode returns the MATLAB_object_decode attribute of the
+// node, or 0 if it has none.
+func nodeObjectDecode(n *TreeNode) int32 {
+	if attr := n.attribute(types.AttrMatlabObjectDecode); attr != nil {
+		if decode, ok := attr.Value.(int32); ok {
+			return decode
+		}
+	}
+	return 0
+}
+
+// nodeDims converts dataspace dimensions to MATLAB dimensions, which are
+// stored in reverse order. Scalars are 1x1.
+func nodeDims(n *TreeNode) []int {
+	if len(n.Dims) == 0 {
+		return []int{1, 1}
+	}
+	dims := make([]int, len(n.Dims))
+	for i, d := range n.Dims {
+		dims[i] = int(d)
+	}
+	slices.Reverse(dims)
+	return dims
+}
+
+// subtreeBytes sums the data sizes of all datasets below n.
+func subtreeBytes(n *TreeNode) uint64 {
+	total := n.Bytes()
+	for _, child := range n.Children {
+		total += subtreeBytes(child)
+	}
+	return total
+}
+
+// subtreeStored sums the stored data sizes of all datasets below n,
+// counting datasets of unknown stored size as uncompressed.
+func subtreeStored(n *TreeNode) int64 {
+	total := n.StoredBytes
+	if n.Kind != KindDataset {
+		total = 0
+	} else if total < 0 {
+		total = int64(n.Bytes())
+	}
+	for _, child := range n.Children {
+		total += subtreeStored(child)
+	}
+	return total
+}
+
+// subtreeCompressed reports whether any dataset below n is compressed.
+func subtreeCompressed(n *TreeNode) bool {
+	if n.Compressed() {
+		return true
+	}
+	for _, child := range n.Children {
+		if subtreeCompressed(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// classToDataType maps a MATLAB class name to a DataType.
+func classToDataType(class string) types.DataType {
+	return (&HDF5Adapter{}).matlabClassToDataType(class)
+}
@@ -0,0 +1,46 @@
+package v73
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestVariablesFromTree(t *testing.T) {
+	classAttr := func(class string) []*TreeAttribute {
+		return []*TreeAttribute{{Name: "MATLAB_class", Value: class}}
+	}
+	root := &TreeNode{Path: "/", Kind: KindGroup, Children: []*TreeNode{
+		{
+			Path: "/s", Kind: KindGroup, Attributes: classAttr("struct"),
+			Children: []*TreeNode{
+				{Path: "/s/a", Kind: KindDataset, ElementSize: 8, Dims: []uint64{4}, StoredBytes: -1},
+			},
+		},
+		{
+			Path: "/g", Kind: KindGroup,
+			Children: []*TreeNode{
+				{Path: "/g/x", Kind: KindDataset, ElementSize: 1, Dims: []uint64{5, 1}, StoredBytes: 5,
+					Attributes: classAttr("uint8"), Layout: "contiguous (address=0x800, size=5)"},
+			},
+		},
+		{Path: "/n", Kind: KindDataset, ElementSize: 8, Layout: "compact (size=8)", StoredBytes: -1},
+	}}
+
+	want := []*types.VariableInfo{
+		{Name: "s", Dimensions: []int{1, 1}, DataType: types.Struct, Offset: -1, Size: 32, UncompressedSize: 32},
+		{Name: "/g/x", Dimensions: []int{1, 5}, DataType: types.Uint8, Offset: 0x800, Size: 5, UncompressedSize: 5},
+		{Name: "n", Dimensions: []int{1, 1}, DataType: types.Double, Offset: -1, Size: 8, UncompressedSize: 8},
+	}
+
+	got := VariablesFromTree(root)
+	if len(got) != len(want) {
+		t.Fatalf("got %d variables, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("variable %d = %+v, want %+v", i, *got[i], *want[i])
+		}
+	}
+}
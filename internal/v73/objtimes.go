@@ -0,0 +1,322 @@
+package v73
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Attribute names under which object header times are reported in
+// Variable.Attributes. The values are time.Time in UTC.
+const (
+	AttrAccessTime       = "HDF5_access_time"
+	AttrModificationTime = "HDF5_modification_time"
+	AttrChangeTime       = "HDF5_change_time"
+	AttrBirthTime        = "HDF5_birth_time"
+)
+
+// AttrCreationTime is the attribute the writer stamps on each variable
+// when Writer.Now is set. The value is an RFC 3339 timestamp in UTC.
+const AttrCreationTime = "creation_time"
+
+// Object header message types that carry times.
+const (
+	msgModificationTimeOld = 0x000E
+	msgContinuation        = 0x0010
+	msgModificationTime    = 0x0012
+)
+
+// Bounds on object header parsing, so a corrupted file cannot loop
+// forever or force huge allocations.
+const (
+	maxHeaderBlocks    = 64
+	maxHeaderBlockSize = 1 << 24
+)
+
+// errNoTimes indicates an object header without any time information.
+var errNoTimes = errors.New("object header has no times")
+
+// ObjectTimes holds the times recorded in an HDF5 object header.
+// Fields are zero when the header does not record them. Version 1 headers
+// only record the modification time.
+type ObjectTimes struct {
+	Access       time.Time
+	Modification time.Time
+	Change       time.Time
+	Birth        time.Time
+}
+
+// attributes returns the non-zero times keyed by their attribute names.
+func (t ObjectTimes) attributes() map[string]interface{} {
+	attrs := make(map[string]interface{})
+	for name, value := range map[string]time.Time{
+		AttrAccessTime:       t.Access,
+		AttrModificationTime: t.Modification,
+		AttrChangeTime:       t.Change,
+		AttrBirthTime:        t.Birth,
+	} {
+		if !value.IsZero() {
+			attrs[name] = value
+		}
+	}
+	return attrs
+}
+
+// headerReader reads object header fields with the file's address sizes.
+type headerReader struct {
+	r          io.ReaderAt
+	offsetSize int
+	lengthSize int
+}
+
+// readObjectTimes reads the times recorded in the object header at
+// address. HDF5 only records times when the writer enables time tracking
+// (the HDF5 library and MATLAB do by default). Returns errNoTimes if the
+// header records none.
+func readObjectTimes(r io.ReaderAt, address uint64, offsetSize, lengthSize int) (ObjectTimes, error) {
+	h := headerReader{r: r, offsetSize: offsetSize, lengthSize: lengthSize}
+
+	var times ObjectTimes
+	err := h.messages(address, &times, func(msgType uint16, data []byte) error {
+		recordTime(msgType, data, &times)
+		return nil
+	})
+	if err != nil {
+		return ObjectTimes{}, err
+	}
+	if times == (ObjectTimes{}) {
+		return ObjectTimes{}, errNoTimes
+	}
+	return times, nil
+}
+
+// recordTime records the modification time carried by a message, unless
+// one is known already.
+func recordTime(msgType uint16, data []byte, times *ObjectTimes) {
+	switch msgType {
+	case msgModificationTime:
+		// Version (1), reserved (3), seconds since the epoch (4)
+		if len(data) >= 8 && times.Modification.IsZero() {
+			times.Modification = unixTime(binary.LittleEndian.Uint32(data[4:]))
+		}
+	case msgModificationTimeOld:
+		// ASCII "YYYYMMDDhhmmss" in UTC
+		if len(data) >= 14 && times.Modification.IsZero() {
+			if t, err := time.Parse("20060102150405", string(data[:14])); err == nil {
+				times.Modification = t
+			}
+		}
+	}
+}
+
+// headerMessage is an object header message with its place in the file,
+// for callers that patch messages in place.
+type headerMessage struct {
+	msgType uint16
+	data    []byte
+	address uint64 // File address of data
+	chunk   block  // Version 2 chunk holding the message, checksum excluded
+	summed  bool   // The chunk is followed by its checksum (version 2)
+}
+
+// messages calls visit with every message of the object header at
+// address, following continuation blocks. Version 2 headers may record
+// times in their prefix; these are stored in times if it is not nil.
+func (h headerReader) messages(address uint64, times *ObjectTimes, visit func(msgType uint16, data []byte) error) error {
+	return h.scan(address, times, func(m headerMessage) error {
+		return visit(m.msgType, m.data)
+	})
+}
+
+// scan is messages with the place of each message in the file.
+func (h headerReader) scan(address uint64, times *ObjectTimes, visit func(headerMessage) error) error {
+	prefix, err := h.read(address, 16)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case string(prefix[0:4]) == "OHDR":
+		return h.messagesV2(address, times, visit)
+	case prefix[0] == 1 && prefix[1] == 0:
+		return h.messagesV1(address, prefix, visit)
+	default:
+		return fmt.Errorf("invalid object header signature at 0x%X", address)
+	}
+}
+
+// read reads n bytes at the given address.
+func (h headerReader) read(address uint64, n int) ([]byte, error) {
+	if address > 1<<62 {
+		return nil, fmt.Errorf("object header address 0x%X out of range", address)
+	}
+	buf := make([]byte, n)
+	//nolint:gosec // G115: address checked above
+	if _, err := h.r.ReadAt(buf, int64(address)); err != nil {
+		return nil, fmt.Errorf("failed to read object header at 0x%X: %w", address, err)
+	}
+	return buf, nil
+}
+
+// messagesV1 scans a version 1 object header and its continuation blocks.
+func (h headerReader) messagesV1(address uint64, prefix []byte, visit func(headerMessage) error) error {
+	// Messages start after the 16-byte prefix (12 bytes + alignment).
+	blocks := []block{{address + 16, uint64(binary.LittleEndian.Uint32(prefix[8:12]))}}
+
+	for i := 0; i < len(blocks); i++ {
+		data, err := h.readBlock(blocks, i)
+		if err != nil {
+			return err
+		}
+
+		for pos := 0; pos+8 <= len(data); {
+			msgType := binary.LittleEndian.Uint16(data[pos:])
+			size := int(binary.LittleEndian.Uint16(data[pos+2:]))
+			pos += 8
+			if pos+size > len(data) {
+				return fmt.Errorf("object header message overruns block")
+			}
+			next, err := h.handleMessage(headerMessage{
+				msgType: msgType,
+				data:    data[pos : pos+size],
+				address: blocks[i].address + uint64(pos),
+			}, visit)
+			if err != nil {
+				return err
+			}
+			if next != nil {
+				blocks = append(blocks, *next)
+			}
+			pos += size
+		}
+	}
+	return nil
+}
+
+// messagesV2 reads the header times of a version 2 object header and
+// scans its chunks.
+func (h headerReader) messagesV2(address uint64, times *ObjectTimes, visit func(headerMessage) error) error {
+	// Signature (4), version (1), flags (1), optional times (16),
+	// optional attribute phase change values (4), chunk size (1-8).
+	head, err := h.read(address, 4+1+1+16+4+8)
+	if err != nil {
+		return err
+	}
+	flags := head[5]
+	pos := 6
+
+	if flags&0x20 != 0 {
+		if times != nil {
+			times.Access = unixTime(binary.LittleEndian.Uint32(head[pos:]))
+			times.Modification = unixTime(binary.LittleEndian.Uint32(head[pos+4:]))
+			times.Change = unixTime(binary.LittleEndian.Uint32(head[pos+8:]))
+			times.Birth = unixTime(binary.LittleEndian.Uint32(head[pos+12:]))
+		}
+		pos += 16
+	}
+	if flags&0x10 != 0 {
+		pos += 4
+	}
+	sizeBytes := 1 << (flags & 0x03)
+	chunkSize := readUint(head[pos:], sizeBytes)
+	pos += sizeBytes
+
+	blocks := []block{{address + uint64(pos), chunkSize}}
+	for i := 0; i < len(blocks); i++ {
+		data, err := h.readBlock(blocks, i)
+		if err != nil {
+			return err
+		}
+		// The first chunk is checksummed from the signature on
+		chunk := block{address, uint64(pos) + chunkSize}
+		start := blocks[i].address
+		if i > 0 {
+			// Continuation chunks: "OCHK" signature ... checksum (4)
+			if len(data) < 8 || string(data[0:4]) != "OCHK" {
+				return fmt.Errorf("invalid object header continuation chunk")
+			}
+			data = data[4 : len(data)-4]
+			chunk = block{blocks[i].address, blocks[i].length - 4}
+			start += 4
+		}
+
+		msgHeader := 4
+		if flags&0x04 != 0 {
+			msgHeader += 2 // creation order
+		}
+		for pos := 0; pos+msgHeader <= len(data); {
+			msgType := uint16(data[pos])
+			size := int(binary.LittleEndian.Uint16(data[pos+1:]))
+			pos += msgHeader
+			if pos+size > len(data) {
+				return fmt.Errorf("object header message overruns chunk")
+			}
+			next, err := h.handleMessage(headerMessage{
+				msgType: msgType,
+				data:    data[pos : pos+size],
+				address: start + uint64(pos),
+				chunk:   chunk,
+				summed:  true,
+			}, visit)
+			if err != nil {
+				return err
+			}
+			if next != nil {
+				blocks = append(blocks, *next)
+			}
+			pos += size
+		}
+	}
+	return nil
+}
+
+// readBlock reads block i of an object header, enforcing the block limits.
+func (h headerReader) readBlock(blocks []block, i int) ([]byte, error) {
+	if i >= maxHeaderBlocks {
+		return nil, fmt.Errorf("too many object header continuation blocks")
+	}
+	if blocks[i].length > maxHeaderBlockSize {
+		return nil, fmt.Errorf("object header block too large: %d bytes", blocks[i].length)
+	}
+	return h.read(blocks[i].address, int(blocks[i].length))
+}
+
+// block is a contiguous run of object header messages.
+type block struct {
+	address uint64
+	length  uint64
+}
+
+// handleMessage passes a message to visit and returns the block
+// referenced by a continuation message.
+func (h headerReader) handleMessage(m headerMessage, visit func(headerMessage) error) (*block, error) {
+	if m.msgType == msgContinuation {
+		if len(m.data) < h.offsetSize+h.lengthSize {
+			return nil, fmt.Errorf("truncated continuation message")
+		}
+		return &block{
+			address: readUint(m.data, h.offsetSize),
+			length:  readUint(m.data[h.offsetSize:], h.lengthSize),
+		}, nil
+	}
+	return nil, visit(m)
+}
+
+// readUint reads a little-endian unsigned integer of 1 to 8 bytes.
+func readUint(data []byte, size int) uint64 {
+	var value uint64
+	for i := size - 1; i >= 0; i-- {
+		value = value<<8 | uint64(data[i])
+	}
+	return value
+}
+
+// unixTime converts HDF5 seconds since the epoch to UTC time.
+func unixTime(seconds uint32) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(seconds), 0).UTC()
+}
@@ -0,0 +1,161 @@
+package v73
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// v1Message encodes a version 1 object header message.
+func v1Message(msgType uint16, data []byte) []byte {
+	for len(data)%8 != 0 {
+		data = append(data, 0)
+	}
+	msg := make([]byte, 8, 8+len(data))
+	binary.LittleEndian.PutUint16(msg[0:], msgType)
+	binary.LittleEndian.PutUint16(msg[2:], uint16(len(data)))
+	return append(msg, data...)
+}
+
+// v2Message encodes a version 2 object header message without creation order.
+func v2Message(msgType byte, data []byte) []byte {
+	msg := []byte{msgType, 0, 0, 0}
+	binary.LittleEndian.PutUint16(msg[1:], uint16(len(data)))
+	return append(msg, data...)
+}
+
+// mtimeMessage encodes a modification time message body.
+func mtimeMessage(seconds uint32) []byte {
+	data := []byte{1, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(data[4:], seconds)
+	return data
+}
+
+// continuationMessage encodes a continuation message body with 8-byte
+// offsets and lengths.
+func continuationMessage(address, length uint64) []byte {
+	data := make([]byte, 16)
+	binary.LittleEndian.PutUint64(data[0:], address)
+	binary.LittleEndian.PutUint64(data[8:], length)
+	return data
+}
+
+// v1Header builds a version 1 object header at offset 0 followed by an
+// optional continuation block.
+func v1Header(messages, continuation []byte) []byte {
+	prefix := make([]byte, 16)
+	prefix[0] = 1
+	binary.LittleEndian.PutUint32(prefix[8:], uint32(len(messages)))
+	return append(append(prefix, messages...), continuation...)
+}
+
+func TestReadObjectTimes(t *testing.T) {
+	const mtime = 1700000000
+	want := time.Unix(mtime, 0).UTC()
+
+	// v1 with the modification time in a continuation block
+	// (16-byte prefix, then a 24-byte continuation message).
+	contMsgs := v1Message(msgModificationTime, mtimeMessage(mtime))
+	first := v1Message(msgContinuation, continuationMessage(16+24, uint64(len(contMsgs))))
+	v1Cont := v1Header(first, contMsgs)
+
+	// v2 with header times and a modification time message in an OCHK chunk
+	v2 := []byte("OHDR")
+	v2 = append(v2, 2, 0x20) // version, flags: times stored, 1-byte chunk size
+	for _, s := range []uint32{mtime - 3, mtime - 2, mtime - 1, mtime - 4} {
+		v2 = binary.LittleEndian.AppendUint32(v2, s)
+	}
+	v2Msgs := v2Message(msgContinuation, continuationMessage(0, 0))
+	v2 = append(v2, byte(len(v2Msgs)))
+	ochkAddr := uint64(len(v2) + len(v2Msgs) + 4)
+	ochk := append([]byte("OCHK"), v2Message(msgModificationTime, mtimeMessage(mtime))...)
+	ochk = append(ochk, 0, 0, 0, 0) // checksum
+	v2Msgs = v2Message(msgContinuation, continuationMessage(ochkAddr, uint64(len(ochk))))
+	v2 = append(v2, v2Msgs...)
+	v2 = append(v2, 0, 0, 0, 0) // checksum
+	v2 = append(v2, ochk...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want ObjectTimes
+	}{
+		{
+			name: "v1 message",
+			data: v1Header(v1Message(msgModificationTime, mtimeMessage(mtime)), nil),
+			want: ObjectTimes{Modification: want},
+		},
+		{
+			name: "v1 old-style message",
+			data: v1Header(v1Message(msgModificationTimeOld, []byte("20231114221320")), nil),
+			want: ObjectTimes{Modification: want},
+		},
+		{
+			name: "v1 continuation",
+			data: v1Cont,
+			want: ObjectTimes{Modification: want},
+		},
+		{
+			name: "v2 header times",
+			data: v2,
+			want: ObjectTimes{
+				Access:       time.Unix(mtime-3, 0).UTC(),
+				Modification: time.Unix(mtime-2, 0).UTC(),
+				Change:       time.Unix(mtime-1, 0).UTC(),
+				Birth:        time.Unix(mtime-4, 0).UTC(),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readObjectTimes(bytes.NewReader(tt.data), 0, 8, 8)
+			if err != nil {
+				t.Fatalf("readObjectTimes() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("readObjectTimes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadObjectTimes_Errors(t *testing.T) {
+	loop := v1Header(v1Message(msgContinuation, continuationMessage(16, 24)), nil)
+
+	huge := v1Header(v1Message(msgContinuation, continuationMessage(16, 1<<40)), nil)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{"no times", v1Header(v1Message(0, make([]byte, 8)), nil), errNoTimes},
+		{"bad signature", []byte("XXXXXXXXXXXXXXXX"), nil},
+		{"truncated", []byte{1, 0}, nil},
+		{"continuation loop", loop, nil},
+		{"huge continuation", huge, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readObjectTimes(bytes.NewReader(tt.data), 0, 8, 8)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestObjectTimes_Attributes(t *testing.T) {
+	mtime := time.Unix(1700000000, 0).UTC()
+	attrs := ObjectTimes{Modification: mtime}.attributes()
+	if len(attrs) != 1 || attrs[AttrModificationTime] != mtime {
+		t.Errorf("attributes() = %v", attrs)
+	}
+}
@@ -10,25 +10,112 @@ import (
 )
 
 // Parser handles parsing of v7.3 MAT-files (HDF5 format).
-type Parser struct{}
+type Parser struct {
+	Limits           Limits          // Traversal limits; zero fields select defaults
+	FlattenStructs   bool            // Report struct group fields as separate variables
+	TempDir          string          // Directory for temporary copies; "" selects os.TempDir()
+	TempPrefix       string          // Name prefix of temporary copies; "" selects DefaultTempPrefix
+	SourcePath       string          // Regular file with the same content as the reader, opened in place if set
+	ResolveSoftLinks bool            // Convert soft links to their targets; see HDF5Adapter
+	KeepRaw          bool            // Record the stored bytes of datasets; see HDF5Adapter
+	DimLimits        types.DimLimits // Shape limits of datasets read; zero fields select defaults
+	Tree             *TreeNode       // HDF5 object hierarchy, populated by Parse
+
+	// Transform, if set, is applied to each variable as it is converted.
+	// Returning nil drops the variable.
+	Transform func(*types.Variable) (*types.Variable, error)
+}
+
+// DefaultTempPrefix is the name prefix of temporary copies. They are
+// named prefix, random digits and ".tmp", such as matfile-123456789.tmp.
+const DefaultTempPrefix = "matfile-"
 
 // NewParser creates a new v7.3 parser.
 func NewParser() *Parser {
-	return &Parser{}
+	return &Parser{ResolveSoftLinks: true}
 }
 
 // Parse reads the HDF5-based MAT-file.
 // Since the HDF5 library requires a file path, we create a temporary file
-// from the io.Reader, parse it, and then clean up.
+// from the io.Reader, parse it, and then clean up, unless SourcePath names
+// the file already.
 func (p *Parser) Parse(r io.Reader) ([]*types.Variable, error) {
+	var variables []*types.Variable
+	err := p.withFile(r, func(file *hdf5.File) error {
+		// Create adapter and convert to MATLAB variables
+		adapter := NewHDF5AdapterWithLimits(file, p.Limits)
+		adapter.FlattenStructs = p.FlattenStructs
+		adapter.Transform = p.Transform
+		adapter.ResolveSoftLinks = p.ResolveSoftLinks
+		adapter.KeepRaw = p.KeepRaw
+		adapter.DimLimits = p.DimLimits
+		var err error
+		variables, err = adapter.ConvertToMatlab()
+		if err != nil {
+			return err
+		}
+
+		// Record the raw HDF5 structure while the file is still open
+		tree, err := BuildTree(file, p.Limits)
+		if err != nil {
+			return err
+		}
+		p.Tree = tree
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return variables, nil
+}
+
+// ParseMetadata describes the variables of the HDF5-based MAT-file from
+// its object headers and attributes, without reading any dataset data.
+func (p *Parser) ParseMetadata(r io.Reader) ([]*types.VariableInfo, error) {
+	var infos []*types.VariableInfo
+	err := p.withFile(r, func(file *hdf5.File) error {
+		tree, err := BuildTree(file, p.Limits)
+		if err != nil {
+			return err
+		}
+		p.Tree = tree
+		infos = VariablesFromTree(tree)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// withFile opens the HDF5 file and calls fn with it. The file named by
+// SourcePath is opened in place; otherwise r is copied to a temporary file
+// in TempDir, which is removed afterwards. A panic while reading, such as
+// one raised by the HDF5 library on a malformed file, is returned as an
+// error once the temporary file is removed.
+func (p *Parser) withFile(r io.Reader, fn func(file *hdf5.File) error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic while reading HDF5 file: %v", v)
+		}
+	}()
+	if p.SourcePath != "" {
+		return openAndRun(p.SourcePath, fn)
+	}
+
 	// Create temporary file
-	tmpFile, err := os.CreateTemp("", "matfile-*.tmp")
+	prefix := p.TempPrefix
+	if prefix == "" {
+		prefix = DefaultTempPrefix
+	}
+	tmpFile, err := os.CreateTemp(p.TempDir, prefix+"*.tmp")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp file: %w", err)
+		return fmt.Errorf("failed to create temp file: %w", err)
 	}
 	tmpPath := tmpFile.Name()
 
-	// Ensure cleanup
+	// Ensure cleanup, also when fn panics (this runs before the recover
+	// above)
 	defer func() {
 		_ = tmpFile.Close()
 		_ = os.Remove(tmpPath)
@@ -36,22 +123,24 @@ func (p *Parser) Parse(r io.Reader) ([]*types.Variable, error) {
 
 	// Copy reader to temp file
 	if _, err := io.Copy(tmpFile, r); err != nil {
-		return nil, fmt.Errorf("failed to copy data to temp file: %w", err)
+		return fmt.Errorf("failed to copy data to temp file: %w", err)
 	}
 
 	// Close to flush before opening with HDF5
 	if err := tmpFile.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close temp file: %w", err)
+		return fmt.Errorf("failed to close temp file: %w", err)
 	}
 
-	// Open with HDF5
-	file, err := hdf5.Open(tmpPath)
+	return openAndRun(tmpPath, fn)
+}
+
+// openAndRun opens path with the HDF5 library and calls fn with the file.
+func openAndRun(path string, fn func(file *hdf5.File) error) error {
+	file, err := hdf5.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open HDF5 file: %w", err)
+		return fmt.Errorf("failed to open HDF5 file: %w", err)
 	}
 	defer file.Close() //nolint:errcheck // Best effort cleanup
 
-	// Create adapter and convert to MATLAB variables
-	adapter := NewHDF5Adapter(file)
-	return adapter.ConvertToMatlab()
+	return fn(file)
 }
@@ -2,7 +2,9 @@ package v73
 
 import (
 	"bytes"
+	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/scigolib/matlab/types"
@@ -313,3 +315,96 @@ func TestParser_Parse_ScalarRoundtrip(t *testing.T) {
 		t.Errorf("got %v, want [42.0]", floatData)
 	}
 }
+
+func TestParser_Parse_PopulatesTree(t *testing.T) {
+	tmpFile := writeTestFile(t, &types.Variable{
+		Name:       "x",
+		Dimensions: []int{2},
+		DataType:   types.Int32,
+		Data:       []int32{1, 2},
+	})
+
+	data, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	parser := NewParser()
+	if _, err := parser.Parse(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if parser.Tree == nil {
+		t.Fatal("Tree is nil after Parse")
+	}
+	if len(parser.Tree.Children) != 1 || parser.Tree.Children[0].Path != "/x" {
+		t.Errorf("unexpected tree:\n%s", parser.Tree)
+	}
+	if got := parser.Tree.Children[0].Datatype; got != "integer" {
+		t.Errorf("Datatype = %q, want %q", got, "integer")
+	}
+}
+
+// TestParser_TempFileCleanup checks that no temporary copy is left in
+// TempDir after parsing succeeds, fails or panics.
+func TestParser_TempFileCleanup(t *testing.T) {
+	data, err := os.ReadFile(writeTestFile(t, &types.Variable{
+		Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	panicking := func(*types.Variable) (*types.Variable, error) {
+		panic("transform failed")
+	}
+
+	tests := []struct {
+		name      string
+		input     []byte
+		transform func(*types.Variable) (*types.Variable, error)
+		wantErr   bool
+	}{
+		{"valid", data, nil, false},
+		{"garbage", []byte("not an HDF5 file"), nil, true},
+		{"panic", data, panicking, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			parser := NewParser()
+			parser.TempDir = dir
+			parser.TempPrefix = "leak-"
+			parser.Transform = tt.transform
+
+			var created string
+			_, err := parser.Parse(io.TeeReader(bytes.NewReader(tt.input), writerFunc(func(p []byte) (int, error) {
+				if created == "" {
+					entries, _ := os.ReadDir(dir)
+					if len(entries) == 1 {
+						created = entries[0].Name()
+					}
+				}
+				return len(p), nil
+			})))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !strings.HasPrefix(created, "leak-") || !strings.HasSuffix(created, ".tmp") {
+				t.Errorf("temporary copy named %q, want leak-*.tmp", created)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				t.Errorf("left behind: %s", e.Name())
+			}
+		})
+	}
+}
+
+// writerFunc adapts a function to io.Writer.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,119 @@
+package v73
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/scigolib/hdf5"
+	"github.com/scigolib/matlab/types"
+)
+
+// contiguousLayoutPattern matches the layout part of hdf5.Dataset.Info for
+// contiguous storage, e.g. "contiguous (address=0x13D7, size=4)".
+var contiguousLayoutPattern = regexp.MustCompile(`^contiguous \(address=0x([0-9A-Fa-f]+), size=(\d+)\)$`)
+
+// readRawContiguous returns the stored bytes of a dataset with contiguous
+// layout, read directly from the file.
+//
+// It is used for datatypes the HDF5 library cannot convert, such as
+// 1-byte integers. Compact and chunked layouts are not supported.
+func readRawContiguous(file *hdf5.File, ds *hdf5.Dataset) ([]byte, error) {
+	address, size, err := contiguousExtent(ds)
+	if err != nil {
+		return nil, err
+	}
+
+	// ReadAll grows the buffer as data arrives, so a bogus size in a
+	// corrupted file cannot force a huge allocation up front.
+	data, err := io.ReadAll(io.NewSectionReader(file.Reader(), address, size))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read raw data: %w", err)
+	}
+	if int64(len(data)) != size {
+		return nil, fmt.Errorf("raw data truncated: got %d of %d bytes", len(data), size)
+	}
+	return data, nil
+}
+
+// contiguousExtent returns the file address and size of the data of a
+// dataset with contiguous layout.
+func contiguousExtent(ds *hdf5.Dataset) (address, size int64, err error) {
+	node := describeDataset(ds, ds.Name())
+	m := contiguousLayoutPattern.FindStringSubmatch(node.Layout)
+	if m == nil {
+		return 0, 0, fmt.Errorf("raw read requires contiguous layout, got %q", node.Layout)
+	}
+
+	address, err = strconv.ParseInt(m[1], 16, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid data address %q: %w", m[1], err)
+	}
+	size, err = strconv.ParseInt(m[2], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid data size %q: %w", m[2], err)
+	}
+	return address, size, nil
+}
+
+// datasetLocation returns where a dataset is stored: its object header
+// address and, for contiguous storage, the extent of its data.
+func datasetLocation(ds *hdf5.Dataset) types.Location {
+	loc := types.Location{Offset: -1, Address: ds.Address()}
+	if address, size, err := contiguousExtent(ds); err == nil {
+		loc.Offset, loc.Length = address, size
+	}
+	return loc
+}
+
+// ErrDatasetNotFound indicates a path passed to ReadRaw that names no
+// dataset.
+var ErrDatasetNotFound = errors.New("dataset not found")
+
+// rawData returns the stored bytes of a contiguous dataset with its
+// datatype and dataspace.
+func rawData(file *hdf5.File, ds *hdf5.Dataset, class string) (*types.RawData, error) {
+	data, err := readRawContiguous(file, ds)
+	if err != nil {
+		return nil, err
+	}
+	node := describeDataset(ds, ds.Name())
+	dims := make([]int, len(node.Dims))
+	for i, d := range node.Dims {
+		dims[i] = int(d) //nolint:gosec // G115: bounded by the bytes read
+	}
+	return &types.RawData{
+		Bytes:       data,
+		Datatype:    node.Datatype,
+		ElementSize: node.ElementSize,
+		Dims:        dims,
+		Class:       class,
+	}, nil
+}
+
+// ReadRaw returns the stored bytes of the contiguous dataset at the
+// slash-separated path name in the HDF5 file at path, without decoding
+// any other dataset.
+func ReadRaw(path, name string) (raw *types.RawData, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic while reading HDF5 file: %v", v)
+		}
+	}()
+	err = openAndRun(path, func(file *hdf5.File) error {
+		ds, ok := findObject(file, name).(*hdf5.Dataset)
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrDatasetNotFound, name)
+		}
+		class := ""
+		if val, err := ds.ReadAttribute(types.AttrMatlabClass); err == nil {
+			class, _ = val.(string)
+		}
+		var err error
+		raw, err = rawData(file, ds, class)
+		return err
+	})
+	return raw, err
+}
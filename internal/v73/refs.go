@@ -0,0 +1,206 @@
+package v73
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/scigolib/hdf5"
+	"github.com/scigolib/matlab/types"
+)
+
+// RefsGroup is the group in which MATLAB stores the values that reference
+// datasets point to, such as the elements of cell arrays. Readers skip it
+// and the other groups whose names start with '#', as MATLAB does.
+const RefsGroup = "/#refs#"
+
+// references is a dataset of object references, whose addresses are
+// filled in on Close, once every target is written.
+type references struct {
+	path    string   // Path of the reference dataset
+	targets []string // Paths of the referenced objects, in storage order
+}
+
+// WriteRef writes v into RefsGroup under a generated name and returns its
+// path, for use as a target of WriteReferences. v.Name is ignored.
+//
+// Parameters:
+//   - v: Value to write (must not be nil)
+//
+// Returns:
+//   - string: Absolute HDF5 path of the written value, such as "/#refs#/a"
+//   - error: If validation or writing fails
+func (w *Writer) WriteRef(v *types.Variable) (string, error) {
+	if v == nil {
+		return "", fmt.Errorf("variable cannot be nil")
+	}
+	if w.refs == 0 {
+		if _, err := w.file.CreateGroup(RefsGroup); err != nil {
+			return "", fmt.Errorf("failed to create group %q: %w", RefsGroup, err)
+		}
+	}
+
+	value := *v
+	value.Name = refName(w.refs)
+	w.refs++
+	path := RefsGroup + "/" + value.Name
+	w.variablePath = "" // Referenced values are not stamped
+	if err := w.writeVariableAt(path, &value); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// refName returns the name of the i-th value in RefsGroup: "a" to "z",
+// then "ba", "bb" and so on, as MATLAB names them.
+func refName(i int) string {
+	name := []byte{byte('a' + i%26)}
+	for i /= 26; i > 0; i /= 26 {
+		name = append([]byte{byte('a' + i%26)}, name...)
+	}
+	return string(name)
+}
+
+// WriteReferences writes a top-level dataset of HDF5 object references
+// to the objects at the target paths, with the given MATLAB_class and,
+// unless decode is 0, MATLAB_object_decode. A cell array is written with
+// class "cell" and decode 0, its elements written with WriteRef.
+//
+// The HDF5 library does not report the addresses of the objects it
+// writes, so the references are written as zeros and filled in by Close.
+//
+// Parameters:
+//   - name: Variable name
+//   - class: MATLAB_class attribute, such as "cell" or an object class
+//   - decode: MATLAB_object_decode attribute, or 0 for none
+//   - dims: Dimensions of the dataset
+//   - targets: Absolute paths of the referenced objects, one per element
+//
+// Returns:
+//   - error: If the arguments are invalid or writing fails
+func (w *Writer) WriteReferences(name, class string, decode int32, dims []int, targets []string) error {
+	if name == "" || strings.ContainsAny(name, "/\x00") {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	if class == "" {
+		return fmt.Errorf("MATLAB class is required")
+	}
+	if decode != 0 {
+		if err := types.CheckMatlabAttr(types.AttrMatlabObjectDecode, decode); err != nil {
+			return err
+		}
+	}
+	count := 1
+	hdims := make([]uint64, len(dims))
+	for i, d := range dims {
+		if d <= 0 {
+			return fmt.Errorf("dimension[%d] must be positive, got %d", i, d)
+		}
+		count *= d
+		hdims[i] = uint64(d)
+	}
+	if len(dims) == 0 || count != len(targets) {
+		return fmt.Errorf("%d targets, dimensions %v require %d", len(targets), dims, count)
+	}
+	for _, target := range targets {
+		if !strings.HasPrefix(target, "/") {
+			return fmt.Errorf("reference target %q is not an absolute path", target)
+		}
+	}
+
+	slices.Reverse(hdims)
+
+	path := "/" + name
+	dataset, err := w.file.CreateDataset(path, hdf5.ObjectReference, hdims)
+	if err != nil {
+		return fmt.Errorf("failed to create dataset: %w", err)
+	}
+	if err := dataset.Write(make([]uint64, count)); err != nil {
+		return fmt.Errorf("failed to write data: %w", err)
+	}
+	if err := dataset.WriteAttribute(types.AttrMatlabClass, class); err != nil {
+		return fmt.Errorf("failed to write MATLAB_class attribute: %w", err)
+	}
+	if decode != 0 {
+		if err := dataset.WriteAttribute(types.AttrMatlabObjectDecode, decode); err != nil {
+			return fmt.Errorf("failed to write MATLAB_object_decode attribute: %w", err)
+		}
+	}
+
+	w.references = append(w.references, references{path: path, targets: append([]string(nil), targets...)})
+	return nil
+}
+
+// resolveReferences fills in the reference datasets of the closed file
+// with the object header addresses of their targets.
+func (w *Writer) resolveReferences() error {
+	patches, err := w.referencePatches()
+	if err != nil {
+		return err
+	}
+
+	//nolint:gosec // G304: path of the file just written
+	f, err := os.OpenFile(w.filename, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to resolve references: %w", err)
+	}
+	for offset, data := range patches {
+		if _, err := f.WriteAt(data, offset); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to resolve references: %w", err)
+		}
+	}
+	return f.Close()
+}
+
+// referencePatches reads the written file and returns the contents of
+// each reference dataset by the file offset of its data.
+func (w *Writer) referencePatches() (map[int64][]byte, error) {
+	file, err := hdf5.Open(w.filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reopen file to resolve references: %w", err)
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+
+	sb := file.Superblock()
+	if sb.OffsetSize != 8 {
+		return nil, fmt.Errorf("cannot resolve references with %d-byte addresses", sb.OffsetSize)
+	}
+	r := &linkReader{
+		h:         headerReader{r: file.Reader(), offsetSize: int(sb.OffsetSize), lengthSize: int(sb.LengthSize)},
+		limits:    Limits{}.withDefaults(),
+		links:     make(map[string]Link),
+		visited:   make(map[uint64]bool),
+		addresses: make(map[string]uint64),
+	}
+	if err := r.object(sb.RootGroup, "", 0); err != nil {
+		return nil, fmt.Errorf("failed to read object addresses: %w", err)
+	}
+
+	patches := make(map[int64][]byte, len(w.references))
+	for _, ref := range w.references {
+		dataset, ok := findObject(file, ref.path).(*hdf5.Dataset)
+		if !ok {
+			return nil, fmt.Errorf("reference dataset %q not found", ref.path)
+		}
+		offset, size, err := contiguousExtent(dataset)
+		if err != nil {
+			return nil, fmt.Errorf("reference dataset %q: %w", ref.path, err)
+		}
+		if size != int64(8*len(ref.targets)) {
+			return nil, fmt.Errorf("reference dataset %q holds %d bytes, want %d", ref.path, size, 8*len(ref.targets))
+		}
+		data := make([]byte, size)
+		for i, target := range ref.targets {
+			address, ok := r.addresses[target]
+			if !ok {
+				return nil, fmt.Errorf("reference dataset %q: no object at %q", ref.path, target)
+			}
+			binary.LittleEndian.PutUint64(data[8*i:], address)
+		}
+		patches[offset] = data
+	}
+	return patches, nil
+}
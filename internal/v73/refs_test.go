@@ -0,0 +1,85 @@
+package v73
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	"github.com/scigolib/hdf5"
+	"github.com/scigolib/matlab/types"
+)
+
+func TestWriteReferences(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "refs.mat")
+	writer, err := NewWriter(tmpFile)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+
+	x, err := writer.WriteRef(&types.Variable{Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}})
+	if err != nil {
+		t.Fatalf("WriteRef failed: %v", err)
+	}
+	label, err := writer.WriteRef(&types.Variable{Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"})
+	if err != nil {
+		t.Fatalf("WriteRef failed: %v", err)
+	}
+	if x != "/#refs#/a" || label != "/#refs#/b" {
+		t.Errorf("paths = %q, %q", x, label)
+	}
+	if err := writer.WriteReferences("c", matlabClassCell, 0, []int{1, 2}, []string{x, label}); err != nil {
+		t.Fatalf("WriteReferences failed: %v", err)
+	}
+	if err := writer.WriteReferences("d", matlabClassCell, 0, []int{1, 3}, []string{x, label}); err == nil {
+		t.Error("expected error for dimensions not matching the targets")
+	}
+	if err := writer.WriteReferences("d", "geom.Point", 7, []int{1}, []string{x}); err == nil {
+		t.Error("expected error for invalid MATLAB_object_decode")
+	}
+	if err := writer.WriteVariable(&types.Variable{
+		Name: "y", Dimensions: []int{1}, DataType: types.Double, Data: []float64{3},
+	}); err != nil {
+		t.Fatalf("WriteVariable failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	file := openHDF5(t, tmpFile)
+	defer file.Close()
+	refs, ok := findObject(file, "/c").(*hdf5.Dataset)
+	if !ok {
+		t.Fatal("reference dataset /c not found")
+	}
+	raw, err := readRawContiguous(file, refs)
+	if err != nil {
+		t.Fatalf("readRawContiguous() error = %v", err)
+	}
+	for i, target := range []string{x, label} {
+		ds, ok := findObject(file, target).(*hdf5.Dataset)
+		if !ok {
+			t.Fatalf("target %s not found", target)
+		}
+		if got := binary.LittleEndian.Uint64(raw[8*i:]); got != ds.Address() {
+			t.Errorf("reference %d = 0x%X, want 0x%X (%s)", i, got, ds.Address(), target)
+		}
+	}
+
+	variables, err := NewHDF5Adapter(file).ConvertToMatlab()
+	if err != nil {
+		t.Fatalf("ConvertToMatlab() error = %v", err)
+	}
+	for _, v := range variables {
+		if v.Name != "c" && v.Name != "y" {
+			t.Errorf("unexpected variable %q; #refs# should be skipped", v.Name)
+		}
+	}
+}
+
+func TestRefName(t *testing.T) {
+	for i, want := range map[int]string{0: "a", 25: "z", 26: "ba", 27: "bb", 26 * 26: "baa"} {
+		if got := refName(i); got != want {
+			t.Errorf("refName(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package v73
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Object header messages describing dataset storage.
+const (
+	msgDataLayout     = 0x0008
+	msgFilterPipeline = 0x000B
+)
+
+// Data layout classes.
+const (
+	layoutCompact    = 0
+	layoutContiguous = 1
+	layoutChunked    = 2
+)
+
+// HDF5 filter identifiers.
+const (
+	filterDeflate     = 1
+	filterShuffle     = 2
+	filterFletcher32  = 3
+	filterSzip        = 4
+	filterNbit        = 5
+	filterScaleOffset = 6
+)
+
+// Bounds on chunk index traversal.
+const (
+	maxBTreeDepth = 32
+	maxBTreeNodes = 1 << 20
+)
+
+// filterNames names the predefined HDF5 filters. Other identifiers are
+// reported as "filter-<id>".
+var filterNames = map[uint16]string{
+	filterDeflate:     "deflate",
+	filterShuffle:     "shuffle",
+	filterFletcher32:  "fletcher32",
+	filterSzip:        "szip",
+	filterNbit:        "nbit",
+	filterScaleOffset: "scaleoffset",
+}
+
+// Storage describes how a dataset's data is stored in the file.
+type Storage struct {
+	Filters []uint16 // Filter pipeline identifiers, in application order
+	Stored  int64    // Bytes of data stored in the file, -1 if unknown
+}
+
+// Compressed reports whether the filter pipeline compresses data.
+// Shuffle and checksum filters alone do not.
+func (s Storage) Compressed() bool {
+	for _, id := range s.Filters {
+		if id != filterShuffle && id != filterFletcher32 {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterNames returns the names of the filters in the pipeline.
+func (s Storage) FilterNames() []string {
+	if len(s.Filters) == 0 {
+		return nil
+	}
+	names := make([]string, len(s.Filters))
+	for i, id := range s.Filters {
+		name, ok := filterNames[id]
+		if !ok {
+			name = fmt.Sprintf("filter-%d", id)
+		}
+		names[i] = name
+	}
+	return names
+}
+
+// readStorage reads the layout and filter pipeline messages of the dataset
+// object header at address. The stored size of chunked datasets is the
+// sum of their chunk sizes, which is only known for version 3 layouts
+// indexed by a version 1 B-tree (the format MATLAB writes).
+func readStorage(r io.ReaderAt, address uint64, offsetSize, lengthSize int) (Storage, error) {
+	h := headerReader{r: r, offsetSize: offsetSize, lengthSize: lengthSize}
+
+	var layout, pipeline []byte
+	err := h.messages(address, nil, func(msgType uint16, data []byte) error {
+		switch msgType {
+		case msgDataLayout:
+			layout = data
+		case msgFilterPipeline:
+			pipeline = data
+		}
+		return nil
+	})
+	if err != nil {
+		return Storage{}, err
+	}
+
+	storage := Storage{Stored: -1}
+	if pipeline != nil {
+		if storage.Filters, err = parseFilterPipeline(pipeline); err != nil {
+			return Storage{}, err
+		}
+	}
+	if layout != nil {
+		if storage.Stored, err = h.storedSize(layout); err != nil {
+			return Storage{}, err
+		}
+	}
+	return storage, nil
+}
+
+// parseFilterPipeline returns the filter identifiers of a filter pipeline
+// message.
+func parseFilterPipeline(data []byte) ([]uint16, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("truncated filter pipeline message")
+	}
+	version, count := data[0], int(data[1])
+	// Version 1 has six reserved bytes and 8-byte aligned names. Some
+	// writers (including the Go HDF5 library) use that layout with
+	// version 2; it is recognized by the reserved filter id 0.
+	padded := version == 1 || (len(data) >= 4 && binary.LittleEndian.Uint16(data[2:]) == 0)
+	pos := 2
+	if padded {
+		pos = 8
+	}
+
+	filters := make([]uint16, 0, count)
+	for i := 0; i < count; i++ {
+		if pos+2 > len(data) {
+			return nil, fmt.Errorf("truncated filter pipeline message")
+		}
+		id := binary.LittleEndian.Uint16(data[pos:])
+		pos += 2
+
+		nameLen := 0
+		if padded || id >= 256 {
+			if pos+2 > len(data) {
+				return nil, fmt.Errorf("truncated filter pipeline message")
+			}
+			nameLen = int(binary.LittleEndian.Uint16(data[pos:]))
+			pos += 2
+		}
+		if pos+4 > len(data) {
+			return nil, fmt.Errorf("truncated filter pipeline message")
+		}
+		values := int(binary.LittleEndian.Uint16(data[pos+2:])) // after flags
+		pos += 4
+
+		if padded {
+			nameLen = (nameLen + 7) &^ 7
+		}
+		if version == 1 && values%2 == 1 {
+			values++ // padded to 8 bytes
+		}
+		pos += nameLen + 4*values
+		filters = append(filters, id)
+	}
+	if pos > len(data) {
+		return nil, fmt.Errorf("truncated filter pipeline message")
+	}
+	return filters, nil
+}
+
+// storedSize returns the number of data bytes a version 3 layout message
+// refers to, or -1 for layouts it cannot size.
+func (h headerReader) storedSize(layout []byte) (int64, error) {
+	if len(layout) < 2 || layout[0] != 3 {
+		return -1, nil
+	}
+	data := layout[2:]
+	switch layout[1] {
+	case layoutCompact:
+		if len(data) < 2 {
+			return 0, fmt.Errorf("truncated layout message")
+		}
+		return int64(binary.LittleEndian.Uint16(data)), nil
+	case layoutContiguous:
+		if len(data) < h.offsetSize+h.lengthSize {
+			return 0, fmt.Errorf("truncated layout message")
+		}
+		if readUint(data, h.offsetSize) == undefinedAddress(h.offsetSize) {
+			return 0, nil // not allocated yet
+		}
+		//nolint:gosec // G115: sizes beyond int64 are rejected below
+		size := int64(readUint(data[h.offsetSize:], h.lengthSize))
+		if size < 0 {
+			return 0, fmt.Errorf("invalid contiguous data size")
+		}
+		return size, nil
+	case layoutChunked:
+		if len(data) < 1+h.offsetSize {
+			return 0, fmt.Errorf("truncated layout message")
+		}
+		dims := int(data[0])
+		address := readUint(data[1:], h.offsetSize)
+		if address == undefinedAddress(h.offsetSize) {
+			return 0, nil // no chunks written
+		}
+		nodes := 0
+		return h.chunkBytes(address, dims, 0, &nodes)
+	default:
+		return -1, nil
+	}
+}
+
+// chunkBytes sums the chunk sizes recorded in the version 1 B-tree node at
+// address and its descendants. dims is the layout dimensionality, which
+// includes the extra element size dimension.
+func (h headerReader) chunkBytes(address uint64, dims, depth int, nodes *int) (int64, error) {
+	*nodes++
+	if depth > maxBTreeDepth || *nodes > maxBTreeNodes {
+		return 0, fmt.Errorf("chunk index too large or cyclic")
+	}
+
+	// Signature (4), type (1), level (1), entries (2), siblings (2 addresses)
+	head, err := h.read(address, 8+2*h.offsetSize)
+	if err != nil {
+		return 0, err
+	}
+	if string(head[0:4]) != "TREE" || head[4] != 1 {
+		return 0, fmt.Errorf("invalid chunk index node at 0x%X", address)
+	}
+	level := head[5]
+	entries := int(binary.LittleEndian.Uint16(head[6:]))
+
+	// Keys (chunk size, filter mask, offsets) alternate with child
+	// addresses; there is one more key than children.
+	keySize := 8 + 8*dims
+	body, err := h.read(address+uint64(len(head)), entries*(keySize+h.offsetSize)+keySize)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for i := 0; i < entries; i++ {
+		entry := body[i*(keySize+h.offsetSize):]
+		if level == 0 {
+			total += int64(binary.LittleEndian.Uint32(entry))
+			continue
+		}
+		child := readUint(entry[keySize:], h.offsetSize)
+		size, err := h.chunkBytes(child, dims, depth+1, nodes)
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// undefinedAddress returns the all-ones address HDF5 uses for "not set".
+func undefinedAddress(offsetSize int) uint64 {
+	if offsetSize >= 8 {
+		return ^uint64(0)
+	}
+	return 1<<(8*offsetSize) - 1
+}
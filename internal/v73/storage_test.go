@@ -0,0 +1,138 @@
+package v73
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/hdf5"
+)
+
+// writeChunkedFile creates an HDF5 file with a contiguous dataset "plain"
+// and a shuffled, deflated chunked dataset "packed", both of 1000 doubles.
+func writeChunkedFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "chunked.h5")
+	file, err := hdf5.CreateForWrite(path, hdf5.CreateTruncate)
+	if err != nil {
+		t.Fatalf("CreateForWrite() error = %v", err)
+	}
+
+	data := make([]float64, 1000)
+	for i := range data {
+		data[i] = float64(i % 10)
+	}
+
+	plain, err := file.CreateDataset("/plain", hdf5.Float64, []uint64{1000})
+	if err != nil {
+		t.Fatalf("CreateDataset(plain) error = %v", err)
+	}
+	if err := plain.Write(data); err != nil {
+		t.Fatalf("Write(plain) error = %v", err)
+	}
+
+	packed, err := file.CreateDataset("/packed", hdf5.Float64, []uint64{1000},
+		hdf5.WithChunkDims([]uint64{100}), hdf5.WithShuffle(), hdf5.WithGZIPCompression(9))
+	if err != nil {
+		t.Fatalf("CreateDataset(packed) error = %v", err)
+	}
+	if err := packed.Write(data); err != nil {
+		t.Fatalf("Write(packed) error = %v", err)
+	}
+
+	if err := file.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return path
+}
+
+func TestBuildTree_Storage(t *testing.T) {
+	file := openHDF5(t, writeChunkedFile(t))
+	defer file.Close()
+
+	tree, err := BuildTree(file, Limits{})
+	if err != nil {
+		t.Fatalf("BuildTree() error = %v", err)
+	}
+
+	nodes := map[string]*TreeNode{}
+	for _, child := range tree.Children {
+		nodes[child.Path] = child
+	}
+
+	plain := nodes["/plain"]
+	if plain == nil || plain.Compressed() || plain.Filters != nil || plain.StoredBytes != 8000 {
+		t.Errorf("plain = %+v, want unfiltered with 8000 stored bytes", plain)
+	}
+
+	packed := nodes["/packed"]
+	if packed == nil {
+		t.Fatal("packed dataset missing")
+	}
+	if !reflect.DeepEqual(packed.Filters, []string{"shuffle", "deflate"}) || !packed.Compressed() {
+		t.Errorf("packed filters = %v", packed.Filters)
+	}
+	if packed.StoredBytes <= 0 || packed.StoredBytes >= 8000 {
+		t.Errorf("packed stored bytes = %d, want between 0 and 8000", packed.StoredBytes)
+	}
+
+	infos := VariablesFromTree(tree)
+	for _, info := range infos {
+		if info.UncompressedSize != 8000 {
+			t.Errorf("%s: UncompressedSize = %d, want 8000", info.Name, info.UncompressedSize)
+		}
+		if info.Name == "packed" && (!info.Compressed || info.Size != packed.StoredBytes) {
+			t.Errorf("packed info = %+v", info)
+		}
+	}
+}
+
+func TestParseFilterPipeline(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []uint16
+	}{
+		{
+			// Version 1: deflate named "deflate" with one client value
+			name: "version 1",
+			data: []byte{
+				1, 1, 0, 0, 0, 0, 0, 0,
+				1, 0, 8, 0, 1, 0, 1, 0,
+				'd', 'e', 'f', 'l', 'a', 't', 'e', 0,
+				6, 0, 0, 0, 0, 0, 0, 0,
+			},
+			want: []uint16{filterDeflate},
+		},
+		{
+			// Version 2: shuffle (one value), then a registered filter
+			// with a 3-byte name and no values
+			name: "version 2",
+			data: []byte{
+				2, 2,
+				2, 0, 1, 0, 1, 0, 8, 0, 0, 0,
+				0x7D, 0x7D, 3, 0, 0, 0, 0, 0, 'z', 's', 't',
+			},
+			want: []uint16{filterShuffle, 32125},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFilterPipeline(tt.data)
+			if err != nil {
+				t.Fatalf("parseFilterPipeline() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFilterPipeline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseFilterPipeline([]byte{2, 1, 1}); err == nil {
+		t.Error("expected error for truncated message")
+	}
+	if names := (Storage{Filters: []uint16{filterDeflate, 32125}}).FilterNames(); !reflect.DeepEqual(names, []string{"deflate", "filter-32125"}) {
+		t.Errorf("FilterNames() = %v", names)
+	}
+}
@@ -0,0 +1,241 @@
+package v73
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/scigolib/hdf5"
+)
+
+// Object kinds reported in TreeNode.Kind.
+const (
+	KindGroup   = "group"
+	KindDataset = "dataset"
+)
+
+// TreeNode describes one object of an HDF5 file hierarchy.
+//
+// The tree is a debugging aid: it reports what the HDF5 layer sees,
+// independently of whether the MATLAB class could be decoded.
+type TreeNode struct {
+	Path        string           // Absolute HDF5 path ("/" for the root group)
+	Kind        string           // KindGroup or KindDataset
+	Datatype    string           // HDF5 datatype class (datasets only)
+	ElementSize int              // Bytes per element (datasets only)
+	Dims        []uint64         // Dataspace dimensions (datasets only, nil for scalars)
+	Layout      string           // Storage layout description (datasets only)
+	Filters     []string         // Filter pipeline, e.g. "deflate" (datasets only)
+	StoredBytes int64            // Bytes of data stored in the file, -1 if unknown (datasets only)
+	Attributes  []*TreeAttribute // Attributes attached to the object
+	Children    []*TreeNode      // Child objects (groups only)
+}
+
+// TreeAttribute describes an HDF5 attribute.
+type TreeAttribute struct {
+	Name        string      // Attribute name
+	Datatype    string      // HDF5 datatype class
+	ElementSize int         // Bytes per element
+	Dims        []uint64    // Dataspace dimensions (nil for scalars)
+	Value       interface{} // Decoded value, nil if the type is not supported
+}
+
+// Bytes returns the uncompressed size of a dataset's data in bytes.
+func (n *TreeNode) Bytes() uint64 {
+	if n.Kind != KindDataset {
+		return 0
+	}
+	total := uint64(n.ElementSize)
+	for _, d := range n.Dims {
+		total *= d
+	}
+	return total
+}
+
+// Compressed reports whether a dataset's filter pipeline compresses its
+// data. Shuffle and checksum filters alone do not.
+func (n *TreeNode) Compressed() bool {
+	for _, name := range n.Filters {
+		if name != filterNames[filterShuffle] && name != filterNames[filterFletcher32] {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the subtree as indented text, one object per line.
+func (n *TreeNode) String() string {
+	var sb strings.Builder
+	n.render(&sb, 0)
+	return sb.String()
+}
+
+// render writes the node and its descendants at the given indentation level.
+func (n *TreeNode) render(sb *strings.Builder, level int) {
+	indent := strings.Repeat("  ", level)
+	if n.Kind == KindDataset {
+		filters := ""
+		if len(n.Filters) > 0 {
+			filters = ", filters " + strings.Join(n.Filters, "+")
+		}
+		fmt.Fprintf(sb, "%s%s (dataset: %s, %d bytes/elem, dims %v, %s%s)\n",
+			indent, n.Path, n.Datatype, n.ElementSize, n.Dims, n.Layout, filters)
+	} else {
+		fmt.Fprintf(sb, "%s%s (group)\n", indent, n.Path)
+	}
+	for _, attr := range n.Attributes {
+		fmt.Fprintf(sb, "%s  @%s (%s, %d bytes/elem, dims %v) = %v\n",
+			indent, attr.Name, attr.Datatype, attr.ElementSize, attr.Dims, attr.Value)
+	}
+	for _, child := range n.Children {
+		child.render(sb, level+1)
+	}
+}
+
+// BuildTree describes the object hierarchy of an open HDF5 file.
+//
+// The same depth and object limits as ConvertToMatlab apply.
+func BuildTree(file *hdf5.File, limits Limits) (*TreeNode, error) {
+	b := &treeBuilder{file: file, limits: limits.withDefaults()}
+	return b.group(file.Root(), "/", 0)
+}
+
+// treeBuilder holds traversal state for BuildTree.
+type treeBuilder struct {
+	file    *hdf5.File
+	limits  Limits
+	objects int
+}
+
+// group describes a group and, recursively, its children.
+func (b *treeBuilder) group(g *hdf5.Group, path string, depth int) (*TreeNode, error) {
+	if depth > b.limits.MaxDepth {
+		return nil, fmt.Errorf("%w: %d levels at %q", ErrMaxDepthExceeded, b.limits.MaxDepth, path)
+	}
+	b.objects++
+	if b.objects > b.limits.MaxObjects {
+		return nil, fmt.Errorf("%w: %d objects at %q", ErrMaxObjectsExceeded, b.limits.MaxObjects, path)
+	}
+
+	node := &TreeNode{Path: path, Kind: KindGroup}
+	if attrs, err := g.Attributes(); err == nil {
+		for _, attr := range attrs {
+			if attr.Datatype == nil || attr.Dataspace == nil {
+				continue
+			}
+			node.Attributes = append(node.Attributes,
+				describeAttribute(attr.Name, attr.Datatype.String(), attr.Dataspace.String(), attr.ReadValue))
+		}
+	}
+
+	for _, child := range g.Children() {
+		childPath := strings.TrimSuffix(path, "/") + "/" + child.Name()
+		switch obj := child.(type) {
+		case *hdf5.Group:
+			sub, err := b.group(obj, childPath, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, sub)
+		case *hdf5.Dataset:
+			b.objects++
+			if b.objects > b.limits.MaxObjects {
+				return nil, fmt.Errorf("%w: %d objects at %q", ErrMaxObjectsExceeded, b.limits.MaxObjects, childPath)
+			}
+			node.Children = append(node.Children, b.dataset(obj, childPath))
+		}
+	}
+
+	return node, nil
+}
+
+// dataset describes a dataset, including its filters and stored size.
+// Storage information is left unknown if the object header cannot be
+// parsed.
+func (b *treeBuilder) dataset(ds *hdf5.Dataset, path string) *TreeNode {
+	node := describeDataset(ds, path)
+	sb := b.file.Superblock()
+	storage, err := readStorage(b.file.Reader(), ds.Address(), int(sb.OffsetSize), int(sb.LengthSize))
+	if err == nil {
+		node.Filters = storage.FilterNames()
+		node.StoredBytes = storage.Stored
+	}
+	return node
+}
+
+// datasetInfoPattern matches the description returned by hdf5.Dataset.Info,
+// e.g. "Dataset: float (size=8 bytes), 2D array [3 x 2], contiguous (...)".
+var datasetInfoPattern = regexp.MustCompile(`^Dataset: (\S+) \(size=(\d+) bytes\), (.+?), (\w.*)$`)
+
+// describeDataset builds the TreeNode for a dataset.
+func describeDataset(ds *hdf5.Dataset, path string) *TreeNode {
+	node := &TreeNode{Path: path, Kind: KindDataset, StoredBytes: -1}
+
+	if info, err := ds.Info(); err == nil {
+		if m := datasetInfoPattern.FindStringSubmatch(info); m != nil {
+			node.Datatype = m[1]
+			node.ElementSize, _ = strconv.Atoi(m[2])
+			node.Dims = parseDataspace(m[3])
+			node.Layout = m[4]
+		}
+	}
+
+	if attrs, err := ds.Attributes(); err == nil {
+		for _, attr := range attrs {
+			if attr.Datatype == nil || attr.Dataspace == nil {
+				continue
+			}
+			node.Attributes = append(node.Attributes,
+				describeAttribute(attr.Name, attr.Datatype.String(), attr.Dataspace.String(), attr.ReadValue))
+		}
+	}
+
+	return node
+}
+
+// datatypePattern matches an HDF5 datatype description such as
+// "integer (size=4 bytes)".
+var datatypePattern = regexp.MustCompile(`^(\S+) \(size=(\d+) bytes\)$`)
+
+// describeAttribute builds a TreeAttribute from the HDF5 datatype and
+// dataspace descriptions. Values that cannot be decoded are left nil.
+func describeAttribute(name, datatype, dataspace string, read func() (interface{}, error)) *TreeAttribute {
+	attr := &TreeAttribute{
+		Name:     name,
+		Datatype: datatype,
+		Dims:     parseDataspace(dataspace),
+	}
+	if m := datatypePattern.FindStringSubmatch(datatype); m != nil {
+		attr.Datatype = m[1]
+		attr.ElementSize, _ = strconv.Atoi(m[2])
+	}
+	if value, err := read(); err == nil {
+		attr.Value = value
+	}
+	return attr
+}
+
+// parseDataspace extracts dimensions from an HDF5 dataspace description
+// such as "1D array [3]", "2D array [3 x 2]" or "3D array [2 3 4]".
+// Scalar and unrecognized dataspaces yield nil.
+func parseDataspace(desc string) []uint64 {
+	start := strings.IndexByte(desc, '[')
+	end := strings.LastIndexByte(desc, ']')
+	if start < 0 || end < start {
+		return nil
+	}
+
+	fields := strings.FieldsFunc(desc[start+1:end], func(r rune) bool {
+		return r == ' ' || r == 'x'
+	})
+	dims := make([]uint64, 0, len(fields))
+	for _, f := range fields {
+		d, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return nil
+		}
+		dims = append(dims, d)
+	}
+	return dims
+}
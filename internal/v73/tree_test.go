@@ -0,0 +1,131 @@
+package v73
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestBuildTree_SimpleAndComplex(t *testing.T) {
+	tmpFile := writeTestFile(t,
+		&types.Variable{
+			Name:       "A",
+			Dimensions: []int{3, 2},
+			DataType:   types.Double,
+			Data:       []float64{1, 2, 3, 4, 5, 6},
+		},
+		&types.Variable{
+			Name:       "z",
+			Dimensions: []int{2},
+			DataType:   types.Double,
+			IsComplex:  true,
+			Data: &types.NumericArray{
+				Real: []float64{1, 2},
+				Imag: []float64{3, 4},
+			},
+		},
+	)
+
+	file := openHDF5(t, tmpFile)
+	defer file.Close()
+
+	tree, err := BuildTree(file, Limits{})
+	if err != nil {
+		t.Fatalf("BuildTree() error = %v", err)
+	}
+
+	if tree.Path != "/" || tree.Kind != KindGroup {
+		t.Fatalf("root = %q (%s), want / (group)", tree.Path, tree.Kind)
+	}
+	if len(tree.Children) != 2 {
+		t.Fatalf("root has %d children, want 2", len(tree.Children))
+	}
+
+	byPath := make(map[string]*TreeNode)
+	for _, c := range tree.Children {
+		byPath[c.Path] = c
+	}
+
+	a := byPath["/A"]
+	if a == nil {
+		t.Fatal("missing /A")
+	}
+	if a.Kind != KindDataset || a.Datatype != "float" || a.ElementSize != 8 {
+		t.Errorf("/A = %+v", a)
+	}
+	// The dataspace lists MATLAB's dimensions in reverse
+	if !reflect.DeepEqual(a.Dims, []uint64{2, 3}) {
+		t.Errorf("/A dims = %v, want [2 3]", a.Dims)
+	}
+	if a.Bytes() != 48 {
+		t.Errorf("/A Bytes() = %d, want 48", a.Bytes())
+	}
+	if len(a.Attributes) != 1 || a.Attributes[0].Name != "MATLAB_class" || a.Attributes[0].Value != "double" {
+		t.Errorf("/A attributes = %+v", a.Attributes)
+	}
+
+	z := byPath["/z"]
+	if z == nil || z.Kind != KindGroup {
+		t.Fatalf("/z = %+v, want group", z)
+	}
+	if len(z.Children) != 2 {
+		t.Fatalf("/z has %d children, want 2", len(z.Children))
+	}
+	for _, c := range z.Children {
+		if c.Path != "/z/real" && c.Path != "/z/imag" {
+			t.Errorf("unexpected child of /z: %q", c.Path)
+		}
+	}
+	if z.Bytes() != 0 {
+		t.Errorf("group Bytes() = %d, want 0", z.Bytes())
+	}
+
+	out := tree.String()
+	for _, want := range []string{"/ (group)", "/A (dataset: float", "@MATLAB_class", "  /z (group)", "    /z/real"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("String() missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestBuildTree_Limits(t *testing.T) {
+	tmpFile := writeNestedGroups(t, 3)
+
+	file := openHDF5(t, tmpFile)
+	defer file.Close()
+
+	if _, err := BuildTree(file, Limits{MaxDepth: 2}); !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Errorf("expected ErrMaxDepthExceeded, got %v", err)
+	}
+	if _, err := BuildTree(file, Limits{MaxObjects: 4}); !errors.Is(err, ErrMaxObjectsExceeded) {
+		t.Errorf("expected ErrMaxObjectsExceeded, got %v", err)
+	}
+	if _, err := BuildTree(file, Limits{}); err != nil {
+		t.Errorf("BuildTree() error = %v", err)
+	}
+}
+
+func TestParseDataspace(t *testing.T) {
+	tests := []struct {
+		desc string
+		want []uint64
+	}{
+		{"1D array [3]", []uint64{3}},
+		{"2D array [3 x 2]", []uint64{3, 2}},
+		{"3D array [2 3 4]", []uint64{2, 3, 4}},
+		{"scalar", nil},
+		{"2D array [a x 2]", nil},
+		{"unknown", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := parseDataspace(tt.desc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDataspace(%q) = %v, want %v", tt.desc, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package v73
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+
+	"github.com/scigolib/hdf5"
+	"github.com/scigolib/matlab/types"
+)
+
+// Datatype details of variable-length strings.
+const (
+	msgDatatype         = 0x0003 // Object header message holding the datatype
+	datatypeClassVarLen = 9      // Variable-length sequence or string
+	varLenTypeString    = 1      // Variable-length type of strings
+)
+
+// maxGlobalHeapBytes bounds the global heap collections read for the
+// variable-length strings of one dataset, together, so a corrupted file
+// cannot force huge allocations with one large collection or many.
+const maxGlobalHeapBytes = 1 << 26
+
+// errNotVarLenString indicates a dataset whose datatype is not a
+// variable-length string.
+var errNotVarLenString = errors.New("not a variable-length string dataset")
+
+// readVarLenStrings reads a contiguous dataset of variable-length
+// strings, as HDF5 tools other than MATLAB commonly write names and
+// labels. Each element holds the string length and the global heap
+// object with its bytes; at most budget bytes of heap collections are
+// read. Returns errNotVarLenString for other datatypes.
+func readVarLenStrings(file *hdf5.File, ds *hdf5.Dataset, budget int64) ([]string, error) {
+	sb := file.Superblock()
+	h := headerReader{r: file.Reader(), offsetSize: int(sb.OffsetSize), lengthSize: int(sb.LengthSize)}
+
+	var datatype []byte
+	err := h.messages(ds.Address(), nil, func(msgType uint16, data []byte) error {
+		if msgType == msgDatatype && datatype == nil {
+			datatype = data
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Class and version (1), class bit field (3), element size (4)
+	if len(datatype) < 8 || datatype[0]&0x0F != datatypeClassVarLen || datatype[1]&0x0F != varLenTypeString {
+		return nil, errNotVarLenString
+	}
+	// Length (4), global heap collection address, object index (4)
+	elementSize := 4 + h.offsetSize + 4
+	if size := binary.LittleEndian.Uint32(datatype[4:]); size != uint32(elementSize) { //nolint:gosec // G115: at most 16
+		return nil, fmt.Errorf("variable-length string element of %d bytes, want %d", size, elementSize)
+	}
+
+	data, err := readRawContiguous(file, ds)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%elementSize != 0 {
+		return nil, fmt.Errorf("variable-length string data of %d bytes", len(data))
+	}
+	heaps := make(map[uint64]map[uint32][]byte)
+	values := make([]string, len(data)/elementSize)
+	for i := range values {
+		element := data[i*elementSize:]
+		length := binary.LittleEndian.Uint32(element)
+		address := readUint(element[4:], h.offsetSize)
+		index := binary.LittleEndian.Uint32(element[4+h.offsetSize:])
+		if length == 0 || address == 0 {
+			continue // Empty or unset string
+		}
+		objects, ok := heaps[address]
+		if !ok {
+			if objects, err = h.globalHeap(address, &budget); err != nil {
+				return nil, err
+			}
+			heaps[address] = objects
+		}
+		object, ok := objects[index]
+		if !ok || uint64(len(object)) < uint64(length) {
+			return nil, fmt.Errorf("string %d: global heap object %d at 0x%X missing or short", i, index, address)
+		}
+		values[i] = string(object[:length])
+	}
+	return values, nil
+}
+
+// globalHeap reads the objects of the global heap collection at address,
+// keyed by their index, taking its size from the bytes left in budget.
+func (h headerReader) globalHeap(address uint64, budget *int64) (map[uint32][]byte, error) {
+	// Signature "GCOL" (4), version (1), reserved (3), collection size
+	head, err := h.read(address, 8+h.lengthSize)
+	if err != nil {
+		return nil, err
+	}
+	if string(head[:4]) != "GCOL" {
+		return nil, fmt.Errorf("invalid global heap signature at 0x%X", address)
+	}
+	size := readUint(head[8:], h.lengthSize)
+	if size < uint64(len(head)) {
+		return nil, fmt.Errorf("global heap collection at 0x%X has invalid size %d", address, size)
+	}
+	if size > uint64(max(*budget, 0)) {
+		return nil, fmt.Errorf("global heap collection at 0x%X of %d bytes exceeds the %d bytes left to read", address, size, *budget)
+	}
+	*budget -= int64(size)                        //nolint:gosec // G115: bounded above
+	collection, err := h.read(address, int(size)) //nolint:gosec // G115: bounded above
+	if err != nil {
+		return nil, err
+	}
+
+	// Objects: index (2), reference count (2), reserved (4), size, data
+	// padded to 8 bytes. Index 0 is the free space at the end.
+	objects := make(map[uint32][]byte)
+	for pos := len(head); pos+8+h.lengthSize <= len(collection); {
+		index := binary.LittleEndian.Uint16(collection[pos:])
+		n := readUint(collection[pos+8:], h.lengthSize)
+		pos += 8 + h.lengthSize
+		if index == 0 {
+			break
+		}
+		if n > uint64(len(collection)-pos) {
+			return nil, fmt.Errorf("global heap object %d at 0x%X overruns its collection", index, address)
+		}
+		objects[uint32(index)] = collection[pos : pos+int(n)] //nolint:gosec // G115: bounded above
+		pos += int((n + 7) &^ 7)                              //nolint:gosec // G115: bounded above
+	}
+	return objects, nil
+}
+
+// varLenStringVariable returns the variable holding strings read from a
+// variable-length string dataset: a char row vector for a scalar
+// dataset, and a cellstr of char row vectors with the dataset's
+// dimensions otherwise.
+func varLenStringVariable(dataset *hdf5.Dataset, values []string) *types.Variable {
+	if node := describeDataset(dataset, dataset.Name()); len(node.Dims) == 0 && len(values) == 1 {
+		return charRow(values[0])
+	}
+	cells := make([]*types.Variable, len(values))
+	for i, s := range values {
+		cells[i] = charRow(s)
+	}
+	return &types.Variable{
+		Dimensions: datasetDims(dataset, []int{len(values)}),
+		DataType:   types.CellArray,
+		Data:       cells,
+	}
+}
+
+// charRow returns s as a char row vector, or as a 0x0 char array if s
+// is empty, as MATLAB stores empty strings.
+func charRow(s string) *types.Variable {
+	n := len(utf16.Encode([]rune(s)))
+	dims := []int{1, n}
+	if n == 0 {
+		dims = []int{0, 0}
+	}
+	return &types.Variable{Dimensions: dims, DataType: types.Char, Data: s}
+}
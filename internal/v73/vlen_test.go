@@ -0,0 +1,123 @@
+package v73
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/hdf5"
+	"github.com/scigolib/matlab/types"
+)
+
+// writeVarLenStrings creates an HDF5 file with a dataset of
+// variable-length strings laid out as the HDF5 library writes them. The
+// hdf5 package stores the datatype and the elements differently, so its
+// output is patched: the datatype becomes class 9 with a 1-byte string
+// base type, and each element its length, heap address and index.
+func writeVarLenStrings(t *testing.T, name string, dims []uint64, values []string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "labels.h5")
+	fw, err := hdf5.CreateForWrite(path, hdf5.CreateTruncate)
+	if err != nil {
+		t.Fatalf("CreateForWrite() error = %v", err)
+	}
+	ds, err := fw.CreateDataset("/"+name, hdf5.VLenString, dims)
+	if err != nil {
+		t.Fatalf("CreateDataset() error = %v", err)
+	}
+	if err := ds.Write(values); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	file := openHDF5(t, path)
+	address, size, err := contiguousExtent(findObject(file, name).(*hdf5.Dataset))
+	file.Close()
+	if err != nil || size != int64(16*len(values)) {
+		t.Fatalf("contiguousExtent() = %d, %d, %v", address, size, err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := []byte{0x90, 0, 0, 0, 16, 0, 0, 0}
+	at := bytes.Index(content, written)
+	if at < 0 {
+		t.Fatalf("variable-length datatype % x not found", written)
+	}
+	copy(content[at:], []byte{0x19, 0x01, 0x01, 0, 16, 0, 0, 0, 0x13, 0, 0, 0, 1, 0, 0, 0})
+	for i, s := range values {
+		element := content[address+int64(16*i):]
+		heapAddress, index := binary.LittleEndian.Uint64(element), binary.LittleEndian.Uint32(element[8:])
+		binary.LittleEndian.PutUint32(element, uint32(len(s))) //nolint:gosec // G115: short test strings
+		binary.LittleEndian.PutUint64(element[4:], heapAddress)
+		binary.LittleEndian.PutUint32(element[12:], index)
+	}
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConvertToMatlab_VarLenStrings(t *testing.T) {
+	values := []string{"a", "bb", "température", "", "x", "Δt"}
+	file := openHDF5(t, writeVarLenStrings(t, "labels", []uint64{3, 2}, values))
+	defer file.Close()
+
+	variables, err := NewHDF5Adapter(file).ConvertToMatlab()
+	if err != nil {
+		t.Fatalf("ConvertToMatlab() error = %v", err)
+	}
+	if len(variables) != 1 {
+		t.Fatalf("got %d variables, want 1", len(variables))
+	}
+	v := variables[0]
+	if v.Name != "labels" || v.DataType != types.CellArray || !reflect.DeepEqual(v.Dimensions, []int{2, 3}) {
+		t.Fatalf("variable = %s %v %v, want a 2x3 cell array", v.Name, v.DataType, v.Dimensions)
+	}
+	cells, ok := v.Data.([]*types.Variable)
+	if !ok || len(cells) != len(values) {
+		t.Fatalf("Data = %T of %v, want %d cells", v.Data, v.Data, len(values))
+	}
+	for i, cell := range cells {
+		if cell.DataType != types.Char || cell.Data != values[i] {
+			t.Errorf("cell %d = %v %q, want char %q", i, cell.DataType, cell.Data, values[i])
+		}
+	}
+	if !reflect.DeepEqual(cells[2].Dimensions, []int{1, 11}) || !reflect.DeepEqual(cells[3].Dimensions, []int{0, 0}) {
+		t.Errorf("cell dimensions = %v, %v, want [1 11], [0 0]", cells[2].Dimensions, cells[3].Dimensions)
+	}
+}
+
+func TestReadVarLenStrings_OtherDatatype(t *testing.T) {
+	path := writeTestFile(t, &types.Variable{
+		Name: "x", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2},
+	})
+	file := openHDF5(t, path)
+	defer file.Close()
+	if _, err := readVarLenStrings(file, findObject(file, "x").(*hdf5.Dataset), maxGlobalHeapBytes); !errors.Is(err, errNotVarLenString) {
+		t.Errorf("readVarLenStrings(x) error = %v, want errNotVarLenString", err)
+	}
+}
+
+func TestReadVarLenStrings_HeapBudget(t *testing.T) {
+	values := []string{"a", "bb", "ccc"}
+	file := openHDF5(t, writeVarLenStrings(t, "labels", []uint64{3}, values))
+	defer file.Close()
+	ds := findObject(file, "labels").(*hdf5.Dataset)
+
+	got, err := readVarLenStrings(file, ds, maxGlobalHeapBytes)
+	if err != nil || !reflect.DeepEqual(got, values) {
+		t.Fatalf("readVarLenStrings() = %q, %v, want %q", got, err, values)
+	}
+	// The collection is larger than its few objects, and counts in full
+	if _, err := readVarLenStrings(file, ds, 64); err == nil {
+		t.Error("readVarLenStrings(64-byte budget) succeeded")
+	}
+}
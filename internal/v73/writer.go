@@ -4,6 +4,12 @@ package v73
 import (
 	"fmt"
 	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/scigolib/hdf5"
 	"github.com/scigolib/matlab/types"
@@ -16,6 +22,28 @@ import (
 // attribute indicating the original MATLAB type.
 type Writer struct {
 	file *hdf5.FileWriter
+
+	// Now, if set, is called once per variable and its result is stored
+	// in the variable's creation_time attribute (RFC 3339, UTC).
+	Now func() time.Time
+
+	// ObjectClasses, if set, writes struct variables whose MATLAB_class
+	// attribute names another class as objects of that class: groups
+	// with that MATLAB_class and MATLAB_object_decode
+	// types.ObjectDecodeStruct, which MATLAB loads as instances of the
+	// class with the fields as properties.
+	ObjectClasses bool
+
+	filename     string          // Path of the file, for resolving references on Close
+	refs         int             // Values written to RefsGroup so far
+	references   []references    // Reference datasets to fill in on Close
+	groups       map[string]bool // Namespace groups created so far
+	variablePath string          // Path of the variable being written
+}
+
+// attributeWriter is implemented by HDF5 datasets and groups being written.
+type attributeWriter interface {
+	WriteAttribute(name string, value interface{}) error
 }
 
 // NewWriter creates a new v7.3 writer.
@@ -36,7 +64,7 @@ func NewWriter(filename string) (*Writer, error) {
 		return nil, fmt.Errorf("failed to create HDF5 file: %w", err)
 	}
 
-	return &Writer{file: file}, nil
+	return &Writer{file: file, filename: filename, groups: make(map[string]bool)}, nil
 }
 
 // WriteVariable writes a MATLAB variable as HDF5 dataset with proper attributes.
@@ -56,25 +84,100 @@ func NewWriter(filename string) (*Writer, error) {
 //
 // Supported types:
 //   - Double, Single, Int8, Uint8, Int16, Uint16, Int32, Uint32, Int64, Uint64
+//   - Logical ([]bool, stored as uint8)
+//   - Char (string, stored as uint16 UTF-16 code units)
 //   - Complex numbers (stored as HDF5 groups with /real and /imag datasets)
+//   - Scalar structs (*types.StructArray, stored as HDF5 groups with one
+//     child per field)
 func (w *Writer) WriteVariable(v *types.Variable) error {
 	// Check for nil first
 	if v == nil {
 		return fmt.Errorf("variable cannot be nil")
 	}
 
+	w.variablePath = "/" + v.Name
+	return w.writeVariableAt(w.variablePath, v)
+}
+
+// WriteVariableInGroup writes a variable inside a namespace group.
+//
+// The group path is slash-separated ("run42" or "run42/trial1"). Missing
+// groups are created on first use as HDF5 groups with MATLAB_class
+// "struct", so MATLAB loads each namespace as a struct whose fields are
+// the variables written into it.
+//
+// Parameters:
+//   - group: Group path relative to the root
+//   - v: Variable to write (must not be nil)
+//
+// Returns:
+//   - error: If the group path is invalid, or validation or writing fails
+func (w *Writer) WriteVariableInGroup(group string, v *types.Variable) error {
+	if v == nil {
+		return fmt.Errorf("variable cannot be nil")
+	}
+
+	groupPath, err := w.ensureGroup(group)
+	if err != nil {
+		return err
+	}
+
+	w.variablePath = groupPath + "/" + v.Name
+	return w.writeVariableAt(w.variablePath, v)
+}
+
+// ensureGroup creates the namespace groups along a slash-separated path
+// and returns its absolute HDF5 path.
+func (w *Writer) ensureGroup(group string) (string, error) {
+	if group == "" {
+		return "", fmt.Errorf("group name is required")
+	}
+
+	if w.groups == nil {
+		w.groups = make(map[string]bool)
+	}
+
+	path := ""
+	for _, name := range strings.Split(group, "/") {
+		if name == "" {
+			return "", fmt.Errorf("invalid group path %q: empty segment", group)
+		}
+		path += "/" + name
+		if w.groups[path] {
+			continue
+		}
+
+		g, err := w.file.CreateGroup(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to create group %q: %w", path, err)
+		}
+		if err := g.WriteAttribute(types.AttrMatlabClass, matlabClassStruct); err != nil {
+			return "", fmt.Errorf("failed to write MATLAB_class attribute: %w", err)
+		}
+		w.groups[path] = true
+	}
+	return path, nil
+}
+
+// writeVariableAt validates v and writes it at the given HDF5 path.
+func (w *Writer) writeVariableAt(path string, v *types.Variable) error {
 	// Validate input
 	if err := w.validateVariable(v); err != nil {
 		return fmt.Errorf("invalid variable: %w", err)
 	}
 
+	// Structs are groups with one child per field
+	if v.DataType == types.Struct {
+		return w.writeStructVariable(path, v)
+	}
+
 	// Handle complex numbers separately (group structure with nested datasets)
 	if v.IsComplex {
-		return w.writeComplexVariable(v)
+		return w.writeComplexVariable(path, v)
 	}
 
 	// Write as regular dataset
-	return w.writeSimpleVariable(v)
+	return w.writeSimpleVariable(path, v)
 }
 
 // validateVariable checks if variable has all required fields.
@@ -82,33 +185,165 @@ func (w *Writer) validateVariable(v *types.Variable) error {
 	if v.Name == "" {
 		return fmt.Errorf("variable name is required")
 	}
+	// Names are HDF5 link names: UTF-8 without path separators
+	if !utf8.ValidString(v.Name) {
+		return fmt.Errorf("variable name %q is not valid UTF-8", v.Name)
+	}
+	if n := types.NameLength(v.Name); n > types.MaxNameLength {
+		return fmt.Errorf("variable name too long (max %d characters): %d", types.MaxNameLength, n)
+	}
+	if strings.ContainsAny(v.Name, "/\x00") {
+		return fmt.Errorf("variable name %q contains '/' or a NUL byte", v.Name)
+	}
 	if len(v.Dimensions) == 0 {
 		return fmt.Errorf("variable dimensions are required")
 	}
 	if v.Data == nil {
 		return fmt.Errorf("variable data is required")
 	}
+	if v.IsSparse {
+		return fmt.Errorf("sparse matrices are not supported in v7.3 files (use v5, or ToDense)")
+	}
 
-	// Validate dimensions are positive and check for overflow
-	total := int64(1)
+	// Validate dimensions are positive and check for overflow. HDF5
+	// dataspaces use 64-bit dimensions, so the only limit is that the
+	// element count fits in a uint64.
+	total := uint64(1)
 	for i, d := range v.Dimensions {
 		if d <= 0 {
 			return fmt.Errorf("dimension[%d] must be positive, got %d", i, d)
 		}
 
 		// Check for overflow before multiplying
-		if d > 0 && total > math.MaxInt/int64(d) {
+		if total > math.MaxUint64/uint64(d) {
 			return fmt.Errorf("dimensions overflow (total elements too large): %v", v.Dimensions)
 		}
 
-		total *= int64(d)
+		total *= uint64(d)
 	}
 
 	return nil
 }
 
+// sliceTypes maps the numeric classes to the Go slice type the HDF5
+// library accepts for their datasets.
+var sliceTypes = map[types.DataType]reflect.Type{
+	types.Double: reflect.TypeOf([]float64(nil)),
+	types.Single: reflect.TypeOf([]float32(nil)),
+	types.Int8:   reflect.TypeOf([]int8(nil)),
+	types.Uint8:  reflect.TypeOf([]uint8(nil)),
+	types.Int16:  reflect.TypeOf([]int16(nil)),
+	types.Uint16: reflect.TypeOf([]uint16(nil)),
+	types.Int32:  reflect.TypeOf([]int32(nil)),
+	types.Uint32: reflect.TypeOf([]uint32(nil)),
+	types.Int64:  reflect.TypeOf([]int64(nil)),
+	types.Uint64: reflect.TypeOf([]uint64(nil)),
+}
+
+// Validate checks v as WriteVariable would, without writing anything:
+// its name, dimensions and class, and that its data has the Go type the
+// class requires and one element per array element. Struct fields are
+// checked recursively.
+//
+// HDF5 objects cannot be removed once created, so callers writing a batch
+// validate every variable first.
+func (w *Writer) Validate(v *types.Variable) error {
+	if v == nil {
+		return fmt.Errorf("variable cannot be nil")
+	}
+	if err := w.validateVariable(v); err != nil {
+		return fmt.Errorf("invalid variable: %w", err)
+	}
+
+	switch {
+	case v.DataType == types.Struct:
+		return w.validateStruct(v)
+	case v.IsComplex:
+		numArray, ok := v.Data.(*types.NumericArray)
+		if !ok {
+			return fmt.Errorf("complex variable must have *types.NumericArray data, got %T", v.Data)
+		}
+		if err := checkData(v.DataType, numArray.Real, v.Dimensions); err != nil {
+			return fmt.Errorf("real part: %w", err)
+		}
+		if err := checkData(v.DataType, numArray.Imag, v.Dimensions); err != nil {
+			return fmt.Errorf("imaginary part: %w", err)
+		}
+		return nil
+	default:
+		return checkData(v.DataType, v.Data, v.Dimensions)
+	}
+}
+
+// validateStruct checks the fields of a scalar struct variable.
+func (w *Writer) validateStruct(v *types.Variable) error {
+	st, ok := v.Data.(*types.StructArray)
+	if !ok {
+		return fmt.Errorf("expected *types.StructArray for Struct, got %T", v.Data)
+	}
+	if len(st.Elements) != 1 {
+		return fmt.Errorf("only scalar structs are supported, got %d elements", len(st.Elements))
+	}
+	if len(st.Elements[0]) != len(st.Fields) {
+		return fmt.Errorf("struct has %d fields but %d values", len(st.Fields), len(st.Elements[0]))
+	}
+	for i, field := range st.Fields {
+		if field == "" {
+			return fmt.Errorf("struct field %d has an empty name", i)
+		}
+		value := st.Elements[0][i]
+		if value == nil {
+			return fmt.Errorf("struct field %q has no value", field)
+		}
+		fieldVar := *value
+		fieldVar.Name = field
+		if err := w.Validate(&fieldVar); err != nil {
+			return fmt.Errorf("field %q: %w", field, err)
+		}
+	}
+	return nil
+}
+
+// checkData checks that data has the Go type of its class and one element
+// per array element.
+func checkData(dataType types.DataType, data interface{}, dims []int) error {
+	want := 1
+	for _, d := range dims {
+		want *= d
+	}
+
+	var count int
+	switch dataType {
+	case types.Logical:
+		values, ok := data.([]bool)
+		if !ok {
+			return fmt.Errorf("expected []bool for Logical, got %T", data)
+		}
+		count = len(values)
+	case types.Char:
+		str, ok := data.(string)
+		if !ok {
+			return fmt.Errorf("expected string for Char, got %T", data)
+		}
+		count = len(utf16.Encode([]rune(str)))
+	default:
+		sliceType, ok := sliceTypes[dataType]
+		if !ok {
+			return fmt.Errorf("unsupported MATLAB data type: %v", dataType)
+		}
+		if reflect.TypeOf(data) != sliceType {
+			return fmt.Errorf("expected %v for %v, got %T", sliceType, dataType, data)
+		}
+		count = reflect.ValueOf(data).Len()
+	}
+	if count != want {
+		return fmt.Errorf("data has %d elements, dimensions %v require %d", count, dims, want)
+	}
+	return nil
+}
+
 // writeSimpleVariable writes non-complex variable as HDF5 dataset.
-func (w *Writer) writeSimpleVariable(v *types.Variable) error {
+func (w *Writer) writeSimpleVariable(path string, v *types.Variable) error {
 	// Step 1: Convert dimensions to uint64 (HDF5 API requirement)
 	dims := make([]uint64, len(v.Dimensions))
 	for i, d := range v.Dimensions {
@@ -117,6 +352,9 @@ func (w *Writer) writeSimpleVariable(v *types.Variable) error {
 		}
 		dims[i] = uint64(d)
 	}
+	// HDF5 dataspaces list dimensions slowest-varying first, the reverse
+	// of MATLAB's column-major order
+	slices.Reverse(dims)
 
 	// Step 2: Map MATLAB type to HDF5 datatype
 	hdf5Type, err := w.dataTypeToHDF5(v.DataType)
@@ -125,25 +363,235 @@ func (w *Writer) writeSimpleVariable(v *types.Variable) error {
 	}
 
 	// Step 3: Create dataset using HDF5 API
-	dataset, err := w.file.CreateDataset("/"+v.Name, hdf5Type, dims)
+	// Step 4: Convert data to its storage representation
+	data := v.Data
+	switch v.DataType {
+	case types.Logical:
+		boolData, ok := v.Data.([]bool)
+		if !ok {
+			return fmt.Errorf("expected []bool for Logical, got %T", v.Data)
+		}
+		data = boolToUint8(boolData)
+	case types.Char:
+		str, ok := v.Data.(string)
+		if !ok {
+			return fmt.Errorf("expected string for Char, got %T", v.Data)
+		}
+		data = utf16.Encode([]rune(str))
+	}
+
+	dataset, err := w.file.CreateDataset(path, hdf5Type, dims)
 	if err != nil {
 		return fmt.Errorf("failed to create dataset: %w", err)
 	}
 
-	// Step 4: Write data
-	if err := dataset.Write(v.Data); err != nil {
+	// Step 5: Write data
+	if err := dataset.Write(data); err != nil {
 		return fmt.Errorf("failed to write data: %w", err)
 	}
 
-	// Step 5: Add MATLAB_class attribute
+	// Step 6: Add MATLAB_class attribute
 	matlabClass := w.dataTypeToMatlabClass(v.DataType)
-	if err := dataset.WriteAttribute("MATLAB_class", matlabClass); err != nil {
+	if err := dataset.WriteAttribute(types.AttrMatlabClass, matlabClass); err != nil {
 		return fmt.Errorf("failed to write MATLAB_class attribute: %w", err)
 	}
+	if err := w.stampCreationTime(path, dataset); err != nil {
+		return err
+	}
+	if err := w.writeUserAttributes(path, dataset, v); err != nil {
+		return err
+	}
+
+	// Logical and char arrays are stored as integers; MATLAB_int_decode
+	// tells MATLAB how to interpret them.
+	decode := int32(0)
+	switch v.DataType {
+	case types.Logical:
+		decode = types.IntDecodeLogical
+	case types.Char:
+		decode = types.IntDecodeChar
+	}
+	if decode != 0 {
+		if err := dataset.WriteAttribute(types.AttrMatlabIntDecode, decode); err != nil {
+			return fmt.Errorf("failed to write MATLAB_int_decode attribute: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// WriteExtendable writes a real numeric variable as a chunked dataset whose
+// last dimension (the first of the dataspace) is unlimited, so that it can
+// be extended in place (see Extend), as time series are grown by
+// appending samples. Chunks hold chunk elements along the last dimension,
+// also when the variable is shorter.
+//
+// Parameters:
+//   - v: Variable to write (must not be nil, complex or a struct)
+//   - chunk: Chunk length along the last dimension (must be positive)
+//
+// Returns:
+//   - error: If validation or writing fails
+func (w *Writer) WriteExtendable(v *types.Variable, chunk int) error {
+	if v == nil {
+		return fmt.Errorf("variable cannot be nil")
+	}
+	if chunk <= 0 {
+		return fmt.Errorf("chunk length must be positive, got %d", chunk)
+	}
+	if err := w.Validate(v); err != nil {
+		return err
+	}
+	if v.IsComplex || v.DataType == types.Struct || v.DataType == types.Logical || v.DataType == types.Char {
+		return fmt.Errorf("extendable datasets hold real numeric data, got %v", v.DataType)
+	}
+
+	hdf5Type, err := w.dataTypeToHDF5(v.DataType)
+	if err != nil {
+		return fmt.Errorf("unsupported data type: %w", err)
+	}
+	last := len(v.Dimensions) - 1
+	dims := make([]uint64, len(v.Dimensions))
+	maxDims := make([]uint64, len(v.Dimensions))
+	chunkDims := make([]uint64, len(v.Dimensions))
+	for i, d := range v.Dimensions {
+		dims[i], maxDims[i], chunkDims[i] = uint64(d), uint64(d), uint64(d)
+	}
+	maxDims[last] = hdf5.Unlimited
+	chunkDims[last] = uint64(chunk)
+	slices.Reverse(dims)
+	slices.Reverse(maxDims)
+	slices.Reverse(chunkDims)
+
+	// The HDF5 library needs at least a chunk of data and stores the last
+	// chunk short: the variable is written padded to whole chunks and cut
+	// to its length afterwards
+	data := v.Data
+	padded := slices.Clone(dims)
+	if rem := v.Dimensions[last] % chunk; rem != 0 {
+		length := v.Dimensions[last] + chunk - rem
+		padded[0] = uint64(length)
+		values := reflect.ValueOf(v.Data)
+		n := values.Len() / v.Dimensions[last] * length
+		pad := reflect.MakeSlice(values.Type(), n, n)
+		reflect.Copy(pad, values)
+		data = pad.Interface()
+	}
+
+	w.variablePath = "/" + v.Name
+	dataset, err := w.file.CreateDataset(w.variablePath, hdf5Type, padded,
+		hdf5.WithChunkDims(chunkDims), hdf5.WithMaxDims(maxDims))
+	if err != nil {
+		return fmt.Errorf("failed to create dataset: %w", err)
+	}
+	// Attributes go first: the HDF5 library corrupts the chunk index of a
+	// dataset whose attributes are written after its data
+	if err := dataset.WriteAttribute(types.AttrMatlabClass, w.dataTypeToMatlabClass(v.DataType)); err != nil {
+		return fmt.Errorf("failed to write MATLAB_class attribute: %w", err)
+	}
+	if err := w.stampCreationTime(w.variablePath, dataset); err != nil {
+		return err
+	}
+	if err := w.writeUserAttributes(w.variablePath, dataset, v); err != nil {
+		return err
+	}
+	if err := dataset.Write(data); err != nil {
+		return fmt.Errorf("failed to write data: %w", err)
+	}
+	if padded[0] != dims[0] {
+		if err := dataset.Resize(dims); err != nil {
+			return fmt.Errorf("failed to set dimensions: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeStructVariable writes a scalar struct as an HDF5 group.
+//
+// The group carries MATLAB_class "struct", or the object class (see
+// ObjectClasses), and holds one child dataset or group per field, named
+// after the field. Only 1x1 structs are supported.
+func (w *Writer) writeStructVariable(path string, v *types.Variable) error {
+	st, ok := v.Data.(*types.StructArray)
+	if !ok {
+		return fmt.Errorf("expected *types.StructArray for Struct, got %T", v.Data)
+	}
+	if len(st.Elements) != 1 {
+		return fmt.Errorf("only scalar structs are supported, got %d elements", len(st.Elements))
+	}
+	if len(st.Elements[0]) != len(st.Fields) {
+		return fmt.Errorf("struct has %d fields but %d values", len(st.Fields), len(st.Elements[0]))
+	}
+
+	class := matlabClassStruct
+	if w.ObjectClasses {
+		if name, ok := v.GetStringAttr(types.AttrMatlabClass); ok && name != "" {
+			class = name
+		}
+	}
+
+	group, err := w.file.CreateGroup(path)
+	if err != nil {
+		return fmt.Errorf("failed to create group for struct: %w", err)
+	}
+	if err := group.WriteAttribute(types.AttrMatlabClass, class); err != nil {
+		return fmt.Errorf("failed to write MATLAB_class attribute: %w", err)
+	}
+	if class != matlabClassStruct {
+		if err := group.WriteAttribute(types.AttrMatlabObjectDecode, types.ObjectDecodeStruct); err != nil {
+			return fmt.Errorf("failed to write MATLAB_object_decode attribute: %w", err)
+		}
+	}
+	if err := w.stampCreationTime(path, group); err != nil {
+		return err
+	}
+	if err := w.writeUserAttributes(path, group, v); err != nil {
+		return err
+	}
+
+	for i, field := range st.Fields {
+		if field == "" {
+			return fmt.Errorf("struct field %d has an empty name", i)
+		}
+		value := st.Elements[0][i]
+		if value == nil {
+			return fmt.Errorf("struct field %q has no value", field)
+		}
+		fieldVar := *value
+		fieldVar.Name = field
+		if err := w.writeVariableAt(path+"/"+field, &fieldVar); err != nil {
+			return fmt.Errorf("field %q: %w", field, err)
+		}
+	}
 
 	return nil
 }
 
+// stampCreationTime writes the creation_time attribute on the variable
+// being written when w.Now is set. Struct fields and complex parts are
+// not stamped.
+func (w *Writer) stampCreationTime(path string, obj attributeWriter) error {
+	if w.Now == nil || path != w.variablePath {
+		return nil
+	}
+	stamp := w.Now().UTC().Format(time.RFC3339Nano)
+	if err := obj.WriteAttribute(AttrCreationTime, stamp); err != nil {
+		return fmt.Errorf("failed to write %s attribute: %w", AttrCreationTime, err)
+	}
+	return nil
+}
+
+// boolToUint8 converts logical values to the uint8 storage MATLAB uses.
+func boolToUint8(data []bool) []uint8 {
+	result := make([]uint8, len(data))
+	for i, val := range data {
+		if val {
+			result[i] = 1
+		}
+	}
+	return result
+}
+
 // writeComplexVariable writes complex variable in proper MATLAB v7.3 format.
 //
 // MATLAB v7.3 stores complex numbers as HDF5 groups with nested datasets:
@@ -152,7 +600,7 @@ func (w *Writer) writeSimpleVariable(v *types.Variable) error {
 //   - /imag (dataset containing imaginary part)
 //
 // This matches the standard MATLAB format specification for HDF5-based .mat files.
-func (w *Writer) writeComplexVariable(v *types.Variable) error {
+func (w *Writer) writeComplexVariable(path string, v *types.Variable) error {
 	// Extract real and imaginary parts
 	numArray, ok := v.Data.(*types.NumericArray)
 	if !ok {
@@ -171,6 +619,7 @@ func (w *Writer) writeComplexVariable(v *types.Variable) error {
 		}
 		dims[i] = uint64(d)
 	}
+	slices.Reverse(dims)
 
 	// Map MATLAB type to HDF5 datatype
 	hdf5Type, err := w.dataTypeToHDF5(v.DataType)
@@ -179,25 +628,31 @@ func (w *Writer) writeComplexVariable(v *types.Variable) error {
 	}
 
 	// Step 1: Create group for variable
-	group, err := w.file.CreateGroup("/" + v.Name)
+	group, err := w.file.CreateGroup(path)
 	if err != nil {
 		return fmt.Errorf("failed to create group for complex variable: %w", err)
 	}
 
 	// Step 2: Write MATLAB metadata to group
 	matlabClass := w.dataTypeToMatlabClass(v.DataType)
-	if err := group.WriteAttribute("MATLAB_class", matlabClass); err != nil {
+	if err := group.WriteAttribute(types.AttrMatlabClass, matlabClass); err != nil {
 		return fmt.Errorf("failed to write MATLAB_class attribute: %w", err)
 	}
 
 	// MATLAB_complex attribute indicates this is a complex number
-	if err := group.WriteAttribute("MATLAB_complex", uint8(1)); err != nil {
+	if err := group.WriteAttribute(types.AttrMatlabComplex, uint8(1)); err != nil {
 		return fmt.Errorf("failed to write MATLAB_complex attribute: %w", err)
 	}
+	if err := w.stampCreationTime(path, group); err != nil {
+		return err
+	}
+	if err := w.writeUserAttributes(path, group, v); err != nil {
+		return err
+	}
 
 	// Step 3: Create nested datasets for real/imag parts
-	realPath := "/" + v.Name + "/real"
-	imagPath := "/" + v.Name + "/imag"
+	realPath := path + "/real"
+	imagPath := path + "/imag"
 
 	realDataset, err := w.file.CreateDataset(realPath, hdf5Type, dims)
 	if err != nil {
@@ -230,11 +685,11 @@ func (w *Writer) dataTypeToHDF5(dt types.DataType) (hdf5.Datatype, error) {
 		return hdf5.Float32, nil
 	case types.Int8:
 		return hdf5.Int8, nil
-	case types.Uint8:
+	case types.Uint8, types.Logical:
 		return hdf5.Uint8, nil
 	case types.Int16:
 		return hdf5.Int16, nil
-	case types.Uint16:
+	case types.Uint16, types.Char:
 		return hdf5.Uint16, nil
 	case types.Int32:
 		return hdf5.Int32, nil
@@ -277,6 +732,10 @@ func (w *Writer) dataTypeToMatlabClass(dt types.DataType) string {
 		return "uint64"
 	case types.Char:
 		return "char"
+	case types.Struct:
+		return matlabClassStruct
+	case types.Logical:
+		return matlabClassBool
 	default:
 		return matlabClassDouble // Default fallback
 	}
@@ -293,6 +752,9 @@ func (w *Writer) Close() error {
 	if w.file != nil {
 		err := w.file.Close()
 		w.file = nil // Mark as closed
+		if err == nil && len(w.references) > 0 {
+			err = w.resolveReferences()
+		}
 		return err
 	}
 	return nil
@@ -5,7 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/scigolib/hdf5"
 	"github.com/scigolib/matlab/types"
 )
 
@@ -520,7 +522,6 @@ func TestDataTypeToHDF5_Unsupported(t *testing.T) {
 		dataType types.DataType
 	}{
 		{"Unknown", types.Unknown},
-		{"Char", types.Char},
 		{"Struct", types.Struct},
 		{"CellArray", types.CellArray},
 		{"Object", types.Object},
@@ -568,7 +569,7 @@ func TestDataTypeToMatlabClass_Default(t *testing.T) {
 }
 
 // TestWriteSimpleVariable_UnsupportedType tests that writing a variable with
-// an unsupported data type (e.g. Char) returns an error from dataTypeToHDF5.
+// an unsupported data type (e.g. Object) returns an error from dataTypeToHDF5.
 func TestWriteSimpleVariable_UnsupportedType(t *testing.T) {
 	tmpDir := t.TempDir()
 	tmpFile := filepath.Join(tmpDir, "test.mat")
@@ -580,15 +581,15 @@ func TestWriteSimpleVariable_UnsupportedType(t *testing.T) {
 	defer writer.Close()
 
 	v := &types.Variable{
-		Name:       "text",
+		Name:       "obj",
 		Dimensions: []int{5},
-		DataType:   types.Char,
+		DataType:   types.Object,
 		Data:       []byte("hello"),
 	}
 
 	err = writer.WriteVariable(v)
 	if err == nil {
-		t.Error("WriteVariable() expected error for unsupported Char type, got nil")
+		t.Error("WriteVariable() expected error for unsupported Object type, got nil")
 	}
 }
 
@@ -705,7 +706,7 @@ func TestWriteComplexVariable_AllNumericTypes(t *testing.T) {
 }
 
 // TestWriteComplexVariable_UnsupportedType tests that writing a complex variable
-// with an unsupported data type (e.g. Char) returns an error.
+// with an unsupported data type (e.g. Object) returns an error.
 func TestWriteComplexVariable_UnsupportedType(t *testing.T) {
 	tmpDir := t.TempDir()
 	tmpFile := filepath.Join(tmpDir, "test.mat")
@@ -719,7 +720,7 @@ func TestWriteComplexVariable_UnsupportedType(t *testing.T) {
 	v := &types.Variable{
 		Name:       "z",
 		Dimensions: []int{2},
-		DataType:   types.Char,
+		DataType:   types.Object,
 		IsComplex:  true,
 		Data: &types.NumericArray{
 			Real: []byte{1, 2},
@@ -729,7 +730,7 @@ func TestWriteComplexVariable_UnsupportedType(t *testing.T) {
 
 	err = writer.WriteVariable(v)
 	if err == nil {
-		t.Error("WriteVariable() expected error for unsupported Char complex type, got nil")
+		t.Error("WriteVariable() expected error for unsupported Object complex type, got nil")
 	}
 }
 
@@ -780,9 +781,19 @@ func TestValidateDimensions_Overflow(t *testing.T) {
 			dims:        []int{1000000},
 			expectError: false,
 		},
+		{
+			name:        "beyond 2^31 elements",
+			dims:        []int{1 << 16, 1 << 16},
+			expectError: false,
+		},
+		{
+			name:        "beyond int64 but within uint64",
+			dims:        []int{math.MaxInt / 2, 3},
+			expectError: false,
+		},
 		{
 			name:        "overflow with huge dimensions",
-			dims:        []int{math.MaxInt / 2, 3}, // (MaxInt/2) * 3 overflows
+			dims:        []int{math.MaxInt / 2, 5}, // (MaxInt/2) * 5 overflows uint64
 			expectError: true,
 		},
 		{
@@ -840,3 +851,224 @@ func TestValidateDimensions_Overflow(t *testing.T) {
 		})
 	}
 }
+
+// TestWriteVariable_CreationTime tests that Writer.Now stamps top-level
+// variables with a creation_time attribute.
+func TestWriteVariable_CreationTime(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.mat")
+	writer, err := NewWriter(tmpFile)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	stamp := time.Date(2024, 3, 1, 12, 30, 0, 500, time.FixedZone("CET", 3600))
+	writer.Now = func() time.Time { return stamp }
+
+	if err := writer.WriteVariable(&types.Variable{
+		Name: "x", Dimensions: []int{2}, DataType: types.Double, Data: []float64{1, 2},
+	}); err != nil {
+		t.Fatalf("WriteVariable failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	file := openHDF5(t, tmpFile)
+	defer file.Close()
+	variables, err := NewHDF5Adapter(file).ConvertToMatlab()
+	if err != nil {
+		t.Fatalf("ConvertToMatlab() error = %v", err)
+	}
+
+	value, ok := variables[0].GetStringAttr(AttrCreationTime)
+	if !ok {
+		t.Fatalf("creation_time attribute missing: %v", variables[0].Attributes)
+	}
+	if value != "2024-03-01T11:30:00.0000005Z" {
+		t.Errorf("creation_time = %v", value)
+	}
+}
+
+// TestWriteVariableInGroup tests namespace group creation and reuse.
+func TestWriteVariableInGroup(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.mat")
+	writer, err := NewWriter(tmpFile)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	writer.Now = func() time.Time { return time.Unix(0, 0) }
+
+	for _, name := range []string{"a", "b"} {
+		if err := writer.WriteVariableInGroup("run/trial", &types.Variable{
+			Name: name, Dimensions: []int{1}, DataType: types.Double, Data: []float64{1},
+		}); err != nil {
+			t.Fatalf("WriteVariableInGroup(%s) failed: %v", name, err)
+		}
+	}
+	if !writer.groups["/run"] || !writer.groups["/run/trial"] {
+		t.Errorf("groups = %v", writer.groups)
+	}
+	if err := writer.WriteVariableInGroup("run", nil); err == nil {
+		t.Error("expected error for nil variable")
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	file := openHDF5(t, tmpFile)
+	defer file.Close()
+	variables, err := NewHDF5Adapter(file).ConvertToMatlab()
+	if err != nil {
+		t.Fatalf("ConvertToMatlab() error = %v", err)
+	}
+	if len(variables) != 1 || variables[0].Name != "run" {
+		t.Fatalf("variables = %v, want one struct named run", variables)
+	}
+	trial := variables[0].Data.(*types.StructArray).Field(0, "trial")
+	if trial == nil || len(trial.Data.(*types.StructArray).Fields) != 2 {
+		t.Fatalf("run.trial = %v", trial)
+	}
+	if _, ok := trial.Data.(*types.StructArray).Field(0, "a").Attributes[AttrCreationTime]; !ok {
+		t.Error("grouped variable missing creation_time")
+	}
+}
+
+func TestWriter_Validate(t *testing.T) {
+	w := &Writer{}
+	field := &types.Variable{Dimensions: []int{1, 1}, DataType: types.Uint8, Data: []uint8{1}}
+	valid := []*types.Variable{
+		{Name: "x", Dimensions: []int{2, 2}, DataType: types.Double, Data: []float64{1, 2, 3, 4}},
+		{Name: "s", Dimensions: []int{1, 2}, DataType: types.Char, Data: "ab"},
+		{Name: "m", Dimensions: []int{1, 1}, DataType: types.Logical, Data: []bool{true}},
+		{Name: "z", Dimensions: []int{1, 2}, DataType: types.Int16, IsComplex: true,
+			Data: &types.NumericArray{Real: []int16{1, 2}, Imag: []int16{3, 4}}},
+		{Name: "st", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+			Fields: []string{"f"}, Dimensions: []int{1, 1}, Elements: [][]*types.Variable{{field}},
+		}},
+	}
+	for _, v := range valid {
+		if err := w.Validate(v); err != nil {
+			t.Errorf("Validate(%s) error = %v", v.Name, err)
+		}
+	}
+
+	bad := &types.Variable{Dimensions: []int{1, 1}, DataType: types.Uint8, Data: []int8{1}}
+	invalid := []*types.Variable{
+		nil,
+		{Name: "", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}},
+		{Name: "type", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float32{1, 2}},
+		{Name: "count", Dimensions: []int{2, 2}, DataType: types.Double, Data: []float64{1, 2, 3}},
+		{Name: "text", Dimensions: []int{1, 3}, DataType: types.Char, Data: "ab"},
+		{Name: "imag", Dimensions: []int{1, 2}, DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1, 2}, Imag: []float64{1}}},
+		{Name: "cell", Dimensions: []int{1, 1}, DataType: types.CellArray, Data: []interface{}{1}},
+		{Name: "st", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+			Fields: []string{"f"}, Dimensions: []int{1, 1}, Elements: [][]*types.Variable{{bad}},
+		}},
+	}
+	for _, v := range invalid {
+		if err := w.Validate(v); err == nil {
+			t.Errorf("Validate(%v) error = nil", v)
+		}
+	}
+}
+
+// TestWriter_MatlabAttributes checks that every MATLAB attribute the
+// writer creates has the value type given in the types package.
+func TestWriter_MatlabAttributes(t *testing.T) {
+	tmpFile := writeTestFile(t,
+		&types.Variable{Name: "d", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}},
+		&types.Variable{Name: "l", Dimensions: []int{1, 2}, DataType: types.Logical, Data: []bool{true, false}},
+		&types.Variable{Name: "c", Dimensions: []int{1, 2}, DataType: types.Char, Data: "ab"},
+		&types.Variable{Name: "z", Dimensions: []int{1, 1}, DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1}, Imag: []float64{2}}},
+	)
+	file := openHDF5(t, tmpFile)
+	defer file.Close()
+
+	seen := make(map[string]bool)
+	check := func(path, name string, value interface{}, err error) {
+		seen[name] = true
+		if err != nil {
+			return // The HDF5 library cannot read back 1-byte integer attributes
+		}
+		if err := types.CheckMatlabAttr(name, value); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+	file.Walk(func(path string, obj hdf5.Object) {
+		switch o := obj.(type) {
+		case *hdf5.Dataset:
+			attrs, err := o.Attributes()
+			if err != nil {
+				t.Fatalf("%s: Attributes() error = %v", path, err)
+			}
+			for _, attr := range attrs {
+				value, err := attr.ReadValue()
+				check(path, attr.Name, value, err)
+			}
+		case *hdf5.Group:
+			attrs, err := o.Attributes()
+			if err != nil {
+				t.Fatalf("%s: Attributes() error = %v", path, err)
+			}
+			for _, attr := range attrs {
+				value, err := attr.ReadValue()
+				check(path, attr.Name, value, err)
+			}
+		}
+	})
+	for _, name := range []string{types.AttrMatlabClass, types.AttrMatlabIntDecode, types.AttrMatlabComplex} {
+		if !seen[name] {
+			t.Errorf("no %s attribute written", name)
+		}
+	}
+}
+
+func TestWriter_ObjectClasses(t *testing.T) {
+	point := &types.Variable{
+		Name:       "p",
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     []string{"x"},
+			Elements:   [][]*types.Variable{{{Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{2}}}},
+			Dimensions: []int{1, 1},
+		},
+		Attributes: map[string]interface{}{types.AttrMatlabClass: "geom.Point"},
+	}
+
+	for _, objects := range []bool{false, true} {
+		tmpFile := filepath.Join(t.TempDir(), "test.mat")
+		writer, err := NewWriter(tmpFile)
+		if err != nil {
+			t.Fatalf("NewWriter failed: %v", err)
+		}
+		writer.ObjectClasses = objects
+		if err := writer.WriteVariable(point); err != nil {
+			t.Fatalf("WriteVariable failed: %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		file := openHDF5(t, tmpFile)
+		variables, err := NewHDF5Adapter(file).ConvertToMatlab()
+		_ = file.Close()
+		if err != nil {
+			t.Fatalf("ConvertToMatlab() error = %v", err)
+		}
+		if len(variables) != 1 || variables[0].DataType != types.Struct {
+			t.Fatalf("ObjectClasses=%v: variables = %v, want one struct", objects, variables)
+		}
+		want := "struct"
+		if objects {
+			want = "geom.Point"
+		}
+		if got := variables[0].ClassName(); got != want {
+			t.Errorf("ObjectClasses=%v: ClassName() = %q, want %q", objects, got, want)
+		}
+		if x := variables[0].Data.(*types.StructArray).Field(0, "x"); x == nil {
+			t.Errorf("ObjectClasses=%v: field x missing", objects)
+		}
+	}
+}
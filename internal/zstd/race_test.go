@@ -0,0 +1,11 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build race
+
+package zstd
+
+// raceEnabled stands in for internal/race.Enabled, which cannot be
+// imported outside the standard library.
+const raceEnabled = true
@@ -0,0 +1,90 @@
+package matlab
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/scigolib/matlab/internal/v5"
+)
+
+// LoadVariableInto reads the variable name from the MAT-file in r into
+// dst, a caller-provided slice of the variable's Go element type
+// ([]float64 for double, []int32 for int32, []bool for logical, ...) with
+// exactly one element per array element. Reusing dst across calls avoids
+// allocating data buffers when the same field is read from many files.
+//
+// In v5 files the variable is decoded straight from the stream into dst
+// and all other variables are skipped without decoding; reading stops
+// once the variable is found. v7.3 files are read in full and the data is
+// copied, so they gain nothing over Open.
+//
+// Only real numeric and logical arrays are supported. It returns
+// ErrVariableNotFound if there is no such variable and
+// ErrDestinationMismatch if dst has the wrong type or length.
+//
+// Example:
+//
+//	buf := make([]float64, 1000)
+//	for _, path := range paths {
+//	    file, _ := os.Open(path)
+//	    err := matlab.LoadVariableInto(file, "signal", buf)
+//	    file.Close()
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    total += sum(buf)
+//	}
+func LoadVariableInto(r io.Reader, name string, dst interface{}) error {
+	src, err := newSource(r)
+	if err != nil {
+		return err
+	}
+	defer src.close()
+
+	if isHDF5Format(src.header) {
+		matFile, err := parseV73(src.r, defaultOpenConfig(), "")
+		if err != nil {
+			return err
+		}
+		return copyInto(matFile, name, dst)
+	}
+	if !isV5Format(src.header) {
+		return ErrInvalidFormat
+	}
+
+	parser, err := v5.NewParser(src.r)
+	if err != nil {
+		return err
+	}
+	found, err := parser.ReadInto(name, dst)
+	if err != nil {
+		return fmt.Errorf("variable %s: %w", name, err)
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrVariableNotFound, name)
+	}
+	return nil
+}
+
+// copyInto copies the data of a parsed variable into dst.
+func copyInto(matFile *MatFile, name string, dst interface{}) error {
+	v := matFile.GetVariable(name)
+	if v == nil {
+		return fmt.Errorf("%w: %s", ErrVariableNotFound, name)
+	}
+	src := reflect.ValueOf(v.Data)
+	if v.IsComplex || src.Kind() != reflect.Slice {
+		return fmt.Errorf("cannot read %s %s into a slice: only real numeric and logical arrays are supported",
+			name, v.DataType)
+	}
+	value := reflect.ValueOf(dst)
+	if !value.IsValid() || value.Type() != src.Type() {
+		return fmt.Errorf("%w: %s needs %v, got %T", ErrDestinationMismatch, name, src.Type(), dst)
+	}
+	if value.Len() != src.Len() {
+		return fmt.Errorf("%w: %s has %d elements, destination has %d", ErrDestinationMismatch, name, src.Len(), value.Len())
+	}
+	reflect.Copy(value, src)
+	return nil
+}
@@ -0,0 +1,114 @@
+package matlab
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// writeIntoFile writes a 100x100 double "signal" after an int32 "counts"
+// variable and returns the file contents.
+func writeIntoFile(t *testing.T, version Version, opts ...Option) []byte {
+	t.Helper()
+	signal := make([]float64, 10000)
+	for i := range signal {
+		signal[i] = float64(i) / 3
+	}
+	path := filepath.Join(t.TempDir(), "into.mat")
+	writer, err := Create(path, version, opts...)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	vars := []*types.Variable{
+		{Name: "counts", Dimensions: []int{1, 3}, DataType: types.Int32, Data: []int32{1, -2, 3}},
+		{Name: "signal", Dimensions: []int{100, 100}, DataType: types.Double, Data: signal},
+	}
+	for _, v := range vars {
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestLoadVariableInto(t *testing.T) {
+	files := map[string][]byte{
+		"v5":            writeIntoFile(t, Version5),
+		"v5 compressed": writeIntoFile(t, Version5, WithCompression(6)),
+		"v7.3":          writeIntoFile(t, Version73),
+	}
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			matFile, err := Open(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+
+			signal := make([]float64, 10000)
+			if err := LoadVariableInto(bytes.NewReader(data), "signal", signal); err != nil {
+				t.Fatalf("LoadVariableInto(signal) error = %v", err)
+			}
+			if !reflect.DeepEqual(signal, matFile.GetVariable("signal").Data) {
+				t.Error("signal differs from Open")
+			}
+
+			counts := make([]int32, 3)
+			if err := LoadVariableInto(bytes.NewReader(data), "counts", counts); err != nil || !reflect.DeepEqual(counts, []int32{1, -2, 3}) {
+				t.Errorf("LoadVariableInto(counts) = %v, %v", counts, err)
+			}
+
+			if err := LoadVariableInto(bytes.NewReader(data), "missing", signal); !errors.Is(err, ErrVariableNotFound) {
+				t.Errorf("missing: error = %v", err)
+			}
+			if err := LoadVariableInto(bytes.NewReader(data), "counts", make([]int64, 3)); !errors.Is(err, ErrDestinationMismatch) {
+				t.Errorf("wrong type: error = %v", err)
+			}
+			if err := LoadVariableInto(bytes.NewReader(data), "signal", make([]float64, 10)); !errors.Is(err, ErrDestinationMismatch) {
+				t.Errorf("wrong length: error = %v", err)
+			}
+		})
+	}
+
+	if err := LoadVariableInto(bytes.NewReader(make([]byte, 128)), "x", nil); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("invalid file: error = %v", err)
+	}
+}
+
+// TestLoadVariableInto_Allocations tests that reading a v5 variable does
+// not allocate a buffer for its data.
+func TestLoadVariableInto_Allocations(t *testing.T) {
+	data := writeIntoFile(t, Version5)
+	signal := make([]float64, 10000)
+	load := func() {
+		if err := LoadVariableInto(bytes.NewReader(data), "signal", signal); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load() // warm up pools
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	const runs = 10
+	for i := 0; i < runs; i++ {
+		load()
+	}
+	runtime.ReadMemStats(&after)
+
+	// The data alone is 80000 bytes
+	if perRun := (after.TotalAlloc - before.TotalAlloc) / runs; perRun > 8000 {
+		t.Errorf("allocated %d bytes per read", perRun)
+	}
+}
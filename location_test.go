@@ -0,0 +1,82 @@
+package matlab
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestVariable_Location(t *testing.T) {
+	values := []float64{1.5, 2.5, 3.5}
+	for _, tt := range []struct {
+		name    string
+		version Version
+		opts    []Option
+	}{
+		{"v5", Version5, nil},
+		{"v5 compressed", Version5, []Option{WithCompression(6)}},
+		{"v7.3", Version73, nil},
+	} {
+		path := filepath.Join(t.TempDir(), "loc.mat")
+		w, err := Create(path, tt.version, tt.opts...)
+		if err != nil {
+			t.Fatalf("%s: Create() error = %v", tt.name, err)
+		}
+		for _, name := range []string{"a", "b"} {
+			if err := w.WriteVariable(&types.Variable{Name: name, Dimensions: []int{1, 3}, DataType: types.Double, Data: values}); err != nil {
+				t.Fatalf("%s: WriteVariable() error = %v", tt.name, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: Close() error = %v", tt.name, err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matFile, err := openPath(path, nil)
+		if err != nil {
+			t.Fatalf("%s: Open() error = %v", tt.name, err)
+		}
+
+		for _, v := range matFile.Variables {
+			loc, ok := v.Location()
+			if !ok {
+				t.Fatalf("%s: %s has no location", tt.name, v.Name)
+			}
+			if loc.Offset < 0 || loc.Offset+loc.Length > int64(len(content)) {
+				t.Fatalf("%s: %s location %+v outside the file of %d bytes", tt.name, v.Name, loc, len(content))
+			}
+			stored := content[loc.Offset : loc.Offset+loc.Length]
+
+			if tt.version == Version73 {
+				if loc.Address == 0 || loc.Compressed {
+					t.Errorf("%s: %s location = %+v, want an address", tt.name, v.Name, loc)
+				}
+				data := make([]byte, 8*len(values))
+				for i, x := range values {
+					binary.LittleEndian.PutUint64(data[8*i:], math.Float64bits(x))
+				}
+				if !bytes.Equal(stored, data) {
+					t.Errorf("%s: %s stored data = %v, want %v", tt.name, v.Name, stored, data)
+				}
+				continue
+			}
+			tag, want := binary.LittleEndian.Uint32(stored), uint32(14) // miMATRIX
+			if loc.Compressed {
+				want = 15 // miCOMPRESSED
+			}
+			if tag != want || loc.Compressed != (tt.opts != nil) {
+				t.Errorf("%s: %s element type = %d (compressed %v), want %d", tt.name, v.Name, tag, loc.Compressed, want)
+			}
+			if size := int64(binary.LittleEndian.Uint32(stored[4:])); size+8 > loc.Length {
+				t.Errorf("%s: %s element of %d bytes longer than location %+v", tt.name, v.Name, size, loc)
+			}
+		}
+	}
+}
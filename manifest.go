@@ -0,0 +1,310 @@
+package matlab
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// ManifestVariable is the name of the struct variable in which
+// WriteSection and WithManifest record the variables of a file.
+const ManifestVariable = "__manifest__"
+
+// ErrNoManifest indicates a file without a manifest variable.
+var ErrNoManifest = errors.New("MAT-file has no manifest")
+
+// ErrManifestMismatch indicates a variable whose value no longer matches
+// the checksum recorded in the manifest.
+var ErrManifestMismatch = errors.New("variable does not match manifest")
+
+// manifestFields are the fields of the manifest struct. Each is a char
+// matrix with one row per recorded variable, padded with trailing spaces
+// as MATLAB's char function pads rows, so cellstr(__manifest__.name)
+// lists the names in MATLAB.
+var manifestFields = []string{"name", "section", "version", "sha256"}
+
+// Manifest lists the variables of a file written with WriteSection or
+// WithManifest, grouped into named sections.
+type Manifest struct {
+	Entries []ManifestEntry // In the order the variables were written
+}
+
+// ManifestEntry records one variable of a manifest.
+type ManifestEntry struct {
+	Name    string // Variable name
+	Section string // Section the variable belongs to, "" for none
+	Version string // Version of the section's contents, "" for none
+	SHA256  string // Hex SHA-256 of the value, "" for structs and other unhashed classes
+}
+
+// Sections returns the names of the sections of m in the order they were
+// first written. Variables outside any section are not listed.
+func (m *Manifest) Sections() []string {
+	var sections []string
+	seen := make(map[string]bool)
+	for _, e := range m.Entries {
+		if e.Section != "" && !seen[e.Section] {
+			seen[e.Section] = true
+			sections = append(sections, e.Section)
+		}
+	}
+	return sections
+}
+
+// Section returns the entries of the named section.
+func (m *Manifest) Section(name string) []ManifestEntry {
+	var entries []ManifestEntry
+	for _, e := range m.Entries {
+		if e.Section == name {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// WithManifest records every top-level variable written in a manifest,
+// which is written as ManifestVariable when the writer is closed. Without
+// the option only variables written with WriteSection are recorded.
+//
+// Example:
+//
+//	writer, _ := matlab.Create("archive.mat", matlab.Version5, matlab.WithManifest())
+func WithManifest() Option {
+	return func(c *config) {
+		c.manifest = true
+	}
+}
+
+// WriteSection writes vars like WriteVariables and records them in the
+// file's manifest as the named section, with a version string for its
+// contents and the SHA-256 of each value. The manifest is written as the
+// struct ManifestVariable when the writer is closed, so archives
+// holding several workspaces or result sets in one file still load in
+// MATLAB; MatFile.Section and MatFile.VerifyManifest use it when reading.
+//
+// Example:
+//
+//	err := writer.WriteSection("calibration", "v2", gain, offset)
+//	err = writer.WriteSection("run1", "", time, voltage)
+func (w *MatFileWriter) WriteSection(section, version string, vars ...*types.Variable) error {
+	w.inSection, w.section, w.sectionVersion = true, section, version
+	defer func() { w.inSection, w.section, w.sectionVersion = false, "", "" }()
+	return w.WriteVariables(vars...)
+}
+
+// recordManifest adds v to the manifest under the section being written,
+// if it is written by WriteSection or WithManifest is set.
+func (w *MatFileWriter) recordManifest(v *types.Variable) {
+	if !w.inSection && !w.manifestAll {
+		return
+	}
+	if w.manifest == nil {
+		w.manifest = &Manifest{}
+	}
+	w.manifest.Entries = append(w.manifest.Entries, ManifestEntry{
+		Name:    v.Name,
+		Section: w.section,
+		Version: w.sectionVersion,
+		SHA256:  manifestChecksum(v),
+	})
+}
+
+// manifestVar returns the manifest of w as a 1x1 struct variable, or nil
+// if no variable was recorded.
+func (w *MatFileWriter) manifestVar() (*types.Variable, error) {
+	if w.manifest == nil || len(w.manifest.Entries) == 0 {
+		return nil, nil
+	}
+	if w.names[ManifestVariable] {
+		return nil, fmt.Errorf("failed to write manifest: %w: %q", ErrDuplicateVariable, ManifestVariable)
+	}
+	columns := make([][]string, len(manifestFields))
+	for _, e := range w.manifest.Entries {
+		for j, value := range []string{e.Name, e.Section, e.Version, e.SHA256} {
+			columns[j] = append(columns[j], value)
+		}
+	}
+	fields := make([]*types.Variable, len(manifestFields))
+	for j, field := range manifestFields {
+		fields[j] = charMatrix(field, columns[j])
+	}
+	return &types.Variable{
+		Name:       ManifestVariable,
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     manifestFields,
+			Elements:   [][]*types.Variable{fields},
+			Dimensions: []int{1, 1},
+		},
+	}, nil
+}
+
+// charMatrix returns a char matrix variable with one row per string,
+// padded with spaces to the longest (at least one column, since empty
+// arrays cannot be written).
+func charMatrix(name string, rows []string) *types.Variable {
+	runes := make([][]rune, len(rows))
+	width := 1
+	for i, row := range rows {
+		runes[i] = []rune(row)
+		width = max(width, len(runes[i]))
+	}
+	// Column-major, as MATLAB stores matrices
+	data := make([]rune, len(rows)*width)
+	for i, row := range runes {
+		for j := 0; j < width; j++ {
+			r := ' '
+			if j < len(row) {
+				r = row[j]
+			}
+			data[j*len(rows)+i] = r
+		}
+	}
+	return &types.Variable{Name: name, Dimensions: []int{len(rows), width}, DataType: types.Char, Data: string(data)}
+}
+
+// manifestRows returns the rows of a manifest char matrix with the
+// padding removed.
+func manifestRows(v *types.Variable) ([]string, bool) {
+	var text string
+	switch d := v.Data.(type) {
+	case string:
+		text = d
+	case *types.CharArray:
+		text = string(d.Data)
+	default:
+		return nil, false
+	}
+	rows := charRows(text, v.Dimensions)
+	for i, row := range rows {
+		rows[i] = strings.TrimRight(row, " ")
+	}
+	return rows, true
+}
+
+// manifestChecksum returns the hex SHA-256 of the value of v as
+// hashValue computes it, with logical data hashed as uint8 so the
+// checksum does not depend on how a format returns logicals, or "" if v
+// cannot be hashed.
+func manifestChecksum(v *types.Variable) string {
+	if data, ok := v.Data.([]bool); ok {
+		bytes := make([]uint8, len(data))
+		for i, b := range data {
+			if b {
+				bytes[i] = 1
+			}
+		}
+		v = &types.Variable{DataType: v.DataType, Data: bytes}
+	}
+	digest, err := hashValue(v, nil)
+	if err != nil {
+		return ""
+	}
+	return digest
+}
+
+// Manifest returns the manifest of a file written with WriteSection or
+// WithManifest, or ErrNoManifest if it has none.
+//
+// Example:
+//
+//	manifest, err := matFile.Manifest()
+//	for _, section := range manifest.Sections() {
+//	    fmt.Println(section, len(manifest.Section(section)))
+//	}
+func (m *MatFile) Manifest() (*Manifest, error) {
+	v := m.GetVariable(ManifestVariable)
+	if v == nil {
+		return nil, ErrNoManifest
+	}
+	st, ok := v.Data.(*types.StructArray)
+	if !ok || len(st.Elements) != 1 {
+		return nil, fmt.Errorf("%s is not a scalar struct", ManifestVariable)
+	}
+	columns := make(map[string][]string, len(st.Fields))
+	n := -1
+	for j, field := range st.Fields {
+		if j >= len(st.Elements[0]) || st.Elements[0][j] == nil {
+			continue
+		}
+		rows, ok := manifestRows(st.Elements[0][j])
+		if !ok {
+			return nil, fmt.Errorf("%s.%s is not a char matrix", ManifestVariable, field)
+		}
+		if n >= 0 && len(rows) != n {
+			return nil, fmt.Errorf("%s.%s has %d rows, want %d", ManifestVariable, field, len(rows), n)
+		}
+		n = len(rows)
+		columns[field] = rows
+	}
+	if columns["name"] == nil {
+		return nil, fmt.Errorf("%s has no name field", ManifestVariable)
+	}
+	manifest := &Manifest{Entries: make([]ManifestEntry, n)}
+	for i := range manifest.Entries {
+		e := &manifest.Entries[i]
+		e.Name = columns["name"][i]
+		if columns["section"] != nil {
+			e.Section = columns["section"][i]
+		}
+		if columns["version"] != nil {
+			e.Version = columns["version"][i]
+		}
+		if columns["sha256"] != nil {
+			e.SHA256 = columns["sha256"][i]
+		}
+	}
+	return manifest, nil
+}
+
+// Section returns the variables of the named manifest section, in the
+// order they were written. Variables listed in the manifest but missing
+// from the file are skipped.
+//
+// Example:
+//
+//	calibration, err := matFile.Section("calibration")
+func (m *MatFile) Section(name string) ([]*types.Variable, error) {
+	manifest, err := m.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	var vars []*types.Variable
+	for _, e := range manifest.Section(name) {
+		if v := m.GetVariable(e.Name); v != nil {
+			vars = append(vars, v)
+		}
+	}
+	return vars, nil
+}
+
+// VerifyManifest checks every variable recorded in the manifest: it must
+// exist and, if a checksum was recorded, its value must still match. The
+// error joins one error per failed variable, wrapping ErrVariableNotFound
+// or ErrManifestMismatch.
+//
+// Example:
+//
+//	if err := matFile.VerifyManifest(); errors.Is(err, matlab.ErrManifestMismatch) {
+//	    log.Fatalf("archive was modified: %v", err)
+//	}
+func (m *MatFile) VerifyManifest() error {
+	manifest, err := m.Manifest()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, e := range manifest.Entries {
+		v := m.GetVariable(e.Name)
+		switch {
+		case v == nil:
+			errs = append(errs, fmt.Errorf("%w: %s", ErrVariableNotFound, e.Name))
+		case e.SHA256 != "" && manifestChecksum(v) != e.SHA256:
+			errs = append(errs, fmt.Errorf("%w: %s", ErrManifestMismatch, e.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,141 @@
+package matlab
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// openPathT opens the MAT-file at path.
+func openPathT(t *testing.T, path string) *MatFile {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	matFile, err := Open(f)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return matFile
+}
+
+func TestWriteSection(t *testing.T) {
+	gain := &types.Variable{Name: "gain", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1.5, 2}}
+	unit := &types.Variable{Name: "unit", Dimensions: []int{1, 1}, DataType: types.Char, Data: "V"}
+	mask := &types.Variable{Name: "mask", Dimensions: []int{1, 3}, DataType: types.Logical, Data: []bool{true, false, true}}
+	notes := &types.Variable{Name: "notes", Dimensions: []int{1, 2}, DataType: types.Char, Data: "ok"}
+
+	for _, version := range []Version{Version5, Version73} {
+		path := filepath.Join(t.TempDir(), "archive.mat")
+		w, err := Create(path, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteSection("calibration", "v2", gain, unit); err != nil {
+			t.Fatalf("v%d: WriteSection() error = %v", version, err)
+		}
+		if err := w.WriteSection("run1", "", mask); err != nil {
+			t.Fatalf("v%d: WriteSection() error = %v", version, err)
+		}
+		if err := w.WriteVariable(notes); err != nil { // Not recorded without WithManifest
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("v%d: Close() error = %v", version, err)
+		}
+
+		matFile := openPathT(t, path)
+		manifest, err := matFile.Manifest()
+		if err != nil {
+			t.Fatalf("v%d: Manifest() error = %v", version, err)
+		}
+		var names []string
+		for _, e := range manifest.Entries {
+			names = append(names, e.Name)
+		}
+		if want := []string{"gain", "unit", "mask"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("v%d: entries = %v, want %v", version, names, want)
+		}
+		if got := manifest.Sections(); !reflect.DeepEqual(got, []string{"calibration", "run1"}) {
+			t.Errorf("v%d: Sections() = %v", version, got)
+		}
+		if e := manifest.Entries[0]; e.Section != "calibration" || e.Version != "v2" || len(e.SHA256) != 64 {
+			t.Errorf("v%d: entry = %+v", version, e)
+		}
+		if e := manifest.Entries[2]; e.Section != "run1" || e.Version != "" {
+			t.Errorf("v%d: entry = %+v", version, e)
+		}
+		section, err := matFile.Section("calibration")
+		if err != nil || len(section) != 2 || section[0].Name != "gain" || section[1].Name != "unit" {
+			t.Errorf("v%d: Section() = %v, %v", version, section, err)
+		}
+		if err := matFile.VerifyManifest(); err != nil {
+			t.Errorf("v%d: VerifyManifest() error = %v", version, err)
+		}
+
+		matFile.GetVariable("gain").Data = []float64{1.5, 3}
+		if err := matFile.VerifyManifest(); !errors.Is(err, ErrManifestMismatch) {
+			t.Errorf("v%d: VerifyManifest() after change error = %v, want ErrManifestMismatch", version, err)
+		}
+	}
+}
+
+func TestWithManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.mat")
+	w, err := Create(path, Version5, WithManifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteVariable(&types.Variable{Name: "x", Dimensions: []int{1, 1}, DataType: types.Int32, Data: []int32{7}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteSection("s", "1", &types.Variable{Name: "y", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	matFile := openPathT(t, path)
+	manifest, err := matFile.Manifest()
+	if err != nil {
+		t.Fatalf("Manifest() error = %v", err)
+	}
+	if len(manifest.Entries) != 2 || manifest.Entries[0].Section != "" || manifest.Entries[1].Section != "s" {
+		t.Errorf("entries = %+v", manifest.Entries)
+	}
+
+	matFile.Variables = matFile.Variables[1:] // Drop x
+	if err := matFile.VerifyManifest(); !errors.Is(err, ErrVariableNotFound) {
+		t.Errorf("VerifyManifest() error = %v, want ErrVariableNotFound", err)
+	}
+}
+
+func TestManifest_Missing(t *testing.T) {
+	matFile := &MatFile{}
+	if _, err := matFile.Manifest(); !errors.Is(err, ErrNoManifest) {
+		t.Errorf("Manifest() error = %v, want ErrNoManifest", err)
+	}
+	if err := matFile.VerifyManifest(); !errors.Is(err, ErrNoManifest) {
+		t.Errorf("VerifyManifest() error = %v, want ErrNoManifest", err)
+	}
+}
+
+func TestWriteSection_NameConflict(t *testing.T) {
+	w, err := Create(filepath.Join(t.TempDir(), "archive.mat"), Version5, WithManifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteVariable(&types.Variable{Name: ManifestVariable, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); !errors.Is(err, ErrDuplicateVariable) {
+		t.Errorf("Close() error = %v, want ErrDuplicateVariable", err)
+	}
+}
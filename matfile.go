@@ -6,6 +6,9 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/scigolib/matlab/internal/v5"
 	"github.com/scigolib/matlab/internal/v73"
@@ -18,38 +21,181 @@ var ErrUnsupportedVersion = errors.New("unsupported MAT-file version")
 // ErrInvalidFormat indicates an invalid MAT-file format.
 var ErrInvalidFormat = errors.New("invalid MAT-file format")
 
+// ErrEmptyFile indicates an input with no content, such as a file that
+// was created but never written.
+var ErrEmptyFile = errors.New("empty file")
+
+// ErrIsDirectory indicates a directory given where a MAT-file was
+// expected.
+var ErrIsDirectory = errors.New("is a directory")
+
+// ErrVariableNotFound indicates that no variable with the requested name exists.
+var ErrVariableNotFound = errors.New("variable not found")
+
+// ErrNotScalarStruct indicates a variable that is not a 1x1 struct.
+var ErrNotScalarStruct = errors.New("variable is not a scalar struct")
+
+// ErrGroupsNotSupported indicates a group write to a format without groups.
+var ErrGroupsNotSupported = errors.New("groups are only supported in v7.3 files")
+
+// ErrWriteRejected indicates a variable rejected by a hook registered
+// with WithWriteHook. The hook's error is wrapped as well.
+var ErrWriteRejected = errors.New("variable rejected by write hook")
+
+// ErrDuplicateVariable indicates a batch passed to WriteVariables that
+// names a variable twice, or names one already written to the file.
+var ErrDuplicateVariable = errors.New("duplicate variable name")
+
+// ErrDestinationMismatch indicates a slice passed to LoadVariableInto
+// whose type or length does not match the variable.
+var ErrDestinationMismatch = v5.ErrDestinationMismatch
+
+// ErrColumnOutOfRange indicates a column passed to GetColumns or
+// LoadColumns that lies outside the matrix.
+var ErrColumnOutOfRange = v5.ErrColumnOutOfRange
+
+// ErrMaxDepthExceeded indicates a v7.3 file whose HDF5 groups are nested
+// deeper than allowed (see WithMaxDepth).
+var ErrMaxDepthExceeded = v73.ErrMaxDepthExceeded
+
+// ErrMaxObjectsExceeded indicates a v7.3 file containing more HDF5 objects
+// than allowed (see WithMaxObjects).
+var ErrMaxObjectsExceeded = v73.ErrMaxObjectsExceeded
+
+// ErrCycleDetected indicates a v7.3 file whose HDF5 group hierarchy links
+// back to one of its own ancestors.
+var ErrCycleDetected = v73.ErrCycleDetected
+
+// ErrDimensionLimit indicates an array whose shape exceeds the limits set
+// with WithMaxRank, WithMaxDimension or WithMaxElements.
+var ErrDimensionLimit = types.ErrDimensionLimit
+
+// ErrInvalidName indicates a name that is not a valid MATLAB identifier
+// (see WithStrictNames), or is wrapped by InvalidNameWarning for a
+// variable renamed while reading.
+var ErrInvalidName = types.ErrInvalidName
+
+// ErrReservedName indicates a variable named after a MATLAB keyword or a
+// commonly used function or constant (see WithStrictReservedNames), or is
+// wrapped by ReservedNameWarning.
+var ErrReservedName = types.ErrReservedName
+
+// Attribute names for v7.3 variable metadata in Variable.Attributes.
+const (
+	// AttrCreationTime is written by WithCreationTime. The value read
+	// back is the RFC 3339 timestamp string.
+	AttrCreationTime = v73.AttrCreationTime
+
+	// AttrModificationTime holds the time.Time recorded in the dataset's
+	// object header, if the writing application tracked times (MATLAB
+	// and the HDF5 library do by default).
+	AttrModificationTime = v73.AttrModificationTime
+
+	// AttrAccessTime, AttrChangeTime and AttrBirthTime hold the further
+	// time.Time values that version 2 object headers may record.
+	AttrAccessTime = v73.AttrAccessTime
+	AttrChangeTime = v73.AttrChangeTime
+	AttrBirthTime  = v73.AttrBirthTime
+
+	// AttrLinkTarget holds the object path a soft or external link points
+	// to, and AttrLinkFile the file of an external link (see
+	// WithSoftLinkResolution).
+	AttrLinkTarget = v73.AttrLinkTarget
+	AttrLinkFile   = v73.AttrLinkFile
+)
+
 // MatFile represents a parsed MAT-file.
 type MatFile struct {
 	Version     string            // MAT-file version (e.g., "5.0", "7.3")
 	Endian      string            // Byte order indicator ("MI" or "IM")
 	Description string            // File description from header
 	Variables   []*types.Variable // List of variables in the file
+	Features    Features          // Format features used by the file
+	Warnings    []error           // Non-fatal problems found while reading, such as *InvalidNameWarning
+	StoredNames map[string]string // Stored names of variables renamed by WithNameNormalization, by new name
+
+	hdf5Tree *HDF5Node             // Raw HDF5 hierarchy (v7.3 only)
+	details  *FormatDetails        // Layout details (v5 only)
+	storage  []*types.VariableInfo // Stored sizes of the variables, in file order
+	stats    Stats                 // Statistics of Open
 }
 
 // Open reads and parses a MAT-file from an io.Reader.
-func Open(r io.Reader) (*MatFile, error) {
-	// Read and check the first 128 bytes to determine format
-	header := make([]byte, 128)
-	if _, err := io.ReadFull(r, header); err != nil {
+//
+// Readers that implement io.ReaderAt, such as *os.File and *bytes.Reader,
+// are read in place if positioned at their start, and left positioned
+// after the bytes read; other readers are consumed as a stream.
+//
+// Returns ErrEmptyFile for input without content and ErrIsDirectory for
+// a directory, with the file name when r is a file.
+//
+// Optional parameters can be provided using functional options:
+//   - WithAllocator(types.Allocator) - v5 memory for decoded numeric data
+//   - WithInterning(*InternPool) - share identical small payloads across files
+//   - WithMaxDepth(int) - v7.3 maximum HDF5 group nesting depth
+//   - WithMaxObjects(int) - v7.3 maximum number of HDF5 objects visited
+//   - WithMaxNameLength(int) - longest variable name kept as stored
+//   - WithMaxRank(int), WithMaxDimension(int), WithMaxElements(int64) - array shape limits
+//   - WithNameNormalization(func) - rename variables, e.g. to lower case
+//   - WithNameSanitizing(bool) - rename variables with unsafe names (default true)
+//   - WithRawBytes() - keep the stored bytes of v7.3 datasets
+//   - WithSoftLinkResolution(bool) - resolve v7.3 soft links (default true)
+//   - WithTempDir(string) - directory for the v7.3 temporary copy
+//   - WithTempPrefix(string) - name prefix of the v7.3 temporary copy
+//   - WithTransform(func) - transform or drop each variable as it is parsed
+func Open(r io.Reader, opts ...OpenOption) (*MatFile, error) {
+	start := time.Now()
+	cfg := defaultOpenConfig()
+	applyOpenOptions(cfg, opts)
+	src, err := newSource(r)
+	if err != nil {
 		return nil, err
 	}
+	defer src.close()
 
-	// Create a MultiReader to re-include the header
-	fullReader := io.MultiReader(bytes.NewReader(header), r)
+	m, err := open(src, cfg)
+	if err != nil {
+		return nil, err
+	}
+	m.stats = m.readStats(time.Since(start), src.bytesRead(), src.path)
+	return m, nil
+}
 
+// open detects the format of src and parses it.
+func open(src *source, cfg *openConfig) (*MatFile, error) {
 	// Check for HDF5 format (MATLAB v7.3+)
-	if isHDF5Format(header) {
-		return parseV73(fullReader)
+	if isHDF5Format(src.header) {
+		return parseV73(src.r, cfg, src.path)
 	}
 
 	// Check for v5 format (MATLAB v5-v7.2)
-	if isV5Format(header) {
-		return parseV5(fullReader)
+	if isV5Format(src.header) {
+		return parseV5(src.r, cfg)
 	}
 
 	return nil, ErrInvalidFormat
 }
 
+// OpenWithTransform reads a MAT-file like Open, applying fn to each
+// variable as it is parsed. It is shorthand for Open with WithTransform.
+//
+// Example:
+//
+//	toSingle := func(v *types.Variable) (*types.Variable, error) {
+//	    if data, ok := v.Data.([]float64); ok {
+//	        single := make([]float32, len(data))
+//	        for i, x := range data {
+//	            single[i] = float32(x)
+//	        }
+//	        v.Data, v.DataType = single, types.Single
+//	    }
+//	    return v, nil
+//	}
+//	matFile, err := matlab.OpenWithTransform(file, toSingle)
+func OpenWithTransform(r io.Reader, fn func(*types.Variable) (*types.Variable, error), opts ...OpenOption) (*MatFile, error) {
+	return Open(r, append(opts, WithTransform(fn))...)
+}
+
 // isHDF5Format checks for HDF5 signature.
 func isHDF5Format(header []byte) bool {
 	// HDF5 signature: 0x89 0x48 0x44 0x46 0x0d 0x0a 0x1a 0x0a
@@ -64,39 +210,100 @@ func isV5Format(header []byte) bool {
 }
 
 // parseV5 parses v5 format MAT-files.
-func parseV5(r io.Reader) (*MatFile, error) {
+func parseV5(r io.Reader, cfg *openConfig) (*MatFile, error) {
 	parser, err := v5.NewParser(r)
 	if err != nil {
 		return nil, err
 	}
+	parser.Transform = cfg.transform()
+	parser.Allocator = cfg.allocator
+	parser.DimLimits = cfg.dimLimits
+	parser.Locations = true
 
 	v5File, err := parser.Parse()
 	if err != nil {
 		return nil, err
 	}
 
-	return &MatFile{
+	m := &MatFile{
 		Version:     "5.0",
 		Endian:      v5File.Header.EndianIndicator,
 		Description: v5File.Header.Description,
 		Variables:   v5File.Variables,
-	}, nil
+		storage:     v5File.Storage,
+		details:     formatDetails(v5File),
+		Warnings:    cfg.warnings,
+		StoredNames: cfg.storedNames,
+		Features: Features{
+			Compressed:   v5File.Features.Compressed,
+			UnicodeChars: v5File.Features.UnicodeChars,
+		},
+	}
+	applyAttributesVariable(m)
+	return m, nil
 }
 
-// parseV73 parses v7.3 format MAT-files (HDF5-based).
-func parseV73(r io.Reader) (*MatFile, error) {
-	parser := v73.NewParser()
+// parseV73 parses v7.3 format MAT-files (HDF5-based). If path is not
+// empty, it names a regular file with the same content as r.
+func parseV73(r io.Reader, cfg *openConfig, path string) (*MatFile, error) {
+	parser := newV73Parser(cfg, path)
+	parser.FlattenStructs = cfg.flattenGroups
+	parser.Transform = cfg.transform()
 	variables, err := parser.Parse(r)
 	if err != nil {
 		return nil, err
 	}
 
 	return &MatFile{
-		Version:   "7.3",
-		Variables: variables,
+		Version:     "7.3",
+		Variables:   variables,
+		Features:    Features{HDF5: true},
+		Warnings:    cfg.warnings,
+		StoredNames: cfg.storedNames,
+		hdf5Tree:    parser.Tree,
+		storage:     v73.VariablesFromTree(parser.Tree),
 	}, nil
 }
 
+// newV73Parser creates a v7.3 parser configured from the open options.
+func newV73Parser(cfg *openConfig, path string) *v73.Parser {
+	parser := v73.NewParser()
+	parser.Limits = v73.Limits{
+		MaxDepth:   cfg.maxDepth,
+		MaxObjects: cfg.maxObjects,
+	}
+	parser.TempDir = cfg.tempDir
+	parser.TempPrefix = cfg.tempPrefix
+	parser.ResolveSoftLinks = !cfg.keepSoftLinks
+	parser.KeepRaw = cfg.keepRaw
+	parser.DimLimits = cfg.dimLimits
+	parser.SourcePath = path
+	return parser
+}
+
+// regularFilePath returns the name of the file r reads from if r is an
+// *os.File positioned at the start of a regular file that can be reopened
+// by that name, so the HDF5 library can read it in place. Otherwise it
+// returns "".
+func regularFilePath(r io.Reader) string {
+	f, ok := r.(*os.File)
+	if !ok {
+		return ""
+	}
+	if pos, err := f.Seek(0, io.SeekCurrent); err != nil || pos != 0 {
+		return ""
+	}
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	named, err := os.Stat(f.Name())
+	if err != nil || !os.SameFile(info, named) {
+		return ""
+	}
+	return f.Name()
+}
+
 // GetVariable retrieves a variable by name.
 // Returns nil if the variable is not found.
 //
@@ -107,13 +314,42 @@ func parseV73(r io.Reader) (*MatFile, error) {
 //	if data != nil {
 //	    fmt.Println("Found:", data.Name)
 //	}
+//
+// Slash-separated names address variables inside v7.3 groups: "run42/x"
+// finds field x of the struct variable run42, or the flattened variable
+// "/run42/x" when the file was opened with WithFlattenGroups.
 func (m *MatFile) GetVariable(name string) *types.Variable {
 	for _, v := range m.Variables {
 		if v.Name == name {
 			return v
 		}
 	}
-	return nil
+	if !strings.Contains(name, "/") {
+		return nil
+	}
+
+	// Flattened variables are named by their absolute HDF5 path
+	path := "/" + strings.TrimPrefix(name, "/")
+	for _, v := range m.Variables {
+		if v.Name == path {
+			return v
+		}
+	}
+
+	// Walk struct fields
+	parts := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	v := m.GetVariable(parts[0])
+	for _, field := range parts[1:] {
+		if v == nil {
+			return nil
+		}
+		st, ok := v.Data.(*types.StructArray)
+		if !ok || len(st.Elements) != 1 {
+			return nil
+		}
+		v = st.Field(0, field)
+	}
+	return v
 }
 
 // GetVariableNames returns the names of all variables in the file.
@@ -308,6 +308,69 @@ func TestOpen_GeneratedHDF5Files(t *testing.T) {
 	}
 }
 
+// TestOpen_V5EndianFiles tests that v5 files in both byte orders, from this
+// writer and from MATLAB, carry the spec endian indicator and read back.
+func TestOpen_V5EndianFiles(t *testing.T) {
+	tests := []struct {
+		path       string
+		wantEndian string
+		wantVar    string
+	}{
+		{filepath.Join("testdata", "generated", "endian_le_v5.mat"), "IM", "A"},
+		{filepath.Join("testdata", "generated", "endian_be_v5.mat"), "MI", "A"},
+		{filepath.Join("testdata", "scipy", "testdouble_7.4_GLNX86.mat"), "IM", "testdouble"},
+	}
+
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.path), func(t *testing.T) {
+			file, err := os.Open(tt.path)
+			if err != nil {
+				t.Fatalf("Failed to open: %v", err)
+			}
+			defer file.Close()
+
+			matFile, err := Open(file)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			if matFile.Endian != tt.wantEndian {
+				t.Errorf("Endian = %q, want %q", matFile.Endian, tt.wantEndian)
+			}
+
+			v := matFile.GetVariable(tt.wantVar)
+			if v == nil {
+				t.Fatalf("variable %q not found", tt.wantVar)
+			}
+			if data, ok := v.Data.([]float64); !ok || len(data) == 0 {
+				t.Errorf("Data = %#v, want non-empty []float64", v.Data)
+			}
+		})
+	}
+
+	// Both byte orders must decode to the same values.
+	var values [2][]float64
+	for i, name := range []string{"endian_le_v5.mat", "endian_be_v5.mat"} {
+		file, err := os.Open(filepath.Join("testdata", "generated", name))
+		if err != nil {
+			t.Fatalf("Failed to open: %v", err)
+		}
+		matFile, err := Open(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", name, err)
+		}
+		values[i], _ = matFile.GetVariable("A").Data.([]float64)
+	}
+	if len(values[0]) != 6 || len(values[1]) != 6 {
+		t.Fatalf("unexpected lengths %d and %d", len(values[0]), len(values[1]))
+	}
+	for i := range values[0] {
+		if values[0][i] != values[1][i] {
+			t.Errorf("element %d: little-endian %v, big-endian %v", i, values[0][i], values[1][i])
+		}
+	}
+}
+
 // TestOpen_V73File tests writing a v73 file then reading it back via Open.
 func TestOpen_V73File(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -1,10 +1,18 @@
 package matlab
 
 import (
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha512"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/scigolib/matlab/internal/v5"
 	"github.com/scigolib/matlab/internal/v73"
@@ -32,13 +40,46 @@ const (
 type MatFileWriter struct {
 	filename string
 	version  Version
+	hooks    []func(*types.Variable) error
+	strict   bool            // Reject reserved names (WithStrictReservedNames)
+	names    map[string]bool // Top-level variables written so far
+	warnings []error         // Variables with reserved names, see Warnings
+
+	manifest       *Manifest         // Variables recorded for ManifestVariable, nil if none are
+	manifestAll    bool              // Record every variable (WithManifest), not only sections
+	attributesAll  bool              // Record attributes for AttributesVariable (WithAttributesVariable)
+	attributes     []*types.Variable // Attributes of each recorded variable, as structs
+	inSection      bool              // WriteSection is writing
+	section        string            // Manifest section being written by WriteSection
+	sectionVersion string            // Version of that section
+
+	maxVariables int   // Top-level variables a file may hold, 0 for no limit (WithMaxVariables)
+	maxFileSize  int64 // Size after which writes are refused, 0 for no limit (WithMaxFileSize)
+
+	derived  []derivedVariable          // Variables written at Close (WithDerived)
+	operands map[string]*types.Variable // Variables written so far that derived ones use
+
+	normalizeScalars bool        // Write [] and [1] scalars as 1x1
+	orientation      Orientation // Shape of 1-D vectors
+	stats            Stats       // Statistics of the writes so far
+
+	downcast          bool               // Write doubles as singles (WithDowncastToSingle)
+	downcastTolerance float64            // Relative tolerance of the downcast
+	pendingDowncast   map[string]float64 // Downcast variables of the write in progress
+
+	encryptionKey []byte  // Key of the encrypted envelope, nil for plain files
+	encryptTo     string  // Destination of an encrypted v7.3 file, written at Close from filename
+	gzip          bool    // Compress the file at Close (v7.3)
+	signer        *signer // Signs the file at Close, nil for unsigned files
 
 	// v7.3 specific
 	v73writer *v73.Writer
 
 	// v5 specific
 	v5writer *v5.Writer
-	v5file   *os.File
+	v5file   *os.File       // nil for NewWriter
+	v5enc    *encryptWriter // nil for plain files
+	v5gzip   *gzip.Writer   // nil for uncompressed files
 }
 
 // Create creates a new MATLAB file for writing with optional configuration.
@@ -49,7 +90,28 @@ type MatFileWriter struct {
 // Supported options:
 //   - WithEndianness(binary.ByteOrder) - v5 byte order (default: LittleEndian)
 //   - WithDescription(string) - v5 file description (max 116 bytes)
-//   - WithCompression(int) - compression level 0-9 (not yet implemented)
+//   - WithCompression(int) - v5 compression level 0-9 (default: none)
+//   - WithMatlabV7Compatibility() - v5 files as MATLAB's default save writes them
+//   - WithCreationTime() - v7.3 creation_time attribute on each variable
+//   - WithWriteHook(func) - validate each variable before it is written
+//   - WithStrictNames() - accept only valid MATLAB identifiers as names
+//   - WithScalarNormalization(bool) - write [] and [1] scalars as 1x1 (default: true)
+//   - WithVectorOrientation(Orientation) - write [n] vectors as rows or columns
+//   - WithCreateDirs() - create missing parent directories
+//   - WithNoClobber() - fail if the file already exists
+//   - WithBackup() - rename an existing file to <name>.bak first
+//   - WithChunkSize(int), WithRateLimit(int), WithContext(ctx) - chunked v5 output
+//   - WithEncryption(key) - AES-GCM envelope, read with OpenEncrypted
+//   - WithGzip() - gzip-compressed file, read transparently by Open
+//   - WithSignature(key) - Ed25519 signature, checked with Verify
+//   - WithManifest() - record every variable in ManifestVariable
+//   - WithAttributesVariable() - store v5 variable attributes in AttributesVariable
+//   - WithObjectClasses() - v7.3 structs naming another class as objects
+//   - WithDowncastToSingle(rtol) - write doubles as singles within rtol
+//   - WithMaxVariables(n), WithMaxFileSize(size) - refuse writes to a full file (see RollingWriter)
+//   - WithDerived(name, expression) - write a variable computed from others at Close
+//
+// Slashes in filename are accepted as separators on every platform.
 //
 // Example (basic):
 //
@@ -73,64 +135,272 @@ func Create(filename string, version Version, opts ...Option) (*MatFileWriter, e
 	cfg := defaultConfig()
 	applyOptions(cfg, opts)
 
+	if err := checkKeys(cfg); err != nil {
+		return nil, err
+	}
+	if err := parseDerived(cfg.derived); err != nil {
+		return nil, err
+	}
+
+	filename = filepath.Clean(filepath.FromSlash(filename))
+	if err := prepareDir(filepath.Dir(filename), cfg.createDirs); err != nil {
+		return nil, err
+	}
+	if err := protectExisting(filename, cfg); err != nil {
+		return nil, err
+	}
+
 	// Create based on version
+	var w *MatFileWriter
+	var err error
 	switch version {
 	case Version73:
-		return createV73(filename, cfg)
+		w, err = createV73(filename, cfg)
 	case Version5:
-		return createV5(filename, cfg)
+		w, err = createV5(filename, cfg)
 	default:
 		return nil, fmt.Errorf("unsupported MAT-file version: %d", version)
 	}
+	if err != nil {
+		return nil, err
+	}
+	w.hooks = cfg.writeHooks
+	w.strict = cfg.strictReservedNames
+	w.normalizeScalars = cfg.normalizeScalars
+	w.orientation = cfg.orientation
+	w.downcast, w.downcastTolerance = cfg.downcast, cfg.downcastTolerance
+	w.encryptionKey = cfg.encryptionKey
+	w.gzip = cfg.gzip
+	w.manifestAll = cfg.manifest
+	w.attributesAll = cfg.attributesVariable
+	w.maxVariables, w.maxFileSize = cfg.maxVariables, cfg.maxFileSize
+	w.derived = cfg.derived
+	if cfg.signingKey != nil && w.signer == nil {
+		w.signer = &signer{key: cfg.signingKey}
+	}
+	return w, nil
+}
+
+// checkKeys validates the keys of WithEncryption and WithSignature, and
+// their combination with the other options.
+func checkKeys(cfg *config) error {
+	if cfg.encryptionKey != nil {
+		if _, err := newGCM(cfg.encryptionKey); err != nil {
+			return err
+		}
+	}
+	if cfg.signingKey != nil {
+		if len(cfg.signingKey) != ed25519.PrivateKeySize {
+			return fmt.Errorf("signing key has %d bytes, want %d", len(cfg.signingKey), ed25519.PrivateKeySize)
+		}
+		if cfg.encryptionKey != nil {
+			return errors.New("WithSignature and WithEncryption cannot be combined")
+		}
+		if cfg.gzip {
+			return errors.New("WithSignature and WithGzip cannot be combined")
+		}
+	}
+	return nil
+}
+
+// prepareDir checks that the directory a file is created in exists, or
+// creates it if create is set. The HDF5 library and os.Create report a
+// missing directory less clearly.
+func prepareDir(dir string, create bool) error {
+	if create {
+		if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // G301: output directories are meant to be shared
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		return nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("directory %s (see WithCreateDirs): %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	return nil
+}
+
+// protectExisting applies WithNoClobber and WithBackup to an existing
+// file.
+func protectExisting(filename string, cfg *config) error {
+	if !cfg.noClobber && !cfg.backup {
+		return nil
+	}
+	info, err := os.Lstat(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check %s: %w", filename, err)
+	}
+	if cfg.noClobber {
+		return fmt.Errorf("%s (WithNoClobber): %w", filename, fs.ErrExist)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", filename)
+	}
+	if err := os.Rename(filename, filename+".bak"); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", filename, err)
+	}
+	return nil
 }
 
 // createV73 creates a v7.3 format writer with configuration.
 func createV73(filename string, cfg *config) (*MatFileWriter, error) {
-	// Note: v73 doesn't use endianness or description (HDF5 handles that)
-	_ = cfg // Avoid unused parameter warning
+	encryptTo := ""
+	if cfg.encryptionKey != nil {
+		// The HDF5 library writes plain text: keep it away from the
+		// destination until Close encrypts it there
+		tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+		if err != nil {
+			return nil, err
+		}
+		if err := tmp.Close(); err != nil {
+			_ = os.Remove(tmp.Name())
+			return nil, err
+		}
+		filename, encryptTo = tmp.Name(), filename
+	}
 
+	// Note: v73 doesn't use endianness or description (HDF5 handles that)
 	writer, err := v73.NewWriter(filename)
 	if err != nil {
+		if encryptTo != "" {
+			_ = os.Remove(filename)
+		}
 		return nil, fmt.Errorf("failed to create v7.3 writer: %w", err)
 	}
+	if cfg.creationTime {
+		writer.Now = time.Now
+	}
+	writer.ObjectClasses = cfg.objectClasses
 
 	return &MatFileWriter{
 		filename:  filename,
+		encryptTo: encryptTo,
 		version:   Version73,
 		v73writer: writer,
 	}, nil
 }
 
+// NewWriter returns a writer that writes a v5 MAT-file to dst, for
+// destinations other than files such as network connections or pipes.
+// Options apply as for Create; WithChunkSize, WithRateLimit and
+// WithContext control how the output reaches a slow destination.
+//
+// Close does not close dst. Unlike files created with Create, a failed
+// WriteVariables batch cannot be rolled back, so dst may hold part of it.
+//
+// Example:
+//
+//	writer, err := matlab.NewWriter(conn,
+//	    matlab.WithChunkSize(64<<10),
+//	    matlab.WithRateLimit(50<<20),
+//	    matlab.WithContext(ctx))
+//	if err != nil {
+//	    return err
+//	}
+//	err = writer.WriteVariable(v)
+func NewWriter(dst io.Writer, opts ...Option) (*MatFileWriter, error) {
+	cfg := defaultConfig()
+	applyOptions(cfg, opts)
+	if err := checkKeys(cfg); err != nil {
+		return nil, err
+	}
+	if err := parseDerived(cfg.derived); err != nil {
+		return nil, err
+	}
+	w, err := newV5Writer(dst, cfg)
+	if err != nil {
+		return nil, err
+	}
+	w.hooks = cfg.writeHooks
+	w.strict = cfg.strictReservedNames
+	w.normalizeScalars = cfg.normalizeScalars
+	w.orientation = cfg.orientation
+	w.downcast, w.downcastTolerance = cfg.downcast, cfg.downcastTolerance
+	w.manifestAll = cfg.manifest
+	w.attributesAll = cfg.attributesVariable
+	w.maxVariables, w.maxFileSize = cfg.maxVariables, cfg.maxFileSize
+	w.derived = cfg.derived
+	return w, nil
+}
+
 // createV5 creates a v5 format writer with configuration.
 func createV5(filename string, cfg *config) (*MatFileWriter, error) {
 	// Create file
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if cfg.noClobber {
+		flags |= os.O_EXCL // Another process may have created it since protectExisting
+	}
 	//nolint:gosec // G304: filename is provided by user for MAT-file creation, expected behavior
-	f, err := os.Create(filename)
+	f, err := os.OpenFile(filename, flags, 0o666)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file: %w", err)
 	}
 
-	// Determine endianness string
-	var endian string
-	if cfg.endianness == binary.LittleEndian {
-		endian = "MI"
-	} else {
-		endian = "IM"
+	w, err := newV5Writer(f, cfg)
+	if err != nil {
+		//nolint:errcheck,gosec // G104: File cleanup after error, error logged elsewhere
+		f.Close()
+		return nil, err
+	}
+	w.filename = filename
+	w.v5file = f
+	return w, nil
+}
+
+// newV5Writer creates a v5 format writer to dst with configuration.
+func newV5Writer(dst io.Writer, cfg *config) (*MatFileWriter, error) {
+	// The endian indicator is the value 'MI' stored in the file's byte
+	// order, so little-endian files read "IM". Encoding it with the chosen
+	// order also handles orders other than the binary package's values,
+	// such as binary.NativeEndian.
+	order := cfg.endianness
+	if order == nil {
+		order = binary.LittleEndian
+	}
+	indicator := make([]byte, 2)
+	order.PutUint16(indicator, 'M'<<8|'I')
+	endian := string(indicator)
+
+	dst = newChunkWriter(dst, cfg)
+	var sign *signer
+	if cfg.signingKey != nil {
+		sign = &signer{key: cfg.signingKey, digest: sha512.New(), endian: endian}
+		dst = io.MultiWriter(dst, sign.digest)
+	}
+	var enc *encryptWriter
+	if cfg.encryptionKey != nil {
+		var err error
+		if enc, err = newEncryptWriter(dst, cfg.encryptionKey); err != nil {
+			return nil, fmt.Errorf("failed to create v5 writer: %w", err)
+		}
+		dst = enc
+	}
+	var gz *gzip.Writer
+	if cfg.gzip {
+		gz = gzip.NewWriter(dst)
+		dst = gz
 	}
 
 	// Create v5 writer (writes header immediately) with config
-	writer, err := v5.NewWriter(f, cfg.description, endian)
+	writer, err := v5.NewWriter(dst, cfg.description, endian)
 	if err != nil {
-		//nolint:errcheck,gosec // G104: File cleanup after error, error logged elsewhere
-		f.Close()
 		return nil, fmt.Errorf("failed to create v5 writer: %w", err)
 	}
+	writer.Compression = cfg.compression
 
 	return &MatFileWriter{
-		filename: filename,
 		version:  Version5,
 		v5writer: writer,
-		v5file:   f,
+		v5enc:    enc,
+		v5gzip:   gz,
+		signer:   sign,
+		stats:    Stats{Bytes: writer.Offset()},
 	}, nil
 }
 
@@ -148,6 +418,7 @@ func createV5(filename string, cfg *config) (*MatFileWriter, error) {
 // Supported types:
 //   - Double, Single (float64, float32)
 //   - Int8, Uint8, Int16, Uint16, Int32, Uint32, Int64, Uint64
+//   - Logical ([]bool)
 //   - Complex numbers (use types.NumericArray with Real/Imag)
 //
 // Example:
@@ -175,21 +446,413 @@ func (w *MatFileWriter) WriteVariable(v *types.Variable) error {
 	if v == nil {
 		return errors.New("variable cannot be nil")
 	}
+	if err := w.checkRoom(1); err != nil {
+		return err
+	}
+	if err := w.runHooks(v); err != nil {
+		return err
+	}
+	original := v
+	v = w.prepare(v)
+	if err := w.checkReservedName(v.Name); err != nil {
+		return err
+	}
+	start := time.Now()
+
+	var err error
+	switch w.version {
+	case Version73:
+		if w.v73writer == nil {
+			return errors.New("v7.3 writer is not initialized")
+		}
+		err = w.v73writer.WriteVariable(v)
+	case Version5:
+		if w.v5writer == nil {
+			return errors.New("v5 writer is not initialized")
+		}
+		err = w.v5writer.WriteVariable(v)
+	default:
+		return fmt.Errorf("unsupported version: %d", w.version)
+	}
+	if err == nil {
+		w.recordName(v.Name)
+		w.recordManifest(v)
+		w.recordAttributes(v)
+		w.recordOperand(original)
+	}
+	w.track(start, err, v)
+	return err
+}
+
+// WriteVariables writes a batch of variables with all-or-nothing
+// validation: hooks, names, duplicates (within the batch and against
+// variables already written), classes, data and the format's size limits
+// are checked for every variable before the first one is written. If any
+// check fails, nothing is written and the error names the variable.
+//
+// For v5 files the batch is encoded in memory first, and an I/O error
+// while writing it truncates the file back to its state before the call
+// (not possible for NewWriter destinations).
+// v7.3 files cannot be rolled back, so an I/O error there may leave part
+// of the batch written.
+//
+// Example:
+//
+//	err := writer.WriteVariables(time, voltage, current)
+//	if errors.Is(err, matlab.ErrDuplicateVariable) {
+//	    // Nothing was written
+//	}
+func (w *MatFileWriter) WriteVariables(vars ...*types.Variable) error {
+	start := time.Now()
+	err := w.writeVariables(vars)
+	w.track(start, err, vars...)
+	return err
+}
+
+// writeVariables implements WriteVariables.
+func (w *MatFileWriter) writeVariables(vars []*types.Variable) error {
+	seen := make(map[string]bool, len(vars))
+	for i, v := range vars {
+		if v == nil {
+			return fmt.Errorf("variable %d cannot be nil", i)
+		}
+		if err := w.runHooks(v); err != nil {
+			return err
+		}
+		if seen[v.Name] || w.names[v.Name] {
+			return fmt.Errorf("%w: %q", ErrDuplicateVariable, v.Name)
+		}
+		seen[v.Name] = true
+	}
+	if err := w.checkRoom(len(vars)); err != nil {
+		return err
+	}
+	originals := vars
+	batch := make([]*types.Variable, len(vars))
+	for i, v := range vars {
+		batch[i] = w.prepare(v)
+		if err := w.checkReservedName(batch[i].Name); err != nil {
+			return err
+		}
+	}
+	vars = batch
 
 	switch w.version {
 	case Version73:
 		if w.v73writer == nil {
 			return errors.New("v7.3 writer is not initialized")
 		}
-		return w.v73writer.WriteVariable(v)
+		for _, v := range vars {
+			if err := w.v73writer.Validate(v); err != nil {
+				return fmt.Errorf("%s: %w", v.Name, err)
+			}
+		}
+		for i, v := range vars {
+			if err := w.v73writer.WriteVariable(v); err != nil {
+				return fmt.Errorf("%s: %w", v.Name, err)
+			}
+			w.recordName(v.Name)
+			w.recordManifest(v)
+			w.recordAttributes(v)
+			w.recordOperand(originals[i])
+		}
 	case Version5:
 		if w.v5writer == nil {
 			return errors.New("v5 writer is not initialized")
 		}
-		return w.v5writer.WriteVariable(v)
+		start := w.v5writer.Offset()
+		var digest []byte
+		if w.signer != nil {
+			var err error
+			if digest, err = w.signer.mark(); err != nil {
+				return err
+			}
+		}
+		if err := w.v5writer.WriteVariables(vars); err != nil {
+			if w.v5file == nil || w.v5enc != nil || w.v5gzip != nil {
+				return err
+			}
+			// Drop a partly written batch, from the file and the digest
+			if truncErr := w.v5file.Truncate(start); truncErr != nil {
+				return errors.Join(err, truncErr)
+			}
+			if _, seekErr := w.v5file.Seek(start, io.SeekStart); seekErr != nil {
+				return errors.Join(err, seekErr)
+			}
+			if w.signer != nil {
+				if rewindErr := w.signer.rewind(digest); rewindErr != nil {
+					return errors.Join(err, rewindErr)
+				}
+			}
+			return err
+		}
+		for i, v := range vars {
+			w.recordName(v.Name)
+			w.recordManifest(v)
+			w.recordAttributes(v)
+			w.recordOperand(originals[i])
+		}
 	default:
 		return fmt.Errorf("unsupported version: %d", w.version)
 	}
+	return nil
+}
+
+// checkReservedName rejects a top-level variable with a reserved name
+// under WithStrictReservedNames.
+func (w *MatFileWriter) checkReservedName(name string) error {
+	if !w.strict {
+		return nil
+	}
+	if err := types.CheckReservedName(name); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrWriteRejected, name, err)
+	}
+	return nil
+}
+
+// recordName notes a top-level variable written to the file, with a
+// warning if its name is reserved.
+func (w *MatFileWriter) recordName(name string) {
+	if w.names == nil {
+		w.names = make(map[string]bool)
+	}
+	if err := types.CheckReservedName(name); err != nil && !w.names[name] {
+		w.warnings = append(w.warnings, &ReservedNameWarning{Name: name, Err: err})
+	}
+	w.names[name] = true
+}
+
+// WriteLogical writes a MATLAB logical array.
+//
+// The values are stored as uint8 with the logical class marker for the
+// target format, so MATLAB loads them as a logical array.
+//
+// Example:
+//
+//	writer.WriteLogical("mask", []int{1, 3}, []bool{true, false, true})
+func (w *MatFileWriter) WriteLogical(name string, dims []int, data []bool) error {
+	return w.WriteVariable(&types.Variable{
+		Name:       name,
+		Dimensions: dims,
+		DataType:   types.Logical,
+		Data:       data,
+	})
+}
+
+// WritePolar writes a complex double array given the magnitude and phase
+// (in radians) of each element, as types.PolarToComplex builds it.
+//
+// Example:
+//
+//	writer.WritePolar("H", []int{1, len(mag)}, mag, phase)
+func (w *MatFileWriter) WritePolar(name string, dims []int, magnitude, phase []float64) error {
+	v, err := types.PolarToComplex(name, dims, magnitude, phase)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return w.WriteVariable(v)
+}
+
+// GroupWriter writes variables into a named group of a v7.3 file.
+//
+// Groups let one file hold many independent sets of variables, such as
+// one group per experiment run. MATLAB loads each group as a struct whose
+// fields are the variables written into it; Open does the same unless
+// WithFlattenGroups is given.
+type GroupWriter struct {
+	w    *MatFileWriter
+	path string
+}
+
+// Group returns a writer for the named group, which is created when the
+// first variable is written into it. Groups are only supported in v7.3
+// files; for v5 files WriteVariable returns ErrGroupsNotSupported.
+//
+// Example:
+//
+//	run := writer.Group("run42")
+//	run.WriteVariable(&types.Variable{Name: "x", ...})
+//	run.Group("trial1").WriteVariable(&types.Variable{Name: "y", ...})
+func (w *MatFileWriter) Group(name string) *GroupWriter {
+	return &GroupWriter{w: w, path: name}
+}
+
+// Group returns a writer for a subgroup of g.
+func (g *GroupWriter) Group(name string) *GroupWriter {
+	return &GroupWriter{w: g.w, path: g.path + "/" + name}
+}
+
+// Path returns the slash-separated group path, such as "run42/trial1".
+func (g *GroupWriter) Path() string {
+	return g.path
+}
+
+// WriteVariable writes a variable into the group. The top-level group,
+// which MATLAB loads as a variable, counts against WithMaxVariables and
+// its name is checked like those of other variables; the variables in it
+// load as struct fields, whose names may be reserved.
+func (g *GroupWriter) WriteVariable(v *types.Variable) error {
+	if v == nil {
+		return errors.New("variable cannot be nil")
+	}
+	if g.w.version != Version73 {
+		return ErrGroupsNotSupported
+	}
+	if g.w.v73writer == nil {
+		return errors.New("v7.3 writer is not initialized")
+	}
+	root, _, _ := strings.Cut(g.path, "/")
+	if !g.w.names[root] {
+		if err := g.w.checkRoom(1); err != nil {
+			return err
+		}
+	}
+	if err := g.w.runHooks(v); err != nil {
+		return err
+	}
+	if err := g.w.checkReservedName(root); err != nil {
+		return err
+	}
+	v = g.w.prepare(v)
+	start := time.Now()
+	err := g.w.v73writer.WriteVariableInGroup(g.path, v)
+	if err == nil {
+		g.w.recordName(root)
+	}
+	g.w.track(start, err, v)
+	return err
+}
+
+// normalize returns v with the 1-D shapes it would be written with
+// replaced by 2-D ones, in v and its struct fields: scalars [] and [1]
+// become [1, 1] (WithScalarNormalization) and vectors [n] become [1, n] or
+// [n, 1] (WithVectorOrientation). v itself is not modified; it is returned
+// as is if nothing changes.
+func (w *MatFileWriter) normalize(v *types.Variable) *types.Variable {
+	if !w.normalizeScalars && w.orientation == AsGiven {
+		return v
+	}
+	return mapDims(v, w.shape)
+}
+
+// shape returns the normalized form of dims, or nil to keep them.
+func (w *MatFileWriter) shape(dims []int) []int {
+	if len(dims) > 1 {
+		return nil
+	}
+	n := 1
+	if len(dims) == 1 {
+		n = dims[0]
+	}
+	switch {
+	case n == 1 && (w.normalizeScalars || (len(dims) == 1 && w.orientation != AsGiven)):
+		return []int{1, 1}
+	case len(dims) == 1 && w.orientation == Row:
+		return []int{1, n}
+	case len(dims) == 1 && w.orientation == Column:
+		return []int{n, 1}
+	}
+	return nil
+}
+
+// mapDims returns v with its dimensions and those of its struct fields
+// replaced by shape(dims) where that is not nil, copying only what
+// changes.
+func mapDims(v *types.Variable, shape func([]int) []int) *types.Variable {
+	out := v
+	if dims := shape(v.Dimensions); dims != nil {
+		copied := *v
+		copied.Dimensions = dims
+		out = &copied
+	}
+
+	st, ok := v.Data.(*types.StructArray)
+	if !ok {
+		return out
+	}
+	var elements [][]*types.Variable
+	for i, element := range st.Elements {
+		for j, field := range element {
+			if field == nil {
+				continue
+			}
+			mapped := mapDims(field, shape)
+			if mapped == field {
+				continue
+			}
+			if elements == nil {
+				elements = make([][]*types.Variable, len(st.Elements))
+				for k := range st.Elements {
+					elements[k] = append([]*types.Variable(nil), st.Elements[k]...)
+				}
+			}
+			elements[i][j] = mapped
+		}
+	}
+	if elements == nil {
+		return out
+	}
+	copiedStruct := *st
+	copiedStruct.Elements = elements
+	if out == v {
+		copied := *v
+		out = &copied
+	}
+	out.Data = &copiedStruct
+	return out
+}
+
+// runHooks calls the hooks registered with WithWriteHook.
+func (w *MatFileWriter) runHooks(v *types.Variable) error {
+	for _, hook := range w.hooks {
+		if err := hook(v); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrWriteRejected, v.Name, err)
+		}
+	}
+	return nil
+}
+
+// track adds the time since start to the write statistics and, if err is
+// nil, the variables written and the size of the file so far.
+func (w *MatFileWriter) track(start time.Time, err error, vars ...*types.Variable) {
+	w.stats.Duration += time.Since(start)
+	w.commitDowncast(err)
+	if err != nil {
+		return
+	}
+	w.stats.count(vars...)
+	if w.v5writer != nil {
+		w.stats.Bytes = w.v5writer.Offset()
+		w.stats.DecompressedBytes = w.v5writer.Inflated()
+	}
+}
+
+// checkSignatureName reports a variable written under the name of the
+// signature of a signed file.
+func (w *MatFileWriter) checkSignatureName() error {
+	if w.signer != nil && w.names[SignatureVariable] {
+		return fmt.Errorf("failed to sign file: %w: %q", ErrDuplicateVariable, SignatureVariable)
+	}
+	return nil
+}
+
+// writeManifest writes the manifest variable, if variables were recorded.
+func (w *MatFileWriter) writeManifest() error {
+	v, err := w.manifestVar()
+	if err != nil || v == nil {
+		return err
+	}
+	switch {
+	case w.v73writer != nil:
+		err = w.v73writer.WriteVariable(v)
+	case w.v5writer != nil:
+		err = w.v5writer.WriteVariable(v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	w.recordName(v.Name)
+	return nil
 }
 
 // Close closes the MATLAB file and flushes all data to disk.
@@ -202,17 +865,83 @@ func (w *MatFileWriter) WriteVariable(v *types.Variable) error {
 // Returns:
 //   - error: If flushing or closing fails
 func (w *MatFileWriter) Close() error {
+	return errors.Join(w.writeDerived(), w.close())
+}
+
+// close finalizes and closes the file.
+func (w *MatFileWriter) close() error {
 	switch w.version {
 	case Version73:
 		if w.v73writer != nil {
-			err := w.v73writer.Close()
+			start := time.Now()
+			var placeholder []byte
+			err := w.writeManifest()
+			if err == nil {
+				err = w.checkSignatureName()
+			}
+			if err == nil && w.signer != nil {
+				if placeholder, err = newPlaceholder(); err == nil {
+					err = w.v73writer.WriteVariable(signatureVar(placeholder))
+				}
+			}
+			err = errors.Join(err, w.v73writer.Close())
 			w.v73writer = nil // Mark as closed
+			if err == nil && w.signer != nil {
+				if signErr := w.signer.signFile(w.filename, placeholder); signErr != nil {
+					err = fmt.Errorf("failed to sign file: %w", signErr)
+				}
+			}
+			if err == nil && w.gzip {
+				if gzErr := gzipFile(w.filename); gzErr != nil {
+					err = fmt.Errorf("failed to compress file: %w", gzErr)
+				}
+			}
+			if w.encryptTo != "" {
+				if err == nil {
+					if encErr := encryptFileTo(w.filename, w.encryptTo, w.encryptionKey); encErr != nil {
+						err = fmt.Errorf("failed to encrypt file: %w", encErr)
+					}
+				}
+				_ = os.Remove(w.filename) // Plain text, already encrypted or failed
+				w.filename = w.encryptTo
+			}
+			w.stats.Duration += time.Since(start)
+			if info, statErr := os.Stat(w.filename); statErr == nil {
+				w.stats.Bytes = info.Size()
+			}
 			return err
 		}
 		return nil
 	case Version5:
-		if w.v5file != nil {
-			err := w.v5file.Close()
+		if w.v5writer != nil {
+			start := time.Now()
+			err := w.writeAttributes()
+			if err == nil {
+				err = w.writeManifest()
+			}
+			if err == nil {
+				err = w.checkSignatureName()
+			}
+			if err == nil && w.signer != nil {
+				if signErr := w.signer.signV5(w.v5writer); signErr != nil {
+					err = fmt.Errorf("failed to sign file: %w", signErr)
+				} else {
+					w.stats.Bytes = w.v5writer.Offset()
+				}
+			}
+			if w.v5gzip != nil {
+				err = errors.Join(err, w.v5gzip.Close())
+			}
+			if w.v5enc != nil {
+				err = errors.Join(err, w.v5enc.Close())
+			}
+			if w.v5file != nil {
+				if info, statErr := w.v5file.Stat(); statErr == nil && w.v5gzip != nil {
+					w.stats.Bytes = info.Size()
+				}
+				err = errors.Join(err, w.v5file.Close())
+			}
+			w.stats.Duration += time.Since(start)
 			w.v5writer = nil // Mark as closed
 			w.v5file = nil
 			return err
@@ -1,8 +1,13 @@
 package matlab
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/scigolib/matlab/types"
@@ -199,6 +204,20 @@ func TestRoundTrip_v73_Matrix(t *testing.T) {
 	}
 
 	_ = writer.Close()
+
+	file, err := os.Open(tmpFile)
+	if err != nil {
+		t.Fatalf("os.Open() error = %v", err)
+	}
+	defer file.Close()
+	matFile, err := Open(file)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	m := matFile.GetVariable("M")
+	if m == nil || !reflect.DeepEqual(m.Dimensions, []int{2, 3}) {
+		t.Errorf("M = %+v, want dimensions [2 3]", m)
+	}
 }
 
 // TestRoundTrip_v73_AllNumericTypes tests writing different numeric types.
@@ -843,3 +862,383 @@ func TestRoundTrip_v73_Complex(t *testing.T) {
 		})
 	}
 }
+
+func TestRoundTrip_Logical(t *testing.T) {
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			tmpfile := filepath.Join(t.TempDir(), "logical.mat")
+			writer, err := Create(tmpfile, version)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			want := []bool{true, false, true, true}
+			if err := writer.WriteLogical("mask", []int{1, 4}, want); err != nil {
+				t.Fatalf("WriteLogical() error = %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			file, err := os.Open(tmpfile)
+			if err != nil {
+				t.Fatalf("os.Open() error = %v", err)
+			}
+			defer file.Close()
+
+			matFile, err := Open(file)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			v := matFile.GetVariable("mask")
+			if v == nil {
+				t.Fatal("variable mask not found")
+			}
+			if v.DataType != types.Logical {
+				t.Errorf("DataType = %v, want logical", v.DataType)
+			}
+			got, ok := v.Data.([]bool)
+			if !ok {
+				t.Fatalf("Data type = %T, want []bool", v.Data)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Data = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_v5_Compression(t *testing.T) {
+	data := make([]float64, 200*400)
+	for i := range data {
+		data[i] = float64(i % 7)
+	}
+	tmpfile := filepath.Join(t.TempDir(), "compressed.mat")
+	writer, err := Create(tmpfile, Version5, WithCompression(6))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	vars := []*types.Variable{
+		{Name: "big", Dimensions: []int{200, 400}, DataType: types.Double, Data: data},
+		{Name: "s", Dimensions: []int{1, 5}, DataType: types.Char, Data: "hello"},
+		{Name: "z", Dimensions: []int{1, 2}, DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1, 2}, Imag: []float64{3, 4}}},
+	}
+	for _, v := range vars {
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	file, err := os.Open(tmpfile)
+	if err != nil {
+		t.Fatalf("os.Open() error = %v", err)
+	}
+	defer file.Close()
+	matFile, err := Open(file)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if !matFile.Features.Compressed {
+		t.Error("Features.Compressed = false")
+	}
+
+	for _, want := range vars[:2] {
+		got := matFile.GetVariable(want.Name)
+		if got == nil || !reflect.DeepEqual(got.Data, want.Data) {
+			t.Errorf("%s: read back %+v", want.Name, got)
+		}
+	}
+	z, ok := matFile.GetVariable("z").Data.(*types.NumericArray)
+	if !ok || !reflect.DeepEqual(z.Real, []float64{1, 2}) || !reflect.DeepEqual(z.Imag, []float64{3, 4}) {
+		t.Errorf("z: read back %+v", matFile.GetVariable("z").Data)
+	}
+
+	big := matFile.VariableSizes()[0]
+	if !big.Compressed || big.Size >= big.UncompressedSize/10 {
+		t.Errorf("big: Compressed = %v, stored %d of %d bytes", big.Compressed, big.Size, big.UncompressedSize)
+	}
+}
+
+func TestWriteLogical_WrongDataType(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "logical_bad.mat")
+	writer, err := Create(tmpfile, Version5)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer writer.Close()
+
+	err = writer.WriteVariable(&types.Variable{
+		Name:       "mask",
+		Dimensions: []int{1, 2},
+		DataType:   types.Logical,
+		Data:       []uint8{1, 0},
+	})
+	if err == nil {
+		t.Error("expected error for non-[]bool logical data")
+	}
+}
+
+// TestWritePolar tests that magnitude and phase written with WritePolar
+// read back through GetMagnitude and GetPhase in both formats.
+func TestWritePolar(t *testing.T) {
+	magnitude := []float64{1, 2, 0.5}
+	phase := []float64{0, math.Pi / 2, -math.Pi / 4}
+	for _, version := range []Version{Version5, Version73} {
+		tmpfile := filepath.Join(t.TempDir(), "polar.mat")
+		writer, err := Create(tmpfile, version)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if err := writer.WritePolar("H", []int{1, 3}, magnitude, phase); err != nil {
+			t.Fatalf("WritePolar() error = %v", err)
+		}
+		if err := writer.WritePolar("bad", []int{1, 2}, magnitude, phase); err == nil {
+			t.Error("WritePolar(wrong dimensions) error = nil")
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		file, err := os.Open(tmpfile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matFile, err := Open(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		h := matFile.GetVariable("H")
+		gotMag, err := h.GetMagnitude()
+		if err != nil {
+			t.Fatalf("GetMagnitude() error = %v", err)
+		}
+		gotPhase, err := h.GetPhase()
+		if err != nil {
+			t.Fatalf("GetPhase() error = %v", err)
+		}
+		for i := range magnitude {
+			if math.Abs(gotMag[i]-magnitude[i]) > 1e-12 || math.Abs(gotPhase[i]-phase[i]) > 1e-12 {
+				t.Errorf("v%d element %d: %v∠%v, want %v∠%v", version, i, gotMag[i], gotPhase[i], magnitude[i], phase[i])
+			}
+		}
+	}
+}
+
+// TestGroupWriter_RoundTrip tests writing variables into namespace groups
+// and reading them back as structs and as flattened variables.
+func TestGroupWriter_RoundTrip(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "runs.mat")
+	writer, err := Create(tmpfile, Version73)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	for i, run := range []string{"run1", "run2"} {
+		group := writer.Group(run)
+		if err := group.WriteVariable(&types.Variable{
+			Name: "x", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{float64(i), 1},
+		}); err != nil {
+			t.Fatalf("WriteVariable(%s/x) error = %v", run, err)
+		}
+		if err := group.Group("meta").WriteVariable(&types.Variable{
+			Name: "id", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{float64(i + 1)},
+		}); err != nil {
+			t.Fatalf("WriteVariable(%s/meta/id) error = %v", run, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	readFile := func(opts ...OpenOption) *MatFile {
+		t.Helper()
+		file, err := os.Open(tmpfile)
+		if err != nil {
+			t.Fatalf("Failed to open file: %v", err)
+		}
+		defer file.Close()
+		matFile, err := Open(file, opts...)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		return matFile
+	}
+
+	t.Run("structs", func(t *testing.T) {
+		matFile := readFile()
+		if names := matFile.GetVariableNames(); !reflect.DeepEqual(names, []string{"run1", "run2"}) {
+			t.Errorf("names = %v, want [run1 run2]", names)
+		}
+		if v := matFile.GetVariable("run2").Data.(*types.StructArray).Field(0, "x"); v == nil {
+			t.Fatal("run2.x missing")
+		}
+		if v := matFile.GetVariable("run2/meta/id"); v == nil || !reflect.DeepEqual(v.Data, []float64{2}) {
+			t.Errorf("run2/meta/id = %v", v)
+		}
+	})
+
+	t.Run("flattened", func(t *testing.T) {
+		matFile := readFile(WithFlattenGroups())
+		want := []string{"/run1/meta/id", "/run1/x", "/run2/meta/id", "/run2/x"}
+		if names := matFile.GetVariableNames(); !reflect.DeepEqual(names, want) {
+			t.Errorf("names = %v, want %v", names, want)
+		}
+		if v := matFile.GetVariable("run1/x"); v == nil || !reflect.DeepEqual(v.Data, []float64{0, 1}) {
+			t.Errorf("run1/x = %v", v)
+		}
+	})
+}
+
+// TestGroupWriter_Validation tests that group writes count their
+// top-level group as a variable and check its name like WriteVariable.
+func TestGroupWriter_Validation(t *testing.T) {
+	scalar := func(name string) *types.Variable {
+		return &types.Variable{Name: name, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}
+	}
+
+	writer, err := Create(filepath.Join(t.TempDir(), "full.mat"), Version73, WithMaxVariables(1))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer writer.Close()
+	for _, name := range []string{"x", "y"} {
+		if err := writer.Group("run1").WriteVariable(scalar(name)); err != nil {
+			t.Fatalf("WriteVariable(run1/%s) error = %v", name, err)
+		}
+	}
+	if err := writer.Group("run2").WriteVariable(scalar("x")); !errors.Is(err, ErrFileFull) {
+		t.Errorf("WriteVariable(run2/x) error = %v, want ErrFileFull", err)
+	}
+	if err := writer.WriteVariables(scalar("run1")); !errors.Is(err, ErrDuplicateVariable) {
+		t.Errorf("WriteVariables(run1) error = %v, want ErrDuplicateVariable", err)
+	}
+
+	strict, err := Create(filepath.Join(t.TempDir(), "strict.mat"), Version73, WithStrictReservedNames())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer strict.Close()
+	if err := strict.Group("pi").WriteVariable(scalar("x")); !errors.Is(err, ErrWriteRejected) {
+		t.Errorf("WriteVariable(pi/x) error = %v, want ErrWriteRejected", err)
+	}
+
+	lenient, err := Create(filepath.Join(t.TempDir(), "lenient.mat"), Version73)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer lenient.Close()
+	if err := lenient.Group("pi").WriteVariable(scalar("x")); err != nil {
+		t.Fatalf("WriteVariable(pi/x) error = %v", err)
+	}
+	var warning *ReservedNameWarning
+	if warnings := lenient.Warnings(); len(warnings) != 1 || !errors.As(warnings[0], &warning) || warning.Name != "pi" {
+		t.Errorf("Warnings() = %v, want a reserved name warning for pi", warnings)
+	}
+}
+
+// TestGroupWriter_Errors tests group writes that must fail.
+func TestGroupWriter_Errors(t *testing.T) {
+	v := &types.Variable{Name: "x", Dimensions: []int{1}, DataType: types.Double, Data: []float64{1}}
+
+	v5file := filepath.Join(t.TempDir(), "v5.mat")
+	writer, err := Create(v5file, Version5)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer writer.Close()
+	if err := writer.Group("run").WriteVariable(v); !errors.Is(err, ErrGroupsNotSupported) {
+		t.Errorf("v5 group write error = %v, want ErrGroupsNotSupported", err)
+	}
+
+	v73file := filepath.Join(t.TempDir(), "v73.mat")
+	writer73, err := Create(v73file, Version73)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer writer73.Close()
+	if err := writer73.Group("run").WriteVariable(nil); err == nil {
+		t.Error("expected error for nil variable")
+	}
+	if err := writer73.Group("").WriteVariable(v); err == nil {
+		t.Error("expected error for empty group name")
+	}
+	if err := writer73.Group("a//b").WriteVariable(v); err == nil {
+		t.Error("expected error for empty path segment")
+	}
+	if got := writer73.Group("a").Group("b").Path(); got != "a/b" {
+		t.Errorf("Path() = %q, want a/b", got)
+	}
+}
+
+// TestWriteVariables tests that a batch is written only if every variable
+// passes the hooks, duplicate checks and format validation.
+func TestWriteVariables(t *testing.T) {
+	errRejected := errors.New("no negative gain")
+	rejectNegative := func(v *types.Variable) error {
+		if data, ok := v.Data.([]float64); ok && v.Name == "gain" && data[0] < 0 {
+			return errRejected
+		}
+		return nil
+	}
+	scalar := func(name string, x float64) *types.Variable {
+		return &types.Variable{Name: name, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{x}}
+	}
+
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			tmpFile := filepath.Join(t.TempDir(), "batch.mat")
+			writer, err := Create(tmpFile, version, WithWriteHook(rejectNegative))
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if err := writer.WriteVariable(scalar("first", 1)); err != nil {
+				t.Fatalf("WriteVariable() error = %v", err)
+			}
+
+			rejected := []struct {
+				name  string
+				batch []*types.Variable
+				want  error
+			}{
+				{"duplicate in batch", []*types.Variable{scalar("a", 1), scalar("a", 2)}, ErrDuplicateVariable},
+				{"already written", []*types.Variable{scalar("a", 1), scalar("first", 2)}, ErrDuplicateVariable},
+				{"hook", []*types.Variable{scalar("a", 1), scalar("gain", -1)}, ErrWriteRejected},
+				{"invalid data", []*types.Variable{scalar("a", 1),
+					{Name: "b", Dimensions: []int{1, 2}, DataType: types.Int32, Data: []float64{1, 2}}}, nil},
+				{"nil", []*types.Variable{scalar("a", 1), nil}, nil},
+			}
+			for _, tt := range rejected {
+				err := writer.WriteVariables(tt.batch...)
+				if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+					t.Errorf("%s: WriteVariables() error = %v, want %v", tt.name, err, tt.want)
+				}
+			}
+
+			if err := writer.WriteVariables(scalar("a", 1), scalar("gain", 2)); err != nil {
+				t.Fatalf("WriteVariables() error = %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			file, err := os.Open(tmpFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = file.Close() }()
+			matFile, err := Open(file)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			names := matFile.GetVariableNames()
+			sort.Strings(names)
+			if want := []string{"a", "first", "gain"}; !reflect.DeepEqual(names, want) {
+				t.Errorf("variables = %v, want %v", names, want)
+			}
+		})
+	}
+}
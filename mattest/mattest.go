@@ -0,0 +1,180 @@
+// Package mattest provides helpers for tests of code that reads and
+// writes MAT-files with this library, so that custom types and extensions
+// can be checked against both file formats without internal packages.
+//
+// Example:
+//
+//	func TestReadingsRoundTrip(t *testing.T) {
+//	    v := readings.ToVariable() // your conversion to *types.Variable
+//	    mattest.AssertRoundTrip(t, v) // v5 and v7.3
+//	}
+package mattest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+// AllVersions lists the formats AssertRoundTrip checks by default.
+var AllVersions = []matlab.Version{matlab.Version5, matlab.Version73}
+
+// AssertRoundTrip writes v to a temporary file in each of the given
+// versions (AllVersions if none are given), reads it back and reports an
+// error on t for every version in which the variable read differs from v.
+//
+// Name, class, complexity, dimensions and data are compared; float values
+// compare equal if both are NaN. Attributes are not compared, since v7.3
+// files add their own.
+//
+// Example:
+//
+//	mattest.AssertRoundTrip(t, &types.Variable{
+//	    Name: "m", Dimensions: []int{2, 2}, DataType: types.Int16,
+//	    Data: []int16{1, 2, 3, 4},
+//	}, matlab.Version5)
+func AssertRoundTrip(t testing.TB, v *types.Variable, versions ...matlab.Version) {
+	t.Helper()
+	if len(versions) == 0 {
+		versions = AllVersions
+	}
+	for _, version := range versions {
+		got, err := roundTrip(t, v, version)
+		if err != nil {
+			t.Errorf("%s: %v", versionName(version), err)
+			continue
+		}
+		if diff := Diff(v, got); diff != "" {
+			t.Errorf("%s: %s", versionName(version), diff)
+		}
+	}
+}
+
+// RoundTrip writes v to a temporary file in the given version and returns
+// the variable read back. It stops the test on failure.
+func RoundTrip(t testing.TB, v *types.Variable, version matlab.Version) *types.Variable {
+	t.Helper()
+	got, err := roundTrip(t, v, version)
+	if err != nil {
+		t.Fatalf("%s: %v", versionName(version), err)
+	}
+	return got
+}
+
+// AssertFixture opens the MAT-file at path, for example one saved by
+// MATLAB, and reports an error on t for each of want that is missing from
+// it or differs from the variable of the same name, as compared by Diff.
+//
+// Example:
+//
+//	mattest.AssertFixture(t, "testdata/calibration.mat", wantGain, wantOffset)
+func AssertFixture(t testing.TB, path string, want ...*types.Variable) {
+	t.Helper()
+	matFile, err := open(path)
+	if err != nil {
+		t.Fatalf("fixture %s: %v", path, err)
+	}
+	for _, w := range want {
+		got := matFile.GetVariable(w.Name)
+		if got == nil {
+			t.Errorf("fixture %s: variable %q not found", path, w.Name)
+			continue
+		}
+		if diff := Diff(w, got); diff != "" {
+			t.Errorf("fixture %s: %s", path, diff)
+		}
+	}
+}
+
+// Diff describes the first difference between two variables, or returns
+// "" if they are equal for the purposes of AssertRoundTrip. It is
+// types.Compare with NaN equal to NaN; use types.Compare directly to allow
+// numeric tolerances.
+func Diff(want, got *types.Variable) string {
+	if m := types.Compare(want, got, types.WithNaNEqual()); m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+// WriteFixture writes vars to a new file in t's temporary directory in
+// the given version and returns its path, so integration tests can build
+// their input files in code instead of shipping binary fixtures. It stops
+// the test on failure.
+//
+// Example:
+//
+//	path := mattest.WriteFixture(t, matlab.Version73,
+//	    &types.Variable{Name: "gain", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{2}})
+//	cfg, err := mypkg.LoadCalibration(path)
+func WriteFixture(t testing.TB, version matlab.Version, vars ...*types.Variable) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixture.mat")
+	if err := writeFile(path, version, vars); err != nil {
+		t.Fatalf("%s: %v", versionName(version), err)
+	}
+	return path
+}
+
+// roundTrip writes v to a file in t's temporary directory and reads it back.
+func roundTrip(t testing.TB, v *types.Variable, version matlab.Version) (*types.Variable, error) {
+	path := filepath.Join(t.TempDir(), "roundtrip.mat")
+	if err := writeFile(path, version, []*types.Variable{v}); err != nil {
+		return nil, err
+	}
+
+	matFile, err := open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	got := matFile.GetVariable(v.Name)
+	if got == nil {
+		return nil, fmt.Errorf("variable %q not found after reading back (have %v)", v.Name, matFile.GetVariableNames())
+	}
+	return got, nil
+}
+
+// writeFile writes vars to a new MAT-file at path.
+func writeFile(path string, version matlab.Version, vars []*types.Variable) error {
+	writer, err := matlab.Create(path, version)
+	if err != nil {
+		return err
+	}
+	for _, v := range vars {
+		if err := writer.WriteVariable(v); err != nil {
+			_ = writer.Close()
+			return fmt.Errorf("write: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	return nil
+}
+
+// open opens and parses the MAT-file at path.
+func open(path string) (*matlab.MatFile, error) {
+	//nolint:gosec // G304: path is provided by the test
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+	return matlab.Open(file)
+}
+
+// versionName returns the display name of a format version.
+func versionName(version matlab.Version) string {
+	switch version {
+	case matlab.Version5:
+		return "v5"
+	case matlab.Version73:
+		return "v7.3"
+	default:
+		return fmt.Sprintf("version %d", version)
+	}
+}
@@ -0,0 +1,133 @@
+package mattest
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+// recorder captures errors reported through testing.TB.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertRoundTrip(t *testing.T) {
+	vars := []*types.Variable{
+		{Name: "m", Dimensions: []int{2, 3}, DataType: types.Double, Data: []float64{1, 2, math.NaN(), 4, 5, 6}},
+		{Name: "i", Dimensions: []int{1, 4}, DataType: types.Int16, Data: []int16{-1, 0, 1, 2}},
+		{Name: "u", Dimensions: []int{2, 2}, DataType: types.Uint32, Data: []uint32{1, 2, 3, 4}},
+		{Name: "big", Dimensions: []int{1, 2}, DataType: types.Int64, Data: []int64{1<<60 + 1, -5}},
+		{Name: "mask", Dimensions: []int{1, 3}, DataType: types.Logical, Data: []bool{true, false, true}},
+		{Name: "s", Dimensions: []int{1, 5}, DataType: types.Char, Data: "hello"},
+		{Name: "z", Dimensions: []int{1, 2}, DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1, 2}, Imag: []float64{3, 4}}},
+		{Name: "zi", Dimensions: []int{2, 1}, DataType: types.Int16, IsComplex: true,
+			Data: &types.NumericArray{Real: []int16{1, -2}, Imag: []int16{3, 4}}},
+		{Name: "cfg", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+			Fields:     []string{"gain"},
+			Elements:   [][]*types.Variable{{{Name: "gain", Dimensions: []int{1, 1}, DataType: types.Single, Data: []float32{0.5}}}},
+			Dimensions: []int{1, 1},
+		}},
+	}
+	for _, v := range vars {
+		t.Run(v.Name, func(t *testing.T) {
+			AssertRoundTrip(t, v)
+		})
+	}
+}
+
+func TestAssertRoundTrip_ReportsMismatch(t *testing.T) {
+	// Strings are not valid double data
+	bad := &types.Variable{Name: "m", Dimensions: []int{1, 2}, DataType: types.Double, Data: []string{"a", "b"}}
+	rec := &recorder{TB: t}
+	AssertRoundTrip(rec, bad)
+	if len(rec.errors) != 2 || !strings.HasPrefix(rec.errors[0], "v5: ") || !strings.HasPrefix(rec.errors[1], "v7.3: ") {
+		t.Errorf("errors = %q", rec.errors)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	v := &types.Variable{Name: "x", Dimensions: []int{1, 1}, DataType: types.Single, Data: []float32{2.5}}
+	got := RoundTrip(t, v, matlab.Version5)
+	if got.DataType != types.Single || got.Data.([]float32)[0] != 2.5 {
+		t.Errorf("RoundTrip() = %+v", got)
+	}
+}
+
+func TestWriteFixture(t *testing.T) {
+	want := []*types.Variable{
+		{Name: "a", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}},
+		{Name: "b", Dimensions: []int{1, 3}, DataType: types.Char, Data: "abc"},
+	}
+	for _, version := range AllVersions {
+		path := WriteFixture(t, version, want...)
+		AssertFixture(t, path, want...)
+	}
+}
+
+func TestAssertFixture(t *testing.T) {
+	path := filepath.Join("..", "testdata", "generated", "matrix_2x3.mat")
+	matFile, err := open(path)
+	if err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	AssertFixture(t, path, matFile.Variables...)
+
+	rec := &recorder{TB: t}
+	AssertFixture(rec, path, &types.Variable{Name: "missing"})
+	if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], `"missing" not found`) {
+		t.Errorf("errors = %q", rec.errors)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	base := func() *types.Variable {
+		return &types.Variable{Name: "a", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}}
+	}
+	tests := []struct {
+		name   string
+		modify func(v *types.Variable)
+		want   string
+	}{
+		{"equal", func(*types.Variable) {}, ""},
+		{"name", func(v *types.Variable) { v.Name = "b" }, `name "b", want "a"`},
+		{"class", func(v *types.Variable) { v.DataType = types.Single }, "class single, want double"},
+		{"dims", func(v *types.Variable) { v.Dimensions = []int{2, 1} }, "dimensions [2 1], want [1 2]"},
+		{"element", func(v *types.Variable) { v.Data = []float64{1, 3} }, "element 1 is 3, want 2"},
+		{"type", func(v *types.Variable) { v.Data = []float32{1, 2} }, "data is []float32, want []float64"},
+		{"struct", func(v *types.Variable) {
+			v.DataType = types.Struct
+			v.Data = &types.StructArray{Fields: []string{"f"}, Elements: [][]*types.Variable{{base()}}}
+		}, "class struct"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := base()
+			tt.modify(got)
+			diff := Diff(base(), got)
+			if (tt.want == "") != (diff == "") || !strings.Contains(diff, tt.want) {
+				t.Errorf("Diff() = %q, want it to contain %q", diff, tt.want)
+			}
+		})
+	}
+
+	field := func(x float64) *types.Variable {
+		return &types.Variable{Name: "s", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+			Fields:   []string{"x"},
+			Elements: [][]*types.Variable{{{Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{x}}}},
+		}}
+	}
+	if diff := Diff(field(1), field(2)); !strings.Contains(diff, "element 0 field x") {
+		t.Errorf("struct Diff() = %q", diff)
+	}
+}
@@ -0,0 +1,336 @@
+package mattest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+// propertyTypes lists the classes RandomVariable generates.
+var propertyTypes = []types.DataType{
+	types.Double, types.Single,
+	types.Int8, types.Uint8, types.Int16, types.Uint16,
+	types.Int32, types.Uint32, types.Int64, types.Uint64,
+	types.Logical, types.Char,
+}
+
+// PropertyOption configures CheckRoundTrip.
+type PropertyOption func(*propertyConfig)
+
+// propertyConfig holds the settings of CheckRoundTrip.
+type propertyConfig struct {
+	count    int              // Number of random variables
+	seed     int64            // Seed of the generator
+	versions []matlab.Version // Formats checked
+}
+
+// WithCount sets the number of random variables CheckRoundTrip writes.
+//
+// Default: 100
+func WithCount(n int) PropertyOption {
+	return func(c *propertyConfig) {
+		c.count = n
+	}
+}
+
+// WithSeed fixes the seed of the random generator, to replay a failure
+// reported by CheckRoundTrip.
+//
+// Default: the current time
+//
+// Example:
+//
+//	mattest.CheckRoundTrip(t, mattest.WithSeed(1712345678))
+func WithSeed(seed int64) PropertyOption {
+	return func(c *propertyConfig) {
+		c.seed = seed
+	}
+}
+
+// WithVersions sets the formats CheckRoundTrip checks.
+//
+// Default: AllVersions
+func WithVersions(versions ...matlab.Version) PropertyOption {
+	return func(c *propertyConfig) {
+		c.versions = versions
+	}
+}
+
+// CheckRoundTrip is a property test of the writers and readers: it
+// writes random variables (see RandomVariable) in each version, reads
+// them back and reports an error on t for every variable that differs, as
+// compared by Diff.
+//
+// A failing variable is shrunk to the smallest variable that still fails
+// (see Shrink) before it is reported, with the seed that reproduces it.
+//
+// Example:
+//
+//	func TestRoundTripProperty(t *testing.T) {
+//	    mattest.CheckRoundTrip(t, mattest.WithCount(500))
+//	}
+func CheckRoundTrip(t testing.TB, opts ...PropertyOption) {
+	t.Helper()
+	cfg := &propertyConfig{count: 100, seed: time.Now().UnixNano(), versions: AllVersions}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	r := rand.New(rand.NewSource(cfg.seed)) //nolint:gosec // G404: test data
+	for i := 0; i < cfg.count; i++ {
+		v := RandomVariable(r)
+		for _, version := range cfg.versions {
+			fails := func(v *types.Variable) string {
+				got, err := roundTrip(t, v, version)
+				if err != nil {
+					return err.Error()
+				}
+				return Diff(v, got)
+			}
+			msg := fails(v)
+			if msg == "" {
+				continue
+			}
+			minimal, msg := shrinkFailure(v, msg, fails)
+			t.Errorf("%s: round trip of random variable %d (seed %d) failed: %s\nminimal variable: %s",
+				versionName(version), i, cfg.seed, msg, describe(minimal))
+		}
+	}
+}
+
+// shrinkFailure repeatedly replaces v by the first of its Shrink
+// candidates that still fails, until none does, and returns the last
+// failing variable with its failure.
+func shrinkFailure(v *types.Variable, msg string, fails func(*types.Variable) string) (*types.Variable, string) {
+	for {
+		shrunk := false
+		for _, candidate := range Shrink(v) {
+			if m := fails(candidate); m != "" {
+				v, msg, shrunk = candidate, m, true
+				break
+			}
+		}
+		if !shrunk {
+			return v, msg
+		}
+	}
+}
+
+// RandomVariable returns a random variable for property tests: a numeric,
+// logical or character array of 2 to 4 dimensions, each of 1 to 4
+// elements. Numeric arrays are complex one time in four, and float data
+// includes NaN, infinities and signed zeros.
+//
+// Example:
+//
+//	r := rand.New(rand.NewSource(1))
+//	v := mattest.RandomVariable(r)
+//	mattest.AssertRoundTrip(t, v)
+func RandomVariable(r *rand.Rand) *types.Variable {
+	dims := make([]int, 2+r.Intn(3))
+	n := 1
+	for i := range dims {
+		dims[i] = 1 + r.Intn(4)
+		n *= dims[i]
+	}
+
+	dataType := propertyTypes[r.Intn(len(propertyTypes))]
+	v := &types.Variable{
+		Name:       fmt.Sprintf("v%d", r.Intn(1000)),
+		Dimensions: dims,
+		DataType:   dataType,
+	}
+	switch dataType {
+	case types.Logical:
+		data := make([]bool, n)
+		for i := range data {
+			data[i] = r.Intn(2) == 1
+		}
+		v.Data = data
+	case types.Char:
+		text := make([]byte, n)
+		for i := range text {
+			text[i] = byte('a' + r.Intn(26))
+		}
+		v.Data = string(text)
+	default:
+		v.Data = randomNumbers(r, dataType, n)
+		if r.Intn(4) == 0 {
+			v.IsComplex = true
+			v.Data = &types.NumericArray{Real: v.Data, Imag: randomNumbers(r, dataType, n)}
+		}
+	}
+	return v
+}
+
+// randomNumbers returns n random values of a numeric class.
+func randomNumbers(r *rand.Rand, dataType types.DataType, n int) interface{} {
+	specials := []float64{math.NaN(), math.Inf(1), math.Inf(-1), math.Copysign(0, -1)}
+	float := func() float64 {
+		if r.Intn(8) == 0 {
+			return specials[r.Intn(len(specials))]
+		}
+		return r.NormFloat64() * math.Pow(10, float64(r.Intn(20)-10))
+	}
+	switch dataType {
+	case types.Double:
+		data := make([]float64, n)
+		for i := range data {
+			data[i] = float()
+		}
+		return data
+	case types.Single:
+		data := make([]float32, n)
+		for i := range data {
+			data[i] = float32(float())
+		}
+		return data
+	}
+
+	// Integers: fill the slice with random bytes of its element size
+	slice := reflect.MakeSlice(reflect.SliceOf(integerType(dataType)), n, n)
+	for i := 0; i < n; i++ {
+		elem := slice.Index(i)
+		if elem.CanInt() {
+			elem.SetInt(int64(r.Uint64()))
+		} else {
+			elem.SetUint(r.Uint64())
+		}
+	}
+	return slice.Interface()
+}
+
+// integerType returns the Go element type of an integer class.
+func integerType(dataType types.DataType) reflect.Type {
+	switch dataType {
+	case types.Int8:
+		return reflect.TypeOf(int8(0))
+	case types.Uint8:
+		return reflect.TypeOf(uint8(0))
+	case types.Int16:
+		return reflect.TypeOf(int16(0))
+	case types.Uint16:
+		return reflect.TypeOf(uint16(0))
+	case types.Int32:
+		return reflect.TypeOf(int32(0))
+	case types.Uint32:
+		return reflect.TypeOf(uint32(0))
+	case types.Int64:
+		return reflect.TypeOf(int64(0))
+	default:
+		return reflect.TypeOf(uint64(0))
+	}
+}
+
+// Shrink returns smaller variants of a variable generated by
+// RandomVariable, simplest first: the real part of a complex array, the
+// array cut down to its first element along one dimension, and the array
+// with its first element only. CheckRoundTrip uses it to report minimal
+// failing variables.
+func Shrink(v *types.Variable) []*types.Variable {
+	var candidates []*types.Variable
+	if numArray, ok := v.Data.(*types.NumericArray); ok && v.IsComplex {
+		realPart := *v
+		realPart.IsComplex = false
+		realPart.Data = numArray.Real
+		candidates = append(candidates, &realPart)
+	}
+	if numElements(v.Dimensions) > 1 {
+		first := make([]int, len(v.Dimensions))
+		for i := range first {
+			first[i] = 1
+		}
+		candidates = append(candidates, slab(v, first))
+	}
+	for k, d := range v.Dimensions {
+		if d > 1 {
+			keep := append([]int(nil), v.Dimensions...)
+			keep[k] = d - 1
+			candidates = append(candidates, slab(v, keep))
+		}
+	}
+	if len(v.Dimensions) > 2 {
+		last := len(v.Dimensions) - 1
+		keep := append([]int(nil), v.Dimensions...)
+		keep[last] = 1
+		dropped := slab(v, keep)
+		dropped.Dimensions = dropped.Dimensions[:last]
+		candidates = append(candidates, dropped)
+	}
+	return candidates
+}
+
+// slab returns a copy of v holding its leading keep[k] elements along
+// every dimension k.
+func slab(v *types.Variable, keep []int) *types.Variable {
+	var indices []int
+	index := make([]int, len(keep))
+	for i := 0; i < numElements(keep); i++ {
+		linear, stride := 0, 1
+		for k := range index {
+			linear += index[k] * stride
+			stride *= v.Dimensions[k]
+		}
+		indices = append(indices, linear)
+		for k := range index {
+			if index[k]++; index[k] < keep[k] {
+				break
+			}
+			index[k] = 0
+		}
+	}
+
+	out := *v
+	out.Dimensions = keep
+	switch data := v.Data.(type) {
+	case string:
+		text := make([]byte, len(indices))
+		for i, j := range indices {
+			text[i] = data[j]
+		}
+		out.Data = string(text)
+	case *types.NumericArray:
+		out.Data = &types.NumericArray{Real: pick(data.Real, indices), Imag: pick(data.Imag, indices)}
+	default:
+		out.Data = pick(data, indices)
+	}
+	return &out
+}
+
+// pick returns the elements of a slice at the given indices.
+func pick(data interface{}, indices []int) interface{} {
+	src := reflect.ValueOf(data)
+	dst := reflect.MakeSlice(src.Type(), len(indices), len(indices))
+	for i, j := range indices {
+		dst.Index(i).Set(src.Index(j))
+	}
+	return dst.Interface()
+}
+
+// numElements returns the product of the dimensions.
+func numElements(dims []int) int {
+	n := 1
+	for _, d := range dims {
+		n *= d
+	}
+	return n
+}
+
+// describe formats a variable as a Go literal for a failure report.
+func describe(v *types.Variable) string {
+	class := v.DataType.String()
+	class = strings.ToUpper(class[:1]) + class[1:] // Constant name, e.g. types.Int8
+	if numArray, ok := v.Data.(*types.NumericArray); ok {
+		return fmt.Sprintf("&types.Variable{Name: %q, Dimensions: %#v, DataType: types.%s, IsComplex: true, "+
+			"Data: &types.NumericArray{Real: %#v, Imag: %#v}}", v.Name, v.Dimensions, class, numArray.Real, numArray.Imag)
+	}
+	return fmt.Sprintf("&types.Variable{Name: %q, Dimensions: %#v, DataType: types.%s, Data: %#v}",
+		v.Name, v.Dimensions, class, v.Data)
+}
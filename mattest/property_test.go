@@ -0,0 +1,102 @@
+package mattest
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestCheckRoundTrip(t *testing.T) {
+	CheckRoundTrip(t, WithCount(200), WithSeed(1))
+}
+
+func TestRandomVariable(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	classes := make(map[types.DataType]bool)
+	for i := 0; i < 500; i++ {
+		v := RandomVariable(r)
+		classes[v.DataType] = true
+		if len(v.Dimensions) < 2 || len(v.Dimensions) > 4 {
+			t.Fatalf("%s: dimensions %v", v.Name, v.Dimensions)
+		}
+		if got := elementCount(v); got != numElements(v.Dimensions) {
+			t.Fatalf("%s: %d elements for dimensions %v", v.Name, got, v.Dimensions)
+		}
+	}
+	if len(classes) != len(propertyTypes) {
+		t.Errorf("generated %d classes, want %d", len(classes), len(propertyTypes))
+	}
+}
+
+func TestShrink(t *testing.T) {
+	// 2x3 complex matrix, column-major
+	v := &types.Variable{Name: "z", Dimensions: []int{2, 3}, DataType: types.Int16, IsComplex: true,
+		Data: &types.NumericArray{Real: []int16{1, 2, 3, 4, 5, 6}, Imag: []int16{-1, -2, -3, -4, -5, -6}}}
+	candidates := Shrink(v)
+	if len(candidates) != 4 {
+		t.Fatalf("Shrink() returned %d candidates, want 4", len(candidates))
+	}
+	if c := candidates[0]; c.IsComplex || !reflect.DeepEqual(c.Data, []int16{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("real part = %+v", c)
+	}
+	// Two columns kept
+	if c := candidates[3]; !reflect.DeepEqual(c.Dimensions, []int{2, 2}) ||
+		!reflect.DeepEqual(c.Data.(*types.NumericArray).Real, []int16{1, 2, 3, 4}) {
+		t.Errorf("columns cut = %v %+v", c.Dimensions, c.Data)
+	}
+	// First row kept
+	if c := candidates[2]; !reflect.DeepEqual(c.Dimensions, []int{1, 3}) ||
+		!reflect.DeepEqual(c.Data.(*types.NumericArray).Imag, []int16{-1, -3, -5}) {
+		t.Errorf("rows cut = %v %+v", c.Dimensions, c.Data)
+	}
+
+	text := &types.Variable{Name: "s", Dimensions: []int{2, 1, 2}, DataType: types.Char, Data: "abcd"}
+	last := Shrink(text)
+	if c := last[len(last)-1]; !reflect.DeepEqual(c.Dimensions, []int{2, 1}) || c.Data != "ab" {
+		t.Errorf("last dimension dropped = %v %q", c.Dimensions, c.Data)
+	}
+	scalar := &types.Variable{Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}
+	if got := Shrink(scalar); len(got) != 0 {
+		t.Errorf("Shrink(scalar) = %v", got)
+	}
+}
+
+func TestShrinkFailure(t *testing.T) {
+	// Fails while the data holds a negative value; the first element is one
+	v := &types.Variable{Name: "m", Dimensions: []int{3, 2, 2}, DataType: types.Double, IsComplex: true,
+		Data: &types.NumericArray{Real: []float64{-1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, Imag: make([]float64, 12)}}
+	fails := func(v *types.Variable) string {
+		data, ok := v.Data.([]float64)
+		if !ok {
+			data = v.Data.(*types.NumericArray).Real.([]float64)
+		}
+		for _, x := range data {
+			if x < 0 {
+				return "negative"
+			}
+		}
+		return ""
+	}
+	minimal, msg := shrinkFailure(v, "negative", fails)
+	if msg != "negative" || minimal.IsComplex || !reflect.DeepEqual(minimal.Data, []float64{-1}) {
+		t.Errorf("shrinkFailure() = %s, %q", describe(minimal), msg)
+	}
+	if got := describe(minimal); !strings.Contains(got, "DataType: types.Double") {
+		t.Errorf("describe() = %s", got)
+	}
+}
+
+// elementCount returns the number of elements of generated data.
+func elementCount(v *types.Variable) int {
+	switch data := v.Data.(type) {
+	case string:
+		return len(data)
+	case *types.NumericArray:
+		return reflect.ValueOf(data.Real).Len()
+	default:
+		return reflect.ValueOf(data).Len()
+	}
+}
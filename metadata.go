@@ -0,0 +1,75 @@
+package matlab
+
+import (
+	"io"
+
+	"github.com/scigolib/matlab/internal/v5"
+	"github.com/scigolib/matlab/types"
+)
+
+// Metadata describes a MAT-file's variables without their data.
+type Metadata struct {
+	Version     string                // MAT-file version (e.g., "5.0", "7.3")
+	Endian      string                // Byte order indicator ("MI" or "IM"), v5 only
+	Description string                // File description from header, v5 only
+	Variables   []*types.VariableInfo // Variables in file order
+}
+
+// OpenMetadata reads the names, classes, dimensions and byte extents of
+// all variables in a MAT-file without loading their data.
+//
+// Data payloads are skipped rather than read into memory, which makes it
+// practical to index large collections of files. Compressed v5 variables
+// are inflated only as far as their array header. v7.3 files are described
+// from their HDF5 object headers and attributes.
+//
+// The WithMaxDepth, WithMaxObjects, WithTempDir and WithTempPrefix
+// options apply to v7.3 files.
+//
+// Example:
+//
+//	file, _ := os.Open("data.mat")
+//	defer file.Close()
+//	meta, err := matlab.OpenMetadata(file)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, v := range meta.Variables {
+//	    fmt.Println(v.Name, v.DataType, v.Dimensions, v.Size)
+//	}
+func OpenMetadata(r io.Reader, opts ...OpenOption) (*Metadata, error) {
+	cfg := defaultOpenConfig()
+	applyOpenOptions(cfg, opts)
+	src, err := newSource(r)
+	if err != nil {
+		return nil, err
+	}
+	defer src.close()
+
+	if isHDF5Format(src.header) {
+		infos, err := newV73Parser(cfg, src.path).ParseMetadata(src.r)
+		if err != nil {
+			return nil, err
+		}
+		return &Metadata{Version: "7.3", Variables: infos}, nil
+	}
+
+	if isV5Format(src.header) {
+		parser, err := v5.NewParser(src.r)
+		if err != nil {
+			return nil, err
+		}
+		infos, err := parser.ParseMetadata()
+		if err != nil {
+			return nil, err
+		}
+		return &Metadata{
+			Version:     "5.0",
+			Endian:      parser.Header.EndianIndicator,
+			Description: parser.Header.Description,
+			Variables:   infos,
+		}, nil
+	}
+
+	return nil, ErrInvalidFormat
+}
@@ -0,0 +1,164 @@
+package matlab
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// TestOpenMetadata_V5 tests that v5 metadata matches the variables read by
+// Open and that the byte extents tile the file after the header.
+func TestOpenMetadata_V5(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "meta.mat")
+	writer, err := Create(tmpfile, Version5)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	vars := []*types.Variable{
+		{Name: "A", Dimensions: []int{2, 3}, DataType: types.Double, Data: []float64{1, 2, 3, 4, 5, 6}},
+		{Name: "mask", Dimensions: []int{1, 3}, DataType: types.Logical, Data: []bool{true, false, true}},
+		{Name: "z", Dimensions: []int{1, 2}, DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1, 2}, Imag: []float64{3, 4}}},
+		{Name: "label", Dimensions: []int{1, 5}, DataType: types.Char, Data: "hello"},
+	}
+	for _, v := range vars {
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	file, err := os.Open(tmpfile)
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer file.Close()
+
+	meta, err := OpenMetadata(file)
+	if err != nil {
+		t.Fatalf("OpenMetadata() error = %v", err)
+	}
+	if meta.Version != "5.0" || meta.Endian != "IM" {
+		t.Errorf("Version, Endian = %q, %q, want 5.0, IM", meta.Version, meta.Endian)
+	}
+	if len(meta.Variables) != len(vars) {
+		t.Fatalf("got %d variables, want %d", len(meta.Variables), len(vars))
+	}
+
+	offset := int64(128)
+	for i, info := range meta.Variables {
+		want := vars[i]
+		if info.Name != want.Name || info.DataType != want.DataType || info.IsComplex != want.IsComplex {
+			t.Errorf("variable %d = %s %s complex=%v, want %s %s complex=%v",
+				i, info.Name, info.DataType, info.IsComplex, want.Name, want.DataType, want.IsComplex)
+		}
+		if !reflect.DeepEqual(info.Dimensions, want.Dimensions) {
+			t.Errorf("%s: Dimensions = %v, want %v", info.Name, info.Dimensions, want.Dimensions)
+		}
+		if info.Offset != offset {
+			t.Errorf("%s: Offset = %d, want %d", info.Name, info.Offset, offset)
+		}
+		offset += info.Size
+	}
+
+	stat, err := os.Stat(tmpfile)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if offset != stat.Size() {
+		t.Errorf("extents end at %d, file size is %d", offset, stat.Size())
+	}
+}
+
+// TestOpenMetadata_V5Compressed tests metadata of compressed MATLAB files.
+func TestOpenMetadata_V5Compressed(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "scipy", "testmatrix_7.4_GLNX86.mat"))
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer file.Close()
+
+	meta, err := OpenMetadata(file)
+	if err != nil {
+		t.Fatalf("OpenMetadata() error = %v", err)
+	}
+	if len(meta.Variables) != 1 {
+		t.Fatalf("got %d variables, want 1", len(meta.Variables))
+	}
+	info := meta.Variables[0]
+	if info.Name != "testmatrix" || !reflect.DeepEqual(info.Dimensions, []int{3, 5}) {
+		t.Errorf("got %s %v, want testmatrix [3 5]", info.Name, info.Dimensions)
+	}
+	if !info.Compressed || info.Offset != 128 || info.Size <= 0 {
+		t.Errorf("Compressed, Offset, Size = %v, %d, %d", info.Compressed, info.Offset, info.Size)
+	}
+}
+
+// TestOpenMetadata_V73 tests metadata of a v7.3 file.
+func TestOpenMetadata_V73(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "meta73.mat")
+	writer, err := Create(tmpfile, Version73)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := writer.WriteVariable(&types.Variable{
+		Name: "A", Dimensions: []int{3, 2}, DataType: types.Int32, Data: []int32{1, 2, 3, 4, 5, 6},
+	}); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	if err := writer.WriteVariable(&types.Variable{
+		Name: "z", Dimensions: []int{2}, DataType: types.Double, IsComplex: true,
+		Data: &types.NumericArray{Real: []float64{1, 2}, Imag: []float64{3, 4}},
+	}); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	file, err := os.Open(tmpfile)
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer file.Close()
+
+	meta, err := OpenMetadata(file)
+	if err != nil {
+		t.Fatalf("OpenMetadata() error = %v", err)
+	}
+	if meta.Version != "7.3" || len(meta.Variables) != 2 {
+		t.Fatalf("Version = %q with %d variables, want 7.3 with 2", meta.Version, len(meta.Variables))
+	}
+
+	a := meta.Variables[0]
+	if a.Name != "A" || a.DataType != types.Int32 || !reflect.DeepEqual(a.Dimensions, []int{3, 2}) {
+		t.Errorf("got %s %s %v, want A int32 [3 2]", a.Name, a.DataType, a.Dimensions)
+	}
+	if a.Offset <= 0 || a.Size != 24 {
+		t.Errorf("A: Offset, Size = %d, %d, want contiguous 24 bytes", a.Offset, a.Size)
+	}
+
+	z := meta.Variables[1]
+	if z.Name != "z" || !z.IsComplex || z.DataType != types.Double || z.Offset != -1 || z.Size != 32 {
+		t.Errorf("z = %+v", *z)
+	}
+}
+
+// TestOpenMetadata_Invalid tests rejection of non-MAT data.
+func TestOpenMetadata_Invalid(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "generated", "README.md"))
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := OpenMetadata(file); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("OpenMetadata() error = %v, want ErrInvalidFormat", err)
+	}
+}
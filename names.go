@@ -0,0 +1,232 @@
+package matlab
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// DefaultMaxNameLength is the longest variable name, in bytes, kept as
+// stored when reading. MATLAB itself allows 63 characters; the larger
+// default accepts the longer names other writers produce.
+const DefaultMaxNameLength = 1024
+
+// InvalidNameWarning reports a variable renamed while reading because its
+// stored name contained NUL or other control characters, invalid UTF-8
+// or path separators, or was longer than the limit set with
+// WithMaxNameLength. It is recorded
+// in MatFile.Warnings.
+//
+// Example:
+//
+//	for _, w := range matFile.Warnings {
+//	    var bad *matlab.InvalidNameWarning
+//	    if errors.As(w, &bad) {
+//	        log.Printf("renamed %q to %q: %s", bad.Name, bad.Replacement, bad.Reason)
+//	    }
+//	}
+type InvalidNameWarning struct {
+	Name        string // Name as stored in the file
+	Replacement string // Name the variable was given
+	Reason      string // What was wrong with Name
+}
+
+// Error implements the error interface.
+func (w *InvalidNameWarning) Error() string {
+	return fmt.Sprintf("%v %q renamed to %q: %s", ErrInvalidName, w.Name, w.Replacement, w.Reason)
+}
+
+// Unwrap returns ErrInvalidName, so the warning matches it with errors.Is.
+func (w *InvalidNameWarning) Unwrap() error {
+	return ErrInvalidName
+}
+
+// sanitizeName returns the name to give a variable stored as name: each
+// control character, run of invalid UTF-8 and path separator replaced
+// with '_', truncated to at most maxLen bytes at a character boundary,
+// then "." and ".." replaced with underscores and an empty name with
+// "_", so the result is safe to use as a file name. With paths set, as for the HDF5 paths of flattened
+// groups, '/' separates elements that are each checked instead. reason is
+// "" if name is kept as is.
+func sanitizeName(name string, maxLen int, paths bool) (replacement, reason string) {
+	var reasons []string
+	replacement = name
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 || !utf8.ValidString(name) {
+		reasons = append(reasons, "control character or invalid UTF-8")
+		replacement = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return '_'
+			}
+			return r
+		}, strings.ToValidUTF8(name, "_"))
+	}
+	separators := `/\`
+	if paths {
+		separators = `\`
+	}
+	if strings.ContainsAny(replacement, separators) {
+		reasons = append(reasons, "path separator")
+		replacement = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(separators, r) {
+				return '_'
+			}
+			return r
+		}, replacement)
+	}
+	if len(replacement) > maxLen {
+		reasons = append(reasons, fmt.Sprintf("longer than %d bytes", maxLen))
+		cut := maxLen
+		for cut > 0 && !utf8.RuneStart(replacement[cut]) {
+			cut--
+		}
+		replacement = replacement[:cut]
+	}
+	// Checked after truncating, which can leave "..", or nothing
+	elements := strings.Split(replacement, "/")
+	relative := false
+	for i, element := range elements {
+		if element == "." || element == ".." {
+			relative = true
+			elements[i] = strings.Repeat("_", len(element))
+		}
+	}
+	if relative {
+		reasons = append(reasons, "relative path element")
+		replacement = strings.Join(elements, "/")
+	}
+	if replacement == "" {
+		reasons = append(reasons, "empty")
+		replacement = "_"
+	}
+	return replacement, strings.Join(reasons, "; ")
+}
+
+// nameSanitizer returns the transform that renames variables with invalid
+// names, recording a warning for each on c.
+func (c *openConfig) nameSanitizer() func(*types.Variable) (*types.Variable, error) {
+	maxLen := c.maxNameLength
+	if maxLen <= 0 {
+		maxLen = DefaultMaxNameLength
+	}
+	return func(v *types.Variable) (*types.Variable, error) {
+		replacement, reason := sanitizeName(v.Name, maxLen, c.flattenGroups)
+		if reason != "" {
+			c.warnings = append(c.warnings, &InvalidNameWarning{Name: v.Name, Replacement: replacement, Reason: reason})
+			v.Name = replacement
+		}
+		return v, nil
+	}
+}
+
+// WithNameNormalization renames each top-level variable to fn(name) as it
+// is read, such as strings.ToLower for vendor files that are inconsistent
+// about case. Variables whose normalized names collide are kept apart
+// deterministically: the first in file order gets the name, later ones
+// the name with a suffix _2, _3 and so on. MatFile.StoredNames maps each
+// renamed variable to its stored name.
+//
+// Normalization runs after the checks of WithNameSanitizing and before
+// any WithTransform function or WithNamePattern sees the variable. Struct
+// field names are not changed.
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithNameNormalization(strings.ToLower))
+//	v := matFile.GetVariable("temperature") // stored as "Temperature"
+//	fmt.Println(matFile.StoredNames["temperature"]) // Temperature
+func WithNameNormalization(fn func(string) string) OpenOption {
+	return func(c *openConfig) {
+		c.normalizeName = fn
+	}
+}
+
+// nameNormalizer returns the transform that renames variables with
+// normalizeName, recording the stored names of renamed ones on c.
+func (c *openConfig) nameNormalizer() func(*types.Variable) (*types.Variable, error) {
+	seen := make(map[string]bool)
+	return func(v *types.Variable) (*types.Variable, error) {
+		base := c.normalizeName(v.Name)
+		name := base
+		for n := 2; seen[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		seen[name] = true
+		if name != v.Name {
+			if c.storedNames == nil {
+				c.storedNames = make(map[string]string)
+			}
+			c.storedNames[name] = v.Name
+			v.Name = name
+		}
+		return v, nil
+	}
+}
+
+// GetVariableCaseInsensitive retrieves a variable by name ignoring case,
+// for files whose writers are inconsistent about it. An exact match, as
+// GetVariable finds, is preferred; otherwise the first top-level variable
+// in file order whose name equals name under Unicode case folding is
+// returned. Returns nil if there is none.
+//
+// Example:
+//
+//	v := matFile.GetVariableCaseInsensitive("temperature") // finds "Temperature" or "TEMPERATURE"
+func (m *MatFile) GetVariableCaseInsensitive(name string) *types.Variable {
+	if v := m.GetVariable(name); v != nil {
+		return v
+	}
+	for _, v := range m.Variables {
+		if strings.EqualFold(v.Name, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+// storedName returns the name variable name had in the file.
+func (m *MatFile) storedName(name string) string {
+	if stored, ok := m.StoredNames[name]; ok {
+		return stored
+	}
+	return name
+}
+
+// ReservedNameWarning reports a variable written under the name of a
+// MATLAB keyword or a commonly used function or constant, such as end, pi
+// or i. MATLAB loads the variable, but it hides the function or constant
+// in the workspace. It is recorded in MatFileWriter.Warnings unless
+// WithStrictReservedNames rejects such variables instead.
+//
+// Example:
+//
+//	for _, w := range writer.Warnings() {
+//	    var reserved *matlab.ReservedNameWarning
+//	    if errors.As(w, &reserved) {
+//	        log.Printf("%s will shadow a MATLAB name", reserved.Name)
+//	    }
+//	}
+type ReservedNameWarning struct {
+	Name string // Name of the variable
+	Err  error  // Error of types.CheckReservedName, wrapping ErrReservedName
+}
+
+// Error implements the error interface.
+func (w *ReservedNameWarning) Error() string {
+	return fmt.Sprintf("variable %s written: %v", w.Name, w.Err)
+}
+
+// Unwrap returns the error of types.CheckReservedName, so the warning
+// matches ErrReservedName with errors.Is.
+func (w *ReservedNameWarning) Unwrap() error {
+	return w.Err
+}
+
+// Warnings returns the problems noted so far that did not prevent a
+// write, such as *ReservedNameWarning for each variable with a reserved
+// name, in the order the variables were written.
+func (w *MatFileWriter) Warnings() []error {
+	return append([]error(nil), w.warnings...)
+}
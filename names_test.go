@@ -0,0 +1,209 @@
+package matlab
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// v5FileNamed returns a v5 file holding one double scalar stored under
+// name, which the writer would reject.
+func v5FileNamed(t *testing.T, name string) []byte {
+	t.Helper()
+	placeholder := strings.Repeat("z", len(name))
+	path := filepath.Join(t.TempDir(), "names.mat")
+	w, err := Create(path, Version5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteVariable(&types.Variable{
+		Name: placeholder, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Replace(data, []byte(placeholder), []byte(name), 1)
+}
+
+func TestOpen_SanitizesNames(t *testing.T) {
+	data := v5FileNamed(t, "ab\x00\x1bcd")
+
+	matFile, err := Open(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got := matFile.Variables[0].Name; got != "ab__cd" {
+		t.Errorf("name = %q, want %q", got, "ab__cd")
+	}
+	if len(matFile.Warnings) != 1 {
+		t.Fatalf("Warnings = %v, want one", matFile.Warnings)
+	}
+	var warning *InvalidNameWarning
+	if !errors.As(matFile.Warnings[0], &warning) {
+		t.Fatalf("warning %T is not an *InvalidNameWarning", matFile.Warnings[0])
+	}
+	if warning.Name != "ab\x00\x1bcd" || warning.Replacement != "ab__cd" {
+		t.Errorf("warning = %+v", warning)
+	}
+	if !errors.Is(warning, ErrInvalidName) {
+		t.Error("warning does not match ErrInvalidName")
+	}
+
+	var seen string
+	_, err = Open(bytes.NewReader(data), WithTransform(func(v *types.Variable) (*types.Variable, error) {
+		seen = v.Name
+		return v, nil
+	}))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if seen != "ab__cd" {
+		t.Errorf("transform saw %q, want the sanitized name", seen)
+	}
+
+	matFile, err = Open(bytes.NewReader(data), WithNameSanitizing(false))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got := matFile.Variables[0].Name; got != "ab\x00\x1bcd" || len(matFile.Warnings) != 0 {
+		t.Errorf("WithNameSanitizing(false): name = %q, warnings = %v", got, matFile.Warnings)
+	}
+}
+
+func TestOpen_MaxNameLength(t *testing.T) {
+	data := v5FileNamed(t, "temperature")
+
+	matFile, err := Open(bytes.NewReader(data), WithMaxNameLength(4))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got := matFile.Variables[0].Name; got != "temp" {
+		t.Errorf("name = %q, want %q", got, "temp")
+	}
+	if len(matFile.Warnings) != 1 {
+		t.Errorf("Warnings = %v, want one", matFile.Warnings)
+	}
+
+	matFile, err = Open(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got := matFile.Variables[0].Name; got != "temperature" || len(matFile.Warnings) != 0 {
+		t.Errorf("default limit: name = %q, warnings = %v", got, matFile.Warnings)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name, want string
+		maxLen     int
+		paths      bool
+	}{
+		{"ok_name", "ok_name", 10, false},
+		{"tab\there", "tab_here", 10, false},
+		{"bad\xff\xfeutf8", "bad_utf8", 10, false},
+		{"trailing\x7f", "trailing_", 10, false},
+		{"température", "tempé", 6, false}, // not cut inside 'é'
+		{"température", "temp", 5, false},
+		{"../../etc/x", ".._.._etc_x", 20, false},
+		{`a\b`, "a_b", 10, false},
+		{"..", "__", 10, false},
+		{".", "_", 10, false},
+		{"/run1/x", "/run1/x", 10, true},
+		{"/../x", "/__/x", 10, true},
+		{`/a\b/x`, "/a_b/x", 10, true},
+		// Truncation leaves a relative element or nothing
+		{"..abc", "__", 2, false},
+		{"a/...", "a/__", 4, true},
+		{"été", "_", 1, false},
+		{"", "_", 10, false},
+	}
+	for _, tt := range tests {
+		got, reason := sanitizeName(tt.name, tt.maxLen, tt.paths)
+		if got != tt.want {
+			t.Errorf("sanitizeName(%q, %d) = %q, want %q", tt.name, tt.maxLen, got, tt.want)
+		}
+		if (reason == "") != (tt.name == tt.want) {
+			t.Errorf("sanitizeName(%q, %d) reason = %q", tt.name, tt.maxLen, reason)
+		}
+	}
+}
+
+func TestWithNameNormalization(t *testing.T) {
+	scalar := func(name string, x float64) *types.Variable {
+		return &types.Variable{Name: name, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{x},
+			Attributes: map[string]interface{}{"units": name}}
+	}
+	for _, version := range []Version{Version5, Version73} {
+		path := filepath.Join(t.TempDir(), "vendor.mat")
+		w, err := Create(path, version, WithAttributesVariable())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// File order: Temp, TEMP, temp_2, other (v7.3 orders by name)
+		if err := w.WriteVariables(scalar("Temp", 1), scalar("TEMP", 2), scalar("temp_2", 3), scalar("other", 4)); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		matFile, err := openPath(path, []OpenOption{WithNameNormalization(strings.ToLower)})
+		if err != nil {
+			t.Fatalf("v%d: Open() error = %v", version, err)
+		}
+		names := matFile.GetVariableNames()
+		stored := make(map[string]string)
+		for _, name := range names {
+			stored[name] = matFile.storedName(name)
+		}
+		// The first of Temp and TEMP in file order gets temp and the other
+		// temp_2, so the variable stored as temp_2 comes after them
+		first, second := "Temp", "TEMP"
+		if version == Version73 {
+			first, second = second, first
+		}
+		want := map[string]string{"temp": first, "temp_2": second, "temp_2_2": "temp_2", "other": "other"}
+		if len(stored) != len(want) {
+			t.Fatalf("v%d: names = %v, want %v", version, names, want)
+		}
+		for name, s := range want {
+			if stored[name] != s {
+				t.Errorf("v%d: %s stored as %q, want %q", version, name, stored[name], s)
+			}
+		}
+		if _, ok := matFile.StoredNames["other"]; ok {
+			t.Errorf("v%d: StoredNames = %v, want only renamed variables", version, matFile.StoredNames)
+		}
+		if v := matFile.GetVariable("temp_2"); v == nil || v.Attributes["units"] != second {
+			t.Errorf("v%d: temp_2 = %v, want the attributes of %s", version, v, second)
+		}
+	}
+}
+
+func TestMatFile_GetVariableCaseInsensitive(t *testing.T) {
+	scalar := func(name string) *types.Variable {
+		return &types.Variable{Name: name, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}
+	}
+	matFile := &MatFile{Variables: []*types.Variable{scalar("Speed"), scalar("SPEED"), scalar("speed")}}
+	if v := matFile.GetVariableCaseInsensitive("speed"); v != matFile.Variables[2] {
+		t.Errorf("GetVariableCaseInsensitive(speed) = %v, want the exact match", v)
+	}
+	if v := matFile.GetVariableCaseInsensitive("sPeEd"); v != matFile.Variables[0] {
+		t.Errorf("GetVariableCaseInsensitive(sPeEd) = %v, want the first match", v)
+	}
+	if v := matFile.GetVariableCaseInsensitive("velocity"); v != nil {
+		t.Errorf("GetVariableCaseInsensitive(velocity) = %v, want nil", v)
+	}
+}
@@ -0,0 +1,398 @@
+// Package netcdfio exports MATLAB variables to NetCDF files, which many
+// geoscience tools read where they cannot read MAT-files.
+//
+// Each variable becomes a NetCDF variable of the same name. NetCDF lists
+// dimensions slowest-varying first, so the dimensions of a MATLAB array
+// are written in reverse order, as MATLAB's own netcdf functions do: a
+// 3x4 matrix x becomes x(x_dim2 = 4, x_dim1 = 3), with its elements in the
+// same order as in MATLAB. Dimensions are named <variable>_dim<k> unless
+// named with WithDimensionNames; variables naming the same dimension share
+// it.
+//
+// Types map as follows:
+//
+//	MATLAB                  NetCDF
+//	double                  double
+//	single                  float
+//	int8/16/32              byte, short, int
+//	uint8/16/32             byte, short, int with _Unsigned = "true"
+//	logical                 byte (0 or 1)
+//	int64, uint64           int64, uint64 (CDF-5 only)
+//
+// Files use the 64-bit offset format (CDF-2), or CDF-5 when they hold
+// int64 or uint64 data or a variable larger than 4 GiB. Sparse matrices
+// are written dense. Complex, character, struct and cell variables are
+// not supported.
+package netcdfio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// Header tags and external types of the classic NetCDF format.
+const (
+	tagDimension = 0x0A
+	tagVariable  = 0x0B
+	tagAttribute = 0x0C
+
+	ncByte   = 1
+	ncChar   = 2
+	ncShort  = 3
+	ncInt    = 4
+	ncFloat  = 5
+	ncDouble = 6
+	ncInt64  = 10
+	ncUint64 = 11
+)
+
+// maxClassicSize is the largest variable size CDF-2 can record.
+const maxClassicSize = math.MaxUint32 - 3
+
+// ErrUnsupported indicates a variable that cannot be exported.
+var ErrUnsupported = errors.New("unsupported by netcdfio")
+
+// Option configures ToNetCDF.
+type Option func(*config)
+
+// config holds optional configuration for ToNetCDF.
+type config struct {
+	units map[string]string   // Units per variable
+	dims  map[string][]string // Dimension names per variable, in MATLAB order
+}
+
+// WithUnits sets the units attribute of a variable, overriding a "units"
+// attribute the variable carries in Variable.Attributes.
+//
+// Example:
+//
+//	netcdfio.ToNetCDF(vars, "out.nc", netcdfio.WithUnits("temp", "K"))
+func WithUnits(variable, units string) Option {
+	return func(c *config) {
+		c.units[variable] = units
+	}
+}
+
+// WithDimensionNames names the dimensions of a variable, in MATLAB order.
+// Variables naming the same dimension share it, and must agree on its
+// length.
+//
+// Example:
+//
+//	// temp is 180x360 and pressure 180x360x12: latitude by longitude (by month)
+//	netcdfio.ToNetCDF(vars, "out.nc",
+//	    netcdfio.WithDimensionNames("temp", "lat", "lon"),
+//	    netcdfio.WithDimensionNames("pressure", "lat", "lon", "month"))
+func WithDimensionNames(variable string, names ...string) Option {
+	return func(c *config) {
+		c.dims[variable] = names
+	}
+}
+
+// ToNetCDF writes numeric and logical variables to a NetCDF file.
+//
+// Example:
+//
+//	matFile, _ := matlab.Open(file)
+//	err := netcdfio.ToNetCDF(matFile.Variables, "out.nc",
+//	    netcdfio.WithUnits("temp", "K"))
+func ToNetCDF(vars []*types.Variable, path string, opts ...Option) error {
+	data, err := Encode(vars, opts...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644) //nolint:gosec // G306: output files are meant to be shared
+}
+
+// ncDim is a NetCDF dimension.
+type ncDim struct {
+	name   string
+	length int
+}
+
+// ncAttr is a text attribute.
+type ncAttr struct {
+	name  string
+	value string
+}
+
+// ncVar is a NetCDF variable with its data in external representation.
+type ncVar struct {
+	name   string
+	dimIDs []int // Slowest-varying first
+	attrs  []ncAttr
+	ncType int32
+	data   []byte
+}
+
+// Encode returns the NetCDF file for the variables. See ToNetCDF.
+func Encode(vars []*types.Variable, opts ...Option) ([]byte, error) {
+	cfg := &config{units: make(map[string]string), dims: make(map[string][]string)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var dims []ncDim
+	dimIDs := make(map[string]int)
+	var variables []ncVar
+	seen := make(map[string]bool)
+	cdf5 := false
+	for _, v := range vars {
+		if v == nil {
+			return nil, errors.New("variable cannot be nil")
+		}
+		if seen[v.Name] {
+			return nil, fmt.Errorf("duplicate variable %q", v.Name)
+		}
+		seen[v.Name] = true
+
+		nv, err := encodeVariable(v, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if nv.ncType == ncInt64 || nv.ncType == ncUint64 || len(nv.data) > maxClassicSize {
+			cdf5 = true
+		}
+
+		names := cfg.dims[v.Name]
+		if names != nil && len(names) != len(v.Dimensions) {
+			return nil, fmt.Errorf("variable %q has %d dimensions, %d names given", v.Name, len(v.Dimensions), len(names))
+		}
+		for k := len(v.Dimensions) - 1; k >= 0; k-- {
+			name := fmt.Sprintf("%s_dim%d", v.Name, k+1)
+			if names != nil {
+				name = names[k]
+			}
+			if err := checkName(name); err != nil {
+				return nil, fmt.Errorf("dimension of %q: %w", v.Name, err)
+			}
+			id, ok := dimIDs[name]
+			if !ok {
+				id = len(dims)
+				dimIDs[name] = id
+				dims = append(dims, ncDim{name: name, length: v.Dimensions[k]})
+			} else if dims[id].length != v.Dimensions[k] {
+				return nil, fmt.Errorf("dimension %q has length %d in %q, %d before", name, v.Dimensions[k], v.Name, dims[id].length)
+			}
+			nv.dimIDs = append(nv.dimIDs, id)
+		}
+		variables = append(variables, nv)
+	}
+
+	// The header has the same size whatever the offsets it records, so
+	// encode it once to place the data, then again with the offsets.
+	begins := make([]int64, len(variables))
+	header := encodeHeader(dims, variables, begins, cdf5)
+	offset := int64(len(header))
+	for i, nv := range variables {
+		begins[i] = offset
+		offset += int64(padded(len(nv.data)))
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(int(offset))
+	buf.Write(encodeHeader(dims, variables, begins, cdf5))
+	for _, nv := range variables {
+		buf.Write(nv.data)
+		buf.Write(make([]byte, padded(len(nv.data))-len(nv.data)))
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeVariable converts a variable to its NetCDF type, attributes and
+// data. Dimension IDs are left to the caller.
+func encodeVariable(v *types.Variable, cfg *config) (ncVar, error) {
+	if err := checkName(v.Name); err != nil {
+		return ncVar{}, err
+	}
+	if v.IsComplex {
+		return ncVar{}, fmt.Errorf("complex variable %q: %w", v.Name, ErrUnsupported)
+	}
+	if v.IsSparse {
+		dense, err := v.ToDense()
+		if err != nil {
+			return ncVar{}, fmt.Errorf("sparse variable %q: %w", v.Name, err)
+		}
+		v = dense
+	}
+
+	n := 1
+	for _, d := range v.Dimensions {
+		if d <= 0 {
+			return ncVar{}, fmt.Errorf("empty variable %q: %w", v.Name, ErrUnsupported)
+		}
+		n *= d
+	}
+	if len(v.Dimensions) == 0 {
+		return ncVar{}, fmt.Errorf("variable %q has no dimensions", v.Name)
+	}
+
+	nv := ncVar{name: v.Name}
+	var values interface{}
+	var length int
+	switch data := v.Data.(type) {
+	case []float64:
+		nv.ncType, values, length = ncDouble, data, len(data)
+	case []float32:
+		nv.ncType, values, length = ncFloat, data, len(data)
+	case []int8:
+		nv.ncType, values, length = ncByte, data, len(data)
+	case []int16:
+		nv.ncType, values, length = ncShort, data, len(data)
+	case []int32:
+		nv.ncType, values, length = ncInt, data, len(data)
+	case []int64:
+		nv.ncType, values, length = ncInt64, data, len(data)
+	case []uint64:
+		nv.ncType, values, length = ncUint64, data, len(data)
+	case []uint8:
+		nv.ncType, values, length = ncByte, data, len(data)
+		nv.attrs = append(nv.attrs, ncAttr{"_Unsigned", "true"})
+	case []uint16:
+		nv.ncType, values, length = ncShort, data, len(data)
+		nv.attrs = append(nv.attrs, ncAttr{"_Unsigned", "true"})
+	case []uint32:
+		nv.ncType, values, length = ncInt, data, len(data)
+		nv.attrs = append(nv.attrs, ncAttr{"_Unsigned", "true"})
+	case []bool:
+		flags := make([]int8, len(data))
+		for i, b := range data {
+			if b {
+				flags[i] = 1
+			}
+		}
+		nv.ncType, values, length = ncByte, flags, len(data)
+	default:
+		return ncVar{}, fmt.Errorf("%s variable %q of type %T: %w", v.DataType, v.Name, v.Data, ErrUnsupported)
+	}
+	if length != n {
+		return ncVar{}, fmt.Errorf("variable %q has %d elements, dimensions %v", v.Name, length, v.Dimensions)
+	}
+
+	units, ok := cfg.units[v.Name]
+	if !ok {
+		units, ok = v.GetStringAttr("units")
+	}
+	if ok {
+		nv.attrs = append([]ncAttr{{"units", units}}, nv.attrs...)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(binary.Size(values))
+	if err := binary.Write(&buf, binary.BigEndian, values); err != nil {
+		return ncVar{}, fmt.Errorf("variable %q: %w", v.Name, err)
+	}
+	nv.data = buf.Bytes()
+	return nv, nil
+}
+
+// checkName checks that name can name a NetCDF object.
+func checkName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("empty name")
+	case !utf8.ValidString(name):
+		return fmt.Errorf("name %q is not valid UTF-8", name)
+	case strings.ContainsAny(name, "/\x00"):
+		return fmt.Errorf("name %q contains '/' or NUL, invalid in NetCDF", name)
+	}
+	return nil
+}
+
+// encodeHeader encodes the file header, with variable i at begins[i].
+func encodeHeader(dims []ncDim, variables []ncVar, begins []int64, cdf5 bool) []byte {
+	e := &encoder{cdf5: cdf5}
+	e.buf.WriteString("CDF")
+	if cdf5 {
+		e.buf.WriteByte(5)
+	} else {
+		e.buf.WriteByte(2)
+	}
+	e.count(0) // numrecs: no record variables
+
+	e.list(tagDimension, len(dims))
+	for _, d := range dims {
+		e.name(d.name)
+		e.count(d.length)
+	}
+
+	e.list(tagAttribute, 0) // No global attributes
+
+	e.list(tagVariable, len(variables))
+	for i, nv := range variables {
+		e.name(nv.name)
+		e.count(len(nv.dimIDs))
+		for _, id := range nv.dimIDs {
+			e.count(id)
+		}
+		e.list(tagAttribute, len(nv.attrs))
+		for _, attr := range nv.attrs {
+			e.name(attr.name)
+			e.int32(ncChar)
+			e.count(len(attr.value))
+			e.buf.WriteString(attr.value)
+			e.pad()
+		}
+		e.int32(nv.ncType)
+		e.count(padded(len(nv.data))) // vsize
+		_ = binary.Write(&e.buf, binary.BigEndian, begins[i])
+	}
+	return e.buf.Bytes()
+}
+
+// encoder writes header fields in big-endian order. Counts (NON_NEG in
+// the specification) take 4 bytes in CDF-2 and 8 bytes in CDF-5.
+type encoder struct {
+	buf  bytes.Buffer
+	cdf5 bool
+}
+
+// int32 writes a 4-byte integer.
+func (e *encoder) int32(v int32) {
+	_ = binary.Write(&e.buf, binary.BigEndian, v)
+}
+
+// count writes a non-negative count, length or ID.
+func (e *encoder) count(n int) {
+	if e.cdf5 {
+		_ = binary.Write(&e.buf, binary.BigEndian, int64(n))
+		return
+	}
+	_ = binary.Write(&e.buf, binary.BigEndian, uint32(n)) //nolint:gosec // G115: sizes over 4 GiB switch to CDF-5
+}
+
+// list writes the tag and length of a list, or ABSENT if it is empty.
+func (e *encoder) list(tag int32, n int) {
+	if n == 0 {
+		tag = 0
+	}
+	e.int32(tag)
+	e.count(n)
+}
+
+// name writes a length-prefixed name padded to 4 bytes.
+func (e *encoder) name(s string) {
+	e.count(len(s))
+	e.buf.WriteString(s)
+	e.pad()
+}
+
+// pad writes zero bytes up to the next 4-byte boundary.
+func (e *encoder) pad() {
+	for e.buf.Len()%4 != 0 {
+		e.buf.WriteByte(0)
+	}
+}
+
+// padded rounds n up to a multiple of 4.
+func padded(n int) int {
+	return (n + 3) &^ 3
+}
@@ -0,0 +1,232 @@
+package netcdfio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// ncFile is a NetCDF file decoded by readNetCDF.
+type ncFile struct {
+	version byte
+	dims    []ncDim
+	vars    map[string]decodedVar
+}
+
+// decodedVar is a variable decoded by readNetCDF.
+type decodedVar struct {
+	dims   []string // Slowest-varying first
+	attrs  map[string]string
+	ncType int32
+	data   []byte // vsize bytes at begin
+}
+
+// readNetCDF decodes the header of a CDF-2 or CDF-5 file written by
+// Encode, following the format specification independently of it.
+func readNetCDF(t *testing.T, data []byte) *ncFile {
+	t.Helper()
+	r := bytes.NewReader(data)
+	magic := make([]byte, 4)
+	if _, err := r.Read(magic); err != nil || string(magic[:3]) != "CDF" {
+		t.Fatalf("magic = %q", magic)
+	}
+	f := &ncFile{version: magic[3], vars: make(map[string]decodedVar)}
+	read32 := func() int64 {
+		var v int32
+		if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+			t.Fatalf("truncated header: %v", err)
+		}
+		return int64(v)
+	}
+	count := func() int64 {
+		if f.version == 5 {
+			var v int64
+			if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+				t.Fatalf("truncated header: %v", err)
+			}
+			return v
+		}
+		return read32()
+	}
+	text := func(n int64) string {
+		buf := make([]byte, (n+3)&^3)
+		if _, err := r.Read(buf); err != nil && n > 0 {
+			t.Fatalf("truncated header: %v", err)
+		}
+		return string(buf[:n])
+	}
+	list := func(tag int64) int64 {
+		got, n := read32(), count()
+		if got != tag && !(got == 0 && n == 0) {
+			t.Fatalf("list tag = %#x, want %#x", got, tag)
+		}
+		return n
+	}
+
+	if numrecs := count(); numrecs != 0 {
+		t.Errorf("numrecs = %d", numrecs)
+	}
+	for i := list(tagDimension); i > 0; i-- {
+		name := text(count())
+		f.dims = append(f.dims, ncDim{name: name, length: int(count())})
+	}
+	if n := list(tagAttribute); n != 0 {
+		t.Errorf("%d global attributes", n)
+	}
+	for i := list(tagVariable); i > 0; i-- {
+		name := text(count())
+		v := decodedVar{attrs: make(map[string]string)}
+		for j := count(); j > 0; j-- {
+			v.dims = append(v.dims, f.dims[count()].name)
+		}
+		for j := list(tagAttribute); j > 0; j-- {
+			attr := text(count())
+			if typ := read32(); typ != ncChar {
+				t.Fatalf("attribute %s has type %d", attr, typ)
+			}
+			v.attrs[attr] = text(count())
+		}
+		v.ncType = int32(read32())
+		vsize := count()
+		var begin int64
+		if err := binary.Read(r, binary.BigEndian, &begin); err != nil {
+			t.Fatal(err)
+		}
+		if begin%4 != 0 || begin+vsize > int64(len(data)) {
+			t.Fatalf("%s: begin %d, vsize %d in %d bytes", name, begin, vsize, len(data))
+		}
+		v.data = data[begin : begin+vsize]
+		f.vars[name] = v
+	}
+	return f
+}
+
+func TestEncode(t *testing.T) {
+	temp := &types.Variable{Name: "temp", Dimensions: []int{2, 3}, DataType: types.Double,
+		Data: []float64{1, 2, 3, 4, 5, 6}, Attributes: map[string]interface{}{"units": "degC"}}
+	vars := []*types.Variable{
+		temp,
+		{Name: "counts", Dimensions: []int{1, 3}, DataType: types.Uint16, Data: []uint16{1, 2, 65535}},
+		{Name: "mask", Dimensions: []int{2, 1}, DataType: types.Logical, Data: []bool{true, false}},
+		{Name: "lat", Dimensions: []int{2, 1}, DataType: types.Single, Data: []float32{-45, 45}},
+	}
+	data, err := Encode(vars,
+		WithUnits("temp", "K"), WithUnits("lat", "degrees_north"),
+		WithDimensionNames("temp", "lat", "lon"),
+		WithDimensionNames("lat", "lat", "one"))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	f := readNetCDF(t, data)
+	if f.version != 2 {
+		t.Errorf("version = %d, want 2 (64-bit offset)", f.version)
+	}
+
+	wantDims := []ncDim{{"lon", 3}, {"lat", 2}, {"counts_dim2", 3}, {"counts_dim1", 1}, {"mask_dim2", 1}, {"mask_dim1", 2}, {"one", 1}}
+	if !reflect.DeepEqual(f.dims, wantDims) {
+		t.Errorf("dims = %v, want %v", f.dims, wantDims)
+	}
+
+	tv := f.vars["temp"]
+	if !reflect.DeepEqual(tv.dims, []string{"lon", "lat"}) || tv.ncType != ncDouble || tv.attrs["units"] != "K" {
+		t.Errorf("temp = %v %d %v", tv.dims, tv.ncType, tv.attrs)
+	}
+	for i, want := range []float64{1, 2, 3, 4, 5, 6} {
+		if got := math.Float64frombits(binary.BigEndian.Uint64(tv.data[8*i:])); got != want {
+			t.Errorf("temp[%d] = %v, want %v", i, got, want)
+		}
+	}
+
+	cv := f.vars["counts"]
+	if cv.ncType != ncShort || cv.attrs["_Unsigned"] != "true" || len(cv.data) != 8 ||
+		binary.BigEndian.Uint16(cv.data[4:]) != 65535 {
+		t.Errorf("counts = %d %v % x", cv.ncType, cv.attrs, cv.data)
+	}
+	if mv := f.vars["mask"]; mv.ncType != ncByte || !bytes.Equal(mv.data, []byte{1, 0, 0, 0}) {
+		t.Errorf("mask = %d % x", mv.ncType, mv.data)
+	}
+	if lv := f.vars["lat"]; lv.ncType != ncFloat || lv.attrs["units"] != "degrees_north" ||
+		!reflect.DeepEqual(lv.dims, []string{"one", "lat"}) {
+		t.Errorf("lat = %v %d %v", lv.dims, lv.ncType, lv.attrs)
+	}
+	if temp.Attributes["units"] != "degC" {
+		t.Error("Encode() modified the variable")
+	}
+}
+
+func TestEncode_CDF5(t *testing.T) {
+	data, err := Encode([]*types.Variable{
+		{Name: "t", Dimensions: []int{1, 2}, DataType: types.Int64, Data: []int64{-1, 1 << 40}},
+		{Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{0.5}},
+	})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	f := readNetCDF(t, data)
+	if f.version != 5 {
+		t.Fatalf("version = %d, want 5", f.version)
+	}
+	tv := f.vars["t"]
+	if tv.ncType != ncInt64 || int64(binary.BigEndian.Uint64(tv.data[8:])) != 1<<40 {
+		t.Errorf("t = %d % x", tv.ncType, tv.data)
+	}
+	if xv := f.vars["x"]; math.Float64frombits(binary.BigEndian.Uint64(xv.data)) != 0.5 {
+		t.Errorf("x = % x", xv.data)
+	}
+}
+
+func TestEncode_Errors(t *testing.T) {
+	x := func() *types.Variable {
+		return &types.Variable{Name: "x", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}}
+	}
+	tests := []struct {
+		name        string
+		vars        []*types.Variable
+		opts        []Option
+		unsupported bool
+	}{
+		{"complex", []*types.Variable{{Name: "z", Dimensions: []int{1, 1}, DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1}, Imag: []float64{2}}}}, nil, true},
+		{"char", []*types.Variable{{Name: "c", Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"}}, nil, true},
+		{"empty", []*types.Variable{{Name: "e", Dimensions: []int{0, 0}, DataType: types.Double, Data: []float64{}}}, nil, true},
+		{"element count", []*types.Variable{{Name: "x", Dimensions: []int{2, 2}, DataType: types.Double, Data: []float64{1}}}, nil, false},
+		{"slash in name", []*types.Variable{{Name: "/g/x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}}, nil, false},
+		{"duplicate", []*types.Variable{x(), x()}, nil, false},
+		{"dimension names", []*types.Variable{x()}, []Option{WithDimensionNames("x", "a")}, false},
+		{"dimension length", []*types.Variable{x(), {Name: "y", Dimensions: []int{1, 3}, DataType: types.Double,
+			Data: []float64{1, 2, 3}}}, []Option{WithDimensionNames("x", "one", "n"), WithDimensionNames("y", "one", "n")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Encode(tt.vars, tt.opts...)
+			if err == nil {
+				t.Fatal("Encode() error = nil")
+			}
+			if errors.Is(err, ErrUnsupported) != tt.unsupported {
+				t.Errorf("Encode() error = %v, ErrUnsupported %v", err, tt.unsupported)
+			}
+		})
+	}
+}
+
+func TestToNetCDF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.nc")
+	vars := []*types.Variable{{Name: "x", Dimensions: []int{1, 2}, DataType: types.Int8, Data: []int8{-1, 1}}}
+	if err := ToNetCDF(vars, path); err != nil {
+		t.Fatalf("ToNetCDF() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xv := readNetCDF(t, data).vars["x"]; !bytes.Equal(xv.data, []byte{0xFF, 1, 0, 0}) {
+		t.Errorf("x = % x", xv.data)
+	}
+}
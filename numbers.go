@@ -0,0 +1,36 @@
+package matlab
+
+import "github.com/scigolib/matlab/types"
+
+// ToFloat64Map returns the data of every real numeric variable of the
+// file as []float64, by name, for scripts that just need the numbers.
+// Arrays of any numeric class are converted, in column-major order;
+// double arrays share their data with the variables. Variables of other
+// kinds (logical, char, struct, cell, sparse, objects) and complex arrays
+// are left out and listed in skipped, in file order.
+//
+// Example:
+//
+//	values, skipped := matFile.ToFloat64Map()
+//	for name, x := range values {
+//	    fmt.Println(name, len(x))
+//	}
+//	if len(skipped) > 0 {
+//	    log.Printf("not numeric: %v", skipped)
+//	}
+func (m *MatFile) ToFloat64Map() (values map[string][]float64, skipped []string) {
+	values = make(map[string][]float64, len(m.Variables))
+	for _, v := range m.Variables {
+		if v.Kind() != types.KindNumeric || v.IsComplex {
+			skipped = append(skipped, v.Name)
+			continue
+		}
+		data, err := v.GetFloat64Array()
+		if err != nil {
+			skipped = append(skipped, v.Name)
+			continue
+		}
+		values[v.Name] = data
+	}
+	return values, skipped
+}
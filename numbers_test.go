@@ -0,0 +1,28 @@
+package matlab
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestMatFile_ToFloat64Map(t *testing.T) {
+	matFile := &MatFile{Variables: []*types.Variable{
+		{Name: "x", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1.5, 2}},
+		{Name: "n", Dimensions: []int{1, 2}, DataType: types.Int16, Data: []int16{-3, 4}},
+		{Name: "flag", Dimensions: []int{1, 1}, DataType: types.Logical, Data: []bool{true}},
+		{Name: "z", Dimensions: []int{1, 1}, DataType: types.Double, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1}, Imag: []float64{2}}},
+		{Name: "s", Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"},
+	}}
+
+	values, skipped := matFile.ToFloat64Map()
+	want := map[string][]float64{"x": {1.5, 2}, "n": {-3, 4}}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("ToFloat64Map() values = %v, want %v", values, want)
+	}
+	if !reflect.DeepEqual(skipped, []string{"flag", "z", "s"}) {
+		t.Errorf("ToFloat64Map() skipped = %v, want [flag z s]", skipped)
+	}
+}
@@ -1,7 +1,17 @@
 package matlab
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
 	"encoding/binary"
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/scigolib/matlab/types"
 )
 
 // config holds optional configuration for Create.
@@ -10,15 +20,72 @@ type config struct {
 	description string           // File description (max 116 bytes)
 	endianness  binary.ByteOrder // Byte order (LittleEndian or BigEndian)
 
-	// Compression options (both formats)
-	compression int // 0-9, 0=none, 9=max (future feature)
+	// Compression options (v5 only for now)
+	compression int // 0-9, 0=none, 9=max
+
+	// v7.3-specific options
+	creationTime  bool // Stamp each variable with AttrCreationTime
+	objectClasses bool // Write structs naming another class as objects
+
+	// Validation run before each variable is written (both formats)
+	writeHooks []func(*types.Variable) error
+
+	// Reject top-level variables with reserved names (both formats)
+	strictReservedNames bool
+
+	// Write scalars with dimensions [] or [1] as 1x1 (both formats)
+	normalizeScalars bool
+
+	// Shape of vectors with dimensions [n] (both formats)
+	orientation Orientation
+
+	// Write doubles as singles within a relative tolerance (both formats)
+	downcast          bool
+	downcastTolerance float64
+
+	// Create missing parent directories of the file
+	createDirs bool
+
+	// Refuse to replace an existing file
+	noClobber bool
+
+	// Rename an existing file to <name>.bak before replacing it
+	backup bool
+
+	// Chunked v5 output: bytes per write, bytes per second and the
+	// context checked between chunks
+	chunkSize int
+	rateLimit int
+	ctx       context.Context
+
+	// AES key of the encrypted envelope, nil for plain files
+	encryptionKey []byte
+
+	// Write the file gzip-compressed
+	gzip bool
+
+	// Store variable attributes in AttributesVariable (v5)
+	attributesVariable bool
+
+	// Key signing the file, nil for unsigned files
+	signingKey ed25519.PrivateKey
+
+	// Record every variable in the manifest, not only sections
+	manifest bool
+
+	// Limits of a file, 0 for none: top-level variables and bytes
+	maxVariables int
+	maxFileSize  int64
+
+	// Variables computed from expressions at Close, in order
+	derived []derivedVariable
 }
 
 // Option configures optional parameters for Create.
 type Option func(*config)
 
-// WithEndianness sets the byte order for v5 files.
-// Valid values: binary.LittleEndian, binary.BigEndian
+// WithEndianness sets the byte order for v5 files. Any binary.ByteOrder
+// is accepted, including binary.NativeEndian.
 //
 // Default: binary.LittleEndian
 //
@@ -53,13 +120,16 @@ func WithDescription(desc string) Option {
 // WithCompression enables compression with specified level (0-9).
 // 0 = no compression, 9 = maximum compression
 //
-// Note: Compression is not yet implemented. This option is reserved for future use.
+// v5 files store each variable as a zlib-compressed miCOMPRESSED element,
+// as MATLAB's save -v7 does. The option is ignored for v7.3 files: the
+// HDF5 writer does not yet produce deflate filter pipelines that readers
+// accept, so datasets are written uncompressed.
 //
 // Default: 0 (no compression)
 //
 // Example:
 //
-//	writer, _ := matlab.Create("file.mat", matlab.Version73,
+//	writer, _ := matlab.Create("file.mat", matlab.Version5,
 //	    matlab.WithCompression(6))
 func WithCompression(level int) Option {
 	return func(c *config) {
@@ -72,12 +142,394 @@ func WithCompression(level int) Option {
 	}
 }
 
+// WithMatlabV7Compatibility writes v5 files as MATLAB's save does in its
+// default -v7 mode: each variable zlib-compressed at level 6, character
+// data in UTF-8 when it is ASCII and in UTF-16 otherwise, and the header
+// text MATLAB writes, such as
+//
+//	MATLAB 5.0 MAT-file, Platform: GLNXA64, Created on: Mon Jan  8 14:03:21 2024
+//
+// padded with spaces, with the platform of the running program and the
+// local time of the call. Options given after it override its settings,
+// so WithCompression or WithDescription can adjust the preset.
+//
+// Example:
+//
+//	writer, _ := matlab.Create("file.mat", matlab.Version5,
+//	    matlab.WithMatlabV7Compatibility())
+func WithMatlabV7Compatibility() Option {
+	return func(c *config) {
+		c.compression = 6
+		c.description = matlabHeaderText(runtime.GOOS, runtime.GOARCH, time.Now())
+	}
+}
+
+// matlabPlatforms maps GOOS/GOARCH to the platform names of MATLAB's
+// computer function.
+var matlabPlatforms = map[string]string{
+	"linux/amd64":   "GLNXA64",
+	"linux/386":     "GLNX86",
+	"windows/amd64": "PCWIN64",
+	"windows/386":   "PCWIN",
+	"darwin/amd64":  "MACI64",
+	"darwin/arm64":  "MACA64",
+}
+
+// matlabHeaderText returns the header text MATLAB writes on the given
+// platform at time t, padded with spaces to the 116 bytes of the field.
+func matlabHeaderText(goos, goarch string, t time.Time) string {
+	platform, ok := matlabPlatforms[goos+"/"+goarch]
+	if !ok {
+		platform = strings.ToUpper(goos + goarch)
+	}
+	text := fmt.Sprintf("MATLAB 5.0 MAT-file, Platform: %s, Created on: %s",
+		platform, t.Format("Mon Jan _2 15:04:05 2006"))
+	if len(text) > 116 {
+		return text[:116]
+	}
+	return text + strings.Repeat(" ", 116-len(text))
+}
+
+// WithCreationTime stamps each variable written to a v7.3 file with a
+// creation_time attribute holding the write time (RFC 3339, UTC). After
+// reading the file back, the stamp is available in Variable.Attributes
+// under AttrCreationTime. Ignored for v5 files, which have no attributes.
+//
+// Default: disabled
+//
+// Example:
+//
+//	writer, _ := matlab.Create("results.mat", matlab.Version73,
+//	    matlab.WithCreationTime())
+func WithCreationTime() Option {
+	return func(c *config) {
+		c.creationTime = true
+	}
+}
+
+// WithObjectClasses writes struct variables whose MATLAB_class attribute
+// names another class as objects of that class, the way MATLAB stores
+// objects whose class saves them as a struct of their properties: an HDF5
+// group with that MATLAB_class and MATLAB_object_decode set to
+// types.ObjectDecodeStruct. MATLAB reconstructs such objects if the class
+// is on its path (through its loadobj method, if it has one). Reading the
+// file back returns structs whose ClassName is the class. Ignored for v5
+// files.
+//
+// Default: disabled (the class attribute of structs is not written)
+//
+// Example:
+//
+//	point.Attributes = map[string]interface{}{types.AttrMatlabClass: "geom.Point"} // point is a 1x1 struct
+//	writer, _ := matlab.Create("shapes.mat", matlab.Version73, matlab.WithObjectClasses())
+//	err := writer.WriteVariable(point)
+func WithObjectClasses() Option {
+	return func(c *config) {
+		c.objectClasses = true
+	}
+}
+
+// WithWriteHook registers a function that is called with each variable
+// before it is written, including variables written through a
+// GroupWriter. If the hook returns an error the variable is not written
+// and WriteVariable returns the error wrapped in ErrWriteRejected. Hooks
+// may also modify the variable, for example to add attributes.
+//
+// The option can be given several times; hooks run in order and the
+// first error stops the write.
+//
+// Example:
+//
+//	requireUnits := func(v *types.Variable) error {
+//	    if _, ok := v.GetStringAttr("units"); !ok {
+//	        return fmt.Errorf("%s has no units", v.Name)
+//	    }
+//	    return nil
+//	}
+//	writer, _ := matlab.Create("results.mat", matlab.Version73,
+//	    matlab.WithWriteHook(requireUnits))
+func WithWriteHook(hook func(*types.Variable) error) Option {
+	return func(c *config) {
+		if hook != nil {
+			c.writeHooks = append(c.writeHooks, hook)
+		}
+	}
+}
+
+// WithStrictNames rejects variables and struct fields whose names are not
+// valid MATLAB identifiers (see types.ValidateName), such as names with
+// non-ASCII letters, spaces or a leading digit. Rejected variables are not
+// written and WriteVariable returns an error wrapping both
+// ErrWriteRejected and types.ErrInvalidName.
+//
+// Without the option such names are written as UTF-8, with their length
+// counted in characters; MATLAB cannot load them under their own names.
+//
+// Example:
+//
+//	writer, _ := matlab.Create("results.mat", matlab.Version5,
+//	    matlab.WithStrictNames())
+func WithStrictNames() Option {
+	return WithWriteHook(validateNames)
+}
+
+// validateNames checks the name of v and its struct field names against
+// the MATLAB identifier rules.
+func validateNames(v *types.Variable) error {
+	if err := types.ValidateName(v.Name); err != nil {
+		return err
+	}
+	return validateFieldNames(v.Data)
+}
+
+// validateFieldNames checks the field names of struct data, including
+// those of nested structs.
+func validateFieldNames(data interface{}) error {
+	st, ok := data.(*types.StructArray)
+	if !ok {
+		return nil
+	}
+	for _, field := range st.Fields {
+		if err := types.ValidateName(field); err != nil {
+			return fmt.Errorf("struct field: %w", err)
+		}
+	}
+	for _, element := range st.Elements {
+		for _, value := range element {
+			if value == nil {
+				continue // Reported by the writer
+			}
+			if err := validateFieldNames(value.Data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WithStrictReservedNames rejects variables named after MATLAB keywords
+// or commonly used functions and constants, such as end, pi, ans, i or j
+// (see types.CheckReservedName). Rejected variables are not written and
+// WriteVariable returns an error wrapping both ErrWriteRejected and
+// ErrReservedName.
+//
+// Without the option such variables are written and reported by
+// MatFileWriter.Warnings as *ReservedNameWarning. Struct fields are not
+// checked, nor are variables written into groups with
+// MatFileWriter.Group, which MATLAB loads as struct fields: they cannot
+// shadow anything. The name is checked as it is written, after any
+// renaming by the writer.
+//
+// Example:
+//
+//	writer, _ := matlab.Create("results.mat", matlab.Version5,
+//	    matlab.WithStrictNames(), matlab.WithStrictReservedNames())
+func WithStrictReservedNames() Option {
+	return func(c *config) {
+		c.strictReservedNames = true
+	}
+}
+
+// WithScalarNormalization controls whether scalars given with dimensions
+// [] or [1] are written as 1x1, as MATLAB stores them, so size(x) checks
+// on the MATLAB side see the usual shape. Without it v7.3 files keep the
+// 1-D shape and v5 files store [1] as 1x1 but reject []. Struct fields are
+// normalized too; the variables passed to WriteVariable are not modified.
+//
+// Default: true
+//
+// Example:
+//
+//	// Keep dimensions exactly as given
+//	writer, _ := matlab.Create("raw.mat", matlab.Version73,
+//	    matlab.WithScalarNormalization(false))
+func WithScalarNormalization(enabled bool) Option {
+	return func(c *config) {
+		c.normalizeScalars = enabled
+	}
+}
+
+// Orientation is the shape WithVectorOrientation gives 1-D vectors.
+type Orientation int
+
+// Vector orientations.
+const (
+	AsGiven Orientation = iota // Keep [n]: v5 stores it as n×1, v7.3 as a 1-D dataset
+	Row                        // Write [n] as 1×n
+	Column                     // Write [n] as n×1
+)
+
+// WithVectorOrientation sets how vectors given with dimensions [n] are
+// written: as 1×n row vectors or n×1 column vectors. MATLAB code using
+// size, indexing with end or matrix products behaves differently for
+// each. Struct fields are reshaped too; the variables passed to
+// WriteVariable are not modified.
+//
+// Default: AsGiven
+//
+// Example:
+//
+//	writer, _ := matlab.Create("signals.mat", matlab.Version73,
+//	    matlab.WithVectorOrientation(matlab.Row))
+func WithVectorOrientation(orientation Orientation) Option {
+	return func(c *config) {
+		c.orientation = orientation
+	}
+}
+
+// WithCreateDirs creates the missing parent directories of the file, as
+// os.MkdirAll does, instead of failing.
+//
+// Default: disabled; Create returns an error wrapping fs.ErrNotExist that
+// names the missing directory
+//
+// Example:
+//
+//	writer, _ := matlab.Create("results/2024/run42.mat", matlab.Version5,
+//	    matlab.WithCreateDirs())
+func WithCreateDirs() Option {
+	return func(c *config) {
+		c.createDirs = true
+	}
+}
+
+// WithNoClobber makes Create fail with an error wrapping fs.ErrExist if
+// the file already exists, so batch scripts cannot destroy earlier
+// results. It takes precedence over WithBackup.
+//
+// Default: disabled; an existing file is truncated
+//
+// Example:
+//
+//	writer, err := matlab.Create("run42.mat", matlab.Version5,
+//	    matlab.WithNoClobber())
+//	if errors.Is(err, fs.ErrExist) {
+//	    log.Fatal("run42.mat already written")
+//	}
+func WithNoClobber() Option {
+	return func(c *config) {
+		c.noClobber = true
+	}
+}
+
+// WithBackup renames an existing file to the same name with ".bak"
+// appended (run42.mat.bak) before Create replaces it. An earlier backup
+// is overwritten.
+//
+// Default: disabled; an existing file is truncated
+//
+// Example:
+//
+//	writer, _ := matlab.Create("run42.mat", matlab.Version73,
+//	    matlab.WithBackup())
+func WithBackup() Option {
+	return func(c *config) {
+		c.backup = true
+	}
+}
+
+// WithChunkSize splits v5 output into writes of at most size bytes, so a
+// slow destination such as a network connection receives bounded writes
+// instead of one write per variable. It suits NewWriter; with Create the
+// chunks only add system calls.
+//
+// Default: one write per variable, or 64 KiB chunks if WithRateLimit or
+// WithContext is given
+//
+// Example:
+//
+//	writer, _ := matlab.NewWriter(conn, matlab.WithChunkSize(32<<10))
+func WithChunkSize(size int) Option {
+	return func(c *config) {
+		c.chunkSize = size
+	}
+}
+
+// WithRateLimit limits v5 output to an average of bytesPerSecond,
+// waiting between chunks (see WithChunkSize) as needed.
+//
+// Default: unlimited
+//
+// Example:
+//
+//	// Leave bandwidth for other traffic
+//	writer, _ := matlab.NewWriter(conn, matlab.WithRateLimit(10<<20))
+func WithRateLimit(bytesPerSecond int) Option {
+	return func(c *config) {
+		c.rateLimit = bytesPerSecond
+	}
+}
+
+// WithContext stops v5 output between chunks (see WithChunkSize) once ctx
+// is done: the write in progress returns an error wrapping ctx.Err(),
+// and the output holds the chunks written before.
+//
+// Default: writes are not interrupted
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+//	defer cancel()
+//	writer, _ := matlab.NewWriter(conn, matlab.WithContext(ctx))
+func WithContext(ctx context.Context) Option {
+	return func(c *config) {
+		c.ctx = ctx
+	}
+}
+
+// WithEncryption writes the file inside an encrypted envelope: AES-GCM
+// with the given 16, 24 or 32-byte key, in authenticated 64 KiB
+// segments after a short header (see IsEncrypted). Read such files with
+// OpenEncrypted.
+//
+// v5 output is encrypted as it is written, so nothing reaches the
+// destination in plain form. v7.3 files are written by the HDF5 library
+// to a temporary file next to the destination, which Close encrypts to
+// the destination and removes; the destination never holds plain text,
+// but the temporary file does until Close. Failed v5 WriteVariables
+// batches cannot be rolled back in encrypted files. The key is copied, so
+// later changes to it do not affect the writer.
+//
+// Default: plain files
+//
+// Example:
+//
+//	key := make([]byte, 32)
+//	rand.Read(key) // Keep it safe: the file cannot be read without it
+//	writer, _ := matlab.Create("results.mat.enc", matlab.Version5,
+//	    matlab.WithEncryption(key))
+func WithEncryption(key []byte) Option {
+	return func(c *config) {
+		c.encryptionKey = bytes.Clone(key)
+	}
+}
+
+// WithSignature signs the file with an Ed25519 private key: Close adds a
+// detached signature of the file contents as the final variable
+// (SignatureVariable, 64 uint8 values), which Verify checks against the
+// public key. It cannot be combined with WithEncryption.
+//
+// Default: unsigned files
+//
+// Example:
+//
+//	writer, _ := matlab.Create("calibration.mat", matlab.Version5,
+//	    matlab.WithSignature(labKey))
+//	// Consumers:
+//	err := matlab.Verify("calibration.mat", labPublicKey)
+func WithSignature(key ed25519.PrivateKey) Option {
+	return func(c *config) {
+		c.signingKey = key
+	}
+}
+
 // defaultConfig returns configuration with default values.
 func defaultConfig() *config {
 	return &config{
-		description: "MATLAB MAT-file, created by scigolib/matlab",
-		endianness:  binary.LittleEndian,
-		compression: 0,
+		description:      "MATLAB MAT-file, created by scigolib/matlab",
+		endianness:       binary.LittleEndian,
+		compression:      0,
+		normalizeScalars: true,
 	}
 }
 
@@ -87,3 +539,399 @@ func applyOptions(cfg *config, opts []Option) {
 		opt(cfg)
 	}
 }
+
+// openConfig holds optional configuration for Open.
+type openConfig struct {
+	// v7.3-specific traversal limits (0 = library default)
+	maxDepth   int
+	maxObjects int
+
+	// Array shape limits of both formats (0 = library default)
+	dimLimits types.DimLimits
+
+	// v7.3-specific layout options
+	flattenGroups bool
+	keepSoftLinks bool // Report soft links instead of resolving them
+	keepRaw       bool // Record the stored bytes of datasets
+
+	// Directory for the v7.3 temporary copy ("" = os.TempDir())
+	tempDir string
+
+	// Name prefix of the v7.3 temporary copy ("" = "matfile-")
+	tempPrefix string
+
+	// Applied to each variable as it is parsed (both formats)
+	transforms []func(*types.Variable) (*types.Variable, error)
+
+	// Provides the slices of decoded v5 numeric data (nil = make)
+	allocator types.Allocator
+
+	// Variable name checks (both formats)
+	keepNames     bool    // Use stored names verbatim
+	maxNameLength int     // 0 = DefaultMaxNameLength
+	warnings      []error // Renamed variables, collected while parsing
+
+	// Applied to each top-level variable name (nil = none), and the stored
+	// names of the variables it renamed, collected while parsing
+	normalizeName func(string) string
+	storedNames   map[string]string
+
+	// Bytes of decompressed data kept per file by on-demand readers (0 = none)
+	payloadBudget int64
+
+	// Shares identical payloads across files (nil = none)
+	intern *InternPool
+}
+
+// OpenOption configures optional parameters for Open.
+type OpenOption func(*openConfig)
+
+// WithMaxDepth limits how deeply nested HDF5 groups may be in a v7.3 file.
+// Files exceeding the limit fail with ErrMaxDepthExceeded.
+//
+// Default: 64
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithMaxDepth(8))
+func WithMaxDepth(depth int) OpenOption {
+	return func(c *openConfig) {
+		c.maxDepth = depth
+	}
+}
+
+// WithMaxObjects limits how many HDF5 groups and datasets are visited
+// while reading a v7.3 file. Files exceeding the limit fail with
+// ErrMaxObjectsExceeded.
+//
+// Default: 1048576
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithMaxObjects(10000))
+func WithMaxObjects(count int) OpenOption {
+	return func(c *openConfig) {
+		c.maxObjects = count
+	}
+}
+
+// WithMaxRank limits the number of dimensions of the arrays read, in
+// both formats. Arrays with more dimensions fail Open with an error
+// wrapping ErrDimensionLimit before their data is read.
+//
+// Default: 64
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithMaxRank(4))
+func WithMaxRank(rank int) OpenOption {
+	return func(c *openConfig) {
+		c.dimLimits.MaxRank = rank
+	}
+}
+
+// WithMaxDimension limits the length of any single dimension of the
+// arrays read, in both formats, like WithMaxRank.
+//
+// Default: no limit
+func WithMaxDimension(length int) OpenOption {
+	return func(c *openConfig) {
+		c.dimLimits.MaxDimension = length
+	}
+}
+
+// WithMaxElements limits the number of elements of each array read, in
+// both formats, like WithMaxRank. Embedded consumers can bound the memory
+// a single variable takes: a limit of n elements bounds a double array to
+// 8n bytes.
+//
+// Default: 2^40
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithMaxElements(1<<20))
+func WithMaxElements(count int64) OpenOption {
+	return func(c *openConfig) {
+		c.dimLimits.MaxElements = count
+	}
+}
+
+// WithFlattenGroups reports the contents of v7.3 struct groups (including
+// namespaces written with MatFileWriter.Group) as separate variables named
+// by their HDF5 path, such as "/run42/x", instead of one struct variable
+// per group.
+//
+// Default: disabled (each group is read as a struct variable)
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithFlattenGroups())
+//	x := matFile.GetVariable("/run42/x")
+func WithFlattenGroups() OpenOption {
+	return func(c *openConfig) {
+		c.flattenGroups = true
+	}
+}
+
+// WithSoftLinkResolution controls how soft links in v7.3 files are read.
+// HDF5 files assembled by other tools may link one name to another
+// object. Resolved links read as a copy of their target under the link's
+// name; unresolved links, dangling soft links and external links (to
+// objects in other files) read as char variables holding the target path,
+// with the AttrLinkTarget attribute and, for external links,
+// AttrLinkFile. External links are never followed.
+//
+// Default: enabled
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithSoftLinkResolution(false))
+//	for _, v := range matFile.Variables {
+//	    if target, ok := v.GetStringAttr(matlab.AttrLinkTarget); ok {
+//	        fmt.Printf("%s -> %s\n", v.Name, target)
+//	    }
+//	}
+func WithSoftLinkResolution(enabled bool) OpenOption {
+	return func(c *openConfig) {
+		c.keepSoftLinks = !enabled
+	}
+}
+
+// WithRawBytes keeps the stored bytes of each v7.3 dataset with
+// contiguous storage, with its HDF5 datatype and dataspace, available
+// from Variable.RawBytes, so classes the library cannot decode yet can be
+// decoded by the caller. It doubles the memory used for such data;
+// ReadRawBytes reads a single dataset on demand instead. Ignored for v5
+// files.
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithRawBytes())
+//	raw, err := matFile.GetVariable("obj").RawBytes()
+func WithRawBytes() OpenOption {
+	return func(c *openConfig) {
+		c.keepRaw = true
+	}
+}
+
+// WithTempDir sets the directory for the temporary copy that reading a
+// v7.3 file from a stream requires, for systems where os.TempDir() is
+// read-only or too small.
+//
+// No temporary copy is made when Open is given an *os.File positioned at
+// the start of a regular file; the file is then read in place.
+//
+// Example:
+//
+//	matFile, err := matlab.Open(conn, matlab.WithTempDir("/scratch"))
+func WithTempDir(dir string) OpenOption {
+	return func(c *openConfig) {
+		c.tempDir = dir
+	}
+}
+
+// WithTempPrefix sets the name prefix of the temporary copy made when
+// reading a v7.3 file from a stream (see WithTempDir). Copies are named
+// prefix, random digits and ".tmp", and are removed when Open returns,
+// also on error or panic; a per-service prefix lets files left behind by
+// a killed process be found and removed with a pattern such as
+// "/scratch/myservice-*.tmp".
+//
+// Default: "matfile-"
+//
+// Example:
+//
+//	matFile, err := matlab.Open(conn, matlab.WithTempDir("/scratch"),
+//	    matlab.WithTempPrefix("myservice-"))
+func WithTempPrefix(prefix string) OpenOption {
+	return func(c *openConfig) {
+		c.tempPrefix = prefix
+	}
+}
+
+// WithNameSanitizing controls whether variables whose stored names contain
+// NUL or other control characters, invalid UTF-8 or the path separators
+// '/' and '\', are "." or "..", or exceed the length set with
+// WithMaxNameLength, are renamed while reading. Each such character (or
+// run of invalid bytes) is replaced with '_' and the name is truncated, so
+// the same file always yields the same names, safe to use as file names,
+// and an InvalidNameWarning is added to MatFile.Warnings. Renaming happens
+// before any WithTransform function sees the variable.
+//
+// Default: true
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithNameSanitizing(false)) // names verbatim
+func WithNameSanitizing(enabled bool) OpenOption {
+	return func(c *openConfig) {
+		c.keepNames = !enabled
+	}
+}
+
+// WithMaxNameLength sets the longest variable name, in bytes, kept as
+// stored when reading; longer names are truncated (see WithNameSanitizing).
+//
+// Default: DefaultMaxNameLength
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithMaxNameLength(63)) // MATLAB's limit
+func WithMaxNameLength(n int) OpenOption {
+	return func(c *openConfig) {
+		c.maxNameLength = n
+	}
+}
+
+// WithTransform applies fn to each top-level variable as soon as it is
+// parsed, before the next one is read. fn may return the variable
+// modified, a replacement (for example downcast or renamed) or nil to
+// drop it, so data that is not needed is released while the file is
+// still being read. An error from fn aborts Open.
+//
+// The option can be given several times; transforms are applied in order
+// and a dropped variable is not passed to later ones. Struct fields are
+// part of their struct variable and are not passed separately.
+//
+// Example:
+//
+//	dropRaw := func(v *types.Variable) (*types.Variable, error) {
+//	    if strings.HasPrefix(v.Name, "raw_") {
+//	        return nil, nil
+//	    }
+//	    return v, nil
+//	}
+//	matFile, err := matlab.Open(file, matlab.WithTransform(dropRaw))
+func WithTransform(fn func(*types.Variable) (*types.Variable, error)) OpenOption {
+	return func(c *openConfig) {
+		if fn != nil {
+			c.transforms = append(c.transforms, fn)
+		}
+	}
+}
+
+// WithAllocator decodes the numeric data of v5 files into slices taken
+// from alloc, such as a types.Arena reused across files, to reduce the
+// garbage collection work of bulk loads. OpenMetadata reports the
+// uncompressed size of each variable for sizing the arena's chunks. The
+// data of v7.3 files is allocated by the HDF5 library and is not affected.
+//
+// Example:
+//
+//	arena := types.NewArena(1 << 20)
+//	matFile, err := matlab.Open(file, matlab.WithAllocator(arena))
+//	// ... use matFile, then release its data for the next file
+//	arena.Reset()
+func WithAllocator(alloc types.Allocator) OpenOption {
+	return func(c *openConfig) {
+		c.allocator = alloc
+	}
+}
+
+// WithNamePattern keeps only the top-level variables whose names match
+// one of the given patterns, in the syntax of path.Match, such as
+// "sensor_*". Other variables are dropped as soon as they are parsed, as
+// by a WithTransform that returns nil. A malformed pattern aborts Open
+// with an error wrapping path.ErrBadPattern.
+//
+// Example:
+//
+//	err := matlab.OpenStream(file, handle, matlab.WithNamePattern("temp_*", "pressure"))
+func WithNamePattern(patterns ...string) OpenOption {
+	return WithTransform(func(v *types.Variable) (*types.Variable, error) {
+		for _, pattern := range patterns {
+			matched, err := path.Match(pattern, v.Name)
+			if err != nil {
+				return nil, fmt.Errorf("name pattern %q: %w", pattern, err)
+			}
+			if matched {
+				return v, nil
+			}
+		}
+		return nil, nil
+	})
+}
+
+// WithDatenums converts variables holding MATLAB serial date numbers to
+// times, as legacy files store timestamps: top-level real double arrays
+// whose names match one of the patterns (in the syntax of path.Match),
+// or that carry a "units" attribute of "datenum", get Data of type
+// []time.Time in UTC (see types.DatenumToTime) and DataType types.Object.
+// NaN datenums become the zero time. A malformed pattern aborts Open with
+// an error wrapping path.ErrBadPattern.
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithDatenums("t", "*_time"))
+//	times := matFile.GetVariable("t").Data.([]time.Time)
+func WithDatenums(patterns ...string) OpenOption {
+	return WithTransform(func(v *types.Variable) (*types.Variable, error) {
+		data, ok := v.Data.([]float64)
+		if !ok || v.IsComplex {
+			return v, nil
+		}
+		flagged := false
+		if units, ok := v.GetStringAttr("units"); ok && strings.EqualFold(units, "datenum") {
+			flagged = true
+		}
+		for _, pattern := range patterns {
+			matched, err := path.Match(pattern, v.Name)
+			if err != nil {
+				return nil, fmt.Errorf("datenum pattern %q: %w", pattern, err)
+			}
+			flagged = flagged || matched
+		}
+		if !flagged {
+			return v, nil
+		}
+		times := make([]time.Time, len(data))
+		for i, d := range data {
+			times[i] = types.DatenumToTime(d)
+		}
+		v.Data, v.DataType = times, types.Object
+		return v, nil
+	})
+}
+
+// transform returns the composition of the name checks and the configured
+// transforms, or nil if there are none.
+func (c *openConfig) transform() func(*types.Variable) (*types.Variable, error) {
+	var transforms []func(*types.Variable) (*types.Variable, error)
+	if !c.keepNames {
+		transforms = append(transforms, c.nameSanitizer())
+	}
+	if c.normalizeName != nil {
+		transforms = append(transforms, c.nameNormalizer())
+	}
+	transforms = append(transforms, c.transforms...)
+	if pool := c.intern; pool != nil {
+		transforms = append(transforms, func(v *types.Variable) (*types.Variable, error) {
+			pool.internVariable(v)
+			return v, nil
+		})
+	}
+	if len(transforms) == 0 {
+		return nil
+	}
+	return func(v *types.Variable) (*types.Variable, error) {
+		for _, fn := range transforms {
+			var err error
+			if v, err = fn(v); err != nil || v == nil {
+				return nil, err
+			}
+		}
+		return v, nil
+	}
+}
+
+// defaultOpenConfig returns read configuration with default values.
+func defaultOpenConfig() *openConfig {
+	return &openConfig{}
+}
+
+// applyOpenOptions applies OpenOption functions to openConfig.
+func applyOpenOptions(cfg *openConfig, opts []OpenOption) {
+	for _, opt := range opts {
+		opt(cfg)
+	}
+}
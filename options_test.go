@@ -1,11 +1,23 @@
 package matlab
 
 import (
+	"bytes"
+	"crypto/ed25519"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/scigolib/matlab/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -126,9 +138,10 @@ func TestCreate_WithOptions(t *testing.T) {
 	desc := string(header[0:116])
 	assert.Contains(t, desc, "Custom description")
 
-	// Check endianness (bytes 126-127)
-	assert.Equal(t, byte('I'), header[126])
-	assert.Equal(t, byte('M'), header[127])
+	// Check endianness (bytes 126-127): big-endian files store 'MI' as is
+	assert.Equal(t, byte('M'), header[126])
+	assert.Equal(t, byte('I'), header[127])
+	assert.Equal(t, uint16(0x0100), binary.BigEndian.Uint16(header[124:126]))
 }
 
 func TestCreate_BackwardCompatibility(t *testing.T) {
@@ -161,9 +174,41 @@ func TestCreate_V5_DefaultEndianness(t *testing.T) {
 	_, err = file.Read(header)
 	require.NoError(t, err)
 
-	// Check default endianness (bytes 126-127) should be "MI" (little-endian)
-	assert.Equal(t, byte('M'), header[126])
-	assert.Equal(t, byte('I'), header[127])
+	// Check default endianness (bytes 126-127) should be "IM" (little-endian)
+	assert.Equal(t, byte('I'), header[126])
+	assert.Equal(t, byte('M'), header[127])
+	assert.Equal(t, uint16(0x0100), binary.LittleEndian.Uint16(header[124:126]))
+}
+
+// wrappedOrder is a byte order that is not one of the binary package's
+// values.
+type wrappedOrder struct{ binary.ByteOrder }
+
+func TestCreate_V5_CustomByteOrder(t *testing.T) {
+	for _, order := range []binary.ByteOrder{wrappedOrder{binary.BigEndian}, binary.NativeEndian} {
+		tmpfile := filepath.Join(t.TempDir(), "custom_order.mat")
+		writer, err := Create(tmpfile, Version5, WithEndianness(order))
+		require.NoError(t, err)
+		require.NoError(t, writer.WriteVariable(&types.Variable{
+			Name: "x", Dimensions: []int{1, 2}, DataType: types.Int32, Data: []int32{1, 2},
+		}))
+		require.NoError(t, writer.Close())
+
+		data, err := os.ReadFile(tmpfile)
+		require.NoError(t, err)
+		indicator := make([]byte, 2)
+		order.PutUint16(indicator, 'M'<<8|'I')
+		assert.Equal(t, string(indicator), string(data[126:128]))
+		assert.Equal(t, uint16(0x0100), order.Uint16(data[124:126]))
+		assert.Equal(t, uint32(14), order.Uint32(data[128:132]), "miMATRIX tag in file order")
+
+		file, err := os.Open(tmpfile)
+		require.NoError(t, err)
+		matFile, err := Open(file)
+		require.NoError(t, file.Close())
+		require.NoError(t, err)
+		assert.Equal(t, []int32{1, 2}, matFile.GetVariable("x").Data)
+	}
 }
 
 func TestCreate_V5_DefaultDescription(t *testing.T) {
@@ -195,7 +240,7 @@ func TestCreate_MultipleOptions(t *testing.T) {
 	writer, err := Create(tmpfile, Version5,
 		WithEndianness(binary.BigEndian),
 		WithDescription("Test with multiple options"),
-		WithCompression(6), // Future feature, should not affect v5 for now
+		WithCompression(6),
 	)
 	require.NoError(t, err)
 	require.NoError(t, writer.Close())
@@ -245,3 +290,683 @@ func TestApplyOptions_Empty(t *testing.T) {
 	assert.Equal(t, originalEndian, cfg.endianness)
 	assert.Equal(t, originalCompression, cfg.compression)
 }
+
+func TestOpenOptions(t *testing.T) {
+	cfg := defaultOpenConfig()
+	assert.Equal(t, 0, cfg.maxDepth)
+	assert.Equal(t, 0, cfg.maxObjects)
+	assert.False(t, cfg.keepSoftLinks)
+
+	applyOpenOptions(cfg, []OpenOption{
+		WithMaxDepth(8),
+		WithMaxObjects(100),
+		WithSoftLinkResolution(false),
+	})
+	assert.Equal(t, 8, cfg.maxDepth)
+	assert.Equal(t, 100, cfg.maxObjects)
+	assert.True(t, cfg.keepSoftLinks)
+}
+
+func TestOpen_WithMaxObjects(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "generated", "simple_double.mat"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = Open(file, WithMaxObjects(1))
+	assert.ErrorIs(t, err, ErrMaxObjectsExceeded)
+}
+
+// TestWithCreationTime tests that v7.3 variables carry a creation_time
+// attribute only when the option is given.
+func TestWithMatlabV7Compatibility(t *testing.T) {
+	// A file saved by MATLAB R2017b in its default mode
+	golden, err := os.ReadFile(filepath.Join("testdata", "scipy", "inner_outer_tbl_param.mat"))
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "v7.mat")
+	w, err := Create(file, Version5, WithMatlabV7Compatibility())
+	require.NoError(t, err)
+	require.NoError(t, w.WriteVariable(&types.Variable{
+		Name: "s", Dimensions: []int{1, 3}, DataType: types.Char, Data: "abc",
+	}))
+	require.NoError(t, w.Close())
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	text := regexp.MustCompile(`^MATLAB 5\.0 MAT-file, Platform: \w+, Created on: \w{3} \w{3} [ \d]\d \d\d:\d\d:\d\d \d{4} +$`)
+	assert.Regexp(t, text, string(golden[:116]))
+	assert.Regexp(t, text, string(data[:116]))
+	assert.Equal(t, golden[116:128], data[116:128], "subsystem offset, version and endian indicator")
+	assert.Equal(t, golden[128:132], data[128:132], "first element is miCOMPRESSED")
+
+	matFile, err := Open(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.True(t, matFile.Features.Compressed)
+	assert.Equal(t, "abc", matFile.GetVariable("s").Data)
+}
+
+func TestMatlabHeaderText(t *testing.T) {
+	when := time.Date(2017, 11, 5, 15, 2, 32, 0, time.Local)
+	got := matlabHeaderText("windows", "amd64", when)
+	assert.Len(t, got, 116)
+	assert.Equal(t, "MATLAB 5.0 MAT-file, Platform: PCWIN64, Created on: Sun Nov  5 15:02:32 2017", strings.TrimRight(got, " "))
+	assert.Contains(t, matlabHeaderText("plan9", "arm", when), "Platform: PLAN9ARM,")
+}
+
+func TestWithCreationTime(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			tmpfile := filepath.Join(t.TempDir(), "stamped.mat")
+
+			var opts []Option
+			if enabled {
+				opts = append(opts, WithCreationTime())
+			}
+			writer, err := Create(tmpfile, Version73, opts...)
+			require.NoError(t, err)
+			require.NoError(t, writer.WriteVariable(&types.Variable{
+				Name: "x", Dimensions: []int{1}, DataType: types.Double, Data: []float64{1},
+			}))
+			require.NoError(t, writer.Close())
+
+			file, err := os.Open(tmpfile)
+			require.NoError(t, err)
+			defer file.Close()
+			matFile, err := Open(file)
+			require.NoError(t, err)
+
+			_, ok := matFile.GetVariable("x").GetAttribute(AttrCreationTime)
+			assert.Equal(t, enabled, ok)
+		})
+	}
+}
+
+func TestWithObjectClasses(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "objects.mat")
+	writer, err := Create(tmpfile, Version73, WithObjectClasses())
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteVariable(&types.Variable{
+		Name:       "p",
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     []string{"x"},
+			Elements:   [][]*types.Variable{{{Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{2}}}},
+			Dimensions: []int{1, 1},
+		},
+		Attributes: map[string]interface{}{types.AttrMatlabClass: "geom.Point"},
+	}))
+	require.NoError(t, writer.Close())
+
+	file, err := os.Open(tmpfile)
+	require.NoError(t, err)
+	defer file.Close()
+	matFile, err := Open(file)
+	require.NoError(t, err)
+
+	p := matFile.GetVariable("p")
+	require.NotNil(t, p)
+	assert.Equal(t, types.Struct, p.DataType)
+	assert.Equal(t, "geom.Point", p.ClassName())
+}
+
+// TestWithTempDir tests that streamed v7.3 input is copied into the given
+// directory, and that files are read in place without a copy.
+func TestWithTempDir(t *testing.T) {
+	path := filepath.Join("testdata", "generated", "simple_double.mat")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	// Streams need a temporary copy
+	matFile, err := Open(bytes.NewReader(data), WithTempDir(t.TempDir()))
+	require.NoError(t, err)
+	assert.True(t, matFile.HasVariable("data"))
+
+	_, err = Open(bytes.NewReader(data), WithTempDir(missing))
+	assert.Error(t, err)
+
+	// Files are opened in place
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	matFile, err = Open(file, WithTempDir(missing))
+	require.NoError(t, err)
+	assert.True(t, matFile.HasVariable("data"))
+}
+
+// TestWithTempPrefix tests that the prefix reaches the temporary copy,
+// which is removed once Open returns.
+func TestWithTempPrefix(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "generated", "simple_double.mat"))
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	matFile, err := Open(bytes.NewReader(data), WithTempDir(dir), WithTempPrefix("svc-"))
+	require.NoError(t, err)
+	assert.True(t, matFile.HasVariable("data"))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// os.CreateTemp rejects separators in the pattern
+	_, err = Open(bytes.NewReader(data), WithTempDir(dir), WithTempPrefix("a/b-"))
+	assert.Error(t, err)
+}
+
+func TestWithAllocator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alloc.mat")
+	w, err := Create(path, Version5, WithCompression(6))
+	require.NoError(t, err)
+	require.NoError(t, w.WriteVariable(&types.Variable{
+		Name: "x", Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{1.5, 2, 3},
+	}))
+	require.NoError(t, w.WriteVariable(&types.Variable{
+		Name: "n", Dimensions: []int{1, 2}, DataType: types.Int16, Data: []int16{-1, 7},
+	}))
+	require.NoError(t, w.Close())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	arena := types.NewArena(1024)
+	for i := 0; i < 2; i++ {
+		matFile, err := Open(bytes.NewReader(data), WithAllocator(arena))
+		require.NoError(t, err)
+		assert.Equal(t, []float64{1.5, 2, 3}, matFile.GetVariable("x").Data)
+		assert.Equal(t, []int16{-1, 7}, matFile.GetVariable("n").Data)
+		arena.Reset()
+	}
+	// One chunk per element type, reused by the second Open
+	assert.Equal(t, int64(1024*8+1024*2), arena.Bytes())
+}
+
+func TestWithDatenums(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "dates.mat")
+	w, err := Create(file, Version5)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteVariable(&types.Variable{
+		Name: "t", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{738887.5, math.NaN()},
+	}))
+	require.NoError(t, w.WriteVariable(&types.Variable{
+		Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{738887.5},
+	}))
+	require.NoError(t, w.Close())
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	matFile, err := Open(bytes.NewReader(data), WithDatenums("t"))
+	require.NoError(t, err)
+	v := matFile.GetVariable("t")
+	assert.Equal(t, types.Object, v.DataType)
+	assert.Equal(t, []time.Time{time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC), {}}, v.Data)
+	assert.Equal(t, []float64{738887.5}, matFile.GetVariable("x").Data)
+
+	// Variables can be flagged by attribute, as v7.3 files may do
+	cfg := defaultOpenConfig()
+	applyOpenOptions(cfg, []OpenOption{WithDatenums()})
+	flagged, err := cfg.transform()(&types.Variable{
+		Name: "when", DataType: types.Double, Data: []float64{types.DatenumUnixEpoch},
+		Attributes: map[string]interface{}{"units": "datenum"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{time.Unix(0, 0).UTC()}, flagged.Data)
+
+	_, err = Open(bytes.NewReader(data), WithDatenums("["))
+	assert.ErrorIs(t, err, path.ErrBadPattern)
+}
+
+func TestWithDimensionLimits(t *testing.T) {
+	for _, version := range []Version{Version5, Version73} {
+		file := filepath.Join(t.TempDir(), "dims.mat")
+		w, err := Create(file, version)
+		require.NoError(t, err)
+		require.NoError(t, w.WriteVariable(&types.Variable{
+			Name: "x", Dimensions: []int{2, 3}, DataType: types.Double, Data: []float64{1, 2, 3, 4, 5, 6},
+		}))
+		require.NoError(t, w.Close())
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		_, err = Open(bytes.NewReader(data), WithMaxElements(6), WithMaxRank(2), WithMaxDimension(3))
+		assert.NoError(t, err, "version %v", version)
+		for _, opt := range []OpenOption{WithMaxElements(5), WithMaxRank(1), WithMaxDimension(2)} {
+			_, err = Open(bytes.NewReader(data), opt)
+			assert.ErrorIs(t, err, ErrDimensionLimit, "version %v", version)
+		}
+	}
+}
+
+func TestRegularFilePath(t *testing.T) {
+	path := filepath.Join("testdata", "generated", "simple_double.mat")
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	assert.Equal(t, path, regularFilePath(file))
+	assert.Equal(t, "", regularFilePath(bytes.NewReader(nil)))
+
+	_, err = file.Seek(1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "", regularFilePath(file))
+}
+
+// TestWithWriteHook tests that hooks run in order before every write and
+// that a rejected variable is not written.
+func TestWithWriteHook(t *testing.T) {
+	errNoUnits := errors.New("missing units")
+	var calls []string
+	requireUnits := func(v *types.Variable) error {
+		calls = append(calls, "units:"+v.Name)
+		if _, ok := v.GetStringAttr("units"); !ok {
+			return errNoUnits
+		}
+		return nil
+	}
+	count := func(v *types.Variable) error {
+		calls = append(calls, "count:"+v.Name)
+		return nil
+	}
+
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			calls = nil
+			tmpfile := filepath.Join(t.TempDir(), "hooked.mat")
+			writer, err := Create(tmpfile, version, WithWriteHook(requireUnits), WithWriteHook(count), WithWriteHook(nil))
+			require.NoError(t, err)
+
+			err = writer.WriteVariable(&types.Variable{
+				Name: "bad", Dimensions: []int{1}, DataType: types.Double, Data: []float64{1},
+			})
+			assert.ErrorIs(t, err, ErrWriteRejected)
+			assert.ErrorIs(t, err, errNoUnits)
+
+			require.NoError(t, writer.WriteVariable(&types.Variable{
+				Name: "good", Dimensions: []int{1}, DataType: types.Double, Data: []float64{1},
+				Attributes: map[string]interface{}{"units": "m"},
+			}))
+			if version == Version73 {
+				err = writer.Group("g").WriteVariable(&types.Variable{
+					Name: "inner", Dimensions: []int{1}, DataType: types.Double, Data: []float64{1},
+				})
+				assert.ErrorIs(t, err, ErrWriteRejected)
+			}
+			require.NoError(t, writer.Close())
+
+			assert.Equal(t, []string{"units:bad", "units:good", "count:good"}, calls[:3])
+
+			file, err := os.Open(tmpfile)
+			require.NoError(t, err)
+			defer file.Close()
+			matFile, err := Open(file)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"good"}, matFile.GetVariableNames())
+		})
+	}
+}
+
+// TestWithTransform tests that transforms run in order on each variable
+// as it is parsed, can replace or drop variables, and abort on error.
+func TestWithTransform(t *testing.T) {
+	dropRaw := func(v *types.Variable) (*types.Variable, error) {
+		if strings.HasPrefix(v.Name, "raw_") {
+			return nil, nil
+		}
+		return v, nil
+	}
+	var seen []string
+	toSingle := func(v *types.Variable) (*types.Variable, error) {
+		seen = append(seen, v.Name)
+		data, err := v.GetFloat64Array()
+		if err != nil {
+			return nil, err
+		}
+		single := make([]float32, len(data))
+		for i, x := range data {
+			single[i] = float32(x)
+		}
+		return &types.Variable{Name: strings.ToUpper(v.Name), Dimensions: v.Dimensions, DataType: types.Single, Data: single}, nil
+	}
+	errBoom := errors.New("boom")
+	fail := func(*types.Variable) (*types.Variable, error) { return nil, errBoom }
+
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			seen = nil
+			tmpfile := filepath.Join(t.TempDir(), "transform.mat")
+			writer, err := Create(tmpfile, version)
+			require.NoError(t, err)
+			for _, name := range []string{"a", "raw_b", "c"} {
+				require.NoError(t, writer.WriteVariable(&types.Variable{
+					Name: name, Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1.5, 2},
+				}))
+			}
+			require.NoError(t, writer.Close())
+
+			file, err := os.Open(tmpfile)
+			require.NoError(t, err)
+			defer file.Close()
+			matFile, err := OpenWithTransform(file, toSingle, WithTransform(dropRaw))
+			require.NoError(t, err)
+
+			assert.ElementsMatch(t, []string{"a", "c"}, seen)
+			assert.ElementsMatch(t, []string{"A", "C"}, matFile.GetVariableNames())
+			assert.Equal(t, []float32{1.5, 2}, matFile.GetVariable("A").Data)
+
+			_, err = file.Seek(0, io.SeekStart)
+			require.NoError(t, err)
+			_, err = Open(file, WithTransform(fail))
+			assert.ErrorIs(t, err, errBoom)
+		})
+	}
+}
+
+// TestWithStrictNames tests that names which are not MATLAB identifiers
+// are rejected only with the option, and that non-ASCII names round trip
+// otherwise.
+func TestWithStrictNames(t *testing.T) {
+	// 63 characters but more than 63 bytes
+	long := strings.Repeat("é", types.MaxNameLength)
+	field := &types.Variable{Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			tmpfile := filepath.Join(t.TempDir(), "names.mat")
+			strict, err := Create(tmpfile, version, WithStrictNames())
+			require.NoError(t, err)
+			for _, v := range []*types.Variable{
+				{Name: "température", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}},
+				{Name: "s", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+					Fields: []string{"2nd"}, Dimensions: []int{1, 1}, Elements: [][]*types.Variable{{field}},
+				}},
+			} {
+				err := strict.WriteVariable(v)
+				assert.ErrorIs(t, err, ErrWriteRejected)
+				assert.ErrorIs(t, err, types.ErrInvalidName)
+			}
+			require.NoError(t, strict.WriteVariable(&types.Variable{
+				Name: "ok_1", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1},
+			}))
+			require.NoError(t, strict.Close())
+
+			writer, err := Create(tmpfile, version)
+			require.NoError(t, err)
+			for _, name := range []string{"température", "温度", long} {
+				require.NoError(t, writer.WriteVariable(&types.Variable{
+					Name: name, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{2},
+				}))
+			}
+			assert.Error(t, writer.WriteVariable(&types.Variable{
+				Name: long + "é", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{2},
+			}))
+			assert.Error(t, writer.WriteVariable(&types.Variable{
+				Name: "bad\xff", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{2},
+			}))
+			require.NoError(t, writer.Close())
+
+			file, err := os.Open(tmpfile)
+			require.NoError(t, err)
+			defer file.Close()
+			matFile, err := Open(file)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []string{"température", "温度", long}, matFile.GetVariableNames())
+		})
+	}
+}
+
+// TestWithStrictReservedNames tests that variables named after MATLAB
+// keywords and built-ins are written with a warning, or rejected with the
+// option.
+func TestWithStrictReservedNames(t *testing.T) {
+	scalar := func(name string) *types.Variable {
+		return &types.Variable{Name: name, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}
+	}
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			tmpfile := filepath.Join(t.TempDir(), "reserved.mat")
+			strict, err := Create(tmpfile, version, WithStrictReservedNames())
+			require.NoError(t, err)
+			for _, name := range []string{"end", "pi", "ans"} {
+				err := strict.WriteVariable(scalar(name))
+				assert.ErrorIs(t, err, ErrWriteRejected)
+				assert.ErrorIs(t, err, ErrReservedName)
+			}
+			require.NoError(t, strict.WriteVariable(scalar("ok")))
+			assert.ErrorIs(t, strict.WriteVariables(scalar("y"), scalar("j")), ErrReservedName)
+			if version == Version73 {
+				// Group members load as struct fields
+				require.NoError(t, strict.Group("g").WriteVariable(scalar("pi")))
+			}
+			assert.Empty(t, strict.Warnings())
+			require.NoError(t, strict.Close())
+			assert.NotContains(t, fileVariables(t, tmpfile), "y")
+
+			writer, err := Create(tmpfile, version)
+			require.NoError(t, err)
+			require.NoError(t, writer.WriteVariables(scalar("i"), scalar("x")))
+			require.NoError(t, writer.WriteVariable(scalar("end")))
+			require.NoError(t, writer.Close())
+
+			warnings := writer.Warnings()
+			require.Len(t, warnings, 2)
+			var reserved *ReservedNameWarning
+			require.ErrorAs(t, warnings[1], &reserved)
+			assert.Equal(t, "end", reserved.Name)
+			assert.ErrorIs(t, warnings[0], ErrReservedName)
+			assert.ElementsMatch(t, []string{"i", "x", "end"}, fileVariables(t, tmpfile))
+		})
+	}
+}
+
+// TestWithScalarNormalization tests that [] and [1] scalars, including
+// struct fields, are written as 1x1 without modifying the caller's
+// variables, unless the option is turned off.
+func TestWithScalarNormalization(t *testing.T) {
+	field := &types.Variable{Dimensions: []int{1}, DataType: types.Int8, Data: []int8{3}}
+	vars := []*types.Variable{
+		{Name: "one", Dimensions: []int{1}, DataType: types.Double, Data: []float64{1}},
+		{Name: "none", DataType: types.Double, Data: []float64{2}},
+		{Name: "s", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+			Fields: []string{"f"}, Dimensions: []int{1, 1}, Elements: [][]*types.Variable{{field}},
+		}},
+	}
+
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			tmpfile := filepath.Join(t.TempDir(), "scalars.mat")
+			writer, err := Create(tmpfile, version)
+			require.NoError(t, err)
+			require.NoError(t, writer.WriteVariable(vars[0]))
+			require.NoError(t, writer.WriteVariables(vars[1:]...))
+			require.NoError(t, writer.Close())
+
+			assert.Equal(t, []int{1}, vars[0].Dimensions)
+			assert.Nil(t, vars[1].Dimensions)
+			assert.Equal(t, []int{1}, field.Dimensions)
+
+			file, err := os.Open(tmpfile)
+			require.NoError(t, err)
+			defer file.Close()
+			matFile, err := Open(file)
+			require.NoError(t, err)
+			for _, name := range []string{"one", "none"} {
+				v := matFile.GetVariable(name)
+				require.NotNil(t, v, name)
+				assert.Equal(t, []int{1, 1}, v.Dimensions, name)
+			}
+			st := matFile.GetVariable("s").Data.(*types.StructArray)
+			assert.Equal(t, []int{1, 1}, st.Elements[0][0].Dimensions)
+		})
+	}
+
+	tmpfile := filepath.Join(t.TempDir(), "raw.mat")
+	writer, err := Create(tmpfile, Version73, WithScalarNormalization(false))
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteVariable(vars[0]))
+	assert.Error(t, writer.WriteVariable(vars[1]))
+	require.NoError(t, writer.Close())
+
+	file, err := os.Open(tmpfile)
+	require.NoError(t, err)
+	defer file.Close()
+	matFile, err := Open(file)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, matFile.GetVariable("one").Dimensions)
+}
+
+// TestWithVectorOrientation tests that [n] vectors are written as rows or
+// columns in both formats, struct fields included.
+func TestWithVectorOrientation(t *testing.T) {
+	tests := []struct {
+		orientation Orientation
+		want        []int
+	}{
+		{Row, []int{1, 3}},
+		{Column, []int{3, 1}},
+	}
+	for _, version := range []Version{Version5, Version73} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("v%d/%d", version, tt.orientation), func(t *testing.T) {
+				field := &types.Variable{Dimensions: []int{3}, DataType: types.Int16, Data: []int16{1, 2, 3}}
+				vars := []*types.Variable{
+					{Name: "x", Dimensions: []int{3}, DataType: types.Double, Data: []float64{1, 2, 3}},
+					{Name: "m", Dimensions: []int{3, 1}, DataType: types.Double, Data: []float64{1, 2, 3}},
+					{Name: "s", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+						Fields: []string{"f"}, Dimensions: []int{1, 1}, Elements: [][]*types.Variable{{field}},
+					}},
+				}
+				tmpfile := filepath.Join(t.TempDir(), "vectors.mat")
+				writer, err := Create(tmpfile, version, WithVectorOrientation(tt.orientation))
+				require.NoError(t, err)
+				require.NoError(t, writer.WriteVariables(vars...))
+				require.NoError(t, writer.Close())
+				assert.Equal(t, []int{3}, vars[0].Dimensions)
+
+				file, err := os.Open(tmpfile)
+				require.NoError(t, err)
+				defer file.Close()
+				matFile, err := Open(file)
+				require.NoError(t, err)
+				x := matFile.GetVariable("x")
+				assert.Equal(t, tt.want, x.Dimensions)
+				assert.Equal(t, []float64{1, 2, 3}, x.Data)
+				assert.Equal(t, []int{3, 1}, matFile.GetVariable("m").Dimensions)
+				st := matFile.GetVariable("s").Data.(*types.StructArray)
+				assert.Equal(t, tt.want, st.Elements[0][0].Dimensions)
+			})
+		}
+	}
+}
+
+func TestWithCreateDirs(t *testing.T) {
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			dir := t.TempDir()
+			// Slashes are separators on every platform
+			path := filepath.ToSlash(dir) + "/results/2024/run.mat"
+
+			_, err := Create(path, version)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+			assert.Contains(t, err.Error(), "WithCreateDirs")
+
+			writer, err := Create(path, version, WithCreateDirs())
+			require.NoError(t, err)
+			require.NoError(t, writer.WriteVariable(&types.Variable{
+				Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1},
+			}))
+			require.NoError(t, writer.Close())
+			_, err = os.Stat(filepath.Join(dir, "results", "2024", "run.mat"))
+			require.NoError(t, err)
+
+			// A file where the directory should be
+			blocker := filepath.Join(dir, "file")
+			require.NoError(t, os.WriteFile(blocker, nil, 0o600))
+			_, err = Create(filepath.Join(blocker, "run.mat"), version)
+			assert.Error(t, err)
+			_, err = Create(filepath.Join(blocker, "sub", "run.mat"), version, WithCreateDirs())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestWithNoClobberAndBackup(t *testing.T) {
+	write := func(t *testing.T, path string, version Version, value float64, opts ...Option) error {
+		t.Helper()
+		writer, err := Create(path, version, opts...)
+		if err != nil {
+			return err
+		}
+		require.NoError(t, writer.WriteVariable(&types.Variable{
+			Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{value},
+		}))
+		return writer.Close()
+	}
+	read := func(t *testing.T, path string) float64 {
+		t.Helper()
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		defer file.Close()
+		matFile, err := Open(file)
+		require.NoError(t, err)
+		v := matFile.GetVariable("x")
+		require.NotNil(t, v)
+		return v.Data.([]float64)[0]
+	}
+
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "run.mat")
+			require.NoError(t, write(t, path, version, 1, WithNoClobber()))
+
+			err := write(t, path, version, 2, WithNoClobber(), WithBackup())
+			assert.ErrorIs(t, err, fs.ErrExist)
+			assert.Equal(t, 1.0, read(t, path))
+			_, err = os.Stat(path + ".bak")
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+
+			require.NoError(t, write(t, path, version, 2, WithBackup()))
+			require.NoError(t, write(t, path, version, 3, WithBackup()))
+			assert.Equal(t, 3.0, read(t, path))
+			assert.Equal(t, 2.0, read(t, path+".bak"))
+		})
+	}
+}
+
+func TestWithGzip(t *testing.T) {
+	x := &types.Variable{Name: "x", Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{1, 2, 3}}
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "run.mat.gz")
+			writer, err := Create(path, version, WithGzip())
+			require.NoError(t, err)
+			require.NoError(t, writer.WriteVariable(x))
+			require.NoError(t, writer.Close())
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, "gzip", compressedContainer(data))
+			if version == Version5 {
+				assert.Equal(t, int64(len(data)), writer.Stats().Bytes)
+			}
+
+			matFile, err := Open(bytes.NewReader(data))
+			require.NoError(t, err)
+			v := matFile.GetVariable("x")
+			require.NotNil(t, v)
+			assert.Equal(t, x.Data, v.Data)
+
+			meta, err := OpenMetadata(bytes.NewReader(data))
+			require.NoError(t, err)
+			require.Len(t, meta.Variables, 1)
+			assert.Equal(t, "x", meta.Variables[0].Name)
+
+			v, err = NewCache(1).Variable(path, "x")
+			require.NoError(t, err)
+			assert.Equal(t, x.Data, v.Data)
+		})
+	}
+
+	_, err := Create(filepath.Join(t.TempDir(), "signed.mat"), Version5,
+		WithGzip(), WithSignature(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))))
+	assert.ErrorContains(t, err, "WithGzip")
+}
@@ -0,0 +1,59 @@
+package matlab
+
+import "github.com/scigolib/matlab/types"
+
+// NumVariables returns the number of variables in the file.
+func (m *MatFile) NumVariables() int {
+	return len(m.Variables)
+}
+
+// VariablePage returns at most limit variables starting at index offset,
+// in file order, for listing files with many variables a page at a time.
+// It returns nil if offset is negative or past the last variable, or if
+// limit is not positive. The page shares its elements with m.Variables,
+// but appending to it does not modify the file's list.
+//
+// Example:
+//
+//	const pageSize = 100
+//	for offset := 0; offset < matFile.NumVariables(); offset += pageSize {
+//	    for _, v := range matFile.VariablePage(offset, pageSize) {
+//	        fmt.Println(v.Name, v.Dimensions)
+//	    }
+//	}
+func (m *MatFile) VariablePage(offset, limit int) []*types.Variable {
+	lo, hi, ok := pageBounds(len(m.Variables), offset, limit)
+	if !ok {
+		return nil
+	}
+	return m.Variables[lo:hi:hi]
+}
+
+// NumVariables returns the number of variables in the file.
+func (m *Metadata) NumVariables() int {
+	return len(m.Variables)
+}
+
+// VariablePage returns at most limit variable descriptions starting at
+// index offset, like MatFile.VariablePage. Combined with OpenMetadata it
+// lists large files without loading any variable data.
+func (m *Metadata) VariablePage(offset, limit int) []*types.VariableInfo {
+	lo, hi, ok := pageBounds(len(m.Variables), offset, limit)
+	if !ok {
+		return nil
+	}
+	return m.Variables[lo:hi:hi]
+}
+
+// pageBounds returns the bounds of the page of a list of n elements
+// starting at offset with at most limit elements.
+func pageBounds(n, offset, limit int) (lo, hi int, ok bool) {
+	if offset < 0 || offset >= n || limit <= 0 {
+		return 0, 0, false
+	}
+	hi = n
+	if limit < n-offset {
+		hi = offset + limit
+	}
+	return offset, hi, true
+}
@@ -0,0 +1,58 @@
+package matlab
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestMatFile_VariablePage(t *testing.T) {
+	m := &MatFile{}
+	for i := 0; i < 5; i++ {
+		m.Variables = append(m.Variables, &types.Variable{Name: fmt.Sprintf("v%d", i)})
+	}
+	if m.NumVariables() != 5 {
+		t.Fatalf("NumVariables() = %d, want 5", m.NumVariables())
+	}
+
+	tests := []struct {
+		offset, limit int
+		want          []string
+	}{
+		{0, 2, []string{"v0", "v1"}},
+		{2, 2, []string{"v2", "v3"}},
+		{4, 2, []string{"v4"}},
+		{0, 10, []string{"v0", "v1", "v2", "v3", "v4"}},
+		{5, 2, nil},
+		{-1, 2, nil},
+		{0, 0, nil},
+	}
+	for _, tt := range tests {
+		page := m.VariablePage(tt.offset, tt.limit)
+		var got []string
+		for _, v := range page {
+			got = append(got, v.Name)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("VariablePage(%d, %d) = %v, want %v", tt.offset, tt.limit, got, tt.want)
+		}
+	}
+
+	page := m.VariablePage(0, 2)
+	_ = append(page, &types.Variable{Name: "extra"})
+	if m.Variables[2].Name != "v2" {
+		t.Error("appending to a page modified the file's variables")
+	}
+}
+
+func TestMetadata_VariablePage(t *testing.T) {
+	m := &Metadata{Variables: []*types.VariableInfo{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+	if m.NumVariables() != 3 {
+		t.Fatalf("NumVariables() = %d, want 3", m.NumVariables())
+	}
+	page := m.VariablePage(1, 5)
+	if len(page) != 2 || page[0].Name != "b" || page[1].Name != "c" {
+		t.Errorf("VariablePage(1, 5) = %v", page)
+	}
+}
@@ -0,0 +1,213 @@
+package parquetio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Parquet physical types.
+const (
+	typeBoolean   = 0
+	typeInt32     = 1
+	typeInt64     = 2
+	typeInt96     = 3
+	typeFloat     = 4
+	typeDouble    = 5
+	typeByteArray = 6
+	typeFixedLen  = 7
+)
+
+// errTruncated indicates page data shorter than its values require.
+var errTruncated = errors.New("truncated page data")
+
+// plainEncode encodes values with the PLAIN encoding. values holds the
+// physical representation: []bool, []int32, []int64, []float32,
+// []float64 or []string.
+func plainEncode(values interface{}) []byte {
+	var buf bytes.Buffer
+	switch v := values.(type) {
+	case []bool:
+		packed := make([]byte, (len(v)+7)/8)
+		for i, b := range v {
+			if b {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		buf.Write(packed)
+	case []int32:
+		for _, x := range v {
+			_ = binary.Write(&buf, binary.LittleEndian, x)
+		}
+	case []int64:
+		for _, x := range v {
+			_ = binary.Write(&buf, binary.LittleEndian, x)
+		}
+	case []float32:
+		for _, x := range v {
+			_ = binary.Write(&buf, binary.LittleEndian, math.Float32bits(x))
+		}
+	case []float64:
+		for _, x := range v {
+			_ = binary.Write(&buf, binary.LittleEndian, math.Float64bits(x))
+		}
+	case []string:
+		for _, s := range v {
+			//nolint:gosec // G115: string lengths are checked when columns are built
+			_ = binary.Write(&buf, binary.LittleEndian, uint32(len(s)))
+			buf.WriteString(s)
+		}
+	}
+	return buf.Bytes()
+}
+
+// plainDecode decodes n PLAIN-encoded values of a physical type and
+// returns them with the number of bytes consumed.
+func plainDecode(data []byte, physical int64, typeLength int64, n int) (interface{}, int, error) {
+	fixed := func(size int) error {
+		if n > len(data)/size {
+			return errTruncated
+		}
+		return nil
+	}
+
+	switch physical {
+	case typeBoolean:
+		if n > len(data)*8 {
+			return nil, 0, errTruncated
+		}
+		out := make([]bool, n)
+		for i := range out {
+			out[i] = data[i/8]&(1<<(i%8)) != 0
+		}
+		return out, (n + 7) / 8, nil
+	case typeInt32:
+		if err := fixed(4); err != nil {
+			return nil, 0, err
+		}
+		out := make([]int32, n)
+		for i := range out {
+			out[i] = int32(binary.LittleEndian.Uint32(data[i*4:])) //nolint:gosec // G115: reinterprets the bits
+		}
+		return out, n * 4, nil
+	case typeInt64:
+		if err := fixed(8); err != nil {
+			return nil, 0, err
+		}
+		out := make([]int64, n)
+		for i := range out {
+			out[i] = int64(binary.LittleEndian.Uint64(data[i*8:])) //nolint:gosec // G115: reinterprets the bits
+		}
+		return out, n * 8, nil
+	case typeFloat:
+		if err := fixed(4); err != nil {
+			return nil, 0, err
+		}
+		out := make([]float32, n)
+		for i := range out {
+			out[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+		}
+		return out, n * 4, nil
+	case typeDouble:
+		if err := fixed(8); err != nil {
+			return nil, 0, err
+		}
+		out := make([]float64, n)
+		for i := range out {
+			out[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[i*8:]))
+		}
+		return out, n * 8, nil
+	case typeByteArray:
+		out := make([]string, 0, min(n, len(data)/4))
+		pos := 0
+		for i := 0; i < n; i++ {
+			if pos+4 > len(data) {
+				return nil, 0, errTruncated
+			}
+			size := int(binary.LittleEndian.Uint32(data[pos:]))
+			pos += 4
+			if size < 0 || size > len(data)-pos {
+				return nil, 0, errTruncated
+			}
+			out = append(out, string(data[pos:pos+size]))
+			pos += size
+		}
+		return out, pos, nil
+	case typeFixedLen:
+		size := int(typeLength)
+		if size <= 0 {
+			return nil, 0, fmt.Errorf("invalid fixed length %d", typeLength)
+		}
+		if err := fixed(size); err != nil {
+			return nil, 0, err
+		}
+		out := make([]string, n)
+		for i := range out {
+			out[i] = string(data[i*size : (i+1)*size])
+		}
+		return out, n * size, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported physical type %d", physical)
+	}
+}
+
+// decodeHybrid decodes n values of the RLE/bit-packing hybrid encoding
+// used for definition levels and dictionary indices.
+func decodeHybrid(data []byte, bitWidth int, n int) ([]int, error) {
+	if bitWidth < 0 || bitWidth > 32 {
+		return nil, fmt.Errorf("invalid bit width %d", bitWidth)
+	}
+	out := make([]int, 0, n)
+	byteWidth := (bitWidth + 7) / 8
+	pos := 0
+
+	for len(out) < n {
+		header, k := binary.Uvarint(data[pos:])
+		if k <= 0 {
+			return nil, errTruncated
+		}
+		pos += k
+
+		if header&1 == 0 {
+			// RLE run: a repeated value stored in byteWidth bytes
+			count := header >> 1
+			if pos+byteWidth > len(data) {
+				return nil, errTruncated
+			}
+			value := 0
+			for i := 0; i < byteWidth; i++ {
+				value |= int(data[pos+i]) << (8 * i)
+			}
+			pos += byteWidth
+			for i := uint64(0); i < count && len(out) < n; i++ {
+				out = append(out, value)
+			}
+			continue
+		}
+
+		// Bit-packed run: groups of 8 values, least significant bit first
+		groups := header >> 1
+		if groups > uint64(len(data)-pos) {
+			return nil, errTruncated
+		}
+		size := int(groups) * bitWidth
+		if pos+size > len(data) {
+			return nil, errTruncated
+		}
+		packed := data[pos : pos+size]
+		pos += size
+		for i := 0; i < int(groups)*8 && len(out) < n; i++ {
+			value := 0
+			for b := 0; b < bitWidth; b++ {
+				bit := i*bitWidth + b
+				if packed[bit/8]&(1<<(bit%8)) != 0 {
+					value |= 1 << b
+				}
+			}
+			out = append(out, value)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,896 @@
+// Package parquetio converts MATLAB variables to and from Apache Parquet
+// files, so tabular .mat data can be used by analytics tools directly.
+//
+// A table is represented the way MATLAB code commonly stores one before
+// the table class: a scalar struct whose fields are column vectors of
+// equal length. ToParquet also accepts a plain 2-D matrix, whose columns
+// become Parquet columns named Var1, Var2, ... like MATLAB's array2table.
+//
+// Column types map as follows:
+//
+//	MATLAB            Parquet
+//	double            DOUBLE
+//	single            FLOAT
+//	int8/16/32        INT32 (INT_8/INT_16 annotated)
+//	uint8/16/32       INT32 (UINT_8/UINT_16/UINT_32 annotated)
+//	int64, uint64     INT64 (UINT_64 annotated)
+//	logical           BOOLEAN
+//	string array      BYTE_ARRAY (UTF8)
+//	datenum (double)  INT64 TIMESTAMP_MILLIS, see WithDatenumColumns
+//
+// Files are written uncompressed with PLAIN encoding in one row group.
+// FromParquet also reads files from other writers using dictionary
+// encoding, optional columns and the SNAPPY or GZIP codecs. Nested
+// schemas, INT96 timestamps and other codecs are not supported.
+package parquetio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// magic starts and ends every Parquet file.
+const magic = "PAR1"
+
+// datenumUnixEpoch is the MATLAB datenum of 1970-01-01.
+const datenumUnixEpoch = 719529
+
+// maxPageSize bounds the decompressed size of a page.
+const maxPageSize = 1 << 30
+
+// Parquet converted types (legacy logical type annotations).
+const (
+	convertedUTF8            = 0
+	convertedDate            = 6
+	convertedTimestampMillis = 9
+	convertedTimestampMicros = 10
+	convertedUint8           = 11
+	convertedUint16          = 12
+	convertedUint32          = 13
+	convertedUint64          = 14
+	convertedInt8            = 15
+	convertedInt16           = 16
+)
+
+// Parquet encodings and codecs.
+const (
+	encodingPlain      = 0
+	encodingPlainDict  = 2
+	encodingRLE        = 3
+	encodingRLEDict    = 8
+	codecUncompressed  = 0
+	codecSnappy        = 1
+	codecGzip          = 2
+	pageData           = 0
+	pageDictionary     = 2
+	pageDataV2         = 3
+	repetitionRequired = 0
+	repetitionOptional = 1
+)
+
+// defaultTableName names variables returned by FromParquet.
+const defaultTableName = "table"
+
+// matrixColumnName formats the column names of matrices, as array2table
+// does.
+const matrixColumnName = "Var%d"
+
+// ErrUnsupported indicates a variable or Parquet feature that cannot be
+// converted.
+var ErrUnsupported = errors.New("unsupported by parquetio")
+
+// Option configures ToParquet.
+type Option func(*config)
+
+// config holds optional configuration for ToParquet.
+type config struct {
+	datenums map[string]bool
+}
+
+// WithDatenumColumns writes the named double columns, which hold MATLAB
+// datenums, as Parquet timestamps in milliseconds. FromParquet converts
+// timestamps and dates back to datenums.
+//
+// Example:
+//
+//	parquetio.ToParquet(v, "log.parquet", parquetio.WithDatenumColumns("time"))
+func WithDatenumColumns(names ...string) Option {
+	return func(c *config) {
+		for _, name := range names {
+			c.datenums[name] = true
+		}
+	}
+}
+
+// column is one table column in physical representation.
+type column struct {
+	name      string
+	physical  int
+	converted int // -1 for none
+	values    interface{}
+}
+
+// ToParquet writes a table variable to a Parquet file.
+//
+// v must be a scalar struct whose fields are vectors of equal length, or a
+// real numeric or logical matrix.
+//
+// Example:
+//
+//	v := matFile.GetVariable("results")
+//	if err := parquetio.ToParquet(v, "results.parquet"); err != nil {
+//	    log.Fatal(err)
+//	}
+func ToParquet(v *types.Variable, path string, opts ...Option) error {
+	data, err := Encode(v, opts...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644) //nolint:gosec // G306: output files are meant to be shared
+}
+
+// FromParquet reads a Parquet file as a scalar struct variable named
+// "table", with one n×1 field per column.
+//
+// Example:
+//
+//	v, err := parquetio.FromParquet("results.parquet")
+//	writer.WriteVariable(v)
+func FromParquet(path string) (*types.Variable, error) {
+	//nolint:gosec // G304: path is provided by the caller
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
+
+// Encode returns the Parquet file for a table variable. See ToParquet.
+func Encode(v *types.Variable, opts ...Option) ([]byte, error) {
+	cfg := &config{datenums: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	columns, rows, err := tableColumns(v, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+
+	schema := []interface{}{tStruct{
+		{4, "schema"},
+		{5, int32(len(columns))}, //nolint:gosec // G115: column count is bounded by the variable
+	}}
+	var chunks []interface{}
+	var total int64
+	for _, col := range columns {
+		element := tStruct{
+			{1, int32(col.physical)},
+			{3, int32(repetitionRequired)},
+			{4, col.name},
+		}
+		if col.converted >= 0 {
+			element = append(element, tField{6, int32(col.converted)})
+		}
+		schema = append(schema, element)
+
+		body := plainEncode(col.values)
+		header := encodeStruct(tStruct{
+			{1, int32(pageData)},
+			{2, int32(len(body))}, //nolint:gosec // G115: page size checked below
+			{3, int32(len(body))}, //nolint:gosec // G115: page size checked below
+			{5, tStruct{
+				{1, int32(rows)}, //nolint:gosec // G115: row count checked by tableColumns
+				{2, int32(encodingPlain)},
+				{3, int32(encodingRLE)},
+				{4, int32(encodingRLE)},
+			}},
+		})
+		if len(body) > math.MaxInt32 {
+			return nil, fmt.Errorf("column %q exceeds the 2 GB page limit: %w", col.name, ErrUnsupported)
+		}
+
+		offset := int64(buf.Len())
+		size := int64(len(header) + len(body))
+		buf.Write(header)
+		buf.Write(body)
+		total += size
+
+		chunks = append(chunks, tStruct{
+			{2, offset},
+			{3, tStruct{
+				{1, int32(col.physical)},
+				{2, tListValue{ctI32, []interface{}{int32(encodingPlain), int32(encodingRLE)}}},
+				{3, tListValue{ctBinary, []interface{}{col.name}}},
+				{4, int32(codecUncompressed)},
+				{5, int64(rows)},
+				{6, size},
+				{7, size},
+				{9, offset},
+			}},
+		})
+	}
+
+	footer := encodeStruct(tStruct{
+		{1, int32(1)},
+		{2, tListValue{ctStruct, schema}},
+		{3, int64(rows)},
+		{4, tListValue{ctStruct, []interface{}{tStruct{
+			{1, tListValue{ctStruct, chunks}},
+			{2, total},
+			{3, int64(rows)},
+		}}}},
+		{6, "scigolib/matlab parquetio"},
+	})
+	buf.Write(footer)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(footer))) //nolint:gosec // G115: footer is small
+	buf.WriteString(magic)
+
+	return buf.Bytes(), nil
+}
+
+// tableColumns splits a table variable into columns.
+func tableColumns(v *types.Variable, cfg *config) ([]column, int, error) {
+	if v == nil {
+		return nil, 0, errors.New("variable cannot be nil")
+	}
+	if v.IsComplex {
+		return nil, 0, fmt.Errorf("complex variable %q: %w", v.Name, ErrUnsupported)
+	}
+
+	var columns []column
+	rows := -1
+	add := func(name string, values interface{}) error {
+		col, err := makeColumn(name, values, cfg.datenums[name])
+		if err != nil {
+			return err
+		}
+		n := reflect.ValueOf(values).Len()
+		if rows >= 0 && n != rows {
+			return fmt.Errorf("column %q has %d rows, want %d", name, n, rows)
+		}
+		rows = n
+		columns = append(columns, col)
+		return nil
+	}
+
+	if st, ok := v.Data.(*types.StructArray); ok {
+		if len(st.Elements) != 1 {
+			return nil, 0, fmt.Errorf("struct %q must be scalar: %w", v.Name, ErrUnsupported)
+		}
+		for i, field := range st.Fields {
+			fv := st.Elements[0][i]
+			if fv == nil || fv.IsComplex {
+				return nil, 0, fmt.Errorf("field %q: %w", field, ErrUnsupported)
+			}
+			if err := add(field, fv.Data); err != nil {
+				return nil, 0, err
+			}
+		}
+	} else {
+		data := reflect.ValueOf(v.Data)
+		if data.Kind() != reflect.Slice {
+			return nil, 0, fmt.Errorf("variable %q of type %T: %w", v.Name, v.Data, ErrUnsupported)
+		}
+		rows, cols := data.Len(), 1
+		if len(v.Dimensions) > 0 {
+			rows, cols = v.Dimensions[0], 1
+			for _, d := range v.Dimensions[1:] {
+				cols *= d
+			}
+		}
+		if rows*cols != data.Len() {
+			return nil, 0, fmt.Errorf("variable %q has %d elements, dimensions %v", v.Name, data.Len(), v.Dimensions)
+		}
+		for j := 0; j < cols; j++ {
+			name := fmt.Sprintf(matrixColumnName, j+1)
+			if err := add(name, data.Slice(j*rows, (j+1)*rows).Interface()); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil, 0, fmt.Errorf("variable %q has no columns: %w", v.Name, ErrUnsupported)
+	}
+	if rows > math.MaxInt32 {
+		return nil, 0, fmt.Errorf("%d rows exceed the page limit: %w", rows, ErrUnsupported)
+	}
+	return columns, rows, nil
+}
+
+// makeColumn converts column data to its physical representation.
+//
+//nolint:gocyclo,cyclop // One case per MATLAB class
+func makeColumn(name string, data interface{}, datenum bool) (column, error) {
+	col := column{name: name, converted: -1}
+	switch v := data.(type) {
+	case []float64:
+		if datenum {
+			millis := make([]int64, len(v))
+			for i, d := range v {
+				millis[i] = int64(math.Round((d - datenumUnixEpoch) * 86400000))
+			}
+			col.physical, col.converted, col.values = typeInt64, convertedTimestampMillis, millis
+		} else {
+			col.physical, col.values = typeDouble, v
+		}
+	case []float32:
+		col.physical, col.values = typeFloat, v
+	case []int8:
+		col.physical, col.converted, col.values = typeInt32, convertedInt8, widen(v)
+	case []int16:
+		col.physical, col.converted, col.values = typeInt32, convertedInt16, widen(v)
+	case []int32:
+		col.physical, col.values = typeInt32, v
+	case []uint8:
+		col.physical, col.converted, col.values = typeInt32, convertedUint8, widen(v)
+	case []uint16:
+		col.physical, col.converted, col.values = typeInt32, convertedUint16, widen(v)
+	case []uint32:
+		col.physical, col.converted, col.values = typeInt32, convertedUint32, widen(v)
+	case []int64:
+		col.physical, col.values = typeInt64, v
+	case []uint64:
+		out := make([]int64, len(v))
+		for i, x := range v {
+			out[i] = int64(x) //nolint:gosec // G115: UINT_64 stores the bits as INT64
+		}
+		col.physical, col.converted, col.values = typeInt64, convertedUint64, out
+	case []bool:
+		col.physical, col.values = typeBoolean, v
+	case []string:
+		for _, s := range v {
+			if len(s) > math.MaxInt32 {
+				return col, fmt.Errorf("column %q: string too long: %w", name, ErrUnsupported)
+			}
+		}
+		col.physical, col.converted, col.values = typeByteArray, convertedUTF8, v
+	default:
+		return col, fmt.Errorf("column %q of type %T: %w", name, data, ErrUnsupported)
+	}
+	if datenum && col.converted != convertedTimestampMillis {
+		return col, fmt.Errorf("datenum column %q must be double, got %T", name, data)
+	}
+	return col, nil
+}
+
+// widen converts a slice of small integers to []int32, reinterpreting
+// uint32 bits as Parquet does.
+func widen(data interface{}) []int32 {
+	v := reflect.ValueOf(data)
+	out := make([]int32, v.Len())
+	for i := range out {
+		elem := v.Index(i)
+		if elem.CanInt() {
+			out[i] = int32(elem.Int()) //nolint:gosec // G115: values fit by type
+		} else {
+			out[i] = int32(uint32(elem.Uint())) //nolint:gosec // G115: reinterprets the bits
+		}
+	}
+	return out
+}
+
+// leaf describes a column of the Parquet schema.
+type leaf struct {
+	name       string
+	physical   int64
+	typeLength int64
+	optional   bool
+	converted  int64 // -1 for none
+	timeUnit   int64 // units per day for timestamps, 0 otherwise
+	date       bool
+}
+
+// Decode reads a Parquet file from memory. See FromParquet.
+func Decode(data []byte) (*types.Variable, error) {
+	if len(data) < 12 || string(data[:4]) != magic || string(data[len(data)-4:]) != magic {
+		return nil, errors.New("not a Parquet file")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerLen <= 0 || footerLen > len(data)-12 {
+		return nil, errors.New("invalid Parquet footer length")
+	}
+	meta, _, err := decodeStruct(data[len(data)-8-footerLen : len(data)-8])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode file metadata: %w", err)
+	}
+
+	leaves, err := schemaLeaves(meta.list(2))
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([][]interface{}, len(leaves))
+	for _, rg := range meta.list(4) {
+		group, _ := rg.(thriftStruct)
+		chunks := group.list(1)
+		if len(chunks) != len(leaves) {
+			return nil, fmt.Errorf("row group has %d columns, schema has %d", len(chunks), len(leaves))
+		}
+		for i, c := range chunks {
+			chunk, _ := c.(thriftStruct)
+			values, err := readChunk(data, chunk.sub(3), leaves[i])
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", leaves[i].name, err)
+			}
+			columns[i] = append(columns[i], values)
+		}
+	}
+
+	st := &types.StructArray{Dimensions: []int{1, 1}, Elements: [][]*types.Variable{{}}}
+	for i, l := range leaves {
+		field, err := columnVariable(l, columns[i])
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", l.name, err)
+		}
+		st.Fields = append(st.Fields, l.name)
+		st.Elements[0] = append(st.Elements[0], field)
+	}
+
+	return &types.Variable{
+		Name:       defaultTableName,
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data:       st,
+	}, nil
+}
+
+// schemaLeaves validates a flat schema and describes its columns.
+func schemaLeaves(schema []interface{}) ([]leaf, error) {
+	if len(schema) == 0 {
+		return nil, errors.New("empty Parquet schema")
+	}
+	root, _ := schema[0].(thriftStruct)
+	if int(root.int(5, 0)) != len(schema)-1 {
+		return nil, fmt.Errorf("nested Parquet schemas: %w", ErrUnsupported)
+	}
+
+	leaves := make([]leaf, 0, len(schema)-1)
+	for _, s := range schema[1:] {
+		el, _ := s.(thriftStruct)
+		if el.int(5, 0) > 0 || el.int(3, repetitionRequired) > repetitionOptional {
+			return nil, fmt.Errorf("nested or repeated column %q: %w", el.str(4), ErrUnsupported)
+		}
+		l := leaf{
+			name:       el.str(4),
+			physical:   el.int(1, -1),
+			typeLength: el.int(2, 0),
+			optional:   el.int(3, repetitionRequired) == repetitionOptional,
+			converted:  el.int(6, -1),
+		}
+		switch l.converted {
+		case convertedTimestampMillis:
+			l.timeUnit = 86400000
+		case convertedTimestampMicros:
+			l.timeUnit = 86400000000
+		case convertedDate:
+			l.date = true
+		}
+		// Newer writers may only set the logical type
+		if logical := el.sub(10); logical != nil {
+			if ts := logical.sub(8); ts != nil {
+				unit := ts.sub(2)
+				switch {
+				case unit.sub(1) != nil:
+					l.timeUnit = 86400000
+				case unit.sub(2) != nil:
+					l.timeUnit = 86400000000
+				case unit.sub(3) != nil:
+					l.timeUnit = 86400000000000
+				}
+			}
+			if logical.sub(6) != nil {
+				l.date = true
+			}
+		}
+		if l.physical == typeInt96 {
+			return nil, fmt.Errorf("INT96 column %q: %w", l.name, ErrUnsupported)
+		}
+		leaves = append(leaves, l)
+	}
+	return leaves, nil
+}
+
+// readChunk decodes all pages of a column chunk. Null values of optional
+// columns are returned as nil entries in the result when the column is
+// not floating point, and as NaN otherwise.
+func readChunk(data []byte, meta thriftStruct, l leaf) (interface{}, error) {
+	if meta == nil {
+		return nil, errors.New("missing column metadata")
+	}
+	codec := meta.int(4, codecUncompressed)
+	remaining := meta.int(5, 0)
+	pos := meta.int(9, 0)
+	if dict := meta.int(11, 0); dict > 0 && dict < pos {
+		pos = dict
+	}
+
+	var dictionary interface{}
+	var parts []interface{}
+	var nulls []bool
+	for remaining > 0 {
+		if pos <= 0 || pos >= int64(len(data)) {
+			return nil, fmt.Errorf("page offset %d outside file", pos)
+		}
+		header, n, err := decodeStruct(data[pos:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode page header: %w", err)
+		}
+		pos += int64(n)
+		size := header.int(3, -1)
+		if size < 0 || size > int64(len(data))-pos {
+			return nil, fmt.Errorf("page size %d exceeds file", size)
+		}
+		raw := data[pos : pos+size]
+		pos += size
+
+		switch header.int(1, -1) {
+		case pageDictionary:
+			body, err := decompress(raw, codec, header.int(2, 0))
+			if err != nil {
+				return nil, err
+			}
+			count := int(header.sub(7).int(1, 0))
+			if dictionary, _, err = plainDecode(body, l.physical, l.typeLength, count); err != nil {
+				return nil, err
+			}
+		case pageData, pageDataV2:
+			values, present, count, err := readDataPage(raw, header, codec, l, dictionary)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, values)
+			nulls = append(nulls, present...)
+			remaining -= int64(count)
+		default:
+			// Index pages and unknown page types are skipped
+		}
+	}
+
+	return joinValues(parts, nulls)
+}
+
+// readDataPage decodes a version 1 or 2 data page. It returns the non-null
+// values, the per-row presence flags and the number of rows.
+func readDataPage(raw []byte, header thriftStruct, codec int64, l leaf, dictionary interface{}) (interface{}, []bool, int, error) {
+	var body []byte
+	var defLevels []byte
+	var count int
+	var encoding int64
+	var err error
+
+	if v2 := header.sub(8); v2 != nil {
+		count = int(v2.int(1, 0))
+		encoding = v2.int(4, encodingPlain)
+		defLen := v2.int(5, 0)
+		repLen := v2.int(6, 0)
+		if defLen < 0 || repLen < 0 || defLen+repLen > int64(len(raw)) {
+			return nil, nil, 0, errTruncated
+		}
+		defLevels = raw[repLen : repLen+defLen]
+		body = raw[repLen+defLen:]
+		if compressed, ok := v2[7].(bool); !ok || compressed {
+			if body, err = decompress(body, codec, header.int(2, 0)-defLen-repLen); err != nil {
+				return nil, nil, 0, err
+			}
+		}
+	} else {
+		v1 := header.sub(5)
+		count = int(v1.int(1, 0))
+		encoding = v1.int(2, encodingPlain)
+		if body, err = decompress(raw, codec, header.int(2, 0)); err != nil {
+			return nil, nil, 0, err
+		}
+		if l.optional {
+			if len(body) < 4 {
+				return nil, nil, 0, errTruncated
+			}
+			n := int(binary.LittleEndian.Uint32(body))
+			if n < 0 || n > len(body)-4 {
+				return nil, nil, 0, errTruncated
+			}
+			defLevels = body[4 : 4+n]
+			body = body[4+n:]
+		}
+	}
+	if count < 0 || count > maxPageSize {
+		return nil, nil, 0, fmt.Errorf("invalid value count %d", count)
+	}
+
+	present := make([]bool, count)
+	nonNull := count
+	if l.optional {
+		levels, err := decodeHybrid(defLevels, 1, count)
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("definition levels: %w", err)
+		}
+		nonNull = 0
+		for i, level := range levels {
+			present[i] = level == 1
+			if present[i] {
+				nonNull++
+			}
+		}
+	} else {
+		for i := range present {
+			present[i] = true
+		}
+	}
+
+	switch encoding {
+	case encodingPlain:
+		values, _, err := plainDecode(body, l.physical, l.typeLength, nonNull)
+		return values, present, count, err
+	case encodingPlainDict, encodingRLEDict:
+		if dictionary == nil {
+			return nil, nil, 0, errors.New("dictionary page missing")
+		}
+		if len(body) < 1 {
+			return nil, nil, 0, errTruncated
+		}
+		indices, err := decodeHybrid(body[1:], int(body[0]), nonNull)
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("dictionary indices: %w", err)
+		}
+		dict := reflect.ValueOf(dictionary)
+		values := reflect.MakeSlice(dict.Type(), len(indices), len(indices))
+		for i, idx := range indices {
+			if idx >= dict.Len() {
+				return nil, nil, 0, fmt.Errorf("dictionary index %d out of range", idx)
+			}
+			values.Index(i).Set(dict.Index(idx))
+		}
+		return values.Interface(), present, count, nil
+	default:
+		return nil, nil, 0, fmt.Errorf("encoding %d: %w", encoding, ErrUnsupported)
+	}
+}
+
+// decompress undoes the page codec.
+func decompress(data []byte, codec int64, size int64) ([]byte, error) {
+	if size < 0 || size > maxPageSize {
+		return nil, fmt.Errorf("invalid page size %d", size)
+	}
+	switch codec {
+	case codecUncompressed:
+		return data, nil
+	case codecSnappy:
+		return snappyDecode(data, int(size))
+	case codecGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close() //nolint:errcheck // Reading from memory
+		return io.ReadAll(io.LimitReader(zr, size))
+	default:
+		return nil, fmt.Errorf("compression codec %d: %w", codec, ErrUnsupported)
+	}
+}
+
+// nullRows records a chunk's values with the rows they belong to.
+type nullRows struct {
+	values  interface{}
+	present []bool
+}
+
+// joinValues concatenates page values and keeps the presence flags.
+func joinValues(parts []interface{}, present []bool) (interface{}, error) {
+	if len(parts) == 0 {
+		return nullRows{present: present}, nil
+	}
+	all := reflect.MakeSlice(reflect.TypeOf(parts[0]), 0, len(present))
+	for _, p := range parts {
+		all = reflect.AppendSlice(all, reflect.ValueOf(p))
+	}
+	return nullRows{values: all.Interface(), present: present}, nil
+}
+
+// columnVariable converts decoded row groups of a column to a MATLAB
+// column vector.
+func columnVariable(l leaf, groups []interface{}) (*types.Variable, error) {
+	var values reflect.Value
+	var present []bool
+	for _, g := range groups {
+		rows, _ := g.(nullRows)
+		present = append(present, rows.present...)
+		if rows.values == nil {
+			continue
+		}
+		v := reflect.ValueOf(rows.values)
+		if !values.IsValid() {
+			values = reflect.MakeSlice(v.Type(), 0, v.Len())
+		}
+		values = reflect.AppendSlice(values, v)
+	}
+
+	physical, err := expandNulls(l, values, present)
+	if err != nil {
+		return nil, err
+	}
+	data, dataType := logicalValues(l, physical)
+	return &types.Variable{
+		Name:       l.name,
+		Dimensions: []int{len(present), 1},
+		DataType:   dataType,
+		Data:       data,
+	}, nil
+}
+
+// expandNulls returns the column's values with one entry per row. Nulls
+// become NaN in floating-point and timestamp columns and are an error
+// elsewhere, as MATLAB numeric arrays have no missing value.
+func expandNulls(l leaf, values reflect.Value, present []bool) (interface{}, error) {
+	nonNull := 0
+	for _, p := range present {
+		if p {
+			nonNull++
+		}
+	}
+	if !values.IsValid() {
+		values = reflect.ValueOf(emptyPhysical(l.physical))
+	}
+	if values.Len() != nonNull {
+		return nil, fmt.Errorf("%d values for %d present rows", values.Len(), nonNull)
+	}
+	if nonNull == len(present) {
+		return values.Interface(), nil
+	}
+
+	isTime := l.timeUnit > 0 || l.date
+	if !isTime && l.physical != typeFloat && l.physical != typeDouble {
+		return nil, fmt.Errorf("null values in a %s column: %w", physicalName(l.physical), ErrUnsupported)
+	}
+
+	// Expand to float64 with NaN for nulls; time columns become datenums
+	out := make([]float64, len(present))
+	j := 0
+	for i, p := range present {
+		if !p {
+			out[i] = math.NaN()
+			continue
+		}
+		out[i] = toFloat(values.Index(j))
+		j++
+	}
+	if isTime {
+		return timeToDatenum(l, out), nil
+	}
+	if l.physical == typeFloat {
+		single := make([]float32, len(out))
+		for i, x := range out {
+			single[i] = float32(x)
+		}
+		return single, nil
+	}
+	return out, nil
+}
+
+// logicalValues converts physical values to the MATLAB type implied by
+// the column's annotations.
+func logicalValues(l leaf, values interface{}) (interface{}, types.DataType) {
+	if f, ok := values.([]float64); ok && (l.timeUnit > 0 || l.date) {
+		// Already converted while expanding nulls
+		return f, types.Double
+	}
+	switch v := values.(type) {
+	case []bool:
+		return v, types.Logical
+	case []float32:
+		return v, types.Single
+	case []float64:
+		return v, types.Double
+	case []string:
+		return v, types.Char
+	case []int32:
+		switch {
+		case l.date:
+			return timeToDatenum(l, toFloats(v)), types.Double
+		case l.converted == convertedInt8:
+			return convertInts(v, int8(0)), types.Int8
+		case l.converted == convertedInt16:
+			return convertInts(v, int16(0)), types.Int16
+		case l.converted == convertedUint8:
+			return convertInts(v, uint8(0)), types.Uint8
+		case l.converted == convertedUint16:
+			return convertInts(v, uint16(0)), types.Uint16
+		case l.converted == convertedUint32:
+			return convertInts(v, uint32(0)), types.Uint32
+		}
+		return v, types.Int32
+	case []int64:
+		switch {
+		case l.timeUnit > 0 || l.date:
+			return timeToDatenum(l, toFloats(v)), types.Double
+		case l.converted == convertedUint64:
+			return convertInts(v, uint64(0)), types.Uint64
+		}
+		return v, types.Int64
+	}
+	return values, types.Unknown
+}
+
+// timeToDatenum converts timestamps or day counts since the Unix epoch to
+// MATLAB datenums.
+func timeToDatenum(l leaf, values []float64) []float64 {
+	unit := float64(l.timeUnit)
+	if l.date {
+		unit = 1
+	}
+	out := make([]float64, len(values))
+	for i, x := range values {
+		out[i] = x/unit + datenumUnixEpoch
+	}
+	return out
+}
+
+// convertInts converts an integer slice to a slice of like's type,
+// reinterpreting bits where Parquet stores unsigned values as signed.
+func convertInts(values interface{}, like interface{}) interface{} {
+	v := reflect.ValueOf(values)
+	elem := reflect.TypeOf(like)
+	out := reflect.MakeSlice(reflect.SliceOf(elem), v.Len(), v.Len())
+	for i := 0; i < v.Len(); i++ {
+		x := v.Index(i).Int()
+		if elem.Kind() == reflect.Uint32 {
+			x = int64(uint32(x)) //nolint:gosec // G115: reinterprets the bits
+		}
+		out.Index(i).Set(reflect.ValueOf(x).Convert(elem))
+	}
+	return out.Interface()
+}
+
+// toFloats converts an integer slice to []float64.
+func toFloats(values interface{}) []float64 {
+	v := reflect.ValueOf(values)
+	out := make([]float64, v.Len())
+	for i := range out {
+		out[i] = toFloat(v.Index(i))
+	}
+	return out
+}
+
+// toFloat converts a numeric reflect value to float64.
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case v.CanInt():
+		return float64(v.Int())
+	case v.CanUint():
+		return float64(v.Uint())
+	case v.CanFloat():
+		return v.Float()
+	default:
+		return math.NaN()
+	}
+}
+
+// emptyPhysical returns an empty slice of a physical type's Go type.
+func emptyPhysical(physical int64) interface{} {
+	v, _, _ := plainDecode(nil, physical, 1, 0)
+	if v == nil {
+		return []float64{}
+	}
+	return v
+}
+
+// physicalName returns the Parquet name of a physical type.
+func physicalName(physical int64) string {
+	names := []string{"BOOLEAN", "INT32", "INT64", "INT96", "FLOAT", "DOUBLE", "BYTE_ARRAY", "FIXED_LEN_BYTE_ARRAY"}
+	if physical >= 0 && int(physical) < len(names) {
+		return names[physical]
+	}
+	return fmt.Sprintf("type %d", physical)
+}
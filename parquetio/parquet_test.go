@@ -0,0 +1,318 @@
+package parquetio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// table builds a scalar struct variable from field names and values.
+func table(fields []string, values ...interface{}) *types.Variable {
+	st := &types.StructArray{Fields: fields, Dimensions: []int{1, 1}, Elements: [][]*types.Variable{{}}}
+	for i, v := range values {
+		n := reflect.ValueOf(v).Len()
+		st.Elements[0] = append(st.Elements[0], &types.Variable{
+			Name: fields[i], Dimensions: []int{n, 1}, Data: v,
+		})
+	}
+	return &types.Variable{Name: "t", Dimensions: []int{1, 1}, DataType: types.Struct, Data: st}
+}
+
+// columnData returns the data of each field of a decoded table.
+func columnData(t *testing.T, v *types.Variable) map[string]interface{} {
+	t.Helper()
+	st, ok := v.Data.(*types.StructArray)
+	if !ok || len(st.Elements) != 1 {
+		t.Fatalf("Data = %T, want scalar *types.StructArray", v.Data)
+	}
+	out := make(map[string]interface{})
+	for i, name := range st.Fields {
+		out[name] = st.Elements[0][i].Data
+	}
+	return out
+}
+
+func TestRoundTrip_AllTypes(t *testing.T) {
+	values := []interface{}{
+		[]float64{1.5, -2, math.Inf(1)},
+		[]float32{0.5, 1, 2},
+		[]int8{-128, 0, 127},
+		[]int16{-32768, 1, 32767},
+		[]int32{math.MinInt32, 2, math.MaxInt32},
+		[]uint8{0, 1, 255},
+		[]uint16{0, 1, 65535},
+		[]uint32{0, 1, math.MaxUint32},
+		[]int64{math.MinInt64, 3, math.MaxInt64},
+		[]uint64{0, 1, math.MaxUint64},
+		[]bool{true, false, true},
+		[]string{"alpha", "", "βeta"},
+	}
+	fields := []string{"d", "s", "i8", "i16", "i32", "u8", "u16", "u32", "i64", "u64", "b", "str"}
+
+	path := filepath.Join(t.TempDir(), "all.parquet")
+	if err := ToParquet(table(fields, values...), path); err != nil {
+		t.Fatalf("ToParquet() error = %v", err)
+	}
+	got, err := FromParquet(path)
+	if err != nil {
+		t.Fatalf("FromParquet() error = %v", err)
+	}
+
+	if got.Name != "table" || got.DataType != types.Struct {
+		t.Errorf("got %s %s, want table struct", got.Name, got.DataType)
+	}
+	data := columnData(t, got)
+	for i, name := range fields {
+		if !reflect.DeepEqual(data[name], values[i]) {
+			t.Errorf("column %s = %#v, want %#v", name, data[name], values[i])
+		}
+	}
+
+	st := got.Data.(*types.StructArray)
+	if dims := st.Elements[0][0].Dimensions; !reflect.DeepEqual(dims, []int{3, 1}) {
+		t.Errorf("column dims = %v, want [3 1]", dims)
+	}
+	if dt := st.Elements[0][10].DataType; dt != types.Logical {
+		t.Errorf("logical column DataType = %v", dt)
+	}
+}
+
+func TestRoundTrip_Matrix(t *testing.T) {
+	v := &types.Variable{
+		Name: "A", Dimensions: []int{3, 2}, DataType: types.Double,
+		Data: []float64{1, 2, 3, 4, 5, 6},
+	}
+	data, err := Encode(v)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	cols := columnData(t, got)
+	if !reflect.DeepEqual(cols["Var1"], []float64{1, 2, 3}) || !reflect.DeepEqual(cols["Var2"], []float64{4, 5, 6}) {
+		t.Errorf("columns = %v", cols)
+	}
+}
+
+func TestRoundTrip_Datenum(t *testing.T) {
+	// 2024-01-01 12:00 and 1970-01-01
+	times := []float64{739252.5, 719529}
+	data, err := Encode(table([]string{"time"}, times), WithDatenumColumns("time"))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if cols := columnData(t, got); !reflect.DeepEqual(cols["time"], times) {
+		t.Errorf("time = %v, want %v", cols["time"], times)
+	}
+
+	if _, err := Encode(table([]string{"time"}, []int32{1}), WithDatenumColumns("time")); err == nil {
+		t.Error("expected error for non-double datenum column")
+	}
+}
+
+func TestEncode_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    *types.Variable
+	}{
+		{"nil", nil},
+		{"complex", &types.Variable{Name: "z", Dimensions: []int{1}, IsComplex: true,
+			Data: &types.NumericArray{Real: []float64{1}, Imag: []float64{2}}}},
+		{"ragged", table([]string{"a", "b"}, []float64{1, 2}, []float64{1})},
+		{"char", &types.Variable{Name: "c", Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"}},
+		{"bad dims", &types.Variable{Name: "x", Dimensions: []int{2, 2}, Data: []float64{1}}},
+		{"struct array", &types.Variable{Name: "s", DataType: types.Struct, Data: &types.StructArray{
+			Fields: []string{"a"}, Dimensions: []int{1, 2}, Elements: [][]*types.Variable{{}, {}},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Encode(tt.v); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	for _, data := range [][]byte{
+		nil,
+		[]byte("PAR1PAR1"),
+		[]byte("PAR1\xff\xff\xff\xff\x10\x00\x00\x00PAR1"),
+	} {
+		if _, err := Decode(data); err == nil {
+			t.Errorf("Decode(%q) expected error", data)
+		}
+	}
+}
+
+// buildFile assembles a Parquet file with one column chunk made of the
+// given pages (header and body each).
+func buildFile(t *testing.T, leaf tStruct, rows int64, codec int32, pages ...[]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+	start := int64(buf.Len())
+	for _, p := range pages {
+		buf.Write(p)
+	}
+
+	footer := encodeStruct(tStruct{
+		{1, int32(1)},
+		{2, tListValue{ctStruct, []interface{}{tStruct{{4, "schema"}, {5, int32(1)}}, leaf}}},
+		{3, rows},
+		{4, tListValue{ctStruct, []interface{}{tStruct{
+			{1, tListValue{ctStruct, []interface{}{tStruct{
+				{2, start},
+				{3, tStruct{
+					{1, leaf[0].value},
+					{2, tListValue{ctI32, []interface{}{int32(0)}}},
+					{3, tListValue{ctBinary, []interface{}{"c"}}},
+					{4, codec},
+					{5, rows},
+					{6, int64(buf.Len()) - start},
+					{7, int64(buf.Len()) - start},
+					{9, start},
+				}},
+			}}}},
+			{2, int64(buf.Len()) - start},
+			{3, rows},
+		}}}},
+	})
+	buf.Write(footer)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(footer)))
+	buf.WriteString(magic)
+	return buf.Bytes()
+}
+
+// page encodes a page header followed by its body.
+func page(header tStruct, body []byte) []byte {
+	return append(encodeStruct(header), body...)
+}
+
+// snappyLiteral encodes data as a Snappy block made of one literal.
+func snappyLiteral(data []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(len(data)))
+	out = append(out, byte(len(data)-1)<<2)
+	return append(out, data...)
+}
+
+// TestDecode_OptionalWithNulls tests a v1 page with definition levels.
+func TestDecode_OptionalWithNulls(t *testing.T) {
+	var body bytes.Buffer
+	// Definition levels 1, 0, 1 as one bit-packed group
+	_ = binary.Write(&body, binary.LittleEndian, uint32(2))
+	body.Write([]byte{3, 0b101})
+	body.Write(plainEncode([]float64{1.5, 3}))
+
+	data := buildFile(t,
+		tStruct{{1, int32(typeDouble)}, {3, int32(repetitionOptional)}, {4, "c"}},
+		3, codecUncompressed,
+		page(tStruct{
+			{1, int32(pageData)}, {2, int32(body.Len())}, {3, int32(body.Len())},
+			{5, tStruct{{1, int32(3)}, {2, int32(encodingPlain)}, {3, int32(encodingRLE)}, {4, int32(encodingRLE)}}},
+		}, body.Bytes()),
+	)
+
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	col, _ := columnData(t, got)["c"].([]float64)
+	if len(col) != 3 || col[0] != 1.5 || !math.IsNaN(col[1]) || col[2] != 3 {
+		t.Errorf("c = %v, want [1.5 NaN 3]", col)
+	}
+}
+
+// TestDecode_SnappyDictionary tests a dictionary-encoded string column
+// with Snappy-compressed pages.
+func TestDecode_SnappyDictionary(t *testing.T) {
+	dict := plainEncode([]string{"a", "b"})
+	// Bit width 1, indices 1, 0, 1, 1 as one bit-packed group
+	indices := []byte{1, 3, 0b1101}
+
+	dictPage := snappyLiteral(dict)
+	dataPage := snappyLiteral(indices)
+	data := buildFile(t,
+		tStruct{{1, int32(typeByteArray)}, {3, int32(repetitionRequired)}, {4, "c"}, {6, int32(convertedUTF8)}},
+		4, codecSnappy,
+		page(tStruct{
+			{1, int32(pageDictionary)}, {2, int32(len(dict))}, {3, int32(len(dictPage))},
+			{7, tStruct{{1, int32(2)}, {2, int32(encodingPlain)}}},
+		}, dictPage),
+		page(tStruct{
+			{1, int32(pageData)}, {2, int32(len(indices))}, {3, int32(len(dataPage))},
+			{5, tStruct{{1, int32(4)}, {2, int32(encodingRLEDict)}, {3, int32(encodingRLE)}, {4, int32(encodingRLE)}}},
+		}, dataPage),
+	)
+
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if col := columnData(t, got)["c"]; !reflect.DeepEqual(col, []string{"b", "a", "b", "b"}) {
+		t.Errorf("c = %v, want [b a b b]", col)
+	}
+}
+
+// TestDecode_DataPageV2Dates tests a version 2 data page holding dates.
+func TestDecode_DataPageV2Dates(t *testing.T) {
+	levels := []byte{4, 1} // RLE run of two 1s
+	values := plainEncode([]int32{0, 1})
+	body := append(append([]byte{}, levels...), values...)
+
+	data := buildFile(t,
+		tStruct{{1, int32(typeInt32)}, {3, int32(repetitionOptional)}, {4, "c"}, {6, int32(convertedDate)}},
+		2, codecUncompressed,
+		page(tStruct{
+			{1, int32(pageDataV2)}, {2, int32(len(body))}, {3, int32(len(body))},
+			{8, tStruct{
+				{1, int32(2)}, {2, int32(0)}, {3, int32(2)}, {4, int32(encodingPlain)},
+				{5, int32(len(levels))}, {6, int32(0)}, {7, false},
+			}},
+		}, body),
+	)
+
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if col := columnData(t, got)["c"]; !reflect.DeepEqual(col, []float64{719529, 719530}) {
+		t.Errorf("c = %v, want [719529 719530]", col)
+	}
+}
+
+// TestDecode_NullInteger tests that nulls in integer columns are rejected.
+func TestDecode_NullInteger(t *testing.T) {
+	var body bytes.Buffer
+	_ = binary.Write(&body, binary.LittleEndian, uint32(2))
+	body.Write([]byte{3, 0b01})
+	body.Write(plainEncode([]int32{7}))
+
+	data := buildFile(t,
+		tStruct{{1, int32(typeInt32)}, {3, int32(repetitionOptional)}, {4, "c"}},
+		2, codecUncompressed,
+		page(tStruct{
+			{1, int32(pageData)}, {2, int32(body.Len())}, {3, int32(body.Len())},
+			{5, tStruct{{1, int32(2)}, {2, int32(encodingPlain)}, {3, int32(encodingRLE)}, {4, int32(encodingRLE)}}},
+		}, body.Bytes()),
+	)
+
+	if _, err := Decode(data); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Decode() error = %v, want ErrUnsupported", err)
+	}
+}
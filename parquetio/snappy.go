@@ -0,0 +1,81 @@
+package parquetio
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// errSnappy indicates malformed Snappy data.
+var errSnappy = errors.New("malformed snappy data")
+
+// snappyDecode decompresses a raw Snappy block, the format Parquet uses
+// for its SNAPPY codec. maxLen bounds the decompressed size.
+func snappyDecode(src []byte, maxLen int) ([]byte, error) {
+	n, k := binary.Uvarint(src)
+	if k <= 0 || n > uint64(maxLen) {
+		return nil, errSnappy
+	}
+	src = src[k:]
+	dst := make([]byte, 0, n)
+
+	for len(src) > 0 {
+		tag := src[0]
+		var length, offset int
+		switch tag & 3 {
+		case 0: // literal
+			length = int(tag>>2) + 1
+			src = src[1:]
+			if extra := int(tag>>2) - 59; extra > 0 {
+				if len(src) < extra {
+					return nil, errSnappy
+				}
+				length = 1
+				for i := extra - 1; i >= 0; i-- {
+					length += int(src[i]) << (8 * i)
+				}
+				src = src[extra:]
+			}
+			if length <= 0 || length > len(src) || len(dst)+length > int(n) {
+				return nil, errSnappy
+			}
+			dst = append(dst, src[:length]...)
+			src = src[length:]
+			continue
+		case 1: // copy with 1-byte offset
+			if len(src) < 2 {
+				return nil, errSnappy
+			}
+			length = 4 + int(tag>>2&7)
+			offset = int(tag>>5)<<8 | int(src[1])
+			src = src[2:]
+		case 2: // copy with 2-byte offset
+			if len(src) < 3 {
+				return nil, errSnappy
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+		case 3: // copy with 4-byte offset
+			if len(src) < 5 {
+				return nil, errSnappy
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+
+		if offset <= 0 || offset > len(dst) || len(dst)+length > int(n) {
+			return nil, errSnappy
+		}
+		// Copies may overlap their own output
+		start := len(dst) - offset
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+
+	if len(dst) != int(n) {
+		return nil, errSnappy
+	}
+	return dst, nil
+}
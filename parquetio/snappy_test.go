@@ -0,0 +1,55 @@
+package parquetio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSnappyDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+		want string
+	}{
+		{"literal", []byte{3, 0x08, 'a', 'b', 'c'}, "abc"},
+		// Literal "abc", then an overlapping 1-byte-offset copy of 9 bytes
+		{"overlapping copy", []byte{12, 0x08, 'a', 'b', 'c', 0x15, 0x03}, "abcabcabcabc"},
+		// Literal "ab", then a 2-byte-offset copy of 2 bytes
+		{"two-byte offset", []byte{4, 0x04, 'a', 'b', 0x06, 0x02, 0x00}, "abab"},
+		// Long literal with a one-byte length
+		{"long literal", append([]byte{61, 60 << 2, 60}, bytes.Repeat([]byte{'x'}, 61)...), string(bytes.Repeat([]byte{'x'}, 61))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := snappyDecode(tt.src, 1024)
+			if err != nil {
+				t.Fatalf("snappyDecode() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("snappyDecode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnappyDecode_Malformed(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+	}{
+		{"empty", nil},
+		{"too large", []byte{0xFF, 0x0F}},
+		{"short literal", []byte{3, 0x08, 'a'}},
+		{"offset before start", []byte{4, 0x00, 'a', 0x01, 0x05}},
+		{"length mismatch", []byte{5, 0x08, 'a', 'b', 'c'}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := snappyDecode(tt.src, 1024); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
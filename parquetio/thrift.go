@@ -0,0 +1,348 @@
+package parquetio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Parquet metadata is serialized with the Thrift compact protocol. Only
+// the subset needed for Parquet is implemented: values are built and
+// decoded generically and the Parquet structures are assembled by field
+// id, as listed in parquet.thrift.
+
+// Thrift compact protocol type codes.
+const (
+	ctBoolTrue  = 1
+	ctBoolFalse = 2
+	ctByte      = 3
+	ctI16       = 4
+	ctI32       = 5
+	ctI64       = 6
+	ctDouble    = 7
+	ctBinary    = 8
+	ctList      = 9
+	ctSet       = 10
+	ctMap       = 11
+	ctStruct    = 12
+)
+
+// maxThriftDepth bounds struct and container nesting when decoding.
+const maxThriftDepth = 32
+
+// errThrift indicates malformed Thrift data.
+var errThrift = errors.New("malformed thrift data")
+
+// tField is a field of a Thrift struct being encoded.
+type tField struct {
+	id    int16
+	value interface{} // int32, int64, string, bool, tStruct or tListValue
+}
+
+// tStruct is a Thrift struct being encoded. Fields must be in id order.
+type tStruct []tField
+
+// tListValue is a Thrift list being encoded.
+type tListValue struct {
+	elemType byte
+	items    []interface{}
+}
+
+// encodeStruct serializes s with the compact protocol.
+func encodeStruct(s tStruct) []byte {
+	var buf bytes.Buffer
+	writeStruct(&buf, s)
+	return buf.Bytes()
+}
+
+// writeStruct writes the fields of s followed by the stop byte.
+func writeStruct(buf *bytes.Buffer, s tStruct) {
+	var last int16
+	for _, f := range s {
+		typ := typeCode(f.value)
+		if b, ok := f.value.(bool); ok && !b {
+			typ = ctBoolFalse
+		}
+		if delta := f.id - last; delta > 0 && delta <= 15 {
+			buf.WriteByte(byte(delta)<<4 | typ)
+		} else {
+			buf.WriteByte(typ)
+			writeVarint(buf, int64(f.id))
+		}
+		last = f.id
+		if _, ok := f.value.(bool); !ok {
+			writeValue(buf, f.value)
+		}
+	}
+	buf.WriteByte(0)
+}
+
+// writeValue writes a value without a field header.
+func writeValue(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case int32:
+		writeVarint(buf, int64(v))
+	case int64:
+		writeVarint(buf, v)
+	case string:
+		writeUvarint(buf, uint64(len(v)))
+		buf.WriteString(v)
+	case tStruct:
+		writeStruct(buf, v)
+	case tListValue:
+		if len(v.items) < 15 {
+			buf.WriteByte(byte(len(v.items))<<4 | v.elemType)
+		} else {
+			buf.WriteByte(0xF0 | v.elemType)
+			writeUvarint(buf, uint64(len(v.items)))
+		}
+		for _, item := range v.items {
+			writeValue(buf, item)
+		}
+	default:
+		panic(fmt.Sprintf("parquetio: cannot encode %T", v))
+	}
+}
+
+// typeCode returns the compact type code of an encodable value.
+func typeCode(v interface{}) byte {
+	switch v.(type) {
+	case bool:
+		return ctBoolTrue
+	case int32:
+		return ctI32
+	case int64:
+		return ctI64
+	case string:
+		return ctBinary
+	case tStruct:
+		return ctStruct
+	case tListValue:
+		return ctList
+	default:
+		panic(fmt.Sprintf("parquetio: cannot encode %T", v))
+	}
+}
+
+// writeVarint writes a zigzag-encoded varint.
+func writeVarint(buf *bytes.Buffer, v int64) {
+	writeUvarint(buf, uint64(v<<1)^uint64(v>>63))
+}
+
+// writeUvarint writes an unsigned varint.
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	buf.Write(tmp[:n])
+}
+
+// thriftStruct is a decoded Thrift struct, keyed by field id. Integers
+// decode to int64, binary fields to []byte, lists and sets to
+// []interface{} and nested structs to thriftStruct. Maps are skipped.
+type thriftStruct map[int16]interface{}
+
+// int returns an integer field, or def if it is absent.
+func (s thriftStruct) int(id int16, def int64) int64 {
+	if v, ok := s[id].(int64); ok {
+		return v
+	}
+	return def
+}
+
+// str returns a binary field as a string.
+func (s thriftStruct) str(id int16) string {
+	b, _ := s[id].([]byte)
+	return string(b)
+}
+
+// sub returns a nested struct field, or nil.
+func (s thriftStruct) sub(id int16) thriftStruct {
+	v, _ := s[id].(thriftStruct)
+	return v
+}
+
+// list returns a list field, or nil.
+func (s thriftStruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+// thriftReader decodes compact protocol data from a byte slice.
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+// decodeStruct decodes a struct at the start of data and returns it with
+// the number of bytes consumed.
+func decodeStruct(data []byte) (thriftStruct, int, error) {
+	r := &thriftReader{data: data}
+	s, err := r.readStruct(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s, r.pos, nil
+}
+
+// readStruct reads fields up to and including the stop byte.
+func (r *thriftReader) readStruct(depth int) (thriftStruct, error) {
+	if depth > maxThriftDepth {
+		return nil, fmt.Errorf("%w: nesting too deep", errThrift)
+	}
+	s := make(thriftStruct)
+	var last int16
+	for {
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return s, nil
+		}
+
+		typ := header & 0x0F
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			v, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		last = id
+
+		switch typ {
+		case ctBoolTrue:
+			s[id] = true
+		case ctBoolFalse:
+			s[id] = false
+		default:
+			v, err := r.readValue(typ, depth)
+			if err != nil {
+				return nil, err
+			}
+			s[id] = v
+		}
+	}
+}
+
+// readValue reads a value of the given type.
+func (r *thriftReader) readValue(typ byte, depth int) (interface{}, error) {
+	switch typ {
+	case ctBoolTrue, ctBoolFalse:
+		// Inside containers booleans take one byte
+		b, err := r.byte()
+		return b == ctBoolTrue, err
+	case ctByte:
+		b, err := r.byte()
+		return int64(int8(b)), err
+	case ctI16, ctI32, ctI64:
+		return r.varint()
+	case ctDouble:
+		if r.pos+8 > len(r.data) {
+			return nil, fmt.Errorf("%w: truncated double", errThrift)
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos:]))
+		r.pos += 8
+		return v, nil
+	case ctBinary:
+		n, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.data)-r.pos) {
+			return nil, fmt.Errorf("%w: truncated binary", errThrift)
+		}
+		b := r.data[r.pos : r.pos+int(n)]
+		r.pos += int(n)
+		return b, nil
+	case ctList, ctSet:
+		return r.readList(depth)
+	case ctMap:
+		return nil, r.skipMap(depth)
+	case ctStruct:
+		return r.readStruct(depth + 1)
+	default:
+		return nil, fmt.Errorf("%w: unknown type %d", errThrift, typ)
+	}
+}
+
+// readList reads a list or set.
+func (r *thriftReader) readList(depth int) ([]interface{}, error) {
+	if depth > maxThriftDepth {
+		return nil, fmt.Errorf("%w: nesting too deep", errThrift)
+	}
+	header, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	size := uint64(header >> 4)
+	if size == 15 {
+		if size, err = r.uvarint(); err != nil {
+			return nil, err
+		}
+	}
+	// Every element takes at least one byte
+	if size > uint64(len(r.data)-r.pos) {
+		return nil, fmt.Errorf("%w: list size %d exceeds data", errThrift, size)
+	}
+	items := make([]interface{}, 0, size)
+	for i := uint64(0); i < size; i++ {
+		v, err := r.readValue(header&0x0F, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
+
+// skipMap reads and discards a map.
+func (r *thriftReader) skipMap(depth int) error {
+	size, err := r.uvarint()
+	if err != nil || size == 0 {
+		return err
+	}
+	types, err := r.byte()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < size; i++ {
+		if _, err := r.readValue(types>>4, depth+1); err != nil {
+			return err
+		}
+		if _, err := r.readValue(types&0x0F, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// byte reads one byte.
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, fmt.Errorf("%w: unexpected end of data", errThrift)
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+// uvarint reads an unsigned varint.
+func (r *thriftReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("%w: bad varint", errThrift)
+	}
+	r.pos += n
+	return v, nil
+}
+
+// varint reads a zigzag-encoded varint.
+func (r *thriftReader) varint() (int64, error) {
+	u, err := r.uvarint()
+	//nolint:gosec // G115: zigzag decoding reinterprets the bits
+	return int64(u>>1) ^ -int64(u&1), err
+}
@@ -0,0 +1,74 @@
+package parquetio
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestThrift_RoundTrip(t *testing.T) {
+	items := make([]interface{}, 20)
+	want := make([]interface{}, 20)
+	for i := range items {
+		items[i] = int32(i - 10)
+		want[i] = int64(i - 10)
+	}
+
+	data := encodeStruct(tStruct{
+		{1, int32(-5)},
+		{2, true},
+		{3, false},
+		{4, "name"},
+		{40, int64(1) << 40}, // long field id delta
+		{41, tListValue{ctI32, items}},
+		{42, tStruct{{1, "nested"}}},
+	})
+
+	got, n, err := decodeStruct(append(data, 0xAA))
+	if err != nil {
+		t.Fatalf("decodeStruct() error = %v", err)
+	}
+	if n != len(data) {
+		t.Errorf("consumed %d bytes, want %d", n, len(data))
+	}
+
+	if got.int(1, 0) != -5 || got[2] != true || got[3] != false || got.str(4) != "name" {
+		t.Errorf("scalar fields = %v", got)
+	}
+	if got.int(40, 0) != 1<<40 {
+		t.Errorf("field 40 = %v", got[40])
+	}
+	if !reflect.DeepEqual(got.list(41), want) {
+		t.Errorf("list = %v, want %v", got.list(41), want)
+	}
+	if got.sub(42).str(1) != "nested" {
+		t.Errorf("nested = %v", got.sub(42))
+	}
+	if got.int(99, 7) != 7 || got.sub(99) != nil {
+		t.Error("absent fields should yield defaults")
+	}
+}
+
+func TestThrift_Malformed(t *testing.T) {
+	tests := [][]byte{
+		{},                 // no stop byte
+		{0x15},             // i32 field without value
+		{0x18, 0x0A, 'a'},  // binary longer than data
+		{0x19, 0xF5, 0x7F}, // list longer than data
+		{0x1D},             // unknown type
+	}
+	for _, data := range tests {
+		if _, _, err := decodeStruct(data); !errors.Is(err, errThrift) {
+			t.Errorf("decodeStruct(%x) error = %v, want errThrift", data, err)
+		}
+	}
+
+	// Deeply nested structs
+	deep := make([]byte, 0, 2*maxThriftDepth+4)
+	for i := 0; i < maxThriftDepth+2; i++ {
+		deep = append(deep, 0x1C)
+	}
+	if _, _, err := decodeStruct(deep); !errors.Is(err, errThrift) {
+		t.Errorf("deep nesting error = %v, want errThrift", err)
+	}
+}
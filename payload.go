@@ -0,0 +1,103 @@
+package matlab
+
+import (
+	"container/list"
+	"sync"
+)
+
+// WithDecompressionCache keeps up to maxBytes of decompressed data per
+// file for readers that load variables on demand, so a compressed
+// variable read repeatedly is inflated once. It applies to Cache, whose
+// compressed .mat.gz and .mat.zst files are otherwise decompressed again
+// for each variable, and to FileSet reads (see
+// FileSet.SetDecompressionCache). The least recently used payloads are
+// evicted once the budget is exceeded; payloads larger than the budget
+// are not kept.
+//
+// Default: 0 (no cache)
+//
+// Example:
+//
+//	cache := matlab.NewCache(32, matlab.WithDecompressionCache(64<<20))
+func WithDecompressionCache(maxBytes int64) OpenOption {
+	return func(c *openConfig) {
+		c.payloadBudget = maxBytes
+	}
+}
+
+// payloadCache keeps the decompressed payloads of one file, keyed by the
+// offset of their element: the decompressed miMATRIX element of a
+// miCOMPRESSED variable, or at offset 0 the whole content of a file
+// compressed with gzip or zstd. A nil *payloadCache keeps nothing.
+type payloadCache struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	used    int64
+	entries map[int64]*list.Element // Offset to element of lru holding *payload
+	lru     *list.List              // Most recently used first
+}
+
+// payload is a cached decompressed payload.
+type payload struct {
+	offset int64
+	data   []byte
+}
+
+// newPayloadCache returns a cache of maxBytes, or nil if maxBytes is not
+// positive.
+func newPayloadCache(maxBytes int64) *payloadCache {
+	if maxBytes <= 0 {
+		return nil
+	}
+	return &payloadCache{
+		maxBytes: maxBytes,
+		entries:  make(map[int64]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// load returns the payload at offset, calling inflate if it is not
+// cached. The returned bytes are shared and must not be modified.
+func (c *payloadCache) load(offset int64, inflate func() ([]byte, error)) ([]byte, error) {
+	if c == nil {
+		return inflate()
+	}
+	c.mu.Lock()
+	if elem, ok := c.entries[offset]; ok {
+		c.lru.MoveToFront(elem)
+		c.mu.Unlock()
+		return elem.Value.(*payload).data, nil
+	}
+	c.mu.Unlock()
+
+	// Concurrent misses may both inflate; the payload is cached once
+	data, err := inflate()
+	if err != nil || int64(len(data)) > c.maxBytes {
+		return data, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[offset]; !ok {
+		c.entries[offset] = c.lru.PushFront(&payload{offset: offset, data: data})
+		c.used += int64(len(data))
+	}
+	for c.used > c.maxBytes {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		evicted := oldest.Value.(*payload)
+		delete(c.entries, evicted.offset)
+		c.used -= int64(len(evicted.data))
+	}
+	return data, nil
+}
+
+// size returns the bytes cached.
+func (c *payloadCache) size() int64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.used
+}
@@ -0,0 +1,145 @@
+package matlab
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestPayloadCache(t *testing.T) {
+	if newPayloadCache(0) != nil {
+		t.Error("newPayloadCache(0) != nil")
+	}
+
+	c := newPayloadCache(10)
+	inflated := 0
+	load := func(offset int64, size int) []byte {
+		t.Helper()
+		data, err := c.load(offset, func() ([]byte, error) {
+			inflated++
+			return make([]byte, size), nil
+		})
+		if err != nil || len(data) != size {
+			t.Fatalf("load(%d) = %d bytes, %v, want %d bytes", offset, len(data), err, size)
+		}
+		return data
+	}
+
+	load(100, 4)
+	load(200, 4)
+	load(100, 4) // Hit, now most recently used
+	if inflated != 2 {
+		t.Errorf("inflated %d payloads, want 2", inflated)
+	}
+	load(300, 4) // Evicts 200
+	if got := c.size(); got != 8 {
+		t.Errorf("size() = %d, want 8", got)
+	}
+	load(100, 4)
+	load(200, 4)
+	if inflated != 4 {
+		t.Errorf("inflated %d payloads, want 4", inflated)
+	}
+
+	load(400, 11) // Larger than the budget: returned, not kept
+	load(400, 11)
+	if inflated != 6 {
+		t.Errorf("inflated %d payloads, want 6", inflated)
+	}
+	if got := c.size(); got != 8 {
+		t.Errorf("size() = %d, want 8", got)
+	}
+
+	failure := errors.New("corrupt")
+	if _, err := c.load(500, func() ([]byte, error) { return nil, failure }); !errors.Is(err, failure) {
+		t.Errorf("load() error = %v, want %v", err, failure)
+	}
+
+	var none *payloadCache
+	if _, err := none.load(0, func() ([]byte, error) { return []byte{1}, nil }); err != nil {
+		t.Errorf("nil load() error = %v", err)
+	}
+}
+
+func TestWithDecompressionCache(t *testing.T) {
+	dir := t.TempDir()
+	x := &types.Variable{Name: "x", Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{1, 2, 3}}
+	y := &types.Variable{Name: "y", Dimensions: []int{1, 2}, DataType: types.Int32, Data: []int32{4, 5}}
+
+	for _, opt := range []Option{WithCompression(6), WithGzip()} {
+		path := filepath.Join(dir, "run.mat")
+		w, err := Create(path, Version5, opt)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if err := w.WriteVariables(x, y); err != nil {
+			t.Fatalf("WriteVariables() error = %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		cache := NewCache(1, WithDecompressionCache(1<<20))
+		for _, want := range []*types.Variable{x, y} {
+			v, err := cache.Variable(path, want.Name)
+			if err != nil {
+				t.Fatalf("Variable(%s) error = %v", want.Name, err)
+			}
+			if !reflect.DeepEqual(v.Data, want.Data) {
+				t.Errorf("Variable(%s) = %v, want %v", want.Name, v.Data, want.Data)
+			}
+		}
+		entry, err := cache.entry(path)
+		if err != nil {
+			t.Fatalf("entry() error = %v", err)
+		}
+		if entry.payloads.size() == 0 {
+			t.Error("no decompressed data cached")
+		}
+		cache.Invalidate(path)
+	}
+}
+
+func TestFileSet_SetDecompressionCache(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i, name := range []string{"a.mat", "b.mat"} {
+		x := &types.Variable{Name: "x", Dimensions: []int{1, 2}, DataType: types.Double,
+			Data: []float64{float64(2 * i), float64(2*i + 1)}}
+		path := filepath.Join(dir, name)
+		w, err := Create(path, Version5, WithCompression(6))
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if err := w.WriteVariable(x); err != nil {
+			t.Fatalf("WriteVariable() error = %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	set, err := OpenSet(1, paths...)
+	if err != nil {
+		t.Fatalf("OpenSet() error = %v", err)
+	}
+	set.SetDecompressionCache(1 << 20)
+	for range 2 {
+		window, err := set.ReadRange("x", 1, 2)
+		if err != nil {
+			t.Fatalf("ReadRange() error = %v", err)
+		}
+		if !reflect.DeepEqual(window.Data, []float64{1, 2}) {
+			t.Errorf("ReadRange() = %v, want [1 2]", window.Data)
+		}
+	}
+	for i, payloads := range set.payloads {
+		if payloads.size() == 0 {
+			t.Errorf("file %d: no decompressed data cached", i)
+		}
+	}
+}
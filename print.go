@@ -0,0 +1,368 @@
+package matlab
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// PrintOption configures Print.
+type PrintOption func(*printConfig)
+
+// printConfig holds optional configuration for Print.
+type printConfig struct {
+	precision int // Significant digits of floating-point values
+	width     int // Maximum line width before matrices are split
+}
+
+// defaultPrintConfig returns the default print configuration.
+func defaultPrintConfig() *printConfig {
+	return &printConfig{precision: 5, width: 80}
+}
+
+// WithPrecision sets the number of significant digits printed for
+// floating-point values. Values are printed in the shortest of fixed and
+// scientific notation, like MATLAB's "format short g", so 1234567 prints as
+// 1.2346e+06 rather than losing its magnitude. A negative value prints
+// each value with the fewest digits that read back exactly, as
+// strconv.FormatFloat does, for output that must round-trip.
+//
+// Numbers are always formatted with strconv, never with locale-dependent
+// separators.
+//
+// Default: 5
+//
+// Example:
+//
+//	text := matlab.Sprint(v, matlab.WithPrecision(15))
+//	exact := matlab.Sprint(v, matlab.WithPrecision(-1))
+func WithPrecision(digits int) PrintOption {
+	return func(c *printConfig) {
+		switch {
+		case digits > 0:
+			c.precision = digits
+		case digits < 0:
+			c.precision = -1
+		}
+	}
+}
+
+// WithLineWidth sets the maximum width of a printed matrix row. Wider
+// matrices are split into blocks of columns headed "Columns 1 through 6",
+// as MATLAB does.
+//
+// Default: 80
+//
+// Example:
+//
+//	err := matlab.Print(os.Stdout, v, matlab.WithLineWidth(120))
+func WithLineWidth(width int) PrintOption {
+	return func(c *printConfig) {
+		if width > 0 {
+			c.width = width
+		}
+	}
+}
+
+// Print writes v to w the way MATLAB displays a variable: the name
+// followed by its values, with the columns of each matrix aligned. The
+// output is deterministic, so printed result files can be diffed and
+// reviewed as text.
+//
+// Arrays with more than two dimensions are printed page by page, as
+// x(:,:,1), x(:,:,2) and so on. Scalar structs list their fields with a
+// short summary of each value; other arrays that cannot be shown as a
+// matrix are summarized by their size and class.
+//
+// Example:
+//
+//	for _, v := range matFile.Variables {
+//	    if err := matlab.Print(os.Stdout, v, matlab.WithPrecision(8)); err != nil {
+//	        return err
+//	    }
+//	}
+//
+// Output:
+//
+//	x =
+//
+//	     1.5      -2   1e-09
+//	       3    4.25     NaN
+func Print(w io.Writer, v *types.Variable, opts ...PrintOption) error {
+	_, err := io.WriteString(w, Sprint(v, opts...))
+	return err
+}
+
+// Sprint returns the text Print writes for v.
+func Sprint(v *types.Variable, opts ...PrintOption) string {
+	cfg := defaultPrintConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	var b strings.Builder
+	cfg.printVariable(&b, v)
+	return b.String()
+}
+
+// printVariable prints one variable.
+func (c *printConfig) printVariable(b *strings.Builder, v *types.Variable) {
+	switch data := v.Data.(type) {
+	case *types.StructArray:
+		fmt.Fprintf(b, "%s =\n\n", v.Name)
+		c.printStruct(b, data)
+		return
+	case string:
+		fmt.Fprintf(b, "%s =\n\n", v.Name)
+		for _, row := range charRows(data, v.Dimensions) {
+			fmt.Fprintf(b, "    '%s'\n", row)
+		}
+		b.WriteString("\n")
+		return
+	}
+
+	cells, ok := c.formatElements(v)
+	if !ok {
+		fmt.Fprintf(b, "%s =\n\n  [%s]\n\n", v.Name, summary(v))
+		return
+	}
+	if len(cells) == 0 {
+		fmt.Fprintf(b, "%s =\n\n  %s empty %s\n\n", v.Name, dimsString(v.Dimensions), v.DataType)
+		return
+	}
+
+	rows, cols := 1, len(cells)
+	if len(v.Dimensions) >= 2 {
+		rows, cols = v.Dimensions[0], v.Dimensions[1]
+	}
+	width := 0
+	for _, cell := range cells {
+		if len(cell) > width {
+			width = len(cell)
+		}
+	}
+
+	pageSize := rows * cols
+	for page := 0; page*pageSize < len(cells); page++ {
+		fmt.Fprintf(b, "%s =\n\n", pageName(v.Name, v.Dimensions, page))
+		c.printMatrix(b, cells[page*pageSize:(page+1)*pageSize], rows, cols, width)
+	}
+}
+
+// printMatrix prints a column-major page of formatted cells, right-aligned
+// to width and split into column blocks that fit the line width.
+func (c *printConfig) printMatrix(b *strings.Builder, cells []string, rows, cols, width int) {
+	perBlock := c.width / (width + 3)
+	if perBlock < 1 {
+		perBlock = 1
+	}
+	for first := 0; first < cols; first += perBlock {
+		last := first + perBlock
+		if last > cols {
+			last = cols
+		}
+		if perBlock < cols {
+			if last-first == 1 {
+				fmt.Fprintf(b, "  Column %d\n\n", first+1)
+			} else {
+				fmt.Fprintf(b, "  Columns %d through %d\n\n", first+1, last)
+			}
+		}
+		for r := 0; r < rows; r++ {
+			for col := first; col < last; col++ {
+				fmt.Fprintf(b, "   %*s", width, cells[col*rows+r])
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+}
+
+// printStruct prints the fields of a scalar struct, or the field names of
+// a struct array.
+func (c *printConfig) printStruct(b *strings.Builder, st *types.StructArray) {
+	if len(st.Elements) != 1 {
+		fmt.Fprintf(b, "  %s struct array with fields:\n\n", dimsString(st.Dimensions))
+		for _, field := range st.Fields {
+			fmt.Fprintf(b, "    %s\n", field)
+		}
+		b.WriteString("\n")
+		return
+	}
+
+	b.WriteString("  struct with fields:\n\n")
+	nameWidth := 0
+	for _, field := range st.Fields {
+		if len(field) > nameWidth {
+			nameWidth = len(field)
+		}
+	}
+	for _, field := range st.Fields {
+		value := "[]"
+		if fv := st.Field(0, field); fv != nil {
+			value = c.fieldValue(fv)
+		}
+		fmt.Fprintf(b, "    %*s: %s\n", nameWidth, field, value)
+	}
+	b.WriteString("\n")
+}
+
+// fieldValue returns the one-line form of a struct field: the value of
+// scalars, short row vectors and single-row text, or its size and class.
+func (c *printConfig) fieldValue(v *types.Variable) string {
+	if text, ok := v.Data.(string); ok {
+		if rows := charRows(text, v.Dimensions); len(rows) == 1 {
+			return "'" + rows[0] + "'"
+		}
+		return "[" + summary(v) + "]"
+	}
+	cells, ok := c.formatElements(v)
+	switch {
+	case !ok:
+		return "[" + summary(v) + "]"
+	case len(cells) == 0:
+		return "[]"
+	case len(cells) == 1:
+		return cells[0]
+	case len(v.Dimensions) == 2 && v.Dimensions[0] == 1 && len(cells) <= 10:
+		return "[" + strings.Join(cells, " ") + "]"
+	}
+	return "[" + summary(v) + "]"
+}
+
+// formatElements formats every element of a numeric or logical variable
+// in column-major order. It reports false for other data.
+func (c *printConfig) formatElements(v *types.Variable) ([]string, bool) {
+	realPart, imagPart := v.Data, interface{}(nil)
+	if complexData, ok := v.Data.(*types.NumericArray); ok {
+		realPart, imagPart = complexData.Real, complexData.Imag
+	}
+
+	re := reflect.ValueOf(realPart)
+	if re.Kind() != reflect.Slice || re.Len() != numElements(v.Dimensions) {
+		return nil, false
+	}
+	im := reflect.ValueOf(imagPart)
+	if imagPart != nil && (im.Kind() != reflect.Slice || im.Len() != re.Len()) {
+		return nil, false
+	}
+
+	cells := make([]string, re.Len())
+	for i := range cells {
+		text, ok := c.formatValue(re.Index(i))
+		if !ok {
+			return nil, false
+		}
+		if imagPart != nil {
+			imagText, ok := c.formatValue(im.Index(i))
+			if !ok {
+				return nil, false
+			}
+			if strings.HasPrefix(imagText, "-") {
+				text += imagText + "i"
+			} else {
+				text += "+" + imagText + "i"
+			}
+		}
+		cells[i] = text
+	}
+	return cells, true
+}
+
+// formatValue formats one numeric or logical element.
+func (c *printConfig) formatValue(value reflect.Value) (string, bool) {
+	switch value.Kind() {
+	case reflect.Float64, reflect.Float32:
+		f := value.Float()
+		switch {
+		case math.IsNaN(f):
+			return "NaN", true
+		case math.IsInf(f, 1):
+			return "Inf", true
+		case math.IsInf(f, -1):
+			return "-Inf", true
+		}
+		return strconv.FormatFloat(f, 'g', c.precision, value.Type().Bits()), true
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		return strconv.FormatInt(value.Int(), 10), true
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		return strconv.FormatUint(value.Uint(), 10), true
+	case reflect.Bool:
+		if value.Bool() {
+			return "1", true
+		}
+		return "0", true
+	default:
+		return "", false
+	}
+}
+
+// charRows splits column-major character data into the rows of its
+// matrix. Text that does not fill its dimensions is returned as one row.
+func charRows(text string, dims []int) []string {
+	runes := []rune(text)
+	if len(dims) != 2 || dims[0] <= 1 || dims[0]*dims[1] != len(runes) {
+		return []string{text}
+	}
+	rows := make([]string, dims[0])
+	for r := range rows {
+		row := make([]rune, dims[1])
+		for col := range row {
+			row[col] = runes[col*dims[0]+r]
+		}
+		rows[r] = string(row)
+	}
+	return rows
+}
+
+// pageName returns the display name of a page of an N-D array, such as
+// x(:,:,2,1), or the plain name for 2-D arrays.
+func pageName(name string, dims []int, page int) string {
+	if len(dims) <= 2 {
+		return name
+	}
+	subscripts := []string{":", ":"}
+	for _, d := range dims[2:] {
+		subscripts = append(subscripts, strconv.Itoa(page%d+1))
+		page /= d
+	}
+	return name + "(" + strings.Join(subscripts, ",") + ")"
+}
+
+// summary returns the size and class of a variable, like "2x3 double".
+func summary(v *types.Variable) string {
+	text := dimsString(v.Dimensions)
+	if v.IsComplex {
+		text += " complex"
+	}
+	if v.IsSparse {
+		text += " sparse"
+	}
+	return text + " " + v.DataType.String()
+}
+
+// dimsString formats dimensions as "2x3".
+func dimsString(dims []int) string {
+	parts := make([]string, len(dims))
+	for i, d := range dims {
+		parts[i] = strconv.Itoa(d)
+	}
+	return strings.Join(parts, "x")
+}
+
+// numElements returns the number of elements of an array with the given
+// dimensions.
+func numElements(dims []int) int {
+	if len(dims) == 0 {
+		return 0
+	}
+	n := 1
+	for _, d := range dims {
+		n *= d
+	}
+	return n
+}
@@ -0,0 +1,101 @@
+package matlab
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestSprint(t *testing.T) {
+	tests := []struct {
+		name string
+		v    *types.Variable
+		opts []PrintOption
+		want string
+	}{
+		{
+			name: "matrix",
+			v: &types.Variable{Name: "x", Dimensions: []int{2, 3}, DataType: types.Double,
+				Data: []float64{1.5, 3, -2, 4.25, 1e-9, math.NaN()}},
+			want: "x =\n\n     1.5      -2   1e-09\n       3    4.25     NaN\n\n",
+		},
+		{
+			name: "precision",
+			v:    &types.Variable{Name: "p", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1234567, math.Inf(-1)}},
+			opts: []PrintOption{WithPrecision(3)},
+			want: "p =\n\n   1.23e+06       -Inf\n\n",
+		},
+		{
+			name: "exact",
+			v:    &types.Variable{Name: "q", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{0.30000000000000004, 1e-310}},
+			opts: []PrintOption{WithPrecision(-1)},
+			want: "q =\n\n   0.30000000000000004                1e-310\n\n",
+		},
+		{
+			name: "integers and logical",
+			v:    &types.Variable{Name: "m", Dimensions: []int{1, 3}, DataType: types.Logical, Data: []bool{true, false, true}},
+			want: "m =\n\n   1   0   1\n\n",
+		},
+		{
+			name: "complex",
+			v: &types.Variable{Name: "z", Dimensions: []int{1, 2}, DataType: types.Double, IsComplex: true,
+				Data: &types.NumericArray{Real: []float64{1, 0.5}, Imag: []float64{-2, 3}}},
+			want: "z =\n\n     1-2i   0.5+3i\n\n",
+		},
+		{
+			name: "pages",
+			v:    &types.Variable{Name: "c", Dimensions: []int{1, 1, 2}, DataType: types.Int8, Data: []int8{-1, 2}},
+			want: "c(:,:,1) =\n\n   -1\n\nc(:,:,2) =\n\n    2\n\n",
+		},
+		{
+			name: "char matrix",
+			v:    &types.Variable{Name: "s", Dimensions: []int{2, 2}, DataType: types.Char, Data: "acbd"},
+			want: "s =\n\n    'ab'\n    'cd'\n\n",
+		},
+		{
+			name: "empty",
+			v:    &types.Variable{Name: "e", Dimensions: []int{0, 3}, DataType: types.Double, Data: []float64{}},
+			want: "e =\n\n  0x3 empty double\n\n",
+		},
+		{
+			name: "struct",
+			v: &types.Variable{Name: "cfg", Dimensions: []int{1, 1}, DataType: types.Struct,
+				Data: &types.StructArray{
+					Fields:     []string{"rate", "label", "w", "big"},
+					Dimensions: []int{1, 1},
+					Elements: [][]*types.Variable{{
+						{Name: "rate", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{44100}},
+						{Name: "label", Dimensions: []int{1, 3}, DataType: types.Char, Data: "run"},
+						{Name: "w", Dimensions: []int{1, 2}, DataType: types.Uint8, Data: []uint8{7, 9}},
+						{Name: "big", Dimensions: []int{20, 20}, DataType: types.Single, Data: make([]float32, 400)},
+					}},
+				}},
+			want: "cfg =\n\n  struct with fields:\n\n     rate: 44100\n    label: 'run'\n        w: [7 9]\n      big: [20x20 single]\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sprint(tt.v, tt.opts...); got != tt.want {
+				t.Errorf("Sprint() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrint_ColumnBlocks(t *testing.T) {
+	v := &types.Variable{Name: "w", Dimensions: []int{1, 5}, DataType: types.Int32, Data: []int32{1, 2, 3, 4, 5}}
+	var buf bytes.Buffer
+	if err := Print(&buf, v, WithLineWidth(8)); err != nil {
+		t.Fatalf("Print() error = %v", err)
+	}
+	want := "w =\n\n  Columns 1 through 2\n\n   1   2\n\n  Columns 3 through 4\n\n   3   4\n\n  Column 5\n\n   5\n\n"
+	if buf.String() != want {
+		t.Errorf("Print() =\n%q\nwant\n%q", buf.String(), want)
+	}
+	if got := Sprint(v); strings.Contains(got, "Column") {
+		t.Errorf("default width split the matrix: %q", got)
+	}
+}
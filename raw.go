@@ -0,0 +1,48 @@
+package matlab
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/scigolib/matlab/internal/v73"
+	"github.com/scigolib/matlab/types"
+)
+
+// ReadRawBytes reads the undecoded content of one dataset of the v7.3
+// file at path, the lazy counterpart of opening the file WithRawBytes:
+// no other data is read or decoded. name is the variable name, or a
+// slash-separated path such as "run42/x" for a struct field. The dataset
+// must have contiguous storage.
+//
+// Returns ErrVariableNotFound if there is no such dataset and
+// ErrUnsupportedVersion for files other than v7.3.
+//
+// Example:
+//
+//	raw, err := matlab.ReadRawBytes("objects.mat", "obj")
+//	fmt.Println(raw.Datatype, raw.ElementSize, len(raw.Bytes))
+func ReadRawBytes(path, name string) (*types.RawData, error) {
+	//nolint:gosec // G304: path is provided by the caller
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	src, err := newSource(f)
+	_ = f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if !isHDF5Format(src.header) {
+		return nil, fmt.Errorf("raw bytes of %s: %w", path, ErrUnsupportedVersion)
+	}
+	if src.path == "" {
+		return nil, fmt.Errorf("raw bytes of %s: compressed files are not supported", path)
+	}
+
+	raw, err := v73.ReadRaw(path, "/"+name)
+	if errors.Is(err, v73.ErrDatasetNotFound) {
+		return nil, fmt.Errorf("%w: %q", ErrVariableNotFound, name)
+	}
+	return raw, err
+}
@@ -0,0 +1,80 @@
+package matlab
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestRawBytes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "raw.mat")
+	w, err := Create(path, Version73)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteVariable(&types.Variable{
+		Name: "x", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1.5, -2},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := make([]byte, 16)
+	binary.LittleEndian.PutUint64(want, math.Float64bits(1.5))
+	binary.LittleEndian.PutUint64(want[8:], math.Float64bits(-2))
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	matFile, err := Open(f, WithRawBytes())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	raw, err := matFile.GetVariable("x").RawBytes()
+	if err != nil {
+		t.Fatalf("RawBytes() error = %v", err)
+	}
+	if !reflect.DeepEqual(raw.Bytes, want) || raw.ElementSize != 8 || raw.Class != "double" || raw.Datatype == "" {
+		t.Errorf("RawBytes() = %+v, want bytes %v of 8-byte double elements", raw, want)
+	}
+
+	lazy, err := ReadRawBytes(path, "x")
+	if err != nil {
+		t.Fatalf("ReadRawBytes() error = %v", err)
+	}
+	if !reflect.DeepEqual(lazy, raw) {
+		t.Errorf("ReadRawBytes() = %+v, want %+v", lazy, raw)
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	matFile, err = Open(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := matFile.GetVariable("x").RawBytes(); !errors.Is(err, types.ErrNoRawData) {
+		t.Errorf("RawBytes() without WithRawBytes: error = %v, want ErrNoRawData", err)
+	}
+}
+
+func TestReadRawBytes_Errors(t *testing.T) {
+	if _, err := ReadRawBytes(filepath.Join("testdata", "generated", "simple_double.mat"), "missing"); !errors.Is(err, ErrVariableNotFound) {
+		t.Errorf("missing variable: error = %v, want ErrVariableNotFound", err)
+	}
+	if _, err := ReadRawBytes(filepath.Join("testdata", "scipy", "testdouble_7.4_GLNX86.mat"), "testdouble"); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("v5 file: error = %v, want ErrUnsupportedVersion", err)
+	}
+	if _, err := ReadRawBytes(t.TempDir(), "x"); !errors.Is(err, ErrIsDirectory) {
+		t.Errorf("directory: error = %v, want ErrIsDirectory", err)
+	}
+}
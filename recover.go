@@ -0,0 +1,75 @@
+package matlab
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/scigolib/matlab/internal/v5"
+)
+
+// RecoveryReport describes what Recover could and could not salvage.
+type RecoveryReport struct {
+	HeaderDamaged bool          // Header unreadable; byte order guessed
+	Recovered     []string      // Names of the recovered variables, in file order
+	Gaps          []RecoveryGap // Damaged byte ranges that were skipped
+}
+
+// RecoveryGap is a byte range of a damaged file that held no intact
+// variable.
+type RecoveryGap struct {
+	Offset int64  // Start of the range
+	Length int64  // Length of the range in bytes
+	Reason string // Why parsing failed at Offset
+}
+
+// Complete reports whether the file was intact.
+func (r *RecoveryReport) Complete() bool {
+	return !r.HeaderDamaged && len(r.Gaps) == 0
+}
+
+// Recover extracts the intact variables from a damaged v5 MAT-file, such
+// as one truncated when the disk filled up. Where Open fails on the first
+// damaged element, Recover skips it and resynchronizes on the next
+// plausible variable tag, so every variable stored wholly before, between
+// or after damaged regions is returned. The report lists the skipped byte
+// ranges.
+//
+// Recover reads r to the end. v7.3 (HDF5) files are not supported and
+// return ErrUnsupportedVersion.
+//
+// Example:
+//
+//	matFile, report, err := matlab.Recover(file)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, gap := range report.Gaps {
+//	    log.Printf("lost %d bytes at %d: %s", gap.Length, gap.Offset, gap.Reason)
+//	}
+func Recover(r io.Reader) (*MatFile, *RecoveryReport, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isHDF5Format(data) {
+		return nil, nil, fmt.Errorf("%w: recovery supports v5 files only", ErrUnsupportedVersion)
+	}
+
+	rec := v5.Recover(data)
+	matFile := &MatFile{
+		Version:     "5.0",
+		Endian:      rec.Header.EndianIndicator,
+		Description: rec.Header.Description,
+		Variables:   rec.Variables,
+		storage:     rec.Storage,
+	}
+	report := &RecoveryReport{HeaderDamaged: rec.HeaderDamaged}
+	for _, info := range rec.Storage {
+		matFile.Features.Compressed = matFile.Features.Compressed || info.Compressed
+		report.Recovered = append(report.Recovered, info.Name)
+	}
+	for _, gap := range rec.Gaps {
+		report.Gaps = append(report.Gaps, RecoveryGap(gap))
+	}
+	return matFile, report, nil
+}
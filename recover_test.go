@@ -0,0 +1,68 @@
+package matlab
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// TestRecover_Truncated tests that the variables before the point where a
+// v5 file was cut off are recovered.
+func TestRecover_Truncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "full.mat")
+	writer, err := Create(path, Version5)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for _, name := range []string{"x", "y", "z"} {
+		v := &types.Variable{Name: name, Dimensions: []int{1, 100}, DataType: types.Double, Data: make([]float64, 100)}
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) error = %v", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	truncated := data[:len(data)-100]
+	if _, err := Open(bytes.NewReader(truncated)); err == nil {
+		t.Fatal("Open() of truncated file succeeded")
+	}
+
+	matFile, report, err := Recover(bytes.NewReader(truncated))
+	if err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	if !reflect.DeepEqual(matFile.GetVariableNames(), []string{"x", "y"}) ||
+		!reflect.DeepEqual(report.Recovered, []string{"x", "y"}) {
+		t.Errorf("recovered %v, report %v", matFile.GetVariableNames(), report.Recovered)
+	}
+	if report.Complete() || len(report.Gaps) != 1 || report.Gaps[0].Offset+report.Gaps[0].Length != int64(len(truncated)) {
+		t.Errorf("report = %+v", report)
+	}
+	if matFile.Version != "5.0" || len(matFile.VariableSizes()) != 2 {
+		t.Errorf("matFile = %+v", matFile)
+	}
+
+	_, report, err = Recover(bytes.NewReader(data))
+	if err != nil || !report.Complete() || len(report.Recovered) != 3 {
+		t.Errorf("intact file: report %+v, error %v", report, err)
+	}
+}
+
+// TestRecover_V73 tests that HDF5 files are rejected.
+func TestRecover_V73(t *testing.T) {
+	data := []byte{0x89, 'H', 'D', 'F', 0x0d, 0x0a, 0x1a, 0x0a, 0, 0}
+	if _, _, err := Recover(bytes.NewReader(data)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Recover() error = %v, want ErrUnsupportedVersion", err)
+	}
+}
@@ -0,0 +1,345 @@
+package matlab
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/scigolib/matlab/internal/v5"
+	"github.com/scigolib/matlab/types"
+)
+
+// RedactAction is what Redact does with a matching variable.
+type RedactAction int
+
+const (
+	// RedactZero keeps the variable with every value set to zero: numbers
+	// and logicals become 0 and characters NUL, with class and dimensions
+	// unchanged. Struct fields and cell contents are zeroed recursively;
+	// sparse matrices keep their pattern of stored elements.
+	RedactZero RedactAction = iota + 1
+
+	// RedactHash replaces the variable with the char row vector of the
+	// hex SHA-256 of its value (see WithRedactSalt), so equal identifiers
+	// still match across files without being readable. Only numeric,
+	// logical and char variables can be hashed.
+	RedactHash
+
+	// RedactDrop removes the variable.
+	RedactDrop
+)
+
+// RedactRule applies an action to the variables whose names match a
+// pattern, in the syntax of path.Match: "patient_*" matches patient_name
+// and patient_id. Patterns are matched against top-level variable names.
+type RedactRule struct {
+	Pattern string
+	Action  RedactAction
+}
+
+// RedactOption configures Redact.
+type RedactOption func(*redactConfig)
+
+// redactConfig holds optional configuration for Redact.
+type redactConfig struct {
+	salt []byte // Prefix of the hashed values
+}
+
+// WithRedactSalt hashes values for RedactHash with a secret salt, so
+// hashes of short identifiers cannot be reversed by hashing every
+// candidate. Use the same salt for files whose hashes must match.
+//
+// Default: no salt
+//
+// Example:
+//
+//	report, err := matlab.Redact("study.mat", "shared.mat", rules,
+//	    matlab.WithRedactSalt(studySecret))
+func WithRedactSalt(salt []byte) RedactOption {
+	return func(c *redactConfig) {
+		c.salt = salt
+	}
+}
+
+// RedactReport describes the result of Redact.
+type RedactReport struct {
+	Copied  []string // Variables copied unchanged, in file order
+	Zeroed  []string // Variables written with RedactZero
+	Hashed  []string // Variables written with RedactHash
+	Dropped []string // Variables removed with RedactDrop
+}
+
+// Redact copies the MAT-file src to dst, zeroing, hashing or dropping the
+// variables matched by rules, to produce a file that can be shared. A
+// variable takes the action of the first rule it matches; variables
+// matching no rule are copied unchanged.
+//
+// For v5 sources, everything that is not redacted is copied verbatim,
+// byte for byte: the header, the stored elements of the other variables
+// (compressed or not) and any other elements. Redacted variables are
+// re-encoded in place with the file's byte order, compressed if they were.
+// v7.3 files cannot be copied element by element, so they are read and
+// rewritten as Repack does.
+//
+// dst is written through a temporary file in the same directory and only
+// replaced once the copy succeeded, so src and dst may be the same file.
+//
+// Example:
+//
+//	report, err := matlab.Redact("study.mat", "shared.mat", []matlab.RedactRule{
+//	    {Pattern: "patient_id", Action: matlab.RedactHash},
+//	    {Pattern: "patient_*", Action: matlab.RedactDrop},
+//	    {Pattern: "dob", Action: matlab.RedactZero},
+//	})
+func Redact(src, dst string, rules []RedactRule, opts ...RedactOption) (*RedactReport, error) {
+	cfg := &redactConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	for _, rule := range rules {
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("redact pattern %q: %w", rule.Pattern, err)
+		}
+		if rule.Action < RedactZero || rule.Action > RedactDrop {
+			return nil, fmt.Errorf("redact pattern %q: unknown action %d", rule.Pattern, rule.Action)
+		}
+	}
+
+	matFile, _, err := openForRepack(src)
+	if err != nil {
+		return nil, err
+	}
+	report := &RedactReport{}
+	replacements := make([]*types.Variable, len(matFile.Variables))
+	for i, v := range matFile.Variables {
+		action := redactAction(rules, v.Name)
+		switch action {
+		case 0:
+			report.Copied = append(report.Copied, v.Name)
+			continue
+		case RedactDrop:
+			report.Dropped = append(report.Dropped, v.Name)
+			continue
+		}
+		replacement, err := redactVariable(v, action, cfg.salt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to redact %s: %w", v.Name, err)
+		}
+		replacements[i] = replacement
+		if action == RedactZero {
+			report.Zeroed = append(report.Zeroed, v.Name)
+		} else {
+			report.Hashed = append(report.Hashed, v.Name)
+		}
+	}
+
+	if matFile.Version == "7.3" {
+		var variables []*types.Variable
+		for i, v := range matFile.Variables {
+			switch {
+			case replacements[i] != nil:
+				variables = append(variables, replacements[i])
+			case redactAction(rules, v.Name) == 0:
+				variables = append(variables, v)
+			}
+		}
+		_, err = writeReplacing(dst, Version73, variables, nil)
+	} else {
+		err = replaceFile(dst, func(tmp *os.File) error {
+			return copyRedactedV5(src, tmp, matFile, replacements, rules)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// redactAction returns the action of the first rule matching name, or 0.
+func redactAction(rules []RedactRule, name string) RedactAction {
+	for _, rule := range rules {
+		if ok, _ := path.Match(rule.Pattern, name); ok {
+			return rule.Action
+		}
+	}
+	return 0
+}
+
+// copyRedactedV5 copies the v5 file src to dst, writing the replacements
+// in place of the variables they replace and leaving out the dropped
+// variables. replacements and the storage of matFile are in file order.
+func copyRedactedV5(src string, dst io.Writer, matFile *MatFile, replacements []*types.Variable, rules []RedactRule) error {
+	//nolint:gosec // G304: src is provided by the caller
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+
+	storage := matFile.storage
+	if len(storage) != len(matFile.Variables) {
+		return errors.New("element offsets are not available for this file")
+	}
+	var pos int64 // Bytes of src copied or skipped so far
+	for i, info := range storage {
+		action := redactAction(rules, info.Name)
+		if action == 0 {
+			continue
+		}
+		if info.Offset < pos {
+			return fmt.Errorf("%s: element offset %d is not available", info.Name, info.Offset)
+		}
+		if _, err := io.Copy(dst, io.NewSectionReader(file, pos, info.Offset-pos)); err != nil {
+			return err
+		}
+		pos = info.Offset + info.Size
+		if action == RedactDrop {
+			continue
+		}
+		element, err := encodeV5Element(replacements[i], matFile.Endian, info.Compressed)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", info.Name, err)
+		}
+		if _, err := dst.Write(element); err != nil {
+			return err
+		}
+	}
+	_, err = io.Copy(dst, io.NewSectionReader(file, pos, 1<<62))
+	return err
+}
+
+// encodeV5Element returns the stored element of v in a v5 file with the
+// endian indicator, as a miCOMPRESSED element if compressed is set.
+func encodeV5Element(v *types.Variable, endian string, compressed bool) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder, err := v5.NewWriter(&buf, "", endian)
+	if err != nil {
+		return nil, err
+	}
+	if compressed {
+		encoder.Compression = 6
+	}
+	header := buf.Len()
+	if err := encoder.WriteVariable(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes()[header:], nil
+}
+
+// redactVariable returns the replacement of v for a zero or hash action.
+func redactVariable(v *types.Variable, action RedactAction, salt []byte) (*types.Variable, error) {
+	if action == RedactHash {
+		digest, err := hashValue(v, salt)
+		if err != nil {
+			return nil, err
+		}
+		return &types.Variable{Name: v.Name, Dimensions: []int{1, len(digest)}, DataType: types.Char, Data: digest}, nil
+	}
+	zeroed := v.Clone()
+	data, err := zeroData(zeroed.Data)
+	if err != nil {
+		return nil, err
+	}
+	zeroed.Data = data
+	return zeroed, nil
+}
+
+// hashValue returns the hex SHA-256 of salt followed by the value of v:
+// the UTF-8 bytes of char data, or the little-endian bytes of numeric and
+// logical data (real parts, then imaginary parts).
+func hashValue(v *types.Variable, salt []byte) (string, error) {
+	h := sha256.New()
+	h.Write(salt)
+	var parts []interface{}
+	switch data := v.Data.(type) {
+	case string:
+		h.Write([]byte(data))
+	case *types.CharArray:
+		h.Write([]byte(string(data.Data)))
+	case *types.NumericArray:
+		parts = []interface{}{data.Real, data.Imag}
+	default:
+		parts = []interface{}{data}
+	}
+	for _, part := range parts {
+		if err := binary.Write(h, binary.LittleEndian, part); err != nil {
+			return "", fmt.Errorf("cannot hash %s data (%T)", v.DataType, part)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// zeroData returns a zeroed copy of a variable's data.
+func zeroData(data interface{}) (interface{}, error) {
+	switch d := data.(type) {
+	case string:
+		return strings.Repeat("\x00", utf8.RuneCountInString(d)), nil
+	case *types.CharArray:
+		return &types.CharArray{Data: make([]rune, len(d.Data)), Dimensions: d.Dimensions}, nil
+	case *types.NumericArray:
+		re, err := zeroData(d.Real)
+		if err != nil {
+			return nil, err
+		}
+		im, err := zeroData(d.Imag)
+		if err != nil {
+			return nil, err
+		}
+		return &types.NumericArray{Real: re, Imag: im, Type: d.Type}, nil
+	case *types.SparseMatrix:
+		zeroed := d.Clone()
+		re, err := zeroData(d.Real)
+		if err != nil {
+			return nil, err
+		}
+		zeroed.Real = re
+		if d.Imag != nil {
+			zeroed.Imag = make([]float64, len(d.Imag))
+		}
+		return zeroed, nil
+	case *types.StructArray:
+		zeroed := d.Clone()
+		for _, element := range zeroed.Elements {
+			for _, field := range element {
+				if err := zeroVariable(field); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return zeroed, nil
+	case []*types.Variable:
+		for _, cell := range d {
+			if err := zeroVariable(cell); err != nil {
+				return nil, err
+			}
+		}
+		return d, nil
+	}
+	value := reflect.ValueOf(data)
+	if value.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot zero %T data", data)
+	}
+	return reflect.MakeSlice(value.Type(), value.Len(), value.Len()).Interface(), nil
+}
+
+// zeroVariable zeroes the data of v in place.
+func zeroVariable(v *types.Variable) error {
+	if v == nil {
+		return nil
+	}
+	data, err := zeroData(v.Data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", v.Name, err)
+	}
+	v.Data = data
+	return nil
+}
@@ -0,0 +1,140 @@
+package matlab
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// writeRedactSource writes a study file with identifiers and measurements.
+func writeRedactSource(t *testing.T, version Version, opts ...Option) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "study.mat")
+	writer, err := Create(path, version, opts...)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for _, v := range []*types.Variable{
+		{Name: "patient_id", Dimensions: []int{1, 6}, DataType: types.Char, Data: "P-0042"},
+		{Name: "heart_rate", Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{61, 64, 70}},
+		{Name: "patient_name", Dimensions: []int{1, 3}, DataType: types.Char, Data: "Ann"},
+		{Name: "dob", Dimensions: []int{1, 3}, DataType: types.Int16, Data: []int16{1980, 5, 17}},
+	} {
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) error = %v", v.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return path
+}
+
+var studyRules = []RedactRule{
+	{Pattern: "patient_id", Action: RedactHash},
+	{Pattern: "patient_*", Action: RedactDrop},
+	{Pattern: "dob", Action: RedactZero},
+}
+
+func TestRedact(t *testing.T) {
+	salt := []byte("study-7")
+	sum := sha256.Sum256(append(append([]byte(nil), salt...), "P-0042"...))
+	wantHash := hex.EncodeToString(sum[:])
+
+	for _, tt := range []struct {
+		name    string
+		version Version
+		opts    []Option
+	}{
+		{"v5", Version5, nil},
+		{"v5 compressed big-endian", Version5, []Option{WithCompression(6), WithEndianness(binary.BigEndian)}},
+		{"v7.3", Version73, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			src := writeRedactSource(t, tt.version, tt.opts...)
+			dst := filepath.Join(t.TempDir(), "shared.mat")
+			report, err := Redact(src, dst, studyRules, WithRedactSalt(salt))
+			if err != nil {
+				t.Fatalf("Redact() error = %v", err)
+			}
+			want := &RedactReport{Copied: []string{"heart_rate"}, Zeroed: []string{"dob"},
+				Hashed: []string{"patient_id"}, Dropped: []string{"patient_name"}}
+			if !reflect.DeepEqual(report, want) {
+				t.Errorf("Redact() report = %+v, want %+v", report, want)
+			}
+
+			matFile := openFile(t, dst)
+			names := matFile.GetVariableNames()
+			sort.Strings(names) // v7.3 files list variables by name
+			if !reflect.DeepEqual(names, []string{"dob", "heart_rate", "patient_id"}) {
+				t.Errorf("variables = %v", names)
+			}
+			if id := matFile.GetVariable("patient_id"); id.Data != wantHash {
+				t.Errorf("patient_id = %v, want %s", id.Data, wantHash)
+			}
+			if dob := matFile.GetVariable("dob"); dob.DataType != types.Int16 || !reflect.DeepEqual(dob.Data, []int16{0, 0, 0}) {
+				t.Errorf("dob = %v %v", dob.DataType, dob.Data)
+			}
+			if hr := matFile.GetVariable("heart_rate"); !reflect.DeepEqual(hr.Data, []float64{61, 64, 70}) {
+				t.Errorf("heart_rate = %v", hr.Data)
+			}
+		})
+	}
+}
+
+func TestRedact_VerbatimCopy(t *testing.T) {
+	src := writeRepackSource(t) // Duplicate "a", trailing non-variable element
+	dst := filepath.Join(t.TempDir(), "out.mat")
+	if _, err := Redact(src, dst, []RedactRule{{Pattern: "b", Action: RedactDrop}}); err != nil {
+		t.Fatalf("Redact() error = %v", err)
+	}
+	in, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := openFile(t, src).VariableSizes()[1]
+	want := append(append([]byte(nil), in[:b.Offset]...), in[b.Offset+b.Size:]...)
+	if !bytes.Equal(out, want) {
+		t.Errorf("output is not the source without b (%d bytes, want %d)", len(out), len(want))
+	}
+
+	// No rules: an identical copy, in place
+	if _, err := Redact(dst, dst, nil); err != nil {
+		t.Fatalf("Redact(in place) error = %v", err)
+	}
+	again, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, out) {
+		t.Error("Redact() without rules changed the file")
+	}
+}
+
+func TestRedact_Errors(t *testing.T) {
+	src := writeRedactSource(t, Version5)
+	dst := filepath.Join(t.TempDir(), "out.mat")
+	for name, rules := range map[string][]RedactRule{
+		"bad pattern":    {{Pattern: "[", Action: RedactDrop}},
+		"unknown action": {{Pattern: "dob", Action: 0}},
+	} {
+		if _, err := Redact(src, dst, rules); err == nil {
+			t.Errorf("%s: Redact() error = nil", name)
+		}
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("Redact() left %s behind", dst)
+	}
+}
@@ -0,0 +1,81 @@
+//go:build matlab_experimental
+
+package matlab
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// This file holds low-level v7.3 writing APIs, built only with
+// -tags matlab_experimental. They write MATLAB's internal layout directly,
+// so a wrong class or decode value produces files MATLAB refuses to load,
+// and their signatures may change between releases.
+
+// ErrReferencesNotSupported indicates a reference write to a v5 file.
+var ErrReferencesNotSupported = errors.New("references are only supported in v7.3 files")
+
+// WriteReferences writes a v7.3 variable whose elements are HDF5 object
+// references, the layout MATLAB uses for cell arrays and for objects it
+// reconstructs from references. Each target is written into the file's
+// #refs# group, which readers skip; the variable holds one reference per
+// target, in column-major order, and carries the MATLAB_class class and,
+// unless decode is 0, MATLAB_object_decode (one of the types.ObjectDecode
+// constants).
+//
+// The references are filled in when the writer is closed, so the file is
+// only complete after Close.
+//
+// Example:
+//
+//	// A 1x2 cell array {x, label}
+//	err := writer.WriteReferences("c", "cell", 0, []int{1, 2}, x, label)
+func (w *MatFileWriter) WriteReferences(name, class string, decode int32, dims []int, targets ...*types.Variable) error {
+	if w.version != Version73 {
+		return ErrReferencesNotSupported
+	}
+	if w.v73writer == nil {
+		return errors.New("v7.3 writer is not initialized")
+	}
+	if err := types.ValidateName(name); err != nil {
+		return err
+	}
+	if err := w.checkReservedName(name); err != nil {
+		return err
+	}
+	if w.names[name] {
+		return fmt.Errorf("%w: %q", ErrDuplicateVariable, name)
+	}
+
+	// Checked before the targets are written, as HDF5 objects cannot be
+	// removed again
+	count := 1
+	for _, d := range dims {
+		if d <= 0 {
+			return fmt.Errorf("invalid dimensions %v", dims)
+		}
+		count *= d
+	}
+	if len(dims) == 0 || count != len(targets) {
+		return fmt.Errorf("%d targets, dimensions %v require %d", len(targets), dims, count)
+	}
+
+	paths := make([]string, len(targets))
+	for i, target := range targets {
+		if target == nil {
+			return fmt.Errorf("reference target %d is nil", i)
+		}
+		path, err := w.v73writer.WriteRef(w.normalize(target))
+		if err != nil {
+			return fmt.Errorf("reference target %d: %w", i, err)
+		}
+		paths[i] = path
+	}
+	if err := w.v73writer.WriteReferences(name, class, decode, dims, paths); err != nil {
+		return err
+	}
+	w.recordName(name)
+	return nil
+}
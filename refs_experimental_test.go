@@ -0,0 +1,56 @@
+//go:build matlab_experimental
+
+package matlab
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestWriteReferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cell.mat")
+	writer, err := Create(path, Version73)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	x := &types.Variable{Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}}
+	label := &types.Variable{Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"}
+	if err := writer.WriteReferences("c", "cell", 0, []int{1, 2}, x, label); err != nil {
+		t.Fatalf("WriteReferences() error = %v", err)
+	}
+	if err := writer.WriteReferences("c", "cell", 0, []int{1}, x); !errors.Is(err, ErrDuplicateVariable) {
+		t.Errorf("duplicate name: error = %v, want ErrDuplicateVariable", err)
+	}
+	if err := writer.WriteReferences("d", "cell", 0, []int{1, 3}, x, label); err == nil {
+		t.Error("expected error for dimensions not matching the targets")
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	matFile, err := Open(file)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(matFile.Variables) != 1 || matFile.Variables[0].Name != "c" {
+		t.Errorf("variables = %v, want only c", matFile.Variables)
+	}
+
+	v5, err := Create(filepath.Join(t.TempDir(), "v5.mat"), Version5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v5.Close()
+	if err := v5.WriteReferences("c", "cell", 0, []int{1}, x); !errors.Is(err, ErrReferencesNotSupported) {
+		t.Errorf("v5: error = %v, want ErrReferencesNotSupported", err)
+	}
+}
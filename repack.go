@@ -0,0 +1,235 @@
+package matlab
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// RepackOption configures Repack.
+type RepackOption func(*repackConfig)
+
+// repackConfig holds optional configuration for Repack.
+type repackConfig struct {
+	version Version  // Target format; 0 keeps the source format
+	write   []Option // Options for the rewritten file
+}
+
+// WithTargetVersion converts the file to the given format while repacking.
+//
+// Default: the format of the source file
+//
+// Example:
+//
+//	report, err := matlab.Repack("old.mat", "new.mat",
+//	    matlab.WithTargetVersion(matlab.Version73))
+func WithTargetVersion(version Version) RepackOption {
+	return func(c *repackConfig) {
+		c.version = version
+	}
+}
+
+// WithWriteOptions sets the options used to create the rewritten file,
+// such as WithCompression. They are applied after the source file's
+// description and byte order, which are kept by default.
+//
+// Example:
+//
+//	report, err := matlab.Repack("results.mat", "results.mat",
+//	    matlab.WithWriteOptions(matlab.WithCompression(9)))
+func WithWriteOptions(opts ...Option) RepackOption {
+	return func(c *repackConfig) {
+		c.write = append(c.write, opts...)
+	}
+}
+
+// RepackReport describes the result of Repack.
+type RepackReport struct {
+	Variables     []string // Variables written, in file order
+	Duplicates    []string // Names stored more than once; only the last copy was kept
+	SourceBytes   int64    // Size of the source file
+	RepackedBytes int64    // Size of the rewritten file
+}
+
+// Repack reads the MAT-file src and rewrites it to dst, analogous to
+// h5repack. The rewritten file holds every variable once, laid out
+// contiguously in file order with a fresh header, and compressed as chosen
+// with WithWriteOptions(WithCompression(level)). Elements that are not
+// variables and space left over by earlier edits are dropped. When a name
+// is stored more than once, the last copy is kept, as MATLAB's load does.
+//
+// dst is written through a temporary file in the same directory and only
+// replaced once the rewrite succeeded, so src and dst may be the same file.
+//
+// Example:
+//
+//	report, err := matlab.Repack("archive.mat", "archive.mat",
+//	    matlab.WithWriteOptions(matlab.WithCompression(6)))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("%d -> %d bytes\n", report.SourceBytes, report.RepackedBytes)
+func Repack(src, dst string, opts ...RepackOption) (*RepackReport, error) {
+	cfg := &repackConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	matFile, srcSize, err := openForRepack(src)
+	if err != nil {
+		return nil, err
+	}
+
+	version := cfg.version
+	if version == 0 {
+		version = Version5
+		if matFile.Version == "7.3" {
+			version = Version73
+		}
+	}
+	var writeOpts []Option
+	if matFile.Version == "5.0" {
+		writeOpts = append(writeOpts, WithDescription(matFile.Description))
+		if matFile.Endian == "MI" {
+			writeOpts = append(writeOpts, WithEndianness(binary.BigEndian))
+		}
+	}
+	writeOpts = append(writeOpts, cfg.write...)
+
+	variables, duplicates := lastCopies(matFile.Variables)
+	size, err := writeReplacing(dst, version, variables, writeOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RepackReport{
+		Duplicates:    duplicates,
+		SourceBytes:   srcSize,
+		RepackedBytes: size,
+	}
+	for _, v := range variables {
+		report.Variables = append(report.Variables, v.Name)
+	}
+	return report, nil
+}
+
+// openForRepack opens and parses src and returns its size.
+func openForRepack(src string) (*MatFile, int64, error) {
+	//nolint:gosec // G304: src is provided by the caller
+	file, err := os.Open(src)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	matFile, err := Open(file)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse %s: %w", src, err)
+	}
+	return matFile, info.Size(), nil
+}
+
+// lastCopies returns the variables without earlier copies of names that
+// are stored more than once, and those names.
+func lastCopies(variables []*types.Variable) ([]*types.Variable, []string) {
+	last := make(map[string]int, len(variables))
+	for i, v := range variables {
+		last[v.Name] = i
+	}
+
+	var kept []*types.Variable
+	var duplicates []string
+	reported := make(map[string]bool)
+	for i, v := range variables {
+		if last[v.Name] == i {
+			kept = append(kept, v)
+			continue
+		}
+		if !reported[v.Name] {
+			reported[v.Name] = true
+			duplicates = append(duplicates, v.Name)
+		}
+	}
+	return kept, duplicates
+}
+
+// writeReplacing writes the variables to a temporary file next to dst and
+// renames it to dst. It returns the size of the written file.
+func writeReplacing(dst string, version Version, variables []*types.Variable, opts []Option) (int64, error) {
+	var size int64
+	err := replaceFile(dst, func(tmp *os.File) error {
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+		var err error
+		size, err = writeAll(tmp.Name(), version, variables, opts)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// replaceFile calls write with a temporary file next to dst and renames
+// it to dst if write succeeds. dst keeps its permissions; the temporary
+// file is closed after write if write did not close it.
+func replaceFile(dst string, write func(tmp *os.File) error) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(dst); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".repack-*.mat")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	err = write(tmp)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil && !errors.Is(closeErr, os.ErrClosed) {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpName, mode)
+	}
+	if err == nil {
+		err = os.Rename(tmpName, dst)
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// writeAll creates filename with the variables and returns its size.
+func writeAll(filename string, version Version, variables []*types.Variable, opts []Option) (int64, error) {
+	writer, err := Create(filename, version, opts...)
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range variables {
+		if err := writer.WriteVariable(v); err != nil {
+			_ = writer.Close()
+			return 0, fmt.Errorf("failed to write %s: %w", v.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
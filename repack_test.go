@@ -0,0 +1,132 @@
+package matlab
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// writeRepackSource writes a big-endian v5 file storing "a" twice and
+// ending with an element that is not a variable.
+func writeRepackSource(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "source.mat")
+	writer, err := Create(path, Version5, WithEndianness(binary.BigEndian), WithDescription("repack source"))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for i, name := range []string{"a", "b", "a"} {
+		v := &types.Variable{Name: name, Dimensions: []int{1, 1000}, DataType: types.Double, Data: make([]float64, 1000)}
+		v.Data.([]float64)[0] = float64(i)
+		if err := writer.WriteVariable(v); err != nil {
+			t.Fatalf("WriteVariable(%s) error = %v", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// An miINT8 element with 8 bytes of data
+	junk := []byte{0, 0, 0, 1, 0, 0, 0, 8, 1, 2, 3, 4, 5, 6, 7, 8}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(junk); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func openFile(t *testing.T, path string) *MatFile {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("os.Open() error = %v", err)
+	}
+	defer file.Close()
+	matFile, err := Open(file)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return matFile
+}
+
+func TestRepack_Compress(t *testing.T) {
+	src := writeRepackSource(t)
+	dst := filepath.Join(t.TempDir(), "packed.mat")
+
+	report, err := Repack(src, dst, WithWriteOptions(WithCompression(6)))
+	if err != nil {
+		t.Fatalf("Repack() error = %v", err)
+	}
+	if !reflect.DeepEqual(report.Variables, []string{"b", "a"}) || !reflect.DeepEqual(report.Duplicates, []string{"a"}) {
+		t.Errorf("report = %+v", report)
+	}
+	if report.RepackedBytes <= 0 || report.RepackedBytes >= report.SourceBytes/4 {
+		t.Errorf("repacked %d of %d bytes", report.RepackedBytes, report.SourceBytes)
+	}
+
+	matFile := openFile(t, dst)
+	if matFile.Endian != "MI" || matFile.Description != "repack source" || !matFile.Features.Compressed {
+		t.Errorf("repacked file = %+v", matFile)
+	}
+	if names := matFile.GetVariableNames(); !reflect.DeepEqual(names, []string{"b", "a"}) {
+		t.Errorf("variables = %v", names)
+	}
+	if a := matFile.GetVariable("a").Data.([]float64); a[0] != 2 {
+		t.Errorf("a[0] = %v, want the last copy (2)", a[0])
+	}
+}
+
+func TestRepack_InPlaceConversion(t *testing.T) {
+	src := writeRepackSource(t)
+	if err := os.Chmod(src, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Repack(src, src, WithTargetVersion(Version73)); err != nil {
+		t.Fatalf("Repack() error = %v", err)
+	}
+	matFile := openFile(t, src)
+	if matFile.Version != "7.3" || len(matFile.Variables) != 2 {
+		t.Errorf("repacked file = %+v", matFile)
+	}
+	if info, err := os.Stat(src); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, error %v", info.Mode(), err)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(src))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("directory holds %d entries, want 1 (error %v)", len(entries), err)
+	}
+}
+
+func TestRepack_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Repack(filepath.Join(dir, "missing.mat"), filepath.Join(dir, "out.mat")); err == nil {
+		t.Error("expected error for missing source")
+	}
+
+	src := writeRepackSource(t)
+	if _, err := Repack(src, filepath.Join(dir, "no", "such", "dir.mat")); err == nil {
+		t.Error("expected error for missing destination directory")
+	}
+
+	// A failed write leaves neither dst nor a temporary file behind
+	reject := WithWriteHook(func(*types.Variable) error { return os.ErrInvalid })
+	dst := filepath.Join(dir, "out.mat")
+	if _, err := Repack(src, dst, WithWriteOptions(reject)); err == nil {
+		t.Error("expected error from rejecting hook")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("directory holds %d entries after failed repack", len(entries))
+	}
+}
@@ -0,0 +1,202 @@
+// Package reshape converts array data between MATLAB's column-major
+// (Fortran) element order and the row-major (C) order used by CSV files,
+// NumPy's defaults and most Go code, and rearranges dimensions like
+// MATLAB's permute and squeeze.
+//
+// The functions work on slices of any element type a MATLAB variable
+// holds and copy into a caller-provided destination, so fixing up legacy
+// data that was saved in the wrong order needs no intermediate buffers:
+//
+//	data := v.Data.([]float64)
+//	fixed := make([]float64, len(data))
+//	if err := reshape.RowMajorToColMajor(fixed, data, v.Dimensions); err != nil {
+//	    log.Fatal(err)
+//	}
+//	v.Data = fixed
+package reshape
+
+import "fmt"
+
+// Element is the set of element types MATLAB variables hold.
+type Element interface {
+	~float64 | ~float32 |
+		~int8 | ~int16 | ~int32 | ~int64 |
+		~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~bool | ~string
+}
+
+// ColMajorToRowMajor copies src, an array of the given dimensions in
+// MATLAB's column-major order, to dst in row-major order, where the last
+// subscript varies fastest. For a matrix this lists the elements row by
+// row. dst and src must have one element per array element and must not
+// overlap.
+//
+// Example:
+//
+//	// [1 2 3; 4 5 6] as MATLAB stores it
+//	rowMajor := make([]float64, 6)
+//	reshape.ColMajorToRowMajor(rowMajor, []float64{1, 4, 2, 5, 3, 6}, []int{2, 3})
+//	// rowMajor = [1 2 3 4 5 6]
+func ColMajorToRowMajor[T Element](dst, src []T, dims []int) error {
+	if err := checkLengths(len(dst), len(src), dims); err != nil {
+		return err
+	}
+	// dst is the column-major layout of the reversed dimensions
+	n := len(dims)
+	reversed := make([]int, n)
+	strides := make([]int, n)
+	srcStrides := colMajorStrides(dims)
+	for k := range dims {
+		reversed[k] = dims[n-1-k]
+		strides[k] = srcStrides[n-1-k]
+	}
+	gather(dst, src, reversed, strides)
+	return nil
+}
+
+// RowMajorToColMajor copies src, an array of the given dimensions in
+// row-major order, to dst in MATLAB's column-major order. It is the
+// inverse of ColMajorToRowMajor. dst and src must have one element per
+// array element and must not overlap.
+//
+// Example:
+//
+//	// Rows read from a CSV file, one after another
+//	colMajor := make([]float64, 6)
+//	reshape.RowMajorToColMajor(colMajor, []float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+//	// colMajor = [1 4 2 5 3 6]
+func RowMajorToColMajor[T Element](dst, src []T, dims []int) error {
+	if err := checkLengths(len(dst), len(src), dims); err != nil {
+		return err
+	}
+	gather(dst, src, dims, rowMajorStrides(dims))
+	return nil
+}
+
+// Permute copies src, a column-major array of the given dimensions, to dst
+// with its dimensions rearranged, like MATLAB's permute(A, order) with
+// zero-based order: dimension k of the result is dimension order[k] of
+// src. It returns the dimensions of the result. A 2-D Permute with order
+// [1 0] is a transpose.
+//
+// Example:
+//
+//	// x is 2x3x4; put the pages first
+//	out := make([]float64, 24)
+//	dims, err := reshape.Permute(out, x, []int{2, 3, 4}, []int{2, 0, 1}) // dims = [4 2 3]
+func Permute[T Element](dst, src []T, dims, order []int) ([]int, error) {
+	if err := checkLengths(len(dst), len(src), dims); err != nil {
+		return nil, err
+	}
+	if len(order) != len(dims) {
+		return nil, fmt.Errorf("permutation %v does not match %d dimensions", order, len(dims))
+	}
+	seen := make([]bool, len(dims))
+	for _, d := range order {
+		if d < 0 || d >= len(dims) || seen[d] {
+			return nil, fmt.Errorf("invalid permutation %v", order)
+		}
+		seen[d] = true
+	}
+
+	srcStrides := colMajorStrides(dims)
+	permuted := make([]int, len(dims))
+	strides := make([]int, len(dims))
+	for k, d := range order {
+		permuted[k] = dims[d]
+		strides[k] = srcStrides[d]
+	}
+	gather(dst, src, permuted, strides)
+	return permuted, nil
+}
+
+// Squeeze returns dims without singleton dimensions, like MATLAB's
+// squeeze. The data is unchanged: removing dimensions of length 1 does
+// not reorder elements. Results keep at least two dimensions, so a
+// 1x1x3 array becomes 3x1, and 2-D dimensions are returned unchanged.
+//
+// Example:
+//
+//	reshape.Squeeze([]int{1, 4, 1, 3}) // [4 3]
+func Squeeze(dims []int) []int {
+	if len(dims) <= 2 {
+		return append([]int(nil), dims...)
+	}
+	squeezed := make([]int, 0, len(dims))
+	for _, d := range dims {
+		if d != 1 {
+			squeezed = append(squeezed, d)
+		}
+	}
+	for len(squeezed) < 2 {
+		squeezed = append(squeezed, 1)
+	}
+	return squeezed
+}
+
+// gather fills dst in column-major order over dims, reading the element
+// with subscripts s from src[sum(s[k]*strides[k])].
+func gather[T Element](dst, src []T, dims, strides []int) {
+	if len(dst) == 0 {
+		return
+	}
+	if len(dims) == 0 {
+		dst[0] = src[0]
+		return
+	}
+	sub := make([]int, len(dims))
+	offset := 0
+	for i := range dst {
+		dst[i] = src[offset]
+		// Advance the subscripts like an odometer, first dimension fastest
+		for k := range sub {
+			sub[k]++
+			offset += strides[k]
+			if sub[k] < dims[k] {
+				break
+			}
+			offset -= sub[k] * strides[k]
+			sub[k] = 0
+		}
+	}
+}
+
+// colMajorStrides returns the distance between consecutive elements
+// along each dimension in column-major order.
+func colMajorStrides(dims []int) []int {
+	strides := make([]int, len(dims))
+	stride := 1
+	for k, d := range dims {
+		strides[k] = stride
+		stride *= d
+	}
+	return strides
+}
+
+// rowMajorStrides returns the distance between consecutive elements
+// along each dimension in row-major order.
+func rowMajorStrides(dims []int) []int {
+	strides := make([]int, len(dims))
+	stride := 1
+	for k := len(dims) - 1; k >= 0; k-- {
+		strides[k] = stride
+		stride *= dims[k]
+	}
+	return strides
+}
+
+// checkLengths checks that dst and src hold one element per array
+// element of the given dimensions.
+func checkLengths(dstLen, srcLen int, dims []int) error {
+	n := 1
+	for i, d := range dims {
+		if d < 0 {
+			return fmt.Errorf("dimension[%d] is negative: %d", i, d)
+		}
+		n *= d
+	}
+	if srcLen != n || dstLen != n {
+		return fmt.Errorf("dimensions %v need %d elements, src has %d and dst %d", dims, n, srcLen, dstLen)
+	}
+	return nil
+}
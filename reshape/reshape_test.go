@@ -0,0 +1,129 @@
+package reshape
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestColMajorToRowMajor(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []int32
+		dims []int
+		want []int32
+	}{
+		{"matrix", []int32{1, 4, 2, 5, 3, 6}, []int{2, 3}, []int32{1, 2, 3, 4, 5, 6}},
+		{"vector", []int32{1, 2, 3}, []int{3, 1}, []int32{1, 2, 3}},
+		// A(i,j,k) = 100i + 10j + k, 1-based, for a 2x2x2 array
+		{"3-D", []int32{111, 211, 121, 221, 112, 212, 122, 222}, []int{2, 2, 2},
+			[]int32{111, 112, 121, 122, 211, 212, 221, 222}},
+		{"scalar", []int32{7}, nil, []int32{7}},
+		{"empty", []int32{}, []int{0, 3}, []int32{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]int32, len(tt.src))
+			if err := ColMajorToRowMajor(got, tt.src, tt.dims); err != nil {
+				t.Fatalf("ColMajorToRowMajor() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ColMajorToRowMajor() = %v, want %v", got, tt.want)
+			}
+
+			back := make([]int32, len(got))
+			if err := RowMajorToColMajor(back, got, tt.dims); err != nil {
+				t.Fatalf("RowMajorToColMajor() error = %v", err)
+			}
+			if !reflect.DeepEqual(back, tt.src) {
+				t.Errorf("RowMajorToColMajor() = %v, want %v", back, tt.src)
+			}
+		})
+	}
+}
+
+func TestConversions_OtherTypes(t *testing.T) {
+	names := make([]string, 4)
+	if err := RowMajorToColMajor(names, []string{"a", "b", "c", "d"}, []int{2, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "c", "b", "d"}) {
+		t.Errorf("strings = %v", names)
+	}
+	mask := make([]bool, 3)
+	if err := ColMajorToRowMajor(mask, []bool{true, false, false}, []int{1, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(mask, []bool{true, false, false}) {
+		t.Errorf("bools = %v", mask)
+	}
+}
+
+func TestPermute(t *testing.T) {
+	// 2x3x4 array holding its own column-major index
+	src := make([]float64, 24)
+	for i := range src {
+		src[i] = float64(i)
+	}
+	dst := make([]float64, 24)
+	dims, err := Permute(dst, src, []int{2, 3, 4}, []int{2, 0, 1})
+	if err != nil {
+		t.Fatalf("Permute() error = %v", err)
+	}
+	if !reflect.DeepEqual(dims, []int{4, 2, 3}) {
+		t.Fatalf("Permute() dims = %v", dims)
+	}
+	// dst(k,i,j) = src(i,j,k)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 4; k++ {
+				if got, want := dst[k+4*(i+2*j)], src[i+2*(j+3*k)]; got != want {
+					t.Fatalf("dst(%d,%d,%d) = %g, want %g", k, i, j, got, want)
+				}
+			}
+		}
+	}
+
+	// A 2-D permute is a transpose
+	transposed := make([]uint8, 6)
+	if _, err := Permute(transposed, []uint8{1, 4, 2, 5, 3, 6}, []int{2, 3}, []int{1, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(transposed, []uint8{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("transpose = %v", transposed)
+	}
+}
+
+func TestSqueeze(t *testing.T) {
+	tests := []struct {
+		dims, want []int
+	}{
+		{[]int{1, 4, 1, 3}, []int{4, 3}},
+		{[]int{1, 1, 3}, []int{3, 1}},
+		{[]int{1, 1, 1}, []int{1, 1}},
+		{[]int{1, 5}, []int{1, 5}},
+		{[]int{2, 3, 4}, []int{2, 3, 4}},
+	}
+	for _, tt := range tests {
+		if got := Squeeze(tt.dims); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Squeeze(%v) = %v, want %v", tt.dims, got, tt.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	src := []float64{1, 2, 3, 4}
+	if err := ColMajorToRowMajor(make([]float64, 4), src, []int{2, 3}); err == nil {
+		t.Error("ColMajorToRowMajor(short src) error = nil")
+	}
+	if err := RowMajorToColMajor(make([]float64, 3), src, []int{2, 2}); err == nil {
+		t.Error("RowMajorToColMajor(short dst) error = nil")
+	}
+	if err := ColMajorToRowMajor(make([]float64, 0), []float64{}, []int{-1, 0}); err == nil {
+		t.Error("negative dimension error = nil")
+	}
+	for _, order := range [][]int{{0}, {0, 0}, {0, 2}, {-1, 0}} {
+		if _, err := Permute(make([]float64, 4), src, []int{2, 2}, order); err == nil {
+			t.Errorf("Permute(order %v) error = nil", order)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package matlab
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/scigolib/matlab/internal/v5"
+)
+
+// Checkpoint is the state of a v5 MatFileWriter that Resume needs to
+// continue the file after a restart: the length of the file holding the
+// variables written so far, and their names. It marshals to JSON, so it
+// can be stored next to the file.
+type Checkpoint struct {
+	Offset int64    `json:"offset"` // Bytes of the file holding complete variables
+	Names  []string `json:"names"`  // Top-level variables written, sorted
+}
+
+// Checkpoint flushes the file to stable storage and returns the state
+// needed to resume writing it after the process restarts: variables
+// written after the checkpoint are dropped by Resume, so a crash loses at
+// most the writes since the last one.
+//
+// Only v5 files created with Create can be checkpointed, without the
+// options that keep state until Close: WithGzip, WithEncryption,
+// WithSignature, WithManifest, WithAttributesVariable, WithDerived and
+// WriteSection.
+//
+// Example:
+//
+//	for block := range blocks {
+//	    writer.WriteVariable(block)
+//	    cp, err := writer.Checkpoint()
+//	    if err != nil {
+//	        return err
+//	    }
+//	    saveJSON("run.mat.checkpoint", cp)
+//	}
+func (w *MatFileWriter) Checkpoint() (Checkpoint, error) {
+	if w.version != Version5 || w.v5writer == nil || w.v5file == nil {
+		return Checkpoint{}, errors.New("checkpoint: only open v5 files created with Create can be resumed")
+	}
+	if err := checkResumable(w.gzip, w.encryptionKey != nil, w.signer != nil,
+		w.manifest != nil || w.manifestAll, w.attributesAll, len(w.derived) > 0); err != nil {
+		return Checkpoint{}, fmt.Errorf("checkpoint: %w", err)
+	}
+	if err := w.v5file.Sync(); err != nil {
+		return Checkpoint{}, fmt.Errorf("checkpoint: failed to sync %s: %w", w.filename, err)
+	}
+	names := make([]string, 0, len(w.names))
+	for name := range w.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return Checkpoint{Offset: w.v5writer.Offset(), Names: names}, nil
+}
+
+// checkResumable returns an error naming the first option set that
+// writes state at Close, which a resumed writer would not have.
+func checkResumable(gzip, encryption, signature, manifest, attributes, derived bool) error {
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		{"WithGzip", gzip}, {"WithEncryption", encryption}, {"WithSignature", signature},
+		{"a manifest", manifest}, {"WithAttributesVariable", attributes}, {"WithDerived", derived},
+	} {
+		if option.set {
+			return fmt.Errorf("files written with %s cannot be resumed", option.name)
+		}
+	}
+	return nil
+}
+
+// Resume reopens the v5 file at filename to continue writing it from
+// checkpoint cp, as returned by Checkpoint before the process stopped.
+// Bytes written after the checkpoint, such as a partly written variable,
+// are truncated, and the variables of the checkpoint count as written for
+// duplicate names and WithMaxVariables. opts apply as for Create, except
+// that the byte order and description come from the file, and the
+// options that Checkpoint refuses cannot be given.
+//
+// Returns an error if the file is shorter than the checkpoint or its
+// variables up to the checkpoint are not the ones it names.
+//
+// Example:
+//
+//	var cp matlab.Checkpoint
+//	loadJSON("run.mat.checkpoint", &cp)
+//	writer, err := matlab.Resume("run.mat", cp)
+func Resume(filename string, cp Checkpoint, opts ...Option) (*MatFileWriter, error) {
+	cfg := defaultConfig()
+	applyOptions(cfg, opts)
+	if err := checkResumable(cfg.gzip, cfg.encryptionKey != nil, cfg.signingKey != nil,
+		cfg.manifest, cfg.attributesVariable, len(cfg.derived) > 0); err != nil {
+		return nil, fmt.Errorf("resume: %w", err)
+	}
+
+	filename = filepath.Clean(filepath.FromSlash(filename))
+	//nolint:gosec // G304: filename is provided by user for MAT-file creation, expected behavior
+	f, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("resume: %w", err)
+	}
+	w, err := resumeV5(f, filename, cp, cfg)
+	if err != nil {
+		//nolint:errcheck,gosec // G104: File cleanup after error, error logged elsewhere
+		f.Close()
+		return nil, fmt.Errorf("resume %s: %w", filename, err)
+	}
+	return w, nil
+}
+
+// resumeV5 checks f against cp, truncates it to the checkpoint and
+// returns a writer appending to it.
+func resumeV5(f *os.File, filename string, cp Checkpoint, cfg *config) (*MatFileWriter, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < cp.Offset {
+		return nil, fmt.Errorf("file has %d bytes, checkpoint is at %d", info.Size(), cp.Offset)
+	}
+	header := make([]byte, 128)
+	if _, err := f.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	meta, err := OpenMetadata(io.NewSectionReader(f, 0, cp.Offset))
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint does not match the file: %w", err)
+	}
+	if meta.Version == "7.3" {
+		return nil, errors.New("v7.3 files cannot be resumed")
+	}
+	names := make(map[string]bool, len(meta.Variables))
+	for _, v := range meta.Variables {
+		names[v.Name] = true
+	}
+	for _, name := range cp.Names {
+		if !names[name] {
+			return nil, fmt.Errorf("checkpoint does not match the file: variable %q not found", name)
+		}
+	}
+	if len(names) != len(cp.Names) {
+		return nil, fmt.Errorf("checkpoint does not match the file: %d variables, checkpoint names %d", len(names), len(cp.Names))
+	}
+
+	if err := f.Truncate(cp.Offset); err != nil {
+		return nil, fmt.Errorf("failed to truncate: %w", err)
+	}
+	if _, err := f.Seek(cp.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	writer, err := v5.ResumeWriter(newChunkWriter(f, cfg), header, cp.Offset)
+	if err != nil {
+		return nil, err
+	}
+	writer.Compression = cfg.compression
+
+	w := &MatFileWriter{
+		filename: filename,
+		version:  Version5,
+		v5writer: writer,
+		v5file:   f,
+		stats:    Stats{Bytes: writer.Offset()},
+	}
+	w.hooks = cfg.writeHooks
+	w.strict = cfg.strictReservedNames
+	w.normalizeScalars = cfg.normalizeScalars
+	w.orientation = cfg.orientation
+	w.downcast, w.downcastTolerance = cfg.downcast, cfg.downcastTolerance
+	w.maxVariables, w.maxFileSize = cfg.maxVariables, cfg.maxFileSize
+	for name := range names {
+		w.recordName(name)
+	}
+	return w, nil
+}
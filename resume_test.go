@@ -0,0 +1,111 @@
+package matlab
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.mat")
+	w, err := Create(path, Version5)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := w.WriteVariables(rollingVar("a", 2), rollingVar("b", 3)); err != nil {
+		t.Fatalf("WriteVariables() error = %v", err)
+	}
+	cp, err := w.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+	if !reflect.DeepEqual(cp.Names, []string{"a", "b"}) {
+		t.Errorf("Checkpoint().Names = %v, want [a b]", cp.Names)
+	}
+
+	// Crash after another variable and part of the next one
+	if err := w.WriteVariable(rollingVar("c", 1)); err != nil {
+		t.Fatalf("WriteVariable(c) error = %v", err)
+	}
+	if _, err := w.v5file.Write([]byte{14, 0, 0, 0, 0, 1}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	w.v5file.Close()
+
+	w, err = Resume(path, cp, WithMaxVariables(3))
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if err := w.WriteVariables(rollingVar("a", 1)); !errors.Is(err, ErrDuplicateVariable) {
+		t.Errorf("WriteVariables(a) error = %v, want ErrDuplicateVariable", err)
+	}
+	if err := w.WriteVariable(rollingVar("d", 4)); err != nil {
+		t.Fatalf("WriteVariable(d) error = %v", err)
+	}
+	if err := w.WriteVariable(rollingVar("e", 1)); !errors.Is(err, ErrFileFull) {
+		t.Errorf("WriteVariable(e) error = %v, want ErrFileFull", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if names := fileVariables(t, path); !reflect.DeepEqual(names, []string{"a", "b", "d"}) {
+		t.Errorf("variables = %v, want [a b d]", names)
+	}
+}
+
+func TestResume_Mismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.mat")
+	w, err := Create(path, Version5)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := w.WriteVariable(rollingVar("a", 2)); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	cp, err := w.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for _, bad := range []Checkpoint{
+		{Offset: cp.Offset + 8, Names: cp.Names},
+		{Offset: cp.Offset - 8, Names: cp.Names},
+		{Offset: cp.Offset, Names: []string{"b"}},
+		{Offset: cp.Offset, Names: nil},
+	} {
+		if _, err := Resume(path, bad); err == nil {
+			t.Errorf("Resume(%+v) error = nil, want an error", bad)
+		}
+	}
+	if _, err := Resume(path, cp, WithGzip()); err == nil {
+		t.Errorf("Resume(WithGzip) error = nil, want an error")
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != cp.Offset {
+		t.Errorf("file changed by failed Resume calls: %v, %v", info, err)
+	}
+}
+
+func TestCheckpoint_Unsupported(t *testing.T) {
+	dir := t.TempDir()
+	for name, create := range map[string]func() (*MatFileWriter, error){
+		"v7.3": func() (*MatFileWriter, error) { return Create(filepath.Join(dir, "a.mat"), Version73) },
+		"gzip": func() (*MatFileWriter, error) { return Create(filepath.Join(dir, "b.mat"), Version5, WithGzip()) },
+		"manifest": func() (*MatFileWriter, error) {
+			return Create(filepath.Join(dir, "c.mat"), Version5, WithManifest())
+		},
+	} {
+		w, err := create()
+		if err != nil {
+			t.Fatalf("%s: Create() error = %v", name, err)
+		}
+		if _, err := w.Checkpoint(); err == nil {
+			t.Errorf("%s: Checkpoint() error = nil, want an error", name)
+		}
+		w.Close()
+	}
+}
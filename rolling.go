@@ -0,0 +1,211 @@
+package matlab
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// ErrFileFull indicates a write to a file that has reached the limit set
+// with WithMaxVariables or WithMaxFileSize.
+var ErrFileFull = errors.New("file is full")
+
+// WithMaxVariables limits a file to n top-level variables: writes that
+// would add more return ErrFileFull and write nothing. RollingWriter
+// continues in a new file instead.
+//
+// Default: 0 (no limit)
+//
+// Example:
+//
+//	writer, _ := matlab.Create("out.mat", matlab.Version5, matlab.WithMaxVariables(1000))
+func WithMaxVariables(n int) Option {
+	return func(c *config) {
+		c.maxVariables = max(n, 0)
+	}
+}
+
+// WithMaxFileSize stops writes to a file once it holds size bytes or
+// more: later writes return ErrFileFull and write nothing, so a file
+// exceeds size by at most the last variable written before it. The size
+// is that of the file being written, before WithGzip compression or
+// WithEncryption. RollingWriter continues in a new file instead.
+//
+// Default: 0 (no limit)
+//
+// Example:
+//
+//	writer, _ := matlab.Create("out.mat", matlab.Version73, matlab.WithMaxFileSize(1<<30))
+func WithMaxFileSize(size int64) Option {
+	return func(c *config) {
+		c.maxFileSize = max(size, 0)
+	}
+}
+
+// checkRoom returns ErrFileFull if n more variables would exceed the
+// limits of WithMaxVariables and WithMaxFileSize.
+func (w *MatFileWriter) checkRoom(n int) error {
+	if w.maxVariables > 0 && len(w.names)+n > w.maxVariables {
+		return fmt.Errorf("%w: %d of %d variables written", ErrFileFull, len(w.names), w.maxVariables)
+	}
+	if w.maxFileSize > 0 {
+		if size := w.size(); size >= w.maxFileSize {
+			return fmt.Errorf("%w: %d of %d bytes written", ErrFileFull, size, w.maxFileSize)
+		}
+	}
+	return nil
+}
+
+// size returns the bytes written to the file so far.
+func (w *MatFileWriter) size() int64 {
+	if w.v5writer != nil {
+		return w.v5writer.Offset()
+	}
+	// The HDF5 library writes datasets to the file as they are created
+	if info, err := os.Stat(w.filename); err == nil {
+		return info.Size()
+	}
+	return 0
+}
+
+// RollingWriter writes variables to a series of numbered MAT-files, for
+// long-running acquisition jobs that must bound the size of each file.
+// When a write would exceed the limits of WithMaxVariables or
+// WithMaxFileSize, the current file is closed and the write goes to the
+// next one. Files are named by formatting their zero-based number with a
+// pattern such as "run_%03d.mat", and created as they are needed; the
+// other options apply to every file as for Create.
+//
+// A WriteVariables batch is never split: it goes to one file, and fails
+// with ErrFileFull if it does not fit even in a new one, which is left
+// empty. Variable names must be unique within a file, not across files.
+// The files can be read back as one dataset with OpenSet.
+//
+// Example:
+//
+//	rw, err := matlab.NewRollingWriter("acq/run_%03d.mat", matlab.Version73,
+//	    matlab.WithMaxFileSize(512<<20), matlab.WithCreateDirs())
+//	if err != nil {
+//	    return err
+//	}
+//	for block := range blocks {
+//	    if err := rw.WriteVariable(block); err != nil {
+//	        return err
+//	    }
+//	}
+//	err = rw.Close()
+//	log.Printf("wrote %v", rw.Files())
+type RollingWriter struct {
+	pattern string
+	version Version
+	opts    []Option
+
+	writer *MatFileWriter // Current file, nil before the first write and after Close
+	files  []string       // Files created, in order
+	stats  Stats          // Statistics of the closed files
+	closed bool
+}
+
+// NewRollingWriter returns a writer of the numbered files named by
+// pattern, which must contain one integer verb such as %d or %03d. No
+// file is created until the first write.
+func NewRollingWriter(pattern string, version Version, opts ...Option) (*RollingWriter, error) {
+	first, second := fmt.Sprintf(pattern, 0), fmt.Sprintf(pattern, 1)
+	if first == second || strings.Contains(first, "%!") {
+		return nil, fmt.Errorf("file name pattern %q must contain one integer verb such as %%03d", pattern)
+	}
+	if version != Version5 && version != Version73 {
+		return nil, fmt.Errorf("unsupported MAT-file version: %d", version)
+	}
+	return &RollingWriter{
+		pattern: pattern,
+		version: version,
+		opts:    append([]Option(nil), opts...),
+	}, nil
+}
+
+// WriteVariable writes v to the current file, or to a new one if the
+// current file is full.
+func (r *RollingWriter) WriteVariable(v *types.Variable) error {
+	return r.write(func(w *MatFileWriter) error { return w.WriteVariable(v) })
+}
+
+// WriteVariables writes a batch of variables to one file, as
+// MatFileWriter.WriteVariables does, starting a new file if the current
+// one cannot hold the batch.
+func (r *RollingWriter) WriteVariables(vars ...*types.Variable) error {
+	return r.write(func(w *MatFileWriter) error { return w.WriteVariables(vars...) })
+}
+
+// write calls fn with the current file, and again with a new file if the
+// current one is full.
+func (r *RollingWriter) write(fn func(*MatFileWriter) error) error {
+	if r.closed {
+		return errors.New("rolling writer is closed")
+	}
+	if r.writer == nil {
+		if err := r.next(); err != nil {
+			return err
+		}
+	}
+	err := fn(r.writer)
+	if !errors.Is(err, ErrFileFull) || len(r.writer.names) == 0 {
+		return err // A new file would be full too
+	}
+	if err := r.closeCurrent(); err != nil {
+		return err
+	}
+	if err := r.next(); err != nil {
+		return err
+	}
+	return fn(r.writer)
+}
+
+// next creates the next file of the series.
+func (r *RollingWriter) next() error {
+	filename := fmt.Sprintf(r.pattern, len(r.files))
+	w, err := Create(filename, r.version, r.opts...)
+	if err != nil {
+		return err
+	}
+	r.writer = w
+	r.files = append(r.files, filename)
+	return nil
+}
+
+// closeCurrent closes the current file and keeps its statistics.
+func (r *RollingWriter) closeCurrent() error {
+	err := r.writer.Close()
+	r.stats = r.stats.Add(r.writer.Stats())
+	r.writer = nil
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.files[len(r.files)-1], err)
+	}
+	return nil
+}
+
+// Files returns the files created so far, in order.
+func (r *RollingWriter) Files() []string {
+	return append([]string(nil), r.files...)
+}
+
+// Stats returns the statistics of the writes to all files so far, summed
+// as Stats.Add does.
+func (r *RollingWriter) Stats() Stats {
+	if r.writer == nil {
+		return r.stats.clone()
+	}
+	return r.stats.Add(r.writer.Stats())
+}
+
+// Close closes the current file. Further writes return an error.
+func (r *RollingWriter) Close() error {
+	r.closed = true
+	if r.writer == nil {
+		return nil
+	}
+	return r.closeCurrent()
+}
@@ -0,0 +1,135 @@
+package matlab
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// rollingVar returns a 1xn double variable named name.
+func rollingVar(name string, n int) *types.Variable {
+	return &types.Variable{Name: name, Dimensions: []int{1, n}, DataType: types.Double, Data: make([]float64, n)}
+}
+
+// fileVariables returns the names of the variables of the file at path.
+func fileVariables(t *testing.T, path string) []string {
+	t.Helper()
+	matFile, err := openPath(path, nil)
+	if err != nil {
+		t.Fatalf("Open(%s) error = %v", path, err)
+	}
+	var names []string
+	for _, v := range matFile.Variables {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+func TestWithMaxVariables(t *testing.T) {
+	w, err := Create(filepath.Join(t.TempDir(), "out.mat"), Version5, WithMaxVariables(2))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer w.Close()
+	if err := w.WriteVariable(rollingVar("a", 1)); err != nil {
+		t.Fatalf("WriteVariable(a) error = %v", err)
+	}
+	if err := w.WriteVariables(rollingVar("b", 1), rollingVar("c", 1)); !errors.Is(err, ErrFileFull) {
+		t.Errorf("WriteVariables(b, c) error = %v, want ErrFileFull", err)
+	}
+	if err := w.WriteVariable(rollingVar("b", 1)); err != nil {
+		t.Fatalf("WriteVariable(b) error = %v", err)
+	}
+	if err := w.WriteVariable(rollingVar("c", 1)); !errors.Is(err, ErrFileFull) {
+		t.Errorf("WriteVariable(c) error = %v, want ErrFileFull", err)
+	}
+	if s := w.Stats(); s.Variables != 2 {
+		t.Errorf("Stats().Variables = %d, want 2", s.Variables)
+	}
+}
+
+func TestRollingWriter(t *testing.T) {
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			dir := t.TempDir()
+			rw, err := NewRollingWriter(filepath.Join(dir, "run_%03d.mat"), version, WithMaxVariables(2))
+			if err != nil {
+				t.Fatalf("NewRollingWriter() error = %v", err)
+			}
+			for _, name := range []string{"a", "b", "c"} {
+				if err := rw.WriteVariable(rollingVar(name, 3)); err != nil {
+					t.Fatalf("WriteVariable(%s) error = %v", name, err)
+				}
+			}
+			// The batch does not fit beside c, and goes to a new file
+			if err := rw.WriteVariables(rollingVar("a", 3), rollingVar("b", 3)); err != nil {
+				t.Fatalf("WriteVariables() error = %v", err)
+			}
+			if err := rw.WriteVariables(rollingVar("x", 1), rollingVar("y", 1), rollingVar("z", 1)); !errors.Is(err, ErrFileFull) {
+				t.Errorf("WriteVariables(3 variables) error = %v, want ErrFileFull", err)
+			}
+			if err := rw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if err := rw.WriteVariable(rollingVar("d", 1)); err == nil {
+				t.Error("WriteVariable() after Close error = nil")
+			}
+
+			files := rw.Files()
+			want := [][]string{{"a", "b"}, {"c"}, {"a", "b"}, nil}
+			if len(files) != len(want) || files[1] != filepath.Join(dir, "run_001.mat") {
+				t.Fatalf("Files() = %v, want %d files", files, len(want))
+			}
+			for i, path := range files {
+				if got := fileVariables(t, path); !reflect.DeepEqual(got, want[i]) {
+					t.Errorf("%s holds %v, want %v", path, got, want[i])
+				}
+			}
+			if s := rw.Stats(); s.Variables != 5 {
+				t.Errorf("Stats().Variables = %d, want 5", s.Variables)
+			}
+		})
+	}
+}
+
+func TestRollingWriter_MaxFileSize(t *testing.T) {
+	// Each variable takes about 80 kB
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			rw, err := NewRollingWriter(filepath.Join(t.TempDir(), "part%d.mat"), version, WithMaxFileSize(100_000))
+			if err != nil {
+				t.Fatalf("NewRollingWriter() error = %v", err)
+			}
+			for i := range 5 {
+				if err := rw.WriteVariable(rollingVar(fmt.Sprintf("v%d", i), 10_000)); err != nil {
+					t.Fatalf("WriteVariable(%d) error = %v", i, err)
+				}
+			}
+			if err := rw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			files := rw.Files()
+			if len(files) != 3 {
+				t.Fatalf("Files() = %v, want 3 files", files)
+			}
+			if got := fileVariables(t, files[2]); !reflect.DeepEqual(got, []string{"v4"}) {
+				t.Errorf("%s holds %v, want [v4]", files[2], got)
+			}
+		})
+	}
+}
+
+func TestNewRollingWriter_Pattern(t *testing.T) {
+	for _, pattern := range []string{"run.mat", "run_%d_%d.mat"} {
+		if _, err := NewRollingWriter(pattern, Version5); err == nil {
+			t.Errorf("NewRollingWriter(%q) error = nil", pattern)
+		}
+	}
+	if _, err := NewRollingWriter("run_%d.mat", Version(6)); err == nil {
+		t.Error("NewRollingWriter(version 6) error = nil")
+	}
+}
@@ -0,0 +1,73 @@
+package matlab
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestWriteScaled(t *testing.T) {
+	raw := []int16{-32768, 0, 16384, 32767}
+	want := []float64{-10, 0, 5, 32767 * 10.0 / 32768}
+	for _, version := range []Version{Version5, Version73} {
+		path := filepath.Join(t.TempDir(), "daq.mat")
+		writer, err := Create(path, version)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if err := writer.WriteScaled("ch1", raw, 10.0/32768, 0); err != nil {
+			t.Fatalf("WriteScaled() error = %v", err)
+		}
+		if err := writer.WriteScaled("ch2", raw[:2], 2, 1.5); err != nil {
+			t.Fatalf("WriteScaled() error = %v", err)
+		}
+		if err := writer.WriteVariable(&types.Variable{Name: "plain", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		matFile := openFile(t, path)
+		got, err := matFile.GetEngineeringUnits("ch1")
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("version %d: GetEngineeringUnits(ch1) = %v, %v, want %v", version, got, err, want)
+		}
+		if got, err := matFile.GetEngineeringUnits("ch2"); err != nil || !reflect.DeepEqual(got, []float64{-65534.5, 1.5}) {
+			t.Errorf("version %d: GetEngineeringUnits(ch2) = %v, %v", version, got, err)
+		}
+		st := matFile.GetVariable("ch1").Data.(*types.StructArray)
+		if field := st.Field(0, "raw"); field.DataType != types.Int16 || !reflect.DeepEqual(field.Data, raw) {
+			t.Errorf("version %d: raw = %v %v", version, field.DataType, field.Data)
+		}
+		if _, err := matFile.GetEngineeringUnits("plain"); !errors.Is(err, ErrNotScaled) {
+			t.Errorf("version %d: GetEngineeringUnits(plain) error = %v", version, err)
+		}
+		if _, err := matFile.GetEngineeringUnits("missing"); !errors.Is(err, ErrVariableNotFound) {
+			t.Errorf("version %d: GetEngineeringUnits(missing) error = %v", version, err)
+		}
+	}
+}
+
+func TestGetEngineeringUnits_Attributes(t *testing.T) {
+	matFile := &MatFile{Variables: []*types.Variable{
+		{Name: "v", Dimensions: []int{1, 2}, DataType: types.Int16, Data: []int16{10, 20},
+			Attributes: map[string]interface{}{"scale": []float64{0.5}, "offset": int32(-1)}},
+		{Name: "s", Dimensions: []int{1, 1}, DataType: types.Uint8, Data: []uint8{4},
+			Attributes: map[string]interface{}{"offset": float32(2)}},
+		{Name: "bad", Dimensions: []int{1, 1}, DataType: types.Uint8, Data: []uint8{4},
+			Attributes: map[string]interface{}{"scale": "high"}},
+	}}
+	if got, err := matFile.GetEngineeringUnits("v"); err != nil || !reflect.DeepEqual(got, []float64{4, 9}) {
+		t.Errorf("GetEngineeringUnits(v) = %v, %v", got, err)
+	}
+	if got, err := matFile.GetEngineeringUnits("s"); err != nil || !reflect.DeepEqual(got, []float64{6}) {
+		t.Errorf("GetEngineeringUnits(s) = %v, %v", got, err)
+	}
+	if _, err := matFile.GetEngineeringUnits("bad"); !errors.Is(err, ErrNotScaled) {
+		t.Errorf("GetEngineeringUnits(bad) error = %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package matlab
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// scipyCorpusWant returns the variables of the savemat corpus files (see
+// scripts/scipy-corpus/generate.py) in file order, with the 1-D arrays
+// shaped as oned_as writes them.
+func scipyCorpusWant(oned func(n int) []int) []*types.Variable {
+	scalar := []int{1, 1}
+	return []*types.Variable{
+		{Name: "scalar", Dimensions: scalar, DataType: types.Double, Data: []float64{3.5}},
+		{Name: "integer", Dimensions: scalar, DataType: types.Int64, Data: []int64{42}},
+		{Name: "flag", Dimensions: scalar, DataType: types.Logical, Data: []bool{true}},
+		{Name: "vector", Dimensions: oned(5), DataType: types.Double, Data: []float64{1, 2, 3, 4, 5}},
+		{Name: "matrix", Dimensions: []int{2, 3}, DataType: types.Double, Data: []float64{1, 4, 2, 5, 3, 6}},
+		{Name: "int32s", Dimensions: oned(3), DataType: types.Int32, Data: []int32{-1, 2, 3}},
+		{Name: "uint8s", Dimensions: oned(2), DataType: types.Uint8, Data: []uint8{0, 255}},
+		{Name: "mask", Dimensions: oned(3), DataType: types.Logical, Data: []bool{true, false, true}},
+		{Name: "z", Dimensions: scalar, DataType: types.Double, IsComplex: true, Data: &types.NumericArray{
+			Real: []float64{1}, Imag: []float64{-2}, Dimensions: scalar, Type: types.Double,
+		}},
+		{Name: "text", Dimensions: []int{1, 5}, DataType: types.Char, Data: "hello"},
+		{Name: "unicode", Dimensions: []int{1, 11}, DataType: types.Char, Data: "température"},
+		{Name: "chars", Dimensions: []int{2, 3}, DataType: types.Char, Data: "acbd e"}, // ["ab ", "cde"], column-major
+		{Name: "empty", Dimensions: []int{0, 0}, DataType: types.Double, Data: []float64{}},
+		{Name: "cells", Dimensions: oned(3), DataType: types.CellArray, Data: []*types.Variable{
+			{Dimensions: scalar, DataType: types.Double, Data: []float64{1}},
+			{Dimensions: []int{1, 3}, DataType: types.Char, Data: "two"},
+			{Dimensions: oned(2), DataType: types.Int16, Data: []int16{3, 4}},
+		}},
+		{Name: "s", Dimensions: scalar, DataType: types.Struct, Data: &types.StructArray{
+			Fields:     []string{"a", "name", "nested"},
+			Dimensions: scalar,
+			Elements: [][]*types.Variable{{
+				{Name: "a", Dimensions: scalar, DataType: types.Double, Data: []float64{1}},
+				{Name: "name", Dimensions: scalar, DataType: types.Char, Data: "x"},
+				{Name: "nested", Dimensions: scalar, DataType: types.Struct, Data: &types.StructArray{
+					Fields:     []string{"b"},
+					Dimensions: scalar,
+					Elements: [][]*types.Variable{{
+						{Name: "b", Dimensions: oned(2), DataType: types.Int64, Data: []int64{1, 2}},
+					}},
+				}},
+			}},
+		}},
+	}
+}
+
+// TestScipyCorpus reads the files scipy.io.savemat writes with each
+// combination of do_compression and oned_as.
+func TestScipyCorpus(t *testing.T) {
+	row := func(n int) []int { return []int{1, n} }
+	column := func(n int) []int { return []int{n, 1} }
+	for _, compression := range []string{"uncompressed", "compressed"} {
+		for _, onedAs := range []string{"row", "column"} {
+			name := fmt.Sprintf("savemat_%s_%s.mat", compression, onedAs)
+			t.Run(name, func(t *testing.T) {
+				f, err := os.Open(filepath.Join("testdata", "scipy", name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				matFile, err := Open(f)
+				if err != nil {
+					t.Fatalf("Open() error = %v", err)
+				}
+				if matFile.Features.Compressed != (compression == "compressed") {
+					t.Errorf("Features.Compressed = %v", matFile.Features.Compressed)
+				}
+
+				oned := row
+				if onedAs == "column" {
+					oned = column
+				}
+				want := scipyCorpusWant(oned)
+				if len(matFile.Variables) != len(want)+1 {
+					t.Fatalf("%d variables, want %d", len(matFile.Variables), len(want)+1)
+				}
+				for i, w := range want {
+					if m := types.Compare(w, matFile.Variables[i]); m != nil {
+						t.Errorf("%s: %s", w.Name, m.Message)
+					}
+				}
+
+				sparse := matFile.GetVariable("sparse")
+				sm, ok := sparse.Data.(*types.SparseMatrix)
+				if !ok || !sparse.IsSparse {
+					t.Fatalf("sparse: %T, IsSparse = %v", sparse.Data, sparse.IsSparse)
+				}
+				if sm.Rows != 3 || sm.Cols != 2 || !reflect.DeepEqual(sm.RowIndices, []int{0, 2, 1}) ||
+					!reflect.DeepEqual(sm.ColPointers, []int{0, 2, 3}) || !reflect.DeepEqual(sm.Real, []float64{1.5, 2.5, 3.5}) {
+					t.Errorf("sparse = %+v", sm)
+				}
+			})
+		}
+	}
+}
@@ -1,23 +1,53 @@
-// Package main - Generate minimal test MAT-files
+// Package main - Generate test MAT-files
 //
-// This script creates minimal MATLAB test files for testdata/ directory.
-// Uses our own writer to generate files (dogfooding approach).
+// Without flags, this script creates the minimal MATLAB test files of the
+// testdata/ directory. Uses our own writer to generate files (dogfooding
+// approach).
 //
-// Usage: go run scripts/generate-testdata.go
+// With size flags, it instead writes a v5 and a v7.3 file of the
+// requested size and shape, for benchmarks and soak tests:
+//
+//	go run ./scripts/generate-testdata -vars 20 -dims 1000x1000 \
+//	    -types double,int16 -compression 6 -o /tmp/large
+//
+// writes /tmp/large/large_v5.mat and /tmp/large/large_v73.mat. See
+// internal/testgen for the data written.
+//
+// Usage: go run ./scripts/generate-testdata [flags]
 package main
 
 import (
+	"encoding/binary"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/internal/testgen"
 	"github.com/scigolib/matlab/types"
 )
 
 func main() {
+	var spec testgen.Spec
+	var dims, classes string
+	output := flag.String("o", filepath.Join("testdata", "large"), "output directory for sized files")
+	flag.IntVar(&spec.Variables, "vars", 1, "number of variables")
+	flag.IntVar(&spec.Elements, "elements", 1000, "elements per variable, as a 1xN row (ignored with -dims)")
+	flag.StringVar(&dims, "dims", "", "dimensions of each variable, e.g. 100x200x3")
+	flag.StringVar(&classes, "types", "double", "comma-separated classes assigned to variables in turn")
+	flag.IntVar(&spec.Compression, "compression", 0, "v5 compression level 0-9")
+	flag.Parse()
+	if flag.NFlag() > 0 {
+		if err := generateSized(*output, spec, dims, classes); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	fmt.Println("📦 Generating MATLAB test files for testdata/")
 	fmt.Println(strings.Repeat("=", 60))
 
@@ -144,6 +174,54 @@ func main() {
 		fmt.Println("✅")
 	}
 
+	// Generate v5 files in both byte orders
+	fmt.Println("\n📝 Generating v5 endianness test files:")
+	endianFiles := []struct {
+		filename string
+		order    binary.ByteOrder
+	}{
+		{"endian_le_v5.mat", binary.LittleEndian},
+		{"endian_be_v5.mat", binary.BigEndian},
+	}
+	for _, test := range endianFiles {
+		filename := filepath.Join(testdataDir, test.filename)
+		fmt.Printf("  - %s: %v... ", test.filename, test.order)
+
+		writer, err := matlab.Create(filename, matlab.Version5, matlab.WithEndianness(test.order))
+		if err != nil {
+			fmt.Printf("❌ FAILED\n    Error: %v\n", err)
+			continue
+		}
+
+		if err := writer.WriteVariable(&types.Variable{
+			Name:       "A",
+			Dimensions: []int{2, 3},
+			DataType:   types.Double,
+			Data:       []float64{1, 2, 3, 4, 5, 6},
+		}); err != nil {
+			fmt.Printf("❌ FAILED\n    Error: %v\n", err)
+			_ = writer.Close() // Best effort cleanup on error
+			continue
+		}
+
+		if err := writer.Close(); err != nil {
+			fmt.Printf("❌ FAILED\n    Error: %v\n", err)
+			continue
+		}
+
+		fmt.Println("✅")
+	}
+
+	// Generate a big-endian v5 file with every class the writer supports,
+	// checked independently by scripts/octave/check_endian.m
+	filename := filepath.Join(testdataDir, "endian_be_types_v5.mat")
+	fmt.Printf("  - endian_be_types_v5.mat: all classes, big-endian... ")
+	if err := writeVariables(filename, endianTypeVariables(), matlab.WithEndianness(binary.BigEndian)); err != nil {
+		fmt.Printf("❌ FAILED\n    Error: %v\n", err)
+	} else {
+		fmt.Println("✅")
+	}
+
 	// Create README
 	readmePath := filepath.Join(testdataDir, "README.md")
 	readme := `# MATLAB Test Data
@@ -162,6 +240,9 @@ This directory contains minimal MATLAB files for testing.
 | matrix_2x3.mat | v7.3 | 2x3 matrix | matrix | double | [2, 3] |
 | matrix_3x2.mat | v7.3 | 3x2 matrix | A | double | [3, 2] |
 | scalar.mat | v7.3 | Scalar value | x | double | [1] |
+| endian_le_v5.mat | v5 | Little-endian ("IM" indicator) | A | double | [2, 3] |
+| endian_be_v5.mat | v5 | Big-endian ("MI" indicator) | A | double | [2, 3] |
+| endian_be_types_v5.mat | v5 | Big-endian, every class (see check_endian.m) | d, s, i8, ... | all | various |
 
 ## Generation
 
@@ -181,7 +262,11 @@ Use these files for:
 
 ## Notes
 
-- All files are v7.3 format (HDF5-based)
+- All files except endian_*_v5.mat are v7.3 format (HDF5-based)
+- endian_be_types_v5.mat can be verified with Octave:
+  ` + "`octave --no-gui scripts/octave/check_endian.m`" + `
+- The v5 endian indicator is 'MI' stored in the file's byte order, so
+  little-endian files read "IM" and big-endian files read "MI"
 - Files use MATLAB_class attributes for type info
 - Data is stored in column-major order (MATLAB convention)
 - Complex numbers use separate real/imaginary datasets
@@ -195,9 +280,98 @@ Use these files for:
 
 	fmt.Println("\n" + strings.Repeat("=", 60))
 	fmt.Println("✅ Test data generation complete!")
-	fmt.Printf("📁 Generated %d test files in testdata/\n", len(tests))
+	fmt.Printf("📁 Generated %d test files in testdata/\n", len(tests)+len(endianFiles))
 	fmt.Println("\nNext steps:")
 	fmt.Println("  1. Run tests: go test ./...")
 	fmt.Println("  2. Verify files: ls -lh testdata/")
 	fmt.Println("  3. Test with MATLAB/Octave (if available)")
 }
+
+// generateSized writes large_v5.mat and large_v73.mat to dir, holding the
+// variables described by spec and the -dims and -types flags.
+func generateSized(dir string, spec testgen.Spec, dims, classes string) error {
+	if dims != "" {
+		for _, field := range strings.Split(dims, "x") {
+			d, err := strconv.Atoi(field)
+			if err != nil {
+				return fmt.Errorf("invalid -dims %q: %w", dims, err)
+			}
+			spec.Dims = append(spec.Dims, d)
+		}
+	}
+	var err error
+	if spec.Types, err = testgen.ParseTypes(classes); err != nil {
+		return err
+	}
+
+	for _, file := range []struct {
+		name    string
+		version matlab.Version
+	}{
+		{"large_v5.mat", matlab.Version5},
+		{"large_v73.mat", matlab.Version73},
+	} {
+		path := filepath.Join(dir, file.name)
+		fmt.Printf("  - %s... ", path)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := testgen.Write(path, file.version, spec); err != nil {
+			fmt.Println("❌ FAILED")
+			return err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ %d bytes\n", info.Size())
+	}
+	return nil
+}
+
+// writeVariables writes a v5 file holding the variables.
+func writeVariables(filename string, variables []*types.Variable, opts ...matlab.Option) error {
+	writer, err := matlab.Create(filename, matlab.Version5, opts...)
+	if err != nil {
+		return err
+	}
+	for _, v := range variables {
+		if err := writer.WriteVariable(v); err != nil {
+			_ = writer.Close() // Best effort cleanup on error
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// endianTypeVariables returns one variable per class and storage type,
+// with values whose bytes differ under swapping. The internal/v5 tests
+// and scripts/octave/check_endian.m expect exactly these values.
+func endianTypeVariables() []*types.Variable {
+	return []*types.Variable{
+		{Name: "d", Dimensions: []int{2, 3}, DataType: types.Double, Data: []float64{1, -2.5, 3e100, 4, 5, 6}},
+		{Name: "s", Dimensions: []int{1, 3}, DataType: types.Single, Data: []float32{1.5, -2, 3}},
+		{Name: "i8", Dimensions: []int{1, 3}, DataType: types.Int8, Data: []int8{-128, 0, 127}},
+		{Name: "u8", Dimensions: []int{1, 3}, DataType: types.Uint8, Data: []uint8{0, 1, 255}},
+		{Name: "i16", Dimensions: []int{1, 3}, DataType: types.Int16, Data: []int16{-32768, 1, 32767}},
+		{Name: "u16", Dimensions: []int{1, 3}, DataType: types.Uint16, Data: []uint16{0, 258, 65535}},
+		{Name: "i32", Dimensions: []int{1, 3}, DataType: types.Int32, Data: []int32{-1 << 31, 1, 1<<31 - 1}},
+		{Name: "u32", Dimensions: []int{1, 3}, DataType: types.Uint32, Data: []uint32{0, 0x01020304, 1<<32 - 1}},
+		{Name: "i64", Dimensions: []int{1, 3}, DataType: types.Int64, Data: []int64{-1 << 63, 0x0102030405060708, 1<<63 - 1}},
+		{Name: "u64", Dimensions: []int{1, 3}, DataType: types.Uint64, Data: []uint64{0, 0x0102030405060708, 1<<64 - 1}},
+		{Name: "mask", Dimensions: []int{1, 3}, DataType: types.Logical, Data: []bool{true, false, true}},
+		{Name: "ascii", Dimensions: []int{1, 5}, DataType: types.Char, Data: "hello"},
+		{Name: "bmp", Dimensions: []int{1, 4}, DataType: types.Char, Data: "Grüß"},
+		{Name: "z", Dimensions: []int{1, 2}, DataType: types.Int16, IsComplex: true,
+			Data: &types.NumericArray{Real: []int16{1, -2}, Imag: []int16{3, -4}}},
+		{Name: "cube", Dimensions: []int{2, 1, 2}, DataType: types.Double, Data: []float64{1, 2, 3, 4}},
+		{Name: "cfg", Dimensions: []int{1, 1}, DataType: types.Struct, Data: &types.StructArray{
+			Fields:     []string{"rate", "label"},
+			Dimensions: []int{1, 1},
+			Elements: [][]*types.Variable{{
+				{Name: "rate", Dimensions: []int{1, 1}, DataType: types.Uint32, Data: []uint32{44100}},
+				{Name: "label", Dimensions: []int{1, 2}, DataType: types.Char, Data: "ab"},
+			}},
+		}},
+	}
+}
@@ -0,0 +1,238 @@
+#!/usr/bin/env python3
+"""Generate the scipy.io.savemat compatibility corpus in testdata/scipy.
+
+Writes one file per combination of do_compression and oned_as:
+
+    savemat_{uncompressed,compressed}_{row,column}.mat
+
+holding the variables of VARIABLES below. The Go tests in
+scipy_corpus_test.go check every value.
+
+With scipy installed the files are written by scipy.io.savemat itself.
+Without it, a port of the v5 encoder of scipy.io.matlab (mio5.py, scipy
+1.x) writes the same element layout, including the quirks the reader has
+to handle: field names padded to the longest name plus one instead of 32
+bytes, char data as miUTF8, logicals as uint8 arrays with the logical
+flag, Python lists and tuples as cell arrays, empty names as zero-length
+small elements and unpadded miCOMPRESSED elements.
+
+Usage:
+
+    python3 scripts/scipy-corpus/generate.py [outdir]
+"""
+
+import os
+import struct
+import sys
+import time
+import zlib
+
+# MAT-file v5 data types and array classes
+miINT8, miUINT8, miINT16, miUINT16, miINT32, miUINT32 = 1, 2, 3, 4, 5, 6
+miSINGLE, miDOUBLE, miINT64, miUINT64 = 7, 9, 12, 13
+miMATRIX, miCOMPRESSED, miUTF8 = 14, 15, 16
+mxCELL, mxSTRUCT, mxCHAR, mxSPARSE, mxDOUBLE = 1, 2, 4, 5, 6
+mxINT32, mxUINT8, mxINT64 = 12, 9, 14
+
+
+class Array:
+    """A typed numeric array: code is a struct format character."""
+
+    def __init__(self, code, values, shape=None):
+        self.code = code
+        self.values = list(values)
+        self.shape = shape  # None for 1-D
+
+
+class Sparse:
+    """A real sparse matrix in compressed sparse column form."""
+
+    def __init__(self, shape, indices, indptr, data):
+        self.shape, self.indices, self.indptr, self.data = shape, indices, indptr, data
+
+
+def variables():
+    """The corpus variables, in file order (dicts keep insertion order)."""
+    return {
+        "scalar": 3.5,
+        "integer": 42,  # Python ints are written as int64
+        "flag": True,
+        "vector": Array("d", [1, 2, 3, 4, 5]),  # 1-D: shape follows oned_as
+        "matrix": Array("d", [1, 2, 3, 4, 5, 6], (2, 3)),  # Row-major values
+        "int32s": Array("i", [-1, 2, 3]),
+        "uint8s": Array("B", [0, 255]),
+        "mask": Array("?", [True, False, True]),
+        "z": complex(1, -2),
+        "text": "hello",
+        "unicode": "température",
+        "chars": ["ab", "cde"],  # Written by scipy as a 2x3 char matrix
+        "empty": Array("d", []),
+        "cells": (1.0, "two", Array("h", [3, 4])),
+        "s": {"a": 1.0, "name": "x", "nested": {"b": Array("q", [1, 2])}},
+        "sparse": Sparse((3, 2), [0, 2, 1], [0, 2, 3], [1.5, 2.5, 3.5]),
+    }
+
+
+def with_scipy(path, compress, oned_as):
+    import numpy as np
+    import scipy.io
+    import scipy.sparse
+
+    def convert(value):
+        if isinstance(value, Array):
+            dtype = {"d": "f8", "i": "i4", "B": "u1", "?": "b1", "h": "i2", "q": "i8"}[value.code]
+            arr = np.array(value.values, dtype=dtype)
+            return arr.reshape(value.shape) if value.shape else arr
+        if isinstance(value, Sparse):
+            return scipy.sparse.csc_matrix((value.data, value.indices, value.indptr), shape=value.shape)
+        if isinstance(value, dict):
+            return {k: convert(v) for k, v in value.items()}
+        if isinstance(value, tuple):
+            cells = np.empty((len(value),), dtype=object)
+            for i, v in enumerate(value):
+                cells[i] = convert(v)
+            return cells
+        if isinstance(value, list):
+            return np.array(value)
+        return value
+
+    mdict = {name: convert(v) for name, v in variables().items()}
+    scipy.io.savemat(path, mdict, do_compression=compress, oned_as=oned_as)
+
+
+class Encoder:
+    """Port of scipy.io.matlab._mio5.VarWriter5 for the corpus types."""
+
+    def __init__(self, oned_as):
+        self.oned_as = oned_as
+        self.out = bytearray()
+
+    def element(self, data, mdtype):
+        if len(data) <= 4:
+            self.out += struct.pack("<I", (len(data) << 16) + mdtype)
+            self.out += data.ljust(4, b"\0")
+            return
+        self.out += struct.pack("<II", mdtype, len(data))
+        self.out += data
+        if len(data) % 8:
+            self.out += b"\0" * (8 - len(data) % 8)
+
+    def header(self, name, shape, mclass, complex_=False, logical=False, nzmax=0):
+        flags = (complex_ << 3) | (logical << 1)
+        self.out += struct.pack("<IIII", miUINT32, 8, mclass | flags << 8, nzmax)
+        self.element(struct.pack("<%di" % len(shape), *shape), miINT32)
+        if name:
+            self.element(name, miINT8)
+        else:
+            self.out += struct.pack("<II", miINT8, 0)  # Zero-length small element
+
+    def dims(self, n):
+        if n == 0:
+            return (0, 0)
+        return (1, n) if self.oned_as == "row" else (n, 1)
+
+    def matrix(self, name, value):
+        start = len(self.out)
+        self.out += struct.pack("<II", miMATRIX, 0)
+        self.contents(name, value)
+        struct.pack_into("<I", self.out, start + 4, len(self.out) - start - 8)
+
+    def contents(self, name, value):
+        codes = {"d": (mxDOUBLE, miDOUBLE), "i": (mxINT32, miINT32), "B": (mxUINT8, miUINT8),
+                 "?": (mxUINT8, miUINT8), "h": (10, miINT16), "q": (mxINT64, miINT64)}
+        if isinstance(value, bool):
+            value = Array("?", [value], ())
+        elif isinstance(value, int):
+            value = Array("q", [value], ())
+        elif isinstance(value, float):
+            value = Array("d", [value], ())
+        if isinstance(value, complex):
+            self.header(name, (1, 1), mxDOUBLE, complex_=True)
+            self.element(struct.pack("<d", value.real), miDOUBLE)
+            self.element(struct.pack("<d", value.imag), miDOUBLE)
+        elif isinstance(value, Array):
+            mclass, mdtype = codes[value.code]
+            values = value.values
+            if value.shape == ():
+                shape = (1, 1)
+            elif value.shape:
+                shape = value.shape
+                rows, cols = shape
+                values = [values[r * cols + c] for c in range(cols) for r in range(rows)]
+            else:
+                shape = self.dims(len(values))
+            self.header(name, shape, mclass, logical=value.code == "?")
+            code = "B" if value.code == "?" else value.code
+            self.element(struct.pack("<%d%s" % (len(values), code), *values), mdtype)
+        elif isinstance(value, str):
+            self.chars(name, [value])
+        elif isinstance(value, list):
+            self.chars(name, value)
+        elif isinstance(value, tuple):
+            self.header(name, self.dims(len(value)), mxCELL)
+            for v in value:
+                self.matrix(b"", v)
+        elif isinstance(value, dict):
+            self.header(name, (1, 1), mxSTRUCT)
+            fields = list(value)
+            length = max(len(f) for f in fields) + 1
+            self.element(struct.pack("<i", length), miINT32)
+            self.element(b"".join(f.encode().ljust(length, b"\0") for f in fields), miINT8)
+            for f in fields:
+                self.matrix(b"", value[f])
+        elif isinstance(value, Sparse):
+            self.header(name, value.shape, mxSPARSE, nzmax=max(1, len(value.data)))
+            self.element(struct.pack("<%di" % len(value.indices), *value.indices), miINT32)
+            self.element(struct.pack("<%di" % len(value.indptr), *value.indptr), miINT32)
+            self.element(struct.pack("<%dd" % len(value.data), *value.data), miDOUBLE)
+        else:
+            raise TypeError(type(value))
+
+    def chars(self, name, rows):
+        # A single string is 1xN; a list of strings is a space-padded matrix
+        width = max(len(r) for r in rows)
+        if width == 0:
+            self.header(name, (0, 0), mxCHAR)
+            self.out += struct.pack("<II", miUTF8, 0)
+            return
+        rows = [r.ljust(width) for r in rows]
+        self.header(name, (len(rows), width), mxCHAR)
+        text = "".join(rows[r][c] for c in range(width) for r in range(len(rows)))
+        self.element(text.encode("utf-8"), miUTF8)
+
+
+def emulated(path, compress, oned_as):
+    out = bytearray()
+    description = "MATLAB 5.0 MAT-file Platform: posix, Created on: %s" % time.asctime()
+    out += description.encode().ljust(116, b"\0")
+    out += b"\0" * 8 + struct.pack("<H", 0x0100) + b"IM"
+    for name, value in variables().items():
+        enc = Encoder(oned_as)
+        enc.matrix(name.encode("latin1"), value)
+        if compress:
+            data = zlib.compress(bytes(enc.out))
+            out += struct.pack("<II", miCOMPRESSED, len(data)) + data  # No padding
+        else:
+            out += enc.out
+    with open(path, "wb") as f:
+        f.write(out)
+
+
+def main():
+    outdir = sys.argv[1] if len(sys.argv) > 1 else os.path.join("testdata", "scipy")
+    try:
+        import scipy.io  # noqa: F401
+        write = with_scipy
+    except ImportError:
+        print("scipy not installed; using the port of its v5 encoder")
+        write = emulated
+    for compress in (False, True):
+        for oned_as in ("row", "column"):
+            name = "savemat_%s_%s.mat" % ("compressed" if compress else "uncompressed", oned_as)
+            path = os.path.join(outdir, name)
+            write(path, compress, oned_as)
+            print("wrote", path)
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,361 @@
+// Package flight serves the variables of a MAT-file over Apache Arrow
+// Flight, the gRPC protocol for streaming columnar data, so remote
+// dashboards and notebooks (pyarrow.flight, the Arrow Flight clients for
+// Go, Java, C++ and R) can pull slices of large matrices without copying
+// whole files around.
+//
+// It is a separate module, so the gRPC and Arrow dependencies are only
+// pulled in by programs that use it; package serve answers the same
+// requests over plain HTTP.
+//
+// A 2-D real numeric or logical variable is a flight whose columns are
+// the columns of the matrix, named Var1, Var2, ... as MATLAB's
+// array2table names them, with the matching Arrow type (double is
+// float64, logical is bool, and so on). Flights are described by a
+// command of the form "X" or "X?cols=3:8", selecting zero-based columns
+// as serve does (see serve.ParseColumns), or by the path ["X"]; the
+// command is also the ticket. In v5 files only the selected columns are
+// read from disk (see matlab.LoadColumns); whole variables are read on
+// first request and kept in a matlab.Cache. DoGet streams record batches
+// of at most BatchRows rows.
+//
+// Example:
+//
+//	srv := arrowflight.NewServerWithMiddleware(nil)
+//	if err := srv.Init(":8815"); err != nil {
+//	    log.Fatal(err)
+//	}
+//	srv.RegisterFlightService(flight.NewServer("results.mat"))
+//	log.Fatal(srv.Serve())
+//
+// and on the client, in Python:
+//
+//	client = pyarrow.flight.connect("grpc://localhost:8815")
+//	table = client.do_get(pyarrow.flight.Ticket(b"X?cols=0:10")).read_all()
+package flight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	arrowflight "github.com/apache/arrow-go/v18/arrow/flight"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/serve"
+	"github.com/scigolib/matlab/types"
+)
+
+// BatchRows is the most rows DoGet sends in one record batch.
+const BatchRows = 65536
+
+// Server serves the variables of one MAT-file as Arrow Flight flights.
+type Server struct {
+	arrowflight.BaseFlightServer
+
+	path  string
+	cache *matlab.Cache
+	mem   memory.Allocator
+}
+
+// NewServer returns a Flight service serving the variables of the
+// MAT-file at path, to register with a Flight server. The options are
+// used whenever the file is read; the file is not read until the first
+// request.
+func NewServer(path string, opts ...matlab.OpenOption) *Server {
+	return &Server{path: path, cache: matlab.NewCache(1, opts...), mem: memory.DefaultAllocator}
+}
+
+// request is a variable and the columns selected from it.
+type request struct {
+	info *types.VariableInfo
+	spec string // Column selection as given, "" for all
+	cols []int
+}
+
+// ListFlights sends a flight for every variable the server can serve.
+func (s *Server) ListFlights(_ *arrowflight.Criteria, stream arrowflight.FlightService_ListFlightsServer) error {
+	meta, err := s.cache.Metadata(s.path)
+	if err != nil {
+		return statusError(err)
+	}
+	for _, info := range meta.Variables {
+		r, err := newRequest(info, "")
+		if err != nil {
+			continue // Not servable
+		}
+		if err := stream.Send(s.flightInfo(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetFlightInfo describes the flight of a command or path descriptor.
+func (s *Server) GetFlightInfo(_ context.Context, desc *arrowflight.FlightDescriptor) (*arrowflight.FlightInfo, error) {
+	r, err := s.describe(desc)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return s.flightInfo(r), nil
+}
+
+// GetSchema returns the schema of the flight of a descriptor.
+func (s *Server) GetSchema(_ context.Context, desc *arrowflight.FlightDescriptor) (*arrowflight.SchemaResult, error) {
+	r, err := s.describe(desc)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &arrowflight.SchemaResult{Schema: arrowflight.SerializeSchema(r.schema(), s.mem)}, nil
+}
+
+// DoGet streams the selected columns of a variable.
+func (s *Server) DoGet(ticket *arrowflight.Ticket, stream arrowflight.FlightService_DoGetServer) error {
+	r, err := s.parse(string(ticket.GetTicket()))
+	if err != nil {
+		return statusError(err)
+	}
+	v, err := s.load(r)
+	if err != nil {
+		return statusError(err)
+	}
+
+	schema := r.schema()
+	w := arrowflight.NewRecordWriter(stream, ipc.WithSchema(schema))
+	defer w.Close() //nolint:errcheck // Nothing left to send on failure
+
+	// Column i of the selection is data[i*rows:(i+1)*rows]; an empty
+	// matrix is sent as one empty batch
+	rows := r.info.Dimensions[0]
+	b := array.NewRecordBuilder(s.mem, schema)
+	defer b.Release()
+	for from := 0; ; from += BatchRows {
+		to := min(from+BatchRows, rows)
+		for i := range r.cols {
+			if err := appendValues(b.Field(i), v.Data, i*rows+from, i*rows+to); err != nil {
+				return statusError(err)
+			}
+		}
+		batch := b.NewRecordBatch()
+		err := w.Write(batch)
+		batch.Release()
+		if err != nil {
+			return err
+		}
+		if to >= rows {
+			return nil
+		}
+	}
+}
+
+// describe resolves a descriptor to a request.
+func (s *Server) describe(desc *arrowflight.FlightDescriptor) (*request, error) {
+	switch desc.GetType() {
+	case arrowflight.DescriptorCMD:
+		return s.parse(string(desc.GetCmd()))
+	case arrowflight.DescriptorPATH:
+		if path := desc.GetPath(); len(path) == 1 {
+			return s.request(path[0], "")
+		}
+		return nil, fmt.Errorf("%w: path %v, want one variable name", serve.ErrBadRequest, desc.GetPath())
+	}
+	return nil, fmt.Errorf("%w: descriptor type %v", serve.ErrBadRequest, desc.GetType())
+}
+
+// parse parses a command or ticket, "name" or "name?cols=spec".
+func (s *Server) parse(command string) (*request, error) {
+	name, rawQuery, _ := strings.Cut(command, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", serve.ErrBadRequest, command, err)
+	}
+	return s.request(name, query.Get("cols"))
+}
+
+// request returns the request for the columns spec selects from the
+// variable name.
+func (s *Server) request(name, spec string) (*request, error) {
+	meta, err := s.cache.Metadata(s.path)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range meta.Variables {
+		if info.Name == name {
+			return newRequest(info, spec)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", matlab.ErrVariableNotFound, name)
+}
+
+// newRequest checks that a variable can be served and parses the columns
+// spec selects from it.
+func newRequest(info *types.VariableInfo, spec string) (*request, error) {
+	if _, err := fieldType(info); err != nil {
+		return nil, err
+	}
+	n := info.Dimensions[1]
+	r := &request{info: info, spec: spec}
+	if spec == "" {
+		r.cols = make([]int, n)
+		for i := range r.cols {
+			r.cols[i] = i
+		}
+		return r, nil
+	}
+
+	cols, err := serve.ParseColumns(spec, n)
+	if err != nil {
+		return nil, err
+	}
+	for _, col := range cols {
+		if col < 0 || col >= n {
+			return nil, fmt.Errorf("%w: column %d of %s", matlab.ErrColumnOutOfRange, col, info.Name)
+		}
+	}
+	r.cols = cols
+	return r, nil
+}
+
+// load reads the selected columns of a variable.
+func (s *Server) load(r *request) (*types.Variable, error) {
+	if r.spec == "" {
+		return s.cache.Variable(s.path, r.info.Name)
+	}
+	//nolint:gosec // G304: path is configured by the server, not the request
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck // Read-only file
+	return matlab.LoadColumns(f, r.info.Name, r.cols...)
+}
+
+// flightInfo describes the flight of a request, whose ticket is its
+// command.
+func (s *Server) flightInfo(r *request) *arrowflight.FlightInfo {
+	command := r.info.Name
+	if r.spec != "" {
+		command += "?cols=" + url.QueryEscape(r.spec)
+	}
+	rows, cols := r.info.Dimensions[0], len(r.cols)
+	return &arrowflight.FlightInfo{
+		Schema:           arrowflight.SerializeSchema(r.schema(), s.mem),
+		FlightDescriptor: &arrowflight.FlightDescriptor{Type: arrowflight.DescriptorCMD, Cmd: []byte(command)},
+		Endpoint:         []*arrowflight.FlightEndpoint{{Ticket: &arrowflight.Ticket{Ticket: []byte(command)}}},
+		TotalRecords:     int64(rows),
+		TotalBytes:       int64(rows) * int64(cols) * int64(elementSize(r.info.DataType)),
+		Ordered:          true,
+	}
+}
+
+// schema returns the Arrow schema of the selected columns.
+func (r *request) schema() *arrow.Schema {
+	typ, _ := fieldType(r.info)
+	fields := make([]arrow.Field, len(r.cols))
+	for i, col := range r.cols {
+		fields[i] = arrow.Field{Name: "Var" + strconv.Itoa(col+1), Type: typ}
+	}
+	metadata := arrow.NewMetadata([]string{"matlab.name", "matlab.class"}, []string{r.info.Name, r.info.DataType.String()})
+	return arrow.NewSchema(fields, &metadata)
+}
+
+// fieldType returns the Arrow type of the columns of a variable, or an
+// error wrapping serve.ErrBadRequest if it cannot be served.
+func fieldType(info *types.VariableInfo) (arrow.DataType, error) {
+	if len(info.Dimensions) != 2 || info.IsComplex || info.IsSparse {
+		return nil, fmt.Errorf("%w: %s is not a real 2-D matrix", serve.ErrBadRequest, info.Name)
+	}
+	switch info.DataType {
+	case types.Double:
+		return arrow.PrimitiveTypes.Float64, nil
+	case types.Single:
+		return arrow.PrimitiveTypes.Float32, nil
+	case types.Int8:
+		return arrow.PrimitiveTypes.Int8, nil
+	case types.Uint8:
+		return arrow.PrimitiveTypes.Uint8, nil
+	case types.Int16:
+		return arrow.PrimitiveTypes.Int16, nil
+	case types.Uint16:
+		return arrow.PrimitiveTypes.Uint16, nil
+	case types.Int32:
+		return arrow.PrimitiveTypes.Int32, nil
+	case types.Uint32:
+		return arrow.PrimitiveTypes.Uint32, nil
+	case types.Int64:
+		return arrow.PrimitiveTypes.Int64, nil
+	case types.Uint64:
+		return arrow.PrimitiveTypes.Uint64, nil
+	case types.Logical:
+		return arrow.FixedWidthTypes.Boolean, nil
+	}
+	return nil, fmt.Errorf("%w: %s variable %s cannot be served", serve.ErrBadRequest, info.DataType, info.Name)
+}
+
+// elementSize returns the bytes of one element of a numeric or logical
+// class.
+func elementSize(class types.DataType) int {
+	switch class {
+	case types.Double, types.Int64, types.Uint64:
+		return 8
+	case types.Single, types.Int32, types.Uint32:
+		return 4
+	case types.Int16, types.Uint16:
+		return 2
+	}
+	return 1
+}
+
+// appendValues appends data[from:to] to the builder of a column.
+func appendValues(b array.Builder, data interface{}, from, to int) error {
+	switch d := data.(type) {
+	case []float64:
+		b.(*array.Float64Builder).AppendValues(d[from:to], nil)
+	case []float32:
+		b.(*array.Float32Builder).AppendValues(d[from:to], nil)
+	case []int8:
+		b.(*array.Int8Builder).AppendValues(d[from:to], nil)
+	case []uint8:
+		b.(*array.Uint8Builder).AppendValues(d[from:to], nil)
+	case []int16:
+		b.(*array.Int16Builder).AppendValues(d[from:to], nil)
+	case []uint16:
+		b.(*array.Uint16Builder).AppendValues(d[from:to], nil)
+	case []int32:
+		b.(*array.Int32Builder).AppendValues(d[from:to], nil)
+	case []uint32:
+		b.(*array.Uint32Builder).AppendValues(d[from:to], nil)
+	case []int64:
+		b.(*array.Int64Builder).AppendValues(d[from:to], nil)
+	case []uint64:
+		b.(*array.Uint64Builder).AppendValues(d[from:to], nil)
+	case []bool:
+		b.(*array.BooleanBuilder).AppendValues(d[from:to], nil)
+	default:
+		return fmt.Errorf("unexpected %T data", data)
+	}
+	return nil
+}
+
+// statusError converts err to a gRPC status with the code its kind calls
+// for.
+func statusError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, matlab.ErrVariableNotFound):
+		code = codes.NotFound
+	case errors.Is(err, serve.ErrBadRequest), errors.Is(err, matlab.ErrColumnOutOfRange):
+		code = codes.InvalidArgument
+	}
+	return status.Error(code, err.Error())
+}
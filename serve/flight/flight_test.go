@@ -0,0 +1,241 @@
+package flight
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	arrowflight "github.com/apache/arrow-go/v18/arrow/flight"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+// writeTestFile writes a v5 file with a 2x3 matrix X, a logical row
+// vector and a char row vector.
+func writeTestFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "served.mat")
+	w, err := matlab.Create(path, matlab.Version5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteVariables(
+		&types.Variable{Name: "X", Dimensions: []int{2, 3}, DataType: types.Double, Data: []float64{1, 2, 3, 4, 5, 6}},
+		&types.Variable{Name: "mask", Dimensions: []int{1, 2}, DataType: types.Logical, Data: []bool{true, false}},
+		&types.Variable{Name: "label", Dimensions: []int{1, 3}, DataType: types.Char, Data: "run"},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// startServer serves the file at path and returns a client for it.
+func startServer(t *testing.T, path string) arrowflight.Client {
+	t.Helper()
+	srv := arrowflight.NewServerWithMiddleware(nil)
+	if err := srv.Init("localhost:0"); err != nil {
+		t.Fatal(err)
+	}
+	srv.RegisterFlightService(NewServer(path))
+	go srv.Serve() //nolint:errcheck // Stopped by Shutdown
+	t.Cleanup(srv.Shutdown)
+
+	client, err := arrowflight.NewClientWithMiddleware(srv.Addr().String(), nil, nil,
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// doGet fetches the flight of ticket and returns its columns.
+func doGet(t *testing.T, client arrowflight.Client, ticket string) (*arrow.Schema, [][]interface{}, error) {
+	t.Helper()
+	stream, err := client.DoGet(context.Background(), &arrowflight.Ticket{Ticket: []byte(ticket)})
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := arrowflight.NewRecordReader(stream)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Release()
+
+	columns := make([][]interface{}, r.Schema().NumFields())
+	for r.Next() {
+		for i, col := range r.RecordBatch().Columns() {
+			for j := 0; j < col.Len(); j++ {
+				columns[i] = append(columns[i], col.GetOneForMarshal(j))
+			}
+		}
+	}
+	if err := r.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+	return r.Schema(), columns, nil
+}
+
+func TestServer_ListFlights(t *testing.T) {
+	client := startServer(t, writeTestFile(t))
+	stream, err := client.ListFlights(context.Background(), &arrowflight.Criteria{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tickets []string
+	for {
+		info, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		tickets = append(tickets, string(info.GetEndpoint()[0].GetTicket().GetTicket()))
+		if info.GetTotalRecords() != 1 && info.GetTotalRecords() != 2 {
+			t.Errorf("%s: total records = %d", tickets[len(tickets)-1], info.GetTotalRecords())
+		}
+	}
+	// label is a char array, which has no flight
+	if !reflect.DeepEqual(tickets, []string{"X", "mask"}) {
+		t.Errorf("tickets = %v, want [X mask]", tickets)
+	}
+}
+
+func TestServer_DoGet(t *testing.T) {
+	client := startServer(t, writeTestFile(t))
+
+	schema, columns, err := doGet(t, client, "X")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schema.Field(2); got.Name != "Var3" || got.Type.ID() != arrow.FLOAT64 {
+		t.Errorf("field 2 = %v", got)
+	}
+	if want := [][]interface{}{{1.0, 2.0}, {3.0, 4.0}, {5.0, 6.0}}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("X = %v, want %v", columns, want)
+	}
+
+	schema, columns, err = doGet(t, client, "X?cols=2,0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Field(0).Name != "Var3" || schema.Field(1).Name != "Var1" {
+		t.Errorf("schema = %v", schema)
+	}
+	if want := [][]interface{}{{5.0, 6.0}, {1.0, 2.0}}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("X?cols=2,0 = %v, want %v", columns, want)
+	}
+
+	_, columns, err = doGet(t, client, "mask")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]interface{}{{true}, {false}}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("mask = %v, want %v", columns, want)
+	}
+}
+
+func TestServer_GetFlightInfo(t *testing.T) {
+	client := startServer(t, writeTestFile(t))
+
+	info, err := client.GetFlightInfo(context.Background(), &arrowflight.FlightDescriptor{
+		Type: arrowflight.DescriptorCMD,
+		Cmd:  []byte("X?cols=1:3"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := arrowflight.DeserializeSchema(info.GetSchema(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.NumFields() != 2 || info.GetTotalRecords() != 2 || info.GetTotalBytes() != 32 {
+		t.Errorf("info = %v, schema = %v", info, schema)
+	}
+
+	info, err = client.GetFlightInfo(context.Background(), &arrowflight.FlightDescriptor{
+		Type: arrowflight.DescriptorPATH,
+		Path: []string{"X"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(info.GetEndpoint()[0].GetTicket().GetTicket()); got != "X" {
+		t.Errorf("ticket = %q, want X", got)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	client := startServer(t, writeTestFile(t))
+	tests := []struct {
+		ticket string
+		code   codes.Code
+	}{
+		{"missing", codes.NotFound},
+		{"missing?cols=0", codes.NotFound},
+		{"label", codes.InvalidArgument},
+		{"X?cols=0:9", codes.InvalidArgument},
+		{"X?cols=a", codes.InvalidArgument},
+		{"X?cols=7", codes.InvalidArgument},
+		{"X?cols=%zz", codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		if _, _, err := doGet(t, client, tt.ticket); status.Code(err) != tt.code {
+			t.Errorf("DoGet(%q) error = %v, want %v", tt.ticket, err, tt.code)
+		}
+	}
+}
+
+func TestServer_DoGet_Batches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "long.mat")
+	w, err := matlab.Create(path, matlab.Version5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]int32, 2*(BatchRows+1))
+	for i := range data {
+		data[i] = int32(i)
+	}
+	if err := w.WriteVariable(&types.Variable{Name: "n", Dimensions: []int{BatchRows + 1, 2}, DataType: types.Int32, Data: data}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	client := startServer(t, path)
+	stream, err := client.DoGet(context.Background(), &arrowflight.Ticket{Ticket: []byte("n?cols=1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := arrowflight.NewRecordReader(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Release()
+	var lengths []int64
+	var last int32
+	for r.Next() {
+		batch := r.RecordBatch()
+		lengths = append(lengths, batch.NumRows())
+		values := batch.Column(0).(*array.Int32).Int32Values()
+		last = values[len(values)-1]
+	}
+	if !reflect.DeepEqual(lengths, []int64{BatchRows, 1}) || last != data[len(data)-1] {
+		t.Errorf("batches of %v rows ending in %d, want [%d 1] ending in %d", lengths, last, BatchRows, data[len(data)-1])
+	}
+}
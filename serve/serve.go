@@ -0,0 +1,325 @@
+// Package serve exposes the variables of a MAT-file over HTTP, so remote
+// dashboards and notebooks can pull slices of large matrices without
+// copying whole files around.
+//
+// The handler answers two requests:
+//
+//	GET /variables          metadata of every variable, as JSON
+//	GET /variables/{name}   one variable, or some of its columns
+//
+// Variables are returned as JSON, with NaN and infinities as null, or with
+// ?format=raw as the little-endian bytes of the data in MATLAB's
+// column-major order (the real parts, then the imaginary parts of complex
+// arrays), described by the X-Matlab-Class, X-Matlab-Dimensions and
+// X-Matlab-Complex headers. ?cols=3:8 (zero-based, end excluded) or
+// ?cols=0,4,17 (at most MaxColumnList indices) selects columns of a 2-D
+// numeric or logical array; in v5 files only the selected columns are
+// read from disk (see matlab.LoadColumns). The serve/flight module serves
+// the same variables and selections over Arrow Flight. Whole variables
+// are read on first request and kept in a matlab.Cache.
+//
+// Example:
+//
+//	http.Handle("/results/", http.StripPrefix("/results", serve.NewHandler("results.mat")))
+//	log.Fatal(http.ListenAndServe(":8080", nil))
+//
+// and on the client:
+//
+//	curl 'localhost:8080/results/variables/X?cols=0:10&format=raw'
+package serve
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+// ErrBadRequest indicates a request with an invalid query, such as a
+// malformed column selection.
+var ErrBadRequest = errors.New("bad request")
+
+// MaxColumnList is the most column indices a comma-separated selection
+// may list. Indices may repeat, so without a limit a short file could be
+// made to produce an arbitrarily large response.
+const MaxColumnList = 1024
+
+// Handler serves the variables of one MAT-file.
+type Handler struct {
+	path  string
+	cache *matlab.Cache
+	mux   *http.ServeMux
+}
+
+// NewHandler returns a handler serving the variables of the MAT-file at
+// path. The options are used whenever the file is read; the file is not
+// read until the first request.
+func NewHandler(path string, opts ...matlab.OpenOption) *Handler {
+	h := &Handler{path: path, cache: matlab.NewCache(1, opts...), mux: http.NewServeMux()}
+	h.mux.HandleFunc("GET /variables", h.list)
+	h.mux.HandleFunc("GET /variables/{name}", h.variable)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// VariableInfo is the JSON description of a stored variable.
+type VariableInfo struct {
+	Name       string `json:"name"`
+	Class      string `json:"class"`
+	Dimensions []int  `json:"dimensions"`
+	Complex    bool   `json:"complex,omitempty"`
+	Sparse     bool   `json:"sparse,omitempty"`
+	Bytes      int64  `json:"bytes"` // Uncompressed size in the file
+}
+
+// Variable is the JSON form of a variable or of selected columns.
+type Variable struct {
+	Name       string      `json:"name"`
+	Class      string      `json:"class"`
+	Dimensions []int       `json:"dimensions"`
+	Real       interface{} `json:"real"`           // Column-major values
+	Imag       interface{} `json:"imag,omitempty"` // Imaginary parts of complex arrays
+}
+
+// list writes the metadata of the file's variables.
+func (h *Handler) list(w http.ResponseWriter, _ *http.Request) {
+	meta, err := h.cache.Metadata(h.path)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	infos := make([]VariableInfo, len(meta.Variables))
+	for i, v := range meta.Variables {
+		infos[i] = VariableInfo{
+			Name:       v.Name,
+			Class:      v.DataType.String(),
+			Dimensions: v.Dimensions,
+			Complex:    v.IsComplex,
+			Sparse:     v.IsSparse,
+			Bytes:      v.UncompressedSize,
+		}
+	}
+	writeJSON(w, infos)
+}
+
+// variable writes one variable or the selected columns of it.
+func (h *Handler) variable(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "raw" {
+		writeError(w, fmt.Errorf("%w: format %q, want json or raw", ErrBadRequest, format))
+		return
+	}
+
+	var v *types.Variable
+	var err error
+	if spec := query.Get("cols"); spec != "" {
+		v, err = h.columns(name, spec)
+	} else {
+		v, err = h.cache.Variable(h.path, name)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	re, im, err := parts(v)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if format == "raw" {
+		writeRaw(w, v, re, im)
+		return
+	}
+	out := Variable{Name: v.Name, Class: v.DataType.String(), Dimensions: v.Dimensions, Real: jsonValues(re)}
+	if im != nil {
+		out.Imag = jsonValues(im)
+	}
+	writeJSON(w, out)
+}
+
+// columns reads the columns of variable name selected by spec.
+func (h *Handler) columns(name, spec string) (*types.Variable, error) {
+	meta, err := h.cache.Metadata(h.path)
+	if err != nil {
+		return nil, err
+	}
+	var info *types.VariableInfo
+	for _, v := range meta.Variables {
+		if v.Name == name {
+			info = v
+			break
+		}
+	}
+	if info == nil {
+		return nil, fmt.Errorf("%w: %s", matlab.ErrVariableNotFound, name)
+	}
+	if len(info.Dimensions) != 2 {
+		return nil, fmt.Errorf("%w: cols needs a 2-D variable, %s is %v", ErrBadRequest, name, info.Dimensions)
+	}
+	cols, err := ParseColumns(spec, info.Dimensions[1])
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:gosec // G304: path is configured by the server, not the request
+	f, err := os.Open(h.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck // Read-only file
+	return matlab.LoadColumns(f, name, cols...)
+}
+
+// ParseColumns parses a column selection: a range "start:end" (end
+// excluded; either may be omitted) or a comma-separated list of at most
+// MaxColumnList indices, all zero-based, of a matrix with n columns.
+// Errors wrap ErrBadRequest; indices of a list are checked by the read.
+func ParseColumns(spec string, n int) ([]int, error) {
+	if start, end, ok := strings.Cut(spec, ":"); ok {
+		lo, hi := 0, n
+		var err error
+		if start != "" {
+			if lo, err = strconv.Atoi(start); err != nil {
+				return nil, fmt.Errorf("%w: cols %q: %w", ErrBadRequest, spec, err)
+			}
+		}
+		if end != "" {
+			if hi, err = strconv.Atoi(end); err != nil {
+				return nil, fmt.Errorf("%w: cols %q: %w", ErrBadRequest, spec, err)
+			}
+		}
+		if lo < 0 || hi > n || lo >= hi {
+			return nil, fmt.Errorf("%w: cols %q outside 0:%d or empty", ErrBadRequest, spec, n)
+		}
+		cols := make([]int, hi-lo)
+		for i := range cols {
+			cols[i] = lo + i
+		}
+		return cols, nil
+	}
+
+	if strings.Count(spec, ",") >= MaxColumnList {
+		return nil, fmt.Errorf("%w: cols lists more than %d columns", ErrBadRequest, MaxColumnList)
+	}
+	fields := strings.Split(spec, ",")
+	cols := make([]int, len(fields))
+	for i, field := range fields {
+		col, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("%w: cols %q: %w", ErrBadRequest, spec, err)
+		}
+		cols[i] = col
+	}
+	return cols, nil
+}
+
+// parts returns the real and imaginary data of a numeric, logical or char
+// variable; im is nil if the variable is real.
+func parts(v *types.Variable) (re, im interface{}, err error) {
+	switch data := v.Data.(type) {
+	case *types.NumericArray:
+		return data.Real, data.Imag, nil
+	case string:
+		return data, nil, nil
+	case *types.CharArray:
+		return string(data.Data), nil, nil
+	case []float64, []float32, []int8, []uint8, []int16, []uint16,
+		[]int32, []uint32, []int64, []uint64, []bool:
+		return data, nil, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %s variable %s cannot be served", ErrBadRequest, v.DataType, v.Name)
+}
+
+// jsonValues returns data with NaN and infinite floats replaced by nil,
+// which encoding/json rejects.
+func jsonValues(data interface{}) interface{} {
+	switch d := data.(type) {
+	case []float64:
+		return finiteOrNil(len(d), func(i int) float64 { return d[i] }, data)
+	case []float32:
+		return finiteOrNil(len(d), func(i int) float64 { return float64(d[i]) }, data)
+	case []uint8:
+		// encoding/json writes []byte as base64
+		values := make([]uint16, len(d))
+		for i, x := range d {
+			values[i] = uint16(x)
+		}
+		return values
+	}
+	return data
+}
+
+// finiteOrNil returns data unchanged if all n values at(i) are finite,
+// and otherwise the values with nil in place of the others.
+func finiteOrNil(n int, at func(int) float64, data interface{}) interface{} {
+	finite := true
+	for i := 0; i < n && finite; i++ {
+		finite = !math.IsNaN(at(i)) && !math.IsInf(at(i), 0)
+	}
+	if finite {
+		return data
+	}
+	values := make([]interface{}, n)
+	for i := range values {
+		if x := at(i); !math.IsNaN(x) && !math.IsInf(x, 0) {
+			values[i] = x
+		}
+	}
+	return values
+}
+
+// writeRaw writes the data of v as little-endian bytes.
+func writeRaw(w http.ResponseWriter, v *types.Variable, re, im interface{}) {
+	dims := make([]string, len(v.Dimensions))
+	for i, d := range v.Dimensions {
+		dims[i] = strconv.Itoa(d)
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("X-Matlab-Class", v.DataType.String())
+	w.Header().Set("X-Matlab-Dimensions", strings.Join(dims, "x"))
+	w.Header().Set("X-Matlab-Complex", strconv.FormatBool(im != nil))
+	for _, part := range []interface{}{re, im} {
+		switch data := part.(type) {
+		case nil:
+		case string:
+			_, _ = w.Write([]byte(data)) // UTF-8
+		default:
+			if err := binary.Write(w, binary.LittleEndian, data); err != nil {
+				return // Headers are sent; the client sees a short body
+			}
+		}
+	}
+}
+
+// writeJSON writes v as the JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err with the status code its kind calls for.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, matlab.ErrVariableNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrBadRequest), errors.Is(err, matlab.ErrColumnOutOfRange):
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
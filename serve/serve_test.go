@@ -0,0 +1,161 @@
+package serve
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab"
+	"github.com/scigolib/matlab/types"
+)
+
+// writeTestFile writes a v5 file with a 2x3 matrix X holding a NaN, and
+// a char row vector.
+func writeTestFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "served.mat")
+	w, err := matlab.Create(path, matlab.Version5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteVariables(
+		&types.Variable{Name: "X", Dimensions: []int{2, 3}, DataType: types.Double, Data: []float64{1, 2, 3, math.NaN(), 5, 6}},
+		&types.Variable{Name: "label", Dimensions: []int{1, 3}, DataType: types.Char, Data: "run"},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// get serves a GET request for target.
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestHandler_List(t *testing.T) {
+	h := NewHandler(writeTestFile(t))
+	rec := get(h, "/variables")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var infos []VariableInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &infos); err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 || infos[0].Name != "X" || infos[0].Class != "double" || !reflect.DeepEqual(infos[0].Dimensions, []int{2, 3}) {
+		t.Errorf("infos = %+v", infos)
+	}
+}
+
+func TestHandler_Variable(t *testing.T) {
+	h := NewHandler(writeTestFile(t))
+
+	rec := get(h, "/variables/X")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var v struct {
+		Dimensions []int      `json:"dimensions"`
+		Real       []*float64 `json:"real"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Real) != 6 || v.Real[3] != nil || *v.Real[4] != 5 {
+		t.Errorf("real = %v, want NaN as null", v.Real)
+	}
+
+	rec = get(h, "/variables/label")
+	if rec.Code != http.StatusOK || !json.Valid(rec.Body.Bytes()) {
+		t.Errorf("label: status = %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestHandler_Columns(t *testing.T) {
+	h := NewHandler(writeTestFile(t))
+
+	rec := get(h, "/variables/X?cols=1:3&format=raw")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("X-Matlab-Dimensions"); got != "2x2" {
+		t.Errorf("X-Matlab-Dimensions = %q, want 2x2", got)
+	}
+	if got := rec.Header().Get("X-Matlab-Class"); got != "double" {
+		t.Errorf("X-Matlab-Class = %q, want double", got)
+	}
+	data := make([]float64, 4)
+	if err := binary.Read(rec.Body, binary.LittleEndian, data); err != nil {
+		t.Fatal(err)
+	}
+	if data[0] != 3 || !math.IsNaN(data[1]) || data[2] != 5 || data[3] != 6 {
+		t.Errorf("data = %v, want columns 1 and 2 of X", data)
+	}
+
+	rec = get(h, "/variables/X?cols=2,0")
+	var v Variable
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v.Real, []interface{}{5.0, 6.0, 1.0, 2.0}) {
+		t.Errorf("cols=2,0: real = %v", v.Real)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	h := NewHandler(writeTestFile(t))
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/variables/missing", http.StatusNotFound},
+		{"/variables/missing?cols=0", http.StatusNotFound},
+		{"/variables/X?cols=0:9", http.StatusBadRequest},
+		{"/variables/X?cols=a", http.StatusBadRequest},
+		{"/variables/X?cols=7", http.StatusBadRequest},
+		{"/variables/X?format=csv", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := get(h, tt.target); rec.Code != tt.status {
+			t.Errorf("GET %s: status = %d, want %d (%s)", tt.target, rec.Code, tt.status, rec.Body)
+		}
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []int
+	}{
+		{"1:3", []int{1, 2}},
+		{":2", []int{0, 1}},
+		{"3:", []int{3, 4}},
+		{"4, 0", []int{4, 0}},
+	}
+	for _, tt := range tests {
+		got, err := ParseColumns(tt.spec, 5)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseColumns(%q) = %v, %v, want %v", tt.spec, got, err, tt.want)
+		}
+	}
+
+	long := strings.Repeat("0,", MaxColumnList) + "0"
+	if _, err := ParseColumns(long, 5); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("ParseColumns(%d indices) error = %v, want ErrBadRequest", MaxColumnList+1, err)
+	}
+	if got, err := ParseColumns(long[2:], 5); err != nil || len(got) != MaxColumnList {
+		t.Errorf("ParseColumns(%d indices) = %d columns, %v", MaxColumnList, len(got), err)
+	}
+}
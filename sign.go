@@ -0,0 +1,258 @@
+package matlab
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/scigolib/matlab/internal/v5"
+	"github.com/scigolib/matlab/types"
+)
+
+// SignatureVariable is the name of the variable holding the signature of
+// a file written with WithSignature.
+const SignatureVariable = "__signature__"
+
+// ErrNotSigned indicates a file without a signature variable.
+var ErrNotSigned = errors.New("MAT-file is not signed")
+
+// ErrSignatureInvalid indicates a signature that does not match the file
+// contents or the public key.
+var ErrSignatureInvalid = errors.New("MAT-file signature is invalid")
+
+// signatureOptions selects Ed25519ph, so files are signed and verified
+// from their SHA-512 digest without holding them in memory.
+var signatureOptions = &ed25519.Options{Hash: crypto.SHA512, Context: "scigolib/matlab MAT-file signature"}
+
+// Verify checks the signature of a MAT-file written with WithSignature
+// against the signer's public key. It returns nil if the file is
+// unmodified and was signed with the matching private key, an error
+// wrapping ErrSignatureInvalid if not, and ErrNotSigned for files without
+// a signature.
+//
+// The signed message is the whole file with the 64 bytes of the signature
+// set to zero, so every byte, the signature variable's own header
+// included, is authenticated.
+//
+// Example:
+//
+//	if err := matlab.Verify("calibration.mat", vendorKey); err != nil {
+//	    log.Fatalf("untrusted calibration file: %v", err)
+//	}
+func Verify(path string, pub ed25519.PublicKey) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("public key has %d bytes, want %d", len(pub), ed25519.PublicKeySize)
+	}
+	sig, err := readSignature(path)
+	if err != nil {
+		return err
+	}
+	//nolint:gosec // G304: path is provided by the caller
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck // Read-only file
+
+	offset, err := findUnique(f, sig)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSignatureInvalid, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	digest, err := zeroedDigest(f, offset)
+	if err != nil {
+		return err
+	}
+	if err := ed25519.VerifyWithOptions(pub, digest, sig, signatureOptions); err != nil {
+		return fmt.Errorf("%w: %w", ErrSignatureInvalid, err)
+	}
+	return nil
+}
+
+// readSignature returns the signature variable of the file at path.
+func readSignature(path string) ([]byte, error) {
+	//nolint:gosec // G304: path is provided by the caller
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck // Read-only file
+	matFile, err := Open(f)
+	if err != nil {
+		return nil, err
+	}
+	v := matFile.GetVariable(SignatureVariable)
+	if v == nil {
+		return nil, ErrNotSigned
+	}
+	sig, ok := v.Data.([]uint8)
+	if !ok || len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w: %s is not %d uint8 values", ErrSignatureInvalid, SignatureVariable, ed25519.SignatureSize)
+	}
+	return sig, nil
+}
+
+// signatureVar returns the signature variable holding sig.
+func signatureVar(sig []byte) *types.Variable {
+	return &types.Variable{
+		Name:       SignatureVariable,
+		Dimensions: []int{1, ed25519.SignatureSize},
+		DataType:   types.Uint8,
+		Data:       sig,
+	}
+}
+
+// signer signs a file as it is written.
+type signer struct {
+	key    ed25519.PrivateKey
+	digest hash.Hash // v5: the bytes written so far
+	endian string    // v5: endian indicator of the file
+}
+
+// mark returns the state of the digest, to rewind it to when bytes
+// written after it are removed from the file again.
+func (s *signer) mark() ([]byte, error) {
+	state, ok := s.digest.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, errors.New("digest state cannot be saved")
+	}
+	return state.MarshalBinary()
+}
+
+// rewind restores the digest to a state returned by mark.
+func (s *signer) rewind(state []byte) error {
+	digest, ok := s.digest.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return errors.New("digest state cannot be restored")
+	}
+	return digest.UnmarshalBinary(state)
+}
+
+// signV5 appends the signature element to a v5 file: the element is
+// encoded with a zero signature to complete the digest, then written with
+// the signature of the digest.
+func (s *signer) signV5(w *v5.Writer) error {
+	element, err := encodeV5Element(signatureVar(make([]byte, ed25519.SignatureSize)), s.endian, false)
+	if err != nil {
+		return err
+	}
+	s.digest.Write(element)
+	sig, err := s.key.Sign(rand.Reader, s.digest.Sum(nil), signatureOptions)
+	if err != nil {
+		return err
+	}
+
+	// Uncompressed, so the signature bytes appear in the file as Verify
+	// expects
+	compression := w.Compression
+	w.Compression = 0
+	defer func() { w.Compression = compression }()
+	return w.WriteVariable(signatureVar(sig))
+}
+
+// newPlaceholder returns random bytes to write as the signature of a v7.3
+// file, which signFile replaces once the HDF5 library has closed it.
+func newPlaceholder() ([]byte, error) {
+	placeholder := make([]byte, ed25519.SignatureSize)
+	if _, err := rand.Read(placeholder); err != nil {
+		return nil, err
+	}
+	return placeholder, nil
+}
+
+// signFile replaces the placeholder in the file at path with the
+// signature of the file with the placeholder set to zero.
+func (s *signer) signFile(path string, placeholder []byte) error {
+	//nolint:gosec // G304: path is the file just written
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck // Closed explicitly below
+
+	offset, err := findUnique(f, placeholder)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	digest, err := zeroedDigest(f, offset)
+	if err != nil {
+		return err
+	}
+	sig, err := s.key.Sign(rand.Reader, digest, signatureOptions)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(sig, offset); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// findUnique returns the offset of the only occurrence of pattern in r,
+// reading it once.
+func findUnique(r io.Reader, pattern []byte) (int64, error) {
+	br := bufio.NewReaderSize(r, 1<<20)
+	buf := make([]byte, 0, 1<<20+len(pattern))
+	var base int64 // Offset of buf[0]
+	offset := int64(-1)
+	for {
+		n, err := io.ReadFull(br, buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		for i := 0; ; {
+			j := bytes.Index(buf[i:], pattern)
+			if j < 0 {
+				break
+			}
+			if offset >= 0 {
+				return 0, errors.New("signature bytes occur more than once")
+			}
+			offset = base + int64(i+j)
+			i += j + 1
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		// Keep the tail, which may hold the start of a match
+		keep := min(len(buf), len(pattern)-1)
+		base += int64(len(buf) - keep)
+		buf = append(buf[:0], buf[len(buf)-keep:]...)
+	}
+	if offset < 0 {
+		return 0, errors.New("signature bytes not found")
+	}
+	return offset, nil
+}
+
+// zeroedDigest returns the SHA-512 digest of r with the signature at
+// offset set to zero.
+func zeroedDigest(r io.Reader, offset int64) ([]byte, error) {
+	digest := sha512.New()
+	if _, err := io.CopyN(digest, r, offset); err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, r, ed25519.SignatureSize); err != nil {
+		return nil, err
+	}
+	digest.Write(make([]byte, ed25519.SignatureSize))
+	if _, err := io.Copy(digest, r); err != nil {
+		return nil, err
+	}
+	return digest.Sum(nil), nil
+}
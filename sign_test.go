@@ -0,0 +1,157 @@
+package matlab
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	gain := &types.Variable{Name: "gain", Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{1.5, 2, 2.5}}
+
+	for _, tt := range []struct {
+		name    string
+		version Version
+		opts    []Option
+	}{
+		{"v5", Version5, nil},
+		{"v5 compressed", Version5, []Option{WithCompression(6), WithEndianness(binary.BigEndian)}},
+		{"v7.3", Version73, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cal.mat")
+			writer, err := Create(path, tt.version, append(tt.opts, WithSignature(priv))...)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if err := writer.WriteVariable(gain); err != nil {
+				t.Fatalf("WriteVariable() error = %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			if err := Verify(path, pub); err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if err := Verify(path, otherPub); !errors.Is(err, ErrSignatureInvalid) {
+				t.Errorf("Verify(other key) error = %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Flip a bit of the description (v5) or of the HDF5 superblock
+			// area, leaving the file readable
+			data[20] ^= 1
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			err = Verify(path, pub)
+			if err == nil || (tt.version == Version5 && !errors.Is(err, ErrSignatureInvalid)) {
+				t.Errorf("Verify(modified) error = %v", err)
+			}
+		})
+	}
+}
+
+// cancelAfter is a context canceled once its Err method has been called
+// n times.
+type cancelAfter struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.n--; c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+// TestSignature_RolledBackBatch tests that a batch dropped from a signed
+// file after a cancel mid-write is dropped from the signed digest too.
+func TestSignature_RolledBackBatch(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "signed.mat")
+	ctx := &cancelAfter{Context: context.Background(), n: 1 << 30}
+	writer, err := Create(path, Version5, WithSignature(priv), WithContext(ctx), WithChunkSize(512))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	big := func(name string) *types.Variable {
+		return &types.Variable{Name: name, Dimensions: []int{1, 1000}, DataType: types.Double, Data: make([]float64, 1000)}
+	}
+	if err := writer.WriteVariable(big("a")); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	ctx.n = 20 // b is written in 16 chunks, c is not
+	if err := writer.WriteVariables(big("b"), big("c")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("WriteVariables() error = %v, want context.Canceled", err)
+	}
+	ctx.n = 1 << 30
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := Verify(path, pub); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if names := fileVariables(t, path); len(names) != 2 || names[0] != "a" {
+		t.Errorf("variables = %v, want a and the signature", names)
+	}
+}
+
+func TestSignature_Errors(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "plain.mat")
+	writer, err := Create(path, Version5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteVariable(&types.Variable{Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(path, pub); !errors.Is(err, ErrNotSigned) {
+		t.Errorf("Verify(unsigned) error = %v", err)
+	}
+
+	writer, err = Create(filepath.Join(dir, "clash.mat"), Version5, WithSignature(priv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteVariable(signatureVar(make([]byte, 64))); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); !errors.Is(err, ErrDuplicateVariable) {
+		t.Errorf("Close() with %s written error = %v", SignatureVariable, err)
+	}
+
+	if _, err := Create(filepath.Join(dir, "both.mat"), Version5, WithSignature(priv), WithEncryption(make([]byte, 32))); err == nil {
+		t.Error("Create(signature and encryption) error = nil")
+	}
+	if _, err := NewWriter(&bytes.Buffer{}, WithSignature(priv[:10])); err == nil {
+		t.Error("NewWriter(short key) error = nil")
+	}
+}
@@ -0,0 +1,318 @@
+package matlab
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// WithSimulinkSignals converts the logs Simulink saves into plain structs
+// of numeric signals, as SimulinkSignals does, for each top-level
+// variable as it is read. Other variables are not changed.
+//
+// Example:
+//
+//	matFile, err := matlab.Open(file, matlab.WithSimulinkSignals())
+//	logsout := matFile.GetVariable("logsout") // logsout.speed.time, logsout.speed.data
+func WithSimulinkSignals() OpenOption {
+	return func(c *openConfig) {
+		c.transforms = append(c.transforms, func(v *types.Variable) (*types.Variable, error) {
+			if converted, ok := SimulinkSignals(v); ok {
+				return converted, nil
+			}
+			return v, nil
+		})
+	}
+}
+
+// SimulinkSignals extracts the numeric signals and time vectors of a
+// Simulink log into nested 1x1 structs, on a best-effort basis. It
+// recognizes, also as struct fields at any depth:
+//
+//   - Signals logged in the Structure with time format (a struct with a
+//     signals struct array whose elements hold values, and a time field):
+//     a struct with a time field, if the log has one, and one field per
+//     signal holding its values.
+//   - timeseries objects: a struct with the fields time and data.
+//   - Simulink.SimulationData.Dataset objects (logsout): a struct with one
+//     field per element, holding the element's timeseries as above, or a
+//     struct of them for a bus.
+//
+// Signals are named by their label, or their name for Dataset elements,
+// falling back to the last part of the block path and then to signal1,
+// signal2 and so on. Names are made valid MATLAB identifiers (invalid
+// characters replaced by '_', an 'x' prepended if they do not start with
+// a letter) and made unique with suffixes _2, _3 and so on.
+//
+// Objects are only recognized when they are stored as structs of their
+// properties: in v7.3 files, or v5 files written by releases that saved
+// these classes in the older object format. Newer releases save them as
+// classdef objects whose properties the library does not decode.
+//
+// SimulinkSignals returns the converted variable, with the name of v, and
+// true, or v and false if it holds no recognized log. v is not modified.
+//
+// Example:
+//
+//	if signals, ok := matlab.SimulinkSignals(matFile.GetVariable("yout")); ok {
+//	    speed := signals.Data.(*types.StructArray).Field(0, "speed")
+//	    fmt.Println(speed.Dimensions)
+//	}
+func SimulinkSignals(v *types.Variable) (*types.Variable, bool) {
+	if v == nil {
+		return v, false
+	}
+	converted, ok := simulinkValue(v)
+	if !ok {
+		return v, false
+	}
+	converted.Name = v.Name
+	return converted, true
+}
+
+// simulinkValue converts v if it is a recognized log, or a struct holding
+// one at any depth.
+func simulinkValue(v *types.Variable) (*types.Variable, bool) {
+	switch class := v.ClassName(); {
+	case class == "timeseries":
+		return simulinkTimeseries(v)
+	case strings.HasPrefix(class, "Simulink.SimulationData.Dataset"):
+		return simulinkDataset(v)
+	}
+	if out, ok := simulinkStructureWithTime(v); ok {
+		return out, true
+	}
+
+	// Logs saved inside other structs, such as a SimulationOutput
+	st, ok := v.Data.(*types.StructArray)
+	if !ok || v.DataType != types.Struct || len(st.Elements) != 1 {
+		return nil, false
+	}
+	var elements []*types.Variable
+	changed := false
+	for _, field := range st.Elements[0] {
+		if field != nil {
+			if converted, ok := simulinkValue(field); ok {
+				converted.Name = field.Name
+				field = converted
+				changed = true
+			}
+		}
+		elements = append(elements, field)
+	}
+	if !changed {
+		return nil, false
+	}
+	out := *v
+	out.Data = &types.StructArray{
+		Fields:     st.Fields,
+		Elements:   [][]*types.Variable{elements},
+		Dimensions: st.Dimensions,
+	}
+	return &out, true
+}
+
+// simulinkTimeseries converts a timeseries object to a struct with the
+// fields time and data.
+func simulinkTimeseries(v *types.Variable) (*types.Variable, bool) {
+	data := structField(v, "Data")
+	if data == nil {
+		return nil, false
+	}
+	var sig signalStruct
+	if t := structField(v, "Time"); t != nil {
+		sig.add("time", t)
+	}
+	sig.add("data", data)
+	return sig.variable(), true
+}
+
+// simulinkDataset converts a Dataset object to a struct with one field
+// per element.
+func simulinkDataset(v *types.Variable) (*types.Variable, bool) {
+	var elements []*types.Variable
+	for _, name := range []string{"Elements", "Storage_", "Storage"} {
+		if field := structField(v, name); field != nil {
+			elements = structElements(field)
+			if len(elements) > 0 {
+				break
+			}
+		}
+	}
+	var sig signalStruct
+	for i, element := range elements {
+		values := structField(element, "Values")
+		if values == nil {
+			continue
+		}
+		converted, ok := simulinkValue(values)
+		if !ok {
+			if !isNumeric(values) {
+				continue
+			}
+			converted = values
+		}
+		sig.add(signalName(i, structString(element, "Name"), structString(element, "BlockPath")), converted)
+	}
+	if len(sig.fields) == 0 {
+		return nil, false
+	}
+	return sig.variable(), true
+}
+
+// simulinkStructureWithTime converts signals logged in the Structure with
+// time or Structure format.
+func simulinkStructureWithTime(v *types.Variable) (*types.Variable, bool) {
+	signals := structField(v, "signals")
+	if signals == nil {
+		return nil, false
+	}
+	var sig signalStruct
+	if t := structField(v, "time"); t != nil && isNumeric(t) && len(t.Dimensions) > 0 && t.Dimensions[0] > 0 {
+		sig.add("time", t)
+	}
+	for i, signal := range structElements(signals) {
+		values := structField(signal, "values")
+		if values == nil || !isNumeric(values) {
+			continue
+		}
+		sig.add(signalName(i, structString(signal, "label"), structString(signal, "blockName")), values)
+	}
+	if len(sig.fields) == 0 || (len(sig.fields) == 1 && sig.fields[0] == "time") {
+		return nil, false
+	}
+	return sig.variable(), true
+}
+
+// signalStruct builds a 1x1 struct with unique, valid field names.
+type signalStruct struct {
+	fields []string
+	values []*types.Variable
+	seen   map[string]bool
+}
+
+// add appends a field named after name, made a valid and unique MATLAB
+// identifier.
+func (s *signalStruct) add(name string, value *types.Variable) {
+	if s.seen == nil {
+		s.seen = make(map[string]bool)
+	}
+	base := validFieldName(name)
+	name = base
+	for n := 2; s.seen[name]; n++ {
+		name = fmt.Sprintf("%s_%d", base, n)
+	}
+	s.seen[name] = true
+	field := *value
+	field.Name = name
+	s.fields = append(s.fields, name)
+	s.values = append(s.values, &field)
+}
+
+// variable returns the struct built by s.
+func (s *signalStruct) variable() *types.Variable {
+	return &types.Variable{
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     s.fields,
+			Elements:   [][]*types.Variable{s.values},
+			Dimensions: []int{1, 1},
+		},
+	}
+}
+
+// signalName returns the name of the i-th signal: its label, or the last
+// part of its block path, or signalN.
+func signalName(i int, label, blockPath string) string {
+	if label = strings.TrimSpace(label); label != "" {
+		return label
+	}
+	if blockPath = strings.TrimSpace(blockPath); blockPath != "" {
+		return blockPath[strings.LastIndex(blockPath, "/")+1:]
+	}
+	return fmt.Sprintf("signal%d", i+1)
+}
+
+// validFieldName makes name a valid MATLAB identifier, as
+// matlab.lang.makeValidName does for common cases.
+func validFieldName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r < 128 && (r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	valid := b.String()
+	if valid == "" || !(valid[0] >= 'a' && valid[0] <= 'z' || valid[0] >= 'A' && valid[0] <= 'Z') {
+		valid = "x" + valid
+	}
+	if len(valid) > types.MaxNameLength {
+		valid = valid[:types.MaxNameLength]
+	}
+	return valid
+}
+
+// structField returns the field name of the 1x1 struct v, or nil.
+func structField(v *types.Variable, name string) *types.Variable {
+	st, ok := v.Data.(*types.StructArray)
+	if !ok || len(st.Elements) != 1 {
+		return nil
+	}
+	return st.Field(0, name)
+}
+
+// structString returns the char field name of the 1x1 struct v, or "".
+func structString(v *types.Variable, name string) string {
+	field := structField(v, name)
+	if field == nil {
+		return ""
+	}
+	switch data := field.Data.(type) {
+	case string:
+		return data
+	case *types.CharArray:
+		return string(data.Data)
+	}
+	return ""
+}
+
+// structElements returns the elements of a struct array, or of a cell
+// array holding 1x1 structs, each as a 1x1 struct.
+func structElements(v *types.Variable) []*types.Variable {
+	switch data := v.Data.(type) {
+	case *types.StructArray:
+		elements := make([]*types.Variable, len(data.Elements))
+		for i, element := range data.Elements {
+			elements[i] = &types.Variable{
+				Dimensions: []int{1, 1},
+				DataType:   types.Struct,
+				Data: &types.StructArray{
+					Fields:     data.Fields,
+					Elements:   [][]*types.Variable{element},
+					Dimensions: []int{1, 1},
+				},
+			}
+		}
+		return elements
+	case []*types.Variable:
+		var elements []*types.Variable
+		for _, cell := range data {
+			if cell != nil && cell.DataType == types.Struct {
+				elements = append(elements, cell)
+			}
+		}
+		return elements
+	}
+	return nil
+}
+
+// isNumeric reports whether v holds real or complex numeric or logical
+// data.
+func isNumeric(v *types.Variable) bool {
+	kind := v.Kind()
+	return kind == types.KindNumeric || kind == types.KindLogical
+}
@@ -0,0 +1,159 @@
+package matlab
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// simStruct returns a 1x1 struct of the given fields and values, with
+// class as its MATLAB_class attribute unless it is "".
+func simStruct(name, class string, fields []string, values ...*types.Variable) *types.Variable {
+	v := &types.Variable{
+		Name:       name,
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data:       &types.StructArray{Fields: fields, Elements: [][]*types.Variable{values}, Dimensions: []int{1, 1}},
+	}
+	if class != "" {
+		v.Attributes = map[string]interface{}{types.AttrMatlabClass: class}
+	}
+	return v
+}
+
+func simDouble(values ...float64) *types.Variable {
+	return &types.Variable{Dimensions: []int{len(values), 1}, DataType: types.Double, Data: values}
+}
+
+func simChar(s string) *types.Variable {
+	return &types.Variable{Dimensions: []int{1, len(s)}, DataType: types.Char, Data: s}
+}
+
+// fieldData returns the data of the field at path (field names) of the
+// struct v, failing the test if there is none.
+func fieldData(t *testing.T, v *types.Variable, path ...string) interface{} {
+	t.Helper()
+	for _, name := range path {
+		st, ok := v.Data.(*types.StructArray)
+		if !ok {
+			t.Fatalf("%s is %T, not a struct", v.Name, v.Data)
+		}
+		if v = st.Field(0, name); v == nil {
+			t.Fatalf("no field %s in %v", name, st.Fields)
+		}
+	}
+	return v.Data
+}
+
+func TestSimulinkSignals_StructureWithTime(t *testing.T) {
+	// 1x3 signals struct array: labelled, named by block, unnamed
+	signals := &types.Variable{
+		Dimensions: []int{1, 3},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields: []string{"values", "label", "blockName"},
+			Elements: [][]*types.Variable{
+				{simDouble(1, 2), simChar("motor speed"), simChar("model/Motor")},
+				{simDouble(3, 4), simChar(""), simChar("model/Sub/Torque")},
+				{simDouble(5, 6), simChar(""), simChar("")},
+			},
+			Dimensions: []int{1, 3},
+		},
+	}
+	yout := simStruct("yout", "", []string{"time", "signals", "blockName"}, simDouble(0, 0.1), signals, simChar("model"))
+
+	got, ok := SimulinkSignals(yout)
+	if !ok {
+		t.Fatal("SimulinkSignals() not recognized")
+	}
+	if got.Name != "yout" {
+		t.Errorf("Name = %q, want yout", got.Name)
+	}
+	st := got.Data.(*types.StructArray)
+	if want := []string{"time", "motor_speed", "Torque", "signal3"}; !reflect.DeepEqual(st.Fields, want) {
+		t.Errorf("fields = %v, want %v", st.Fields, want)
+	}
+	if data := fieldData(t, got, "Torque"); !reflect.DeepEqual(data, []float64{3, 4}) {
+		t.Errorf("Torque = %v, want [3 4]", data)
+	}
+	if _, ok := yout.Data.(*types.StructArray); !ok || yout.Data.(*types.StructArray).Fields[1] != "signals" {
+		t.Error("SimulinkSignals() modified its argument")
+	}
+}
+
+func TestSimulinkSignals_Dataset(t *testing.T) {
+	ts := func(data ...float64) *types.Variable {
+		return simStruct("", "timeseries", []string{"Name", "Time", "Data"}, simChar("ts"), simDouble(0, 1), simDouble(data...))
+	}
+	element := func(name string, values *types.Variable) *types.Variable {
+		return simStruct("", "Simulink.SimulationData.Signal", []string{"Name", "BlockPath", "Values"}, simChar(name), simChar("m/b"), values)
+	}
+	bus := simStruct("", "", []string{"a", "b"}, ts(5, 6), ts(7, 8))
+	elements := &types.Variable{
+		Dimensions: []int{1, 4},
+		DataType:   types.CellArray,
+		Data:       []*types.Variable{element("speed", ts(1, 2)), element("speed", ts(3, 4)), element("", bus), element("x", simChar("no signal"))},
+	}
+	logsout := simStruct("logsout", "Simulink.SimulationData.Dataset", []string{"Name", "Elements"}, simChar("logsout"), elements)
+	out := simStruct("out", "Simulink.SimulationOutput", []string{"tout", "logsout"}, simDouble(0, 1), logsout)
+
+	got, ok := SimulinkSignals(out)
+	if !ok {
+		t.Fatal("SimulinkSignals() not recognized")
+	}
+	if data := fieldData(t, got, "tout"); !reflect.DeepEqual(data, []float64{0, 1}) {
+		t.Errorf("tout = %v, want [0 1]", data)
+	}
+	if want := []string{"speed", "speed_2", "b"}; !reflect.DeepEqual(got.Data.(*types.StructArray).Field(0, "logsout").Data.(*types.StructArray).Fields, want) {
+		t.Errorf("logsout fields = %v, want %v", got.Data.(*types.StructArray).Field(0, "logsout").Data.(*types.StructArray).Fields, want)
+	}
+	if data := fieldData(t, got, "logsout", "speed_2", "data"); !reflect.DeepEqual(data, []float64{3, 4}) {
+		t.Errorf("logsout.speed_2.data = %v, want [3 4]", data)
+	}
+	if data := fieldData(t, got, "logsout", "b", "b", "time"); !reflect.DeepEqual(data, []float64{0, 1}) {
+		t.Errorf("logsout.b.b.time = %v, want [0 1]", data)
+	}
+
+	plain := simStruct("p", "", []string{"x"}, simDouble(1))
+	if v, ok := SimulinkSignals(plain); ok || v != plain {
+		t.Error("SimulinkSignals(plain struct) recognized")
+	}
+}
+
+func TestWithSimulinkSignals(t *testing.T) {
+	// v7.3 files store timeseries objects as structs of their properties
+	path := filepath.Join(t.TempDir(), "sim.mat")
+	w, err := Create(path, Version73, WithObjectClasses())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	ts := simStruct("ts", "timeseries", []string{"Time", "Data"}, simDouble(0, 0.5, 1), simDouble(2, 4, 8))
+	x := simDouble(1)
+	x.Name = "x"
+	if err := w.WriteVariables(ts, x); err != nil {
+		t.Fatalf("WriteVariables() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	matFile, err := openPath(path, []OpenOption{WithSimulinkSignals()})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	got := matFile.GetVariable("ts")
+	if got == nil {
+		t.Fatal("variable ts not found")
+	}
+	if data := fieldData(t, got, "data"); !reflect.DeepEqual(data, []float64{2, 4, 8}) {
+		t.Errorf("ts.data = %v, want [2 4 8]", data)
+	}
+	if data := fieldData(t, got, "time"); !reflect.DeepEqual(data, []float64{0, 0.5, 1}) {
+		t.Errorf("ts.time = %v, want [0 0.5 1]", data)
+	}
+	if v := matFile.GetVariable("x"); v == nil || v.DataType != types.Double {
+		t.Errorf("x = %v, want the double unchanged", v)
+	}
+}
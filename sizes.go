@@ -0,0 +1,103 @@
+package matlab
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// SizeReport summarizes the space each variable takes in a MAT-file, to
+// find the variables that dominate the file size.
+type SizeReport struct {
+	Variables    []*types.VariableInfo // Largest stored size first
+	Stored       int64                 // Total bytes stored in the file
+	Uncompressed int64                 // Total bytes without compression
+}
+
+// VariableSizes returns how each variable is stored in the file, in file
+// order: its stored and uncompressed sizes and whether it is compressed.
+// v5 variables are compressed as a whole (miCOMPRESSED); v7.3 variables
+// are compressed by HDF5 filters on chunked datasets.
+//
+// The sizes describe the file as read, so variables dropped or renamed by
+// WithTransform are reported under their stored names.
+//
+// Example:
+//
+//	for _, v := range matFile.VariableSizes() {
+//	    fmt.Printf("%s: %d bytes stored, %d uncompressed\n", v.Name, v.Size, v.UncompressedSize)
+//	}
+func (m *MatFile) VariableSizes() []*types.VariableInfo {
+	return m.storage
+}
+
+// SizeReport returns the variables of the file ordered by stored size,
+// with totals. Print it for a table with compression ratios and each
+// variable's share of the file.
+//
+// Example:
+//
+//	fmt.Print(matFile.SizeReport())
+func (m *MatFile) SizeReport() *SizeReport {
+	return newSizeReport(m.storage)
+}
+
+// SizeReport returns the variables ordered by stored size, with totals,
+// like MatFile.SizeReport but without loading any data.
+//
+// Example:
+//
+//	meta, _ := matlab.OpenMetadata(file)
+//	fmt.Print(meta.SizeReport())
+func (m *Metadata) SizeReport() *SizeReport {
+	return newSizeReport(m.Variables)
+}
+
+// newSizeReport sorts a copy of infos by stored size and sums the sizes.
+func newSizeReport(infos []*types.VariableInfo) *SizeReport {
+	report := &SizeReport{Variables: make([]*types.VariableInfo, len(infos))}
+	copy(report.Variables, infos)
+	sort.SliceStable(report.Variables, func(i, j int) bool {
+		return report.Variables[i].Size > report.Variables[j].Size
+	})
+	for _, info := range infos {
+		report.Stored += info.Size
+		report.Uncompressed += info.UncompressedSize
+	}
+	return report
+}
+
+// Ratio returns the overall compression ratio, uncompressed bytes per
+// stored byte, or 1 if nothing is stored.
+func (r *SizeReport) Ratio() float64 {
+	return ratio(r.Uncompressed, r.Stored)
+}
+
+// String renders the report as a table, one variable per line.
+func (r *SizeReport) String() string {
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tCLASS\tSTORED\tUNCOMPRESSED\tRATIO\tSHARE\t")
+	for _, v := range r.Variables {
+		share := 0.0
+		if r.Stored > 0 {
+			share = 100 * float64(v.Size) / float64(r.Stored)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.2f\t%.1f%%\t\n",
+			v.Name, v.DataType, v.Size, v.UncompressedSize, ratio(v.UncompressedSize, v.Size), share)
+	}
+	fmt.Fprintf(tw, "total\t\t%d\t%d\t%.2f\t\t\n", r.Stored, r.Uncompressed, r.Ratio())
+	_ = tw.Flush()
+	return sb.String()
+}
+
+// ratio returns uncompressed/stored, or 1 if stored is not positive.
+func ratio(uncompressed, stored int64) float64 {
+	if stored <= 0 {
+		return 1
+	}
+	return float64(uncompressed) / float64(stored)
+}
@@ -0,0 +1,126 @@
+package matlab
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// TestVariableSizes_V5Compressed tests that a full read and a metadata
+// read report the same sizes for a compressed file.
+func TestVariableSizes_V5Compressed(t *testing.T) {
+	path := filepath.Join("testdata", "scipy", "inner_outer_tbl_param.mat")
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer file.Close()
+
+	matFile, err := Open(file)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := OpenMetadata(file)
+	if err != nil {
+		t.Fatalf("OpenMetadata() error = %v", err)
+	}
+
+	sizes := matFile.VariableSizes()
+	if len(sizes) != len(meta.Variables) || len(sizes) != len(matFile.Variables) {
+		t.Fatalf("got %d sizes, %d metadata entries, %d variables", len(sizes), len(meta.Variables), len(matFile.Variables))
+	}
+	for i, s := range sizes {
+		m := meta.Variables[i]
+		if s.Name != m.Name || s.Offset != m.Offset || s.Size != m.Size || s.UncompressedSize != m.UncompressedSize {
+			t.Errorf("%s: Open reports %+v, OpenMetadata %+v", s.Name, *s, *m)
+		}
+		if !s.Compressed || s.UncompressedSize <= 0 {
+			t.Errorf("%s: Compressed, UncompressedSize = %v, %d", s.Name, s.Compressed, s.UncompressedSize)
+		}
+	}
+
+	if !reflect.DeepEqual(matFile.SizeReport(), meta.SizeReport()) {
+		t.Error("SizeReport differs between Open and OpenMetadata")
+	}
+}
+
+// TestVariableSizes_V73 tests sizes of uncompressed v7.3 variables.
+func TestVariableSizes_V73(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "sizes73.mat")
+	writer, err := Create(tmpfile, Version73)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := writer.WriteVariable(&types.Variable{
+		Name: "x", Dimensions: []int{1, 100}, DataType: types.Double, Data: make([]float64, 100),
+	}); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	file, err := os.Open(tmpfile)
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	defer file.Close()
+	matFile, err := Open(file)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	sizes := matFile.VariableSizes()
+	if len(sizes) != 1 {
+		t.Fatalf("got %d sizes, want 1", len(sizes))
+	}
+	if s := sizes[0]; s.Name != "x" || s.Compressed || s.Size != 800 || s.UncompressedSize != 800 {
+		t.Errorf("sizes[0] = %+v", *s)
+	}
+}
+
+func TestSizeReport(t *testing.T) {
+	meta := &Metadata{Variables: []*types.VariableInfo{
+		{Name: "small", DataType: types.Double, Size: 100, UncompressedSize: 100},
+		{Name: "big", DataType: types.Single, Size: 300, UncompressedSize: 1200, Compressed: true},
+		{Name: "mid", DataType: types.Int8, Size: 200, UncompressedSize: 200},
+	}}
+
+	report := meta.SizeReport()
+	var names []string
+	for _, v := range report.Variables {
+		names = append(names, v.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"big", "mid", "small"}) {
+		t.Errorf("order = %v, want [big mid small]", names)
+	}
+	if report.Stored != 600 || report.Uncompressed != 1500 || report.Ratio() != 2.5 {
+		t.Errorf("Stored, Uncompressed, Ratio = %d, %d, %v", report.Stored, report.Uncompressed, report.Ratio())
+	}
+	if meta.Variables[0].Name != "small" {
+		t.Error("SizeReport reordered the metadata")
+	}
+
+	text := report.String()
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("String() has %d lines:\n%s", len(lines), text)
+	}
+	if fields := strings.Fields(lines[1]); !reflect.DeepEqual(fields, []string{"big", "single", "300", "1200", "4.00", "50.0%"}) {
+		t.Errorf("first row = %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[4], "total") {
+		t.Errorf("last row = %q", lines[4])
+	}
+
+	if empty := (&Metadata{}).SizeReport(); empty.Ratio() != 1 || len(empty.Variables) != 0 {
+		t.Errorf("empty report = %+v", empty)
+	}
+}
@@ -0,0 +1,203 @@
+package matlab
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+
+	"github.com/scigolib/matlab/internal/v5"
+	"github.com/scigolib/matlab/internal/zstd"
+)
+
+// headerSize is the number of bytes read to detect the format of a file:
+// the v5 header, which also covers the HDF5 signature.
+const headerSize = 128
+
+// Magic numbers of the compressed containers read transparently.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// source is the input of a read: the first bytes of the file, which
+// identify its format, and a reader of the whole file from its first
+// byte.
+//
+// Inputs that implement io.ReaderAt, such as *os.File and *bytes.Reader,
+// are read in place when positioned at their start: the header is read
+// at offset 0 and the file through an io.SectionReader, which the parsers
+// can seek. Other inputs are read once, the buffered header followed by
+// the rest of the stream. Files compressed with gzip or zstd are
+// decompressed as they are read, within the limits of miCOMPRESSED
+// elements: 100MB and a compression ratio of 1000:1.
+type source struct {
+	header []byte
+	r      io.Reader // The whole file, from its first byte
+	path   string    // Regular file holding the file as is, if any (see regularFilePath)
+
+	input   io.Reader       // Reader passed by the caller
+	stream  *countingReader // Counts the bytes read from a stream
+	section *sectionReader  // Tracks the extent read of an io.ReaderAt
+}
+
+// newSource reads the header of r and prepares r for parsing.
+//
+// Returns ErrIsDirectory for a directory and ErrEmptyFile for an input
+// without content, naming the file if r tells its name.
+func newSource(r io.Reader) (*source, error) {
+	if info, ok := statInput(r); ok && info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrIsDirectory, inputName(r, info))
+	}
+
+	s := &source{header: make([]byte, headerSize), input: r, path: regularFilePath(r)}
+	var n int
+	var err error
+	if ra, ok := readerAtStart(r); ok {
+		size := int64(math.MaxInt64)
+		if sized, ok := r.(interface{ Size() int64 }); ok {
+			size = sized.Size()
+		}
+		s.section = &sectionReader{SectionReader: io.NewSectionReader(ra, 0, size)}
+		n, err = io.ReadFull(io.NewSectionReader(s.section, 0, headerSize), s.header)
+		s.r = s.section
+	} else {
+		s.stream = &countingReader{r: r}
+		n, err = io.ReadFull(s.stream, s.header)
+		s.r = io.MultiReader(bytes.NewReader(s.header[:n]), s.stream)
+	}
+
+	// Compressed small files may be shorter than a header. Containers
+	// have the limits of miCOMPRESSED elements, against compression bombs
+	limit := &v5.StreamLimit{}
+	switch compressedContainer(s.header[:n]) {
+	case "gzip":
+		zr, err := gzip.NewReader(limit.Input(s.r))
+		if err != nil {
+			return nil, fmt.Errorf("gzip container: %w", err)
+		}
+		return s.decompress("gzip", limit.Output(zr))
+	case "zstd":
+		return s.decompress("zstd", limit.Output(zstd.NewReader(limit.Input(s.r))))
+	}
+	if n == 0 && errors.Is(err, io.EOF) {
+		info, _ := statInput(r)
+		if name := inputName(r, info); name != "" {
+			return nil, fmt.Errorf("%w: %s", ErrEmptyFile, name)
+		}
+		return nil, ErrEmptyFile
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// statInput returns the file information of r, if r is a file such as an
+// *os.File or an fs.File.
+func statInput(r io.Reader) (fs.FileInfo, bool) {
+	f, ok := r.(interface{ Stat() (fs.FileInfo, error) })
+	if !ok {
+		return nil, false
+	}
+	info, err := f.Stat()
+	return info, err == nil
+}
+
+// inputName returns the name of the file r reads from, as opened for an
+// *os.File or as given by info, or "" for other readers.
+func inputName(r io.Reader, info fs.FileInfo) string {
+	if f, ok := r.(interface{ Name() string }); ok {
+		return f.Name()
+	}
+	if info != nil {
+		return info.Name()
+	}
+	return ""
+}
+
+// decompress replaces the header and reader of s with those of the file
+// compressed in container, read from zr.
+func (s *source) decompress(container string, zr io.Reader) (*source, error) {
+	s.path = "" // The file on disk is compressed
+	s.header = make([]byte, headerSize)
+	n, err := io.ReadFull(zr, s.header)
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			err = fmt.Errorf("%w: %s container holds %d bytes", ErrInvalidFormat, container, n)
+		}
+		return nil, fmt.Errorf("%s container: %w", container, err)
+	}
+	s.r = io.MultiReader(bytes.NewReader(s.header), zr)
+	return s, nil
+}
+
+// compressedContainer returns "gzip" or "zstd" if header, the first
+// bytes of a file, starts a compressed container, and "" otherwise.
+func compressedContainer(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return "gzip"
+	case bytes.HasPrefix(header, zstdMagic):
+		return "zstd"
+	}
+	return ""
+}
+
+// readerAtStart returns r as an io.ReaderAt if it can be read in place:
+// it implements io.ReaderAt and, if it can seek, is at offset 0.
+func readerAtStart(r io.Reader) (io.ReaderAt, bool) {
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		return nil, false
+	}
+	if seeker, ok := r.(io.Seeker); ok {
+		if pos, err := seeker.Seek(0, io.SeekCurrent); err != nil || pos != 0 {
+			return nil, false
+		}
+	}
+	return ra, true
+}
+
+// bytesRead returns the number of bytes of the file read: the bytes read
+// from a stream, or the extent read of an io.ReaderAt. Compressed files
+// count their compressed bytes.
+func (s *source) bytesRead() int64 {
+	if s.section != nil {
+		return s.section.end
+	}
+	return s.stream.n
+}
+
+// close moves a seekable input read in place past the bytes read, where
+// reading it as a stream would have left it.
+func (s *source) close() {
+	if seeker, ok := s.input.(io.Seeker); ok && s.section != nil {
+		_, _ = seeker.Seek(s.section.end, io.SeekStart)
+	}
+}
+
+// sectionReader is an io.SectionReader that records the end of the
+// furthest read.
+type sectionReader struct {
+	*io.SectionReader
+	end int64
+}
+
+// Read implements io.Reader.
+func (s *sectionReader) Read(p []byte) (int, error) {
+	pos, _ := s.Seek(0, io.SeekCurrent)
+	n, err := s.SectionReader.Read(p)
+	s.end = max(s.end, pos+int64(n))
+	return n, err
+}
+
+// ReadAt implements io.ReaderAt.
+func (s *sectionReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := s.SectionReader.ReadAt(p, off)
+	s.end = max(s.end, off+int64(n))
+	return n, err
+}
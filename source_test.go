@@ -0,0 +1,135 @@
+package matlab
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// readerAtOnly is an io.ReaderAt whose Read fails, to check that a file
+// is read in place.
+type readerAtOnly struct {
+	*bytes.Reader
+}
+
+func (readerAtOnly) Read([]byte) (int, error) {
+	return 0, errors.New("read called")
+}
+
+// v5FileBytes returns a v5 file holding the variable x.
+func v5FileBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	x := &types.Variable{Name: "x", Dimensions: []int{1, 3}, DataType: types.Double, Data: []float64{1, 2, 3}}
+	if err := w.WriteVariable(x); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpen_Source(t *testing.T) {
+	data := v5FileBytes(t)
+
+	tests := []struct {
+		name string
+		r    func() io.Reader
+	}{
+		{"stream", func() io.Reader { return io.MultiReader(bytes.NewReader(data)) }},
+		{"reader at", func() io.Reader { return bytes.NewReader(data) }},
+		{"read in place", func() io.Reader { return readerAtOnly{bytes.NewReader(data)} }},
+		{"reader at past a prefix", func() io.Reader {
+			r := bytes.NewReader(append([]byte("prefix"), data...))
+			_, _ = r.Seek(6, io.SeekStart)
+			return r
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.r()
+			matFile, err := Open(r)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			if v := matFile.GetVariable("x"); v == nil {
+				t.Fatal("variable x not found")
+			}
+			if got := matFile.Stats().Bytes; got != int64(len(data)) {
+				t.Errorf("Stats().Bytes = %d, want %d", got, len(data))
+			}
+			if seeker, ok := r.(io.Seeker); ok {
+				pos, _ := seeker.Seek(0, io.SeekCurrent)
+				if size, _ := seeker.Seek(0, io.SeekEnd); pos != size {
+					t.Errorf("position after Open = %d, want %d", pos, size)
+				}
+			}
+		})
+	}
+}
+
+func TestOpen_SourceEmpty(t *testing.T) {
+	for _, r := range []io.Reader{bytes.NewReader(nil), io.MultiReader()} {
+		if _, err := Open(r); !errors.Is(err, ErrEmptyFile) {
+			t.Errorf("Open(%T) error = %v, want ErrEmptyFile", r, err)
+		}
+	}
+	if _, err := Open(bytes.NewReader(make([]byte, 10))); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Open(short) error = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestOpen_SourceFiles(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.mat")
+	if err := os.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want error
+	}{
+		{"empty file", empty, ErrEmptyFile},
+		{"directory", dir, ErrIsDirectory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.path)
+			if err != nil {
+				t.Fatalf("os.Open() error = %v", err)
+			}
+			defer f.Close()
+
+			_, err = Open(f)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Open() error = %v, want %v", err, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.path) {
+				t.Errorf("Open() error = %q, want the path %s", err, tt.path)
+			}
+			if _, err := NewCache(1).Metadata(tt.path); !errors.Is(err, tt.want) {
+				t.Errorf("Cache.Metadata() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := OpenFS(os.DirFS(dir), "."); !errors.Is(err, ErrIsDirectory) {
+		t.Errorf("OpenFS(directory) error = %v, want ErrIsDirectory", err)
+	}
+	if _, err := OpenFS(os.DirFS(dir), "empty.mat"); !errors.Is(err, ErrEmptyFile) {
+		t.Errorf("OpenFS(empty) error = %v, want ErrEmptyFile", err)
+	}
+}
@@ -0,0 +1,479 @@
+// Package sqlio converts MATLAB variables to and from SQL table rows, for
+// archiving .mat content in PostgreSQL, TimescaleDB, SQLite and other
+// databases reached through database/sql.
+//
+// A table is represented as in parquetio: a scalar struct whose fields
+// are column vectors of equal length, or a plain 2-D matrix whose columns
+// are named Var1, Var2, ... InsertBatches turns a table into multi-row
+// INSERT statements with their arguments; FromRows reads query results
+// back into a table.
+//
+// The package depends only on database/sql interfaces. *sql.DB, *sql.Tx
+// and sqlx's types can be passed to Insert and FromRows directly; for
+// pgx, pass the query and arguments of each Batch to Conn.Exec (with
+// WithDollarPlaceholders), or use the pgx stdlib driver.
+package sqlio
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// DefaultBatchSize is the number of rows per INSERT statement.
+const DefaultBatchSize = 1000
+
+// maxParameters is the number of query parameters PostgreSQL allows per
+// statement, which bounds the rows per batch of wide tables.
+const maxParameters = 65535
+
+// matrixColumnName is the name of column j+1 of a matrix, as in MATLAB's
+// array2table.
+const matrixColumnName = "Var%d"
+
+// ErrUnsupported indicates a variable or column type that cannot be
+// converted.
+var ErrUnsupported = errors.New("unsupported by sqlio")
+
+// Option configures InsertBatches and Insert.
+type Option func(*config)
+
+// config holds optional configuration for InsertBatches.
+type config struct {
+	batchSize int
+	dollar    bool
+	datenums  map[string]bool
+}
+
+// WithBatchSize sets the number of rows per INSERT statement. Batches of
+// wide tables are made smaller if needed to stay within 65535 parameters.
+//
+// Default: 1000
+func WithBatchSize(rows int) Option {
+	return func(c *config) {
+		if rows > 0 {
+			c.batchSize = rows
+		}
+	}
+}
+
+// WithDollarPlaceholders writes numbered placeholders ($1, $2, ...) as
+// PostgreSQL drivers such as pgx and lib/pq expect, instead of "?".
+func WithDollarPlaceholders() Option {
+	return func(c *config) {
+		c.dollar = true
+	}
+}
+
+// WithDatenumColumns passes the named double columns, which hold MATLAB
+// datenums, as time.Time values in UTC for timestamp columns. FromRows
+// converts time.Time values back to datenums.
+//
+// Example:
+//
+//	batches, err := sqlio.InsertBatches(v, "readings", sqlio.WithDatenumColumns("time"))
+func WithDatenumColumns(names ...string) Option {
+	return func(c *config) {
+		for _, name := range names {
+			c.datenums[name] = true
+		}
+	}
+}
+
+// Batch is one INSERT statement and its arguments.
+type Batch struct {
+	Query string        // INSERT INTO ... VALUES (...), (...)
+	Args  []interface{} // One argument per placeholder, row by row
+	Rows  int           // Number of table rows in the statement
+}
+
+// Execer executes a statement; *sql.DB, *sql.Tx, *sql.Conn and sqlx's
+// types implement it.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Rows is a query result; *sql.Rows and sqlx's Rows implement it.
+type Rows interface {
+	Columns() ([]string, error)
+	Next() bool
+	Scan(dest ...interface{}) error
+	Err() error
+}
+
+// column is one table column with a function returning its SQL value in
+// a row.
+type column struct {
+	name  string
+	value func(row int) (interface{}, error)
+}
+
+// InsertBatches returns the INSERT statements that store a table
+// variable in the named table, whose columns are named after the struct
+// fields (or Var1, Var2, ... for a matrix). NaN values are inserted as
+// NULL; integers are passed as int64, single values as float64. Tables
+// with more than 65535 columns return ErrUnsupported, since not even one
+// row fits in a statement.
+//
+// Example:
+//
+//	batches, err := sqlio.InsertBatches(matFile.GetVariable("log"), "log")
+//	for _, b := range batches {
+//	    if _, err := db.ExecContext(ctx, b.Query, b.Args...); err != nil {
+//	        return err
+//	    }
+//	}
+func InsertBatches(v *types.Variable, table string, opts ...Option) ([]Batch, error) {
+	cfg := &config{batchSize: DefaultBatchSize, datenums: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	columns, rows, err := tableColumns(v, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) > maxParameters {
+		return nil, fmt.Errorf("%w: %d columns exceed the %d parameters of one statement", ErrUnsupported, len(columns), maxParameters)
+	}
+
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = quoteIdentifier(col.name)
+	}
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", quoteIdentifier(table), strings.Join(names, ", "))
+
+	batchSize := cfg.batchSize
+	if limit := maxParameters / len(columns); batchSize > limit {
+		batchSize = limit
+	}
+
+	var batches []Batch
+	for start := 0; start < rows; start += batchSize {
+		end := min(start+batchSize, rows)
+		var sb strings.Builder
+		sb.WriteString(prefix)
+		args := make([]interface{}, 0, (end-start)*len(columns))
+		for row := start; row < end; row++ {
+			if row > start {
+				sb.WriteString(", ")
+			}
+			sb.WriteByte('(')
+			for j, col := range columns {
+				value, err := col.value(row)
+				if err != nil {
+					return nil, fmt.Errorf("column %q row %d: %w", col.name, row+1, err)
+				}
+				args = append(args, value)
+				if j > 0 {
+					sb.WriteString(", ")
+				}
+				if cfg.dollar {
+					fmt.Fprintf(&sb, "$%d", len(args))
+				} else {
+					sb.WriteByte('?')
+				}
+			}
+			sb.WriteByte(')')
+		}
+		batches = append(batches, Batch{Query: sb.String(), Args: args, Rows: end - start})
+	}
+	return batches, nil
+}
+
+// Insert stores a table variable in the named table with the statements
+// of InsertBatches and returns the number of rows inserted. Pass a
+// *sql.Tx to insert all rows or none.
+//
+// Example:
+//
+//	tx, err := db.BeginTx(ctx, nil)
+//	n, err := sqlio.Insert(ctx, tx, matFile.GetVariable("log"), "log")
+//	if err != nil {
+//	    tx.Rollback()
+//	    return err
+//	}
+//	err = tx.Commit()
+func Insert(ctx context.Context, db Execer, v *types.Variable, table string, opts ...Option) (int64, error) {
+	batches, err := InsertBatches(v, table, opts...)
+	if err != nil {
+		return 0, err
+	}
+	var inserted int64
+	for _, b := range batches {
+		if _, err := db.ExecContext(ctx, b.Query, b.Args...); err != nil {
+			return inserted, fmt.Errorf("insert into %s: %w", table, err)
+		}
+		inserted += int64(b.Rows)
+	}
+	return inserted, nil
+}
+
+// tableColumns splits a table variable into columns.
+func tableColumns(v *types.Variable, cfg *config) ([]column, int, error) {
+	if v == nil {
+		return nil, 0, errors.New("variable cannot be nil")
+	}
+	if v.IsComplex {
+		return nil, 0, fmt.Errorf("complex variable %q: %w", v.Name, ErrUnsupported)
+	}
+
+	var columns []column
+	rows := -1
+	add := func(name string, values interface{}) error {
+		col, n, err := makeColumn(name, values, cfg.datenums[name])
+		if err != nil {
+			return err
+		}
+		if rows >= 0 && n != rows {
+			return fmt.Errorf("column %q has %d rows, want %d", name, n, rows)
+		}
+		rows = n
+		columns = append(columns, col)
+		return nil
+	}
+
+	if st, ok := v.Data.(*types.StructArray); ok {
+		if len(st.Elements) != 1 {
+			return nil, 0, fmt.Errorf("struct %q must be scalar: %w", v.Name, ErrUnsupported)
+		}
+		for i, field := range st.Fields {
+			fv := st.Elements[0][i]
+			if fv == nil || fv.IsComplex {
+				return nil, 0, fmt.Errorf("field %q: %w", field, ErrUnsupported)
+			}
+			if err := add(field, fv.Data); err != nil {
+				return nil, 0, err
+			}
+		}
+	} else {
+		data := reflect.ValueOf(v.Data)
+		if data.Kind() != reflect.Slice {
+			return nil, 0, fmt.Errorf("variable %q of type %T: %w", v.Name, v.Data, ErrUnsupported)
+		}
+		rows, cols := data.Len(), 1
+		if len(v.Dimensions) > 0 {
+			rows, cols = v.Dimensions[0], 1
+			for _, d := range v.Dimensions[1:] {
+				cols *= d
+			}
+		}
+		if rows*cols != data.Len() {
+			return nil, 0, fmt.Errorf("variable %q has %d elements, dimensions %v", v.Name, data.Len(), v.Dimensions)
+		}
+		for j := 0; j < cols; j++ {
+			name := fmt.Sprintf(matrixColumnName, j+1)
+			if err := add(name, data.Slice(j*rows, (j+1)*rows).Interface()); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil, 0, fmt.Errorf("variable %q has no columns: %w", v.Name, ErrUnsupported)
+	}
+	return columns, rows, nil
+}
+
+// makeColumn returns the column of SQL values for column data and its
+// number of rows.
+func makeColumn(name string, data interface{}, datenum bool) (column, int, error) {
+	col := column{name: name}
+	switch v := data.(type) {
+	case []float64:
+		col.value = func(i int) (interface{}, error) {
+			switch {
+			case math.IsNaN(v[i]):
+				return nil, nil
+			case datenum:
+				return types.DatenumToTime(v[i]), nil
+			}
+			return v[i], nil
+		}
+	case []float32:
+		col.value = func(i int) (interface{}, error) {
+			if math.IsNaN(float64(v[i])) {
+				return nil, nil
+			}
+			return float64(v[i]), nil
+		}
+	case []bool:
+		col.value = func(i int) (interface{}, error) { return v[i], nil }
+	case []string:
+		col.value = func(i int) (interface{}, error) { return v[i], nil }
+	case []uint64:
+		col.value = func(i int) (interface{}, error) {
+			if v[i] > math.MaxInt64 {
+				return nil, fmt.Errorf("%d exceeds int64: %w", v[i], ErrUnsupported)
+			}
+			return int64(v[i]), nil
+		}
+	case []int8, []int16, []int32, []int64, []uint8, []uint16, []uint32:
+		values := reflect.ValueOf(v)
+		col.value = func(i int) (interface{}, error) {
+			elem := values.Index(i)
+			if elem.CanInt() {
+				return elem.Int(), nil
+			}
+			return int64(elem.Uint()), nil //nolint:gosec // G115: at most 32 bits
+		}
+	default:
+		return col, 0, fmt.Errorf("column %q of type %T: %w", name, data, ErrUnsupported)
+	}
+	if _, ok := data.([]float64); datenum && !ok {
+		return col, 0, fmt.Errorf("datenum column %q must be double, got %T", name, data)
+	}
+	return col, reflect.ValueOf(data).Len(), nil
+}
+
+// FromRows reads the remaining rows of a query result as a scalar struct
+// variable with one n×1 field per result column. The caller closes rows.
+//
+// Column types follow the values the driver returns. Integer columns
+// become int64, floating-point columns double, booleans logical, text and
+// byte columns string arrays and timestamps datenums. NULL becomes NaN in
+// double and datenum columns (integer columns containing NULL are read as
+// double) and "" in string columns; NULL in a boolean column is an error.
+//
+// Example:
+//
+//	rows, err := db.QueryContext(ctx, "SELECT time, value FROM readings")
+//	defer rows.Close()
+//	v, err := sqlio.FromRows(rows, "readings")
+//	writer.WriteVariable(v)
+func FromRows(rows Rows, name string) (*types.Variable, error) {
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([][]interface{}, len(names))
+	dest := make([]interface{}, len(names))
+	for i := range dest {
+		dest[i] = new(interface{})
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, d := range dest {
+			value := *(d.(*interface{}))
+			if b, ok := value.([]byte); ok {
+				value = string(b) // The driver may reuse the buffer
+			}
+			values[i] = append(values[i], value)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	n := 0
+	if len(values) > 0 {
+		n = len(values[0])
+	}
+	fields := make([]*types.Variable, len(names))
+	for i, col := range names {
+		data, dataType, err := columnData(values[i])
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", col, err)
+		}
+		fields[i] = &types.Variable{Name: col, Dimensions: []int{n, 1}, DataType: dataType, Data: data}
+	}
+	return &types.Variable{
+		Name:       name,
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     names,
+			Dimensions: []int{1, 1},
+			Elements:   [][]*types.Variable{fields},
+		},
+	}, nil
+}
+
+// columnData converts the scanned values of a column to a MATLAB array.
+func columnData(values []interface{}) (interface{}, types.DataType, error) {
+	var kind string
+	nulls := false
+	for _, v := range values {
+		var k string
+		switch v.(type) {
+		case nil:
+			nulls = true
+			continue
+		case int64:
+			k = "int"
+		case float64:
+			k = "float"
+		case bool:
+			k = "bool"
+		case string:
+			k = "string"
+		case time.Time:
+			k = "time"
+		default:
+			return nil, types.Unknown, fmt.Errorf("values of type %T: %w", v, ErrUnsupported)
+		}
+		switch {
+		case kind == "" || kind == k:
+			kind = k
+		case (kind == "int" && k == "float") || (kind == "float" && k == "int"):
+			kind = "float"
+		default:
+			return nil, types.Unknown, fmt.Errorf("mixed %s and %s values: %w", kind, k, ErrUnsupported)
+		}
+	}
+
+	switch {
+	case kind == "int" && !nulls:
+		out := make([]int64, len(values))
+		for i, v := range values {
+			out[i] = v.(int64)
+		}
+		return out, types.Int64, nil
+	case kind == "bool":
+		out := make([]bool, len(values))
+		for i, v := range values {
+			b, ok := v.(bool)
+			if !ok {
+				return nil, types.Unknown, fmt.Errorf("NULL in a boolean column: %w", ErrUnsupported)
+			}
+			out[i] = b
+		}
+		return out, types.Logical, nil
+	case kind == "string":
+		out := make([]string, len(values))
+		for i, v := range values {
+			out[i], _ = v.(string)
+		}
+		return out, types.Char, nil
+	}
+
+	out := make([]float64, len(values))
+	for i, v := range values {
+		switch x := v.(type) {
+		case nil:
+			out[i] = math.NaN()
+		case int64:
+			out[i] = float64(x)
+		case float64:
+			out[i] = x
+		case time.Time:
+			out[i] = types.TimeToDatenum(x)
+		}
+	}
+	return out, types.Double, nil
+}
+
+// quoteIdentifier quotes a table or column name for SQL.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
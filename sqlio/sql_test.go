@@ -0,0 +1,185 @@
+package sqlio
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// table returns a struct table with the given fields.
+func table(fields ...*types.Variable) *types.Variable {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+	return &types.Variable{
+		Name:       "t",
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data:       &types.StructArray{Fields: names, Dimensions: []int{1, 1}, Elements: [][]*types.Variable{fields}},
+	}
+}
+
+func TestInsertBatches(t *testing.T) {
+	v := table(
+		&types.Variable{Name: "time", Dimensions: []int{3, 1}, DataType: types.Double, Data: []float64{types.DatenumUnixEpoch, types.DatenumUnixEpoch + 0.5, math.NaN()}},
+		&types.Variable{Name: "count", Dimensions: []int{3, 1}, DataType: types.Uint16, Data: []uint16{1, 2, 3}},
+		&types.Variable{Name: "label", Dimensions: []int{3, 1}, DataType: types.Char, Data: []string{"a", "b", "c"}},
+	)
+	batches, err := InsertBatches(v, "log", WithBatchSize(2), WithDollarPlaceholders(), WithDatenumColumns("time"))
+	if err != nil {
+		t.Fatalf("InsertBatches() error = %v", err)
+	}
+	if len(batches) != 2 {
+		t.Fatalf("got %d batches, want 2", len(batches))
+	}
+	wantQuery := `INSERT INTO "log" ("time", "count", "label") VALUES ($1, $2, $3), ($4, $5, $6)`
+	if batches[0].Query != wantQuery || batches[0].Rows != 2 {
+		t.Errorf("batch 0 = %q (%d rows), want %q", batches[0].Query, batches[0].Rows, wantQuery)
+	}
+	wantArgs := []interface{}{
+		time.Unix(0, 0).UTC(), int64(1), "a",
+		time.Unix(12*3600, 0).UTC(), int64(2), "b",
+	}
+	if !reflect.DeepEqual(batches[0].Args, wantArgs) {
+		t.Errorf("batch 0 args = %v, want %v", batches[0].Args, wantArgs)
+	}
+	if want := []interface{}{nil, int64(3), "c"}; !reflect.DeepEqual(batches[1].Args, want) {
+		t.Errorf("batch 1 args = %v, want %v", batches[1].Args, want)
+	}
+
+	// A matrix has columns Var1, Var2, ...
+	m := &types.Variable{Name: "m", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}}
+	batches, err = InsertBatches(m, `we"ird`)
+	if err != nil {
+		t.Fatalf("InsertBatches() error = %v", err)
+	}
+	if want := `INSERT INTO "we""ird" ("Var1", "Var2") VALUES (?, ?)`; batches[0].Query != want {
+		t.Errorf("query = %q, want %q", batches[0].Query, want)
+	}
+}
+
+func TestInsertBatches_Errors(t *testing.T) {
+	tests := []*types.Variable{
+		nil,
+		{Name: "c", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}, IsComplex: true},
+		{Name: "s", Dimensions: []int{1, 1}, DataType: types.Char, Data: "text"},
+		table(
+			&types.Variable{Name: "a", Dimensions: []int{2, 1}, DataType: types.Double, Data: []float64{1, 2}},
+			&types.Variable{Name: "b", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}},
+		),
+		table(&types.Variable{Name: "u", Dimensions: []int{1, 1}, DataType: types.Uint64, Data: []uint64{math.MaxUint64}}),
+	}
+	for _, v := range tests {
+		if _, err := InsertBatches(v, "t"); err == nil {
+			t.Errorf("InsertBatches(%+v) succeeded", v)
+		}
+	}
+
+	// A row wider than the parameter limit cannot fit in any statement.
+	wide := &types.Variable{Name: "w", Dimensions: []int{1, 70000}, DataType: types.Double, Data: make([]float64, 70000)}
+	if _, err := InsertBatches(wide, "t"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("InsertBatches(1x70000) error = %v, want ErrUnsupported", err)
+	}
+}
+
+// execRecorder records executed statements.
+type execRecorder struct {
+	queries []string
+	fail    error
+}
+
+func (e *execRecorder) ExecContext(_ context.Context, query string, _ ...interface{}) (sql.Result, error) {
+	e.queries = append(e.queries, query)
+	return nil, e.fail
+}
+
+func TestInsert(t *testing.T) {
+	v := &types.Variable{Name: "m", Dimensions: []int{5, 1}, DataType: types.Int32, Data: []int32{1, 2, 3, 4, 5}}
+	db := &execRecorder{}
+	n, err := Insert(context.Background(), db, v, "t", WithBatchSize(2))
+	if err != nil || n != 5 || len(db.queries) != 3 {
+		t.Errorf("Insert() = %d, %v after %d statements, want 5 rows in 3", n, err, len(db.queries))
+	}
+
+	db = &execRecorder{fail: errors.New("disk full")}
+	if _, err := Insert(context.Background(), db, v, "t"); !errors.Is(err, db.fail) {
+		t.Errorf("Insert() error = %v, want disk full", err)
+	}
+}
+
+// fakeRows is a query result held in memory.
+type fakeRows struct {
+	columns []string
+	rows    [][]interface{}
+	next    int
+}
+
+func (r *fakeRows) Columns() ([]string, error) { return r.columns, nil }
+func (r *fakeRows) Err() error                 { return nil }
+
+func (r *fakeRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		*(d.(*interface{})) = r.rows[r.next-1][i]
+	}
+	return nil
+}
+
+func TestFromRows(t *testing.T) {
+	rows := &fakeRows{
+		columns: []string{"id", "value", "sparse", "ok", "name", "at"},
+		rows: [][]interface{}{
+			{int64(1), 1.5, int64(7), true, []byte("a"), time.Unix(0, 0)},
+			{int64(2), int64(3), nil, false, nil, nil},
+		},
+	}
+	v, err := FromRows(rows, "result")
+	if err != nil {
+		t.Fatalf("FromRows() error = %v", err)
+	}
+	st := v.Data.(*types.StructArray)
+	if v.Name != "result" || !reflect.DeepEqual(st.Fields, rows.columns) {
+		t.Fatalf("got %s with fields %v", v.Name, st.Fields)
+	}
+
+	tests := []struct {
+		field    string
+		dataType types.DataType
+		want     interface{}
+	}{
+		{"id", types.Int64, []int64{1, 2}},
+		{"value", types.Double, []float64{1.5, 3}},
+		{"ok", types.Logical, []bool{true, false}},
+		{"name", types.Char, []string{"a", ""}},
+	}
+	for _, tt := range tests {
+		f := st.Field(0, tt.field)
+		if f.DataType != tt.dataType || !reflect.DeepEqual(f.Data, tt.want) || !reflect.DeepEqual(f.Dimensions, []int{2, 1}) {
+			t.Errorf("%s = %v %v %v, want %v %v", tt.field, f.DataType, f.Dimensions, f.Data, tt.dataType, tt.want)
+		}
+	}
+	if sparse := st.Field(0, "sparse").Data.([]float64); sparse[0] != 7 || !math.IsNaN(sparse[1]) {
+		t.Errorf("sparse = %v, want [7 NaN]", sparse)
+	}
+	if at := st.Field(0, "at").Data.([]float64); at[0] != types.DatenumUnixEpoch || !math.IsNaN(at[1]) {
+		t.Errorf("at = %v, want [%d NaN]", at, types.DatenumUnixEpoch)
+	}
+
+	for _, bad := range []interface{}{nil, "x", struct{}{}} {
+		rows := &fakeRows{columns: []string{"c"}, rows: [][]interface{}{{true}, {bad}}}
+		if _, err := FromRows(rows, "r"); err == nil {
+			t.Errorf("FromRows accepted a boolean column followed by %v", bad)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package matlab
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// Stats describes the work done reading or writing a MAT-file, so
+// services can monitor ingestion and export without timing every call.
+// The JSON form of Stats (see String) suits expvar, and Metrics returns
+// flat values for Prometheus-style exporters.
+type Stats struct {
+	Duration          time.Duration  `json:"duration_ns"`        // Time spent opening, or writing and closing
+	Bytes             int64          `json:"bytes"`              // Bytes read from the source, or written to the file
+	DecompressedBytes int64          `json:"decompressed_bytes"` // Size of the compressed variables once inflated
+	Variables         int            `json:"variables"`          // Variables read or written
+	Classes           map[string]int `json:"classes"`            // Variables per class, e.g. "double"
+
+	// Downcast lists the variables written as single by
+	// WithDowncastToSingle, with the largest relative error of each.
+	Downcast map[string]float64 `json:"downcast,omitempty"`
+}
+
+// Stats returns the statistics of opening the file: the time Open took,
+// the bytes it read and the variables it returned. For v7.3 files the
+// HDF5 library reads in place (an *os.File opened at its start), Bytes is
+// the size of the file.
+//
+// Example:
+//
+//	matFile, _ := matlab.Open(file)
+//	s := matFile.Stats()
+//	log.Printf("%d variables, %d bytes in %v", s.Variables, s.Bytes, s.Duration)
+func (m *MatFile) Stats() Stats {
+	return m.stats.clone()
+}
+
+// Stats returns the statistics of the writes so far: the time spent in
+// WriteVariable, WriteVariables, group writes and Close, the bytes
+// written and the variables written per class. v7.3 files are written by
+// the HDF5 library, so their Bytes is only known after Close.
+//
+// Example:
+//
+//	defer func() {
+//	    writer.Close()
+//	    s := writer.Stats()
+//	    log.Printf("wrote %d bytes in %v", s.Bytes, s.Duration)
+//	}()
+func (w *MatFileWriter) Stats() Stats {
+	return w.stats.clone()
+}
+
+// Add returns the sum of two statistics, to aggregate over many files.
+//
+// Example:
+//
+//	var mu sync.Mutex
+//	var total matlab.Stats
+//	expvar.Publish("matfiles", expvar.Func(func() interface{} {
+//	    mu.Lock()
+//	    defer mu.Unlock()
+//	    return total
+//	}))
+//	// For each file:
+//	mu.Lock()
+//	total = total.Add(matFile.Stats())
+//	mu.Unlock()
+func (s Stats) Add(other Stats) Stats {
+	sum := s.clone()
+	sum.Duration += other.Duration
+	sum.Bytes += other.Bytes
+	sum.DecompressedBytes += other.DecompressedBytes
+	sum.Variables += other.Variables
+	for class, n := range other.Classes {
+		if sum.Classes == nil {
+			sum.Classes = make(map[string]int)
+		}
+		sum.Classes[class] += n
+	}
+	for name, loss := range other.Downcast {
+		if sum.Downcast == nil {
+			sum.Downcast = make(map[string]float64)
+		}
+		sum.Downcast[name] = max(sum.Downcast[name], loss)
+	}
+	return sum
+}
+
+// String returns the statistics as a JSON object, so Stats implements
+// expvar.Var.
+//
+// Example:
+//
+//	expvar.Publish("last_load", matFile.Stats())
+func (s Stats) String() string {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// Metrics returns the statistics as metric names and values, in base
+// units (seconds and bytes): duration_seconds, bytes, decompressed_bytes,
+// variables, one variables_<class> per class, e.g. variables_double, and
+// downcast_variables if WithDowncastToSingle converted any.
+//
+// Example:
+//
+//	for name, value := range matFile.Stats().Metrics() {
+//	    gauges.WithLabelValues(name).Set(value)
+//	}
+func (s Stats) Metrics() map[string]float64 {
+	metrics := map[string]float64{
+		"duration_seconds":   s.Duration.Seconds(),
+		"bytes":              float64(s.Bytes),
+		"decompressed_bytes": float64(s.DecompressedBytes),
+		"variables":          float64(s.Variables),
+	}
+	if len(s.Downcast) > 0 {
+		metrics["downcast_variables"] = float64(len(s.Downcast))
+	}
+	for class, n := range s.Classes {
+		metrics["variables_"+class] = float64(n)
+	}
+	return metrics
+}
+
+// clone returns a copy of s that does not share its maps.
+func (s Stats) clone() Stats {
+	if s.Classes != nil {
+		classes := make(map[string]int, len(s.Classes))
+		for class, n := range s.Classes {
+			classes[class] = n
+		}
+		s.Classes = classes
+	}
+	if s.Downcast != nil {
+		downcast := make(map[string]float64, len(s.Downcast))
+		for name, loss := range s.Downcast {
+			downcast[name] = loss
+		}
+		s.Downcast = downcast
+	}
+	return s
+}
+
+// count adds variables to the statistics.
+func (s *Stats) count(vars ...*types.Variable) {
+	for _, v := range vars {
+		if s.Classes == nil {
+			s.Classes = make(map[string]int)
+		}
+		s.Classes[v.DataType.String()]++
+		s.Variables++
+	}
+}
+
+// readStats returns the statistics of a file opened in elapsed time,
+// after n bytes were read from the source. path is the file the HDF5
+// library read in place, if any.
+func (m *MatFile) readStats(elapsed time.Duration, n int64, path string) Stats {
+	s := Stats{Duration: elapsed, Bytes: n}
+	if m.Features.HDF5 && path != "" {
+		if info, err := os.Stat(path); err == nil {
+			s.Bytes = info.Size()
+		}
+	}
+	for _, info := range m.storage {
+		if info.Compressed {
+			s.DecompressedBytes += info.UncompressedSize
+		}
+	}
+	s.count(m.Variables...)
+	return s
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements io.Reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
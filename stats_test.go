@@ -0,0 +1,115 @@
+package matlab
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// writeStatsFile writes three variables and returns the writer's
+// statistics after Close.
+func writeStatsFile(t *testing.T, path string, version Version, opts ...Option) Stats {
+	t.Helper()
+	writer, err := Create(path, version, opts...)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := writer.WriteVariable(&types.Variable{
+		Name: "x", Dimensions: []int{1, 100}, DataType: types.Double, Data: make([]float64, 100),
+	}); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	if err := writer.WriteVariables(
+		&types.Variable{Name: "y", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{1, 2}},
+		&types.Variable{Name: "s", Dimensions: []int{1, 2}, DataType: types.Char, Data: "hi"},
+	); err != nil {
+		t.Fatalf("WriteVariables() error = %v", err)
+	}
+	if err := writer.WriteVariable(&types.Variable{Name: "bad", Dimensions: []int{1, 2}, DataType: types.Double}); err == nil {
+		t.Fatal("WriteVariable(no data) error = nil")
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return writer.Stats()
+}
+
+func TestStats(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		version    Version
+		opts       []Option
+		compressed bool
+	}{
+		{"v5", Version5, nil, false},
+		{"v5 compressed", Version5, []Option{WithCompression(6)}, true},
+		{"v7.3", Version73, nil, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "stats.mat")
+			written := writeStatsFile(t, path, tt.version, tt.opts...)
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			classes := map[string]int{"double": 2, "char": 1}
+			if written.Bytes != info.Size() || written.Variables != 3 || !reflect.DeepEqual(written.Classes, classes) {
+				t.Errorf("writer Stats() = %+v, file has %d bytes", written, info.Size())
+			}
+			if written.Duration <= 0 || (written.DecompressedBytes > 0) != tt.compressed {
+				t.Errorf("writer Stats() = %+v", written)
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			matFile, err := Open(file)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			read := matFile.Stats()
+			if read.Bytes != info.Size() || read.Variables != 3 || !reflect.DeepEqual(read.Classes, classes) {
+				t.Errorf("Stats() = %+v, file has %d bytes", read, info.Size())
+			}
+			if read.Duration <= 0 || read.DecompressedBytes != written.DecompressedBytes {
+				t.Errorf("Stats() = %+v, writer %+v", read, written)
+			}
+		})
+	}
+}
+
+func TestStats_Exports(t *testing.T) {
+	s := Stats{Duration: 1500e6, Bytes: 4096, DecompressedBytes: 8192, Variables: 3,
+		Classes: map[string]int{"double": 2, "char": 1}}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(s.String()), &decoded); err != nil {
+		t.Fatalf("String() = %s is not JSON: %v", s, err)
+	}
+	if decoded["bytes"] != 4096.0 || decoded["duration_ns"] != 1.5e9 {
+		t.Errorf("String() = %s", s)
+	}
+
+	want := map[string]float64{
+		"duration_seconds": 1.5, "bytes": 4096, "decompressed_bytes": 8192, "variables": 3,
+		"variables_double": 2, "variables_char": 1,
+	}
+	if got := s.Metrics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Metrics() = %v, want %v", got, want)
+	}
+
+	sum := s.Add(s)
+	if sum.Bytes != 8192 || sum.Variables != 6 || sum.Classes["double"] != 4 || s.Classes["double"] != 2 {
+		t.Errorf("Add() = %+v, receiver %+v", sum, s)
+	}
+	if total := (Stats{}).Add(s); !reflect.DeepEqual(total, s) {
+		t.Errorf("Stats{}.Add(s) = %+v, want %+v", total, s)
+	}
+}
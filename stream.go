@@ -0,0 +1,31 @@
+package matlab
+
+import (
+	"io"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// OpenStream reads a MAT-file from r in a single pass, calling fn with
+// each top-level variable as soon as it is parsed. The library keeps no
+// reference to a variable after fn returns, so only the variable being
+// handed over is resident and files far larger than memory can be
+// processed; fn may retain variables it needs. An error from fn stops the
+// read and is returned wrapped with the variable's name.
+//
+// The options are those of Open. Variables are passed after any
+// WithTransform, and only if they match WithNamePattern when given. v7.3
+// variables are passed in the order they are read, which is alphabetical.
+//
+// Example:
+//
+//	err := matlab.OpenStream(file, func(v *types.Variable) error {
+//	    return sink.Insert(v.Name, v.Data)
+//	}, matlab.WithNamePattern("sensor_*"))
+func OpenStream(r io.Reader, fn func(*types.Variable) error, opts ...OpenOption) error {
+	release := func(v *types.Variable) (*types.Variable, error) {
+		return nil, fn(v)
+	}
+	_, err := Open(r, append(opts, WithTransform(release))...)
+	return err
+}
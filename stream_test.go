@@ -0,0 +1,87 @@
+package matlab
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// writeStreamFile writes variables a, b1 and b2 in the given version.
+func writeStreamFile(t *testing.T, version Version) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "stream.mat")
+	w, err := Create(file, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"a", "b1", "b2"} {
+		v := &types.Variable{Name: name, Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{float64(i)}}
+		if err := w.WriteVariable(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestOpenStream(t *testing.T) {
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprint(version), func(t *testing.T) {
+			file := writeStreamFile(t, version)
+			f, err := os.Open(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			var names []string
+			var values []float64
+			err = OpenStream(f, func(v *types.Variable) error {
+				names = append(names, v.Name)
+				values = append(values, v.Data.([]float64)[0])
+				return nil
+			}, WithNamePattern("b*"))
+			if err != nil {
+				t.Fatalf("OpenStream() error = %v", err)
+			}
+			if !reflect.DeepEqual(names, []string{"b1", "b2"}) || !reflect.DeepEqual(values, []float64{1, 2}) {
+				t.Errorf("got %v = %v, want [b1 b2] = [1 2]", names, values)
+			}
+		})
+	}
+}
+
+func TestOpenStream_Errors(t *testing.T) {
+	file := writeStreamFile(t, Version5)
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	stop := errors.New("stop")
+	calls := 0
+	err = OpenStream(f, func(v *types.Variable) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("OpenStream() error = %v after %d calls, want stop after 1", err, calls)
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	err = OpenStream(f, func(*types.Variable) error { return nil }, WithNamePattern("["))
+	if !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("OpenStream() error = %v, want path.ErrBadPattern", err)
+	}
+}
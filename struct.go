@@ -0,0 +1,244 @@
+package matlab
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// GetStructAsMap returns the fields of a 1x1 struct variable as a map.
+//
+// This is a convenience for configuration and metadata structs, where
+// walking types.StructArray is unnecessary. Field values are simplified:
+//   - 1x1 numeric arrays become their Go scalar (float64, int32, ...)
+//   - 1x1 logical arrays become bool
+//   - char arrays become string
+//   - 1x1 nested structs become nested map[string]interface{}
+//   - anything else is returned as the variable's Data
+//
+// Returns ErrVariableNotFound if the variable does not exist and
+// ErrNotScalarStruct if it is not a 1x1 struct.
+//
+// Example:
+//
+//	cfg, err := matFile.GetStructAsMap("config")
+//	if err != nil {
+//	    return err
+//	}
+//	rate := cfg["sampleRate"].(float64)
+func (m *MatFile) GetStructAsMap(name string) (map[string]interface{}, error) {
+	v := m.GetVariable(name)
+	if v == nil {
+		return nil, fmt.Errorf("%w: %q", ErrVariableNotFound, name)
+	}
+	result, ok := structToMap(v)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNotScalarStruct, name)
+	}
+	return result, nil
+}
+
+// structToMap converts a 1x1 struct variable to a map.
+func structToMap(v *types.Variable) (map[string]interface{}, bool) {
+	if v.DataType != types.Struct {
+		return nil, false
+	}
+	st, ok := v.Data.(*types.StructArray)
+	if !ok || len(st.Elements) != 1 {
+		return nil, false
+	}
+
+	result := make(map[string]interface{}, len(st.Fields))
+	for _, field := range st.Fields {
+		if value := st.Field(0, field); value != nil {
+			result[field] = simplifyValue(value)
+		}
+	}
+	return result, true
+}
+
+// simplifyValue converts a struct field to the representation documented
+// on GetStructAsMap.
+func simplifyValue(v *types.Variable) interface{} {
+	if nested, ok := structToMap(v); ok {
+		return nested
+	}
+	if v.IsComplex {
+		return v.Data
+	}
+
+	rv := reflect.ValueOf(v.Data)
+	if rv.Kind() == reflect.Slice && rv.Len() == 1 {
+		return rv.Index(0).Interface()
+	}
+	return v.Data
+}
+
+// WriteStructFromMap writes a map as a 1x1 struct variable.
+//
+// Fields are written in sorted key order. Supported values are:
+//   - bool and numeric scalars (stored as 1x1 arrays of the matching class;
+//     int and uint are stored as int64 and uint64, []int as int64)
+//   - complex128 (stored as a 1x1 complex double)
+//   - string (stored as a 1xN char array)
+//   - slices of bool or numeric types (stored as 1xN row vectors)
+//   - named types of the above, such as type Celsius float64 (stored as
+//     their underlying type)
+//   - map[string]interface{} (stored as a nested 1x1 struct)
+//   - *types.Variable (stored as-is under the field name)
+//
+// Example:
+//
+//	writer.WriteStructFromMap("config", map[string]interface{}{
+//	    "sampleRate": 1000.0,
+//	    "channels":   []int32{1, 2, 3},
+//	    "device":     "scope-1",
+//	})
+func (w *MatFileWriter) WriteStructFromMap(name string, fields map[string]interface{}) error {
+	v, err := structFromMap(name, fields)
+	if err != nil {
+		return err
+	}
+	return w.WriteVariable(v)
+}
+
+// structFromMap builds a 1x1 struct variable from a map.
+func structFromMap(name string, fields map[string]interface{}) (*types.Variable, error) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([]*types.Variable, len(keys))
+	for i, key := range keys {
+		value, err := valueToVariable(key, fields[key])
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", key, err)
+		}
+		values[i] = value
+	}
+
+	return &types.Variable{
+		Name:       name,
+		Dimensions: []int{1, 1},
+		DataType:   types.Struct,
+		Data: &types.StructArray{
+			Fields:     keys,
+			Elements:   [][]*types.Variable{values},
+			Dimensions: []int{1, 1},
+		},
+	}, nil
+}
+
+// kindToDataType maps Go element kinds to MATLAB classes.
+var kindToDataType = map[reflect.Kind]types.DataType{
+	reflect.Bool:    types.Logical,
+	reflect.Float64: types.Double,
+	reflect.Float32: types.Single,
+	reflect.Int8:    types.Int8,
+	reflect.Uint8:   types.Uint8,
+	reflect.Int16:   types.Int16,
+	reflect.Uint16:  types.Uint16,
+	reflect.Int32:   types.Int32,
+	reflect.Uint32:  types.Uint32,
+	reflect.Int64:   types.Int64,
+	reflect.Uint64:  types.Uint64,
+}
+
+// kindToElemType maps Go element kinds to the element types of the
+// slices the writers accept.
+var kindToElemType = map[reflect.Kind]reflect.Type{
+	reflect.Bool:    reflect.TypeOf(false),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+}
+
+// valueToVariable converts a Go value to a struct field variable.
+func valueToVariable(name string, value interface{}) (*types.Variable, error) {
+	switch val := value.(type) {
+	case *types.Variable:
+		if val == nil {
+			return nil, fmt.Errorf("nil variable")
+		}
+		field := *val
+		field.Name = name
+		return &field, nil
+	case map[string]interface{}:
+		return structFromMap(name, val)
+	case string:
+		return &types.Variable{
+			Name:       name,
+			Dimensions: []int{1, len(utf16.Encode([]rune(val)))},
+			DataType:   types.Char,
+			Data:       val,
+		}, nil
+	case complex128:
+		return &types.Variable{
+			Name:       name,
+			Dimensions: []int{1, 1},
+			DataType:   types.Double,
+			IsComplex:  true,
+			Data: &types.NumericArray{
+				Real: []float64{real(val)},
+				Imag: []float64{imag(val)},
+			},
+		}, nil
+	case int:
+		value = int64(val)
+	case uint:
+		value = uint64(val)
+	case []int:
+		converted := make([]int64, len(val))
+		for i, x := range val {
+			converted[i] = int64(x)
+		}
+		value = converted
+	}
+
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("nil value")
+	}
+
+	// Wrap scalars in a one-element slice
+	if rv.Kind() != reflect.Slice {
+		slice := reflect.MakeSlice(reflect.SliceOf(rv.Type()), 1, 1)
+		slice.Index(0).Set(rv)
+		rv = slice
+	}
+
+	kind := rv.Type().Elem().Kind()
+	dataType, ok := kindToDataType[kind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+
+	// Named types, such as type Celsius float64, are converted to the
+	// slices of their underlying type
+	if sliceType := reflect.SliceOf(kindToElemType[kind]); rv.Type() != sliceType {
+		converted := reflect.MakeSlice(sliceType, rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			converted.Index(i).Set(rv.Index(i).Convert(sliceType.Elem()))
+		}
+		rv = converted
+	}
+
+	return &types.Variable{
+		Name:       name,
+		Dimensions: []int{1, rv.Len()},
+		DataType:   dataType,
+		Data:       rv.Interface(),
+	}, nil
+}
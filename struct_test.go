@@ -0,0 +1,169 @@
+package matlab
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// TestStructMap_RoundTrip tests WriteStructFromMap followed by
+// GetStructAsMap for both formats.
+func TestStructMap_RoundTrip(t *testing.T) {
+	config := map[string]interface{}{
+		"sampleRate": 1000.0,
+		"gain":       float32(0.5),
+		"channels":   []int32{1, 2, 3},
+		"count":      7,
+		"enabled":    true,
+		"device":     "scope-1",
+		"offset":     complex(1, -2),
+		"filter": map[string]interface{}{
+			"order":  int16(4),
+			"cutoff": 50.0,
+		},
+	}
+
+	want := map[string]interface{}{
+		"sampleRate": 1000.0,
+		"gain":       float32(0.5),
+		"channels":   []int32{1, 2, 3},
+		"count":      int64(7),
+		"enabled":    true,
+		"device":     "scope-1",
+		"filter": map[string]interface{}{
+			"order":  int16(4),
+			"cutoff": 50.0,
+		},
+	}
+
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			tmpfile := filepath.Join(t.TempDir(), "config.mat")
+
+			writer, err := Create(tmpfile, version)
+			if err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			if err := writer.WriteStructFromMap("config", config); err != nil {
+				t.Fatalf("WriteStructFromMap failed: %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			file, err := os.Open(tmpfile)
+			if err != nil {
+				t.Fatalf("Failed to open file: %v", err)
+			}
+			defer file.Close()
+
+			matFile, err := Open(file)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+
+			got, err := matFile.GetStructAsMap("config")
+			if err != nil {
+				t.Fatalf("GetStructAsMap failed: %v", err)
+			}
+
+			offset, ok := got["offset"].(*types.NumericArray)
+			if !ok {
+				t.Fatalf("offset = %#v, want *types.NumericArray", got["offset"])
+			}
+			if !reflect.DeepEqual(offset.Real, []float64{1}) || !reflect.DeepEqual(offset.Imag, []float64{-2}) {
+				t.Errorf("offset = %v%+vi, want 1-2i", offset.Real, offset.Imag)
+			}
+			delete(got, "offset")
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GetStructAsMap() =\n%#v\nwant\n%#v", got, want)
+			}
+		})
+	}
+}
+
+// TestGetStructAsMap_Errors tests lookups of missing and non-struct variables.
+func TestGetStructAsMap_Errors(t *testing.T) {
+	matFile := &MatFile{
+		Variables: []*types.Variable{
+			{Name: "x", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}},
+		},
+	}
+
+	if _, err := matFile.GetStructAsMap("missing"); !errors.Is(err, ErrVariableNotFound) {
+		t.Errorf("missing variable: err = %v, want ErrVariableNotFound", err)
+	}
+	if _, err := matFile.GetStructAsMap("x"); !errors.Is(err, ErrNotScalarStruct) {
+		t.Errorf("numeric variable: err = %v, want ErrNotScalarStruct", err)
+	}
+}
+
+// TestWriteStructFromMap_UnsupportedValue tests that unsupported field
+// values are rejected before anything is written.
+func TestWriteStructFromMap_UnsupportedValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"nil", nil},
+		{"nil variable", (*types.Variable)(nil)},
+		{"string slice", []string{"a"}},
+		{"struct value", struct{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := structFromMap("s", map[string]interface{}{"field": tt.value})
+			if err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+// TestWriteStructFromMap_NamedTypes tests that values of named numeric
+// and slice types are written as their underlying type.
+func TestWriteStructFromMap_NamedTypes(t *testing.T) {
+	type celsius float64
+	type samples []int32
+	fields := map[string]interface{}{
+		"temperature": []celsius{20.5, 21},
+		"setpoint":    celsius(22),
+		"counts":      samples{1, 2},
+	}
+	want := map[string]interface{}{
+		"temperature": []float64{20.5, 21},
+		"setpoint":    22.0,
+		"counts":      []int32{1, 2},
+	}
+
+	for _, version := range []Version{Version5, Version73} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			tmpfile := filepath.Join(t.TempDir(), "named.mat")
+			writer, err := Create(tmpfile, version)
+			if err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			if err := writer.WriteStructFromMap("s", fields); err != nil {
+				t.Fatalf("WriteStructFromMap failed: %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			got, err := readMatFile(t, tmpfile).GetStructAsMap("s")
+			if err != nil {
+				t.Fatalf("GetStructAsMap failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GetStructAsMap() = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package matlab
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/scigolib/matlab/internal/v73"
+	"github.com/scigolib/matlab/types"
+)
+
+// TimeSeriesChunk is the chunk length, in samples, of the channel
+// datasets written by TimeSeriesWriter.
+const TimeSeriesChunk = 4096
+
+// ErrNotChannel indicates an append to a variable of a time-series file
+// that is not a channel: a real double row vector.
+var ErrNotChannel = errors.New("variable is not a time-series channel")
+
+// TimeSeriesWriter appends samples to the channels of a v7.3 MAT-file, for
+// loggers that keep adding to the same file across restarts. Each channel
+// is a 1xN double row vector, stored as a chunked dataset whose columns
+// are unlimited, so MATLAB loads it as an ordinary variable.
+//
+// Samples are buffered until Flush or Close, which extend the channels in
+// place: only the new samples and the chunk index are written, and each
+// channel's new length last, so a crash loses the samples since the last
+// Flush, never the file. Adding a channel, or continuing one that is not
+// stored as an extendable dataset, writes the file anew once, through a
+// temporary file that replaces it once complete.
+//
+// Example:
+//
+//	ts, err := matlab.OpenTimeSeries("log.mat")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer ts.Close()
+//	for reading := range readings {
+//	    ts.AppendSamples("t", reading.Time)
+//	    ts.AppendSamples("temperature", reading.Celsius)
+//	    if ts.Len("t")%1000 == 0 {
+//	        ts.Flush()
+//	    }
+//	}
+type TimeSeriesWriter struct {
+	filename string
+	opts     []Option
+
+	names   []string             // Channels in the order they were created
+	stored  map[string]int       // Samples in the file by channel
+	pending map[string][]float64 // Samples appended since the last write
+	others  map[string]bool      // Variables of the file that are not channels
+	closed  bool
+}
+
+// OpenTimeSeries opens the time-series file at filename, creating it on
+// the first Flush if it does not exist. The real double row vectors of an
+// existing file are its channels; only their lengths are read. opts apply
+// when the file is written anew, as for Create; options that transform
+// the whole file (encryption, signing, gzip) are not supported, as
+// appends write to the file in place.
+func OpenTimeSeries(filename string, opts ...Option) (*TimeSeriesWriter, error) {
+	cfg := defaultConfig()
+	applyOptions(cfg, opts)
+	if cfg.encryptionKey != nil || cfg.signingKey != nil || cfg.gzip {
+		return nil, errors.New("time series cannot be encrypted, signed or gzip-compressed")
+	}
+
+	ts := &TimeSeriesWriter{
+		filename: filename,
+		opts:     opts,
+		stored:   make(map[string]int),
+		pending:  make(map[string][]float64),
+		others:   make(map[string]bool),
+	}
+
+	//nolint:gosec // G304: filename is provided by the caller
+	file, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return ts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck // Read-only file
+
+	meta, err := OpenMetadata(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	if meta.Version != "7.3" {
+		return nil, fmt.Errorf("%s is a v%s file, time series need v7.3", filename, meta.Version)
+	}
+	for _, info := range meta.Variables {
+		if isChannel(info) {
+			ts.names = append(ts.names, info.Name)
+			ts.stored[info.Name] = info.Dimensions[1]
+		} else {
+			ts.others[info.Name] = true
+		}
+	}
+	return ts, nil
+}
+
+// isChannel reports whether a variable is a channel.
+func isChannel(info *types.VariableInfo) bool {
+	return info.DataType == types.Double && !info.IsComplex && !info.IsSparse &&
+		len(info.Dimensions) == 2 && info.Dimensions[0] == 1
+}
+
+// AppendSamples appends samples to a channel, creating it on first use.
+// Returns ErrNotChannel if the file holds another kind of variable under
+// that name.
+func (ts *TimeSeriesWriter) AppendSamples(channel string, samples ...float64) error {
+	if ts.closed {
+		return errors.New("time series is closed")
+	}
+	if ts.others[channel] {
+		return fmt.Errorf("%w: %s", ErrNotChannel, channel)
+	}
+	if _, ok := ts.stored[channel]; !ok {
+		if err := types.ValidateName(channel); err != nil {
+			return err
+		}
+		ts.names = append(ts.names, channel)
+		ts.stored[channel] = 0
+	}
+	ts.pending[channel] = append(ts.pending[channel], samples...)
+	return nil
+}
+
+// Len returns the number of samples of a channel, 0 if it does not exist.
+func (ts *TimeSeriesWriter) Len(channel string) int {
+	return ts.stored[channel] + len(ts.pending[channel])
+}
+
+// Channels returns the names of the channels in the order they were
+// created.
+func (ts *TimeSeriesWriter) Channels() []string {
+	return slices.Clone(ts.names)
+}
+
+// Flush writes the samples appended since the last write. Channels
+// without samples are not written, as empty arrays cannot be.
+func (ts *TimeSeriesWriter) Flush() error {
+	var extensions []v73.Extension
+	rewrite := false
+	for _, name := range ts.names {
+		if samples := ts.pending[name]; len(samples) > 0 {
+			extensions = append(extensions, v73.Extension{Name: name, Data: samples})
+			rewrite = rewrite || ts.stored[name] == 0
+		}
+	}
+	if len(extensions) == 0 {
+		return nil
+	}
+
+	var err error
+	if !rewrite {
+		err = v73.Extend(ts.filename, extensions)
+	}
+	if rewrite || errors.Is(err, v73.ErrNotExtendable) {
+		err = replaceFile(ts.filename, func(tmp *os.File) error {
+			if err := tmp.Close(); err != nil {
+				return err
+			}
+			return ts.write(tmp.Name())
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", ts.filename, err)
+	}
+
+	for _, ext := range extensions {
+		ts.stored[ext.Name] += len(ext.Data)
+	}
+	clear(ts.pending)
+	return nil
+}
+
+// write writes the variables of the file, if it exists, and the channels
+// with their pending samples to path.
+func (ts *TimeSeriesWriter) write(path string) (err error) {
+	var variables []*types.Variable
+	if _, statErr := os.Stat(ts.filename); statErr == nil {
+		matFile, _, err := openForRepack(ts.filename)
+		if err != nil {
+			return err
+		}
+		variables = matFile.Variables
+	}
+
+	w, err := Create(path, Version73, ts.opts...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, w.Close())
+	}()
+
+	written := make(map[string]bool)
+	for _, v := range variables {
+		if _, ok := ts.stored[v.Name]; !ok {
+			if err := w.WriteVariable(v); err != nil {
+				return err
+			}
+			continue
+		}
+		samples, ok := v.Data.([]float64)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrNotChannel, v.Name)
+		}
+		if err := ts.writeChannel(w, v.Name, slices.Concat(samples, ts.pending[v.Name])); err != nil {
+			return err
+		}
+		written[v.Name] = true
+	}
+	for _, name := range ts.names {
+		if !written[name] {
+			if err := ts.writeChannel(w, name, ts.pending[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeChannel writes a channel as an extendable dataset.
+func (ts *TimeSeriesWriter) writeChannel(w *MatFileWriter, name string, samples []float64) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	v := &types.Variable{Name: name, Dimensions: []int{1, len(samples)}, DataType: types.Double, Data: samples}
+	if err := w.runHooks(v); err != nil {
+		return err
+	}
+	if err := w.checkReservedName(name); err != nil {
+		return err
+	}
+	if err := w.v73writer.WriteExtendable(v, TimeSeriesChunk); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	w.recordName(name)
+	return nil
+}
+
+// Close flushes the samples and closes the time series.
+func (ts *TimeSeriesWriter) Close() error {
+	if ts.closed {
+		return nil
+	}
+	err := ts.Flush()
+	ts.closed = true
+	return err
+}
@@ -0,0 +1,195 @@
+package matlab
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+func TestTimeSeriesWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.mat")
+
+	// First session creates the file, with a variable that is not a channel
+	ts, err := OpenTimeSeries(path)
+	if err != nil {
+		t.Fatalf("OpenTimeSeries() error = %v", err)
+	}
+	if err := ts.AppendSamples("t", 0, 1, 2); err != nil {
+		t.Fatalf("AppendSamples() error = %v", err)
+	}
+	if err := ts.AppendSamples("v", 10, 11); err != nil {
+		t.Fatalf("AppendSamples() error = %v", err)
+	}
+	if err := ts.AppendSamples("v", 12); err != nil {
+		t.Fatalf("AppendSamples() error = %v", err)
+	}
+	if err := ts.AppendSamples("1bad", 0); err == nil {
+		t.Error("AppendSamples(invalid name) error = nil")
+	}
+	if err := ts.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := ts.AppendSamples("t", 3); err == nil {
+		t.Error("AppendSamples() after Close error = nil")
+	}
+
+	// Add a non-channel variable between sessions
+	matFile := readMatFile(t, path)
+	w, err := Create(path, Version73)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	unit := &types.Variable{Name: "unit", Dimensions: []int{1, 1}, DataType: types.Char, Data: "V"}
+	if err := w.WriteVariables(append(matFile.Variables, unit)...); err != nil {
+		t.Fatalf("WriteVariables() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Second session continues the channels
+	ts, err = OpenTimeSeries(path)
+	if err != nil {
+		t.Fatalf("OpenTimeSeries() error = %v", err)
+	}
+	if got := ts.Channels(); !reflect.DeepEqual(got, []string{"t", "v"}) {
+		t.Errorf("Channels() = %v", got)
+	}
+	if ts.Len("t") != 3 || ts.Len("missing") != 0 {
+		t.Errorf("Len() = %d, %d", ts.Len("t"), ts.Len("missing"))
+	}
+	if err := ts.AppendSamples("unit", 1); !errors.Is(err, ErrNotChannel) {
+		t.Errorf("AppendSamples(unit) error = %v, want ErrNotChannel", err)
+	}
+	if err := ts.AppendSamples("t", 3, 4); err != nil {
+		t.Fatalf("AppendSamples() error = %v", err)
+	}
+	if err := ts.AppendSamples("v", 13, 14); err != nil {
+		t.Fatalf("AppendSamples() error = %v", err)
+	}
+	// Spans several chunks
+	big := make([]float64, TimeSeriesChunk+100)
+	for i := range big {
+		big[i] = float64(i)
+	}
+	if err := ts.AppendSamples("big", big...); err != nil {
+		t.Fatalf("AppendSamples() error = %v", err)
+	}
+	if err := ts.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if err := ts.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	matFile = readMatFile(t, path)
+	want := map[string][]float64{"t": {0, 1, 2, 3, 4}, "v": {10, 11, 12, 13, 14}, "big": big}
+	for name, samples := range want {
+		v := matFile.GetVariable(name)
+		if v == nil {
+			t.Fatalf("variable %q not found", name)
+		}
+		if !reflect.DeepEqual(v.Dimensions, []int{1, len(samples)}) || !reflect.DeepEqual(v.Data, samples) {
+			t.Errorf("%s = %v %v, want %v", name, v.Dimensions, v.Data, samples)
+		}
+	}
+	if v := matFile.GetVariable("unit"); v == nil || v.Data != "V" {
+		t.Errorf("unit = %v", v)
+	}
+
+	for _, node := range matFile.HDF5Tree().Children {
+		if node.Path == "/t" && !strings.HasPrefix(node.Layout, "chunked") {
+			t.Errorf("/t layout = %q, want chunked", node.Layout)
+		}
+	}
+
+	// Third session extends the channels in place, without rewriting the file
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, err = OpenTimeSeries(path)
+	if err != nil {
+		t.Fatalf("OpenTimeSeries() error = %v", err)
+	}
+	if ts.Len("big") != len(big) {
+		t.Errorf("Len(big) = %d, want %d", ts.Len("big"), len(big))
+	}
+	for range 2 {
+		if err := ts.AppendSamples("t", float64(ts.Len("t"))); err != nil {
+			t.Fatalf("AppendSamples() error = %v", err)
+		}
+		if err := ts.AppendSamples("big", big[:TimeSeriesChunk]...); err != nil {
+			t.Fatalf("AppendSamples() error = %v", err)
+		}
+		if err := ts.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+	}
+	if err := ts.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) {
+		t.Error("file was replaced, want appends in place")
+	}
+
+	matFile = readMatFile(t, path)
+	want["t"] = []float64{0, 1, 2, 3, 4, 5, 6}
+	want["big"] = slices.Concat(big, big[:TimeSeriesChunk], big[:TimeSeriesChunk])
+	for name, samples := range want {
+		if v := matFile.GetVariable(name); v == nil || !reflect.DeepEqual(v.Data, samples) {
+			t.Errorf("%s after in-place appends = %v, want %d samples", name, v, len(samples))
+		}
+	}
+}
+
+func TestOpenTimeSeries_WholeFileOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.mat")
+	for _, opt := range []Option{WithGzip(), WithEncryption(make([]byte, 32))} {
+		if _, err := OpenTimeSeries(path, opt); err == nil {
+			t.Error("OpenTimeSeries() error = nil, want unsupported option")
+		}
+	}
+}
+
+func TestOpenTimeSeries_V5(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v5.mat")
+	w, err := Create(path, Version5)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := w.WriteVariable(&types.Variable{Name: "t", Dimensions: []int{1, 1}, DataType: types.Double, Data: []float64{1}}); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := OpenTimeSeries(path); err == nil {
+		t.Error("OpenTimeSeries(v5 file) error = nil")
+	}
+}
+
+// readMatFile opens and reads the MAT-file at path.
+func readMatFile(t *testing.T, path string) *MatFile {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("os.Open() error = %v", err)
+	}
+	defer file.Close()
+	matFile, err := Open(file)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return matFile
+}
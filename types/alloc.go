@@ -0,0 +1,212 @@
+package types
+
+import "reflect"
+
+// Allocator provides the slices that numeric data is decoded into, so
+// services loading many files can take them from memory they manage
+// instead of the garbage-collected heap (see Arena). Each method returns
+// a slice of length n. The decoder overwrites every element, so the
+// slices need not be zeroed.
+type Allocator interface {
+	Float64s(n int) []float64
+	Float32s(n int) []float32
+	Int8s(n int) []int8
+	Uint8s(n int) []uint8
+	Int16s(n int) []int16
+	Uint16s(n int) []uint16
+	Int32s(n int) []int32
+	Uint32s(n int) []uint32
+	Int64s(n int) []int64
+	Uint64s(n int) []uint64
+}
+
+// HeapAllocator is the Allocator used by default: it allocates each
+// slice with make.
+type HeapAllocator struct{}
+
+// Float64s returns make([]float64, n).
+func (HeapAllocator) Float64s(n int) []float64 { return make([]float64, n) }
+
+// Float32s returns make([]float32, n).
+func (HeapAllocator) Float32s(n int) []float32 { return make([]float32, n) }
+
+// Int8s returns make([]int8, n).
+func (HeapAllocator) Int8s(n int) []int8 { return make([]int8, n) }
+
+// Uint8s returns make([]uint8, n).
+func (HeapAllocator) Uint8s(n int) []uint8 { return make([]uint8, n) }
+
+// Int16s returns make([]int16, n).
+func (HeapAllocator) Int16s(n int) []int16 { return make([]int16, n) }
+
+// Uint16s returns make([]uint16, n).
+func (HeapAllocator) Uint16s(n int) []uint16 { return make([]uint16, n) }
+
+// Int32s returns make([]int32, n).
+func (HeapAllocator) Int32s(n int) []int32 { return make([]int32, n) }
+
+// Uint32s returns make([]uint32, n).
+func (HeapAllocator) Uint32s(n int) []uint32 { return make([]uint32, n) }
+
+// Int64s returns make([]int64, n).
+func (HeapAllocator) Int64s(n int) []int64 { return make([]int64, n) }
+
+// Uint64s returns make([]uint64, n).
+func (HeapAllocator) Uint64s(n int) []uint64 { return make([]uint64, n) }
+
+// Arena is an Allocator that carves slices out of large chunks of memory
+// and reuses the chunks after Reset. A service that loads one file at a
+// time, processes it and calls Reset allocates almost nothing per file
+// once its chunks have grown to the size of the data, which removes most
+// of the garbage collection work of bulk loads.
+//
+// Slices returned before Reset must not be used after it: their memory
+// is handed out again. Requests larger than the chunk size get memory of
+// their own, which is not reused. An Arena is not safe for concurrent
+// use; give each worker its own.
+//
+// Example:
+//
+//	arena := types.NewArena(1 << 20)
+//	for _, path := range paths {
+//	    matFile, err := matlab.Open(file, matlab.WithAllocator(arena))
+//	    // ... process matFile, keeping none of its data
+//	    arena.Reset()
+//	}
+type Arena struct {
+	chunk int                    // Elements per chunk
+	slabs map[reflect.Type]*slab // Chunks of each element type
+}
+
+// slab holds the chunks of one element type.
+type slab struct {
+	chunks []reflect.Value // Slices of the arena's chunk length
+	cur    int             // Index of the chunk being carved
+	used   int             // Elements of chunks[cur] handed out
+}
+
+// NewArena returns an arena whose chunks hold chunk elements each; chunk
+// below 1024 selects 1024.
+func NewArena(chunk int) *Arena {
+	if chunk < 1024 {
+		chunk = 1024
+	}
+	return &Arena{chunk: chunk, slabs: make(map[reflect.Type]*slab)}
+}
+
+// Reset makes all memory of the arena available again. Slices returned
+// earlier must no longer be used.
+func (a *Arena) Reset() {
+	for _, s := range a.slabs {
+		s.cur, s.used = 0, 0
+	}
+}
+
+// Bytes returns the memory the arena holds for reuse.
+func (a *Arena) Bytes() int64 {
+	var total int64
+	for elem, s := range a.slabs {
+		total += int64(len(s.chunks)) * int64(a.chunk) * int64(elem.Size())
+	}
+	return total
+}
+
+// alloc returns a slice of n elements of type elem.
+func (a *Arena) alloc(elem reflect.Type, n int) interface{} {
+	if n > a.chunk {
+		return reflect.MakeSlice(reflect.SliceOf(elem), n, n).Interface()
+	}
+	s := a.slabs[elem]
+	if s == nil {
+		s = &slab{}
+		a.slabs[elem] = s
+	}
+	if s.cur < len(s.chunks) && s.used+n > a.chunk {
+		s.cur, s.used = s.cur+1, 0
+	}
+	if s.cur == len(s.chunks) {
+		s.chunks = append(s.chunks, reflect.MakeSlice(reflect.SliceOf(elem), a.chunk, a.chunk))
+	}
+	out := s.chunks[s.cur].Slice3(s.used, s.used+n, s.used+n)
+	s.used += n
+	return out.Interface()
+}
+
+// Float64s returns n float64 elements of the arena.
+func (a *Arena) Float64s(n int) []float64 {
+	return a.alloc(reflect.TypeOf(float64(0)), n).([]float64)
+}
+
+// Float32s returns n float32 elements of the arena.
+func (a *Arena) Float32s(n int) []float32 {
+	return a.alloc(reflect.TypeOf(float32(0)), n).([]float32)
+}
+
+// Int8s returns n int8 elements of the arena.
+func (a *Arena) Int8s(n int) []int8 {
+	return a.alloc(reflect.TypeOf(int8(0)), n).([]int8)
+}
+
+// Uint8s returns n uint8 elements of the arena.
+func (a *Arena) Uint8s(n int) []uint8 {
+	return a.alloc(reflect.TypeOf(uint8(0)), n).([]uint8)
+}
+
+// Int16s returns n int16 elements of the arena.
+func (a *Arena) Int16s(n int) []int16 {
+	return a.alloc(reflect.TypeOf(int16(0)), n).([]int16)
+}
+
+// Uint16s returns n uint16 elements of the arena.
+func (a *Arena) Uint16s(n int) []uint16 {
+	return a.alloc(reflect.TypeOf(uint16(0)), n).([]uint16)
+}
+
+// Int32s returns n int32 elements of the arena.
+func (a *Arena) Int32s(n int) []int32 {
+	return a.alloc(reflect.TypeOf(int32(0)), n).([]int32)
+}
+
+// Uint32s returns n uint32 elements of the arena.
+func (a *Arena) Uint32s(n int) []uint32 {
+	return a.alloc(reflect.TypeOf(uint32(0)), n).([]uint32)
+}
+
+// Int64s returns n int64 elements of the arena.
+func (a *Arena) Int64s(n int) []int64 {
+	return a.alloc(reflect.TypeOf(int64(0)), n).([]int64)
+}
+
+// Uint64s returns n uint64 elements of the arena.
+func (a *Arena) Uint64s(n int) []uint64 {
+	return a.alloc(reflect.TypeOf(uint64(0)), n).([]uint64)
+}
+
+// MakeSlice returns a slice of n elements of the numeric type elem from
+// alloc, or nil if elem is not one of the types an Allocator provides.
+func MakeSlice(alloc Allocator, elem reflect.Kind, n int) interface{} {
+	switch elem {
+	case reflect.Float64:
+		return alloc.Float64s(n)
+	case reflect.Float32:
+		return alloc.Float32s(n)
+	case reflect.Int8:
+		return alloc.Int8s(n)
+	case reflect.Uint8:
+		return alloc.Uint8s(n)
+	case reflect.Int16:
+		return alloc.Int16s(n)
+	case reflect.Uint16:
+		return alloc.Uint16s(n)
+	case reflect.Int32:
+		return alloc.Int32s(n)
+	case reflect.Uint32:
+		return alloc.Uint32s(n)
+	case reflect.Int64:
+		return alloc.Int64s(n)
+	case reflect.Uint64:
+		return alloc.Uint64s(n)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,47 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArena(t *testing.T) {
+	a := NewArena(0) // Raised to 1024
+	x := a.Float64s(1000)
+	y := a.Float64s(100) // Does not fit after x: starts a second chunk
+	if len(x) != 1000 || cap(x) != 1000 || len(y) != 100 || cap(y) != 100 {
+		t.Fatalf("len, cap = %d, %d and %d, %d", len(x), cap(x), len(y), cap(y))
+	}
+	if got := a.Bytes(); got != 2*1024*8 {
+		t.Errorf("Bytes() = %d, want %d", got, 2*1024*8)
+	}
+
+	x[0] = 1
+	a.Reset()
+	if z := a.Float64s(1); &z[0] != &x[0] {
+		t.Error("Reset did not reuse the first chunk")
+	}
+	if big := a.Int32s(5000); len(big) != 5000 {
+		t.Errorf("len(Int32s(5000)) = %d", len(big))
+	}
+	if got := a.Bytes(); got != 2*1024*8 {
+		t.Errorf("Bytes() = %d after an oversized request, want %d", got, 2*1024*8)
+	}
+}
+
+func TestMakeSlice(t *testing.T) {
+	for _, alloc := range []Allocator{HeapAllocator{}, NewArena(1024)} {
+		for _, kind := range []reflect.Kind{
+			reflect.Float64, reflect.Float32, reflect.Int8, reflect.Uint8, reflect.Int16,
+			reflect.Uint16, reflect.Int32, reflect.Uint32, reflect.Int64, reflect.Uint64,
+		} {
+			v := reflect.ValueOf(MakeSlice(alloc, kind, 3))
+			if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != kind || v.Len() != 3 {
+				t.Errorf("%T: MakeSlice(%v, 3) = %v", alloc, kind, v.Type())
+			}
+		}
+		if MakeSlice(alloc, reflect.String, 3) != nil {
+			t.Errorf("%T: MakeSlice(String) is not nil", alloc)
+		}
+	}
+}
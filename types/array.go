@@ -40,6 +40,40 @@ func (c CharArray) Size() int { return numElements(c.Dimensions) }
 // ElementType returns the data type of elements.
 func (c CharArray) ElementType() DataType { return Char }
 
+// StructArray represents a MATLAB struct array.
+//
+// Elements are stored in column-major order. Each element holds one
+// Variable per field, in the same order as Fields; the Variable names
+// match the field names.
+type StructArray struct {
+	Fields     []string      // Field names in declaration order
+	Elements   [][]*Variable // Elements[i][j] is field Fields[j] of element i
+	Dimensions []int         // Array dimensions (1x1 for a scalar struct)
+}
+
+// Dims returns the array dimensions.
+func (s StructArray) Dims() []int { return s.Dimensions }
+
+// Size returns the total number of elements.
+func (s StructArray) Size() int { return numElements(s.Dimensions) }
+
+// ElementType returns the data type of elements.
+func (s StructArray) ElementType() DataType { return Struct }
+
+// Field returns the value of a field for the element at the given
+// linear (column-major) index, or nil if either does not exist.
+func (s StructArray) Field(index int, name string) *Variable {
+	if index < 0 || index >= len(s.Elements) {
+		return nil
+	}
+	for j, field := range s.Fields {
+		if field == name && j < len(s.Elements[index]) {
+			return s.Elements[index][j]
+		}
+	}
+	return nil
+}
+
 // numElements calculates total elements from dimensions.
 func numElements(dims []int) int {
 	if len(dims) == 0 {
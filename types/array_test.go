@@ -161,3 +161,34 @@ func TestArrayInterface(_ *testing.T) {
 	var _ Array = NumericArray{}
 	var _ Array = CharArray{}
 }
+
+func TestStructArray(t *testing.T) {
+	x := &Variable{Name: "x", Dimensions: []int{1, 1}, DataType: Double, Data: []float64{1}}
+	y := &Variable{Name: "y", Dimensions: []int{1, 1}, DataType: Double, Data: []float64{2}}
+	s := StructArray{
+		Fields:     []string{"x", "y"},
+		Elements:   [][]*Variable{{x, y}},
+		Dimensions: []int{1, 1},
+	}
+
+	if s.Size() != 1 {
+		t.Errorf("Size() = %d, want 1", s.Size())
+	}
+	if s.ElementType() != Struct {
+		t.Errorf("ElementType() = %v, want struct", s.ElementType())
+	}
+	if len(s.Dims()) != 2 {
+		t.Errorf("Dims() = %v, want [1 1]", s.Dims())
+	}
+	if got := s.Field(0, "y"); got != y {
+		t.Errorf("Field(0, y) = %v, want %v", got, y)
+	}
+	if got := s.Field(0, "z"); got != nil {
+		t.Errorf("Field(0, z) = %v, want nil", got)
+	}
+	if got := s.Field(1, "x"); got != nil {
+		t.Errorf("Field(1, x) = %v, want nil", got)
+	}
+
+	var _ Array = s
+}
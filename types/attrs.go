@@ -0,0 +1,97 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// HDF5 attribute names with which v7.3 MAT-files describe their datasets
+// and groups. The reader and writer use these names, and code writing
+// v7.3 files through the HDF5 library directly should use them with the
+// value types given here (see CheckMatlabAttr), so MATLAB loads the data.
+const (
+	// AttrMatlabClass names the MATLAB class of a dataset or group: a
+	// string such as "double", "char", "logical", "struct", "cell" or an
+	// object class such as "datetime".
+	AttrMatlabClass = "MATLAB_class"
+
+	// AttrMatlabComplex marks a group holding the "real" and "imag"
+	// datasets of a complex array; the value is uint8 1.
+	AttrMatlabComplex = "MATLAB_complex"
+
+	// AttrMatlabSparse marks a group holding the "data", "ir" and "jc"
+	// datasets of a sparse matrix; the value is the number of rows as a
+	// uint64.
+	AttrMatlabSparse = "MATLAB_sparse"
+
+	// AttrMatlabEmpty marks an empty array, whose dataset holds the
+	// array's dimensions instead of data; the value is uint8 1.
+	AttrMatlabEmpty = "MATLAB_empty"
+
+	// AttrMatlabIntDecode tells how the integers of a dataset decode; the
+	// value is an int32, IntDecodeLogical or IntDecodeChar.
+	AttrMatlabIntDecode = "MATLAB_int_decode"
+
+	// AttrMatlabGlobal marks a variable declared global; the value is
+	// uint8 1.
+	AttrMatlabGlobal = "MATLAB_global"
+
+	// AttrMatlabObjectDecode tells how MATLAB reconstructs an object whose
+	// class is named by AttrMatlabClass; the value is an int32, one of the
+	// ObjectDecode constants.
+	AttrMatlabObjectDecode = "MATLAB_object_decode"
+)
+
+// Values of AttrMatlabIntDecode.
+const (
+	IntDecodeLogical int32 = 1 // uint8 data holds a logical array
+	IntDecodeChar    int32 = 2 // uint16 data holds UTF-16 code units of a char array
+)
+
+// Values of AttrMatlabObjectDecode.
+const (
+	ObjectDecodeFunctionHandle int32 = 1 // Function handle
+	ObjectDecodeStruct         int32 = 2 // Object stored as a group of its properties, like a struct
+	ObjectDecodeOpaque         int32 = 3 // classdef object stored as references into #subsystem#
+)
+
+// ErrMatlabAttr indicates a MATLAB attribute value of the wrong type.
+var ErrMatlabAttr = errors.New("invalid MATLAB attribute value")
+
+// CheckMatlabAttr checks that value has the Go type the MATLAB attribute
+// name is written with, as listed with the Attr constants. Names of other
+// attributes are accepted with any value.
+//
+// Example:
+//
+//	if err := types.CheckMatlabAttr(types.AttrMatlabIntDecode, decode); err != nil {
+//	    return err
+//	}
+//	err := dataset.WriteAttribute(types.AttrMatlabIntDecode, decode)
+func CheckMatlabAttr(name string, value interface{}) error {
+	var ok bool
+	var want string
+	switch name {
+	case AttrMatlabClass:
+		_, ok = value.(string)
+		want = "string"
+	case AttrMatlabComplex, AttrMatlabEmpty, AttrMatlabGlobal:
+		ok = value == uint8(1)
+		want = "uint8 1"
+	case AttrMatlabSparse:
+		_, ok = value.(uint64)
+		want = "uint64"
+	case AttrMatlabIntDecode:
+		ok = value == IntDecodeLogical || value == IntDecodeChar
+		want = "int32 1 or 2"
+	case AttrMatlabObjectDecode:
+		ok = value == ObjectDecodeFunctionHandle || value == ObjectDecodeStruct || value == ObjectDecodeOpaque
+		want = "int32 1, 2 or 3"
+	default:
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s is %T %v, want %s", ErrMatlabAttr, name, value, value, want)
+	}
+	return nil
+}
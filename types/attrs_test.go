@@ -0,0 +1,39 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckMatlabAttr(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		ok    bool
+	}{
+		{AttrMatlabClass, "double", true},
+		{AttrMatlabClass, []byte("double"), false},
+		{AttrMatlabComplex, uint8(1), true},
+		{AttrMatlabComplex, int32(1), false},
+		{AttrMatlabEmpty, uint8(1), true},
+		{AttrMatlabGlobal, uint8(0), false},
+		{AttrMatlabSparse, uint64(5), true},
+		{AttrMatlabSparse, int64(5), false},
+		{AttrMatlabIntDecode, IntDecodeLogical, true},
+		{AttrMatlabIntDecode, IntDecodeChar, true},
+		{AttrMatlabIntDecode, int32(3), false},
+		{AttrMatlabIntDecode, uint8(1), false},
+		{AttrMatlabObjectDecode, ObjectDecodeStruct, true},
+		{AttrMatlabObjectDecode, int32(4), false},
+		{"units", 3.5, true},
+	}
+	for _, tt := range tests {
+		err := CheckMatlabAttr(tt.name, tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("CheckMatlabAttr(%s, %T %v) error = %v, want ok = %v", tt.name, tt.value, tt.value, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrMatlabAttr) {
+			t.Errorf("CheckMatlabAttr(%s, %v) error = %v, want ErrMatlabAttr", tt.name, tt.value, err)
+		}
+	}
+}
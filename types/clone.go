@@ -0,0 +1,207 @@
+package types
+
+import "reflect"
+
+// Clone returns a deep copy of the variable.
+//
+// Dimensions, Data, Attributes and the data returned by RawBytes and
+// Location are copied, so the clone can be mutated without affecting the
+// original. Data payloads are copied recursively:
+// numeric and logical slices, strings, *NumericArray, *CharArray,
+// *StructArray, *SparseMatrix, *Variable, []*Variable (cell contents),
+// slices and maps of these. Pointers to other types (for example HDF5 attribute
+// handles on v7.3 variables) are shared, not copied.
+//
+// Example:
+//
+//	scaled := v.Clone()
+//	for i := range scaled.Data.([]float64) {
+//	    scaled.Data.([]float64)[i] *= 2
+//	}
+func (v *Variable) Clone() *Variable {
+	if v == nil {
+		return nil
+	}
+	clone := *v
+	clone.Dimensions = cloneInts(v.Dimensions)
+	clone.Data = cloneData(v.Data)
+	if v.Attributes != nil {
+		clone.Attributes = make(map[string]interface{}, len(v.Attributes))
+		for name, value := range v.Attributes {
+			clone.Attributes[name] = cloneData(value)
+		}
+	}
+	if v.raw != nil {
+		raw := *v.raw
+		raw.Bytes = cloneData(v.raw.Bytes).([]byte)
+		raw.Dims = cloneInts(v.raw.Dims)
+		clone.raw = &raw
+	}
+	if v.location != nil {
+		location := *v.location
+		clone.location = &location
+	}
+	return &clone
+}
+
+// Clone returns a deep copy of the array.
+func (n *NumericArray) Clone() *NumericArray {
+	if n == nil {
+		return nil
+	}
+	return &NumericArray{
+		Real:       cloneData(n.Real),
+		Imag:       cloneData(n.Imag),
+		Dimensions: cloneInts(n.Dimensions),
+		Type:       n.Type,
+	}
+}
+
+// Clone returns a deep copy of the array.
+func (c *CharArray) Clone() *CharArray {
+	if c == nil {
+		return nil
+	}
+	clone := &CharArray{Dimensions: cloneInts(c.Dimensions)}
+	if c.Data != nil {
+		clone.Data = append([]rune(nil), c.Data...)
+	}
+	return clone
+}
+
+// Clone returns a deep copy of the array, including every field value.
+func (s *StructArray) Clone() *StructArray {
+	if s == nil {
+		return nil
+	}
+	clone := &StructArray{Dimensions: cloneInts(s.Dimensions)}
+	if s.Fields != nil {
+		clone.Fields = append([]string(nil), s.Fields...)
+	}
+	if s.Elements != nil {
+		clone.Elements = make([][]*Variable, len(s.Elements))
+		for i, element := range s.Elements {
+			clone.Elements[i] = cloneVariables(element)
+		}
+	}
+	return clone
+}
+
+// Clone returns a deep copy of the matrix.
+func (s *SparseMatrix) Clone() *SparseMatrix {
+	if s == nil {
+		return nil
+	}
+	clone := *s
+	clone.RowIndices = cloneInts(s.RowIndices)
+	clone.ColPointers = cloneInts(s.ColPointers)
+	clone.Real = cloneData(s.Real)
+	if s.Imag != nil {
+		clone.Imag = append([]float64(nil), s.Imag...)
+	}
+	return &clone
+}
+
+// cloneInts copies an int slice, preserving nil.
+func cloneInts(values []int) []int {
+	if values == nil {
+		return nil
+	}
+	return append([]int(nil), values...)
+}
+
+// cloneVariables deep-copies a slice of variables, preserving nil.
+func cloneVariables(vars []*Variable) []*Variable {
+	if vars == nil {
+		return nil
+	}
+	clone := make([]*Variable, len(vars))
+	for i, v := range vars {
+		clone[i] = v.Clone()
+	}
+	return clone
+}
+
+// cloneData deep-copies a Data payload or attribute value.
+func cloneData(data interface{}) interface{} {
+	switch d := data.(type) {
+	case nil:
+		return nil
+	case *Variable:
+		return d.Clone()
+	case []*Variable:
+		return cloneVariables(d)
+	case *NumericArray:
+		return d.Clone()
+	case NumericArray:
+		return *d.Clone()
+	case *CharArray:
+		return d.Clone()
+	case CharArray:
+		return *d.Clone()
+	case *StructArray:
+		return d.Clone()
+	case StructArray:
+		return *d.Clone()
+	case *SparseMatrix:
+		return d.Clone()
+	}
+
+	rv := reflect.ValueOf(data)
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.IsNil() {
+			return data
+		}
+		clone := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		if isFlat(rv.Type().Elem()) {
+			reflect.Copy(clone, rv)
+		} else {
+			for i := 0; i < rv.Len(); i++ {
+				setCloned(clone.Index(i), rv.Index(i))
+			}
+		}
+		return clone.Interface()
+	case reflect.Map:
+		if rv.IsNil() {
+			return data
+		}
+		clone := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			value := reflect.New(rv.Type().Elem()).Elem()
+			setCloned(value, iter.Value())
+			clone.SetMapIndex(iter.Key(), value)
+		}
+		return clone.Interface()
+	default:
+		// Scalars and strings are values; other pointers are shared.
+		return data
+	}
+}
+
+// setCloned stores a deep copy of src into dst.
+func setCloned(dst, src reflect.Value) {
+	if src.Kind() == reflect.Interface && src.IsNil() {
+		return
+	}
+	if cloned := cloneData(src.Interface()); cloned != nil {
+		dst.Set(reflect.ValueOf(cloned))
+	}
+}
+
+// isFlat reports whether values of type t contain no references, so a
+// shallow copy is a deep copy.
+func isFlat(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Array:
+		return isFlat(t.Elem())
+	default:
+		return false
+	}
+}
@@ -0,0 +1,151 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVariable_Clone_Payloads(t *testing.T) {
+	field := &Variable{Name: "f", Dimensions: []int{1, 2}, DataType: Double, Data: []float64{1, 2}}
+
+	tests := []struct {
+		name string
+		data interface{}
+	}{
+		{"nil", nil},
+		{"float64", []float64{1, 2, 3}},
+		{"float32", []float32{1, 2}},
+		{"int8", []int8{-1, 2}},
+		{"uint8", []uint8{1, 255}},
+		{"int16", []int16{-1, 2}},
+		{"uint16", []uint16{1, 2}},
+		{"int32", []int32{-1, 2}},
+		{"uint32", []uint32{1, 2}},
+		{"int64", []int64{-1, 2}},
+		{"uint64", []uint64{1, 2}},
+		{"bool", []bool{true, false}},
+		{"string", "hello"},
+		{"strings", []string{"a", "b"}},
+		{"numeric array", &NumericArray{Real: []float64{1}, Imag: []float64{2}, Dimensions: []int{1, 1}, Type: Double}},
+		{"char array", &CharArray{Data: []rune("hi"), Dimensions: []int{1, 2}}},
+		{"struct array", &StructArray{Fields: []string{"f"}, Elements: [][]*Variable{{field}}, Dimensions: []int{1, 1}}},
+		{"cell contents", []*Variable{field, nil}},
+		{"nested slices", [][]float64{{1, 2}, nil, {3}}},
+		{"interface slice", []interface{}{1.0, []int32{1}, nil}},
+		{"map", map[string]interface{}{"a": []float64{1}, "b": "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Variable{
+				Name:       "v",
+				Dimensions: []int{1, 2},
+				DataType:   Double,
+				Data:       tt.data,
+				Attributes: map[string]interface{}{"MATLAB_class": "double", "scale": []float64{2}},
+			}
+
+			clone := v.Clone()
+			if !reflect.DeepEqual(clone, v) {
+				t.Fatalf("Clone() = %#v, want %#v", clone, v)
+			}
+			if clone == v {
+				t.Fatal("Clone() returned the same pointer")
+			}
+
+			// Mutating the clone must leave the original untouched
+			before := v.Clone()
+			clone.Dimensions[0] = 99
+			clone.Attributes["scale"].([]float64)[0] = 99
+			clone.Attributes["new"] = true
+			mutate(clone.Data)
+			if !reflect.DeepEqual(v, before) {
+				t.Errorf("original changed after mutating clone:\n%#v\nwant\n%#v", v, before)
+			}
+		})
+	}
+}
+
+// mutate changes the first element reachable in a payload. Every test
+// payload has a non-zero first element.
+func mutate(data interface{}) {
+	switch d := data.(type) {
+	case *NumericArray:
+		d.Real.([]float64)[0] = 99
+		d.Dimensions[0] = 99
+	case *CharArray:
+		d.Data[0] = 'X'
+	case *StructArray:
+		d.Fields[0] = "changed"
+		d.Elements[0][0].Data.([]float64)[0] = 99
+	case []*Variable:
+		d[0].Data.([]float64)[0] = 99
+	case [][]float64:
+		d[0][0] = 99
+	case []interface{}:
+		d[1].([]int32)[0] = 99
+	case map[string]interface{}:
+		d["a"].([]float64)[0] = 99
+	default:
+		rv := reflect.ValueOf(data)
+		if rv.Kind() == reflect.Slice && rv.Len() > 0 {
+			rv.Index(0).Set(reflect.Zero(rv.Type().Elem()))
+		}
+	}
+}
+
+func TestClone_Nil(t *testing.T) {
+	if (*Variable)(nil).Clone() != nil {
+		t.Error("nil Variable Clone() should be nil")
+	}
+	if (*NumericArray)(nil).Clone() != nil {
+		t.Error("nil NumericArray Clone() should be nil")
+	}
+	if (*CharArray)(nil).Clone() != nil {
+		t.Error("nil CharArray Clone() should be nil")
+	}
+	if (*StructArray)(nil).Clone() != nil {
+		t.Error("nil StructArray Clone() should be nil")
+	}
+}
+
+func TestClone_ValuePayloads(t *testing.T) {
+	n := NumericArray{Real: []float64{1}, Dimensions: []int{1, 1}}
+	clone := cloneData(n).(NumericArray)
+	clone.Real.([]float64)[0] = 2
+	if n.Real.([]float64)[0] != 1 {
+		t.Error("NumericArray value payload shares its backing array")
+	}
+
+	s := StructArray{Fields: []string{"a"}, Dimensions: []int{1, 1}}
+	if got := cloneData(s); !reflect.DeepEqual(got, s) {
+		t.Errorf("StructArray value clone = %#v", got)
+	}
+	c := CharArray{Data: []rune("a")}
+	if got := cloneData(c); !reflect.DeepEqual(got, c) {
+		t.Errorf("CharArray value clone = %#v", got)
+	}
+}
+
+func TestClone_RawBytesAndLocation(t *testing.T) {
+	v := &Variable{Name: "x", Dimensions: []int{1, 2}, DataType: Double, Data: []float64{1, 2}}
+	v.SetRawBytes(&RawData{Bytes: []byte{1, 2, 3, 4}, Datatype: "float", ElementSize: 8, Dims: []int{2, 1}})
+	v.SetLocation(Location{Offset: 128, Length: 16})
+
+	clone := v.Clone()
+	raw, err := clone.RawBytes()
+	if err != nil {
+		t.Fatalf("clone RawBytes() error = %v", err)
+	}
+	raw.Bytes[0] = 9
+	raw.Dims[0] = 9
+	clone.SetLocation(Location{Offset: 0})
+
+	orig, _ := v.RawBytes()
+	if !reflect.DeepEqual(orig, &RawData{Bytes: []byte{1, 2, 3, 4}, Datatype: "float", ElementSize: 8, Dims: []int{2, 1}}) {
+		t.Errorf("original RawBytes() = %+v after changing the clone's", orig)
+	}
+	if loc, _ := v.Location(); loc.Offset != 128 {
+		t.Errorf("original Location() = %+v after changing the clone's", loc)
+	}
+}
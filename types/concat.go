@@ -0,0 +1,228 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrDimensionMismatch indicates arrays whose dimensions do not agree for
+// an operation, such as concatenation.
+var ErrDimensionMismatch = errors.New("dimensions do not agree")
+
+// Concat concatenates variables along the zero-based dimension dim, as
+// MATLAB's cat(dim+1, ...) does: 0 stacks rows, 1 appends columns, and
+// higher dimensions add pages. The variables must agree in every other
+// dimension, arrays with fewer dimensions having trailing dimensions of
+// size 1; 0x0 arrays are left out, as MATLAB ignores []. Elements are
+// taken in column-major order.
+//
+// Numeric and logical arrays, real or complex, are promoted to their
+// common class (see Promote); cell arrays concatenate with cell arrays,
+// sharing their contents. The result is named after the first variable
+// and has no attributes.
+//
+// Returns an error wrapping ErrDimensionMismatch if the dimensions do not
+// agree and one wrapping ErrNotPromotable if the classes cannot be
+// combined, or if a variable is sparse, a struct, char or object array.
+//
+// Example:
+//
+//	// A 2x3 and a 2x1 matrix side by side: 2x4
+//	joined, err := types.Concat(1, a, b)
+func Concat(dim int, vars ...*Variable) (*Variable, error) {
+	if dim < 0 {
+		return nil, fmt.Errorf("invalid concatenation dimension %d", dim)
+	}
+	if len(vars) == 0 {
+		return nil, errors.New("no variables to concatenate")
+	}
+	name := vars[0].Name
+	vars = withoutEmpty(vars)
+
+	cells := true
+	for _, v := range vars {
+		cells = cells && v.DataType == CellArray
+	}
+	if !cells {
+		// Real data is held as a plain slice or a *NumericArray without an
+		// imaginary part; promote and join the plain form
+		plain := make([]*Variable, len(vars))
+		for i, v := range vars {
+			plain[i] = realSlice(v)
+		}
+		promoted, err := Promote(plain...)
+		if err != nil {
+			return nil, fmt.Errorf("cannot concatenate %s: %w", name, err)
+		}
+		vars = promoted
+	}
+
+	first := vars[0]
+	shapes := make([][]int, len(vars))
+	for i, v := range vars {
+		shapes[i] = v.Dimensions
+	}
+	dims, err := ConcatDims(dim, shapes...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot concatenate %s: %w", name, err)
+	}
+	ndims := len(dims)
+
+	result := &Variable{Name: name, Dimensions: dims, DataType: first.DataType, IsComplex: first.IsComplex}
+	join := func(part func(*Variable) interface{}) (interface{}, error) {
+		outer, stride := blocks(dims, dim)
+		srcs := make([]reflect.Value, len(vars))
+		for i, v := range vars {
+			srcs[i] = reflect.ValueOf(part(v))
+			if srcs[i].Kind() != reflect.Slice || (i > 0 && srcs[i].Type() != srcs[0].Type()) {
+				return nil, fmt.Errorf("cannot concatenate %s: data is %T", name, part(v))
+			}
+			if n := numElements(padDims(v.Dimensions, ndims)); srcs[i].Len() != n {
+				return nil, fmt.Errorf("%s: data holds %d elements, want %d", name, srcs[i].Len(), n)
+			}
+		}
+		dst := reflect.MakeSlice(srcs[0].Type(), 0, numElements(dims))
+		for o := range outer {
+			for i, v := range vars {
+				n := stride * padDims(v.Dimensions, dim+1)[dim]
+				dst = reflect.AppendSlice(dst, srcs[i].Slice(o*n, (o+1)*n))
+			}
+		}
+		return dst.Interface(), nil
+	}
+
+	if array, ok := first.Data.(*NumericArray); ok && result.IsComplex {
+		re, err := join(func(v *Variable) interface{} { return numericPart(v, false) })
+		if err != nil {
+			return nil, err
+		}
+		im, err := join(func(v *Variable) interface{} { return numericPart(v, true) })
+		if err != nil {
+			return nil, err
+		}
+		result.Data = &NumericArray{Real: re, Imag: im, Dimensions: dims, Type: array.Type}
+		return result, nil
+	}
+	data, err := join(func(v *Variable) interface{} { return v.Data })
+	if err != nil {
+		return nil, err
+	}
+	result.Data = data
+	return result, nil
+}
+
+// ConcatDims returns the dimensions of the concatenation along the
+// zero-based dimension dim of arrays with the given dimensions, as Concat
+// computes them: the arrays must agree in every other dimension, arrays
+// with fewer dimensions having trailing dimensions of size 1. Unlike
+// Concat, it does not leave out 0x0 arrays.
+//
+// Returns an error wrapping ErrDimensionMismatch if the dimensions do not
+// agree.
+//
+// Example:
+//
+//	dims, err := types.ConcatDims(1, []int{2, 3}, []int{2, 1}) // [2 4]
+func ConcatDims(dim int, dims ...[]int) ([]int, error) {
+	if dim < 0 {
+		return nil, fmt.Errorf("invalid concatenation dimension %d", dim)
+	}
+	if len(dims) == 0 {
+		return nil, errors.New("no dimensions to concatenate")
+	}
+	ndims := dim + 1
+	for _, d := range dims {
+		ndims = max(ndims, len(d))
+	}
+	first := padDims(dims[0], ndims)
+	result := append([]int(nil), first...)
+	result[dim] = 0
+	for _, d := range dims {
+		padded := padDims(d, ndims)
+		if !sameExcept(padded, first, dim) {
+			return nil, fmt.Errorf("%w: %v and %v along dimension %d", ErrDimensionMismatch, dims[0], d, dim)
+		}
+		result[dim] += padded[dim]
+	}
+	return result, nil
+}
+
+// realSlice returns v with real data held in a *NumericArray as a plain
+// slice, or v itself otherwise.
+func realSlice(v *Variable) *Variable {
+	array, ok := v.Data.(*NumericArray)
+	if !ok || v.IsComplex || array.Imag != nil {
+		return v
+	}
+	copied := *v
+	copied.Data = array.Real
+	return &copied
+}
+
+// withoutEmpty returns vars without 0x0 arrays, or vars if all are.
+func withoutEmpty(vars []*Variable) []*Variable {
+	var kept []*Variable
+	for _, v := range vars {
+		if len(v.Dimensions) != 2 || v.Dimensions[0] != 0 || v.Dimensions[1] != 0 {
+			kept = append(kept, v)
+		}
+	}
+	if len(kept) == 0 {
+		return vars
+	}
+	return kept
+}
+
+// padDims returns a copy of dims with trailing singleton dimensions
+// appended up to n dimensions.
+func padDims(dims []int, n int) []int {
+	padded := append([]int(nil), dims...)
+	for len(padded) < n {
+		padded = append(padded, 1)
+	}
+	return padded
+}
+
+// sameExcept reports whether a and b are equal in every dimension but
+// skip.
+func sameExcept(a, b []int, skip int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if i != skip && a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// blocks returns, for column-major dims, the number of contiguous blocks
+// that make up the array (the product of the dimensions after dim) and
+// the number of elements in one index of dim within a block (the product
+// of the dimensions before it).
+func blocks(dims []int, dim int) (outer, stride int) {
+	outer, stride = 1, 1
+	for i, d := range dims {
+		switch {
+		case i < dim:
+			stride *= d
+		case i > dim:
+			outer *= d
+		}
+	}
+	return outer, stride
+}
+
+// numericPart returns the real or imaginary data of a complex variable.
+func numericPart(v *Variable, imag bool) interface{} {
+	array, ok := v.Data.(*NumericArray)
+	if !ok {
+		return nil
+	}
+	if imag {
+		return array.Imag
+	}
+	return array.Real
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestConcat(t *testing.T) {
+	// 2x2 and 2x1, column-major
+	a := &Variable{Name: "a", Dimensions: []int{2, 2}, DataType: Double, Data: []float64{1, 2, 3, 4}}
+	b := &Variable{Name: "b", Dimensions: []int{2, 1}, DataType: Int8, Data: []int8{5, 6}}
+	empty := &Variable{Name: "e", Dimensions: []int{0, 0}, DataType: Double, Data: []float64{}}
+
+	got, err := Concat(1, a, empty, b)
+	if err != nil {
+		t.Fatalf("Concat(1) error = %v", err)
+	}
+	if got.Name != "a" || got.DataType != Double || !reflect.DeepEqual(got.Dimensions, []int{2, 3}) ||
+		!reflect.DeepEqual(got.Data, []float64{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("Concat(1) = %s %s %v %v", got.Name, got.DataType, got.Dimensions, got.Data)
+	}
+
+	// Rows interleave in column-major order
+	row := &Variable{Name: "r", Dimensions: []int{1, 2}, DataType: Logical, Data: []bool{true, false}}
+	got, err = Concat(0, a, row)
+	if err != nil {
+		t.Fatalf("Concat(0) error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Dimensions, []int{3, 2}) || !reflect.DeepEqual(got.Data, []float64{1, 2, 1, 3, 4, 0}) {
+		t.Errorf("Concat(0) = %v %v", got.Dimensions, got.Data)
+	}
+
+	// Pages, with a complex part
+	z := &Variable{Name: "z", Dimensions: []int{2, 2}, DataType: Single, IsComplex: true,
+		Data: &NumericArray{Real: []float32{5, 6, 7, 8}, Imag: []float32{1, 1, 1, 1}}}
+	got, err = Concat(2, a, z)
+	if err != nil {
+		t.Fatalf("Concat(2) error = %v", err)
+	}
+	array := got.Data.(*NumericArray)
+	if !got.IsComplex || !reflect.DeepEqual(got.Dimensions, []int{2, 2, 2}) ||
+		!reflect.DeepEqual(array.Real, []float64{1, 2, 3, 4, 5, 6, 7, 8}) ||
+		!reflect.DeepEqual(array.Imag, []float64{0, 0, 0, 0, 1, 1, 1, 1}) {
+		t.Errorf("Concat(2) = %v %v %v", got.Dimensions, array.Real, array.Imag)
+	}
+
+	// Real *NumericArray data, as downcasting produces, alone and mixed
+	// with plain slices of another class
+	ra := &Variable{Name: "ra", Dimensions: []int{2, 1}, DataType: Double, Data: &NumericArray{Real: []float64{7, 8}}}
+	got, err = Concat(1, ra, ra)
+	if err != nil {
+		t.Fatalf("Concat(real arrays) error = %v", err)
+	}
+	if got.IsComplex || !reflect.DeepEqual(got.Data, []float64{7, 8, 7, 8}) {
+		t.Errorf("Concat(real arrays) = %v", got.Data)
+	}
+	rs := &Variable{Name: "rs", Dimensions: []int{2, 1}, DataType: Single, Data: &NumericArray{Real: []float32{9, 10}}}
+	got, err = Concat(1, b, rs, a)
+	if err != nil {
+		t.Fatalf("Concat(mixed) error = %v", err)
+	}
+	if got.DataType != Double || !reflect.DeepEqual(got.Data, []float64{5, 6, 9, 10, 1, 2, 3, 4}) {
+		t.Errorf("Concat(mixed) = %s %v", got.DataType, got.Data)
+	}
+	got, err = Concat(1, ra, z)
+	if err != nil {
+		t.Fatalf("Concat(real array, complex) error = %v", err)
+	}
+	if array := got.Data.(*NumericArray); !reflect.DeepEqual(array.Imag, []float64{0, 0, 1, 1, 1, 1}) {
+		t.Errorf("Concat(real array, complex) imag = %v", array.Imag)
+	}
+
+	cell := func(values ...float64) *Variable {
+		var cells []*Variable
+		for _, x := range values {
+			cells = append(cells, &Variable{Dimensions: []int{1, 1}, DataType: Double, Data: []float64{x}})
+		}
+		return &Variable{Name: "c", Dimensions: []int{1, len(values)}, DataType: CellArray, Data: cells}
+	}
+	c1, c2 := cell(1), cell(2, 3)
+	got, err = Concat(1, c1, c2)
+	if err != nil {
+		t.Fatalf("Concat(cells) error = %v", err)
+	}
+	if cells := got.Data.([]*Variable); got.DataType != CellArray || len(cells) != 3 || cells[2] != c2.Data.([]*Variable)[1] {
+		t.Errorf("Concat(cells) = %v %v", got.Dimensions, got.Data)
+	}
+
+	if _, err := Concat(0, a, b); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Concat(0, 2x2, 2x1) error = %v, want ErrDimensionMismatch", err)
+	}
+	if _, err := Concat(1, a, c1); !errors.Is(err, ErrNotPromotable) {
+		t.Errorf("Concat(double, cell) error = %v, want ErrNotPromotable", err)
+	}
+	if _, err := Concat(-1, a); err == nil {
+		t.Error("Concat(-1) error = nil")
+	}
+	if _, err := Concat(0); err == nil {
+		t.Error("Concat() error = nil")
+	}
+}
+
+func TestConcatDims(t *testing.T) {
+	dims, err := ConcatDims(2, []int{2, 3}, []int{2, 3, 4})
+	if err != nil || !reflect.DeepEqual(dims, []int{2, 3, 5}) {
+		t.Errorf("ConcatDims(2) = %v, %v, want [2 3 5]", dims, err)
+	}
+	if _, err := ConcatDims(0, []int{2, 3}, []int{2, 1}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("ConcatDims(0, 2x3, 2x1) error = %v, want ErrDimensionMismatch", err)
+	}
+}
@@ -0,0 +1,133 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrDestinationMismatch indicates a destination slice whose type or
+// length does not match the variable read into it.
+var ErrDestinationMismatch = errors.New("destination does not match variable")
+
+// Dataset is a named n-dimensional array, the read interface shared by
+// the scigolib format packages, so analysis code can consume MATLAB
+// variables, HDF5 datasets and other formats without format-specific
+// branches.
+//
+// DType names the Go element type of the data: "float64", "float32",
+// "int8" to "uint64", "bool" or "complex128". ReadInto copies the
+// elements, in column-major order, into a slice of that type with one
+// element per array element, and returns an error wrapping
+// ErrDestinationMismatch if dst has another type or length.
+type Dataset interface {
+	Name() string
+	Shape() []int
+	DType() string
+	ReadInto(dst interface{}) error
+}
+
+// AsDataset returns a numeric or logical variable as a Dataset. Complex
+// arrays have DType "complex128" whatever their class, and sparse
+// matrices are read as dense arrays. Character, struct, cell and object
+// variables are not datasets and return an error.
+//
+// Example:
+//
+//	ds, err := types.AsDataset(v)
+//	if err != nil {
+//	    return err
+//	}
+//	n := 1
+//	for _, d := range ds.Shape() {
+//	    n *= d
+//	}
+//	buf := make([]float64, n)
+//	err = ds.ReadInto(buf)
+func AsDataset(v *Variable) (Dataset, error) {
+	dtype, ok := datasetTypes[v.DataType]
+	if !ok {
+		return nil, fmt.Errorf("%s: %s variables are not datasets", v.Name, v.DataType)
+	}
+	if v.IsComplex {
+		dtype = "complex128"
+	}
+	return &variableDataset{v: v, dtype: dtype}, nil
+}
+
+// datasetTypes maps the classes AsDataset accepts to their DType.
+var datasetTypes = map[DataType]string{
+	Double: "float64", Single: "float32",
+	Int8: "int8", Uint8: "uint8", Int16: "int16", Uint16: "uint16",
+	Int32: "int32", Uint32: "uint32", Int64: "int64", Uint64: "uint64",
+	Logical: "bool",
+}
+
+// variableDataset adapts a Variable to Dataset.
+type variableDataset struct {
+	v     *Variable
+	dtype string
+}
+
+// Name returns the variable name.
+func (d *variableDataset) Name() string { return d.v.Name }
+
+// Shape returns the dimensions of the variable.
+func (d *variableDataset) Shape() []int { return d.v.Dimensions }
+
+// DType returns the Go element type of the data.
+func (d *variableDataset) DType() string { return d.dtype }
+
+// ReadInto copies the data of the variable into dst.
+func (d *variableDataset) ReadInto(dst interface{}) error {
+	v := d.v
+	if v.IsSparse {
+		dense, err := v.ToDense()
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.Name, err)
+		}
+		v = dense
+	}
+	data := v.Data
+	if v.IsComplex {
+		values, err := complexValues(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.Name, err)
+		}
+		data = values
+	}
+
+	src := reflect.ValueOf(data)
+	value := reflect.ValueOf(dst)
+	if src.Kind() != reflect.Slice {
+		return fmt.Errorf("%s: data is %T, not a slice", v.Name, data)
+	}
+	if !value.IsValid() || value.Type() != src.Type() {
+		return fmt.Errorf("%w: %s needs %v, got %T", ErrDestinationMismatch, v.Name, src.Type(), dst)
+	}
+	if value.Len() != src.Len() {
+		return fmt.Errorf("%w: %s has %d elements, destination has %d", ErrDestinationMismatch, v.Name, src.Len(), value.Len())
+	}
+	reflect.Copy(value, src)
+	return nil
+}
+
+// complexValues returns the elements of a complex array of any numeric
+// class as complex128.
+func complexValues(v *Variable) ([]complex128, error) {
+	numArray, ok := v.Data.(*NumericArray)
+	if !ok {
+		return nil, fmt.Errorf("complex data is %T, not *NumericArray", v.Data)
+	}
+	re, im := reflect.ValueOf(numArray.Real), reflect.ValueOf(numArray.Imag)
+	if re.Kind() != reflect.Slice || im.Kind() != reflect.Slice || re.Len() != im.Len() ||
+		!isNumericKind(re.Type().Elem().Kind()) || !isNumericKind(im.Type().Elem().Kind()) {
+		return nil, fmt.Errorf("real part %T and imaginary part %T do not match", numArray.Real, numArray.Imag)
+	}
+	float64Type := reflect.TypeOf(float64(0))
+	values := make([]complex128, re.Len())
+	for i := range values {
+		values[i] = complex(re.Index(i).Convert(float64Type).Float(), im.Index(i).Convert(float64Type).Float())
+	}
+	return values, nil
+}
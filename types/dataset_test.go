@@ -0,0 +1,67 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAsDataset(t *testing.T) {
+	tests := []struct {
+		name  string
+		v     *Variable
+		dtype string
+		dst   interface{}
+		want  interface{}
+	}{
+		{"double", &Variable{Name: "x", Dimensions: []int{1, 3}, DataType: Double, Data: []float64{1, 2, 3}},
+			"float64", make([]float64, 3), []float64{1, 2, 3}},
+		{"logical", &Variable{Name: "m", Dimensions: []int{2, 1}, DataType: Logical, Data: []bool{true, false}},
+			"bool", make([]bool, 2), []bool{true, false}},
+		{"complex int16", &Variable{Name: "z", Dimensions: []int{1, 2}, DataType: Int16, IsComplex: true,
+			Data: &NumericArray{Real: []int16{1, -2}, Imag: []int16{3, 4}}},
+			"complex128", make([]complex128, 2), []complex128{1 + 3i, -2 + 4i}},
+		{"sparse", &Variable{Name: "s", Dimensions: []int{2, 2}, DataType: Double, IsSparse: true,
+			Data: &SparseMatrix{Rows: 2, Cols: 2, RowIndices: []int{1}, ColPointers: []int{0, 0, 1}, Real: []float64{5}}},
+			"float64", make([]float64, 4), []float64{0, 0, 0, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds, err := AsDataset(tt.v)
+			if err != nil {
+				t.Fatalf("AsDataset() error = %v", err)
+			}
+			if ds.Name() != tt.v.Name || !reflect.DeepEqual(ds.Shape(), tt.v.Dimensions) || ds.DType() != tt.dtype {
+				t.Errorf("dataset = %s %v %s, want %s %v %s",
+					ds.Name(), ds.Shape(), ds.DType(), tt.v.Name, tt.v.Dimensions, tt.dtype)
+			}
+			if err := ds.ReadInto(tt.dst); err != nil {
+				t.Fatalf("ReadInto() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.dst, tt.want) {
+				t.Errorf("ReadInto() = %v, want %v", tt.dst, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsDataset_Errors(t *testing.T) {
+	for _, v := range []*Variable{
+		{Name: "c", Dimensions: []int{1, 2}, DataType: Char, Data: "hi"},
+		{Name: "s", Dimensions: []int{1, 1}, DataType: Struct, Data: &StructArray{}},
+	} {
+		if _, err := AsDataset(v); err == nil {
+			t.Errorf("AsDataset(%s) error = nil", v.DataType)
+		}
+	}
+
+	ds, err := AsDataset(&Variable{Name: "x", Dimensions: []int{1, 2}, DataType: Double, Data: []float64{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dst := range []interface{}{make([]float32, 2), make([]float64, 3), nil} {
+		if err := ds.ReadInto(dst); !errors.Is(err, ErrDestinationMismatch) {
+			t.Errorf("ReadInto(%T len) error = %v, want ErrDestinationMismatch", dst, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package types
+
+import (
+	"math"
+	"time"
+)
+
+// DatenumUnixEpoch is the MATLAB serial date number of 1970-01-01 00:00 UTC.
+const DatenumUnixEpoch = 719529
+
+// DatenumToTime converts a MATLAB serial date number (days since year 0,
+// as returned by datenum and now) to a UTC time, rounded to the
+// microsecond. A double datenum of a present-day date resolves about
+// 10 µs, so times converted to datenums and back may differ by a few
+// microseconds. NaN and infinite datenums return the zero time.
+//
+// Example:
+//
+//	t := types.DatenumToTime(738887.5) // 2023-01-01 12:00:00 UTC
+func DatenumToTime(d float64) time.Time {
+	if math.IsNaN(d) || math.IsInf(d, 0) {
+		return time.Time{}
+	}
+	days := math.Floor(d)
+	micros := math.Round((d - days) * 86400e6)
+	return time.Unix(int64(days-DatenumUnixEpoch)*86400, 0).UTC().
+		Add(time.Duration(micros) * time.Microsecond)
+}
+
+// TimeToDatenum converts a time to a MATLAB serial date number. The time
+// zone is ignored: the datenum describes the instant in UTC.
+func TimeToDatenum(t time.Time) float64 {
+	secs := t.Unix()
+	days := math.Floor(float64(secs) / 86400)
+	rest := float64(secs-int64(days)*86400) + float64(t.Nanosecond())/1e9
+	return days + DatenumUnixEpoch + rest/86400
+}
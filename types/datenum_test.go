@@ -0,0 +1,41 @@
+package types
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDatenum(t *testing.T) {
+	tests := []struct {
+		datenum float64
+		time    time.Time
+	}{
+		{DatenumUnixEpoch, time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{738887.5, time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{367, time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{693962.25, time.Date(1900, 1, 1, 6, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := DatenumToTime(tt.datenum); !got.Equal(tt.time) {
+			t.Errorf("DatenumToTime(%v) = %v, want %v", tt.datenum, got, tt.time)
+		}
+		if got := TimeToDatenum(tt.time); got != tt.datenum {
+			t.Errorf("TimeToDatenum(%v) = %v, want %v", tt.time, got, tt.datenum)
+		}
+	}
+
+	// Round trip within the resolution of a datenum
+	want := time.Date(2024, 2, 29, 23, 59, 59, 123000000, time.UTC)
+	if got := DatenumToTime(TimeToDatenum(want)); got.Sub(want).Abs() > 10*time.Microsecond {
+		t.Errorf("round trip of %v = %v", want, got)
+	}
+	// Time zones do not change the instant
+	local := want.In(time.FixedZone("UTC+5", 5*3600))
+	if TimeToDatenum(local) != TimeToDatenum(want) {
+		t.Error("TimeToDatenum depends on the time zone")
+	}
+	if !DatenumToTime(math.NaN()).IsZero() {
+		t.Error("DatenumToTime(NaN) is not the zero time")
+	}
+}
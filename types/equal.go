@@ -0,0 +1,320 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// EqualOption configures Equal and Compare.
+type EqualOption func(*equalConfig)
+
+// equalConfig holds optional configuration for Compare.
+type equalConfig struct {
+	absTol   float64 // Allowed absolute difference of numeric elements
+	relTol   float64 // Allowed difference relative to the larger magnitude
+	nanEqual bool    // NaN compares equal to NaN
+}
+
+// WithAbsTolerance lets numeric elements differ by up to tol. Combined
+// with WithRelTolerance, elements a and b are equal if
+// |a-b| <= abs + rel*max(|a|, |b|).
+//
+// Default: 0 (exact)
+//
+// Example:
+//
+//	ok := types.Equal(want, got, types.WithAbsTolerance(1e-12))
+func WithAbsTolerance(tol float64) EqualOption {
+	return func(c *equalConfig) {
+		c.absTol = tol
+	}
+}
+
+// WithRelTolerance lets numeric elements differ by up to tol times the
+// larger of their magnitudes, which suits values of very different scale.
+//
+// Default: 0 (exact)
+//
+// Example:
+//
+//	ok := types.Equal(want, got, types.WithRelTolerance(1e-6))
+func WithRelTolerance(tol float64) EqualOption {
+	return func(c *equalConfig) {
+		c.relTol = tol
+	}
+}
+
+// WithNaNEqual makes NaN equal to NaN, as round-trip tests usually want.
+//
+// Default: NaN is unequal to everything, as in IEEE 754
+func WithNaNEqual() EqualOption {
+	return func(c *equalConfig) {
+		c.nanEqual = true
+	}
+}
+
+// Mismatch describes the first difference Compare found.
+type Mismatch struct {
+	// Message describes the difference, for example
+	// "variable x: element 3 is 1.5, want 1.25 (2 of 6 elements differ, max delta 0.25)".
+	Message string
+	// Index is the linear (column-major) index of the first differing
+	// element, or -1 if the variables differ in name, class, complexity,
+	// dimensions or data type.
+	Index int
+	// Count is the number of differing elements in the array where the
+	// difference was found.
+	Count int
+	// MaxDelta is the largest absolute difference between numeric
+	// elements of that array, ignoring NaN and infinite differences.
+	MaxDelta float64
+}
+
+// String returns the message.
+func (m *Mismatch) String() string { return m.Message }
+
+// Equal reports whether two variables hold the same name, class,
+// complexity, dimensions and data, with numeric elements compared under
+// the given tolerances. Struct fields and cell contents are compared
+// recursively. Attributes are not compared, since v7.3 files add their
+// own. Nil and empty dimensions are equal.
+//
+// Example:
+//
+//	if !types.Equal(want, got, types.WithRelTolerance(1e-9), types.WithNaNEqual()) {
+//	    t.Errorf("round trip changed %s", want.Name)
+//	}
+func Equal(want, got *Variable, opts ...EqualOption) bool {
+	return Compare(want, got, opts...) == nil
+}
+
+// Compare compares two variables like Equal and describes the first
+// difference, or returns nil if they are equal. For element differences
+// the mismatch reports the first differing index, the number of differing
+// elements and the largest difference.
+//
+// Example:
+//
+//	if m := types.Compare(want, got, types.WithAbsTolerance(1e-6)); m != nil {
+//	    t.Errorf("%s (first at %d, max delta %g)", m, m.Index, m.MaxDelta)
+//	}
+func Compare(want, got *Variable, opts ...EqualOption) *Mismatch {
+	cfg := &equalConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg.compareVariables(want, got)
+}
+
+// compareVariables compares two variables.
+func (c *equalConfig) compareVariables(want, got *Variable) *Mismatch {
+	if want == nil || got == nil {
+		if want == got {
+			return nil
+		}
+		return structural("variable is %v, want %v", got, want)
+	}
+	var m *Mismatch
+	switch {
+	case got.Name != want.Name:
+		m = structural("name %q, want %q", got.Name, want.Name)
+	case got.DataType != want.DataType:
+		m = structural("class %v, want %v", got.DataType, want.DataType)
+	case got.IsComplex != want.IsComplex:
+		m = structural("complex %v, want %v", got.IsComplex, want.IsComplex)
+	case !equalDims(got.Dimensions, want.Dimensions):
+		m = structural("dimensions %v, want %v", got.Dimensions, want.Dimensions)
+	default:
+		m = c.compareData(want.Data, got.Data)
+	}
+	return m.prefix("variable " + want.Name + ": ")
+}
+
+// compareData compares variable data: slices, strings, complex parts and
+// struct fields.
+func (c *equalConfig) compareData(want, got interface{}) *Mismatch {
+	switch w := want.(type) {
+	case *NumericArray:
+		g, ok := got.(*NumericArray)
+		if !ok {
+			return structural("data is %T, want %T", got, want)
+		}
+		if m := c.compareValues(w.Real, g.Real); m != nil {
+			return m.prefix("real part: ")
+		}
+		return c.compareValues(w.Imag, g.Imag).prefix("imaginary part: ")
+	case *StructArray:
+		g, ok := got.(*StructArray)
+		if !ok {
+			return structural("data is %T, want %T", got, want)
+		}
+		return c.compareStruct(w, g)
+	default:
+		return c.compareValues(want, got)
+	}
+}
+
+// compareStruct compares struct arrays field by field.
+func (c *equalConfig) compareStruct(want, got *StructArray) *Mismatch {
+	if !reflect.DeepEqual(got.Fields, want.Fields) {
+		return structural("fields %v, want %v", got.Fields, want.Fields)
+	}
+	if len(got.Elements) != len(want.Elements) {
+		return structural("%d struct elements, want %d", len(got.Elements), len(want.Elements))
+	}
+	for i := range want.Elements {
+		for j, field := range want.Fields {
+			if j >= len(want.Elements[i]) || j >= len(got.Elements[i]) {
+				break
+			}
+			if m := c.compareVariables(want.Elements[i][j], got.Elements[i][j]); m != nil {
+				return m.prefix(fmt.Sprintf("element %d field %s: ", i, field))
+			}
+		}
+	}
+	return nil
+}
+
+// compareValues compares two data values element by element.
+func (c *equalConfig) compareValues(want, got interface{}) *Mismatch {
+	wv, gv := reflect.ValueOf(want), reflect.ValueOf(got)
+	if !wv.IsValid() || !gv.IsValid() {
+		if wv.IsValid() == gv.IsValid() {
+			return nil
+		}
+		return structural("data is %T, want %T", got, want)
+	}
+	if wv.Type() != gv.Type() {
+		return structural("data is %T, want %T", got, want)
+	}
+	if wv.Kind() != reflect.Slice {
+		if !reflect.DeepEqual(want, got) {
+			return structural("data is %v, want %v", got, want)
+		}
+		return nil
+	}
+	if gv.Len() != wv.Len() {
+		return structural("%d elements, want %d", gv.Len(), wv.Len())
+	}
+
+	// Cell contents are variables themselves
+	if cells, ok := want.([]*Variable); ok {
+		for i, cell := range cells {
+			if m := c.compareVariables(cell, got.([]*Variable)[i]); m != nil {
+				return m.prefix(fmt.Sprintf("cell %d: ", i))
+			}
+		}
+		return nil
+	}
+
+	numeric := isNumericKind(wv.Type().Elem().Kind())
+	var m *Mismatch
+	for i := 0; i < wv.Len(); i++ {
+		equal, delta := c.compareElement(wv.Index(i), gv.Index(i))
+		if numeric && delta > 0 && !math.IsInf(delta, 0) && !math.IsNaN(delta) {
+			if m == nil {
+				m = &Mismatch{Index: -1}
+			}
+			m.MaxDelta = math.Max(m.MaxDelta, delta)
+		}
+		if equal {
+			continue
+		}
+		if m == nil {
+			m = &Mismatch{Index: -1}
+		}
+		if m.Index < 0 {
+			m.Index = i
+			m.Message = fmt.Sprintf("element %d is %v, want %v", i, gv.Index(i), wv.Index(i))
+		}
+		m.Count++
+	}
+	if m == nil || m.Count == 0 {
+		return nil
+	}
+	switch {
+	case numeric:
+		m.Message += fmt.Sprintf(" (%d of %d elements differ, max delta %g)", m.Count, wv.Len(), m.MaxDelta)
+	case m.Count > 1:
+		m.Message += fmt.Sprintf(" (%d of %d elements differ)", m.Count, wv.Len())
+	}
+	return m
+}
+
+// compareElement compares two slice elements and returns their absolute
+// difference if they are numeric.
+func (c *equalConfig) compareElement(want, got reflect.Value) (bool, float64) {
+	switch want.Kind() {
+	case reflect.Float32, reflect.Float64:
+		w, g := want.Float(), got.Float()
+		if math.IsNaN(w) || math.IsNaN(g) {
+			return c.nanEqual && math.IsNaN(w) && math.IsNaN(g), 0
+		}
+		return w == g || c.withinTolerance(math.Abs(w-g), w, g), math.Abs(w - g)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// The difference is taken in integers: float64 cannot tell large
+		// 64-bit values one apart
+		w, g := want.Int(), got.Int()
+		delta := float64(uint64(w - g)) //nolint:gosec // G115: two's complement distance
+		if w < g {
+			delta = float64(uint64(g - w)) //nolint:gosec // G115: two's complement distance
+		}
+		return w == g || c.withinTolerance(delta, float64(w), float64(g)), delta
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		w, g := want.Uint(), got.Uint()
+		delta := float64(w - g)
+		if w < g {
+			delta = float64(g - w)
+		}
+		return w == g || c.withinTolerance(delta, float64(w), float64(g)), delta
+	default:
+		return reflect.DeepEqual(want.Interface(), got.Interface()), 0
+	}
+}
+
+// withinTolerance reports whether two different numbers w and g, delta
+// apart, are equal under the tolerances.
+func (c *equalConfig) withinTolerance(delta, w, g float64) bool {
+	if c.absTol == 0 && c.relTol == 0 {
+		return false
+	}
+	return delta <= c.absTol+c.relTol*math.Max(math.Abs(w), math.Abs(g))
+}
+
+// isNumericKind reports whether slice elements of kind k are numbers.
+func isNumericKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// structural returns a mismatch that is not an element difference.
+func structural(format string, args ...interface{}) *Mismatch {
+	return &Mismatch{Message: fmt.Sprintf(format, args...), Index: -1}
+}
+
+// prefix prepends context to the message of a non-nil mismatch.
+func (m *Mismatch) prefix(context string) *Mismatch {
+	if m != nil {
+		m.Message = context + m.Message
+	}
+	return m
+}
+
+// equalDims compares dimensions, treating nil and empty as equal.
+func equalDims(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package types
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	vector := func(data ...float64) *Variable {
+		return &Variable{Name: "x", Dimensions: []int{1, len(data)}, DataType: Double, Data: data}
+	}
+	want := vector(1, 2, 3, 1e6)
+
+	tests := []struct {
+		name     string
+		got      *Variable
+		opts     []EqualOption
+		equal    bool
+		message  string
+		index    int
+		count    int
+		maxDelta float64
+	}{
+		{name: "identical", got: vector(1, 2, 3, 1e6), equal: true},
+		{name: "exact by default", got: vector(1, 2.001, 3, 1e6), message: "variable x: element 1 is 2.001, want 2 (1 of 4 elements differ",
+			index: 1, count: 1, maxDelta: 0.001},
+		{name: "absolute", got: vector(1, 2.001, 3, 1e6+0.5), opts: []EqualOption{WithAbsTolerance(0.01)},
+			message: "element 3 is", index: 3, count: 1, maxDelta: 0.5},
+		{name: "relative", got: vector(1, 2, 3, 1e6+0.5), opts: []EqualOption{WithRelTolerance(1e-6)}, equal: true},
+		{name: "counts all", got: vector(0, 2, 4, 1e6), message: "(2 of 4 elements differ, max delta 1)", index: 0, count: 2, maxDelta: 1},
+		{name: "dims", got: &Variable{Name: "x", Dimensions: []int{4, 1}, DataType: Double, Data: []float64{1, 2, 3, 1e6}},
+			message: "dimensions [4 1], want [1 4]", index: -1},
+		{name: "class", got: &Variable{Name: "x", Dimensions: []int{1, 4}, DataType: Single, Data: []float32{1, 2, 3, 1e6}},
+			message: "class single, want double", index: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Compare(want, tt.got, tt.opts...)
+			if Equal(want, tt.got, tt.opts...) != tt.equal || (m == nil) != tt.equal {
+				t.Fatalf("Equal() = %v, Compare() = %v, want equal %v", !tt.equal, m, tt.equal)
+			}
+			if m == nil {
+				return
+			}
+			if !strings.Contains(m.String(), tt.message) || m.Index != tt.index || m.Count != tt.count ||
+				math.Abs(m.MaxDelta-tt.maxDelta) > 1e-9 {
+				t.Errorf("Compare() = %+v", m)
+			}
+		})
+	}
+}
+
+func TestCompare_NaN(t *testing.T) {
+	a := &Variable{Name: "n", Dimensions: []int{1, 2}, DataType: Single, Data: []float32{float32(math.NaN()), 1}}
+	b := a.Clone()
+	if Equal(a, b) {
+		t.Error("NaN equal without WithNaNEqual")
+	}
+	if !Equal(a, b, WithNaNEqual()) {
+		t.Error("NaN unequal with WithNaNEqual")
+	}
+}
+
+func TestCompare_Nested(t *testing.T) {
+	build := func(gain float64, imag int16, cell string) *Variable {
+		return &Variable{Name: "s", Dimensions: []int{1, 1}, DataType: Struct, Data: &StructArray{
+			Fields:     []string{"gain", "z", "c"},
+			Dimensions: []int{1, 1},
+			Elements: [][]*Variable{{
+				{Name: "gain", Dimensions: []int{1, 1}, DataType: Double, Data: []float64{gain}},
+				{Name: "z", Dimensions: []int{1, 1}, DataType: Int16, IsComplex: true,
+					Data: &NumericArray{Real: []int16{1}, Imag: []int16{imag}}},
+				{Name: "c", Dimensions: []int{1, 1}, DataType: CellArray, Data: []*Variable{
+					{Name: "", Dimensions: []int{1, len(cell)}, DataType: Char, Data: cell},
+				}},
+			}},
+		}}
+	}
+	want := build(1, 2, "ab")
+
+	if m := Compare(want, build(1.5, 2, "ab"), WithAbsTolerance(1)); m != nil {
+		t.Errorf("within tolerance: %v", m)
+	}
+	if m := Compare(want, build(1, 3, "ab")); m == nil || !strings.Contains(m.Message, "element 0 field z: variable z: imaginary part: element 0 is 3, want 2") {
+		t.Errorf("complex field: %v", m)
+	}
+	if m := Compare(want, build(1, 2, "ac")); m == nil || !strings.Contains(m.Message, "field c: variable c: cell 0: variable : data is ac, want ab") {
+		t.Errorf("cell field: %v", m)
+	}
+}
+
+func TestCompare_Integers(t *testing.T) {
+	a := &Variable{Name: "i", Dimensions: []int{1, 2}, DataType: Int64, Data: []int64{1<<62 + 1, 5}}
+	b := &Variable{Name: "i", Dimensions: []int{1, 2}, DataType: Int64, Data: []int64{1 << 62, 5}}
+	if Equal(a, b) {
+		t.Error("int64 values one apart compared equal")
+	}
+	if !Equal(a, b, WithAbsTolerance(1)) {
+		t.Error("int64 values within tolerance compared unequal")
+	}
+	if !Equal(nil, nil) || Equal(a, nil) {
+		t.Error("nil handling")
+	}
+}
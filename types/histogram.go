@@ -0,0 +1,159 @@
+package types
+
+import (
+	"fmt"
+	"math"
+)
+
+// Histogram holds the distribution of the values of a real numeric or
+// logical variable, with summary statistics, as computed by
+// Variable.Histogram.
+type Histogram struct {
+	Edges  []float64 // Bin edges, one more than Counts; the last bin includes its upper edge
+	Counts []int     // Values in each bin
+	Count  int       // Values counted, NaN excluded
+	NaN    int       // NaN values, counted in no bin
+	Min    float64   // Smallest value, NaN if Count is 0
+	Max    float64   // Largest value, NaN if Count is 0
+	Mean   float64   // Mean, NaN if Count is 0
+	Std    float64   // Sample standard deviation (normalized by Count-1, as MATLAB's std), NaN if Count is 0
+}
+
+// Histogram returns the histogram of the values of a real numeric or
+// logical variable in bins of equal width spanning the finite values, as
+// MATLAB's histcounts(x, bins) does, with the count, minimum, maximum,
+// mean and standard deviation of the values. -Inf and Inf are counted in
+// the first and last bin, and NaN separately; the mean and standard
+// deviation of values with infinities are those MATLAB gives.
+//
+// The values are streamed from the variable's data in two passes, so no
+// converted copy is allocated however large the variable is; a part of a
+// huge variable taken with NewVariableFromSlice works the same way.
+//
+// Returns an error for complex and non-numeric data and if bins is less
+// than 1.
+//
+// Example:
+//
+//	h, err := v.Histogram(20)
+//	if err != nil {
+//	    return err
+//	}
+//	fmt.Printf("median %g, 95th percentile %g\n", h.Percentile(50), h.Percentile(95))
+func (v *Variable) Histogram(bins int) (*Histogram, error) {
+	if bins < 1 {
+		return nil, fmt.Errorf("invalid number of bins %d", bins)
+	}
+	values, err := v.Iter()
+	if err != nil {
+		return nil, err
+	}
+
+	// First pass: limits and moments of the finite values (Welford's algorithm)
+	h := &Histogram{Min: math.Inf(1), Max: math.Inf(-1), Counts: make([]int, bins)}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	finite := 0
+	var mean, m2 float64
+	for _, x := range values {
+		if math.IsNaN(x) {
+			h.NaN++
+			continue
+		}
+		h.Count++
+		h.Min, h.Max = math.Min(h.Min, x), math.Max(h.Max, x)
+		if math.IsInf(x, 0) {
+			continue
+		}
+		finite++
+		lo, hi = math.Min(lo, x), math.Max(hi, x)
+		delta := x - mean
+		mean += delta / float64(finite)
+		m2 += delta * (x - mean)
+	}
+	h.Mean, h.Std = mean, 0
+	if finite > 1 {
+		h.Std = math.Sqrt(m2 / float64(finite-1))
+	}
+	negInf, posInf := math.IsInf(h.Min, -1), math.IsInf(h.Max, 1)
+	switch {
+	case h.Count == 0:
+		h.Min, h.Max, h.Mean, h.Std = math.NaN(), math.NaN(), math.NaN(), math.NaN()
+	case negInf && posInf:
+		h.Mean, h.Std = math.NaN(), math.NaN()
+	case negInf || posInf:
+		h.Mean, h.Std = h.Min+h.Max, math.NaN() // The infinity
+	}
+
+	switch {
+	case lo > hi: // No finite values
+		lo, hi = 0, 1
+	case lo == hi:
+		lo, hi = lo-0.5, hi+0.5
+	}
+	h.Edges = make([]float64, bins+1)
+	for i := range h.Edges {
+		h.Edges[i] = lo + (hi-lo)*float64(i)/float64(bins)
+	}
+	h.Edges[bins] = hi
+
+	// Second pass: counts
+	for _, x := range values {
+		if !math.IsNaN(x) {
+			h.Counts[h.bin(x)]++
+		}
+	}
+	return h, nil
+}
+
+// bin returns the index of the bin holding x.
+func (h *Histogram) bin(x float64) int {
+	bins := len(h.Counts)
+	lo, hi := h.Edges[0], h.Edges[bins]
+	switch {
+	case x <= lo:
+		return 0
+	case x >= hi:
+		return bins - 1
+	}
+	i := int(float64(bins) * (x - lo) / (hi - lo))
+	// Rounding may put x next to the bin its edges give
+	for i > 0 && x < h.Edges[i] {
+		i--
+	}
+	for i < bins-1 && x >= h.Edges[i+1] {
+		i++
+	}
+	return i
+}
+
+// Percentile returns an estimate of the p-th percentile (0 to 100) of the
+// values, interpolated linearly within the bin that holds it, so it is
+// within one bin width of the exact value: use more bins for a closer
+// estimate. Percentile(0) and Percentile(100) are the exact minimum and
+// maximum. Returns NaN if no values were counted.
+//
+// Example:
+//
+//	h, _ := v.Histogram(1000)
+//	p5, p95 := h.Percentile(5), h.Percentile(95)
+func (h *Histogram) Percentile(p float64) float64 {
+	switch {
+	case h.Count == 0 || math.IsNaN(p):
+		return math.NaN()
+	case p <= 0:
+		return h.Min
+	case p >= 100:
+		return h.Max
+	}
+	rank := p / 100 * float64(h.Count)
+	below := 0.0
+	for i, n := range h.Counts {
+		if n == 0 || below+float64(n) < rank {
+			below += float64(n)
+			continue
+		}
+		x := h.Edges[i] + (rank-below)/float64(n)*(h.Edges[i+1]-h.Edges[i])
+		return math.Max(h.Min, math.Min(h.Max, x))
+	}
+	return h.Max
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestVariable_Histogram(t *testing.T) {
+	v := &Variable{Dimensions: []int{2, 5}, DataType: Int16, Data: []int16{0, 1, 2, 3, 4, 5, 6, 7, 8, 10}}
+	h, err := v.Histogram(5)
+	if err != nil {
+		t.Fatalf("Histogram() error = %v", err)
+	}
+	if want := []float64{0, 2, 4, 6, 8, 10}; !reflect.DeepEqual(h.Edges, want) {
+		t.Errorf("Edges = %v, want %v", h.Edges, want)
+	}
+	if want := []int{2, 2, 2, 2, 2}; !reflect.DeepEqual(h.Counts, want) {
+		t.Errorf("Counts = %v, want %v", h.Counts, want)
+	}
+	if h.Count != 10 || h.Min != 0 || h.Max != 10 || h.Mean != 4.6 {
+		t.Errorf("Count, Min, Max, Mean = %d, %g, %g, %g", h.Count, h.Min, h.Max, h.Mean)
+	}
+	if want := math.Sqrt(92.4 / 9); math.Abs(h.Std-want) > 1e-12 {
+		t.Errorf("Std = %g, want %g", h.Std, want)
+	}
+	for _, tt := range []struct{ p, want float64 }{{0, 0}, {50, 5}, {90, 9}, {100, 10}} {
+		if got := h.Percentile(tt.p); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("Percentile(%g) = %g, want %g", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestVariable_Histogram_Special(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+	h, err := (&Variable{Dimensions: []int{1, 5}, DataType: Double, Data: []float64{2, nan, -inf, 2, inf}}).Histogram(3)
+	if err != nil {
+		t.Fatalf("Histogram() error = %v", err)
+	}
+	if !reflect.DeepEqual(h.Edges, []float64{1.5, 1.8333333333333333, 2.1666666666666665, 2.5}) {
+		t.Errorf("Edges = %v, want [1.5 1.83 2.17 2.5]", h.Edges)
+	}
+	if !reflect.DeepEqual(h.Counts, []int{1, 2, 1}) || h.Count != 4 || h.NaN != 1 {
+		t.Errorf("Counts = %v, Count %d, NaN %d", h.Counts, h.Count, h.NaN)
+	}
+	if !math.IsInf(h.Min, -1) || !math.IsInf(h.Max, 1) || !math.IsNaN(h.Mean) || !math.IsNaN(h.Std) {
+		t.Errorf("Min, Max, Mean, Std = %g, %g, %g, %g", h.Min, h.Max, h.Mean, h.Std)
+	}
+
+	empty, err := (&Variable{Dimensions: []int{0, 0}, DataType: Logical, Data: []bool{}}).Histogram(2)
+	if err != nil {
+		t.Fatalf("Histogram(empty) error = %v", err)
+	}
+	if empty.Count != 0 || !math.IsNaN(empty.Mean) || !math.IsNaN(empty.Percentile(50)) {
+		t.Errorf("empty histogram = %+v", empty)
+	}
+
+	if _, err := (&Variable{DataType: Char, Data: "abc"}).Histogram(2); err == nil {
+		t.Error("Histogram(char) error = nil, want an error")
+	}
+	if _, err := (&Variable{DataType: Double, Data: []float64{1}}).Histogram(0); err == nil {
+		t.Error("Histogram(0 bins) error = nil, want an error")
+	}
+}
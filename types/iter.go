@@ -0,0 +1,215 @@
+package types
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Iter returns an iterator over the elements of a real numeric or logical
+// variable as (linear index, value) pairs, in MATLAB's column-major order.
+// Each element is converted to float64 as it is yielded, so unlike
+// GetFloat64Array no converted copy of the data is allocated, which
+// matters for large integer arrays. Logical elements yield 1 or 0.
+//
+// Returns an error for complex and non-numeric data.
+//
+// Example:
+//
+//	values, err := v.Iter()
+//	if err != nil {
+//	    return err
+//	}
+//	sum := 0.0
+//	for _, x := range values {
+//	    sum += x
+//	}
+//
+//nolint:gocyclo,cyclop // One case per element type
+func (v *Variable) Iter() (iter.Seq2[int, float64], error) {
+	if v.IsComplex {
+		return nil, fmt.Errorf("cannot iterate complex data as float64")
+	}
+
+	switch data := v.Data.(type) {
+	case []float64:
+		return func(yield func(int, float64) bool) {
+			for i, val := range data {
+				if !yield(i, val) {
+					return
+				}
+			}
+		}, nil
+	case []float32:
+		return func(yield func(int, float64) bool) {
+			for i, val := range data {
+				if !yield(i, float64(val)) {
+					return
+				}
+			}
+		}, nil
+	case []int8:
+		return func(yield func(int, float64) bool) {
+			for i, val := range data {
+				if !yield(i, float64(val)) {
+					return
+				}
+			}
+		}, nil
+	case []int16:
+		return func(yield func(int, float64) bool) {
+			for i, val := range data {
+				if !yield(i, float64(val)) {
+					return
+				}
+			}
+		}, nil
+	case []int32:
+		return func(yield func(int, float64) bool) {
+			for i, val := range data {
+				if !yield(i, float64(val)) {
+					return
+				}
+			}
+		}, nil
+	case []int64:
+		return func(yield func(int, float64) bool) {
+			for i, val := range data {
+				if !yield(i, float64(val)) {
+					return
+				}
+			}
+		}, nil
+	case []uint8:
+		return func(yield func(int, float64) bool) {
+			for i, val := range data {
+				if !yield(i, float64(val)) {
+					return
+				}
+			}
+		}, nil
+	case []uint16:
+		return func(yield func(int, float64) bool) {
+			for i, val := range data {
+				if !yield(i, float64(val)) {
+					return
+				}
+			}
+		}, nil
+	case []uint32:
+		return func(yield func(int, float64) bool) {
+			for i, val := range data {
+				if !yield(i, float64(val)) {
+					return
+				}
+			}
+		}, nil
+	case []uint64:
+		return func(yield func(int, float64) bool) {
+			for i, val := range data {
+				if !yield(i, float64(val)) {
+					return
+				}
+			}
+		}, nil
+	case []bool:
+		return func(yield func(int, float64) bool) {
+			for i, val := range data {
+				x := 0.0
+				if val {
+					x = 1
+				}
+				if !yield(i, x) {
+					return
+				}
+			}
+		}, nil
+	default:
+		return nil, fmt.Errorf("cannot iterate %T as float64", v.Data)
+	}
+}
+
+// Rows returns an iterator over the rows of a real numeric or logical 2-D
+// variable, each converted to float64. Rows are gathered from MATLAB's
+// column-major storage one at a time into a single buffer of one element
+// per column, so processing a matrix row by row allocates no more than
+// one row, whatever the matrix size. The yielded slice is reused for the
+// next row; copy it to keep it.
+//
+// Returns an error for complex and non-numeric data and for arrays that
+// are not 2-D.
+//
+// Example:
+//
+//	rows, err := v.Rows()
+//	if err != nil {
+//	    return err
+//	}
+//	for row := range rows {
+//	    fmt.Fprintln(w, row)
+//	}
+func (v *Variable) Rows() (iter.Seq[[]float64], error) {
+	if v.IsComplex {
+		return nil, fmt.Errorf("cannot iterate complex data as float64")
+	}
+	if len(v.Dimensions) != 2 {
+		return nil, fmt.Errorf("cannot iterate rows: array has %d dimensions, want 2", len(v.Dimensions))
+	}
+	at, n, err := float64At(v.Data)
+	if err != nil {
+		return nil, err
+	}
+	rows, cols := v.Dimensions[0], v.Dimensions[1]
+	if rows*cols != n {
+		return nil, fmt.Errorf("cannot iterate rows: %d elements do not match dimensions %dx%d", n, rows, cols)
+	}
+
+	return func(yield func([]float64) bool) {
+		row := make([]float64, cols)
+		for i := 0; i < rows; i++ {
+			for j := range row {
+				row[j] = at(i + j*rows)
+			}
+			if !yield(row) {
+				return
+			}
+		}
+	}, nil
+}
+
+// float64At returns a function reading element i of numeric or logical
+// data as float64, and the number of elements.
+//
+//nolint:gocyclo,cyclop // One case per element type
+func float64At(data interface{}) (func(i int) float64, int, error) {
+	switch d := data.(type) {
+	case []float64:
+		return func(i int) float64 { return d[i] }, len(d), nil
+	case []float32:
+		return func(i int) float64 { return float64(d[i]) }, len(d), nil
+	case []int8:
+		return func(i int) float64 { return float64(d[i]) }, len(d), nil
+	case []int16:
+		return func(i int) float64 { return float64(d[i]) }, len(d), nil
+	case []int32:
+		return func(i int) float64 { return float64(d[i]) }, len(d), nil
+	case []int64:
+		return func(i int) float64 { return float64(d[i]) }, len(d), nil
+	case []uint8:
+		return func(i int) float64 { return float64(d[i]) }, len(d), nil
+	case []uint16:
+		return func(i int) float64 { return float64(d[i]) }, len(d), nil
+	case []uint32:
+		return func(i int) float64 { return float64(d[i]) }, len(d), nil
+	case []uint64:
+		return func(i int) float64 { return float64(d[i]) }, len(d), nil
+	case []bool:
+		return func(i int) float64 {
+			if d[i] {
+				return 1
+			}
+			return 0
+		}, len(d), nil
+	default:
+		return nil, 0, fmt.Errorf("cannot iterate %T as float64", data)
+	}
+}
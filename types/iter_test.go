@@ -0,0 +1,149 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVariable_Iter(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want []float64
+	}{
+		{"float64", []float64{1.5, -2}, []float64{1.5, -2}},
+		{"float32", []float32{0.5}, []float64{0.5}},
+		{"int8", []int8{-128, 127}, []float64{-128, 127}},
+		{"int16", []int16{-3}, []float64{-3}},
+		{"int32", []int32{1 << 30}, []float64{1 << 30}},
+		{"int64", []int64{-1 << 40}, []float64{-1 << 40}},
+		{"uint8", []uint8{255}, []float64{255}},
+		{"uint16", []uint16{65535}, []float64{65535}},
+		{"uint32", []uint32{1 << 31}, []float64{1 << 31}},
+		{"uint64", []uint64{1 << 63}, []float64{1 << 63}},
+		{"logical", []bool{true, false}, []float64{1, 0}},
+		{"empty", []int32{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := (&Variable{Data: tt.data}).Iter()
+			if err != nil {
+				t.Fatalf("Iter() error = %v", err)
+			}
+			var got []float64
+			for i, x := range values {
+				if i != len(got) {
+					t.Fatalf("index %d, want %d", i, len(got))
+				}
+				got = append(got, x)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Iter() yielded %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVariable_Iter_Break(t *testing.T) {
+	values, err := (&Variable{Data: []uint16{1, 2, 3, 4}}).Iter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := 0.0
+	for i, x := range values {
+		if i == 2 {
+			break
+		}
+		sum += x
+	}
+	if sum != 3 {
+		t.Errorf("sum of first two = %g, want 3", sum)
+	}
+}
+
+func TestVariable_Iter_Errors(t *testing.T) {
+	for _, v := range []*Variable{
+		{IsComplex: true, Data: &NumericArray{Real: []float64{1}, Imag: []float64{2}}},
+		{Data: "text"},
+		{Data: &StructArray{}},
+	} {
+		if _, err := v.Iter(); err == nil {
+			t.Errorf("Iter(%T) error = nil", v.Data)
+		}
+	}
+}
+
+func TestVariable_Iter_NoAllocs(t *testing.T) {
+	v := &Variable{Data: make([]int32, 100000)}
+	allocs := testing.AllocsPerRun(10, func() {
+		values, _ := v.Iter()
+		for range values {
+		}
+	})
+	if allocs > 10 { // The iterator itself, not one per element
+		t.Errorf("Iter() made %.0f allocations for 100000 elements", allocs)
+	}
+}
+
+func TestVariable_Rows(t *testing.T) {
+	// 3x2, column-major: columns [1 2 3] and [4 5 6]
+	v := &Variable{Dimensions: []int{3, 2}, Data: []int16{1, 2, 3, 4, 5, 6}}
+	rows, err := v.Rows()
+	if err != nil {
+		t.Fatalf("Rows() error = %v", err)
+	}
+	var got [][]float64
+	for row := range rows {
+		got = append(got, append([]float64(nil), row...))
+	}
+	want := [][]float64{{1, 4}, {2, 5}, {3, 6}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Rows() yielded %v, want %v", got, want)
+	}
+
+	// Stops when the loop breaks
+	count := 0
+	for range rows {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("yielded %d rows after break, want 1", count)
+	}
+
+	logical := &Variable{Dimensions: []int{1, 2}, Data: []bool{true, false}}
+	rows, err = logical.Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for row := range rows {
+		if !reflect.DeepEqual(row, []float64{1, 0}) {
+			t.Errorf("logical row = %v, want [1 0]", row)
+		}
+	}
+}
+
+func TestVariable_Rows_Errors(t *testing.T) {
+	for _, v := range []*Variable{
+		{Dimensions: []int{1, 1}, IsComplex: true, Data: &NumericArray{Real: []float64{1}, Imag: []float64{2}}},
+		{Dimensions: []int{1, 4}, Data: "text"},
+		{Dimensions: []int{2, 2, 2}, Data: make([]float64, 8)},
+		{Dimensions: []int{2, 2}, Data: make([]float64, 3)},
+	} {
+		if _, err := v.Rows(); err == nil {
+			t.Errorf("Rows(%v %T) error = nil", v.Dimensions, v.Data)
+		}
+	}
+}
+
+func TestVariable_Rows_Allocs(t *testing.T) {
+	v := &Variable{Dimensions: []int{1000, 10}, Data: make([]float32, 10000)}
+	allocs := testing.AllocsPerRun(10, func() {
+		rows, _ := v.Rows()
+		for range rows {
+		}
+	})
+	if allocs > 5 {
+		t.Errorf("Rows() made %.0f allocations, want a few", allocs)
+	}
+}
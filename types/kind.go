@@ -0,0 +1,95 @@
+package types
+
+// Kind is what a variable represents to MATLAB code, as opposed to how its
+// elements are stored. DataType names the element type; Kind adds the
+// flags and object classes that change a variable's meaning, so a sparse
+// double matrix, a datetime array and a plain double matrix can be told
+// apart with one switch.
+type Kind int
+
+// Variable kinds.
+const (
+	KindUnknown     Kind = iota // Not recognized
+	KindNumeric                 // Full numeric array; DataType gives the element type
+	KindLogical                 // Logical array
+	KindChar                    // Character array
+	KindString                  // string array (MATLAB R2016b+ object)
+	KindStruct                  // Struct array
+	KindCell                    // Cell array
+	KindSparse                  // Sparse numeric or logical matrix
+	KindDatetime                // datetime array
+	KindDuration                // duration array
+	KindCategorical             // categorical array
+	KindTable                   // table or timetable
+	KindObject                  // Instance of another class; see ClassName
+)
+
+func (k Kind) String() string {
+	names := [...]string{
+		"unknown", "numeric", "logical", "char", "string", "struct", "cell",
+		"sparse", "datetime", "duration", "categorical", "table", "object",
+	}
+	if k < 0 || int(k) >= len(names) {
+		return "unknown"
+	}
+	return names[k]
+}
+
+// objectKinds maps MATLAB class names stored as objects to their kind.
+var objectKinds = map[string]Kind{
+	"string":           KindString,
+	"datetime":         KindDatetime,
+	"duration":         KindDuration,
+	"calendarDuration": KindDuration,
+	"categorical":      KindCategorical,
+	"table":            KindTable,
+	"timetable":        KindTable,
+}
+
+// Kind returns what the variable represents. It is derived from DataType,
+// IsSparse and, for objects, the class name in the MATLAB_class
+// attribute, so it needs no changes to the decoded Data.
+//
+// Example:
+//
+//	switch v.Kind() {
+//	case types.KindNumeric:
+//	    values, _ := v.GetFloat64Array()
+//	case types.KindDatetime, types.KindTable:
+//	    log.Printf("%s: %s objects are not decoded", v.Name, v.Kind())
+//	}
+func (v *Variable) Kind() Kind {
+	if v.IsSparse {
+		return KindSparse
+	}
+	switch v.DataType {
+	case Double, Single, Int8, Uint8, Int16, Uint16, Int32, Uint32, Int64, Uint64:
+		return KindNumeric
+	case Logical:
+		return KindLogical
+	case Char:
+		return KindChar
+	case Struct:
+		return KindStruct
+	case CellArray:
+		return KindCell
+	case Object, Unknown:
+		if kind, ok := objectKinds[v.ClassName()]; ok {
+			return kind
+		}
+		if v.DataType == Object {
+			return KindObject
+		}
+	}
+	return KindUnknown
+}
+
+// ClassName returns the MATLAB class of the variable: the MATLAB_class
+// attribute when present, which names object classes such as "datetime",
+// and otherwise the name of its DataType.
+func (v *Variable) ClassName() string {
+	if class, ok := v.GetStringAttr(AttrMatlabClass); ok && class != "" {
+		return class
+	}
+	return v.DataType.String()
+}
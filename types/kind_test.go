@@ -0,0 +1,47 @@
+package types
+
+import "testing"
+
+func TestVariable_Kind(t *testing.T) {
+	tests := []struct {
+		name  string
+		v     *Variable
+		want  Kind
+		class string
+	}{
+		{"double", &Variable{DataType: Double}, KindNumeric, "double"},
+		{"complex int16", &Variable{DataType: Int16, IsComplex: true}, KindNumeric, "int16"},
+		{"logical", &Variable{DataType: Logical}, KindLogical, "logical"},
+		{"char", &Variable{DataType: Char}, KindChar, "char"},
+		{"struct", &Variable{DataType: Struct}, KindStruct, "struct"},
+		{"cell", &Variable{DataType: CellArray}, KindCell, "cell"},
+		{"sparse", &Variable{DataType: Double, IsSparse: true}, KindSparse, "double"},
+		{"sparse logical", &Variable{DataType: Logical, IsSparse: true}, KindSparse, "logical"},
+		{"string", &Variable{DataType: Object, Attributes: map[string]interface{}{"MATLAB_class": "string"}}, KindString, "string"},
+		{"datetime", &Variable{DataType: Unknown, Attributes: map[string]interface{}{"MATLAB_class": "datetime"}}, KindDatetime, "datetime"},
+		{"duration", &Variable{DataType: Object, Attributes: map[string]interface{}{"MATLAB_class": []string{"duration"}}}, KindDuration, "duration"},
+		{"categorical", &Variable{DataType: Object, Attributes: map[string]interface{}{"MATLAB_class": "categorical"}}, KindCategorical, "categorical"},
+		{"timetable", &Variable{DataType: Object, Attributes: map[string]interface{}{"MATLAB_class": "timetable"}}, KindTable, "timetable"},
+		{"other object", &Variable{DataType: Object, Attributes: map[string]interface{}{"MATLAB_class": "containers.Map"}}, KindObject, "containers.Map"},
+		{"unknown", &Variable{DataType: Unknown}, KindUnknown, "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.v.Kind(); got != tt.want {
+				t.Errorf("Kind() = %v, want %v", got, tt.want)
+			}
+			if got := tt.v.ClassName(); got != tt.class {
+				t.Errorf("ClassName() = %q, want %q", got, tt.class)
+			}
+		})
+	}
+}
+
+func TestKind_String(t *testing.T) {
+	if got := KindCategorical.String(); got != "categorical" {
+		t.Errorf("KindCategorical.String() = %q", got)
+	}
+	if got := Kind(99).String(); got != "unknown" {
+		t.Errorf("Kind(99).String() = %q", got)
+	}
+}
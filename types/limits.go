@@ -0,0 +1,71 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrDimensionLimit indicates an array whose shape exceeds DimLimits.
+var ErrDimensionLimit = errors.New("array dimensions exceed limit")
+
+// Default dimension limits. They accept every array a MAT-file can
+// describe and a 64-bit machine could hold.
+const (
+	DefaultMaxRank      = 64
+	DefaultMaxDimension = math.MaxInt
+	DefaultMaxElements  = 1 << 40
+)
+
+// DimLimits bounds the shape of the arrays a reader accepts, so that
+// consumers with little memory can reject large arrays before their data
+// is read. A zero field selects the default.
+type DimLimits struct {
+	MaxRank      int   // Maximum number of dimensions
+	MaxDimension int   // Maximum length of a single dimension
+	MaxElements  int64 // Maximum product of the dimensions
+}
+
+// withDefaults returns a copy of l with zero fields replaced by defaults.
+func (l DimLimits) withDefaults() DimLimits {
+	if l.MaxRank <= 0 {
+		l.MaxRank = DefaultMaxRank
+	}
+	if l.MaxDimension <= 0 {
+		l.MaxDimension = DefaultMaxDimension
+	}
+	if l.MaxElements <= 0 {
+		l.MaxElements = DefaultMaxElements
+	}
+	return l
+}
+
+// Check returns an error wrapping ErrDimensionLimit if dims exceeds the
+// limits.
+//
+// Example:
+//
+//	limits := types.DimLimits{MaxElements: 1 << 20}
+//	if err := limits.Check(v.Dimensions); err != nil {
+//	    return err
+//	}
+func (l DimLimits) Check(dims []int) error {
+	l = l.withDefaults()
+	if len(dims) > l.MaxRank {
+		return fmt.Errorf("%w: %d dimensions, maximum %d", ErrDimensionLimit, len(dims), l.MaxRank)
+	}
+	elements := int64(1)
+	for _, d := range dims {
+		if d < 0 {
+			return fmt.Errorf("negative dimension %d", d)
+		}
+		if d > l.MaxDimension {
+			return fmt.Errorf("%w: dimension %d, maximum %d", ErrDimensionLimit, d, l.MaxDimension)
+		}
+		if d > 0 && elements > l.MaxElements/int64(d) {
+			return fmt.Errorf("%w: dimensions %v exceed %d elements", ErrDimensionLimit, dims, l.MaxElements)
+		}
+		elements *= int64(d)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestDimLimits_Check(t *testing.T) {
+	tests := []struct {
+		limits DimLimits
+		dims   []int
+		ok     bool
+	}{
+		{DimLimits{}, []int{1 << 20, 1 << 19}, true},
+		{DimLimits{}, []int{1 << 21, 1 << 20}, false},
+		{DimLimits{}, []int{0, math.MaxInt, math.MaxInt}, true},
+		{DimLimits{MaxRank: 2}, []int{1, 2, 3}, false},
+		{DimLimits{MaxDimension: 10}, []int{1, 11}, false},
+		{DimLimits{MaxElements: 100}, []int{10, 10}, true},
+		{DimLimits{MaxElements: 100}, []int{10, 11}, false},
+	}
+	for _, tt := range tests {
+		err := tt.limits.Check(tt.dims)
+		if tt.ok && err != nil {
+			t.Errorf("%+v.Check(%v) error = %v", tt.limits, tt.dims, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrDimensionLimit) {
+			t.Errorf("%+v.Check(%v) error = %v, want ErrDimensionLimit", tt.limits, tt.dims, err)
+		}
+	}
+	if err := (DimLimits{}).Check([]int{-1}); err == nil {
+		t.Error("Check accepted a negative dimension")
+	}
+}
@@ -0,0 +1,43 @@
+package types
+
+// Location is where a variable is stored in its file, for tools that
+// extract or patch stored bytes directly, as dd does.
+//
+// For v5 files, Offset and Length cover the variable's data element, tag
+// and padding included; the element of a compressed variable is an
+// miCOMPRESSED element holding the zlib stream. Offsets are into the
+// MAT-file itself, after any gzip, zstd or encryption envelope around it.
+//
+// For v7.3 files, Address is the HDF5 object header address of the
+// dataset, and Offset and Length cover its data if the dataset has
+// contiguous storage; Offset is -1 for chunked or compact datasets, whose
+// data is not in one piece.
+type Location struct {
+	Offset     int64  // Byte offset of the stored element or data, -1 if not contiguous
+	Length     int64  // Bytes at Offset
+	Address    uint64 // v7.3 object header address of the dataset, 0 for v5
+	Compressed bool   // True if the bytes at Offset are compressed
+}
+
+// Location returns where the variable is stored in the file it was read
+// from. It is available for top-level variables read by matlab.Open and
+// matlab.Recover from v5 files, and for the datasets of v7.3 files,
+// including struct fields stored as datasets.
+//
+// Example:
+//
+//	if loc, ok := v.Location(); ok && loc.Offset >= 0 && !loc.Compressed {
+//	    fmt.Printf("dd if=data.mat bs=1 skip=%d count=%d\n", loc.Offset, loc.Length)
+//	}
+func (v *Variable) Location() (Location, bool) {
+	if v.location == nil {
+		return Location{}, false
+	}
+	return *v.location, true
+}
+
+// SetLocation records the storage returned by Location. It is called by
+// the readers.
+func (v *Variable) SetLocation(loc Location) {
+	v.location = &loc
+}
@@ -0,0 +1,120 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// MaxNameLength is the longest variable or field name MATLAB accepts
+// (namelengthmax), counted in characters, not bytes.
+const MaxNameLength = 63
+
+// ErrInvalidName indicates a name that is not a valid MATLAB identifier.
+var ErrInvalidName = errors.New("invalid MATLAB name")
+
+// NameLength returns the length of a name in characters, as MATLAB
+// counts it against MaxNameLength. Non-ASCII names take more bytes than
+// characters in a file.
+func NameLength(name string) int {
+	return utf8.RuneCountInString(name)
+}
+
+// ValidateName checks that name is a valid MATLAB identifier, as
+// isvarname does: an ASCII letter followed by at most 62 ASCII letters,
+// digits or underscores. Names that fail wrap ErrInvalidName.
+//
+// The writers accept other names, including non-ASCII ones, and store
+// them as UTF-8; MATLAB cannot load such variables under their own names.
+//
+// Example:
+//
+//	if err := types.ValidateName("température"); errors.Is(err, types.ErrInvalidName) {
+//	    // Rename before writing for MATLAB users
+//	}
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidName)
+	}
+	if !utf8.ValidString(name) {
+		return fmt.Errorf("%w: %q is not valid UTF-8", ErrInvalidName, name)
+	}
+	if n := NameLength(name); n > MaxNameLength {
+		return fmt.Errorf("%w: %q has %d characters, maximum is %d", ErrInvalidName, name, n, MaxNameLength)
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r == '_' || (r >= '0' && r <= '9')):
+		case i == 0:
+			return fmt.Errorf("%w: %q does not start with a letter", ErrInvalidName, name)
+		default:
+			return fmt.Errorf("%w: %q contains %q", ErrInvalidName, name, r)
+		}
+	}
+	return nil
+}
+
+// ErrReservedName indicates a name that is a MATLAB keyword or shadows a
+// commonly used MATLAB function or constant.
+var ErrReservedName = errors.New("reserved MATLAB name")
+
+// keywords are the MATLAB keywords, as listed by iskeyword.
+var keywords = map[string]bool{
+	"break": true, "case": true, "catch": true, "classdef": true, "continue": true,
+	"else": true, "elseif": true, "end": true, "for": true, "function": true,
+	"global": true, "if": true, "otherwise": true, "parfor": true, "persistent": true,
+	"return": true, "spmd": true, "switch": true, "try": true, "while": true,
+}
+
+// builtins are the MATLAB functions and constants whose names are most
+// often given to variables by mistake. A variable of the same name hides
+// the function in the workspace it is loaded into.
+var builtins = map[string]bool{
+	// Constants and the default result
+	"ans": true, "pi": true, "i": true, "j": true, "eps": true,
+	"Inf": true, "inf": true, "NaN": true, "nan": true, "true": true, "false": true,
+	// Classes
+	"double": true, "single": true, "char": true, "string": true, "logical": true,
+	"cell": true, "struct": true, "table": true,
+	"int8": true, "int16": true, "int32": true, "int64": true,
+	"uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	// Functions
+	"abs": true, "all": true, "alpha": true, "angle": true, "any": true, "axis": true,
+	"beta": true, "cd": true, "ceil": true, "class": true, "clear": true, "clock": true,
+	"cos": true, "cumsum": true, "date": true, "det": true, "diff": true, "dir": true,
+	"disp": true, "error": true, "exist": true, "exp": true, "figure": true,
+	"filter": true, "find": true, "fix": true, "flag": true, "floor": true,
+	"format": true, "gamma": true, "image": true, "imag": true, "input": true,
+	"inv": true, "length": true, "line": true, "load": true, "log": true, "max": true,
+	"mean": true, "median": true, "min": true, "mod": true, "mode": true, "norm": true,
+	"numel": true, "ones": true, "path": true, "plot": true, "print": true, "prod": true,
+	"rand": true, "randn": true, "rank": true, "real": true, "rem": true, "round": true,
+	"save": true, "sign": true, "sin": true, "size": true, "sort": true, "sqrt": true,
+	"std": true, "sum": true, "tan": true, "text": true, "title": true, "trace": true,
+	"var": true, "version": true, "who": true, "zeros": true,
+}
+
+// CheckReservedName reports whether name is a MATLAB keyword or shadows
+// a commonly used MATLAB function or constant, such as end, pi, ans, i
+// or j. Such names load, but the variable hides the function or constant
+// in the MATLAB workspace, and a variable named after a keyword cannot
+// be referred to at all. Names that fail wrap ErrReservedName.
+//
+// Only a fixed list of common names is checked; MATLAB's exist function
+// is the authority for a given installation.
+//
+// Example:
+//
+//	if err := types.CheckReservedName("pi"); errors.Is(err, types.ErrReservedName) {
+//	    // Rename to avoid shadowing pi in MATLAB
+//	}
+func CheckReservedName(name string) error {
+	switch {
+	case keywords[name]:
+		return fmt.Errorf("%w: %q is a keyword", ErrReservedName, name)
+	case builtins[name]:
+		return fmt.Errorf("%w: %q shadows a MATLAB function or constant", ErrReservedName, name)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	valid := []string{"x", "A1", "my_var", "x_", strings.Repeat("a", MaxNameLength)}
+	for _, name := range valid {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q) error = %v", name, err)
+		}
+	}
+
+	invalid := []string{
+		"", "1x", "_x", "my var", "a-b", "température", "µ",
+		strings.Repeat("a", MaxNameLength+1), "a\xff", "a\x00",
+	}
+	for _, name := range invalid {
+		if err := ValidateName(name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("ValidateName(%q) error = %v, want ErrInvalidName", name, err)
+		}
+	}
+}
+
+func TestNameLength(t *testing.T) {
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"abc", 3},
+		{"température", 11},
+		{"温度", 2},
+		{"a𝄞", 2},
+	}
+	for _, tt := range tests {
+		if got := NameLength(tt.name); got != tt.want {
+			t.Errorf("NameLength(%q) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckReservedName(t *testing.T) {
+	for _, name := range []string{"end", "for", "pi", "ans", "i", "j", "NaN", "sum", "double"} {
+		if err := CheckReservedName(name); !errors.Is(err, ErrReservedName) {
+			t.Errorf("CheckReservedName(%q) error = %v, want ErrReservedName", name, err)
+		}
+	}
+	for _, name := range []string{"x", "End", "PI", "ii", "data", "fs", "sum_x", ""} {
+		if err := CheckReservedName(name); err != nil {
+			t.Errorf("CheckReservedName(%q) error = %v", name, err)
+		}
+	}
+}
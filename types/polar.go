@@ -0,0 +1,77 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// GetMagnitude returns the magnitude |z| of each element of a complex
+// variable of any numeric class, as MATLAB's abs does.
+//
+// Example:
+//
+//	spectrum := matFile.GetVariable("X")
+//	magnitude, err := spectrum.GetMagnitude()
+func (v *Variable) GetMagnitude() ([]float64, error) {
+	return v.polarPart(cmplx.Abs)
+}
+
+// GetPhase returns the phase angle of each element of a complex variable
+// of any numeric class, in radians in [-π, π], as MATLAB's angle does.
+//
+// Example:
+//
+//	phase, err := spectrum.GetPhase()
+func (v *Variable) GetPhase() ([]float64, error) {
+	return v.polarPart(cmplx.Phase)
+}
+
+// polarPart applies part to each element of a complex variable.
+func (v *Variable) polarPart(part func(complex128) float64) ([]float64, error) {
+	if !v.IsComplex {
+		return nil, fmt.Errorf("variable is not complex")
+	}
+	values, err := complexValues(v)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]float64, len(values))
+	for i, z := range values {
+		result[i] = part(z)
+	}
+	return result, nil
+}
+
+// PolarToComplex returns a complex double variable from the magnitude
+// and phase (in radians) of each element, the inverse of GetMagnitude and
+// GetPhase.
+//
+// Example:
+//
+//	v, err := types.PolarToComplex("H", []int{1, len(mag)}, mag, phase)
+//	if err != nil {
+//	    return err
+//	}
+//	writer.WriteVariable(v)
+func PolarToComplex(name string, dims []int, magnitude, phase []float64) (*Variable, error) {
+	if len(magnitude) != len(phase) {
+		return nil, fmt.Errorf("%d magnitudes and %d phases", len(magnitude), len(phase))
+	}
+	if n := numElements(dims); n != len(magnitude) {
+		return nil, fmt.Errorf("dimensions %v hold %d elements, got %d", dims, n, len(magnitude))
+	}
+	re := make([]float64, len(magnitude))
+	im := make([]float64, len(magnitude))
+	for i, r := range magnitude {
+		sin, cos := math.Sincos(phase[i])
+		re[i], im[i] = r*cos, r*sin
+	}
+	return &Variable{
+		Name:       name,
+		Dimensions: dims,
+		DataType:   Double,
+		IsComplex:  true,
+		Data:       &NumericArray{Real: re, Imag: im, Dimensions: dims, Type: Double},
+	}, nil
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVariable_MagnitudePhase(t *testing.T) {
+	z := &Variable{Name: "z", Dimensions: []int{1, 4}, DataType: Double, IsComplex: true,
+		Data: &NumericArray{Real: []float64{3, 0, -1, 0}, Imag: []float64{4, 2, 0, 0}}}
+	magnitude, err := z.GetMagnitude()
+	if err != nil {
+		t.Fatalf("GetMagnitude() error = %v", err)
+	}
+	phase, err := z.GetPhase()
+	if err != nil {
+		t.Fatalf("GetPhase() error = %v", err)
+	}
+	wantMag := []float64{5, 2, 1, 0}
+	wantPhase := []float64{math.Atan2(4, 3), math.Pi / 2, math.Pi, 0}
+	for i := range wantMag {
+		if magnitude[i] != wantMag[i] || math.Abs(phase[i]-wantPhase[i]) > 1e-15 {
+			t.Errorf("element %d: magnitude %v, phase %v, want %v, %v", i, magnitude[i], phase[i], wantMag[i], wantPhase[i])
+		}
+	}
+
+	back, err := PolarToComplex("z", z.Dimensions, magnitude, phase)
+	if err != nil {
+		t.Fatalf("PolarToComplex() error = %v", err)
+	}
+	if !Equal(z, back, WithAbsTolerance(1e-12)) {
+		t.Errorf("PolarToComplex() = %v, want %v", back.Data, z.Data)
+	}
+
+	ints := &Variable{Name: "i", Dimensions: []int{1, 1}, DataType: Int16, IsComplex: true,
+		Data: &NumericArray{Real: []int16{-3}, Imag: []int16{-4}}}
+	if m, err := ints.GetMagnitude(); err != nil || m[0] != 5 {
+		t.Errorf("GetMagnitude(int16) = %v, %v", m, err)
+	}
+}
+
+func TestVariable_MagnitudePhase_Errors(t *testing.T) {
+	real := &Variable{Name: "x", Dimensions: []int{1, 1}, DataType: Double, Data: []float64{1}}
+	if _, err := real.GetMagnitude(); err == nil {
+		t.Error("GetMagnitude(real) error = nil")
+	}
+	if _, err := real.GetPhase(); err == nil {
+		t.Error("GetPhase(real) error = nil")
+	}
+	if _, err := PolarToComplex("z", []int{1, 2}, []float64{1, 2}, []float64{0}); err == nil {
+		t.Error("PolarToComplex(length mismatch) error = nil")
+	}
+	if _, err := PolarToComplex("z", []int{2, 2}, []float64{1, 2}, []float64{0, 0}); err == nil {
+		t.Error("PolarToComplex(dimension mismatch) error = nil")
+	}
+}
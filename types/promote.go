@@ -0,0 +1,250 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNotPromotable indicates classes without a common class, or a
+// conversion to a class that cannot hold every value of the original.
+var ErrNotPromotable = errors.New("cannot promote class")
+
+// numericClass describes how a numeric or logical class holds values.
+type numericClass struct {
+	float  bool
+	signed bool
+	bits   int // Width of integers; logical is an unsigned integer of 0 bits
+}
+
+// numericClasses are the classes that take part in promotion.
+var numericClasses = map[DataType]numericClass{
+	Logical: {bits: 0},
+	Uint8:   {bits: 8}, Uint16: {bits: 16}, Uint32: {bits: 32}, Uint64: {bits: 64},
+	Int8: {signed: true, bits: 8}, Int16: {signed: true, bits: 16},
+	Int32: {signed: true, bits: 32}, Int64: {signed: true, bits: 64},
+	Single: {float: true, signed: true, bits: 32}, Double: {float: true, signed: true, bits: 64},
+}
+
+// classElements maps the classes that take part in promotion to the Go
+// type of their elements.
+var classElements = map[DataType]reflect.Type{
+	Logical: reflect.TypeOf(false),
+	Double:  reflect.TypeOf(float64(0)), Single: reflect.TypeOf(float32(0)),
+	Int8: reflect.TypeOf(int8(0)), Uint8: reflect.TypeOf(uint8(0)),
+	Int16: reflect.TypeOf(int16(0)), Uint16: reflect.TypeOf(uint16(0)),
+	Int32: reflect.TypeOf(int32(0)), Uint32: reflect.TypeOf(uint32(0)),
+	Int64: reflect.TypeOf(int64(0)), Uint64: reflect.TypeOf(uint64(0)),
+}
+
+// PromoteType returns the class that arrays of the given classes are
+// converted to when they are combined, so concatenation, merging and
+// coercion agree: the narrowest class that holds every value of each.
+// Integers widen within their signedness (int8 → int16 → int32 → int64),
+// and mixed signed and unsigned integers promote to a signed class twice
+// as wide as the unsigned one (uint8 with int8 to int16). Integers of up
+// to 16 bits promote with single to single; wider ones, and any class
+// with double, promote to double. Logical promotes to any other class.
+//
+// Unlike MATLAB, which concatenates integers and doubles to the integer
+// class, no values are rounded or saturated, except that int64 and uint64
+// values beyond 2^53 are rounded when they promote to double (with a
+// floating-point class, or uint64 with a signed one).
+//
+// Returns an error wrapping ErrNotPromotable if no class is given or a
+// class is not numeric or logical.
+//
+// Example:
+//
+//	class, _ := types.PromoteType(types.Uint8, types.Int8) // types.Int16
+func PromoteType(classes ...DataType) (DataType, error) {
+	if len(classes) == 0 {
+		return Unknown, fmt.Errorf("%w: no classes", ErrNotPromotable)
+	}
+	result, ok := numericClasses[classes[0]]
+	if !ok {
+		return Unknown, fmt.Errorf("%w: %s is not numeric", ErrNotPromotable, classes[0])
+	}
+	for _, class := range classes[1:] {
+		c, ok := numericClasses[class]
+		if !ok {
+			return Unknown, fmt.Errorf("%w: %s is not numeric", ErrNotPromotable, class)
+		}
+		result = promoteClass(result, c)
+	}
+	for class, c := range numericClasses {
+		if c == result {
+			return class, nil
+		}
+	}
+	return Double, nil // Not reached: promoteClass returns listed classes
+}
+
+// promoteClass returns the narrowest class that holds the values of a and
+// b.
+func promoteClass(a, b numericClass) numericClass {
+	double := numericClass{float: true, signed: true, bits: 64}
+	switch {
+	case a.float && b.float:
+		return numericClass{float: true, signed: true, bits: max(a.bits, b.bits)}
+	case a.float || b.float:
+		f, i := a, b
+		if b.float {
+			f, i = b, a
+		}
+		if f.bits == 32 && i.bits <= 16 {
+			return f // Single holds 24-bit integers exactly
+		}
+		return double
+	case a.signed == b.signed:
+		return numericClass{signed: a.signed, bits: max(a.bits, b.bits)}
+	}
+	s, u := a, b
+	if b.signed {
+		s, u = b, a
+	}
+	bits := max(s.bits, 2*u.bits)
+	if bits > 64 {
+		return double
+	}
+	return numericClass{signed: true, bits: bits}
+}
+
+// Promote returns the numeric or logical variables converted to their
+// common class (see PromoteType), and complex if any of them is complex.
+// Variables that already have that class and complexity are returned as
+// given; the others are converted copies.
+//
+// Returns an error wrapping ErrNotPromotable if a variable is not numeric
+// or logical, or is sparse.
+//
+// Example:
+//
+//	// An int16 and a double variable, both as double
+//	promoted, err := types.Promote(counts, weights)
+func Promote(vars ...*Variable) ([]*Variable, error) {
+	classes := make([]DataType, len(vars))
+	complexData := false
+	for i, v := range vars {
+		classes[i] = v.DataType
+		complexData = complexData || v.IsComplex
+	}
+	class, err := PromoteType(classes...)
+	if err != nil {
+		return nil, err
+	}
+	promoted := make([]*Variable, len(vars))
+	for i, v := range vars {
+		if promoted[i], err = v.PromoteTo(class, complexData); err != nil {
+			return nil, err
+		}
+	}
+	return promoted, nil
+}
+
+// PromoteTo returns the numeric or logical variable converted to class,
+// with a zero imaginary part if complexData is set and v is real. v is
+// returned as is if it already has that class and complexity.
+//
+// Returns an error wrapping ErrNotPromotable if class cannot hold every
+// value of v (see PromoteType), a complex variable would become real, or
+// v is sparse.
+//
+// Example:
+//
+//	wide, err := v.PromoteTo(types.Double, false)
+func (v *Variable) PromoteTo(class DataType, complexData bool) (*Variable, error) {
+	if v.IsSparse {
+		return nil, fmt.Errorf("%w: %s is sparse", ErrNotPromotable, v.Name)
+	}
+	promoted, err := PromoteType(v.DataType, class)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", v.Name, err)
+	}
+	if promoted != class || (v.IsComplex && !complexData) || (complexData && class == Logical) {
+		return nil, fmt.Errorf("%w: %s %s to %s", ErrNotPromotable, v.Name, describeClass(v.DataType, v.IsComplex), describeClass(class, complexData))
+	}
+	if class == v.DataType && complexData == v.IsComplex {
+		return v, nil
+	}
+
+	copied := *v
+	copied.DataType = class
+	copied.IsComplex = complexData
+	array, ok := v.Data.(*NumericArray)
+	switch {
+	case v.IsComplex && ok:
+		re, err := convertSlice(array.Real, class)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Name, err)
+		}
+		im, err := convertSlice(array.Imag, class)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Name, err)
+		}
+		copied.Data = &NumericArray{Real: re, Imag: im, Dimensions: array.Dimensions, Type: class}
+	case v.IsComplex:
+		return nil, fmt.Errorf("%s: complex data is %T, not *NumericArray", v.Name, v.Data)
+	default:
+		re, err := convertSlice(v.Data, class)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Name, err)
+		}
+		copied.Data = re
+		if complexData {
+			n := reflect.ValueOf(re).Len()
+			im := reflect.MakeSlice(reflect.SliceOf(classElements[class]), n, n).Interface()
+			copied.Data = &NumericArray{Real: re, Imag: im, Dimensions: v.Dimensions, Type: class}
+		}
+	}
+	return &copied, nil
+}
+
+// describeClass returns the name of a class, qualified if complex.
+func describeClass(class DataType, complexData bool) string {
+	if complexData {
+		return "complex " + class.String()
+	}
+	return class.String()
+}
+
+// convertSlice returns a numeric or logical slice as a slice of the
+// elements of class, or data itself if it has that type already.
+func convertSlice(data interface{}, class DataType) (interface{}, error) {
+	elem := classElements[class]
+	src := reflect.ValueOf(data)
+	if src.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("data is %T, not a slice", data)
+	}
+	if src.Type().Elem() == elem {
+		return data, nil
+	}
+	if class == Double {
+		at, n, err := float64At(data)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]float64, n)
+		for i := range values {
+			values[i] = at(i)
+		}
+		return values, nil
+	}
+
+	kind := src.Type().Elem().Kind()
+	if elem.Kind() == reflect.Bool || (kind != reflect.Bool && !isNumericKind(kind)) {
+		return nil, fmt.Errorf("cannot convert %T to %s", data, class)
+	}
+	one := reflect.ValueOf(1).Convert(elem)
+	dst := reflect.MakeSlice(reflect.SliceOf(elem), src.Len(), src.Len())
+	for i := range src.Len() {
+		x := src.Index(i)
+		switch {
+		case kind != reflect.Bool:
+			dst.Index(i).Set(x.Convert(elem))
+		case x.Bool():
+			dst.Index(i).Set(one)
+		}
+	}
+	return dst.Interface(), nil
+}
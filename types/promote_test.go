@@ -0,0 +1,121 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPromoteType(t *testing.T) {
+	tests := []struct {
+		classes []DataType
+		want    DataType
+	}{
+		{[]DataType{Int8}, Int8},
+		{[]DataType{Logical, Logical}, Logical},
+		{[]DataType{Logical, Uint8}, Uint8},
+		{[]DataType{Logical, Int8}, Int8},
+		{[]DataType{Int8, Int32, Int16}, Int32},
+		{[]DataType{Uint8, Uint64}, Uint64},
+		{[]DataType{Uint8, Int8}, Int16},
+		{[]DataType{Uint16, Int8}, Int32},
+		{[]DataType{Uint32, Int64}, Int64},
+		{[]DataType{Uint64, Int8}, Double},
+		{[]DataType{Int16, Single}, Single},
+		{[]DataType{Uint16, Single}, Single},
+		{[]DataType{Int32, Single}, Double},
+		{[]DataType{Single, Double}, Double},
+		{[]DataType{Int64, Double}, Double},
+		{[]DataType{Logical, Single}, Single},
+	}
+	for _, tt := range tests {
+		got, err := PromoteType(tt.classes...)
+		if err != nil || got != tt.want {
+			t.Errorf("PromoteType(%v) = %v, %v, want %v", tt.classes, got, err, tt.want)
+		}
+		// Promotion does not depend on order
+		reversed := make([]DataType, len(tt.classes))
+		for i, class := range tt.classes {
+			reversed[len(reversed)-1-i] = class
+		}
+		if got, _ := PromoteType(reversed...); got != tt.want {
+			t.Errorf("PromoteType(%v) = %v, want %v", reversed, got, tt.want)
+		}
+	}
+
+	for _, classes := range [][]DataType{nil, {Double, Char}, {Struct}} {
+		if _, err := PromoteType(classes...); !errors.Is(err, ErrNotPromotable) {
+			t.Errorf("PromoteType(%v) error = %v, want ErrNotPromotable", classes, err)
+		}
+	}
+}
+
+func TestPromote(t *testing.T) {
+	flags := &Variable{Name: "b", Dimensions: []int{1, 2}, DataType: Logical, Data: []bool{true, false}}
+	counts := &Variable{Name: "n", Dimensions: []int{1, 2}, DataType: Uint8, Data: []uint8{200, 7}}
+	offsets := &Variable{Name: "o", Dimensions: []int{1, 2}, DataType: Int8, Data: []int8{-5, 9}}
+
+	promoted, err := Promote(flags, counts, offsets)
+	if err != nil {
+		t.Fatalf("Promote() error = %v", err)
+	}
+	want := [][]int16{{1, 0}, {200, 7}, {-5, 9}}
+	for i, v := range promoted {
+		if v.DataType != Int16 || v.IsComplex || !reflect.DeepEqual(v.Data, want[i]) {
+			t.Errorf("promoted[%d] = %s %v, want int16 %v", i, v.DataType, v.Data, want[i])
+		}
+	}
+	if counts.DataType != Uint8 {
+		t.Error("Promote() modified its arguments")
+	}
+
+	// A complex part makes all complex
+	z := &Variable{Name: "z", Dimensions: []int{1, 1}, DataType: Single, IsComplex: true,
+		Data: &NumericArray{Real: []float32{1}, Imag: []float32{-1}}}
+	x := &Variable{Name: "x", Dimensions: []int{1, 2}, DataType: Double, Data: []float64{2, 3}}
+	promoted, err = Promote(z, x)
+	if err != nil {
+		t.Fatalf("Promote(complex) error = %v", err)
+	}
+	if promoted[1] == x || !promoted[1].IsComplex {
+		t.Fatalf("Promote(complex) did not convert x: %+v", promoted[1])
+	}
+	array := promoted[0].Data.(*NumericArray)
+	if promoted[0].DataType != Double || !reflect.DeepEqual(array.Real, []float64{1}) || !reflect.DeepEqual(array.Imag, []float64{-1}) {
+		t.Errorf("promoted z = %s %v %v", promoted[0].DataType, array.Real, array.Imag)
+	}
+	if array := promoted[1].Data.(*NumericArray); !reflect.DeepEqual(array.Imag, []float64{0, 0}) || array.Type != Double {
+		t.Errorf("promoted x = %v %v", array.Real, array.Imag)
+	}
+
+	if same, err := Promote(x); err != nil || same[0] != x {
+		t.Errorf("Promote(x) = %v, %v, want x unchanged", same, err)
+	}
+
+	text := &Variable{Name: "s", Dimensions: []int{1, 2}, DataType: Char, Data: "hi"}
+	if _, err := Promote(x, text); !errors.Is(err, ErrNotPromotable) {
+		t.Errorf("Promote(char) error = %v, want ErrNotPromotable", err)
+	}
+}
+
+func TestVariable_PromoteTo(t *testing.T) {
+	v := &Variable{Name: "v", Dimensions: []int{1, 2}, DataType: Int32, Data: []int32{-1, 70000}}
+	wide, err := v.PromoteTo(Int64, false)
+	if err != nil || !reflect.DeepEqual(wide.Data, []int64{-1, 70000}) {
+		t.Errorf("PromoteTo(int64) = %v, %v", wide, err)
+	}
+	for _, class := range []DataType{Int16, Single, Uint32, Logical} {
+		if _, err := v.PromoteTo(class, false); !errors.Is(err, ErrNotPromotable) {
+			t.Errorf("PromoteTo(%s) error = %v, want ErrNotPromotable", class, err)
+		}
+	}
+	z := &Variable{Name: "z", Dimensions: []int{1, 1}, DataType: Int8, IsComplex: true,
+		Data: &NumericArray{Real: []int8{1}, Imag: []int8{2}}}
+	if _, err := z.PromoteTo(Double, false); !errors.Is(err, ErrNotPromotable) {
+		t.Errorf("PromoteTo(real) of complex error = %v, want ErrNotPromotable", err)
+	}
+	sparse := &Variable{Name: "s", Dimensions: []int{2, 2}, DataType: Double, IsSparse: true}
+	if _, err := sparse.PromoteTo(Double, true); !errors.Is(err, ErrNotPromotable) {
+		t.Errorf("PromoteTo(sparse) error = %v, want ErrNotPromotable", err)
+	}
+}
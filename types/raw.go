@@ -0,0 +1,42 @@
+package types
+
+import "errors"
+
+// ErrNoRawData indicates a variable whose stored bytes were not kept, or
+// cannot be read without decoding.
+var ErrNoRawData = errors.New("raw data not available")
+
+// RawData is the undecoded content of a v7.3 dataset, for decoding
+// classes the library does not support yet, such as opaque objects.
+type RawData struct {
+	Bytes       []byte // Stored bytes in HDF5 (row-major) element order
+	Datatype    string // HDF5 datatype class, e.g. "integer", "float", "opaque"
+	ElementSize int    // Bytes per element
+	Dims        []int  // HDF5 dataspace dimensions, the reverse of MATLAB's
+	Class       string // MATLAB_class attribute, "" if absent
+}
+
+// RawBytes returns the undecoded content of the dataset the variable was
+// read from. It is available for v7.3 variables read with
+// matlab.WithRawBytes from datasets with contiguous storage; otherwise
+// it returns ErrNoRawData. matlab.ReadRawBytes reads the content of a
+// single dataset on demand instead.
+//
+// Example:
+//
+//	raw, err := v.RawBytes()
+//	if err == nil && raw.Datatype == "opaque" {
+//	    obj, err := decodeMyClass(raw.Bytes)
+//	}
+func (v *Variable) RawBytes() (*RawData, error) {
+	if v.raw == nil {
+		return nil, ErrNoRawData
+	}
+	return v.raw, nil
+}
+
+// SetRawBytes records the undecoded content returned by RawBytes. It is
+// called by the v7.3 reader.
+func (v *Variable) SetRawBytes(raw *RawData) {
+	v.raw = raw
+}
@@ -0,0 +1,165 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrIndexOutOfRange indicates an index or range outside the dimensions
+// of an array.
+var ErrIndexOutOfRange = errors.New("index out of range")
+
+// Range selects the zero-based indices Start, Start+Step, ... below Stop
+// along one dimension, as MATLAB's start+1:step:stop does. A Step of 0
+// means 1.
+type Range struct {
+	Start int
+	Stop  int
+	Step  int
+}
+
+// NewVariableFromSlice returns a new variable holding the elements of v
+// at the given index ranges, one per dimension, in column-major order.
+// Dimensions without a range are taken whole, and ranges past the last
+// dimension of v must select index 0 of a singleton dimension. The result
+// has as many dimensions as v, or as ranges if there are more; the name
+// and attributes of v are kept. Data is copied, so the result can be
+// modified or written on its own, except that cell and struct arrays
+// share their contents with v.
+//
+// Numeric and logical arrays, real or complex, cell arrays and struct
+// arrays can be sliced. To read a range of a variable split across files
+// without loading the rest, see FileSet.ReadRange in the matlab package.
+//
+// Returns an error wrapping ErrIndexOutOfRange if a range is outside the
+// dimensions of v.
+//
+// Example:
+//
+//	// Rows 10 to 19 and every other column of a matrix
+//	trimmed, err := types.NewVariableFromSlice(v, []types.Range{
+//	    {Start: 10, Stop: 20},
+//	    {Start: 0, Stop: v.Dimensions[1], Step: 2},
+//	})
+func NewVariableFromSlice(v *Variable, ranges []Range) (*Variable, error) {
+	if v.IsSparse {
+		return nil, fmt.Errorf("cannot slice %s: sparse matrices are not supported", v.Name)
+	}
+	dims := padDims(v.Dimensions, max(len(ranges), 1))
+	selected := make([][]int, len(dims))
+	outDims := make([]int, len(dims))
+	for d, n := range dims {
+		r := Range{Start: 0, Stop: n, Step: 1}
+		if d < len(ranges) {
+			r = ranges[d]
+		}
+		if r.Step == 0 {
+			r.Step = 1
+		}
+		if r.Start < 0 || r.Stop > n || r.Step < 0 || r.Start > r.Stop {
+			return nil, fmt.Errorf("%w: %s has size %d in dimension %d, got %d:%d:%d",
+				ErrIndexOutOfRange, v.Name, n, d, r.Start, r.Step, r.Stop)
+		}
+		for i := r.Start; i < r.Stop; i += r.Step {
+			selected[d] = append(selected[d], i)
+		}
+		outDims[d] = len(selected[d])
+	}
+
+	result := &Variable{Name: v.Name, Dimensions: outDims, DataType: v.DataType, IsComplex: v.IsComplex}
+	if v.Attributes != nil {
+		result.Attributes = make(map[string]interface{}, len(v.Attributes))
+		for name, value := range v.Attributes {
+			result.Attributes[name] = value
+		}
+	}
+	pick := func(data interface{}) (interface{}, error) {
+		src := reflect.ValueOf(data)
+		if src.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("cannot slice %s %s: data is %T", v.Name, v.DataType, data)
+		}
+		if n := numElements(dims); src.Len() != n {
+			return nil, fmt.Errorf("%s: data holds %d elements, want %d", v.Name, src.Len(), n)
+		}
+		return gather(src, dims, selected).Interface(), nil
+	}
+
+	switch data := v.Data.(type) {
+	case *NumericArray:
+		re, err := pick(data.Real)
+		if err != nil {
+			return nil, err
+		}
+		var im interface{}
+		if data.Imag != nil {
+			if im, err = pick(data.Imag); err != nil {
+				return nil, err
+			}
+		}
+		result.Data = &NumericArray{Real: re, Imag: im, Dimensions: outDims, Type: data.Type}
+	case *StructArray:
+		elements, err := pick(data.Elements)
+		if err != nil {
+			return nil, err
+		}
+		result.Data = &StructArray{Fields: append([]string(nil), data.Fields...), Elements: elements.([][]*Variable), Dimensions: outDims}
+	case string, *CharArray:
+		return nil, fmt.Errorf("cannot slice %s: char arrays are not supported", v.Name)
+	default:
+		values, err := pick(v.Data)
+		if err != nil {
+			return nil, err
+		}
+		result.Data = values
+	}
+	return result, nil
+}
+
+// gather copies the elements of the column-major slice src, of
+// dimensions dims, at the selected indices of each dimension. Runs of
+// consecutive indices in the first dimension are copied at once.
+func gather(src reflect.Value, dims []int, selected [][]int) reflect.Value {
+	n := 1
+	for _, indices := range selected {
+		n *= len(indices)
+	}
+	dst := reflect.MakeSlice(src.Type(), 0, n)
+	if n == 0 {
+		return dst
+	}
+	first := selected[0]
+	contiguous := first[len(first)-1]-first[0] == len(first)-1
+
+	strides := make([]int, len(dims))
+	stride := 1
+	for d, size := range dims {
+		strides[d] = stride
+		stride *= size
+	}
+	counter := make([]int, len(dims)) // Position in selected of dimensions 1 and up
+	for {
+		base := 0
+		for d := 1; d < len(dims); d++ {
+			base += selected[d][counter[d]] * strides[d]
+		}
+		if contiguous {
+			dst = reflect.AppendSlice(dst, src.Slice(base+first[0], base+first[0]+len(first)))
+		} else {
+			for _, i := range first {
+				dst = reflect.Append(dst, src.Index(base+i))
+			}
+		}
+
+		d := 1
+		for ; d < len(dims); d++ {
+			if counter[d]++; counter[d] < len(selected[d]) {
+				break
+			}
+			counter[d] = 0
+		}
+		if d == len(dims) {
+			return dst
+		}
+	}
+}
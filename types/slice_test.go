@@ -0,0 +1,98 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewVariableFromSlice(t *testing.T) {
+	// 3x4 matrix, column-major: element (i, j) is 10*i + j
+	data := make([]int32, 12)
+	for j := range 4 {
+		for i := range 3 {
+			data[j*3+i] = int32(10*i + j)
+		}
+	}
+	v := &Variable{Name: "m", Dimensions: []int{3, 4}, DataType: Int32, Data: data,
+		Attributes: map[string]interface{}{"units": "V"}}
+
+	got, err := NewVariableFromSlice(v, []Range{{Start: 1, Stop: 3}, {Start: 0, Stop: 4, Step: 2}})
+	if err != nil {
+		t.Fatalf("NewVariableFromSlice() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Dimensions, []int{2, 2}) || !reflect.DeepEqual(got.Data, []int32{10, 20, 12, 22}) {
+		t.Errorf("NewVariableFromSlice() = %v %v, want [2 2] [10 20 12 22]", got.Dimensions, got.Data)
+	}
+	if got.Name != "m" || got.Attributes["units"] != "V" {
+		t.Errorf("NewVariableFromSlice() = %s %v, want the name and attributes of m", got.Name, got.Attributes)
+	}
+	got.Data.([]int32)[0] = -1
+	if data[1] != 10 {
+		t.Error("NewVariableFromSlice() shares data with its argument")
+	}
+
+	// Strided rows, no columns, and a range on a trailing singleton
+	got, err = NewVariableFromSlice(v, []Range{{Start: 0, Stop: 3, Step: 2}, {}, {Start: 0, Stop: 1}})
+	if err != nil {
+		t.Fatalf("NewVariableFromSlice(strided) error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Dimensions, []int{2, 0, 1}) || len(got.Data.([]int32)) != 0 {
+		t.Errorf("NewVariableFromSlice(strided) = %v %v, want an empty 2x0x1 array", got.Dimensions, got.Data)
+	}
+	got, err = NewVariableFromSlice(v, []Range{{Start: 0, Stop: 3, Step: 2}})
+	if err != nil {
+		t.Fatalf("NewVariableFromSlice(rows) error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Dimensions, []int{2, 4}) || !reflect.DeepEqual(got.Data, []int32{0, 20, 1, 21, 2, 22, 3, 23}) {
+		t.Errorf("NewVariableFromSlice(rows) = %v %v", got.Dimensions, got.Data)
+	}
+
+	// Complex pages
+	z := &Variable{Name: "z", Dimensions: []int{1, 2, 2}, DataType: Double, IsComplex: true,
+		Data: &NumericArray{Real: []float64{1, 2, 3, 4}, Imag: []float64{-1, -2, -3, -4}}}
+	got, err = NewVariableFromSlice(z, []Range{{Stop: 1}, {Start: 1, Stop: 2}, {Stop: 2}})
+	if err != nil {
+		t.Fatalf("NewVariableFromSlice(complex) error = %v", err)
+	}
+	array := got.Data.(*NumericArray)
+	if !reflect.DeepEqual(got.Dimensions, []int{1, 1, 2}) || !reflect.DeepEqual(array.Real, []float64{2, 4}) ||
+		!reflect.DeepEqual(array.Imag, []float64{-2, -4}) {
+		t.Errorf("NewVariableFromSlice(complex) = %v %v %v", got.Dimensions, array.Real, array.Imag)
+	}
+
+	// Real data in a *NumericArray
+	r := &Variable{Name: "r", Dimensions: []int{1, 3}, DataType: Double, Data: &NumericArray{Real: []float64{1, 2, 3}}}
+	got, err = NewVariableFromSlice(r, []Range{{Stop: 1}, {Start: 1, Stop: 3}})
+	if err != nil {
+		t.Fatalf("NewVariableFromSlice(real array) error = %v", err)
+	}
+	if array := got.Data.(*NumericArray); !reflect.DeepEqual(array.Real, []float64{2, 3}) || array.Imag != nil {
+		t.Errorf("NewVariableFromSlice(real array) = %v %v", array.Real, array.Imag)
+	}
+
+	// Struct arrays keep their fields
+	element := func(x float64) []*Variable {
+		return []*Variable{{Name: "x", Dimensions: []int{1, 1}, DataType: Double, Data: []float64{x}}}
+	}
+	s := &Variable{Name: "s", Dimensions: []int{1, 3}, DataType: Struct,
+		Data: &StructArray{Fields: []string{"x"}, Elements: [][]*Variable{element(1), element(2), element(3)}, Dimensions: []int{1, 3}}}
+	got, err = NewVariableFromSlice(s, []Range{{Stop: 1}, {Start: 2, Stop: 3}})
+	if err != nil {
+		t.Fatalf("NewVariableFromSlice(struct) error = %v", err)
+	}
+	if st := got.Data.(*StructArray); len(st.Elements) != 1 || !reflect.DeepEqual(st.Field(0, "x").Data, []float64{3}) {
+		t.Errorf("NewVariableFromSlice(struct) = %+v", got.Data)
+	}
+
+	for _, ranges := range [][]Range{
+		{{Start: 0, Stop: 4}},
+		{{Start: -1, Stop: 2}},
+		{{Start: 2, Stop: 1}},
+		{{}, {}, {Start: 0, Stop: 2}},
+	} {
+		if _, err := NewVariableFromSlice(v, ranges); !errors.Is(err, ErrIndexOutOfRange) {
+			t.Errorf("NewVariableFromSlice(%v) error = %v, want ErrIndexOutOfRange", ranges, err)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDenseTooLarge indicates a sparse matrix whose dense form has more
+// elements than ToDense allows.
+var ErrDenseTooLarge = errors.New("dense matrix too large")
+
+// ErrTooDense indicates a matrix with more nonzero elements than ToSparse
+// allows.
+var ErrTooDense = errors.New("matrix too dense for sparse storage")
+
+// SparseMatrix represents a 2-D sparse matrix in MATLAB's compressed
+// sparse column layout. The stored elements of column j are at positions
+// ColPointers[j] to ColPointers[j+1]-1 of RowIndices, Real and Imag.
+//
+// A sparse variable has IsSparse set, DataType Double or Logical, and a
+// *SparseMatrix as Data.
+type SparseMatrix struct {
+	Rows        int         // Number of rows
+	Cols        int         // Number of columns
+	RowIndices  []int       // Zero-based row of each stored element (MATLAB's ir)
+	ColPointers []int       // Cols+1 offsets into the stored elements (MATLAB's jc)
+	Real        interface{} // Stored values: []float64, or []bool for logical matrices
+	Imag        []float64   // Imaginary parts of complex matrices, nil otherwise
+	NZMax       int         // Allocated capacity, at least NNZ; MATLAB's preallocation hint
+}
+
+// Dims returns the array dimensions.
+func (s SparseMatrix) Dims() []int { return []int{s.Rows, s.Cols} }
+
+// Size returns the total number of elements, including zeros.
+func (s SparseMatrix) Size() int { return s.Rows * s.Cols }
+
+// ElementType returns the data type of elements.
+func (s SparseMatrix) ElementType() DataType {
+	if _, ok := s.Real.([]bool); ok {
+		return Logical
+	}
+	return Double
+}
+
+// NNZ returns the number of stored elements.
+func (s SparseMatrix) NNZ() int {
+	if len(s.ColPointers) == 0 {
+		return 0
+	}
+	return s.ColPointers[len(s.ColPointers)-1]
+}
+
+// Validate checks that the matrix is consistent: Cols+1 nondecreasing
+// column pointers starting at 0, row indices within the matrix and one
+// value per stored element.
+func (s *SparseMatrix) Validate() error {
+	if s.Rows < 0 || s.Cols < 0 {
+		return fmt.Errorf("invalid sparse size %dx%d", s.Rows, s.Cols)
+	}
+	if len(s.ColPointers) != s.Cols+1 {
+		return fmt.Errorf("sparse matrix has %d column pointers, want %d", len(s.ColPointers), s.Cols+1)
+	}
+	if s.ColPointers[0] != 0 {
+		return fmt.Errorf("first column pointer is %d, want 0", s.ColPointers[0])
+	}
+	for j := 0; j < s.Cols; j++ {
+		if s.ColPointers[j+1] < s.ColPointers[j] {
+			return fmt.Errorf("column pointers decrease at column %d", j)
+		}
+	}
+
+	nnz := s.NNZ()
+	if len(s.RowIndices) < nnz {
+		return fmt.Errorf("sparse matrix has %d row indices for %d elements", len(s.RowIndices), nnz)
+	}
+	for _, r := range s.RowIndices[:nnz] {
+		if r < 0 || r >= s.Rows {
+			return fmt.Errorf("row index %d outside %d rows", r, s.Rows)
+		}
+	}
+	var values int
+	switch stored := s.Real.(type) {
+	case []float64:
+		values = len(stored)
+	case []bool:
+		values = len(stored)
+		if s.Imag != nil {
+			return errors.New("logical sparse matrix cannot be complex")
+		}
+	default:
+		return fmt.Errorf("sparse values must be []float64 or []bool, got %T", s.Real)
+	}
+	if values < nnz || (s.Imag != nil && len(s.Imag) < nnz) {
+		return fmt.Errorf("sparse matrix has too few values for %d elements", nnz)
+	}
+	return nil
+}
+
+// SparseOption configures ToDense and ToSparse.
+type SparseOption func(*sparseConfig)
+
+// sparseConfig holds the size and density guards of the conversions.
+type sparseConfig struct {
+	maxElements int     // Largest dense result ToDense creates
+	maxDensity  float64 // Largest fraction of nonzeros ToSparse accepts
+}
+
+// WithMaxDenseElements limits the number of elements, zeros included,
+// of the dense matrix ToDense creates.
+//
+// Default: 1<<27 (1 GiB of doubles)
+//
+// Example:
+//
+//	dense, err := v.ToDense(types.WithMaxDenseElements(1 << 20))
+func WithMaxDenseElements(n int) SparseOption {
+	return func(c *sparseConfig) {
+		c.maxElements = n
+	}
+}
+
+// WithMaxDensity limits the fraction of nonzero elements a matrix passed
+// to ToSparse may have. Above about one half, sparse storage takes more
+// memory than dense storage.
+//
+// Default: 0.5
+//
+// Example:
+//
+//	sparse, err := v.ToSparse(types.WithMaxDensity(1)) // always convert
+func WithMaxDensity(density float64) SparseOption {
+	return func(c *sparseConfig) {
+		c.maxDensity = density
+	}
+}
+
+// applySparseOptions returns the configuration with defaults and opts.
+func applySparseOptions(opts []SparseOption) *sparseConfig {
+	cfg := &sparseConfig{maxElements: 1 << 27, maxDensity: 0.5}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// ToDense returns a full copy of a sparse variable: []float64 for double,
+// []bool for logical and a *NumericArray for complex matrices, in
+// column-major order. Returns ErrDenseTooLarge if the result would exceed
+// WithMaxDenseElements.
+//
+// Example:
+//
+//	dense, err := v.ToDense()
+//	if errors.Is(err, types.ErrDenseTooLarge) {
+//	    // Work on the *types.SparseMatrix directly
+//	}
+func (v *Variable) ToDense(opts ...SparseOption) (*Variable, error) {
+	s, ok := v.Data.(*SparseMatrix)
+	if !ok || !v.IsSparse {
+		return nil, fmt.Errorf("%s is not a sparse matrix", v.Name)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", v.Name, err)
+	}
+	cfg := applySparseOptions(opts)
+	if s.Rows != 0 && s.Cols > cfg.maxElements/s.Rows {
+		return nil, fmt.Errorf("%w: %s is %dx%d, limit is %d elements", ErrDenseTooLarge, v.Name, s.Rows, s.Cols, cfg.maxElements)
+	}
+
+	dense := &Variable{
+		Name:       v.Name,
+		Dimensions: []int{s.Rows, s.Cols},
+		DataType:   s.ElementType(),
+		IsComplex:  s.Imag != nil,
+	}
+	n := s.Rows * s.Cols
+	switch stored := s.Real.(type) {
+	case []bool:
+		data := make([]bool, n)
+		s.scatter(func(i, k int) { data[i] = stored[k] })
+		dense.Data = data
+	case []float64:
+		data := make([]float64, n)
+		s.scatter(func(i, k int) { data[i] = stored[k] })
+		dense.Data = data
+		if s.Imag != nil {
+			im := make([]float64, n)
+			s.scatter(func(i, k int) { im[i] = s.Imag[k] })
+			dense.Data = &NumericArray{Real: data, Imag: im, Dimensions: dense.Dimensions, Type: Double}
+		}
+	}
+	return dense, nil
+}
+
+// scatter calls set with the column-major dense index and the storage
+// index of every stored element.
+func (s *SparseMatrix) scatter(set func(i, k int)) {
+	for j := 0; j < s.Cols; j++ {
+		for k := s.ColPointers[j]; k < s.ColPointers[j+1]; k++ {
+			set(j*s.Rows+s.RowIndices[k], k)
+		}
+	}
+}
+
+// ToSparse returns a sparse copy of a 2-D double or logical variable,
+// complex or not, storing only its nonzero elements. Returns ErrTooDense
+// if more than the WithMaxDensity fraction of elements are nonzero.
+//
+// Example:
+//
+//	sparse, err := v.ToSparse()
+//	if err != nil {
+//	    return err
+//	}
+//	fmt.Println(sparse.Data.(*types.SparseMatrix).NNZ())
+func (v *Variable) ToSparse(opts ...SparseOption) (*Variable, error) {
+	if len(v.Dimensions) != 2 {
+		return nil, fmt.Errorf("%s: sparse matrices are 2-D, dimensions are %v", v.Name, v.Dimensions)
+	}
+	rows, cols := v.Dimensions[0], v.Dimensions[1]
+
+	var re, im []float64
+	var mask []bool
+	switch data := v.Data.(type) {
+	case []float64:
+		re = data
+	case []bool:
+		mask = data
+	case *NumericArray:
+		var okRe, okIm bool
+		re, okRe = data.Real.([]float64)
+		im, okIm = data.Imag.([]float64)
+		if !okRe || !okIm || len(im) != len(re) {
+			return nil, fmt.Errorf("%s: only double complex data can be sparse", v.Name)
+		}
+	default:
+		return nil, fmt.Errorf("%s: only double and logical data can be sparse, got %T", v.Name, v.Data)
+	}
+	n := len(re) + len(mask)
+	if n != rows*cols {
+		return nil, fmt.Errorf("%s: data has %d elements, dimensions %v need %d", v.Name, n, v.Dimensions, rows*cols)
+	}
+
+	nonzero := func(i int) bool {
+		if mask != nil {
+			return mask[i]
+		}
+		return re[i] != 0 || (im != nil && im[i] != 0)
+	}
+	nnz := 0
+	for i := 0; i < n; i++ {
+		if nonzero(i) {
+			nnz++
+		}
+	}
+	cfg := applySparseOptions(opts)
+	if n > 0 && float64(nnz) > cfg.maxDensity*float64(n) {
+		return nil, fmt.Errorf("%w: %s has %d nonzeros in %d elements, limit is density %g",
+			ErrTooDense, v.Name, nnz, n, cfg.maxDensity)
+	}
+
+	s := &SparseMatrix{
+		Rows:        rows,
+		Cols:        cols,
+		RowIndices:  make([]int, 0, nnz),
+		ColPointers: make([]int, cols+1),
+		NZMax:       nnz,
+	}
+	var values []float64
+	var flags []bool
+	if mask != nil {
+		flags = make([]bool, 0, nnz)
+	} else {
+		values = make([]float64, 0, nnz)
+	}
+	if im != nil {
+		s.Imag = make([]float64, 0, nnz)
+	}
+	for j := 0; j < cols; j++ {
+		for r := 0; r < rows; r++ {
+			i := j*rows + r
+			if !nonzero(i) {
+				continue
+			}
+			s.RowIndices = append(s.RowIndices, r)
+			if mask != nil {
+				flags = append(flags, true)
+				continue
+			}
+			values = append(values, re[i])
+			if im != nil {
+				s.Imag = append(s.Imag, im[i])
+			}
+		}
+		s.ColPointers[j+1] = len(s.RowIndices)
+	}
+	s.Real = values
+	dataType := Double
+	if mask != nil {
+		s.Real = flags
+		dataType = Logical
+	}
+
+	return &Variable{
+		Name:       v.Name,
+		Dimensions: []int{rows, cols},
+		DataType:   dataType,
+		Data:       s,
+		IsComplex:  im != nil,
+		IsSparse:   true,
+	}, nil
+}
@@ -0,0 +1,142 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestVariable_ToSparse(t *testing.T) {
+	// 3x2 matrix [1 0; 0 0; 2 3] in column-major order
+	dense := &Variable{Name: "a", Dimensions: []int{3, 2}, DataType: Double, Data: []float64{1, 0, 2, 0, 0, 3}}
+	sparse, err := dense.ToSparse()
+	if err != nil {
+		t.Fatalf("ToSparse() error = %v", err)
+	}
+	want := &SparseMatrix{
+		Rows: 3, Cols: 2,
+		RowIndices:  []int{0, 2, 2},
+		ColPointers: []int{0, 2, 3},
+		Real:        []float64{1, 2, 3},
+		NZMax:       3,
+	}
+	if !sparse.IsSparse || sparse.DataType != Double || !reflect.DeepEqual(sparse.Data, want) {
+		t.Fatalf("ToSparse() = %+v, data %+v", sparse, sparse.Data)
+	}
+
+	back, err := sparse.ToDense()
+	if err != nil {
+		t.Fatalf("ToDense() error = %v", err)
+	}
+	if back.IsSparse || !reflect.DeepEqual(back.Data, dense.Data) || !reflect.DeepEqual(back.Dimensions, dense.Dimensions) {
+		t.Errorf("ToDense() = %+v", back)
+	}
+}
+
+func TestVariable_ToSparse_LogicalAndComplex(t *testing.T) {
+	mask := &Variable{Name: "m", Dimensions: []int{2, 2}, DataType: Logical, Data: []bool{false, true, false, false}}
+	sparse, err := mask.ToSparse()
+	if err != nil {
+		t.Fatalf("ToSparse(logical) error = %v", err)
+	}
+	if s := sparse.Data.(*SparseMatrix); sparse.DataType != Logical || !reflect.DeepEqual(s.Real, []bool{true}) || s.NNZ() != 1 {
+		t.Errorf("ToSparse(logical) = %+v", s)
+	}
+	if back, err := sparse.ToDense(); err != nil || !reflect.DeepEqual(back.Data, mask.Data) {
+		t.Errorf("ToDense(logical) = %+v, %v", back, err)
+	}
+
+	z := &Variable{Name: "z", Dimensions: []int{1, 4}, DataType: Double, IsComplex: true,
+		Data: &NumericArray{Real: []float64{0, 0, 5, 0}, Imag: []float64{0, 2, 0, 0}, Dimensions: []int{1, 4}, Type: Double}}
+	sparse, err = z.ToSparse()
+	if err != nil {
+		t.Fatalf("ToSparse(complex) error = %v", err)
+	}
+	if s := sparse.Data.(*SparseMatrix); !reflect.DeepEqual(s.Real, []float64{0, 5}) || !reflect.DeepEqual(s.Imag, []float64{2, 0}) {
+		t.Errorf("ToSparse(complex) = %+v", s)
+	}
+	if back, err := sparse.ToDense(); err != nil || !Equal(z, back) {
+		t.Errorf("ToDense(complex) = %+v, %v", back, err)
+	}
+}
+
+func TestVariable_SparseGuards(t *testing.T) {
+	full := &Variable{Name: "f", Dimensions: []int{2, 2}, DataType: Double, Data: []float64{1, 2, 3, 0}}
+	if _, err := full.ToSparse(); !errors.Is(err, ErrTooDense) {
+		t.Errorf("ToSparse(75%% dense) error = %v, want ErrTooDense", err)
+	}
+	if _, err := full.ToSparse(WithMaxDensity(1)); err != nil {
+		t.Errorf("ToSparse(WithMaxDensity(1)) error = %v", err)
+	}
+
+	huge := &Variable{Name: "h", Dimensions: []int{1 << 20, 1 << 20}, DataType: Double, IsSparse: true,
+		Data: &SparseMatrix{Rows: 1 << 20, Cols: 1 << 20, ColPointers: make([]int, 1<<20+1), Real: []float64{}}}
+	if _, err := huge.ToDense(); !errors.Is(err, ErrDenseTooLarge) {
+		t.Errorf("ToDense(2^40 elements) error = %v, want ErrDenseTooLarge", err)
+	}
+	small := &Variable{Name: "s", Dimensions: []int{4, 4}, DataType: Double, IsSparse: true,
+		Data: &SparseMatrix{Rows: 4, Cols: 4, ColPointers: make([]int, 5), Real: []float64{}}}
+	if _, err := small.ToDense(WithMaxDenseElements(15)); !errors.Is(err, ErrDenseTooLarge) {
+		t.Errorf("ToDense(WithMaxDenseElements(15)) error = %v, want ErrDenseTooLarge", err)
+	}
+
+	for _, v := range []*Variable{
+		{Name: "cube", Dimensions: []int{1, 1, 2}, DataType: Double, Data: []float64{1, 0}},
+		{Name: "ints", Dimensions: []int{1, 2}, DataType: Int32, Data: []int32{1, 0}},
+		{Name: "short", Dimensions: []int{2, 2}, DataType: Double, Data: []float64{1}},
+	} {
+		if _, err := v.ToSparse(); err == nil {
+			t.Errorf("ToSparse(%s) error = nil", v.Name)
+		}
+	}
+	if _, err := full.ToDense(); err == nil {
+		t.Error("ToDense(dense variable) error = nil")
+	}
+}
+
+func TestSparseMatrix_Validate(t *testing.T) {
+	valid := func() *SparseMatrix {
+		return &SparseMatrix{Rows: 2, Cols: 2, RowIndices: []int{1}, ColPointers: []int{0, 0, 1}, Real: []float64{4}}
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(s *SparseMatrix)
+	}{
+		{"pointer count", func(s *SparseMatrix) { s.ColPointers = []int{0, 1} }},
+		{"first pointer", func(s *SparseMatrix) { s.ColPointers = []int{1, 1, 1} }},
+		{"decreasing", func(s *SparseMatrix) { s.ColPointers = []int{0, 1, 0} }},
+		{"row range", func(s *SparseMatrix) { s.RowIndices = []int{2} }},
+		{"missing rows", func(s *SparseMatrix) { s.RowIndices = nil }},
+		{"missing values", func(s *SparseMatrix) { s.Real = []float64{} }},
+		{"value type", func(s *SparseMatrix) { s.Real = []int32{4} }},
+		{"complex logical", func(s *SparseMatrix) { s.Real, s.Imag = []bool{true}, []float64{1} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid()
+			tt.corrupt(s)
+			if err := s.Validate(); err == nil {
+				t.Error("Validate() error = nil")
+			}
+		})
+	}
+}
+
+func TestSparseMatrix_Clone(t *testing.T) {
+	s := &SparseMatrix{Rows: 2, Cols: 1, RowIndices: []int{1}, ColPointers: []int{0, 1},
+		Real: []float64{4}, Imag: []float64{1}, NZMax: 5}
+	v := &Variable{Name: "s", Dimensions: []int{2, 1}, DataType: Double, IsSparse: true, IsComplex: true, Data: s}
+	clone := v.Clone()
+	c := clone.Data.(*SparseMatrix)
+	if !reflect.DeepEqual(c, s) {
+		t.Fatalf("Clone() = %+v", c)
+	}
+	c.RowIndices[0], c.Real.([]float64)[0], c.Imag[0] = 0, 9, 9
+	if s.RowIndices[0] != 1 || s.Real.([]float64)[0] != 4 || s.Imag[0] != 1 {
+		t.Error("Clone() shares data with the original")
+	}
+}
@@ -0,0 +1,274 @@
+package types
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// Format is a text format StreamTo can write.
+type Format int
+
+// Formats supported by StreamTo.
+const (
+	FormatCSV  Format = iota // One matrix row per line, values separated by commas
+	FormatJSON               // An object with name, class, dims and the rows as nested arrays
+)
+
+// StreamOption configures StreamTo.
+type StreamOption func(*streamConfig)
+
+// streamConfig holds optional configuration for StreamTo.
+type streamConfig struct {
+	delimiter byte // CSV field separator
+	precision int  // Significant digits of floats; -1 for the shortest exact form
+}
+
+// WithDelimiter sets the CSV field separator, such as '\t' or ';'.
+//
+// Default: ','
+//
+// Example:
+//
+//	err := v.StreamTo(w, types.FormatCSV, types.WithDelimiter('\t'))
+func WithDelimiter(delimiter byte) StreamOption {
+	return func(c *streamConfig) {
+		c.delimiter = delimiter
+	}
+}
+
+// WithSignificantDigits rounds floating-point values to the given number
+// of significant digits, which shortens the output.
+//
+// Default: the shortest representation that reads back exactly
+//
+// Example:
+//
+//	err := v.StreamTo(w, types.FormatCSV, types.WithSignificantDigits(6))
+func WithSignificantDigits(digits int) StreamOption {
+	return func(c *streamConfig) {
+		if digits > 0 {
+			c.precision = digits
+		}
+	}
+}
+
+// StreamTo writes the variable's data to w as CSV or JSON, one matrix row
+// at a time through a small buffer, so the output is never held in memory
+// and the data is never converted to another element type. A matrix of
+// 50 million rows is exported with only its own data in memory; for
+// matrices too large even for that, export column ranges read with
+// matlab.LoadColumns.
+//
+// Arrays with more than two dimensions are written as dims[0] rows, as
+// MATLAB's reshape(x, size(x, 1), []) would. Complex values are written
+// as 1+2i in CSV and as [re, im] pairs in JSON. NaN and infinite values
+// are written as NaN, Inf and -Inf in CSV and as null in JSON, which
+// cannot hold them. Only numeric and logical arrays are supported.
+//
+// Example:
+//
+//	file, _ := os.Create("signal.csv")
+//	defer file.Close()
+//	if err := v.StreamTo(file, types.FormatCSV); err != nil {
+//	    log.Fatal(err)
+//	}
+func (v *Variable) StreamTo(w io.Writer, format Format, opts ...StreamOption) error {
+	cfg := &streamConfig{delimiter: ',', precision: -1}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if format != FormatCSV && format != FormatJSON {
+		return fmt.Errorf("unknown stream format %d", format)
+	}
+	asJSON := format == FormatJSON
+
+	realPart, imagPart := v.Data, interface{}(nil)
+	if complexData, ok := v.Data.(*NumericArray); ok {
+		realPart, imagPart = complexData.Real, complexData.Imag
+	}
+	appendReal, n := elementAppender(realPart, cfg.precision, asJSON)
+	if appendReal == nil {
+		return fmt.Errorf("cannot stream %s %s: only numeric and logical arrays are supported", v.Name, v.DataType)
+	}
+	appendValue := appendReal
+	if imagPart != nil {
+		appendImag, m := elementAppender(imagPart, cfg.precision, asJSON)
+		if appendImag == nil || m != n || reflect.TypeOf(imagPart) != reflect.TypeOf(realPart) {
+			return fmt.Errorf("cannot stream %s: imaginary part %T does not match real part %T", v.Name, imagPart, realPart)
+		}
+		appendValue = complexAppender(appendReal, appendImag, asJSON)
+	}
+
+	rows, cols := streamShape(v.Dimensions)
+	if rows*cols != n {
+		return fmt.Errorf("cannot stream %s: data has %d elements, dimensions %v need %d", v.Name, n, v.Dimensions, rows*cols)
+	}
+
+	bw := bufio.NewWriterSize(w, 64*1024)
+	var err error
+	if asJSON {
+		err = streamJSON(bw, v, rows, cols, appendValue)
+	} else {
+		err = streamCSV(bw, rows, cols, cfg.delimiter, appendValue)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// streamCSV writes the rows of a column-major matrix as CSV lines.
+func streamCSV(w *bufio.Writer, rows, cols int, delimiter byte, appendValue func([]byte, int) []byte) error {
+	var line []byte
+	for r := 0; r < rows; r++ {
+		line = line[:0]
+		for c := 0; c < cols; c++ {
+			if c > 0 {
+				line = append(line, delimiter)
+			}
+			line = appendValue(line, c*rows+r)
+		}
+		line = append(line, '\n')
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamJSON writes a JSON object with the matrix rows as arrays, one row
+// per line.
+func streamJSON(w *bufio.Writer, v *Variable, rows, cols int, appendValue func([]byte, int) []byte) error {
+	name, err := json.Marshal(v.Name)
+	if err != nil {
+		return err
+	}
+	dims, err := json.Marshal(v.Dimensions)
+	if err != nil {
+		return err
+	}
+	if v.Dimensions == nil {
+		dims = []byte("[]")
+	}
+	if _, err := fmt.Fprintf(w, "{\"name\":%s,\"class\":%q,\"complex\":%t,\"dims\":%s,\"data\":[",
+		name, v.DataType, v.IsComplex, dims); err != nil {
+		return err
+	}
+
+	var line []byte
+	for r := 0; r < rows; r++ {
+		line = append(line[:0], '\n', '[')
+		for c := 0; c < cols; c++ {
+			if c > 0 {
+				line = append(line, ',')
+			}
+			line = appendValue(line, c*rows+r)
+		}
+		line = append(line, ']')
+		if r < rows-1 {
+			line = append(line, ',')
+		}
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	_, err = w.WriteString("\n]}\n")
+	return err
+}
+
+// streamShape returns the rows and columns data is written as.
+func streamShape(dims []int) (rows, cols int) {
+	if len(dims) == 0 {
+		return 0, 0
+	}
+	rows, cols = dims[0], 1
+	for _, d := range dims[1:] {
+		cols *= d
+	}
+	return rows, cols
+}
+
+// elementAppender returns a function appending the text of element i of
+// a numeric or logical slice, and the slice length. It returns nil for
+// other data.
+//
+//nolint:gocyclo,cyclop // One case per element type
+func elementAppender(data interface{}, precision int, asJSON bool) (func([]byte, int) []byte, int) {
+	switch d := data.(type) {
+	case []float64:
+		return func(b []byte, i int) []byte { return appendFloat(b, d[i], 64, precision, asJSON) }, len(d)
+	case []float32:
+		return func(b []byte, i int) []byte { return appendFloat(b, float64(d[i]), 32, precision, asJSON) }, len(d)
+	case []int8:
+		return func(b []byte, i int) []byte { return strconv.AppendInt(b, int64(d[i]), 10) }, len(d)
+	case []int16:
+		return func(b []byte, i int) []byte { return strconv.AppendInt(b, int64(d[i]), 10) }, len(d)
+	case []int32:
+		return func(b []byte, i int) []byte { return strconv.AppendInt(b, int64(d[i]), 10) }, len(d)
+	case []int64:
+		return func(b []byte, i int) []byte { return strconv.AppendInt(b, d[i], 10) }, len(d)
+	case []uint8:
+		return func(b []byte, i int) []byte { return strconv.AppendUint(b, uint64(d[i]), 10) }, len(d)
+	case []uint16:
+		return func(b []byte, i int) []byte { return strconv.AppendUint(b, uint64(d[i]), 10) }, len(d)
+	case []uint32:
+		return func(b []byte, i int) []byte { return strconv.AppendUint(b, uint64(d[i]), 10) }, len(d)
+	case []uint64:
+		return func(b []byte, i int) []byte { return strconv.AppendUint(b, d[i], 10) }, len(d)
+	case []bool:
+		if asJSON {
+			return func(b []byte, i int) []byte { return strconv.AppendBool(b, d[i]) }, len(d)
+		}
+		return func(b []byte, i int) []byte {
+			if d[i] {
+				return append(b, '1')
+			}
+			return append(b, '0')
+		}, len(d)
+	default:
+		return nil, 0
+	}
+}
+
+// complexAppender combines the appenders of the real and imaginary parts.
+func complexAppender(appendReal, appendImag func([]byte, int) []byte, asJSON bool) func([]byte, int) []byte {
+	if asJSON {
+		return func(b []byte, i int) []byte {
+			b = appendReal(append(b, '['), i)
+			return append(appendImag(append(b, ','), i), ']')
+		}
+	}
+	return func(b []byte, i int) []byte {
+		b = appendReal(b, i)
+		start := len(b)
+		b = appendImag(b, i)
+		if b[start] != '-' {
+			b = append(b, 0)
+			copy(b[start+1:], b[start:])
+			b[start] = '+'
+		}
+		return append(b, 'i')
+	}
+}
+
+// appendFloat appends a float in the shortest form or with the given
+// significant digits. NaN and infinities are written as MATLAB shows
+// them, or as null in JSON.
+func appendFloat(b []byte, f float64, bits, precision int, asJSON bool) []byte {
+	switch {
+	case (math.IsNaN(f) || math.IsInf(f, 0)) && asJSON:
+		return append(b, "null"...)
+	case math.IsNaN(f):
+		return append(b, "NaN"...)
+	case math.IsInf(f, 1):
+		return append(b, "Inf"...)
+	case math.IsInf(f, -1):
+		return append(b, "-Inf"...)
+	}
+	return strconv.AppendFloat(b, f, 'g', precision, bits)
+}
@@ -0,0 +1,145 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestVariable_StreamTo(t *testing.T) {
+	matrix := &Variable{Name: "x", Dimensions: []int{2, 3}, DataType: Double,
+		Data: []float64{1.5, 3, -2, math.NaN(), 0.1, math.Inf(-1)}}
+	complexRow := &Variable{Name: "z", Dimensions: []int{1, 2}, DataType: Int16, IsComplex: true,
+		Data: &NumericArray{Real: []int16{1, 0}, Imag: []int16{-2, 3}}}
+
+	tests := []struct {
+		name   string
+		v      *Variable
+		format Format
+		opts   []StreamOption
+		want   string
+	}{
+		{"csv", matrix, FormatCSV, nil, "1.5,-2,0.1\n3,NaN,-Inf\n"},
+		{"csv delimiter", matrix, FormatCSV, []StreamOption{WithDelimiter('\t')}, "1.5\t-2\t0.1\n3\tNaN\t-Inf\n"},
+		{"csv digits", &Variable{Name: "p", Dimensions: []int{1, 2}, DataType: Single, Data: []float32{math.Pi, 1e6}},
+			FormatCSV, []StreamOption{WithSignificantDigits(3)}, "3.14,1e+06\n"},
+		{"csv logical", &Variable{Name: "m", Dimensions: []int{3}, DataType: Logical, Data: []bool{true, false, true}},
+			FormatCSV, nil, "1\n0\n1\n"},
+		{"csv complex", complexRow, FormatCSV, nil, "1-2i,0+3i\n"},
+		{"csv 3-D", &Variable{Name: "c", Dimensions: []int{1, 2, 2}, DataType: Uint64, Data: []uint64{1, 2, 3, 1 << 63}},
+			FormatCSV, nil, "1,2,3,9223372036854775808\n"},
+		{"csv empty", &Variable{Name: "e", Dimensions: []int{0, 3}, DataType: Double, Data: []float64{}},
+			FormatCSV, nil, ""},
+		{"json", matrix, FormatJSON, nil,
+			"{\"name\":\"x\",\"class\":\"double\",\"complex\":false,\"dims\":[2,3],\"data\":[\n[1.5,-2,0.1],\n[3,null,null]\n]}\n"},
+		{"json complex", complexRow, FormatJSON, nil,
+			"{\"name\":\"z\",\"class\":\"int16\",\"complex\":true,\"dims\":[1,2],\"data\":[\n[[1,-2],[0,3]]\n]}\n"},
+		{"json logical", &Variable{Name: "m", Dimensions: []int{1, 2}, DataType: Logical, Data: []bool{true, false}},
+			FormatJSON, nil, "{\"name\":\"m\",\"class\":\"logical\",\"complex\":false,\"dims\":[1,2],\"data\":[\n[true,false]\n]}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.v.StreamTo(&buf, tt.format, tt.opts...); err != nil {
+				t.Fatalf("StreamTo() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("StreamTo() =\n%q\nwant\n%q", buf.String(), tt.want)
+			}
+			if tt.format == FormatJSON && !json.Valid(buf.Bytes()) {
+				t.Errorf("StreamTo() wrote invalid JSON: %s", buf.String())
+			}
+		})
+	}
+}
+
+// TestVariable_StreamTo_RoundTrip checks that exported floats parse back
+// to the same bits.
+func TestVariable_StreamTo_RoundTrip(t *testing.T) {
+	values := []float64{0.30000000000000004, 1.0 / 3, math.MaxFloat64, math.SmallestNonzeroFloat64, -1e-310, 123456789012345678}
+	singles := []float32{0.1, math.MaxFloat32, math.SmallestNonzeroFloat32}
+	for _, format := range []Format{FormatCSV, FormatJSON} {
+		var buf bytes.Buffer
+		v := &Variable{Name: "x", Dimensions: []int{1, len(values)}, DataType: Double, Data: values}
+		if err := v.StreamTo(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		s := &Variable{Name: "s", Dimensions: []int{1, len(singles)}, DataType: Single, Data: singles}
+		if err := s.StreamTo(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		text := buf.String()
+		for _, want := range values {
+			if !strings.Contains(text, strconv.FormatFloat(want, 'g', -1, 64)) {
+				t.Errorf("format %d: %v not written exactly in %q", format, want, text)
+			}
+		}
+		for _, want := range singles {
+			formatted := strconv.FormatFloat(float64(want), 'g', -1, 32)
+			got, err := strconv.ParseFloat(formatted, 32)
+			if err != nil || float32(got) != want || !strings.Contains(text, formatted) {
+				t.Errorf("format %d: %v not written exactly in %q", format, want, text)
+			}
+		}
+	}
+}
+
+func TestVariable_StreamTo_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		v      *Variable
+		format Format
+	}{
+		{"char", &Variable{Name: "s", Dimensions: []int{1, 2}, DataType: Char, Data: "ab"}, FormatCSV},
+		{"struct", &Variable{Name: "st", Dimensions: []int{1, 1}, DataType: Struct, Data: &StructArray{}}, FormatJSON},
+		{"dims mismatch", &Variable{Name: "x", Dimensions: []int{2, 2}, DataType: Double, Data: []float64{1, 2, 3}}, FormatCSV},
+		{"imag mismatch", &Variable{Name: "z", Dimensions: []int{1, 1}, DataType: Double, IsComplex: true,
+			Data: &NumericArray{Real: []float64{1}, Imag: []float32{2}}}, FormatCSV},
+		{"format", &Variable{Name: "x", Dimensions: []int{1, 1}, DataType: Double, Data: []float64{1}}, Format(9)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.v.StreamTo(io.Discard, tt.format); err == nil {
+				t.Error("StreamTo() error = nil, want error")
+			}
+		})
+	}
+}
+
+// TestVariable_StreamTo_Memory checks that the output is written row by
+// row instead of being built in memory first.
+func TestVariable_StreamTo_Memory(t *testing.T) {
+	const rows = 100000
+	data := make([]float64, rows*3)
+	for i := range data {
+		data[i] = float64(i) / 7
+	}
+	v := &Variable{Name: "big", Dimensions: []int{rows, 3}, DataType: Double, Data: data}
+
+	var written countingWriter
+	allocs := testing.AllocsPerRun(1, func() {
+		if err := v.StreamTo(&written, FormatCSV); err != nil {
+			t.Fatalf("StreamTo() error = %v", err)
+		}
+	})
+	if written.lines != 2*rows { // AllocsPerRun runs the function twice
+		t.Errorf("wrote %d lines, want %d", written.lines, 2*rows)
+	}
+	if allocs > 100 {
+		t.Errorf("StreamTo() made %.0f allocations for %d rows", allocs, rows)
+	}
+}
+
+// countingWriter counts the lines written to it and discards them.
+type countingWriter struct {
+	lines int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.lines += strings.Count(string(p), "\n")
+	return len(p), nil
+}
@@ -1,6 +1,10 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
 
 // DataType represents MATLAB data types.
 type DataType int
@@ -22,12 +26,14 @@ const (
 	CellArray
 	Object
 	Unknown
+	Logical // Boolean array, Data is []bool
 )
 
 func (d DataType) String() string {
 	return [...]string{
 		"double", "single", "int8", "uint8", "int16", "uint16",
 		"int32", "uint32", "int64", "uint64", "char", "struct", "cell", "object", "unknown",
+		"logical",
 	}[d]
 }
 
@@ -40,6 +46,22 @@ type Variable struct {
 	IsComplex  bool                   // True for complex numbers
 	IsSparse   bool                   // True for sparse matrices
 	Attributes map[string]interface{} // Additional metadata
+
+	raw      *RawData  // Undecoded stored data, see RawBytes
+	location *Location // Storage in the file, see Location
+}
+
+// VariableInfo describes a variable as stored in a file, without its data.
+type VariableInfo struct {
+	Name             string   // Variable name
+	Dimensions       []int    // Array dimensions
+	DataType         DataType // Data type identifier
+	IsComplex        bool     // True for complex numbers
+	IsSparse         bool     // True for sparse matrices
+	Compressed       bool     // True if the stored data is compressed
+	Offset           int64    // Byte offset of the stored variable in the file, -1 if not contiguous
+	Size             int64    // Bytes the variable occupies in the file
+	UncompressedSize int64    // Bytes without compression; equals Size if not compressed
 }
 
 // String returns a string representation of the variable.
@@ -56,6 +78,69 @@ func (v *Variable) GetAttribute(name string) (interface{}, bool) {
 	return val, ok
 }
 
+// GetStringAttr retrieves a string attribute by name. A one-element
+// string slice is accepted as well. Returns false if the attribute is
+// missing or not a string.
+//
+// Example:
+//
+//	class, ok := variable.GetStringAttr(types.AttrMatlabClass)
+func (v *Variable) GetStringAttr(name string) (string, bool) {
+	val, ok := v.GetAttribute(name)
+	if !ok {
+		return "", false
+	}
+	switch s := val.(type) {
+	case string:
+		return s, true
+	case []string:
+		if len(s) == 1 {
+			return s[0], true
+		}
+	}
+	return "", false
+}
+
+// GetIntAttr retrieves an integer attribute by name. Any Go integer type
+// is accepted, as are floating-point values holding a whole number and
+// one-element slices of either. Returns false if the attribute is missing
+// or not an integer.
+//
+// Example:
+//
+//	decode, ok := variable.GetIntAttr(types.AttrMatlabIntDecode)
+func (v *Variable) GetIntAttr(name string) (int64, bool) {
+	val, ok := v.GetAttribute(name)
+	if !ok {
+		return 0, false
+	}
+	if rv := reflect.ValueOf(val); rv.Kind() == reflect.Slice {
+		if rv.Len() != 1 {
+			return 0, false
+		}
+		val = rv.Index(0).Interface()
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(f), true
+	default:
+		return 0, false
+	}
+}
+
 // GetFloat64Array extracts variable data as []float64.
 // Supports automatic conversion from float32, int types.
 // Returns error if data is complex or incompatible type.
@@ -248,8 +333,21 @@ func (v *Variable) GetComplex128Array() ([]complex128, error) {
 	}
 }
 
+// IsScalar reports whether v is a single element. MATLAB scalars are
+// 1x1; dimensions [], [1], [1 1] and [1 1 1] all describe one.
+func (v *Variable) IsScalar() bool {
+	for _, d := range v.Dimensions {
+		if d != 1 {
+			return false
+		}
+	}
+	return true
+}
+
 // GetScalar extracts a scalar value (single element).
-// Returns error if variable has more than one element.
+// Returns error if variable has more than one element. Dimensions [],
+// [1] and [1 1] are accepted alike (see IsScalar). Logical scalars are
+// returned as bool and complex scalars as complex128.
 //
 // Example:
 //
@@ -262,18 +360,29 @@ func (v *Variable) GetComplex128Array() ([]complex128, error) {
 //
 //nolint:gocognit,gocyclo,cyclop // Type extraction requires checking all numeric types
 func (v *Variable) GetScalar() (interface{}, error) {
-	// Calculate total elements.
-	totalElements := 1
-	for _, dim := range v.Dimensions {
-		totalElements *= dim
-	}
-
-	if totalElements != 1 {
+	if !v.IsScalar() {
+		// Calculate total elements.
+		totalElements := 1
+		for _, dim := range v.Dimensions {
+			totalElements *= dim
+		}
 		return nil, fmt.Errorf("variable has %d elements, not a scalar", totalElements)
 	}
 
 	// Extract first element based on type.
 	switch data := v.Data.(type) {
+	case *NumericArray:
+		values, err := v.GetComplex128Array()
+		if err != nil {
+			return nil, err
+		}
+		if len(values) > 0 {
+			return values[0], nil
+		}
+	case []bool:
+		if len(data) > 0 {
+			return data[0], nil
+		}
 	case []float64:
 		if len(data) > 0 {
 			return data[0], nil
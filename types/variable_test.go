@@ -370,6 +370,7 @@ func TestDataType_String(t *testing.T) {
 		{CellArray, "cell"},
 		{Object, "object"},
 		{Unknown, "unknown"},
+		{Logical, "logical"},
 	}
 
 	for _, tt := range tests {
@@ -473,6 +474,76 @@ func TestVariable_GetAttribute(t *testing.T) {
 	})
 }
 
+// TestVariable_GetStringAttr tests typed string attribute retrieval.
+func TestVariable_GetStringAttr(t *testing.T) {
+	v := &Variable{Attributes: map[string]interface{}{
+		"class": "double",
+		"one":   []string{"x"},
+		"two":   []string{"x", "y"},
+		"num":   int64(1),
+	}}
+
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"class", "double", true},
+		{"one", "x", true},
+		{"two", "", false},
+		{"num", "", false},
+		{"missing", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := v.GetStringAttr(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("GetStringAttr(%q) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// TestVariable_GetIntAttr tests typed integer attribute retrieval.
+func TestVariable_GetIntAttr(t *testing.T) {
+	v := &Variable{Attributes: map[string]interface{}{
+		"i64":   int64(-7),
+		"i32":   int32(8),
+		"u32":   uint32(9),
+		"u64":   uint64(1 << 63),
+		"whole": 2.0,
+		"frac":  2.5,
+		"one":   []int64{3},
+		"two":   []int64{3, 4},
+		"str":   "1",
+	}}
+
+	tests := []struct {
+		name   string
+		want   int64
+		wantOK bool
+	}{
+		{"i64", -7, true},
+		{"i32", 8, true},
+		{"u32", 9, true},
+		{"u64", 0, false},
+		{"whole", 2, true},
+		{"frac", 0, false},
+		{"one", 3, true},
+		{"two", 0, false},
+		{"str", 0, false},
+		{"missing", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := v.GetIntAttr(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("GetIntAttr(%q) = %d, %v; want %d, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if _, ok := (&Variable{}).GetIntAttr("x"); ok {
+		t.Error("expected ok=false for nil attributes map")
+	}
+}
+
 // TestVariable_GetFloat64Array_AllTypes tests all numeric type branches.
 func TestVariable_GetFloat64Array_AllTypes(t *testing.T) {
 	tests := []struct {
@@ -765,3 +836,47 @@ func TestVariable_GetComplex128Array_EdgeCases(t *testing.T) {
 		}
 	})
 }
+
+// TestVariable_IsScalar tests that [], [1] and [1 1] are all scalars.
+func TestVariable_IsScalar(t *testing.T) {
+	tests := []struct {
+		dims []int
+		want bool
+	}{
+		{nil, true},
+		{[]int{1}, true},
+		{[]int{1, 1}, true},
+		{[]int{1, 1, 1}, true},
+		{[]int{2}, false},
+		{[]int{1, 2}, false},
+		{[]int{0, 1}, false},
+	}
+	for _, tt := range tests {
+		v := &Variable{Name: "x", Dimensions: tt.dims}
+		if got := v.IsScalar(); got != tt.want {
+			t.Errorf("IsScalar(%v) = %v, want %v", tt.dims, got, tt.want)
+		}
+	}
+}
+
+// TestVariable_GetScalar_Shapes tests GetScalar on every scalar shape and
+// on logical and complex scalars.
+func TestVariable_GetScalar_Shapes(t *testing.T) {
+	for _, dims := range [][]int{nil, {1}, {1, 1}} {
+		v := &Variable{Name: "x", Dimensions: dims, DataType: Double, Data: []float64{7}}
+		if got, err := v.GetScalar(); err != nil || got != 7.0 {
+			t.Errorf("GetScalar(%v) = %v, %v", dims, got, err)
+		}
+	}
+
+	flag := &Variable{Name: "b", Dimensions: []int{1, 1}, DataType: Logical, Data: []bool{true}}
+	if got, err := flag.GetScalar(); err != nil || got != true {
+		t.Errorf("GetScalar(logical) = %v, %v", got, err)
+	}
+
+	z := &Variable{Name: "z", Dimensions: []int{1, 1}, DataType: Double, IsComplex: true,
+		Data: &NumericArray{Real: []float64{1}, Imag: []float64{-2}}}
+	if got, err := z.GetScalar(); err != nil || got != complex(1, -2) {
+		t.Errorf("GetScalar(complex) = %v, %v", got, err)
+	}
+}
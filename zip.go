@@ -0,0 +1,136 @@
+package matlab
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"iter"
+	"strings"
+)
+
+// FSMatFile is a MAT-file read from a file system, such as a member of a
+// zip archive.
+type FSMatFile struct {
+	Path string   // Slash-separated path within the file system
+	File *MatFile // Parsed file, nil if it could not be read
+}
+
+// OpenFS reads and parses the MAT-file name of fsys, as Open does.
+// Compressed files (.mat.gz, .mat.zst) are decompressed transparently.
+//
+// Example:
+//
+//	matFile, err := matlab.OpenFS(os.DirFS("results"), "2024/run42.mat")
+func OpenFS(fsys fs.FS, name string, opts ...OpenOption) (*MatFile, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck // Read-only file
+
+	matFile, err := Open(f, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return matFile, nil
+}
+
+// WalkFS returns an iterator over the MAT-files of fsys, in lexical
+// order. Files are recognized by their .mat, .mat.gz or .mat.zst
+// extension (in any case) and read one at a time as the iteration
+// proceeds.
+//
+// A file that cannot be read is yielded with its path, a nil File and the
+// error; iteration continues with the next file unless the loop breaks.
+// Errors listing fsys are yielded with a nil *FSMatFile.
+//
+// Example:
+//
+//	for f, err := range matlab.WalkFS(os.DirFS("results")) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Println(f.Path, len(f.File.Variables))
+//	}
+func WalkFS(fsys fs.FS, opts ...OpenOption) iter.Seq2[*FSMatFile, error] {
+	return func(yield func(*FSMatFile, error) bool) {
+		_ = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if !yield(nil, err) {
+					return fs.SkipAll
+				}
+				return nil
+			}
+			if d.IsDir() || !isMatFileName(path) {
+				return nil
+			}
+			matFile, err := OpenFS(fsys, path, opts...)
+			if !yield(&FSMatFile{Path: path, File: matFile}, err) {
+				return fs.SkipAll
+			}
+			return nil
+		})
+	}
+}
+
+// OpenZipMember reads the MAT-file member of the zip archive at zipPath
+// without extracting the archive. member is the slash-separated path of
+// the file within the archive.
+//
+// Returns an error wrapping fs.ErrNotExist if the archive has no such
+// member.
+//
+// Example:
+//
+//	matFile, err := matlab.OpenZipMember("experiment.zip", "session1/spikes.mat")
+func OpenZipMember(zipPath, member string, opts ...OpenOption) (*MatFile, error) {
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close() //nolint:errcheck // Read-only file
+	return OpenFS(archive, member, opts...)
+}
+
+// ZipMembers returns an iterator over the MAT-files in the zip archive at
+// zipPath, as WalkFS iterates over a file system. The archive is opened
+// when the iteration starts and closed when it ends; an error opening it
+// is yielded with a nil *FSMatFile.
+//
+// Example:
+//
+//	for member, err := range matlab.ZipMembers("experiment.zip") {
+//	    if err != nil {
+//	        log.Printf("skipping: %v", err)
+//	        continue
+//	    }
+//	    process(member.Path, member.File)
+//	}
+func ZipMembers(zipPath string, opts ...OpenOption) iter.Seq2[*FSMatFile, error] {
+	return func(yield func(*FSMatFile, error) bool) {
+		archive, err := zip.OpenReader(zipPath)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer archive.Close() //nolint:errcheck // Read-only file
+
+		for f, err := range WalkFS(archive, opts...) {
+			if !yield(f, err) {
+				return
+			}
+		}
+	}
+}
+
+// isMatFileName reports whether name has a MAT-file extension, possibly
+// followed by that of a compressed container.
+func isMatFileName(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".mat", ".mat.gz", ".mat.zst"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package matlab
+
+import (
+	"archive/zip"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scigolib/matlab/types"
+)
+
+// writeZip writes a zip archive holding the given members.
+func writeZip(t *testing.T, members map[string][]byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	zw := zip.NewWriter(f)
+	for name, data := range members {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip Create(%s) error = %v", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("zip Write(%s) error = %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip Close() error = %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return path
+}
+
+// v73FileBytes returns a v7.3 file holding the variable y.
+func v73FileBytes(t *testing.T) []byte {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "v73.mat")
+	w, err := Create(path, Version73)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	y := &types.Variable{Name: "y", Dimensions: []int{1, 2}, DataType: types.Double, Data: []float64{4, 5}}
+	if err := w.WriteVariable(y); err != nil {
+		t.Fatalf("WriteVariable() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	return data
+}
+
+func TestOpenZipMember(t *testing.T) {
+	path := writeZip(t, map[string][]byte{
+		"session1/a.mat": v5FileBytes(t),
+		"session2/b.MAT": v73FileBytes(t),
+	})
+
+	matFile, err := OpenZipMember(path, "session1/a.mat")
+	if err != nil {
+		t.Fatalf("OpenZipMember(a.mat) error = %v", err)
+	}
+	if matFile.GetVariable("x") == nil {
+		t.Error("a.mat: variable x not found")
+	}
+	matFile, err = OpenZipMember(path, "session2/b.MAT")
+	if err != nil {
+		t.Fatalf("OpenZipMember(b.MAT) error = %v", err)
+	}
+	if matFile.GetVariable("y") == nil {
+		t.Error("b.MAT: variable y not found")
+	}
+
+	if _, err := OpenZipMember(path, "missing.mat"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("OpenZipMember(missing) error = %v, want fs.ErrNotExist", err)
+	}
+	if _, err := OpenZipMember(filepath.Join(t.TempDir(), "none.zip"), "a.mat"); err == nil {
+		t.Error("OpenZipMember(no archive) succeeded")
+	}
+}
+
+func TestZipMembers(t *testing.T) {
+	path := writeZip(t, map[string][]byte{
+		"b/one.mat":   v5FileBytes(t),
+		"a/two.mat":   v73FileBytes(t),
+		"broken.mat":  []byte("not a MAT-file"),
+		"README.txt":  []byte("notes"),
+		"c/three.mat": v5FileBytes(t),
+	})
+
+	var paths []string
+	var failed []string
+	for member, err := range ZipMembers(path) {
+		if member == nil {
+			t.Fatalf("ZipMembers() error = %v", err)
+		}
+		paths = append(paths, member.Path)
+		if err != nil {
+			failed = append(failed, member.Path)
+			if member.File != nil {
+				t.Errorf("%s: File = %v with error %v", member.Path, member.File, err)
+			}
+			continue
+		}
+		if len(member.File.Variables) != 1 {
+			t.Errorf("%s: %d variables, want 1", member.Path, len(member.File.Variables))
+		}
+	}
+	want := []string{"a/two.mat", "b/one.mat", "broken.mat", "c/three.mat"}
+	if len(paths) != len(want) {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("paths = %v, want %v", paths, want)
+			break
+		}
+	}
+	if len(failed) != 1 || failed[0] != "broken.mat" {
+		t.Errorf("failed = %v, want [broken.mat]", failed)
+	}
+
+	// Breaking out of the loop stops reading
+	n := 0
+	for range ZipMembers(path) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("iterations after break = %d, want 1", n)
+	}
+
+	for member, err := range ZipMembers(filepath.Join(t.TempDir(), "none.zip")) {
+		if member != nil || err == nil {
+			t.Errorf("ZipMembers(no archive) = %v, %v, want an error", member, err)
+		}
+	}
+}